@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/rancher/ecm-distro-tools/release/activity"
+	"github.com/rancher/ecm-distro-tools/repository"
+	"github.com/spf13/cobra"
+)
+
+var (
+	activityRepos  *[]string
+	activityStart  *string
+	activityEnd    *string
+	activityFormat *string
+)
+
+// activityCmd represents the activity command
+var activityCmd = &cobra.Command{
+	Use:   "activity",
+	Short: "Report the releases published across the repos",
+	Long: `List the releases published across the activity repos of the config, or the given ones, between the start and
+end dates, both included, with their GA or prerelease classification and links, as markdown or JSON for the weekly
+engineering report. The range defaults to the last 7 days.`,
+	Example: "release activity --start 2024-03-01 --end 2024-03-07 --format markdown",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		to := time.Now().UTC().Truncate(24 * time.Hour)
+		if *activityEnd != "" {
+			var err error
+			if to, err = time.Parse(time.DateOnly, *activityEnd); err != nil {
+				return err
+			}
+		}
+		from := to.AddDate(0, 0, -6)
+		if *activityStart != "" {
+			var err error
+			if from, err = time.Parse(time.DateOnly, *activityStart); err != nil {
+				return err
+			}
+		}
+
+		repos := *activityRepos
+		if len(repos) == 0 {
+			repos = rootConfig.ActivityRepos
+		}
+		if len(repos) == 0 {
+			repos = []string{"k3s-io/k3s", rootConfig.RKE2.RepoOwner() + "/rke2", "rancher/rancher", "rancher/dashboard", "rancher/cli"}
+		}
+
+		ctx := context.Background()
+		client := repository.NewGithub(ctx, rootConfig.Auth.GithubToken)
+
+		report, err := activity.Collect(ctx, client, repos, from, to)
+		if err != nil {
+			return err
+		}
+
+		switch *activityFormat {
+		case "markdown":
+			fmt.Print(report.Markdown())
+		case "json":
+			b, err := json.MarshalIndent(report, "", "  ")
+			if err != nil {
+				return err
+			}
+			fmt.Println(string(b))
+		default:
+			return errors.New("invalid format: " + *activityFormat + ", expected one of: markdown, json")
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(activityCmd)
+
+	activityRepos = activityCmd.Flags().StringArrayP("repo", "r", nil, "repo to report, owner/name, instead of the activity repos of the config")
+	activityStart = activityCmd.Flags().StringP("start", "s", "", "first day of the range, YYYY-MM-DD")
+	activityEnd = activityCmd.Flags().StringP("end", "e", "", "last day of the range, YYYY-MM-DD, defaults to today")
+	activityFormat = activityCmd.Flags().StringP("format", "f", "markdown", "format (markdown|json)")
+}
@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/rancher/ecm-distro-tools/release/archive"
+	"github.com/rancher/ecm-distro-tools/repository"
+	"github.com/spf13/cobra"
+)
+
+var (
+	archiveBucket   *string
+	archiveEndpoint *string
+	archiveRegion   *string
+	archiveFiles    *[]string
+)
+
+// archiveCmd represents the archive command
+var archiveCmd = &cobra.Command{
+	Use:   "archive [k3s|rke2] [version]",
+	Short: "Archive release artifacts to an S3 or GCS bucket",
+	Long: `Upload all assets of a release, plus any given files such as the generated notes and verification reports,
+to an S3 or GCS bucket under <product>/<version>/. Uploads are resumed if a previous run was interrupted.
+Credentials are read from the default AWS configuration. To use GCS, set --endpoint https://storage.googleapis.com
+and provide HMAC keys as AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY.`,
+	Example: "release archive rke2 v1.29.2+rke2r1 --bucket release-archive --files notes.md,inspect.csv",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) < 2 {
+			return errors.New("expected at least two arguments: [k3s|rke2] [version]")
+		}
+		product, tag := args[0], args[1]
+
+		owner, ok := repoToOwner[product]
+		if !ok || product == "rancher" {
+			return errors.New("invalid product: " + product + ", expected one of: k3s, rke2")
+		}
+
+		ctx := context.Background()
+
+		cfg, err := config.LoadDefaultConfig(ctx, config.WithDefaultRegion(*archiveRegion))
+		if err != nil {
+			return err
+		}
+		client := s3.NewFromConfig(cfg, func(o *s3.Options) {
+			if *archiveEndpoint != "" {
+				o.BaseEndpoint = aws.String(*archiveEndpoint)
+			}
+		})
+
+		gh := repository.NewGithub(ctx, rootConfig.Auth.GithubToken)
+		archiver := archive.NewArchiver(client, *archiveBucket, dryRun)
+
+		return archiver.ArchiveRelease(ctx, gh, owner, product, product, tag, *archiveFiles)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(archiveCmd)
+
+	archiveBucket = archiveCmd.Flags().StringP("bucket", "b", "", "bucket to archive the release to")
+	archiveEndpoint = archiveCmd.Flags().StringP("endpoint", "e", "", "S3 compatible endpoint, e.g. https://storage.googleapis.com for GCS")
+	archiveRegion = archiveCmd.Flags().String("region", "us-east-1", "bucket region")
+	archiveFiles = archiveCmd.Flags().StringSliceP("files", "f", []string{}, "additional local files to archive, e.g. notes and reports")
+
+	if err := archiveCmd.MarkFlagRequired("bucket"); err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+}
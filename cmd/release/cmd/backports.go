@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/rancher/ecm-distro-tools/cmd/release/config"
+	"github.com/rancher/ecm-distro-tools/release/backports"
+	"github.com/spf13/cobra"
+)
+
+// backportsCmd represents the backports command
+var backportsCmd = &cobra.Command{
+	Use:   "backports [k3s|rke2|rancher]",
+	Short: "Apply the milestone and labels of the open backport pull requests",
+	Long: `Find the open pull requests targeting a release branch, e.g. release-1.29, that are missing a milestone or the
+backport label, and set the open milestone of their branch, e.g. v1.29.3+rke2r1, and add the label, so the changelog
+of the release notes is made of the right pull requests. The branches are mapped to their milestone and labels by the
+backport rules of the repo in the config, defaulting to the release-1.xx and release/v2.x branches.`,
+	Example: "release backports rke2 --dry-run",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) < 1 {
+			return errors.New("expected at least one argument: [k3s|rke2|rancher]")
+		}
+		repo := args[0]
+		owner, ok := repoToOwner[repo]
+		if !ok {
+			return errors.New("invalid repo: " + repo + ", expected one of: k3s, rke2, rancher")
+		}
+		if repo == "rke2" {
+			owner = rootConfig.RKE2.RepoOwner()
+		}
+
+		rules, ok := rootConfig.BackportRules[repo]
+		if !ok {
+			rules = backports.DefaultRules
+		}
+
+		ctx := context.Background()
+		client := githubClient(ctx, config.OperationIssue)
+
+		changes, err := backports.Plan(ctx, client, owner, repo, rules)
+		if err != nil {
+			return err
+		}
+
+		var applicable []backports.Change
+		for _, change := range changes {
+			if change.Warning != "" {
+				fmt.Println("#" + strconv.Itoa(change.Number) + " " + change.URL + ": " + change.Warning)
+			}
+			if change.Milestone == "" && len(change.Labels) == 0 {
+				continue
+			}
+			applicable = append(applicable, change)
+
+			var actions []string
+			if change.Milestone != "" {
+				actions = append(actions, "set milestone "+change.Milestone)
+			}
+			if len(change.Labels) > 0 {
+				actions = append(actions, "add labels "+strings.Join(change.Labels, ", "))
+			}
+			prefix := ""
+			if dryRun {
+				prefix = "dry run, would "
+			}
+			fmt.Println(prefix + strings.Join(actions, " and ") + " on #" + strconv.Itoa(change.Number) + " " + change.URL)
+		}
+
+		if dryRun {
+			return nil
+		}
+		if err := backports.Apply(ctx, client, owner, repo, applicable); err != nil {
+			return err
+		}
+		fmt.Printf("updated %d pull requests\n", len(applicable))
+
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(backportsCmd)
+}
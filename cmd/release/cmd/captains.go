@@ -0,0 +1,127 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rancher/ecm-distro-tools/cmd/release/config"
+	"github.com/rancher/ecm-distro-tools/release/rotation"
+	"github.com/spf13/cobra"
+)
+
+var (
+	captainsRotation *string
+	captainsDate     *string
+	captainsAnnounce *bool
+)
+
+// captainsCmd represents the captains command
+var captainsCmd = &cobra.Command{
+	Use:   "captains",
+	Short: "Release captains rotation",
+	Long: `Identify the release captains of a cycle from the rotation config and assign them to the release tracking
+issues. The rotation is a JSON file listing the members, in the order they take turns, and the start and length of
+the cycles. Members away for a cycle are replaced by the next available member and captain the following cycle.`,
+}
+
+var captainsNextSubCmd = &cobra.Command{
+	Use:     "next",
+	Short:   "Print the captains of the next cycle",
+	Example: "release captains next --rotation rotation.json",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		r, err := rotation.Load(*captainsRotation)
+		if err != nil {
+			return err
+		}
+		cycle, err := captainsCycle(r)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("cycle %d from %s to %s: %s\n", cycle.Number, cycle.Start.Format(time.DateOnly), cycle.End.Format(time.DateOnly), strings.Join(cycle.Captains, ", "))
+
+		return nil
+	},
+}
+
+var captainsAssignSubCmd = &cobra.Command{
+	Use:   "assign [k3s|rke2|rancher] [releases]",
+	Short: "Assign the captains of the next cycle to the tracking issues of the given releases",
+	Long: `Assign the captains of the next cycle to the tracking issue of each release, titled "Cut <release>", record
+them in the description of the release milestone and mention them on the issue. With --announce, the captains are
+also posted to the rotation's webhook.`,
+	Example: "release captains assign rke2 v1.29.3+rke2r1 v1.28.8+rke2r1 --announce",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) < 2 {
+			return errors.New("expected at least two arguments: [k3s|rke2|rancher] [releases]")
+		}
+		repo, releases := args[0], args[1:]
+		owner, ok := repoToOwner[repo]
+		if !ok {
+			return errors.New("invalid repo: " + repo + ", expected one of: k3s, rke2, rancher")
+		}
+		if repo == "rke2" {
+			owner = rootConfig.RKE2.RepoOwner()
+		}
+
+		r, err := rotation.Load(*captainsRotation)
+		if err != nil {
+			return err
+		}
+		cycle, err := captainsCycle(r)
+		if err != nil {
+			return err
+		}
+
+		if dryRun {
+			fmt.Println("dry run, would assign " + strings.Join(cycle.Captains, ", ") + " to " + strings.Join(releases, ", "))
+			return nil
+		}
+
+		ctx := context.Background()
+		client := githubClient(ctx, config.OperationIssue)
+
+		if err := rotation.Assign(ctx, client, owner, repo, cycle, releases); err != nil {
+			return err
+		}
+		fmt.Println("assigned " + strings.Join(cycle.Captains, ", ") + " to " + strings.Join(releases, ", "))
+
+		if *captainsAnnounce {
+			return r.Announce(ctx, cycle)
+		}
+
+		return nil
+	},
+}
+
+// captainsCycle returns the cycle following the current one, or the cycle
+// containing --date if set.
+func captainsCycle(r *rotation.Rotation) (*rotation.Cycle, error) {
+	if *captainsDate != "" {
+		date, err := time.Parse(time.DateOnly, *captainsDate)
+		if err != nil {
+			return nil, errors.New("invalid date " + *captainsDate + ", expected YYYY-MM-DD")
+		}
+		return r.CycleAt(date)
+	}
+
+	current, err := r.CycleAt(time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	return r.Cycle(current.Number + 1)
+}
+
+func init() {
+	rootCmd.AddCommand(captainsCmd)
+	captainsCmd.AddCommand(captainsNextSubCmd)
+	captainsCmd.AddCommand(captainsAssignSubCmd)
+
+	captainsRotation = captainsCmd.PersistentFlags().String("rotation", rotation.DefaultPath, "path of the rotation config")
+	captainsDate = captainsCmd.PersistentFlags().String("date", "", "use the cycle containing this date, YYYY-MM-DD, instead of the next one")
+	captainsAnnounce = captainsAssignSubCmd.Flags().Bool("announce", false, "post the captains to the rotation's webhook")
+}
@@ -0,0 +1,150 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/google/go-github/v39/github"
+	"github.com/rancher/ecm-distro-tools/cmd/release/config"
+	ecmHTTP "github.com/rancher/ecm-distro-tools/http"
+	"github.com/rancher/ecm-distro-tools/release"
+	"github.com/rancher/ecm-distro-tools/release/checklist"
+	"github.com/rancher/ecm-distro-tools/release/status"
+	"github.com/rancher/ecm-distro-tools/repository"
+	"github.com/spf13/cobra"
+)
+
+var (
+	checklistIssue    *int
+	checklistKeepOpen *bool
+)
+
+// checklistCmd represents the checklist command
+var checklistCmd = &cobra.Command{
+	Use:   "checklist [k3s|rke2] [tag]",
+	Short: "Evaluate the release checklist and render it into the tracking issue",
+	Long: `Evaluate the release checklist of the product for the given tag and render its progress into the tracking
+issue, titled "Cut <tag>". Items with an automated verification, e.g. the CI state or the release assets, are
+checked against the release. Manual items are attested by checking their box in the tracking issue, which is kept
+when the checklist is rendered again. Once every item is done, a final comment summarizing the release, its tag,
+links, verification results and durations, is posted on the tracking issue and the issue is closed, unless
+--keep-open is set. With --dry-run, the progress and the summary are printed without updating the issue.`,
+	Example: "release checklist rke2 v1.29.2+rke2r1",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) != 2 {
+			return errors.New("expected two arguments: [k3s|rke2] [tag]")
+		}
+		product, tag := args[0], args[1]
+
+		list, err := checklist.Get(product)
+		if err != nil {
+			return err
+		}
+		owner := repoToOwner[product]
+		if product == "rke2" {
+			owner = rootConfig.RKE2.RepoOwner()
+		}
+
+		ctx := context.Background()
+		gh := githubClient(ctx, config.OperationIssue)
+
+		issue, err := checklistTrackingIssue(ctx, gh, owner, product, tag)
+		if err != nil {
+			return err
+		}
+
+		httpClient := ecmHTTP.NewClient(time.Minute)
+		client := release.NewClient(gh)
+		verifier := checklist.NewVerifier(client, &httpClient)
+		target := status.Target{Owner: owner, Repo: product, Tag: tag}
+		progress := checklist.Evaluate(ctx, verifier, list, target, checklist.Attested(issue.GetBody()))
+
+		fmt.Print(checklist.Render(progress))
+
+		closing := progress.Complete() && !*checklistKeepOpen && issue.GetState() != "closed"
+		var comment string
+		if closing {
+			comment = checklistClosingComment(ctx, client, issue, progress)
+		}
+
+		if dryRun {
+			fmt.Println("dry run, skipping the update of " + issue.GetHTMLURL())
+			if closing {
+				fmt.Println("dry run, would close " + issue.GetHTMLURL() + " with the comment:")
+				fmt.Print(comment)
+			}
+			return nil
+		}
+
+		issueRef := "#" + strconv.Itoa(issue.GetNumber())
+		body := checklist.UpdateBody(issue.GetBody(), progress)
+		if _, _, err := gh.Issues.Edit(ctx, owner, product, issue.GetNumber(), &github.IssueRequest{Body: github.String(body)}); err != nil {
+			return repository.WrapGithubError(err, owner, product, issueRef)
+		}
+		fmt.Println("updated " + issue.GetHTMLURL())
+
+		if !closing {
+			return nil
+		}
+		if _, _, err := gh.Issues.CreateComment(ctx, owner, product, issue.GetNumber(), &github.IssueComment{Body: github.String(comment)}); err != nil {
+			return repository.WrapGithubError(err, owner, product, issueRef)
+		}
+		if _, _, err := gh.Issues.Edit(ctx, owner, product, issue.GetNumber(), &github.IssueRequest{State: github.String("closed")}); err != nil {
+			return repository.WrapGithubError(err, owner, product, issueRef)
+		}
+		fmt.Println("closed " + issue.GetHTMLURL())
+
+		return nil
+	},
+}
+
+// checklistTrackingIssue returns the issue set with --issue, or the open
+// issue titled "Cut <tag>".
+func checklistTrackingIssue(ctx context.Context, gh *github.Client, owner, repo, tag string) (*github.Issue, error) {
+	if *checklistIssue != 0 {
+		issue, _, err := gh.Issues.Get(ctx, owner, repo, *checklistIssue)
+		if err != nil {
+			return nil, repository.WrapGithubError(err, owner, repo, "#"+strconv.Itoa(*checklistIssue))
+		}
+		return issue, nil
+	}
+
+	issue, err := repository.FindOpenIssue(ctx, gh, owner, repo, "Cut "+tag)
+	if err != nil {
+		return nil, err
+	}
+	if issue == nil {
+		return nil, errors.New("no open tracking issue titled Cut " + tag + ", set it with --issue")
+	}
+
+	return issue, nil
+}
+
+// checklistClosingComment renders the summary of the completed release
+// closing its tracking issue. The publication date of the release is left
+// out if it can't be read.
+func checklistClosingComment(ctx context.Context, client *release.Client, issue *github.Issue, progress checklist.Progress) string {
+	c := checklist.Closing{
+		Progress: progress,
+		Opened:   issue.GetCreatedAt(),
+		Closed:   time.Now(),
+	}
+
+	t := progress.Target
+	if rel, err := client.Cache.GetReleaseByTag(ctx, client.GitHub, t.Owner, t.Repo, t.Tag); err == nil {
+		c.ReleaseURL = rel.GetHTMLURL()
+		c.Published = rel.GetPublishedAt().Time
+	}
+
+	return checklist.RenderClosing(c)
+}
+
+func init() {
+	rootCmd.AddCommand(checklistCmd)
+
+	checklistIssue = checklistCmd.Flags().Int("issue", 0, "number of the tracking issue, instead of the open issue titled Cut <tag>")
+	checklistKeepOpen = checklistCmd.Flags().Bool("keep-open", false, "don't comment on and close the tracking issue once the checklist is complete")
+}
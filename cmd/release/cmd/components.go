@@ -0,0 +1,248 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/rancher/ecm-distro-tools/release"
+	"github.com/rancher/ecm-distro-tools/release/versiondb"
+	"github.com/spf13/cobra"
+)
+
+var (
+	componentsDB         *string
+	componentsFromImages *bool
+	componentsIntroduced *string
+	componentsFixed      *string
+)
+
+// componentsCmd represents the components command
+var componentsCmd = &cobra.Command{
+	Use:   "components",
+	Short: "Historical component versions of the k3s and rke2 releases",
+	Long: `Record the component versions of the k3s and rke2 releases, resolved the same way as their release notes, into a
+JSON database meant to be committed to a repo, and query which releases shipped a component version.`,
+}
+
+var componentsRecordSubCmd = &cobra.Command{
+	Use:     "record [k3s|rke2] [releases]",
+	Short:   "Record the component versions of the given releases",
+	Example: "release components record rke2 v1.29.2+rke2r1 v1.28.7+rke2r1 --db component-versions.json",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) < 2 {
+			return errors.New("expected at least two arguments: [k3s|rke2] [releases]")
+		}
+		repo, versions := args[0], args[1:]
+		if repo != "k3s" && repo != "rke2" {
+			return errors.New("invalid repo: " + repo + ", expected one of: k3s, rke2")
+		}
+		owner := repoToOwner[repo]
+		if repo == "rke2" {
+			owner = rootConfig.RKE2.RepoOwner()
+		}
+
+		db, err := versiondb.Load(*componentsDB)
+		if err != nil {
+			return err
+		}
+
+		ctx := context.Background()
+		client := notesClient(ctx)
+
+		for _, version := range versions {
+			components, err := client.ResolveComponentVersions(ctx, owner, repo, version, *componentsFromImages)
+			if err != nil {
+				return errors.New("failed to resolve the components of " + version + ": " + err.Error())
+			}
+			db.Put(versiondb.Release{Repo: repo, Version: version, Components: components, RecordedAt: time.Now().UTC()})
+			fmt.Println("recorded " + version + ": " + componentsLine(components))
+		}
+
+		return componentsSave(db)
+	},
+}
+
+var componentsImportSubCmd = &cobra.Command{
+	Use:     "import [snapshots]",
+	Short:   "Record the component versions of release notes snapshots",
+	Long:    `Record the component versions of JSON snapshots written with the --snapshot flag of the release-notes commands, without querying any remote source.`,
+	Example: "release components import v1.29.2+rke2r1.json v1.29.2+k3s1.json --db component-versions.json",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) < 1 {
+			return errors.New("expected at least one argument: [snapshots]")
+		}
+
+		db, err := versiondb.Load(*componentsDB)
+		if err != nil {
+			return err
+		}
+
+		for _, path := range args {
+			b, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			var snapshot release.ReleaseNotesSnapshot
+			if err := json.Unmarshal(b, &snapshot); err != nil {
+				return errors.New("invalid snapshot " + path + ": " + err.Error())
+			}
+
+			components, err := release.ComponentVersions(&snapshot)
+			if err != nil {
+				return errors.New("invalid snapshot " + path + ": " + err.Error())
+			}
+			db.Put(versiondb.Release{Repo: snapshot.Repo, Version: snapshot.Milestone, Components: components, RecordedAt: time.Now().UTC()})
+			fmt.Println("recorded " + snapshot.Milestone + ": " + componentsLine(components))
+		}
+
+		return componentsSave(db)
+	},
+}
+
+var componentsShippedSubCmd = &cobra.Command{
+	Use:     "shipped [component] [version]",
+	Short:   "List the releases that shipped the given component version",
+	Example: "release components shipped runc v1.1.12 --db component-versions.json",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) != 2 {
+			return errors.New("expected two arguments: [component] [version]")
+		}
+
+		db, err := versiondb.Load(*componentsDB)
+		if err != nil {
+			return err
+		}
+
+		shipped := db.Shipped(args[0], args[1])
+		if len(shipped) == 0 {
+			return errors.New("no recorded release shipped " + args[0] + " " + args[1])
+		}
+
+		tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "REPO\tRELEASE")
+		for _, r := range shipped {
+			fmt.Fprintln(tw, r.Repo+"\t"+r.Version)
+		}
+
+		return tw.Flush()
+	},
+}
+
+var componentsHistorySubCmd = &cobra.Command{
+	Use:     "history [k3s|rke2] [component]",
+	Short:   "List the versions of the given component shipped by each release",
+	Example: "release components history rke2 etcd --db component-versions.json",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) != 2 {
+			return errors.New("expected two arguments: [k3s|rke2] [component]")
+		}
+
+		db, err := versiondb.Load(*componentsDB)
+		if err != nil {
+			return err
+		}
+
+		history := db.History(args[0], args[1])
+		if len(history) == 0 {
+			return errors.New("no recorded " + args[0] + " release shipped " + args[1])
+		}
+
+		tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "RELEASE\tVERSION")
+		for _, entry := range history {
+			fmt.Fprintln(tw, entry.Release+"\t"+entry.Version)
+		}
+
+		return tw.Flush()
+	},
+}
+
+var componentsImpactSubCmd = &cobra.Command{
+	Use:   "impact [component]",
+	Short: "List the releases shipping a vulnerable component version and the releases fixing it",
+	Long: `List the recorded k3s and rke2 releases shipping a version of the component affected by a vulnerability, from
+--introduced, included, to --fixed, excluded, ignoring the suffixes of forked versions, e.g. v1.7.11-k3s2 is v1.7.11.
+Each impacted release is listed with the first later patch release of its line shipping a fixed version, if any.`,
+	Example: "release components impact runc --introduced v1.0.0 --fixed v1.1.12 --db component-versions.json",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) != 1 {
+			return errors.New("expected one argument: [component]")
+		}
+		if *componentsIntroduced == "" && *componentsFixed == "" {
+			return errors.New("expected at least one of --introduced or --fixed")
+		}
+		r := versiondb.Range{Introduced: *componentsIntroduced, Fixed: *componentsFixed}
+		if err := r.Validate(); err != nil {
+			return err
+		}
+
+		db, err := versiondb.Load(*componentsDB)
+		if err != nil {
+			return err
+		}
+
+		impacts := db.Impacted(args[0], r)
+		if len(impacts) == 0 {
+			fmt.Println("no recorded release ships an affected version of " + args[0])
+			return nil
+		}
+
+		tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "REPO\tRELEASE\t"+strings.ToUpper(args[0])+"\tFIXED IN")
+		for _, impact := range impacts {
+			fixedIn := impact.FixedIn
+			if fixedIn == "" {
+				fixedIn = "-"
+			}
+			fmt.Fprintln(tw, strings.Join([]string{impact.Repo, impact.Release, impact.Version, fixedIn}, "\t"))
+		}
+
+		return tw.Flush()
+	},
+}
+
+func componentsSave(db *versiondb.DB) error {
+	if dryRun {
+		fmt.Println("dry run, skipping the update of " + *componentsDB)
+		return nil
+	}
+
+	return db.Save(*componentsDB)
+}
+
+// componentsLine lists the given component versions, sorted by component.
+func componentsLine(components map[string]string) string {
+	names := make([]string, 0, len(components))
+	for name := range components {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for i, name := range names {
+		names[i] = name + " " + components[name]
+	}
+
+	return strings.Join(names, ", ")
+}
+
+func init() {
+	rootCmd.AddCommand(componentsCmd)
+
+	componentsCmd.AddCommand(componentsRecordSubCmd)
+	componentsCmd.AddCommand(componentsImportSubCmd)
+	componentsCmd.AddCommand(componentsShippedSubCmd)
+	componentsCmd.AddCommand(componentsHistorySubCmd)
+	componentsCmd.AddCommand(componentsImpactSubCmd)
+
+	componentsDB = componentsCmd.PersistentFlags().String("db", "component-versions.json", "path of the component versions database")
+	componentsFromImages = componentsRecordSubCmd.Flags().Bool("from-images", false, "Read the component versions from the labels and SBOMs of the release images")
+	componentsIntroduced = componentsImpactSubCmd.Flags().String("introduced", "", "first affected version of the component")
+	componentsFixed = componentsImpactSubCmd.Flags().String("fixed", "", "first fixed version of the component")
+}
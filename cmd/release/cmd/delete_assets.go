@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/rancher/ecm-distro-tools/cmd/release/config"
+	"github.com/rancher/ecm-distro-tools/release"
+	"github.com/spf13/cobra"
+)
+
+var deleteAssetsForce *bool
+
+// deleteAssetsCmd represents the delete-assets command
+var deleteAssetsCmd = &cobra.Command{
+	Use:   "delete-assets [k3s|rke2] [tag]",
+	Short: "Delete all assets of a release",
+	Long: `Delete all assets of a release, e.g. to rebuild a broken rc. Assets are deleted concurrently and failures
+don't stop the remaining deletions. Assets of GA releases are only deleted with --force.`,
+	Example: "release delete-assets rke2 v1.29.3-rc1+rke2r1",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) < 2 {
+			return errors.New("expected at least two arguments: [k3s|rke2] [tag]")
+		}
+		repo, tag := args[0], args[1]
+
+		owner, ok := repoToOwner[repo]
+		if !ok || repo == "rancher" {
+			return errors.New("invalid repo: " + repo + ", expected one of: k3s, rke2")
+		}
+
+		ctx := context.Background()
+		client := release.NewClient(githubClient(ctx, config.OperationAssets))
+
+		if dryRun {
+			assets, err := client.ListAssets(ctx, owner, repo, tag)
+			if err != nil {
+				return err
+			}
+			for _, asset := range assets {
+				fmt.Println("dry run, would delete " + asset.Name)
+			}
+			return nil
+		}
+
+		result, err := client.DeleteAssetsByRelease(ctx, owner, repo, tag, *deleteAssetsForce)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("deleted %d assets of %s\n", len(result.Deleted), tag)
+
+		return result.Err()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(deleteAssetsCmd)
+
+	deleteAssetsForce = deleteAssetsCmd.Flags().BoolP("force", "f", false, "delete the assets even if the release is not a prerelease")
+}
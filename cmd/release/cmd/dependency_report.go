@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/rancher/ecm-distro-tools/cmd/release/config"
+	"github.com/rancher/ecm-distro-tools/release/deps"
+	"github.com/rancher/ecm-distro-tools/repository"
+	"github.com/spf13/cobra"
+)
+
+var (
+	dependencyReportOwner      *string
+	dependencyReportIssueOwner *string
+	dependencyReportIssueRepo  *string
+)
+
+// dependencyReportCmd represents the dependency-report command
+var dependencyReportCmd = &cobra.Command{
+	Use:   "dependency-report",
+	Short: "File the weekly dependency bumps report issue",
+	Long: `Check the image-build repos against their upstreams and the Go modules of k3s and rke2 against their latest
+releases, and file a single issue for the current week with a checkbox for each pending bump. If the issue is
+already open, it's updated keeping the checked bumps, so the command can run on a schedule. The owners of each bumped
+component in the owners_file are mentioned next to its bump.`,
+	Example: "release dependency-report --issue-repo ecm-distro-tools",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+		client := githubClient(ctx, config.OperationIssue)
+
+		bumps, errs := deps.Check(ctx, client, *dependencyReportOwner, deps.DefaultImageBuilds, deps.DefaultModules)
+		deps.MentionOwners(bumps, repository.ConfiguredOwners(), *dependencyReportOwner)
+		title := deps.Title(time.Now())
+
+		if dryRun {
+			fmt.Println(title)
+			fmt.Print(deps.RenderReport(bumps, errs, ""))
+			return nil
+		}
+
+		issue, err := deps.FileReport(ctx, client, *dependencyReportIssueOwner, *dependencyReportIssueRepo, title, bumps, errs)
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(issue.GetHTMLURL())
+
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(dependencyReportCmd)
+
+	dependencyReportOwner = dependencyReportCmd.Flags().StringP("owner", "o", "rancher", "owner of the image-build repos")
+	dependencyReportIssueOwner = dependencyReportCmd.Flags().String("issue-owner", "rancher", "owner of the repo the report issue is filed in")
+	dependencyReportIssueRepo = dependencyReportCmd.Flags().String("issue-repo", "ecm-distro-tools", "repo the report issue is filed in")
+}
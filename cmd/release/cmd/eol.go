@@ -0,0 +1,143 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/rancher/ecm-distro-tools/cmd/release/config"
+	"github.com/rancher/ecm-distro-tools/release/eol"
+	"github.com/spf13/cobra"
+)
+
+var (
+	eolPR   *string
+	eolPath *string
+	eolHead *string
+)
+
+// eolCmd represents the eol command
+var eolCmd = &cobra.Command{
+	Use:   "eol",
+	Short: "End of life of the k3s and rke2 release lines",
+	Long:  `List the k3s and rke2 release lines due for end of life per the support_matrix of the config, and generate their end of life announcement.`,
+}
+
+var eolListSubCmd = &cobra.Command{
+	Use:     "list [k3s|rke2]",
+	Short:   "List the release lines of the support matrix and whether they reached end of life",
+	Example: "release eol list rke2",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) != 1 {
+			return errors.New("expected one argument: [k3s|rke2]")
+		}
+		product := args[0]
+		if _, ok := rootConfig.SupportMatrix[product]; !ok {
+			return errors.New("no release line of " + product + " in the support matrix")
+		}
+
+		now := time.Now()
+		tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "LINE\tEOL\tSTATUS")
+		for _, line := range rootConfig.SupportMatrix.Due(product, now) {
+			fmt.Fprintln(tw, line+"\t"+rootConfig.SupportMatrix[product][line].EOL+"\tend of life")
+		}
+		supported := rootConfig.SupportMatrix.Supported(product, now)
+		for i := len(supported) - 1; i >= 0; i-- {
+			fmt.Fprintln(tw, supported[i]+"\t"+rootConfig.SupportMatrix[product][supported[i]].EOL+"\tsupported")
+		}
+
+		return tw.Flush()
+	},
+}
+
+var eolAnnounceSubCmd = &cobra.Command{
+	Use:   "announce [k3s|rke2] [line]",
+	Short: "Generate the end of life announcement of a release line",
+	Long: `Generate the end of life announcement of a release line, e.g. v1.27, with its final release, read from the tags
+of the product, the supported release lines to migrate to and the migration links of the support matrix. With --pr,
+a pull request adding the announcement to the given docs repository is opened, unless --dry-run is set.`,
+	Example: "release eol announce rke2 v1.27 --pr rancher/rke2-docs --path docs/announcements/v1.27-eol.md",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) != 2 {
+			return errors.New("expected two arguments: [k3s|rke2] [line]")
+		}
+		product, line := args[0], args[1]
+
+		owner, ok := repoToOwner[product]
+		if !ok || product == "rancher" {
+			return errors.New("invalid product: " + product + ", expected one of: k3s, rke2")
+		}
+		if product == "rke2" {
+			owner = rootConfig.RKE2.RepoOwner()
+		}
+
+		ctx := context.Background()
+		client := githubClient(ctx, config.OperationPullRequest)
+
+		tags, err := eol.Tags(ctx, client, owner, product)
+		if err != nil {
+			return err
+		}
+		announcement, err := eol.NewAnnouncement(rootConfig.SupportMatrix, owner, product, line, tags)
+		if err != nil {
+			return err
+		}
+
+		if *eolPR == "" {
+			content, err := announcement.Render()
+			if err != nil {
+				return err
+			}
+			fmt.Print(content)
+			return nil
+		}
+
+		prOwner, prRepo, ok := strings.Cut(*eolPR, "/")
+		if !ok {
+			return errors.New("invalid pr repo: " + *eolPR + ", expected owner/repo")
+		}
+		path := *eolPath
+		if path == "" {
+			path = "announcements/" + product + "-" + line + "-eol.md"
+		}
+
+		if dryRun {
+			content, err := announcement.Render()
+			if err != nil {
+				return err
+			}
+			fmt.Println("dry run, would open a pull request adding " + path + " to " + *eolPR + ":")
+			fmt.Print(content)
+			return nil
+		}
+
+		url, err := eol.OpenPR(ctx, client, announcement, eol.PROptions{
+			Owner: prOwner,
+			Repo:  prRepo,
+			Path:  path,
+			Head:  *eolHead,
+		})
+		if err != nil {
+			return err
+		}
+		fmt.Println("Pull request created: " + url)
+
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(eolCmd)
+
+	eolCmd.AddCommand(eolListSubCmd)
+	eolCmd.AddCommand(eolAnnounceSubCmd)
+
+	eolPR = eolAnnounceSubCmd.Flags().String("pr", "", "owner/repo of the docs repository to open the announcement pull request on")
+	eolPath = eolAnnounceSubCmd.Flags().String("path", "", "path of the announcement in the docs repository, defaults to announcements/<product>-<line>-eol.md")
+	eolHead = eolAnnounceSubCmd.Flags().String("head", "", "fork, e.g. your GitHub username, the announcement branch is pushed to, instead of the docs repository")
+}
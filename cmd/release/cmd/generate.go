@@ -1,18 +1,26 @@
 package cmd
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"net/http"
 	"os"
+	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/google/go-github/v39/github"
+	ecmConfig "github.com/rancher/ecm-distro-tools/cmd/release/config"
+	ecmHTTP "github.com/rancher/ecm-distro-tools/http"
 	"github.com/rancher/ecm-distro-tools/release"
+	"github.com/rancher/ecm-distro-tools/release/conformance"
 	"github.com/rancher/ecm-distro-tools/release/k3s"
 	"github.com/rancher/ecm-distro-tools/release/kdm"
 	"github.com/rancher/ecm-distro-tools/release/metrics"
@@ -35,6 +43,9 @@ var (
 	cliPrevMilestone string
 	cliMilestone     string
 
+	ancillaryPrevMilestone string
+	ancillaryMilestone     string
+
 	concurrencyLimit                      int
 	imagesListURL                         string
 	registry                              string
@@ -60,6 +71,19 @@ var (
 	rancherMetricsWorkflowsFilePath       string
 	rancherMetricsPrimeReleasesFilePath   string
 	releases                              []string
+	releaseNotesSnapshotPath              string
+	releaseNotesFromImages                bool
+	releaseNotesPullRequestsPath          string
+	releaseNotesLocale                    string
+	releaseNotesPublishDate               string
+	releaseNotesEOLDate                   string
+	releaseNotesWorkers                   int
+	releaseNotesCheckpoint                string
+	templateDocsSnapshotPath              string
+	templateDocsMilestone                 string
+	templateDocsPrevMilestone             string
+	conformanceResults                    string
+	conformanceOutput                     string
 )
 
 // generateCmd represents the generate command
@@ -77,17 +101,7 @@ var k3sGenerateReleaseNotesSubCmd = &cobra.Command{
 	Use:   "release-notes",
 	Short: "Generate k3s release notes",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		ctx := context.Background()
-		client := repository.NewGithub(ctx, rootConfig.Auth.GithubToken)
-
-		notes, err := release.GenReleaseNotes(ctx, "k3s-io", "k3s", k3sMilestone, k3sPrevMilestone, client)
-		if err != nil {
-			return err
-		}
-
-		fmt.Print(notes.String())
-
-		return nil
+		return genReleaseNotes(context.Background(), "k3s-io", "k3s", k3sMilestone, k3sPrevMilestone)
 	},
 }
 
@@ -104,8 +118,8 @@ var k3sGenerateTagsSubCmd = &cobra.Command{
 			return NewVersionNotFoundError(version, "k3s")
 		}
 		ctx := context.Background()
-		ghClient := repository.NewGithub(ctx, rootConfig.Auth.GithubToken)
-		return k3s.GenerateTags(ctx, ghClient, &k3sRelease, rootConfig.User, rootConfig.Auth.SSHKeyPath)
+		ghClient := githubClient(ctx, ecmConfig.OperationTag)
+		return k3s.GenerateTags(ctx, ghClient, &k3sRelease, rootConfig.User, rootConfig.Auth.SSHKeyPath, cloneOptions("kubernetes"))
 	},
 }
 
@@ -118,17 +132,7 @@ var rke2GenerateReleaseNotesSubCmd = &cobra.Command{
 	Use:   "release-notes",
 	Short: "Generate rke2 release notes",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		ctx := context.Background()
-		client := repository.NewGithub(ctx, rootConfig.Auth.GithubToken)
-
-		notes, err := release.GenReleaseNotes(ctx, "rancher", "rke2", rke2Milestone, rke2PrevMilestone, client)
-		if err != nil {
-			return err
-		}
-
-		fmt.Print(notes.String())
-
-		return nil
+		return genReleaseNotes(context.Background(), "rancher", "rke2", rke2Milestone, rke2PrevMilestone)
 	},
 }
 
@@ -313,17 +317,7 @@ var uiGenerateReleaseNotesSubCmd = &cobra.Command{
 	Use:   "release-notes",
 	Short: "Generate ui release notes",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		ctx := context.Background()
-		client := repository.NewGithub(ctx, rootConfig.Auth.GithubToken)
-
-		notes, err := release.GenReleaseNotes(ctx, "rancher", "ui", dashboardMilestone, dashboardPrevMilestone, client)
-		if err != nil {
-			return err
-		}
-
-		fmt.Print(notes.String())
-
-		return nil
+		return genReleaseNotes(context.Background(), "rancher", "ui", dashboardMilestone, dashboardPrevMilestone)
 	},
 }
 
@@ -336,17 +330,7 @@ var dashboardGenerateReleaseNotesSubCmd = &cobra.Command{
 	Use:   "release-notes",
 	Short: "Generate dashboard release notes",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		ctx := context.Background()
-		client := repository.NewGithub(ctx, rootConfig.Auth.GithubToken)
-
-		notes, err := release.GenReleaseNotes(ctx, "rancher", "dashboard", dashboardMilestone, dashboardPrevMilestone, client)
-		if err != nil {
-			return err
-		}
-
-		fmt.Print(notes.String())
-
-		return nil
+		return genReleaseNotes(context.Background(), "rancher", "dashboard", dashboardMilestone, dashboardPrevMilestone)
 	},
 }
 
@@ -359,17 +343,7 @@ var cliGenerateReleaseNotesSubCmd = &cobra.Command{
 	Use:   "release-notes",
 	Short: "Generate cli release notes",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		ctx := context.Background()
-		client := repository.NewGithub(ctx, rootConfig.Auth.GithubToken)
-
-		notes, err := release.GenReleaseNotes(ctx, "rancher", "cli", cliMilestone, cliPrevMilestone, client)
-		if err != nil {
-			return err
-		}
-
-		fmt.Print(notes.String())
-
-		return nil
+		return genReleaseNotes(context.Background(), "rancher", "cli", cliMilestone, cliPrevMilestone)
 	},
 }
 
@@ -416,6 +390,326 @@ var kdmGenerateRKE2SubCmd = &cobra.Command{
 	},
 }
 
+var unifiedGenerateReleaseNotesSubCmd = &cobra.Command{
+	Use:     "unified-release-notes",
+	Short:   "Generate unified k3s and rke2 release notes",
+	Long:    `Generate a single announcement for the k3s and rke2 releases of the same Kubernetes patch, listing both products' versions, the component versions they share and the changes of each product.`,
+	Example: "release generate unified-release-notes --k3s-milestone v1.29.2+k3s1 --k3s-prev-milestone v1.29.1+k3s2 --rke2-milestone v1.29.2+rke2r1 --rke2-prev-milestone v1.29.1+rke2r1",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+		client := notesClient(ctx)
+
+		dates, err := notesDates("unified")
+		if err != nil {
+			return err
+		}
+
+		notes, err := client.GenUnifiedReleaseNotes(ctx, k3sMilestone, k3sPrevMilestone, rke2Milestone, rke2PrevMilestone, dates)
+		if err != nil {
+			return err
+		}
+
+		fmt.Print(notes.String())
+
+		return nil
+	},
+}
+
+var releaseNotesFromSnapshotSubCmd = &cobra.Command{
+	Use:     "release-notes-from-snapshot [snapshot]",
+	Short:   "Render release notes from a snapshot",
+	Long:    `Render release notes from a JSON snapshot previously written with the --snapshot flag of a release-notes command, reproducing the same notes without querying any remote source.`,
+	Example: "release generate release-notes-from-snapshot v1.29.2+rke2r1.json",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) < 1 {
+			return errors.New("expected at least one argument: [snapshot]")
+		}
+
+		b, err := os.ReadFile(args[0])
+		if err != nil {
+			return err
+		}
+
+		var snapshot release.ReleaseNotesSnapshot
+		if err := json.Unmarshal(b, &snapshot); err != nil {
+			return err
+		}
+
+		notes, err := release.RenderReleaseNotes(&snapshot)
+		if err != nil {
+			return err
+		}
+
+		fmt.Print(notes.String())
+
+		return nil
+	},
+}
+
+var ancillaryGenerateSubCmd = &cobra.Command{
+	Use:   "ancillary",
+	Short: "Generate k3s ancillary repos related artifacts, e.g. kine",
+}
+
+var ancillaryGenerateReleaseNotesSubCmd = &cobra.Command{
+	Use:     "release-notes [kine|helm-controller|local-path-provisioner]",
+	Short:   "Generate release notes of a k3s ancillary repo",
+	Example: "release generate ancillary release-notes kine --milestone v0.11.8 --prev-milestone v0.11.7",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) < 1 {
+			return errors.New("expected at least one argument: [" + strings.Join(release.AncillaryRepos(), "|") + "]")
+		}
+		repo := args[0]
+
+		owner, err := release.AncillaryOwner(repo)
+		if err != nil {
+			return err
+		}
+
+		return genReleaseNotes(context.Background(), owner, repo, ancillaryMilestone, ancillaryPrevMilestone)
+	},
+}
+
+var templateDocsSubCmd = &cobra.Command{
+	Use:   "template-docs [k3s|rke2|ui|dashboard|cli]",
+	Short: "Generate a reference of the release notes template variables and functions",
+	Long: `Introspect the release notes templates and data of a repo and print a markdown reference of the available templates, variables and functions.
+Example values are taken from a snapshot written with the --snapshot flag of a release-notes command, or resolved for the given milestones.`,
+	Example: "release generate template-docs rke2 --milestone v1.29.2+rke2r1 --prev-milestone v1.29.1+rke2r1",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) < 1 {
+			return errors.New("expected at least one argument: [k3s|rke2|ui|dashboard|cli]")
+		}
+		repo := args[0]
+
+		var snapshot *release.ReleaseNotesSnapshot
+		switch {
+		case templateDocsSnapshotPath != "":
+			b, err := os.ReadFile(templateDocsSnapshotPath)
+			if err != nil {
+				return err
+			}
+			snapshot = &release.ReleaseNotesSnapshot{}
+			if err := json.Unmarshal(b, snapshot); err != nil {
+				return err
+			}
+		case templateDocsMilestone != "":
+			if templateDocsPrevMilestone == "" {
+				return errors.New("--prev-milestone is required with --milestone")
+			}
+			owner, ok := repoToOwner[repo]
+			if !ok {
+				owner = "rancher"
+			}
+			if ancillaryOwner, err := release.AncillaryOwner(repo); err == nil {
+				owner = ancillaryOwner
+			}
+
+			ctx := context.Background()
+			client := notesClient(ctx)
+
+			var err error
+			snapshot, err = client.NotesSnapshot(ctx, release.NotesOptions{
+				Owner:         owner,
+				Repo:          repo,
+				Milestone:     templateDocsMilestone,
+				PrevMilestone: templateDocsPrevMilestone,
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		ref, err := release.NewTemplateReference(repo, snapshot)
+		if err != nil {
+			return err
+		}
+		docs, err := release.RenderTemplateReference(ref)
+		if err != nil {
+			return err
+		}
+
+		fmt.Print(docs.String())
+
+		return nil
+	},
+}
+
+// notesClient returns a release client resolving the components of the
+// release notes with the components mapping of the config, if any.
+func notesClient(ctx context.Context) *release.Client {
+	client := release.NewClient(githubClient(ctx, ecmConfig.OperationTag))
+	client.Components = rootConfig.Components
+
+	return client
+}
+
+// notesDates returns the locale of the given product's notes, from the
+// locale flag or the config, and the dates of the publish-date and eol-date
+// flags.
+func notesDates(product string) (release.NotesDates, error) {
+	dates := release.NotesDates{Locale: releaseNotesLocale}
+	if dates.Locale == "" {
+		dates.Locale = rootConfig.Locale.For(product)
+	}
+
+	var err error
+	if releaseNotesPublishDate != "" {
+		if dates.PublishDate, err = time.Parse(time.DateOnly, releaseNotesPublishDate); err != nil {
+			return dates, errors.New("invalid publish date, expected YYYY-MM-DD: " + err.Error())
+		}
+	}
+	if releaseNotesEOLDate != "" {
+		if dates.EOLDate, err = time.Parse(time.DateOnly, releaseNotesEOLDate); err != nil {
+			return dates, errors.New("invalid end of life date, expected YYYY-MM-DD: " + err.Error())
+		}
+	}
+
+	return dates, nil
+}
+
+// changeLogOptions returns the options of the changelog retrieval of the
+// given milestone, printing the progress to the standard error. Unless set
+// with the checkpoint flag, the checkpoint of an interrupted retrieval is
+// saved to the temporary directory.
+func changeLogOptions(owner, repo, milestone string) repository.ChangeLogOptions {
+	checkpoint := releaseNotesCheckpoint
+	if checkpoint == "" {
+		checkpoint = filepath.Join(os.TempDir(), "changelog-"+owner+"-"+repo+"-"+milestone+".json")
+	}
+
+	return repository.ChangeLogOptions{
+		Workers:    releaseNotesWorkers,
+		Checkpoint: checkpoint,
+		Progress: func(done, total int) {
+			fmt.Fprintf(os.Stderr, "fetched %d of %d pull requests\n", done, total)
+		},
+	}
+}
+
+// genReleaseNotes prints the release notes for the given milestones and, if
+// the snapshot flag is set, writes the data used to render them to it. If
+// the pull-requests flag is set, the changelog is made of the pull requests
+// listed in it instead of the ones merged between the milestones.
+func genReleaseNotes(ctx context.Context, owner, repo, milestone, prevMilestone string) error {
+	var pullRequests []int
+	if releaseNotesPullRequestsPath != "" {
+		f, err := os.Open(releaseNotesPullRequestsPath)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		if pullRequests, err = repository.ParsePullRequestList(f); err != nil {
+			return errors.New("failed to read the pull requests of " + releaseNotesPullRequestsPath + ": " + err.Error())
+		}
+	}
+
+	dates, err := notesDates(repo)
+	if err != nil {
+		return err
+	}
+
+	client := notesClient(ctx)
+
+	snapshot, err := client.NotesSnapshot(ctx, release.NotesOptions{
+		Owner:         owner,
+		Repo:          repo,
+		Milestone:     milestone,
+		PrevMilestone: prevMilestone,
+		FromImages:    releaseNotesFromImages,
+		PullRequests:  pullRequests,
+		ChangeLog:     changeLogOptions(owner, repo, milestone),
+		NotesDates:    dates,
+	})
+	if err != nil {
+		return err
+	}
+
+	notes, err := release.RenderReleaseNotes(snapshot)
+	if err != nil {
+		return err
+	}
+
+	if releaseNotesSnapshotPath != "" {
+		b, err := json.MarshalIndent(snapshot, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(releaseNotesSnapshotPath, b, 0644); err != nil {
+			return err
+		}
+	}
+
+	fmt.Print(notes.String())
+
+	return nil
+}
+
+var conformanceGenerateSubCmd = &cobra.Command{
+	Use:     "conformance [version]",
+	Short:   "Verify and archive conformance results and prepare the k8s-conformance PR",
+	Long:    `Read the sonobuoy results tarball of a k3s or rke2 release, verify that all conformance tests passed, archive it and write the files for the cncf/k8s-conformance pull request.`,
+	Example: "release generate conformance v1.29.2+rke2r1 --results ./202403011200_sonobuoy_results.tar.gz --output ./conformance",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) < 1 {
+			return errors.New("expected at least one argument: [version]")
+		}
+		tag := args[0]
+
+		tarball, err := readConformanceResults(conformanceResults)
+		if err != nil {
+			return err
+		}
+
+		results, err := conformance.ReadResults(bytes.NewReader(tarball))
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("ran %d of %d specs: %d passed, %d failed, %d skipped\n", results.Ran, results.Total, results.Passed, results.Failed, results.Skipped)
+
+		if err := results.Verify(); err != nil {
+			return err
+		}
+
+		archive, err := conformance.Archive(tarball, conformanceOutput, tag)
+		if err != nil {
+			return err
+		}
+		fmt.Println("archived results: " + archive)
+
+		dir, err := conformance.PreparePR(results, tag, filepath.Join(conformanceOutput, "k8s-conformance"))
+		if err != nil {
+			return err
+		}
+		fmt.Println("k8s-conformance PR content: " + dir)
+
+		return nil
+	},
+}
+
+// readConformanceResults reads the results tarball from a local path or an
+// http(s) URL.
+func readConformanceResults(location string) ([]byte, error) {
+	if !strings.HasPrefix(location, "http://") && !strings.HasPrefix(location, "https://") {
+		return os.ReadFile(location)
+	}
+
+	httpClient := ecmHTTP.NewClient(time.Minute * 5)
+	resp, err := httpClient.Get(location)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New("failed to download conformance results: " + resp.Status)
+	}
+
+	return io.ReadAll(resp.Body)
+}
+
 func init() {
 	rootCmd.AddCommand(generateCmd)
 
@@ -434,6 +728,7 @@ func init() {
 	uiGenerateSubCmd.AddCommand(uiGenerateReleaseNotesSubCmd)
 	dashboardGenerateSubCmd.AddCommand(dashboardGenerateReleaseNotesSubCmd)
 	cliGenerateSubCmd.AddCommand(cliGenerateReleaseNotesSubCmd)
+	ancillaryGenerateSubCmd.AddCommand(ancillaryGenerateReleaseNotesSubCmd)
 
 	kdmGenerateSubCmd.AddCommand(kdmGenerateRKE2ChartsSubCmd)
 	kdmGenerateSubCmd.AddCommand(kdmGenerateRKE2SubCmd)
@@ -444,9 +739,22 @@ func init() {
 	generateCmd.AddCommand(uiGenerateSubCmd)
 	generateCmd.AddCommand(dashboardGenerateSubCmd)
 	generateCmd.AddCommand(cliGenerateSubCmd)
+	generateCmd.AddCommand(ancillaryGenerateSubCmd)
 	generateCmd.AddCommand(kdmGenerateSubCmd)
+	generateCmd.AddCommand(unifiedGenerateReleaseNotesSubCmd)
+	generateCmd.AddCommand(releaseNotesFromSnapshotSubCmd)
+	generateCmd.AddCommand(templateDocsSubCmd)
+	generateCmd.AddCommand(conformanceGenerateSubCmd)
 
 	// k3s release notes
+	k3sGenerateReleaseNotesSubCmd.Flags().StringVarP(&releaseNotesSnapshotPath, "snapshot", "s", "", "Write the data used to render the notes to a JSON snapshot file")
+	k3sGenerateReleaseNotesSubCmd.Flags().StringVar(&releaseNotesPullRequestsPath, "pull-requests", "", "Read the changelog from a JSON or CSV list of pull request numbers instead of the milestones")
+	k3sGenerateReleaseNotesSubCmd.Flags().IntVar(&releaseNotesWorkers, "workers", 4, "Number of batches of pull requests of the changelog fetched in parallel")
+	k3sGenerateReleaseNotesSubCmd.Flags().StringVar(&releaseNotesCheckpoint, "checkpoint", "", "Save the partial changelog to this file if interrupted, e.g. by the rate limit, and resume from it, defaults to a file of the temporary directory")
+	k3sGenerateReleaseNotesSubCmd.Flags().BoolVar(&releaseNotesFromImages, "from-images", false, "Read the component versions from the labels and SBOMs of the built images, falling back to the repo files")
+	k3sGenerateReleaseNotesSubCmd.Flags().StringVar(&releaseNotesLocale, "locale", "", "Locale the dates are formatted in, e.g. de-DE, defaults to the config's")
+	k3sGenerateReleaseNotesSubCmd.Flags().StringVar(&releaseNotesPublishDate, "publish-date", "", "Publish date of the release, YYYY-MM-DD, defaults to the one of its GitHub release if published")
+	k3sGenerateReleaseNotesSubCmd.Flags().StringVar(&releaseNotesEOLDate, "eol-date", "", "End of life date of the release line, YYYY-MM-DD")
 	k3sGenerateReleaseNotesSubCmd.Flags().StringVarP(&k3sPrevMilestone, "prev-milestone", "p", "", "Previous Milestone")
 	k3sGenerateReleaseNotesSubCmd.Flags().StringVarP(&k3sMilestone, "milestone", "m", "", "Milestone")
 	if err := k3sGenerateReleaseNotesSubCmd.MarkFlagRequired("prev-milestone"); err != nil {
@@ -459,6 +767,14 @@ func init() {
 	}
 
 	// rke2 release notes
+	rke2GenerateReleaseNotesSubCmd.Flags().StringVarP(&releaseNotesSnapshotPath, "snapshot", "s", "", "Write the data used to render the notes to a JSON snapshot file")
+	rke2GenerateReleaseNotesSubCmd.Flags().StringVar(&releaseNotesPullRequestsPath, "pull-requests", "", "Read the changelog from a JSON or CSV list of pull request numbers instead of the milestones")
+	rke2GenerateReleaseNotesSubCmd.Flags().IntVar(&releaseNotesWorkers, "workers", 4, "Number of batches of pull requests of the changelog fetched in parallel")
+	rke2GenerateReleaseNotesSubCmd.Flags().StringVar(&releaseNotesCheckpoint, "checkpoint", "", "Save the partial changelog to this file if interrupted, e.g. by the rate limit, and resume from it, defaults to a file of the temporary directory")
+	rke2GenerateReleaseNotesSubCmd.Flags().BoolVar(&releaseNotesFromImages, "from-images", false, "Read the component versions from the labels and SBOMs of the built images, falling back to the repo files")
+	rke2GenerateReleaseNotesSubCmd.Flags().StringVar(&releaseNotesLocale, "locale", "", "Locale the dates are formatted in, e.g. de-DE, defaults to the config's")
+	rke2GenerateReleaseNotesSubCmd.Flags().StringVar(&releaseNotesPublishDate, "publish-date", "", "Publish date of the release, YYYY-MM-DD, defaults to the one of its GitHub release if published")
+	rke2GenerateReleaseNotesSubCmd.Flags().StringVar(&releaseNotesEOLDate, "eol-date", "", "End of life date of the release line, YYYY-MM-DD")
 	rke2GenerateReleaseNotesSubCmd.Flags().StringVarP(&rke2PrevMilestone, "prev-milestone", "p", "", "Previous Milestone")
 	rke2GenerateReleaseNotesSubCmd.Flags().StringVarP(&rke2Milestone, "milestone", "m", "", "Milestone")
 	if err := rke2GenerateReleaseNotesSubCmd.MarkFlagRequired("prev-milestone"); err != nil {
@@ -470,7 +786,45 @@ func init() {
 		os.Exit(1)
 	}
 
+	// unified release notes
+	unifiedGenerateReleaseNotesSubCmd.Flags().StringVar(&k3sMilestone, "k3s-milestone", "", "k3s Milestone")
+	unifiedGenerateReleaseNotesSubCmd.Flags().StringVar(&k3sPrevMilestone, "k3s-prev-milestone", "", "k3s Previous Milestone")
+	unifiedGenerateReleaseNotesSubCmd.Flags().StringVar(&rke2Milestone, "rke2-milestone", "", "rke2 Milestone")
+	unifiedGenerateReleaseNotesSubCmd.Flags().StringVar(&rke2PrevMilestone, "rke2-prev-milestone", "", "rke2 Previous Milestone")
+	unifiedGenerateReleaseNotesSubCmd.Flags().StringVar(&releaseNotesLocale, "locale", "", "Locale the dates are formatted in, e.g. de-DE, defaults to the config's")
+	unifiedGenerateReleaseNotesSubCmd.Flags().StringVar(&releaseNotesPublishDate, "publish-date", "", "Publish date of the releases, YYYY-MM-DD, defaults to the one of the k3s GitHub release if published")
+	unifiedGenerateReleaseNotesSubCmd.Flags().StringVar(&releaseNotesEOLDate, "eol-date", "", "End of life date of the release line, YYYY-MM-DD")
+	for _, flag := range []string{"k3s-milestone", "k3s-prev-milestone", "rke2-milestone", "rke2-prev-milestone"} {
+		if err := unifiedGenerateReleaseNotesSubCmd.MarkFlagRequired(flag); err != nil {
+			fmt.Println(err.Error())
+			os.Exit(1)
+		}
+	}
+
+	// ancillary release notes
+	ancillaryGenerateReleaseNotesSubCmd.Flags().StringVarP(&releaseNotesSnapshotPath, "snapshot", "s", "", "Write the data used to render the notes to a JSON snapshot file")
+	ancillaryGenerateReleaseNotesSubCmd.Flags().StringVar(&releaseNotesPullRequestsPath, "pull-requests", "", "Read the changelog from a JSON or CSV list of pull request numbers instead of the milestones")
+	ancillaryGenerateReleaseNotesSubCmd.Flags().IntVar(&releaseNotesWorkers, "workers", 4, "Number of batches of pull requests of the changelog fetched in parallel")
+	ancillaryGenerateReleaseNotesSubCmd.Flags().StringVar(&releaseNotesCheckpoint, "checkpoint", "", "Save the partial changelog to this file if interrupted, e.g. by the rate limit, and resume from it, defaults to a file of the temporary directory")
+	ancillaryGenerateReleaseNotesSubCmd.Flags().StringVarP(&ancillaryPrevMilestone, "prev-milestone", "p", "", "Previous Milestone")
+	ancillaryGenerateReleaseNotesSubCmd.Flags().StringVarP(&ancillaryMilestone, "milestone", "m", "", "Milestone")
+	for _, flag := range []string{"prev-milestone", "milestone"} {
+		if err := ancillaryGenerateReleaseNotesSubCmd.MarkFlagRequired(flag); err != nil {
+			fmt.Println(err.Error())
+			os.Exit(1)
+		}
+	}
+
+	// template docs
+	templateDocsSubCmd.Flags().StringVarP(&templateDocsSnapshotPath, "snapshot", "s", "", "Read example values from a JSON snapshot file")
+	templateDocsSubCmd.Flags().StringVarP(&templateDocsMilestone, "milestone", "m", "", "Milestone to resolve example values for")
+	templateDocsSubCmd.Flags().StringVarP(&templateDocsPrevMilestone, "prev-milestone", "p", "", "Previous Milestone")
+
 	// ui release notes
+	uiGenerateReleaseNotesSubCmd.Flags().StringVarP(&releaseNotesSnapshotPath, "snapshot", "s", "", "Write the data used to render the notes to a JSON snapshot file")
+	uiGenerateReleaseNotesSubCmd.Flags().StringVar(&releaseNotesPullRequestsPath, "pull-requests", "", "Read the changelog from a JSON or CSV list of pull request numbers instead of the milestones")
+	uiGenerateReleaseNotesSubCmd.Flags().IntVar(&releaseNotesWorkers, "workers", 4, "Number of batches of pull requests of the changelog fetched in parallel")
+	uiGenerateReleaseNotesSubCmd.Flags().StringVar(&releaseNotesCheckpoint, "checkpoint", "", "Save the partial changelog to this file if interrupted, e.g. by the rate limit, and resume from it, defaults to a file of the temporary directory")
 	uiGenerateReleaseNotesSubCmd.Flags().StringVarP(&dashboardPrevMilestone, "prev-milestone", "p", "", "Previous Milestone")
 	uiGenerateReleaseNotesSubCmd.Flags().StringVarP(&dashboardMilestone, "milestone", "m", "", "Milestone")
 	if err := uiGenerateReleaseNotesSubCmd.MarkFlagRequired("prev-milestone"); err != nil {
@@ -483,6 +837,10 @@ func init() {
 	}
 
 	// dashboard release notes
+	dashboardGenerateReleaseNotesSubCmd.Flags().StringVarP(&releaseNotesSnapshotPath, "snapshot", "s", "", "Write the data used to render the notes to a JSON snapshot file")
+	dashboardGenerateReleaseNotesSubCmd.Flags().StringVar(&releaseNotesPullRequestsPath, "pull-requests", "", "Read the changelog from a JSON or CSV list of pull request numbers instead of the milestones")
+	dashboardGenerateReleaseNotesSubCmd.Flags().IntVar(&releaseNotesWorkers, "workers", 4, "Number of batches of pull requests of the changelog fetched in parallel")
+	dashboardGenerateReleaseNotesSubCmd.Flags().StringVar(&releaseNotesCheckpoint, "checkpoint", "", "Save the partial changelog to this file if interrupted, e.g. by the rate limit, and resume from it, defaults to a file of the temporary directory")
 	dashboardGenerateReleaseNotesSubCmd.Flags().StringVarP(&dashboardPrevMilestone, "prev-milestone", "p", "", "Previous Milestone")
 	dashboardGenerateReleaseNotesSubCmd.Flags().StringVarP(&dashboardMilestone, "milestone", "m", "", "Milestone")
 	if err := dashboardGenerateReleaseNotesSubCmd.MarkFlagRequired("prev-milestone"); err != nil {
@@ -495,6 +853,10 @@ func init() {
 	}
 
 	// cli release notes
+	cliGenerateReleaseNotesSubCmd.Flags().StringVarP(&releaseNotesSnapshotPath, "snapshot", "s", "", "Write the data used to render the notes to a JSON snapshot file")
+	cliGenerateReleaseNotesSubCmd.Flags().StringVar(&releaseNotesPullRequestsPath, "pull-requests", "", "Read the changelog from a JSON or CSV list of pull request numbers instead of the milestones")
+	cliGenerateReleaseNotesSubCmd.Flags().IntVar(&releaseNotesWorkers, "workers", 4, "Number of batches of pull requests of the changelog fetched in parallel")
+	cliGenerateReleaseNotesSubCmd.Flags().StringVar(&releaseNotesCheckpoint, "checkpoint", "", "Save the partial changelog to this file if interrupted, e.g. by the rate limit, and resume from it, defaults to a file of the temporary directory")
 	cliGenerateReleaseNotesSubCmd.Flags().StringVarP(&cliPrevMilestone, "prev-milestone", "p", "", "Previous Milestone")
 	cliGenerateReleaseNotesSubCmd.Flags().StringVarP(&cliMilestone, "milestone", "m", "", "Milestone")
 	if err := cliGenerateReleaseNotesSubCmd.MarkFlagRequired("prev-milestone"); err != nil {
@@ -506,6 +868,14 @@ func init() {
 		os.Exit(1)
 	}
 
+	// conformance
+	conformanceGenerateSubCmd.Flags().StringVarP(&conformanceResults, "results", "r", "", "Path or URL of the sonobuoy results tarball")
+	conformanceGenerateSubCmd.Flags().StringVarP(&conformanceOutput, "output", "o", ".", "Output directory, defaults to current working directory")
+	if err := conformanceGenerateSubCmd.MarkFlagRequired("results"); err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+
 	// rancher artifacts-index
 	rancherGenerateArtifactsIndexSubCmd.Flags().StringSliceVarP(&rancherArtifactsIndexIgnoreVersions, "ignore-versions", "i", []string{}, "Versions to ignore on the index")
 	rancherGenerateArtifactsIndexSubCmd.Flags().StringVarP(&rancherArtifactsIndexWriteToPath, "write-path", "w", ".", "Output directory, defaults to current working directory")
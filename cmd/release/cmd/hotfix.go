@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/rancher/ecm-distro-tools/cmd/release/config"
+	"github.com/rancher/ecm-distro-tools/release/hotfix"
+	"github.com/spf13/cobra"
+)
+
+var (
+	hotfixLine      *string
+	hotfixCommits   *[]string
+	hotfixCVEs      *[]string
+	hotfixWorkspace *string
+)
+
+// hotfixCmd represents the hotfix command
+var hotfixCmd = &cobra.Command{
+	Use:   "hotfix [k3s|rke2] [plan|branch|rc|ga]",
+	Short: "Condensed release flow for emergency CVE fixes",
+	Long: `Cut a hotfix release of the last GA of a release line:
+
+  plan    print the base, branch, rc and GA versions of the hotfix
+  branch  create the hotfix branch from the last GA tag and cherry-pick the given commits
+  rc      tag the next rc from the hotfix branch
+  ga      verify the rc assets and tag the GA release from the rc commit with security focused notes`,
+	Example: "release hotfix rke2 branch --line v1.29 --commits 3f2a1b0,9c8d7e6 --cves CVE-2024-0001",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) < 2 {
+			return errors.New("expected at least two arguments: [k3s|rke2] [plan|branch|rc|ga]")
+		}
+		product, step := args[0], args[1]
+
+		owner, ok := repoToOwner[product]
+		if !ok || product == "rancher" {
+			return errors.New("invalid product: " + product + ", expected one of: k3s, rke2")
+		}
+
+		ctx := context.Background()
+		// the releases are read, and the branch and tags created, as the
+		// identity of the tag operation.
+		client := githubClient(ctx, config.OperationTag)
+
+		plan, err := hotfix.NewPlan(ctx, client, owner, product, *hotfixLine, *hotfixCommits, *hotfixCVEs)
+		if err != nil {
+			return err
+		}
+
+		switch step {
+		case "plan":
+			fmt.Println("base:   " + plan.Base)
+			fmt.Println("branch: " + plan.Branch)
+			fmt.Println("rc:     " + plan.RC)
+			fmt.Println("ga:     " + plan.GA)
+			return nil
+		case "branch":
+			if dryRun {
+				fmt.Println("dry run, would create " + plan.Branch + " from " + plan.Base)
+			} else if err := plan.CreateBranch(ctx, client); err != nil {
+				return err
+			}
+			remoteURL := gitURL("git@github.com:" + owner + "/" + product + ".git")
+			out, err := plan.CherryPick(*hotfixWorkspace, remoteURL, cloneOptions(product), dryRun)
+			if err != nil {
+				return err
+			}
+			fmt.Println(out)
+			return nil
+		case "rc":
+			if dryRun {
+				fmt.Println("dry run, would tag " + plan.RC + " from " + plan.Branch)
+				return nil
+			}
+			if err := plan.TagRC(ctx, client); err != nil {
+				return err
+			}
+			fmt.Println("tag " + plan.RC + " created successfully")
+			return nil
+		case "ga":
+			if err := plan.Verify(ctx, client); err != nil {
+				return err
+			}
+			notes, err := plan.SecurityNotes(ctx, client)
+			if err != nil {
+				return err
+			}
+			if dryRun {
+				fmt.Println("dry run, would tag " + plan.GA + " from " + plan.LatestRC + " (" + plan.RCCommit + ") with notes:")
+				fmt.Println(notes.String())
+				return nil
+			}
+			if err := plan.TagGA(ctx, client, notes.String()); err != nil {
+				return err
+			}
+			fmt.Println("tag " + plan.GA + " created successfully")
+			return nil
+		}
+
+		return errors.New("invalid step: " + step + ", expected one of: plan, branch, rc, ga")
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(hotfixCmd)
+
+	hotfixLine = hotfixCmd.Flags().StringP("line", "l", "", "release line to hotfix, e.g. v1.29")
+	hotfixCommits = hotfixCmd.Flags().StringSlice("commits", []string{}, "commits to cherry-pick onto the last GA")
+	hotfixCVEs = hotfixCmd.Flags().StringSlice("cves", []string{}, "CVEs addressed by the hotfix, listed in the notes")
+	hotfixWorkspace = hotfixCmd.Flags().StringP("workspace", "w", os.TempDir(), "directory to clone the repository into")
+
+	if err := hotfixCmd.MarkFlagRequired("line"); err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+}
@@ -2,17 +2,22 @@ package cmd
 
 import (
 	"context"
+	encodingcsv "encoding/csv"
 	"errors"
 	"fmt"
 	"io"
 	"os"
 	"sort"
+	"strconv"
 	"strings"
 	"text/tabwriter"
+	"time"
 
 	"github.com/google/go-containerregistry/pkg/name"
+	ecmHTTP "github.com/rancher/ecm-distro-tools/http"
 	reg "github.com/rancher/ecm-distro-tools/registry"
 	"github.com/rancher/ecm-distro-tools/release"
+	"github.com/rancher/ecm-distro-tools/release/platforms"
 	"github.com/rancher/ecm-distro-tools/release/rke2"
 	"github.com/rancher/ecm-distro-tools/repository"
 	"github.com/spf13/cobra"
@@ -22,6 +27,13 @@ const (
 	ossRegistry = "docker.io"
 )
 
+var (
+	inspectRKE2ChartsIndex        *string
+	inspectRKE2ChartsSkipPackaged *bool
+	inspectPlatformsRegistries    *[]string
+	inspectPlatformsIncomplete    *bool
+)
+
 func archStatus(expected bool, ossInfo, primeInfo reg.Image, platform reg.Platform) string {
 	if !expected {
 		return "-"
@@ -195,7 +207,334 @@ Currently supports inspecting the image list for published rke2 releases.`,
 	},
 }
 
+var inspectHardenedImagesCmd = &cobra.Command{
+	Use:   "hardened-images [branch]",
+	Short: "Verify the hardened images referenced by an rke2 branch exist",
+	Long: `Verify that every rancher/hardened-* image referenced by the Dockerfile of the given rke2 branch or tag exists
+for all required platforms in Docker Hub and, if configured, the prime registry. Run it before cutting an rc to catch
+images that weren't built yet.`,
+	Example: "release inspect hardened-images release-1.29",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) < 1 {
+			return errors.New("expected at least one argument: [branch]")
+		}
+
+		ctx := context.Background()
+		gh := repository.NewGithub(ctx, rootConfig.Auth.GithubToken)
+
+		refs, err := rke2.DockerfileHardenedImages(ctx, gh, rootConfig.RKE2.RepoOwner(), args[0])
+		if err != nil {
+			return err
+		}
+
+		registries := map[string]rke2.RegistryClient{
+			ossRegistry: reg.NewClient(ossRegistry, debug),
+		}
+		if rootConfig.PrimeRegistry != "" {
+			registries[rootConfig.PrimeRegistry] = reg.NewClient(rootConfig.PrimeRegistry, debug)
+		}
+
+		expected, err := rootConfig.Platforms.For(platforms.Hardened, args[0])
+		if err != nil {
+			return err
+		}
+
+		results, err := rke2.CheckHardenedImages(ctx, refs, registries, expected)
+		if err != nil {
+			return err
+		}
+
+		return hardenedImagesTable(os.Stdout, results)
+	},
+}
+
+// hardenedImagesTable writes the missing images and platforms per registry,
+// and returns an error if any is missing.
+func hardenedImagesTable(w io.Writer, results []rke2.HardenedImage) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "IMAGE\tREGISTRY\tMISSING")
+
+	var missing int
+	for _, result := range results {
+		if result.OK() {
+			fmt.Fprintln(tw, formatImageRef(result.Reference)+"\t-\t✓")
+			continue
+		}
+		missing++
+
+		registries := make([]string, 0, len(result.Missing))
+		for registry := range result.Missing {
+			registries = append(registries, registry)
+		}
+		sort.Strings(registries)
+
+		for _, registry := range registries {
+			platforms := make([]string, len(result.Missing[registry]))
+			for i, platform := range result.Missing[registry] {
+				platforms[i] = platform.String()
+			}
+			fmt.Fprintln(tw, formatImageRef(result.Reference)+"\t"+registry+"\t"+strings.Join(platforms, ","))
+		}
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	if missing > 0 {
+		return errors.New(strconv.Itoa(missing) + " hardened images are missing")
+	}
+
+	return nil
+}
+
+var inspectRKE2ChartsCmd = &cobra.Command{
+	Use:   "rke2-charts [branch]",
+	Short: "Verify the charts packaged by an rke2 branch are published",
+	Long: `Verify that every chart version packaged by the given rke2 branch or tag, as set by the CHART_VERSION of its
+Dockerfile or its charts/chart_versions.yaml, is published in the rke2-charts index, and that the published archive
+matches the index version and appVersion. Run it before cutting an rc to catch chart bumps merged before the chart was
+published.`,
+	Example: "release inspect rke2-charts release-1.29",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) < 1 {
+			return errors.New("expected at least one argument: [branch]")
+		}
+
+		ctx := context.Background()
+		gh := repository.NewGithub(ctx, rootConfig.Auth.GithubToken)
+
+		refs, err := rke2.BranchCharts(ctx, gh, rootConfig.RKE2.RepoOwner(), args[0])
+		if err != nil {
+			return err
+		}
+
+		client := ecmHTTP.NewClient(time.Minute)
+		index, err := rke2.GetChartsIndex(ctx, &client, *inspectRKE2ChartsIndex)
+		if err != nil {
+			return err
+		}
+
+		return chartsTable(os.Stdout, rke2.CheckCharts(ctx, &client, index, refs, !*inspectRKE2ChartsSkipPackaged))
+	},
+}
+
+// chartsTable writes the charts and their problems, and returns an error if
+// any has one.
+func chartsTable(w io.Writer, checks []rke2.ChartCheck) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "CHART\tVERSION\tAPP VERSION\tSTATUS")
+
+	var invalid int
+	for _, check := range checks {
+		status := "✓"
+		if !check.OK() {
+			status = check.Problem
+			invalid++
+		}
+		appVersion := check.AppVersion
+		if appVersion == "" {
+			appVersion = "-"
+		}
+		fmt.Fprintln(tw, check.Name+"\t"+check.Version+"\t"+appVersion+"\t"+status)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	if invalid > 0 {
+		return errors.New(strconv.Itoa(invalid) + " charts aren't published as expected")
+	}
+
+	return nil
+}
+
+var inspectPlatformsCmd = &cobra.Command{
+	Use:   "platforms [k3s|rke2] [version]",
+	Short: "Report the platforms every image of a release is available for in each registry",
+	Long: `Read the image lists of a k3s or rke2 release and report a matrix of the availability of every image for each
+platform in Docker Hub, the prime registry if configured, and the registries given with --registry. The platforms of
+each product and release line are set by the platforms config. An image is expected for a platform if the list of that
+platform references it, k3s listing the images of all its platforms once.
+Run it instead of inspecting the manifest of each image by hand.`,
+	Example: "release inspect platforms rke2 v1.29.2+rke2r1 --registry registry.example.com --incomplete",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) < 2 {
+			return errors.New("expected at least two arguments: [k3s|rke2] [version]")
+		}
+		product, version := args[0], args[1]
+
+		owner := "k3s-io"
+		if product == "rke2" {
+			owner = rootConfig.RKE2.RepoOwner()
+		}
+		if product != "k3s" && product != "rke2" {
+			return errors.New("invalid product: " + product + ", expected one of: k3s, rke2")
+		}
+		expected, err := rootConfig.Platforms.For(product, version)
+		if err != nil {
+			return err
+		}
+
+		ctx := context.Background()
+		gh := repository.NewGithub(ctx, rootConfig.Auth.GithubToken)
+		filesystem, err := release.NewFS(ctx, gh, owner, product, version)
+		if err != nil {
+			return err
+		}
+
+		images, err := platforms.ReleaseImages(filesystem, product, expected)
+		if err != nil {
+			return err
+		}
+
+		registries := map[string]reg.RegistryClient{
+			ossRegistry: reg.NewClient(ossRegistry, debug),
+		}
+		if rootConfig.PrimeRegistry != "" {
+			registries[rootConfig.PrimeRegistry] = reg.NewClient(rootConfig.PrimeRegistry, debug)
+		}
+		for _, registry := range *inspectPlatformsRegistries {
+			registries[registry] = reg.NewClient(registry, debug)
+		}
+
+		report := platforms.Check(ctx, images, registries)
+
+		outputFormat, _ := cmd.Flags().GetString("output")
+		switch outputFormat {
+		case "csv":
+			if err := platformsCSV(os.Stdout, report, *inspectPlatformsIncomplete); err != nil {
+				return err
+			}
+		default:
+			if err := platformsTable(os.Stdout, report, *inspectPlatformsIncomplete); err != nil {
+				return err
+			}
+		}
+
+		if incomplete := report.Incomplete(); incomplete > 0 {
+			return errors.New(strconv.Itoa(incomplete) + " of " + strconv.Itoa(len(report.Images)) + " images are missing platforms")
+		}
+
+		return nil
+	},
+}
+
+// platformStatus returns whether the image is available for the platform
+// in the registry: ✓ if it is, ✗ if it's expected and missing, - if it
+// isn't expected, and ? if the image couldn't be looked up.
+func platformStatus(image platforms.Availability, registry string, platform reg.Platform) string {
+	if _, ok := image.Errs[registry]; ok {
+		return "?"
+	}
+	if image.Images[registry].Platforms[platform] {
+		return "✓"
+	}
+	for _, expected := range image.Platforms {
+		if expected == platform {
+			return "✗"
+		}
+	}
+
+	return "-"
+}
+
+// platformsRows returns a row per image and registry with the status of
+// each platform of the report, and the lookup error if any.
+func platformsRows(report platforms.Report, incompleteOnly bool) [][]string {
+	var rows [][]string
+	for _, image := range report.Images {
+		if incompleteOnly && image.Complete() {
+			continue
+		}
+		for _, registry := range report.Registries {
+			row := []string{formatImageRef(image.Reference), registry}
+			for _, platform := range report.Platforms {
+				row = append(row, platformStatus(image, registry, platform))
+			}
+			if err, ok := image.Errs[registry]; ok {
+				row = append(row, err.Error())
+			} else {
+				row = append(row, "")
+			}
+			rows = append(rows, row)
+		}
+	}
+
+	return rows
+}
+
+func platformsHeader(report platforms.Report) []string {
+	header := []string{"IMAGE", "REGISTRY"}
+	for _, platform := range report.Platforms {
+		header = append(header, platform.String())
+	}
+
+	return append(header, "ERROR")
+}
+
+// platformsTable writes the image × platform matrix of each registry,
+// followed by the failed lookups.
+func platformsTable(w io.Writer, report platforms.Report, incompleteOnly bool) error {
+	if incomplete := report.Incomplete(); incomplete > 0 {
+		fmt.Fprintln(w, incomplete, "incomplete images")
+	} else {
+		fmt.Fprintln(w, "all images OK")
+	}
+
+	header := platformsHeader(report)
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.Join(header[:len(header)-1], "\t"))
+
+	var lookupErrs []string
+	for _, row := range platformsRows(report, incompleteOnly) {
+		fmt.Fprintln(tw, strings.Join(row[:len(row)-1], "\t"))
+		if lookupErr := row[len(row)-1]; lookupErr != "" {
+			lookupErrs = append(lookupErrs, row[0]+" in "+row[1]+": "+lookupErr)
+		}
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	if len(lookupErrs) > 0 {
+		fmt.Fprintln(w, "\nfailed lookups:")
+		for _, lookupErr := range lookupErrs {
+			fmt.Fprintln(w, lookupErr)
+		}
+	}
+
+	return nil
+}
+
+// platformsCSV writes the matrix as CSV, quoting the lookup errors.
+func platformsCSV(w io.Writer, report platforms.Report, incompleteOnly bool) error {
+	cw := encodingcsv.NewWriter(w)
+	header := platformsHeader(report)
+	for i := range header {
+		header[i] = strings.ToLower(header[i])
+	}
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	if err := cw.WriteAll(platformsRows(report, incompleteOnly)); err != nil {
+		return err
+	}
+
+	return cw.Error()
+}
+
 func init() {
 	rootCmd.AddCommand(inspectCmd)
 	inspectCmd.Flags().StringP("output", "o", "table", "Output format (table|csv)")
+
+	inspectCmd.AddCommand(inspectHardenedImagesCmd)
+	inspectCmd.AddCommand(inspectRKE2ChartsCmd)
+	inspectCmd.AddCommand(inspectPlatformsCmd)
+
+	inspectRKE2ChartsIndex = inspectRKE2ChartsCmd.Flags().String("index", rke2.ChartsIndexURL, "URL of the Helm repository index the charts are published to")
+	inspectRKE2ChartsSkipPackaged = inspectRKE2ChartsCmd.Flags().Bool("skip-packaged", false, "only check the index, without downloading the chart archives")
+
+	inspectPlatformsCmd.Flags().StringP("output", "o", "table", "Output format (table|csv)")
+	inspectPlatformsRegistries = inspectPlatformsCmd.Flags().StringSlice("registry", []string{}, "additional registries to check, e.g. mirrors")
+	inspectPlatformsIncomplete = inspectPlatformsCmd.Flags().Bool("incomplete", false, "only list the images missing a platform")
 }
@@ -3,6 +3,7 @@ package cmd
 import (
 	"bytes"
 	"context"
+	"errors"
 	"io/fs"
 	"os"
 	"testing"
@@ -10,6 +11,7 @@ import (
 
 	"github.com/google/go-containerregistry/pkg/name"
 	reg "github.com/rancher/ecm-distro-tools/registry"
+	"github.com/rancher/ecm-distro-tools/release/platforms"
 	"github.com/rancher/ecm-distro-tools/release/rke2"
 )
 
@@ -104,3 +106,83 @@ func mustParseRef(s string) name.Reference {
 	}
 	return ref
 }
+
+func TestHardenedImages(t *testing.T) {
+	amd64 := reg.Platform{OS: "linux", Architecture: "amd64"}
+	arm64 := reg.Platform{OS: "linux", Architecture: "arm64"}
+
+	client := &mockRegistryClient{images: map[string]reg.Image{
+		"rancher/hardened-build-base:v1.21.6b1":                  {Exists: true, Platforms: map[reg.Platform]bool{amd64: true, arm64: true}},
+		"rancher/hardened-containerd:v1.7.11-k3s2-build20231208": {Exists: true, Platforms: map[reg.Platform]bool{amd64: true}},
+	}}
+
+	var refs []name.Reference
+	for _, image := range []string{"rancher/hardened-build-base:v1.21.6b1", "rancher/hardened-containerd:v1.7.11-k3s2-build20231208", "rancher/hardened-etcd:v3.5.12-build20240215"} {
+		ref, err := name.ParseReference(image)
+		if err != nil {
+			t.Fatal(err)
+		}
+		refs = append(refs, ref)
+	}
+
+	results, err := rke2.CheckHardenedImages(context.Background(), refs, map[string]rke2.RegistryClient{ossRegistry: client}, rke2.HardenedPlatforms)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := hardenedImagesTable(&buf, results); err == nil {
+		t.Error("expected an error for the missing images")
+	}
+
+	expected := "IMAGE                                                   REGISTRY   MISSING\n" +
+		"rancher/hardened-build-base:v1.21.6b1                   -          ✓\n" +
+		"rancher/hardened-containerd:v1.7.11-k3s2-build20231208  docker.io  linux/arm64\n" +
+		"rancher/hardened-etcd:v3.5.12-build20240215             docker.io  linux/amd64,linux/arm64\n"
+	if buf.String() != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, buf.String())
+	}
+}
+
+func TestPlatformsTable(t *testing.T) {
+	amd64 := reg.Platform{OS: "linux", Architecture: "amd64"}
+	arm64 := reg.Platform{OS: "linux", Architecture: "arm64"}
+
+	report := platforms.Report{
+		Registries: []string{"docker.io", "registry.example.com"},
+		Platforms:  []reg.Platform{amd64, arm64},
+		Images: []platforms.Availability{
+			{
+				ExpectedImage: platforms.ExpectedImage{Reference: mustParseRef("rancher/hardened-etcd:v3.5.12-build20240215"), Platforms: []reg.Platform{amd64, arm64}},
+				Images: map[string]reg.Image{
+					"docker.io":            {Exists: true, Platforms: map[reg.Platform]bool{amd64: true}},
+					"registry.example.com": {Exists: true, Platforms: map[reg.Platform]bool{amd64: true, arm64: true}},
+				},
+			},
+			{
+				ExpectedImage: platforms.ExpectedImage{Reference: mustParseRef("rancher/rke2-cloud-provider:v1.29.2-build20240215"), Platforms: []reg.Platform{amd64}},
+				Images: map[string]reg.Image{
+					"docker.io": {Exists: true, Platforms: map[reg.Platform]bool{amd64: true}},
+				},
+				Errs: map[string]error{"registry.example.com": errors.New("unauthorized")},
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := platformsTable(&buf, report, false); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "2 incomplete images\n" +
+		"IMAGE                                              REGISTRY              linux/amd64  linux/arm64\n" +
+		"rancher/hardened-etcd:v3.5.12-build20240215        docker.io             ✓            ✗\n" +
+		"rancher/hardened-etcd:v3.5.12-build20240215        registry.example.com  ✓            ✓\n" +
+		"rancher/rke2-cloud-provider:v1.29.2-build20240215  docker.io             ✓            -\n" +
+		"rancher/rke2-cloud-provider:v1.29.2-build20240215  registry.example.com  ?            ?\n" +
+		"\nfailed lookups:\n" +
+		"rancher/rke2-cloud-provider:v1.29.2-build20240215 in registry.example.com: unauthorized\n"
+	if buf.String() != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, buf.String())
+	}
+}
@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	ecmHTTP "github.com/rancher/ecm-distro-tools/http"
+	"github.com/rancher/ecm-distro-tools/release/mirror"
+	"github.com/rancher/ecm-distro-tools/repository"
+	"github.com/spf13/cobra"
+)
+
+// mirrorCmd represents the mirror command
+var mirrorCmd = &cobra.Command{
+	Use:   "mirror [k3s|rke2] [version]",
+	Short: "Mirror release artifacts to an Artifactory or Nexus repository",
+	Long: `Upload all assets of a release, binaries and checksums, to the Artifactory generic or Nexus raw repository of
+the mirror config under <product>/<version>/, e.g. for the air-gap bundles of customers. Assets already mirrored with
+the same sha256 digest are skipped, and the downloads are verified against the checksum files of the release.`,
+	Example: "release mirror rke2 v1.29.2+rke2r1",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) != 2 {
+			return errors.New("expected two arguments: [k3s|rke2] [version]")
+		}
+		product, tag := args[0], args[1]
+
+		owner, ok := repoToOwner[product]
+		if !ok || product == "rancher" {
+			return errors.New("invalid product: " + product + ", expected one of: k3s, rke2")
+		}
+		if product == "rke2" {
+			owner = rootConfig.RKE2.RepoOwner()
+		}
+		if rootConfig.Mirror == nil {
+			return errors.New("missing mirror config")
+		}
+
+		httpClient := ecmHTTP.NewClient(time.Hour)
+		repo, err := mirror.New(*rootConfig.Mirror, &httpClient)
+		if err != nil {
+			return err
+		}
+
+		ctx := context.Background()
+		gh := repository.NewGithub(ctx, rootConfig.Auth.GithubToken)
+
+		result, err := mirror.NewMirror(repo, dryRun).MirrorRelease(ctx, gh, owner, product, product, tag)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("mirrored %d files, %d already mirrored\n", len(result.Uploaded), len(result.Skipped))
+
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(mirrorCmd)
+}
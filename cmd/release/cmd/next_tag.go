@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/rancher/ecm-distro-tools/repository"
+	"github.com/rancher/ecm-distro-tools/version"
+	"github.com/spf13/cobra"
+)
+
+var (
+	nextTagRepo     *string
+	nextTagLine     *string
+	nextTagRC       *bool
+	nextTagRevision *bool
+)
+
+var nextTagProducts = map[string]string{
+	"k3s":  version.K3s,
+	"rke2": version.RKE2,
+}
+
+// nextTagCmd represents the next-tag command
+var nextTagCmd = &cobra.Command{
+	Use:     "next-tag",
+	Short:   "Suggest the next tag for a release line",
+	Long:    `Inspect the existing tags of a release line and suggest the next rc or GA tag, with the correct k3sN/rke2rN revision.`,
+	Example: "release next-tag --repo rke2 --line v1.27 --rc",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		product, ok := nextTagProducts[*nextTagRepo]
+		if !ok {
+			return errors.New("invalid repo: " + *nextTagRepo + ", expected one of: k3s, rke2")
+		}
+
+		ctx := context.Background()
+		client := repository.NewGithub(ctx, rootConfig.Auth.GithubToken)
+
+		tags, err := repository.ListAllTags(ctx, client, repoToOwner[*nextTagRepo], *nextTagRepo)
+		if err != nil {
+			return err
+		}
+
+		names := make([]string, len(tags))
+		for i, tag := range tags {
+			names[i] = tag.GetName()
+		}
+
+		next, err := version.Next(names, product, *nextTagLine, *nextTagRC, *nextTagRevision)
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(next.String())
+
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(nextTagCmd)
+
+	nextTagRepo = nextTagCmd.Flags().StringP("repo", "r", "", "repository (k3s|rke2)")
+	nextTagLine = nextTagCmd.Flags().StringP("line", "l", "", "release line, e.g. v1.27")
+	nextTagRC = nextTagCmd.Flags().Bool("rc", false, "suggest a release candidate tag")
+	nextTagRevision = nextTagCmd.Flags().Bool("revision", false, "bump the k3sN/rke2rN revision instead of the patch version")
+
+	if err := nextTagCmd.MarkFlagRequired("repo"); err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+	if err := nextTagCmd.MarkFlagRequired("line"); err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+}
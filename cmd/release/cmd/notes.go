@@ -0,0 +1,142 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/rancher/ecm-distro-tools/release"
+	"github.com/rancher/ecm-distro-tools/release/notesapi"
+	"github.com/rancher/ecm-distro-tools/release/preview"
+	"github.com/rancher/ecm-distro-tools/release/slackbot"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// notesAPITokenEnv is the environment variable holding the bearer token
+	// the clients of the notes API must send.
+	notesAPITokenEnv = "NOTES_API_TOKEN"
+	// slackSigningSecretEnv is the environment variable holding the signing
+	// secret of the Slack app whose slash command the API answers.
+	slackSigningSecretEnv = "SLACK_SIGNING_SECRET"
+)
+
+var (
+	notesServeAddr        *string
+	notesAPIAddr          *string
+	notesAPIMaxConcurrent *int
+)
+
+// notesCmd represents the notes command
+var notesCmd = &cobra.Command{
+	Use:   "notes",
+	Short: "Release notes utilities",
+}
+
+var notesServeSubCmd = &cobra.Command{
+	Use:   "serve [files]",
+	Short: "Preview release notes rendered to HTML",
+	Long: `Serve an HTML preview of the release notes made of the given files, in order. Files can be markdown drafts
+and snippets, or JSON snapshots written with the --snapshot flag of the release-notes commands. The page reloads
+itself whenever any of the files changes.`,
+	Example: "release notes serve v1.29.2+rke2r1.md important-note.md --addr localhost:8080",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) < 1 {
+			return errors.New("expected at least one argument: [files]")
+		}
+
+		server := preview.NewServer(args)
+
+		fmt.Println("serving release notes preview at http://" + *notesServeAddr)
+
+		return http.ListenAndServe(*notesServeAddr, server.Handler())
+	},
+}
+
+var notesAPISubCmd = &cobra.Command{
+	Use:   "api",
+	Short: "Serve release notes generation over HTTP",
+	Long: `Serve an HTTP API generating release notes, so bots and web UIs can generate them without the release binary.
+POST /notes with a JSON body like {"repo": "rke2", "milestone": "v1.29.2+rke2r1", "prevMilestone": "v1.29.1+rke2r1"}
+returns the notes as markdown, or as JSON along with their snapshot with ?format=json. The optional fields are
+pullRequests, fromImages, locale, publishDate and eolDate, as the flags of the release-notes commands. GET /repos lists
+the supported repos.
+
+If the ` + notesAPITokenEnv + ` environment variable is set, clients must send it as a bearer token.
+
+If the ` + slackSigningSecretEnv + ` environment variable is set, POST /slack/commands answers the /release Slack slash
+command of the app it's the signing secret of: /release status [tag]... returns the status of k3s and rke2 releases
+as the status command, and /release assets [repo] [tag] the assets breaking the asset rules as verify-assets.`,
+	Example: "NOTES_API_TOKEN=secret release notes api --addr :8080",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		owners := map[string]string{
+			"k3s":       "k3s-io",
+			"rke2":      "rancher",
+			"ui":        "rancher",
+			"dashboard": "rancher",
+			"cli":       "rancher",
+		}
+		for _, repo := range release.AncillaryRepos() {
+			owner, err := release.AncillaryOwner(repo)
+			if err != nil {
+				return err
+			}
+			owners[repo] = owner
+		}
+
+		token := os.Getenv(notesAPITokenEnv)
+		if token == "" {
+			fmt.Println(notesAPITokenEnv + " isn't set, the API doesn't require authentication")
+		}
+
+		client := notesClient(context.Background())
+		server := notesapi.NewServer(client, notesapi.Options{
+			Owners:        owners,
+			Token:         token,
+			Locale:        rootConfig.Locale.For,
+			MaxConcurrent: *notesAPIMaxConcurrent,
+		})
+
+		mux := http.NewServeMux()
+		mux.Handle("/", server.Handler())
+
+		if secret := os.Getenv(slackSigningSecretEnv); secret != "" {
+			slackOwners := map[string]string{
+				"k3s":  "k3s-io",
+				"rke2": rootConfig.RKE2.RepoOwner(),
+			}
+			for _, repo := range release.AncillaryRepos() {
+				slackOwners[repo] = owners[repo]
+			}
+			mux.Handle("/slack/commands", slackbot.New(slackbot.NewChecker(client), slackbot.Options{
+				SigningSecret: secret,
+				Owners:        slackOwners,
+			}))
+			fmt.Println("answering the Slack slash command at /slack/commands")
+		}
+
+		fmt.Println("serving the release notes API at " + *notesAPIAddr)
+
+		httpServer := &http.Server{
+			Addr:              *notesAPIAddr,
+			Handler:           mux,
+			ReadHeaderTimeout: 10 * time.Second,
+		}
+
+		return httpServer.ListenAndServe()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(notesCmd)
+	notesCmd.AddCommand(notesServeSubCmd)
+	notesCmd.AddCommand(notesAPISubCmd)
+
+	notesServeAddr = notesServeSubCmd.Flags().StringP("addr", "a", "localhost:8080", "address to listen on")
+
+	notesAPIAddr = notesAPISubCmd.Flags().StringP("addr", "a", "localhost:8080", "address to listen on")
+	notesAPIMaxConcurrent = notesAPISubCmd.Flags().Int("max-concurrent", 4, "maximum number of release notes generated at the same time, 0 for no limit")
+}
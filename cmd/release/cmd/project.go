@@ -0,0 +1,181 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/google/go-github/v39/github"
+	"github.com/rancher/ecm-distro-tools/cmd/release/config"
+	"github.com/rancher/ecm-distro-tools/release/project"
+	"github.com/rancher/ecm-distro-tools/repository"
+	"github.com/spf13/cobra"
+)
+
+var (
+	projectOrg       *string
+	projectNumber    *int
+	projectStatus    *string
+	projectMilestone *string
+)
+
+// projectCmd represents the project command
+var projectCmd = &cobra.Command{
+	Use:   "project",
+	Short: "Manage the release cycle project board",
+}
+
+var projectAddSubCmd = &cobra.Command{
+	Use:     "add [k3s|rke2|rancher] [milestone]",
+	Short:   "Add the issues and pull requests of a milestone to the project board",
+	Example: "release project add rke2 v1.29.3+rke2r1 --number 42 --status Todo",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) < 2 {
+			return errors.New("expected at least two arguments: [k3s|rke2|rancher] [milestone]")
+		}
+		owner, ok := repoToOwner[args[0]]
+		if !ok {
+			return errors.New("invalid repo: " + args[0])
+		}
+
+		ctx := context.Background()
+		client := githubClient(ctx, config.OperationProject)
+
+		board, err := project.GetBoard(ctx, client, *projectOrg, *projectNumber)
+		if err != nil {
+			return err
+		}
+
+		issues, err := project.MilestoneIssues(ctx, client, owner, args[0], args[1])
+		if err != nil {
+			return err
+		}
+
+		if dryRun {
+			for _, issue := range issues {
+				fmt.Println("dry run, would add " + issue.GetHTMLURL() + " to " + board.Title)
+			}
+			return nil
+		}
+
+		if err := project.AddIssues(ctx, client, board, issues, *projectStatus); err != nil {
+			return err
+		}
+
+		fmt.Printf("added %d items to %s\n", len(issues), board.Title)
+
+		return nil
+	},
+}
+
+var projectMoveSubCmd = &cobra.Command{
+	Use:   "move [k3s|rke2|rancher] [issue or pull request numbers]",
+	Short: "Move issues and pull requests to a column of the project board",
+	Long: `Move the given issues and pull requests, or all of a milestone's when --milestone is set, to the given column.
+Items that aren't on the board yet are added to it. This is meant to be run by the automation as each release step completes.`,
+	Example: "release project move rke2 5432 5433 --number 42 --status Done",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) < 1 {
+			return errors.New("expected at least one argument: [k3s|rke2|rancher]")
+		}
+		if len(args) < 2 && *projectMilestone == "" {
+			return errors.New("expected issue or pull request numbers, or --milestone")
+		}
+		repo := args[0]
+		owner, ok := repoToOwner[repo]
+		if !ok {
+			return errors.New("invalid repo: " + repo)
+		}
+
+		ctx := context.Background()
+		client := githubClient(ctx, config.OperationProject)
+
+		board, err := project.GetBoard(ctx, client, *projectOrg, *projectNumber)
+		if err != nil {
+			return err
+		}
+
+		var issues []*github.Issue
+		if *projectMilestone != "" {
+			if issues, err = project.MilestoneIssues(ctx, client, owner, repo, *projectMilestone); err != nil {
+				return err
+			}
+		}
+		for _, arg := range args[1:] {
+			number, err := strconv.Atoi(arg)
+			if err != nil {
+				return errors.New("invalid issue or pull request number: " + arg)
+			}
+			issue, _, err := client.Issues.Get(ctx, owner, repo, number)
+			if err != nil {
+				return repository.WrapGithubError(err, owner, repo, "#"+arg)
+			}
+			issues = append(issues, issue)
+		}
+
+		if dryRun {
+			for _, issue := range issues {
+				fmt.Println("dry run, would move " + issue.GetHTMLURL() + " to " + *projectStatus)
+			}
+			return nil
+		}
+
+		if err := project.AddIssues(ctx, client, board, issues, *projectStatus); err != nil {
+			return err
+		}
+
+		fmt.Printf("moved %d items to %s\n", len(issues), *projectStatus)
+
+		return nil
+	},
+}
+
+var projectReportSubCmd = &cobra.Command{
+	Use:     "report",
+	Short:   "Report the state of the project board",
+	Example: "release project report --number 42",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+		client := githubClient(ctx, config.OperationProject)
+
+		board, err := project.GetBoard(ctx, client, *projectOrg, *projectNumber)
+		if err != nil {
+			return err
+		}
+
+		items, err := project.Items(ctx, client, board)
+		if err != nil {
+			return err
+		}
+
+		project.WriteReport(os.Stdout, board, items)
+
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(projectCmd)
+
+	projectCmd.AddCommand(projectAddSubCmd)
+	projectCmd.AddCommand(projectMoveSubCmd)
+	projectCmd.AddCommand(projectReportSubCmd)
+
+	projectOrg = projectCmd.PersistentFlags().StringP("org", "o", "rancher", "organization that owns the project board")
+	projectNumber = projectCmd.PersistentFlags().IntP("number", "n", 0, "project board number")
+
+	projectStatus = projectAddSubCmd.Flags().StringP("status", "s", "", "column to add the items to, e.g. Todo")
+	projectMoveSubCmd.Flags().StringVarP(projectStatus, "status", "s", "", "column to move the items to, e.g. Done")
+	projectMilestone = projectMoveSubCmd.Flags().StringP("milestone", "m", "", "move all the issues and pull requests of the milestone")
+
+	if err := projectCmd.MarkPersistentFlagRequired("number"); err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+	if err := projectMoveSubCmd.MarkFlagRequired("status"); err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+}
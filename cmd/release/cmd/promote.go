@@ -0,0 +1,118 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	reg "github.com/rancher/ecm-distro-tools/registry"
+	"github.com/rancher/ecm-distro-tools/release"
+	"github.com/rancher/ecm-distro-tools/release/rke2"
+	"github.com/rancher/ecm-distro-tools/repository"
+	"github.com/spf13/cobra"
+)
+
+var (
+	promoteFrom *string
+	promoteTo   *string
+)
+
+// promoteCmd represents the promote command
+var promoteCmd = &cobra.Command{
+	Use:   "promote",
+	Short: "Promote release images between registries",
+}
+
+var rke2PromoteSubCmd = &cobra.Command{
+	Use:   "rke2 [version]",
+	Short: "Promote the images of an rke2 release from staging to production",
+	Long: `Copy the images listed in an rke2 release from the staging registry/org to the production one
+through the registry API, preserving digests and manifest lists, and verify the copies afterwards.
+Use --dry-run to only print the promotion plan.`,
+	Example: "release promote rke2 v1.29.2+rke2r1 --from registry.example.com/rancher-staging --to docker.io/rancher",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) < 1 {
+			return errors.New("expected at least one argument: [version]")
+		}
+
+		ctx := context.Background()
+		gh := repository.NewGithub(ctx, rootConfig.Auth.GithubToken)
+		filesystem, err := release.NewFS(ctx, gh, "rancher", "rke2", args[0])
+		if err != nil {
+			return err
+		}
+
+		images, err := rke2.NewReleaseInspector(filesystem, nil, nil, debug).ReleaseImages()
+		if err != nil {
+			return err
+		}
+
+		promotions := make([]reg.Promotion, 0, len(images))
+		for _, image := range images {
+			src, err := reg.Retarget(*promoteFrom, image.Reference)
+			if err != nil {
+				return err
+			}
+			dst, err := reg.Retarget(*promoteTo, image.Reference)
+			if err != nil {
+				return err
+			}
+
+			p, err := reg.PlanPromotion(ctx, src, dst)
+			if err != nil {
+				return err
+			}
+			promotions = append(promotions, p)
+
+			action := "copy"
+			if p.Exists {
+				action = "skip"
+			}
+			fmt.Printf("%s: %s@%s -> %s\n", action, p.Source, p.Digest, p.Destination)
+		}
+
+		if dryRun {
+			fmt.Println("dry run, skipping image promotion")
+			return nil
+		}
+
+		for _, p := range promotions {
+			if err := reg.Promote(ctx, p); err != nil {
+				return errors.New("failed to promote " + p.Source.String() + ": " + err.Error())
+			}
+		}
+
+		var failed int
+		for _, p := range promotions {
+			if err := reg.VerifyPromotion(ctx, p); err != nil {
+				fmt.Println(err)
+				failed++
+			}
+		}
+		if failed > 0 {
+			return fmt.Errorf("verification failed for %d of %d images", failed, len(promotions))
+		}
+
+		fmt.Printf("promoted and verified %d images\n", len(promotions))
+
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(promoteCmd)
+	promoteCmd.AddCommand(rke2PromoteSubCmd)
+
+	promoteFrom = rke2PromoteSubCmd.Flags().StringP("from", "f", "", "staging registry and org, e.g. registry.example.com/rancher-staging")
+	promoteTo = rke2PromoteSubCmd.Flags().StringP("to", "t", "", "production registry and org, e.g. docker.io/rancher")
+
+	if err := rke2PromoteSubCmd.MarkFlagRequired("from"); err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+	if err := rke2PromoteSubCmd.MarkFlagRequired("to"); err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+}
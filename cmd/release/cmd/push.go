@@ -5,7 +5,12 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
 
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/rancher/ecm-distro-tools/cmd/release/config"
+	reg "github.com/rancher/ecm-distro-tools/registry"
 	"github.com/rancher/ecm-distro-tools/release/charts"
 	"github.com/rancher/ecm-distro-tools/release/k3s"
 	"github.com/rancher/ecm-distro-tools/repository"
@@ -35,7 +40,7 @@ var pushK3sTagsCmd = &cobra.Command{
 			return NewVersionNotFoundError(version, "k3s")
 		}
 		ctx := context.Background()
-		ghClient := repository.NewGithub(ctx, rootConfig.Auth.GithubToken)
+		ghClient := githubClient(ctx, config.OperationTag)
 		return k3s.PushTags(ghClient, &k3sRelease, rootConfig.User, rootConfig.Auth.SSHKeyPath)
 	},
 }
@@ -80,7 +85,7 @@ var pushChartsCmd = &cobra.Command{
 			return err
 		}
 
-		token := rootConfig.Auth.GithubToken
+		token := rootConfig.Auth.GithubTokenFor(config.OperationPullRequest)
 
 		ctx := context.Background()
 		ghc := repository.NewGithub(ctx, token)
@@ -95,9 +100,61 @@ var pushChartsCmd = &cobra.Command{
 	},
 }
 
+var pushArtifactsRepository *string
+
+var pushArtifactsCmd = &cobra.Command{
+	Use:   "artifacts [product] [tag] [files]",
+	Short: "Push release notes, reports and manifests as an OCI artifact",
+	Long: `Push the given files, e.g. the release notes, verification reports and version manifests of a release, as an
+OCI artifact tagged with the release tag, so downstream automation can fetch them without GitHub access. The artifact
+is pushed to <repository>/<product>:<tag>, with + replaced by - in the tag, and can be pulled with ORAS.`,
+	Example: "release push artifacts rke2 v1.29.2+rke2r1 release-notes.md verification.json --repository registry.rancher.com/rancher/release-artifacts",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) < 3 {
+			return errors.New("expected at least three arguments: [product] [tag] [files]")
+		}
+		product, tag, paths := args[0], args[1], args[2:]
+
+		ref, err := name.NewTag(*pushArtifactsRepository + "/" + product + ":" + strings.ReplaceAll(tag, "+", "-"))
+		if err != nil {
+			return err
+		}
+
+		files := make([]reg.ArtifactFile, 0, len(paths))
+		for _, path := range paths {
+			content, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			files = append(files, reg.ArtifactFile{Name: filepath.Base(path), Content: content})
+		}
+
+		if dryRun {
+			fmt.Println("dry run, would push " + strings.Join(paths, ", ") + " to " + ref.String())
+			return nil
+		}
+
+		digest, err := reg.PushArtifact(context.Background(), ref, files, map[string]string{reg.VersionLabel: tag})
+		if err != nil {
+			return err
+		}
+
+		fmt.Println("pushed " + ref.String() + "@" + digest)
+
+		return nil
+	},
+}
+
 func init() {
 	rootCmd.AddCommand(pushCmd)
 	pushCmd.AddCommand(pushK3sCmd)
 	pushCmd.AddCommand(pushChartsCmd)
+	pushCmd.AddCommand(pushArtifactsCmd)
 	pushK3sCmd.AddCommand(pushK3sTagsCmd)
+
+	pushArtifactsRepository = pushArtifactsCmd.Flags().StringP("repository", "r", "", "registry and org the artifacts are pushed to, e.g. registry.rancher.com/rancher/release-artifacts")
+	if err := pushArtifactsCmd.MarkFlagRequired("repository"); err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
 }
@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/rancher/ecm-distro-tools/cmd/release/config"
+	"github.com/rancher/ecm-distro-tools/release/audit"
+	"github.com/rancher/ecm-distro-tools/release/rollback"
+	"github.com/spf13/cobra"
+)
+
+var (
+	rollbackPrevious *string
+	rollbackDraft    *bool
+	rollbackWebhook  *string
+	rollbackHead     *string
+	rollbackAuditLog *string
+)
+
+// rollbackCmd represents the rollback command
+var rollbackCmd = &cobra.Command{
+	Use:   "rollback [k3s|rke2] [version]",
+	Short: "Roll back a botched release",
+	Long: `Mark a botched release as prerelease, or draft, so the install scripts stop picking it, open a pull request
+reverting the channels pointing to it to the previous version, notify a Slack compatible webhook and record the
+rollback in the audit log. Every step is attempted even if a previous one failed.`,
+	Example: "release rollback rke2 v1.29.4+rke2r1 --to v1.29.3+rke2r1 --webhook https://hooks.slack.com/services/...",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) < 2 {
+			return errors.New("expected at least two arguments: [k3s|rke2] [version]")
+		}
+		repo, version := args[0], args[1]
+
+		owner, ok := repoToOwner[repo]
+		if !ok || repo == "rancher" {
+			return errors.New("invalid repo: " + repo + ", expected one of: k3s, rke2")
+		}
+		if repo == "rke2" {
+			owner = rootConfig.RKE2.RepoOwner()
+		}
+
+		ctx := context.Background()
+		client := githubClient(ctx, config.OperationTag)
+
+		result, err := rollback.Rollback(ctx, client, rollback.Options{
+			Owner:      owner,
+			Repo:       repo,
+			Version:    version,
+			Previous:   *rollbackPrevious,
+			Draft:      *rollbackDraft,
+			WebhookURL: *rollbackWebhook,
+			Head:       *rollbackHead,
+			DryRun:     dryRun,
+		})
+		if err != nil {
+			return err
+		}
+
+		fmt.Print(result.Summary())
+
+		entry := audit.Entry{
+			Action:  "rollback",
+			Product: repo,
+			Version: version,
+			DryRun:  dryRun,
+			Details: map[string]string{"previous": *rollbackPrevious},
+		}
+		if rootConfig.User != nil {
+			entry.User = rootConfig.User.GithubUsername
+		}
+		for _, step := range result.Steps {
+			if step.Err != nil {
+				entry.Errors = append(entry.Errors, step.Name+": "+step.Err.Error())
+				continue
+			}
+			entry.Details[step.Name] = step.Detail
+		}
+		if err := audit.Record(*rollbackAuditLog, entry); err != nil {
+			fmt.Println("failed to record the rollback in the audit log: " + err.Error())
+		}
+
+		return result.Err()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(rollbackCmd)
+
+	rollbackPrevious = rollbackCmd.Flags().StringP("to", "t", "", "previous version to revert the channels to")
+	rollbackDraft = rollbackCmd.Flags().Bool("draft", false, "mark the release as draft instead of prerelease")
+	rollbackWebhook = rollbackCmd.Flags().StringP("webhook", "w", "", "Slack compatible webhook to notify")
+	rollbackHead = rollbackCmd.Flags().String("head", "", "fork to push the channels change to, defaults to the product repo")
+	rollbackAuditLog = rollbackCmd.Flags().String("audit-log", audit.DefaultPath, "path of the audit log")
+
+	if err := rollbackCmd.MarkFlagRequired("to"); err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+}
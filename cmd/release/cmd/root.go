@@ -1,12 +1,26 @@
 package cmd
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
+	"net/http"
 	"os"
 	"strings"
+	"time"
 
+	"github.com/google/go-github/v39/github"
 	"github.com/rancher/ecm-distro-tools/cmd/release/config"
+	ecmHTTP "github.com/rancher/ecm-distro-tools/http"
+	"github.com/rancher/ecm-distro-tools/release/backports"
+	"github.com/rancher/ecm-distro-tools/release/failure"
+	"github.com/rancher/ecm-distro-tools/release/rehearsal"
+	"github.com/rancher/ecm-distro-tools/release/watchdog"
+	"github.com/rancher/ecm-distro-tools/repository"
+	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 )
 
 var (
@@ -16,6 +30,22 @@ var (
 	verbose      bool
 	configFile   string
 	stringConfig string
+
+	reportFailures bool
+	reportRepo     string
+	failureLogs    failure.Buffer
+
+	cloneDepth  int
+	cloneFilter string
+	cloneSparse []string
+
+	watchdogTimeout    time.Duration
+	watchdogInactivity time.Duration
+
+	rehearse bool
+
+	recordPath string
+	recorder   *ecmHTTP.Recorder
 )
 
 // rootCmd represents the base command when called without any subcommands
@@ -24,18 +54,125 @@ var rootCmd = &cobra.Command{
 	Short:         "Central command to perform RKE2, K3s, Rancher and Chart Releases",
 	SilenceUsage:  true,
 	SilenceErrors: true,
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		ecmHTTP.DefaultTransport.SetUserAgent(ecmHTTP.DefaultUserAgent + "/" + cmd.Root().Name() + " " + cmd.Root().Version)
+		http.DefaultClient.Transport = ecmHTTP.DefaultTransport
+		if debug {
+			ecmHTTP.DefaultTransport.SetLog(os.Stderr)
+		}
+		if reportFailures {
+			logrus.SetOutput(io.MultiWriter(logrus.StandardLogger().Out, &failureLogs))
+		}
+		if watchdogTimeout > 0 {
+			watchdog.Default.SetDeadline(time.Now().Add(watchdogTimeout))
+		}
+		watchdog.Default.SetInactivity(watchdogInactivity)
+	},
+	PersistentPostRun: func(cmd *cobra.Command, args []string) {
+		if debug {
+			ecmHTTP.DefaultTransport.WriteStats(os.Stderr)
+		}
+	},
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() {
 	cobra.OnInitialize(initConfig)
-	if err := rootCmd.Execute(); err != nil {
+	cmd, err := rootCmd.ExecuteC()
+	if recorder != nil {
+		if err := recorder.Save(recordPath, strings.Join(os.Args, " ")); err != nil {
+			fmt.Println("failed to save the recording: " + err.Error())
+		} else {
+			fmt.Fprintln(os.Stderr, "recorded the HTTP requests to "+recordPath)
+		}
+	}
+	if err != nil {
 		fmt.Println("error: ", err)
+		if reportFailures {
+			if err := reportFailure(cmd, err); err != nil {
+				fmt.Println("failed to report failure: " + err.Error())
+			}
+		}
 		os.Exit(1)
 	}
 }
 
+// reportFailure opens or comments on the tracking issue of the failed
+// command with its sanitized inputs and logs.
+func reportFailure(cmd *cobra.Command, cmdErr error) error {
+	owner, repo, ok := strings.Cut(reportRepo, "/")
+	if !ok {
+		return errors.New("invalid report repo: " + reportRepo + ", expected owner/repo")
+	}
+
+	report := failure.Report{
+		Command: cmd.CommandPath(),
+		Args:    cmd.Flags().Args(),
+		Flags:   make(map[string]string),
+		Err:     cmdErr,
+		Logs:    failureLogs.String(),
+		RunURL:  failure.RunURL(os.Getenv),
+	}
+	cmd.Flags().Visit(func(f *pflag.Flag) {
+		report.Flags[f.Name] = f.Value.String()
+	})
+
+	secrets := runSecrets(rootConfig)
+	token := os.Getenv("GITHUB_TOKEN")
+	if rootConfig != nil && rootConfig.Auth != nil && rootConfig.Auth.GithubTokenFor(config.OperationIssue) != "" {
+		token = rootConfig.Auth.GithubTokenFor(config.OperationIssue)
+	}
+
+	if dryRun {
+		body, err := failure.Body(report, secrets)
+		if err != nil {
+			return err
+		}
+		fmt.Println("dry run, would report failure to " + reportRepo + ": " + failure.Title(report.Command))
+		fmt.Println(body)
+		return nil
+	}
+
+	ctx := context.Background()
+	client := repository.NewGithub(ctx, token)
+
+	url, err := failure.File(ctx, client, owner, repo, report, secrets)
+	if err != nil {
+		return err
+	}
+	fmt.Println("reported failure: " + url)
+
+	return nil
+}
+
+// runSecrets returns the secrets of the run, from the environment and the
+// given config, to redact from what's reported or recorded.
+func runSecrets(conf *config.Config) []string {
+	secrets := failure.EnvSecrets(os.Environ())
+	if conf != nil && conf.Auth != nil {
+		auth := conf.Auth
+		secrets = append(secrets, auth.GithubToken, auth.AWSAccessKeyID, auth.AWSSecretAccessKey, auth.AWSSessionToken)
+		for _, identity := range auth.Identities {
+			secrets = append(secrets, identity.GithubToken)
+		}
+	}
+	if conf != nil && conf.Mirror != nil {
+		secrets = append(secrets, conf.Mirror.Password, conf.Mirror.Token)
+	}
+	if conf != nil && conf.OBS != nil {
+		secrets = append(secrets, conf.OBS.Password)
+	}
+
+	return secrets
+}
+
+// githubClient returns a GitHub client authenticated as the identity
+// configured for the given operation, e.g. config.OperationTag.
+func githubClient(ctx context.Context, operation string) *github.Client {
+	return repository.NewGithub(ctx, rootConfig.Auth.GithubTokenFor(operation))
+}
+
 func SetVersion(version string) {
 	rootCmd.Version = version
 }
@@ -46,10 +183,51 @@ func init() {
 	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "V", false, "Verbose output")
 	rootCmd.PersistentFlags().StringVarP(&configFile, "config-file", "c", "$HOME/.ecm-distro-tools/config.json", "Path for the config.json file")
 	rootCmd.PersistentFlags().StringVarP(&stringConfig, "config", "C", "", "JSON config string")
+	rootCmd.PersistentFlags().BoolVar(&reportFailures, "report-failures", false, "Open or comment on a tracking issue with the sanitized inputs and logs if the command fails")
+	rootCmd.PersistentFlags().StringVar(&reportRepo, "report-repo", failure.DefaultOwner+"/"+failure.DefaultRepo, "owner/repo to report failures to")
+	rootCmd.PersistentFlags().IntVar(&cloneDepth, "clone-depth", 0, "Number of commits to clone in clone based flows, 0 clones the full history")
+	rootCmd.PersistentFlags().StringVar(&cloneFilter, "clone-filter", "", "Partial clone filter of clone based flows, e.g. blob:none, defaults to the repository's")
+	rootCmd.PersistentFlags().StringSliceVar(&cloneSparse, "sparse-checkout", []string{}, "Directories to check out in clone based flows, defaults to the whole tree")
+	rootCmd.PersistentFlags().DurationVar(&watchdogTimeout, "watchdog-timeout", 0, "Abort the waits for builds, releases and images still pending after this long, listing what was pending, 0 disables it")
+	rootCmd.PersistentFlags().DurationVar(&watchdogInactivity, "watchdog-inactivity", 0, "Abort the waits whose status didn't change for this long, 0 disables it")
+	rootCmd.PersistentFlags().StringVar(&recordPath, "record", "", "Record the HTTP requests of the run and their responses, with the secrets redacted, to this file to attach to bug reports")
+	rootCmd.PersistentFlags().BoolVar(&rehearse, "rehearsal", false, "Run against the sandbox organizations and repositories of the rehearsal config, creating real tags, pull requests and releases there")
+}
+
+// gitURL returns the given git URL of a production repository, or of its
+// sandbox with --rehearsal, so git clones and pushes don't touch
+// production during a rehearsal.
+func gitURL(url string) string {
+	if rehearse && rootConfig.Rehearsal != nil {
+		return rootConfig.Rehearsal.GitURL(url)
+	}
+
+	return url
+}
+
+// cloneOptions returns the clone options of the given repository, replacing
+// its defaults with the clone flags that were set.
+func cloneOptions(repo string) repository.CloneOptions {
+	opts := repository.DefaultCloneOptions(repo)
+	flags := rootCmd.PersistentFlags()
+	if flags.Changed("clone-depth") {
+		opts.Depth = cloneDepth
+	}
+	if flags.Changed("clone-filter") {
+		opts.Filter = cloneFilter
+	}
+	if flags.Changed("sparse-checkout") {
+		opts.Sparse = cloneSparse
+	}
+
+	return opts
 }
 
 func initConfig() {
 	if len(os.Args) >= 2 {
+		if os.Args[1] == "self-update" {
+			return
+		}
 		if os.Args[1] == "config" && os.Args[2] == "gen" {
 			return
 		}
@@ -72,5 +250,109 @@ func initConfig() {
 		}
 	}
 
+	if conf.OwnersFile != "" {
+		owners, err := repository.LoadOwners(os.ExpandEnv(conf.OwnersFile))
+		if err != nil {
+			fmt.Println("failed to load owners file: " + err.Error())
+			os.Exit(1)
+		}
+		repository.SetOwners(owners)
+	}
+
+	if err := conf.Platforms.Validate(); err != nil {
+		fmt.Println("invalid platforms config: " + err.Error())
+		os.Exit(1)
+	}
+
+	if err := conf.SupportMatrix.Validate(); err != nil {
+		fmt.Println("invalid support matrix config: " + err.Error())
+		os.Exit(1)
+	}
+
+	if err := conf.Components.Validate(); err != nil {
+		fmt.Println("invalid components config: " + err.Error())
+		os.Exit(1)
+	}
+
+	for repo, rules := range conf.BackportRules {
+		if err := backports.ValidateRules(rules); err != nil {
+			fmt.Println("invalid backport rules config of " + repo + ": " + err.Error())
+			os.Exit(1)
+		}
+	}
+
+	if conf.OBS != nil {
+		if err := conf.OBS.Validate(); err != nil {
+			fmt.Println("invalid obs config: " + err.Error())
+			os.Exit(1)
+		}
+	}
+
+	if conf.Auth != nil {
+		if err := conf.Auth.Validate(); err != nil {
+			fmt.Println("invalid auth config: " + err.Error())
+			os.Exit(1)
+		}
+	}
+
+	var base http.RoundTripper = http.DefaultTransport
+	if conf.Network != nil {
+		base, err = ecmHTTP.NewBaseTransport(*conf.Network)
+		if err != nil {
+			fmt.Println("invalid network config: " + err.Error())
+			os.Exit(1)
+		}
+	}
+	// record the requests as sent, after their rehearsal rewrites.
+	if recordPath != "" {
+		secrets := runSecrets(conf)
+		recorder = ecmHTTP.NewRecorder(base, func(s string) string {
+			return failure.Sanitize(s, secrets)
+		})
+		base = recorder
+	}
+	if rehearse {
+		if err := conf.Rehearsal.Validate(); err != nil {
+			fmt.Println("invalid rehearsal config: " + err.Error())
+			os.Exit(1)
+		}
+		base = &rehearsal.Transport{Mapping: conf.Rehearsal, Base: base}
+		rehearseConfig(conf)
+		fmt.Fprintln(os.Stderr, "rehearsal: GitHub changes are redirected to the sandbox repositories")
+	}
+	ecmHTTP.DefaultTransport.SetBase(base)
+
 	rootConfig = conf
+
+	if conf.Auth != nil && conf.Auth.Operations[config.OperationReview] != "" {
+		repository.SetReviewClient(githubClient(context.Background(), config.OperationReview))
+	}
+}
+
+// rehearseConfig points the git URLs of the config to the sandbox
+// repositories, for the flows cloning and pushing with git rather than
+// through the GitHub API. The URLs the commands build themselves go
+// through gitURL.
+func rehearseConfig(conf *config.Config) {
+	mapping := conf.Rehearsal
+
+	conf.RancherRepositoryURL = mapping.GitURL(conf.RancherRepositoryURL)
+	conf.RancherRepositoryGitURI = mapping.GitURL(conf.RancherRepositoryGitURI)
+	conf.CLIRepositoryGitURI = mapping.GitURL(conf.CLIRepositoryGitURI)
+
+	if conf.K3s != nil {
+		for version, release := range conf.K3s.Versions {
+			release.K8sRancherURL = mapping.GitURL(release.K8sRancherURL)
+			release.K3sUpstreamURL = mapping.GitURL(release.K3sUpstreamURL)
+			if conf.User != nil {
+				release.K3sForkURL = mapping.GitURL(release.K3sFork(conf.User))
+				release.K8sForkURL = mapping.GitURL(release.K8sFork(conf.User))
+			}
+			conf.K3s.Versions[version] = release
+		}
+	}
+	if conf.Charts != nil {
+		conf.Charts.ChartsRepoURL = mapping.GitURL(conf.Charts.ChartsRepoURL)
+		conf.Charts.ChartsForkURL = mapping.GitURL(conf.Charts.ChartsForkURL)
+	}
 }
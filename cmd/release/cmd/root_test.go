@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/rancher/ecm-distro-tools/cmd/release/config"
+	"github.com/rancher/ecm-distro-tools/release/rehearsal"
+)
+
+func TestRehearseConfig(t *testing.T) {
+	conf := &config.Config{
+		User: &config.User{GithubUsername: "captain"},
+		K3s: &config.K3s{Versions: map[string]config.K3sRelease{
+			"v1.29.2": {
+				K8sRancherURL:  "git@github.com:k3s-io/kubernetes.git",
+				K3sUpstreamURL: "git@github.com:k3s-io/k3s.git",
+			},
+		}},
+		Rehearsal: &rehearsal.Mapping{
+			Orgs:  map[string]string{"k3s-io": "k3s-sandbox"},
+			Repos: map[string]string{"captain/k3s": "captain-sandbox/k3s"},
+		},
+	}
+
+	rehearseConfig(conf)
+
+	release := conf.K3s.Versions["v1.29.2"]
+	tests := map[string]struct {
+		got  string
+		want string
+	}{
+		"k8s rancher":  {got: release.K8sRancherURL, want: "git@github.com:k3s-sandbox/kubernetes.git"},
+		"k3s upstream": {got: release.K3sUpstreamURL, want: "git@github.com:k3s-sandbox/k3s.git"},
+		"k3s fork":     {got: release.K3sFork(conf.User), want: "git@github.com:captain-sandbox/k3s.git"},
+		"k8s fork":     {got: release.K8sFork(conf.User), want: "git@github.com:captain/kubernetes.git"},
+	}
+	for name, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s URL = %s, want %s", name, tt.got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/rancher/ecm-distro-tools/release/signature"
+	"github.com/rancher/ecm-distro-tools/repository"
+	"github.com/rancher/ecm-distro-tools/selfupdate"
+	"github.com/spf13/cobra"
+)
+
+var (
+	selfUpdateKeyring        *string
+	selfUpdateAllowedSigners *string
+)
+
+// selfUpdateCmd represents the self-update command
+var selfUpdateCmd = &cobra.Command{
+	Use:   "self-update",
+	Short: "Update release to the latest version",
+	Long: `Check the latest ecm-distro-tools release and, if it's newer than the running version,
+download the binary for this platform, verify its sha256 checksum and replace the running binary.
+The checksums must be signed, in the sha256sums-release.txt.asc asset, by a key of the given GPG keyring
+or SSH allowed signers file. The config file isn't required, GITHUB_TOKEN is used if set.`,
+	Example: "release self-update --gpg-keyring ecm-distro-tools.asc",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if *selfUpdateKeyring == "" && *selfUpdateAllowedSigners == "" {
+			return errors.New("--gpg-keyring or --allowed-signers is required to verify the release")
+		}
+		allow, err := signature.LoadAllowList(*selfUpdateKeyring, *selfUpdateAllowedSigners)
+		if err != nil {
+			return err
+		}
+
+		ctx := context.Background()
+		client := repository.NewGithub(ctx, os.Getenv("GITHUB_TOKEN"))
+
+		latest, updated, err := selfupdate.Update(ctx, client, "release", rootCmd.Version, allow, dryRun)
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case updated:
+			fmt.Println("updated release from " + rootCmd.Version + " to " + latest)
+		case dryRun && selfupdate.Newer(latest, rootCmd.Version):
+			fmt.Println("dry run, skipping update from " + rootCmd.Version + " to " + latest)
+		default:
+			fmt.Println("release is up to date: " + rootCmd.Version)
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(selfUpdateCmd)
+
+	selfUpdateKeyring = selfUpdateCmd.Flags().String("gpg-keyring", "", "armored GPG public keys allowed to sign the release checksums")
+	selfUpdateAllowedSigners = selfUpdateCmd.Flags().String("allowed-signers", "", "SSH allowed signers file, as used by git, listing the keys allowed to sign the release checksums")
+}
@@ -56,7 +56,7 @@ var statsCmd = &cobra.Command{
 		s.Writer = os.Stderr
 		s.Start()
 
-		sd, err := release.Stats(ctx, client, from, to, repoToOwner[*repo], *repo)
+		sd, err := release.NewClient(client).Stats(ctx, release.StatsOptions{Owner: repoToOwner[*repo], Repo: *repo, Start: from, End: to})
 		if err != nil {
 			return err
 		}
@@ -0,0 +1,112 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strconv"
+	"time"
+
+	ecmHTTP "github.com/rancher/ecm-distro-tools/http"
+	"github.com/rancher/ecm-distro-tools/release"
+	"github.com/rancher/ecm-distro-tools/release/junit"
+	"github.com/rancher/ecm-distro-tools/release/obs"
+	"github.com/rancher/ecm-distro-tools/release/status"
+	"github.com/rancher/ecm-distro-tools/repository"
+	"github.com/spf13/cobra"
+)
+
+var (
+	statusK3sTags     *[]string
+	statusRKE2Tags    *[]string
+	statusInterval    *time.Duration
+	statusFailingOnly *bool
+	statusExitCode    *bool
+	statusJUnit       *string
+)
+
+// statusCmd represents the status command
+var statusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Follow the status of in-flight releases",
+	Long: `Render the status of the given releases in a terminal dashboard: whether the tag exists, the state of the CI
+runs it triggered, the state of the GitHub release and whether all assets were uploaded. The dashboard refreshes
+automatically, press r to refresh it immediately and q to quit. When the obs config locates the package the rpms of
+k3s or rke2 are built from, the dashboard also shows whether they are built from the release for every OBS repository
+and architecture. When the output isn't a terminal, the status is printed once. With --exit-code or --junit, e.g. to
+gate a CI pipeline, the releases are checked once and a release is failing unless it's tagged, its CI passed, it's
+released, all its assets were uploaded and its rpms, if any, were built.`,
+	Example: "release status --rke2 v1.29.2-rc1+rke2r1,v1.28.7-rc1+rke2r1 --k3s v1.29.2-rc1+k3s1",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var targets []status.Target
+		for _, tag := range *statusRKE2Tags {
+			targets = append(targets, status.Target{Owner: rootConfig.RKE2.RepoOwner(), Repo: "rke2", Tag: tag})
+		}
+		for _, tag := range *statusK3sTags {
+			targets = append(targets, status.Target{Owner: "k3s-io", Repo: "k3s", Tag: tag})
+		}
+		if len(targets) == 0 {
+			return errors.New("expected at least one k3s or rke2 tag")
+		}
+		if rootConfig.OBS != nil {
+			httpClient := ecmHTTP.NewClient(time.Minute)
+			rpms := obs.NewClient(*rootConfig.OBS, &httpClient)
+			for i, t := range targets {
+				if pkg, ok := rpms.Package(t.Repo); ok {
+					targets[i].RPMs = &status.RPMs{Client: rpms, Package: pkg}
+				}
+			}
+		}
+
+		ctx := context.Background()
+		client := release.New(release.Options{
+			Token: rootConfig.Auth.GithubToken,
+			// keep releases for a single refresh, so the dashboard stays live.
+			Cache: repository.NewReleaseCache(*statusInterval / 2),
+		})
+
+		if !*statusExitCode && *statusJUnit == "" {
+			return status.Dashboard(ctx, os.Stdin, os.Stdout, *statusInterval, func(ctx context.Context) []status.Status {
+				statuses := status.CheckAll(ctx, client, targets)
+				if *statusFailingOnly {
+					return status.Failing(statuses)
+				}
+				return statuses
+			})
+		}
+
+		// CI pipelines check the releases once.
+		statuses := status.CheckAll(ctx, client, targets)
+		failing := status.Failing(statuses)
+
+		shown := statuses
+		if *statusFailingOnly {
+			shown = failing
+		}
+		if err := status.Render(os.Stdout, shown, time.Now()); err != nil {
+			return err
+		}
+
+		if *statusJUnit != "" {
+			if err := junit.WriteFile(*statusJUnit, "status", []junit.TestSuite{status.JUnit(statuses)}); err != nil {
+				return err
+			}
+		}
+		if *statusExitCode && len(failing) > 0 {
+			return errors.New(strconv.Itoa(len(failing)) + " of " + strconv.Itoa(len(statuses)) + " releases aren't complete")
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(statusCmd)
+
+	statusK3sTags = statusCmd.Flags().StringSlice("k3s", []string{}, "k3s tags to follow")
+	statusRKE2Tags = statusCmd.Flags().StringSlice("rke2", []string{}, "rke2 tags to follow")
+	statusInterval = statusCmd.Flags().DurationP("interval", "i", 30*time.Second, "how often to refresh the status")
+	statusFailingOnly = statusCmd.Flags().Bool("failing-only", false, "only show the releases that aren't complete")
+	statusExitCode = statusCmd.Flags().Bool("exit-code", false, "check once and exit non-zero if any release isn't complete")
+	statusJUnit = statusCmd.Flags().String("junit", "", "check once and write the status as a JUnit XML report to the given file")
+}
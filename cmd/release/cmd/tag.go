@@ -4,13 +4,18 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"os"
 	"time"
 
 	"github.com/Masterminds/semver/v3"
+	"github.com/google/go-github/v39/github"
 	"github.com/rancher/ecm-distro-tools/cmd/release/config"
+	reg "github.com/rancher/ecm-distro-tools/registry"
 	"github.com/rancher/ecm-distro-tools/release/cli"
 	"github.com/rancher/ecm-distro-tools/release/dashboard"
+	"github.com/rancher/ecm-distro-tools/release/imagebuild"
 	"github.com/rancher/ecm-distro-tools/release/k3s"
+	"github.com/rancher/ecm-distro-tools/release/pretag"
 	"github.com/rancher/ecm-distro-tools/release/rancher"
 	"github.com/rancher/ecm-distro-tools/release/rke2"
 	"github.com/rancher/ecm-distro-tools/release/ui"
@@ -22,14 +27,32 @@ type tagRKE2CmdFlags struct {
 	ReleaseVersion *string
 	RCVersion      *string
 	RPMVersion     *int
+	WatchTimeout   *time.Duration
 }
 
 var tagRKE2Flags tagRKE2CmdFlags
 
+type tagImageBuildCmdFlags struct {
+	PullRequest *int
+	Image       *string
+	Registry    *string
+	Timeout     *time.Duration
+}
+
+var tagImageBuildFlags tagImageBuildCmdFlags
+
+var (
+	tagForce    *bool
+	tagBuildDir *string
+)
+
 // tagCmd represents the tag command.
 var tagCmd = &cobra.Command{
 	Use:   "tag",
 	Short: "Tag releases",
+	Long: `Tag releases. The head of the release branch of k3s, system-agent-installer-k3s, rancher, dashboard and cli
+must have green required checks, and with --build-dir build with make build in the given checkout, for the tag to be
+created, unless --force is set.`,
 }
 
 var k3sTagSubCmd = &cobra.Command{
@@ -52,7 +75,7 @@ var k3sTagSubCmd = &cobra.Command{
 		}
 
 		ctx := context.Background()
-		ghClient := repository.NewGithub(ctx, rootConfig.Auth.GithubToken)
+		ghClient := githubClient(ctx, config.OperationTag)
 
 		opts := repository.CreateReleaseOpts{
 			Tag:    tag,
@@ -60,6 +83,9 @@ var k3sTagSubCmd = &cobra.Command{
 			Owner:  k3sRelease.K3sRepoOwner,
 			Branch: k3sRelease.ReleaseBranch,
 		}
+		if opts.Commit, err = verifyReleaseBranch(ctx, ghClient, opts.Owner, opts.Repo, opts.Branch, *tagBuildDir); err != nil {
+			return err
+		}
 		return k3s.CreateRelease(ctx, ghClient, &k3sRelease, &opts, rc)
 	},
 }
@@ -69,11 +95,12 @@ var rke2TagSubCmd = &cobra.Command{
 	Short: "Tag rke2 releases",
 	RunE: func(cmd *cobra.Command, args []string) error {
 		ctx := context.Background()
-		client := repository.NewGithub(ctx, rootConfig.Auth.GithubToken)
+		client := githubClient(ctx, config.OperationTag)
+		owner := rootConfig.RKE2.RepoOwner()
 
 		switch args[0] {
 		case "image-build-base":
-			if err := rke2.ImageBuildBaseRelease(ctx, client, dryRun); err != nil {
+			if err := rke2.ImageBuildBaseRelease(ctx, client, owner, rootConfig.Platforms, dryRun); err != nil {
 				return err
 			}
 		case "image-build-kubernetes":
@@ -88,7 +115,7 @@ var rke2TagSubCmd = &cobra.Command{
 			} else {
 				for _, version := range rootConfig.RKE2.Versions {
 					cro := repository.CreateReleaseOpts{
-						Owner:      "rancher",
+						Owner:      owner,
 						Repo:       "image-build-kubernetes",
 						Branch:     "master",
 						Name:       version + suffix,
@@ -108,30 +135,51 @@ var rke2TagSubCmd = &cobra.Command{
 			if len(args) == 1 {
 				return errors.New("invalid rpm tag. expected {testinglatest|stable}")
 			}
+			channel := args[1]
 
-			rpmTag := fmt.Sprintf("+rke2%s.%s.%d", *tagRKE2Flags.ReleaseVersion, args[1], *tagRKE2Flags.RPMVersion)
+			rke2Suffix := "+rke2" + *tagRKE2Flags.ReleaseVersion
 			if *tagRKE2Flags.RCVersion != "" {
-				rpmTag = fmt.Sprintf("+rke2%s-rc%s.%s.%d", *tagRKE2Flags.ReleaseVersion, *tagRKE2Flags.RCVersion, args[1], *tagRKE2Flags.RPMVersion)
+				rke2Suffix += "-rc" + *tagRKE2Flags.RCVersion
+			}
+
+			rpmTags := make([]string, 0, len(rootConfig.RKE2.Versions))
+			for _, version := range rootConfig.RKE2.Versions {
+				rpmTag := rke2.PackagingTag(version+rke2Suffix, channel, *tagRKE2Flags.RPMVersion)
+				if !cmd.Flags().Changed("rpm-version") {
+					var err error
+					rpmTag, err = rke2.NextPackagingTag(ctx, client, owner, version+rke2Suffix, channel)
+					if err != nil {
+						return err
+					}
+				}
+				rpmTags = append(rpmTags, rpmTag)
 			}
 
 			if dryRun {
-				fmt.Print("(dry-run)\n\nTagging github.com/rancher/rke2-packaging:\n\n")
-				for _, version := range rootConfig.RKE2.Versions {
-					fmt.Println("\t" + version + rpmTag)
+				fmt.Print("(dry-run)\n\nTagging github.com/" + owner + "/rke2-packaging:\n\n")
+				for _, rpmTag := range rpmTags {
+					fmt.Println("\t" + rpmTag)
 				}
-			} else {
-				for _, version := range rootConfig.RKE2.Versions {
-					cro := repository.CreateReleaseOpts{
-						Owner:      "rancher",
-						Repo:       "rke2-packaging",
-						Branch:     "master",
-						Name:       version + rpmTag,
-						Tag:        version + rpmTag,
-						Prerelease: false,
-					}
-					if _, err := repository.CreateRelease(ctx, client, &cro); err != nil {
+				return nil
+			}
+
+			for _, rpmTag := range rpmTags {
+				if err := rke2.CreatePackagingTag(ctx, client, owner, rpmTag); err != nil {
+					return err
+				}
+				fmt.Println("tag " + rpmTag + " created successfully")
+			}
+
+			if *tagRKE2Flags.WatchTimeout > 0 {
+				watchCtx, cancel := context.WithTimeout(ctx, *tagRKE2Flags.WatchTimeout)
+				defer cancel()
+
+				for _, rpmTag := range rpmTags {
+					fmt.Println("waiting for rke2-packaging build of " + rpmTag)
+					if err := rke2.WatchPackagingBuild(watchCtx, client, owner, rpmTag, 30*time.Second); err != nil {
 						return err
 					}
+					fmt.Println("rke2-packaging build of " + rpmTag + " succeeded")
 				}
 			}
 		default:
@@ -184,7 +232,7 @@ var rancherTagSubCmd = &cobra.Command{
 		releaseBranch = config.ValueOrDefault(rancherRelease.ReleaseBranch, releaseBranch)
 
 		ctx := context.Background()
-		ghClient := repository.NewGithub(ctx, rootConfig.Auth.GithubToken)
+		ghClient := githubClient(ctx, config.OperationTag)
 
 		opts := &repository.CreateReleaseOpts{
 			Tag:          tag,
@@ -196,6 +244,9 @@ var rancherTagSubCmd = &cobra.Command{
 			ReleaseNotes: "",
 		}
 		fmt.Printf("creating release options: %+v\n", opts)
+		if opts.Commit, err = verifyReleaseBranch(ctx, ghClient, owner, repo, releaseBranch, *tagBuildDir); err != nil {
+			return err
+		}
 		if dryRun {
 			fmt.Println("dry run, skipping creating release")
 			return nil
@@ -231,13 +282,16 @@ var systemAgentInstallerK3sTagSubCmd = &cobra.Command{
 
 		ctx := context.Background()
 
-		ghClient := repository.NewGithub(ctx, rootConfig.Auth.GithubToken)
+		ghClient := githubClient(ctx, config.OperationTag)
 		opts := &repository.CreateReleaseOpts{
 			Tag:    tag,
 			Repo:   "system-agent-installer-k3s",
 			Owner:  k3sRelease.SystemAgentInstallerRepoOwner,
 			Branch: "main",
 		}
+		if opts.Commit, err = verifyReleaseBranch(ctx, ghClient, opts.Owner, opts.Repo, opts.Branch, *tagBuildDir); err != nil {
+			return err
+		}
 
 		return k3s.CreateRelease(ctx, ghClient, &k3sRelease, opts, rc)
 	},
@@ -278,7 +332,7 @@ var dashboardTagSubCmd = &cobra.Command{
 
 		tag := args[1]
 		ctx := context.Background()
-		ghClient := repository.NewGithub(ctx, rootConfig.Auth.GithubToken)
+		ghClient := githubClient(ctx, config.OperationTag)
 
 		dashboardRelease, found := rootConfig.Dashboard.Versions[tag]
 		if !found {
@@ -303,11 +357,23 @@ var dashboardTagSubCmd = &cobra.Command{
 
 		previousTag = config.ValueOrDefault(dashboardRelease.PreviousTag, previousTag)
 
+		// the build dir is a checkout of the dashboard, the ui repo only
+		// needs green checks.
+		uiCommit, err := verifyReleaseBranch(ctx, ghClient, repoOwner, uiRepo, releaseBranch, "")
+		if err != nil {
+			return err
+		}
+		dashboardCommit, err := verifyReleaseBranch(ctx, ghClient, repoOwner, dashboardRepo, releaseBranch, *tagBuildDir)
+		if err != nil {
+			return err
+		}
+
 		uiOpts := &repository.CreateReleaseOpts{
 			Tag:    tag,
 			Repo:   uiRepo,
 			Owner:  repoOwner,
 			Branch: releaseBranch,
+			Commit: uiCommit,
 			Draft:  false,
 		}
 
@@ -320,6 +386,7 @@ var dashboardTagSubCmd = &cobra.Command{
 			Repo:   dashboardRepo,
 			Owner:  repoOwner,
 			Branch: releaseBranch,
+			Commit: dashboardCommit,
 			Draft:  false,
 		}
 
@@ -362,7 +429,7 @@ var cliTagSubCmd = &cobra.Command{
 
 		tag := args[1]
 		ctx := context.Background()
-		ghClient := repository.NewGithub(ctx, rootConfig.Auth.GithubToken)
+		ghClient := githubClient(ctx, config.OperationTag)
 
 		cliRelease, found := rootConfig.CLI.Versions[tag]
 		if !found {
@@ -386,11 +453,17 @@ var cliTagSubCmd = &cobra.Command{
 
 		previousTag = config.ValueOrDefault(cliRelease.PreviousTag, previousTag)
 
+		commit, err := verifyReleaseBranch(ctx, ghClient, owner, repo, releaseBranch, *tagBuildDir)
+		if err != nil {
+			return err
+		}
+
 		cliOpts := &repository.CreateReleaseOpts{
 			Tag:    tag,
 			Repo:   repo,
 			Owner:  owner,
 			Branch: releaseBranch,
+			Commit: commit,
 			Draft:  false,
 		}
 
@@ -398,6 +471,39 @@ var cliTagSubCmd = &cobra.Command{
 	},
 }
 
+// verifyReleaseBranch checks that the head of the given release branch has
+// green checks and, if a build dir is given, that it builds in it, before
+// it's tagged. It returns the verified commit, which the tag is created
+// from so commits merged since aren't tagged unverified. It refuses to tag
+// otherwise, unless forced, and only reports the failures on dry runs; the
+// commit is then empty if the head couldn't be read, tagging the branch.
+func verifyReleaseBranch(ctx context.Context, client *github.Client, owner, repo, branch, buildDir string) (string, error) {
+	result, err := pretag.Verify(ctx, client, owner, repo, branch)
+	var sha string
+	if err == nil {
+		sha = result.SHA
+		err = result.Err()
+	}
+	if err == nil && buildDir != "" {
+		fmt.Println("building " + owner + "/" + repo + " " + branch + " at " + sha + " in " + buildDir)
+		err = pretag.Build(ctx, buildDir, sha, os.Stdout)
+	}
+
+	switch {
+	case err == nil:
+		fmt.Println(owner + "/" + repo + " " + branch + " at " + sha + " is green")
+		return sha, nil
+	case dryRun:
+		fmt.Println("dry run, the tag would be refused: " + err.Error())
+		return sha, nil
+	case *tagForce:
+		fmt.Println("forcing the tag: " + err.Error())
+		return sha, nil
+	default:
+		return "", errors.New(err.Error() + ", use --force to tag anyway")
+	}
+}
+
 func previousPatch(tag string) (string, error) {
 	version, err := semver.NewVersion(tag)
 	if err != nil {
@@ -427,6 +533,43 @@ func copyDashboardVersions() []string {
 	return versions
 }
 
+var imageBuildTagSubCmd = &cobra.Command{
+	Use:   "image-build [repo] [version]",
+	Short: "Tag an image-build repo once its update merges",
+	Long: `Wait for the pull request updating an image-build repo to the given upstream version to merge, tag its
+merge commit with the version and the build date, e.g. v3.5.12-build20240215, to trigger the image publish, and
+wait for the image to be available in the registry.`,
+	Example: "release tag image-build image-build-etcd v3.5.12 --pr 123",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) < 2 {
+			return errors.New("expected at least two arguments: [repo] [version]")
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), *tagImageBuildFlags.Timeout)
+		defer cancel()
+
+		client := githubClient(ctx, config.OperationTag)
+		registry := reg.NewClient(*tagImageBuildFlags.Registry, debug)
+
+		tag, err := imagebuild.Dispatch(ctx, client, registry, imagebuild.DispatchOptions{
+			Owner:       "rancher",
+			Repo:        args[0],
+			PullRequest: *tagImageBuildFlags.PullRequest,
+			Version:     args[1],
+			Image:       *tagImageBuildFlags.Image,
+			Interval:    30 * time.Second,
+			DryRun:      dryRun,
+		})
+		if err != nil {
+			return err
+		}
+
+		fmt.Println("tag " + tag + " published successfully")
+
+		return nil
+	},
+}
+
 func init() {
 	rootCmd.AddCommand(tagCmd)
 
@@ -436,11 +579,26 @@ func init() {
 	tagCmd.AddCommand(systemAgentInstallerK3sTagSubCmd)
 	tagCmd.AddCommand(dashboardTagSubCmd)
 	tagCmd.AddCommand(cliTagSubCmd)
+	tagCmd.AddCommand(imageBuildTagSubCmd)
+
+	tagForce = tagCmd.PersistentFlags().Bool("force", false, "Tag even if the checks of the release branch aren't green or its build fails")
+	tagBuildDir = tagCmd.PersistentFlags().String("build-dir", "", "Checkout of the release branch head to run make build in before tagging")
 
 	// rke2
 	tagRKE2Flags.ReleaseVersion = rke2TagSubCmd.Flags().StringP("release-version", "r", "r1", "Release version")
 	tagRKE2Flags.RCVersion = rke2TagSubCmd.Flags().String("rc", "", "RC version")
-	tagRKE2Flags.RPMVersion = rke2TagSubCmd.Flags().Int("rpm-version", 0, "RPM version")
+	tagRKE2Flags.RPMVersion = rke2TagSubCmd.Flags().Int("rpm-version", 0, "RPM version, defaults to the next packaging iteration")
+	tagRKE2Flags.WatchTimeout = rke2TagSubCmd.Flags().Duration("watch", 0, "Wait up to the given duration for the rpm builds to complete")
+
+	// image-build
+	tagImageBuildFlags.PullRequest = imageBuildTagSubCmd.Flags().Int("pr", 0, "Pull request updating the image-build repo")
+	tagImageBuildFlags.Image = imageBuildTagSubCmd.Flags().String("image", "", "Image published by the repo, defaults to rancher/hardened-<name>")
+	tagImageBuildFlags.Registry = imageBuildTagSubCmd.Flags().String("registry", "docker.io", "Registry the image is published to")
+	tagImageBuildFlags.Timeout = imageBuildTagSubCmd.Flags().Duration("timeout", 2*time.Hour, "How long to wait for the merge and the image publish")
+	if err := imageBuildTagSubCmd.MarkFlagRequired("pr"); err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
 }
 
 func releaseTypePreRelease(releaseType string) (bool, error) {
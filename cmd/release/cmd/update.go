@@ -13,7 +13,6 @@ import (
 	"github.com/rancher/ecm-distro-tools/release/cli"
 	"github.com/rancher/ecm-distro-tools/release/k3s"
 	"github.com/rancher/ecm-distro-tools/release/rancher"
-	"github.com/rancher/ecm-distro-tools/repository"
 	"github.com/spf13/cobra"
 )
 
@@ -44,9 +43,9 @@ var updateK3sReferencesCmd = &cobra.Command{
 
 		ctx := context.Background()
 
-		ghClient := repository.NewGithub(ctx, rootConfig.Auth.GithubToken)
+		ghClient := githubClient(ctx, config.OperationPullRequest)
 
-		return k3s.UpdateK3sReferences(ctx, ghClient, &k3sRelease, rootConfig.User)
+		return k3s.UpdateK3sReferences(ctx, ghClient, &k3sRelease, rootConfig.User, cloneOptions("k3s"))
 	},
 }
 
@@ -174,7 +173,7 @@ var updateRancherDashboardCmd = &cobra.Command{
 
 		rancherRepo := config.ValueOrDefault(rootConfig.RancherRepositoryName, config.RancherRepositoryName)
 		rancherRepoOwner := config.ValueOrDefault(rootConfig.RancherGithubOrganization, config.RancherGithubOrganization)
-		rancherRepoURL := config.ValueOrDefault(rootConfig.RancherRepositoryURL, config.RancherRepositoryURL)
+		rancherRepoURL := gitURL(config.ValueOrDefault(rootConfig.RancherRepositoryURL, config.RancherRepositoryURL))
 
 		rancherReleaseBranch, err := rancher.ReleaseBranchFromTag(tag)
 		if err != nil {
@@ -185,7 +184,7 @@ var updateRancherDashboardCmd = &cobra.Command{
 
 		ctx := context.Background()
 
-		ghClient := repository.NewGithub(ctx, rootConfig.Auth.GithubToken)
+		ghClient := githubClient(ctx, config.OperationPullRequest)
 
 		return rancher.UpdateDashboardReferences(ctx, ghClient, &dashboardRelease, rootConfig.User, tag, rancherReleaseBranch, rancherRepo, rancherRepoOwner, rancherRepoURL, dryRun)
 	},
@@ -223,7 +222,7 @@ var updateRancherCLICmd = &cobra.Command{
 
 		rancherRepo := config.ValueOrDefault(rootConfig.RancherRepositoryName, config.RancherRepositoryName)
 		rancherRepoOwner := config.ValueOrDefault(rootConfig.RancherGithubOrganization, config.RancherGithubOrganization)
-		rancherRepoURL := config.ValueOrDefault(rootConfig.RancherRepositoryURL, config.RancherRepositoryURL)
+		rancherRepoURL := gitURL(config.ValueOrDefault(rootConfig.RancherRepositoryURL, config.RancherRepositoryURL))
 
 		rancherReleaseBranch, err := rancher.ReleaseBranchFromTag(tag)
 		if err != nil {
@@ -234,7 +233,7 @@ var updateRancherCLICmd = &cobra.Command{
 
 		ctx := context.Background()
 
-		ghClient := repository.NewGithub(ctx, rootConfig.Auth.GithubToken)
+		ghClient := githubClient(ctx, config.OperationPullRequest)
 
 		return rancher.UpdateCLIReferences(ctx, ghClient, tag, rancherReleaseBranch, githubUsername, rancherRepo, rancherRepoOwner, rancherRepoURL, dryRun)
 	},
@@ -279,11 +278,11 @@ var updateCLICmd = &cobra.Command{
 
 		rancherRepo := config.ValueOrDefault(rootConfig.RancherRepositoryName, config.RancherRepositoryName)
 		rancherRepoOwner := config.ValueOrDefault(rootConfig.RancherGithubOrganization, config.RancherGithubOrganization)
-		rancherUpstreamURL := config.ValueOrDefault(rootConfig.RancherRepositoryURL, config.RancherRepositoryURL)
+		rancherUpstreamURL := gitURL(config.ValueOrDefault(rootConfig.RancherRepositoryURL, config.RancherRepositoryURL))
 
 		ctx := context.Background()
 
-		ghClient := repository.NewGithub(ctx, rootConfig.Auth.GithubToken)
+		ghClient := githubClient(ctx, config.OperationPullRequest)
 
 		return cli.UpdateRancherReferences(ctx, ghClient, tag, rancherRepo, rancherRepoOwner, rancherUpstreamURL, cliBranch, cliRepo, githubUsername, dryRun)
 	},
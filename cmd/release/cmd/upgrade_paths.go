@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/google/go-github/v39/github"
+	"github.com/rancher/ecm-distro-tools/cmd/release/config"
+	"github.com/rancher/ecm-distro-tools/release/upgrade"
+	"github.com/rancher/ecm-distro-tools/repository"
+	"github.com/spf13/cobra"
+)
+
+var (
+	upgradePathsResults *string
+	upgradePathsIssue   *bool
+)
+
+// upgradePathsCmd represents the upgrade-paths command
+var upgradePathsCmd = &cobra.Command{
+	Use:   "upgrade-paths [k3s|rke2] [tag]",
+	Short: "Inject the validated upgrade paths into the release notes or the tracking issue",
+	Long: `Inject a validated upgrade paths table, built from the JSON results of the upgrade test matrix, into the notes of
+the GitHub release of the tag, or with --issue into its tracking issue, titled "Cut <tag>". The table replaces the one
+injected by a previous run. The results are a list of runs, e.g.
+{"results": [{"from": "v1.28.7+rke2r1", "to": "v1.29.2+rke2r1", "platform": "ubuntu-22.04", "passed": true}]}`,
+	Example: "release upgrade-paths rke2 v1.29.2+rke2r1 --results upgrade-results.json",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) != 2 {
+			return errors.New("expected two arguments: [k3s|rke2] [tag]")
+		}
+		product, tag := args[0], args[1]
+
+		owner, ok := repoToOwner[product]
+		if !ok || product == "rancher" {
+			return errors.New("invalid product: " + product + ", expected one of: k3s, rke2")
+		}
+		if product == "rke2" {
+			owner = rootConfig.RKE2.RepoOwner()
+		}
+
+		report, err := upgrade.Load(*upgradePathsResults)
+		if err != nil {
+			return err
+		}
+		paths := report.Paths(tag)
+		if len(paths) == 0 {
+			return errors.New("no upgrade to " + tag + " in " + *upgradePathsResults)
+		}
+		section := upgrade.Section(tag, paths)
+
+		if dryRun {
+			fmt.Println("dry run, would inject:")
+			fmt.Println(section)
+			return nil
+		}
+
+		ctx := context.Background()
+		if *upgradePathsIssue {
+			gh := githubClient(ctx, config.OperationIssue)
+			issue, err := repository.FindOpenIssue(ctx, gh, owner, product, "Cut "+tag)
+			if err != nil {
+				return err
+			}
+			if issue == nil {
+				return errors.New("no open tracking issue titled Cut " + tag)
+			}
+			body := upgrade.Inject(issue.GetBody(), section)
+			if _, _, err := gh.Issues.Edit(ctx, owner, product, issue.GetNumber(), &github.IssueRequest{Body: github.String(body)}); err != nil {
+				return repository.WrapGithubError(err, owner, product, "#"+strconv.Itoa(issue.GetNumber()))
+			}
+			fmt.Println("updated " + issue.GetHTMLURL())
+			return nil
+		}
+
+		gh := githubClient(ctx, config.OperationTag)
+		release, err := repository.GetReleaseByTag(ctx, gh, owner, product, tag)
+		if err != nil {
+			return err
+		}
+		body := upgrade.Inject(release.GetBody(), section)
+		if _, _, err := gh.Repositories.EditRelease(ctx, owner, product, release.GetID(), &github.RepositoryRelease{Body: github.String(body)}); err != nil {
+			return repository.WrapGithubError(err, owner, product, tag)
+		}
+		repository.InvalidateRelease(owner, product, tag)
+		fmt.Println("updated " + release.GetHTMLURL())
+
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(upgradePathsCmd)
+
+	upgradePathsResults = upgradePathsCmd.Flags().String("results", "", "path of the JSON results of the upgrade test matrix")
+	upgradePathsIssue = upgradePathsCmd.Flags().Bool("issue", false, "inject into the tracking issue instead of the release notes")
+
+	if err := upgradePathsCmd.MarkFlagRequired("results"); err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+}
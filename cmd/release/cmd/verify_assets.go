@@ -0,0 +1,143 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/rancher/ecm-distro-tools/release"
+	"github.com/rancher/ecm-distro-tools/release/junit"
+	"github.com/rancher/ecm-distro-tools/release/signature"
+	"github.com/rancher/ecm-distro-tools/repository"
+	"github.com/spf13/cobra"
+)
+
+var (
+	verifyAssetsKeyring        *string
+	verifyAssetsAllowedSigners *string
+	verifyAssetsFailingOnly    *bool
+	verifyAssetsJUnit          *string
+)
+
+// verifyAssetsCmd represents the verify-assets command
+var verifyAssetsCmd = &cobra.Command{
+	Use:   "verify-assets [k3s|rke2|kine|helm-controller|local-path-provisioner] [tag...]",
+	Short: "Verify the names and content types of the assets of a release",
+	Long: `Verify that every asset of a release follows the naming conventions the install scripts rely on, e.g.
+sha256sum-<arch>.txt and rke2.linux-<arch>.tar.gz, was uploaded with the expected content type and isn't empty, and
+that none of the required assets is missing. If a GPG keyring or an SSH allowed signers file is given, the tag and
+the commit it points to must also be signed by one of their keys, as required for prime releases. The command exits
+non-zero if any tag fails, and the outcome can be written as a JUnit XML report for CI pipelines.`,
+	Example: "release verify-assets rke2 v1.29.2-rc1+rke2r1",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) < 2 {
+			return errors.New("expected at least two arguments: [k3s|rke2|kine|helm-controller|local-path-provisioner] [tag...]")
+		}
+		repo, tags := args[0], args[1:]
+
+		owner, ok := repoToOwner[repo]
+		if !ok || repo == "rancher" {
+			var err error
+			if owner, err = release.AncillaryOwner(repo); err != nil {
+				return errors.New("invalid repo: " + repo + ", expected one of: k3s, rke2, " + strings.Join(release.AncillaryRepos(), ", "))
+			}
+		}
+
+		var allow *signature.AllowList
+		if *verifyAssetsKeyring != "" || *verifyAssetsAllowedSigners != "" {
+			var err error
+			if allow, err = signature.LoadAllowList(*verifyAssetsKeyring, *verifyAssetsAllowedSigners); err != nil {
+				return err
+			}
+		}
+
+		ctx := context.Background()
+		ghClient := repository.NewGithub(ctx, rootConfig.Auth.GithubToken)
+		client := release.NewClient(ghClient)
+
+		var errs []error
+		suites := make([]junit.TestSuite, 0, len(tags))
+		for _, tag := range tags {
+			suite := junit.TestSuite{Name: owner + "/" + repo + "@" + tag}
+			errs = append(errs, verifyTagAssets(ctx, client, owner, repo, tag, allow, &suite))
+			suites = append(suites, suite)
+		}
+
+		if *verifyAssetsJUnit != "" {
+			if err := junit.WriteFile(*verifyAssetsJUnit, "verify-assets", suites); err != nil {
+				errs = append(errs, err)
+			}
+		}
+
+		return errors.Join(errs...)
+	},
+}
+
+// verifyTagAssets checks the assets, and the signatures if an allow list is
+// given, of the given release, printing the outcome and adding it to the
+// given suite. Only the failures are printed with --failing-only.
+func verifyTagAssets(ctx context.Context, client *release.Client, owner, repo, tag string, allow *signature.AllowList, suite *junit.TestSuite) error {
+	checks, err := client.CheckAssets(ctx, owner, repo, tag)
+	if err != nil {
+		suite.Error("assets", err.Error())
+		return errors.New(tag + ": " + err.Error())
+	}
+
+	var violations int
+	for _, check := range checks {
+		if check.OK() {
+			suite.Pass(check.Asset)
+			if !*verifyAssetsFailingOnly {
+				fmt.Println("✓ " + tag + " " + check.Asset)
+			}
+			continue
+		}
+		violations++
+		suite.Fail(check.Asset, strings.Join(check.Reasons, ", "))
+		fmt.Println("✗ " + tag + " " + check.Asset + ": " + strings.Join(check.Reasons, ", "))
+	}
+
+	if violations > 0 {
+		err = errors.New(strconv.Itoa(violations) + " assets of " + tag + " break the asset rules")
+	} else if !*verifyAssetsFailingOnly {
+		fmt.Println("all assets of " + tag + " follow the asset rules")
+	}
+
+	if allow == nil {
+		return err
+	}
+
+	result, sigErr := signature.VerifyTag(ctx, client.GitHub, owner, repo, tag, allow)
+	if sigErr != nil {
+		suite.Error("signatures", sigErr.Error())
+		return errors.Join(err, sigErr)
+	}
+	for _, check := range result.Checks {
+		if check.Err != nil {
+			suite.Fail("signature of "+check.Object, check.Err.Error())
+		} else {
+			suite.Pass("signature of " + check.Object)
+		}
+	}
+	if sigErr := result.Err(); sigErr != nil {
+		fmt.Print(result.Summary())
+		return errors.Join(err, sigErr)
+	}
+	if !*verifyAssetsFailingOnly {
+		fmt.Print(result.Summary())
+		fmt.Println(tag + " and its commit are signed by allowed keys")
+	}
+
+	return err
+}
+
+func init() {
+	rootCmd.AddCommand(verifyAssetsCmd)
+
+	verifyAssetsKeyring = verifyAssetsCmd.Flags().String("gpg-keyring", "", "armored GPG public keys allowed to sign the tag and its commit")
+	verifyAssetsAllowedSigners = verifyAssetsCmd.Flags().String("allowed-signers", "", "SSH allowed signers file, as used by git, listing the keys allowed to sign the tag and its commit")
+	verifyAssetsFailingOnly = verifyAssetsCmd.Flags().Bool("failing-only", false, "only print the failing assets and tags")
+	verifyAssetsJUnit = verifyAssetsCmd.Flags().String("junit", "", "write the outcome as a JUnit XML report to the given file, a test suite per tag")
+}
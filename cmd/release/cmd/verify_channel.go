@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	ecmHTTP "github.com/rancher/ecm-distro-tools/http"
+	"github.com/rancher/ecm-distro-tools/release/channel"
+	"github.com/spf13/cobra"
+)
+
+var (
+	verifyChannelTimeout  *time.Duration
+	verifyChannelInterval *time.Duration
+)
+
+// verifyChannelCmd represents the verify-channel command
+var verifyChannelCmd = &cobra.Command{
+	Use:   "verify-channel [k3s|rke2] [channel] [version]",
+	Short: "Verify a channel resolves to the given version",
+	Long: `Verify that the channel used by get.k3s.io or get.rke2.io resolves to the given version, e.g. after the
+channels were updated to promote a release. The channel server is polled until it does or the timeout expires.`,
+	Example: "release verify-channel rke2 stable v1.29.2+rke2r1 --timeout 30m",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) < 3 {
+			return errors.New("expected at least three arguments: [k3s|rke2] [channel] [version]")
+		}
+		product, ch, version := args[0], args[1], args[2]
+
+		ctx, cancel := context.WithTimeout(context.Background(), *verifyChannelTimeout)
+		defer cancel()
+
+		client := ecmHTTP.NewClient(time.Minute)
+
+		fmt.Println("waiting for the " + product + " " + ch + " channel to resolve to " + version)
+		if err := channel.WaitForVersion(ctx, &client, product, ch, version, *verifyChannelInterval); err != nil {
+			return err
+		}
+		fmt.Println("the " + product + " " + ch + " channel resolves to " + version)
+
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(verifyChannelCmd)
+
+	verifyChannelTimeout = verifyChannelCmd.Flags().DurationP("timeout", "t", 30*time.Minute, "how long to wait for the channel to resolve to the version")
+	verifyChannelInterval = verifyChannelCmd.Flags().DurationP("interval", "i", 30*time.Second, "how often to poll the channel server")
+}
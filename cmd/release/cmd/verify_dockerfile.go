@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+
+	reg "github.com/rancher/ecm-distro-tools/registry"
+	"github.com/rancher/ecm-distro-tools/release/dockerfile"
+	"github.com/spf13/cobra"
+)
+
+var (
+	verifyDockerfileExpect  *[]string
+	verifyDockerfileResolve *bool
+)
+
+// verifyDockerfileCmd represents the verify-dockerfile command
+var verifyDockerfileCmd = &cobra.Command{
+	Use:   "verify-dockerfile [file...]",
+	Short: "Verify the edit of Dockerfiles before committing it",
+	Long: `Verify the uncommitted edit of Dockerfiles of the git working tree, e.g. by an image-build or chart bump
+script, against their HEAD version: the Dockerfiles must parse, only lines matching the --expect patterns may be
+replaced and, with --resolve, the images of the edited FROM instructions must exist. The files can be glob patterns.`,
+	Example: "release verify-dockerfile 'Dockerfile.*' --expect 'golang:' --resolve",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) == 0 {
+			return errors.New("expected at least one argument: [file...]")
+		}
+
+		var opts dockerfile.Options
+		for _, expect := range *verifyDockerfileExpect {
+			re, err := regexp.Compile(expect)
+			if err != nil {
+				return errors.New("invalid expected line pattern " + expect + ": " + err.Error())
+			}
+			opts.Expected = append(opts.Expected, re)
+		}
+		if *verifyDockerfileResolve {
+			opts.Resolve = reg.Exists
+		}
+
+		if err := dockerfile.ValidateEdits(context.Background(), ".", args, opts); err != nil {
+			return err
+		}
+		fmt.Println("the Dockerfile edits are valid")
+
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(verifyDockerfileCmd)
+
+	verifyDockerfileExpect = verifyDockerfileCmd.Flags().StringArrayP("expect", "e", nil, "pattern of the lines the edit is expected to change, any line if not set")
+	verifyDockerfileResolve = verifyDockerfileCmd.Flags().Bool("resolve", false, "check that the images of the edited FROM instructions exist")
+}
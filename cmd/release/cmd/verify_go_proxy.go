@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	ecmHTTP "github.com/rancher/ecm-distro-tools/http"
+	"github.com/rancher/ecm-distro-tools/release"
+	"github.com/rancher/ecm-distro-tools/release/goproxy"
+	"github.com/spf13/cobra"
+	"golang.org/x/mod/semver"
+)
+
+var (
+	verifyGoProxyTimeout  *time.Duration
+	verifyGoProxyInterval *time.Duration
+	verifyGoProxyURL      *string
+)
+
+// verifyGoProxyCmd represents the verify-go-proxy command
+var verifyGoProxyCmd = &cobra.Command{
+	Use:   "verify-go-proxy [repo|module] [version]",
+	Short: "Verify the Go module proxy serves a tagged module version",
+	Long: `Verify that the Go module proxy serves a newly tagged module version, so go.mod bumps in downstream repos
+don't fail while the proxy hasn't fetched the tag yet. The proxy is polled until it does or the timeout expires.
+The module is either a k3s ancillary repo, e.g. kine, or a module path.`,
+	Example: "release verify-go-proxy kine v0.11.4 --timeout 15m",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) < 2 {
+			return errors.New("expected at least two arguments: [repo|module] [version]")
+		}
+		version := args[1]
+		if !semver.IsValid(version) {
+			return errors.New("invalid version: " + version)
+		}
+		modulePath, err := goModulePath(args[0], version)
+		if err != nil {
+			return err
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), *verifyGoProxyTimeout)
+		defer cancel()
+
+		client := ecmHTTP.NewClient(time.Minute)
+
+		fmt.Println("waiting for " + *verifyGoProxyURL + " to serve " + modulePath + "@" + version)
+		info, err := goproxy.WaitForVersion(ctx, &client, *verifyGoProxyURL, modulePath, version, *verifyGoProxyInterval)
+		if err != nil {
+			return err
+		}
+		fmt.Println(*verifyGoProxyURL + " serves " + modulePath + "@" + info.Version + ", published " + info.Time.Format(time.RFC3339))
+
+		return nil
+	},
+}
+
+// goModulePath returns the module path of the given k3s ancillary repo,
+// including the major version suffix of v2 and later versions, or the given
+// module path as is.
+func goModulePath(repoOrModule, version string) (string, error) {
+	if strings.Contains(repoOrModule, "/") {
+		return repoOrModule, nil
+	}
+
+	owner, err := release.AncillaryOwner(repoOrModule)
+	if err != nil {
+		return "", err
+	}
+	modulePath := "github.com/" + owner + "/" + repoOrModule
+	if major := semver.Major(version); major != "v0" && major != "v1" {
+		modulePath += "/" + major
+	}
+
+	return modulePath, nil
+}
+
+func init() {
+	rootCmd.AddCommand(verifyGoProxyCmd)
+
+	verifyGoProxyTimeout = verifyGoProxyCmd.Flags().DurationP("timeout", "t", 30*time.Minute, "how long to wait for the proxy to serve the version")
+	verifyGoProxyInterval = verifyGoProxyCmd.Flags().DurationP("interval", "i", 30*time.Second, "how often to poll the proxy")
+	verifyGoProxyURL = verifyGoProxyCmd.Flags().String("proxy", goproxy.DefaultURL, "URL of the Go module proxy")
+}
@@ -2,11 +2,23 @@ package config
 
 import (
 	"encoding/json"
+	"errors"
 	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
+	"strings"
 	"text/template"
+
+	ecmHTTP "github.com/rancher/ecm-distro-tools/http"
+	"github.com/rancher/ecm-distro-tools/release/backports"
+	"github.com/rancher/ecm-distro-tools/release/components"
+	"github.com/rancher/ecm-distro-tools/release/eol"
+	"github.com/rancher/ecm-distro-tools/release/mirror"
+	"github.com/rancher/ecm-distro-tools/release/obs"
+	"github.com/rancher/ecm-distro-tools/release/platforms"
+	"github.com/rancher/ecm-distro-tools/release/rehearsal"
 )
 
 const (
@@ -84,7 +96,22 @@ type K3sRelease struct {
 	SystemAgentInstallerRepoOwner string `json:"system_agent_installer_repo_owner"`
 	K8sRancherURL                 string `json:"k8s_rancher_url"`
 	K3sUpstreamURL                string `json:"k3s_upstream_url"`
-	DryRun                        bool   `json:"dry_run"`
+	// K3sForkURL and K8sForkURL are the user's forks the k3s references
+	// are pushed to and the kubernetes tags fetched from, by default the
+	// forks of the user's GitHub account.
+	K3sForkURL string `json:"k3s_fork_url,omitempty"`
+	K8sForkURL string `json:"k8s_fork_url,omitempty"`
+	DryRun     bool   `json:"dry_run"`
+}
+
+// K3sFork returns the URL of the user's k3s fork.
+func (r *K3sRelease) K3sFork(u *User) string {
+	return ValueOrDefault(r.K3sForkURL, "git@github.com:"+u.GithubUsername+"/k3s.git")
+}
+
+// K8sFork returns the URL of the user's kubernetes fork.
+func (r *K3sRelease) K8sFork(u *User) string {
+	return ValueOrDefault(r.K8sForkURL, "git@github.com:"+u.GithubUsername+"/kubernetes.git")
 }
 
 // RancherRelease
@@ -105,6 +132,18 @@ type CLIRelease struct {
 // RKE2
 type RKE2 struct {
 	Versions []string `json:"versions"`
+	// Owner is the organization the rke2 repos are tagged in, e.g. a sandbox
+	// organization to rehearse the release. Defaults to rancher.
+	Owner string `json:"owner,omitempty"`
+}
+
+// RepoOwner returns the organization the rke2 repos are tagged in.
+func (r *RKE2) RepoOwner() string {
+	if r == nil {
+		return RancherGithubOrganization
+	}
+
+	return ValueOrDefault(r.Owner, RancherGithubOrganization)
 }
 
 // ChartsRelease
@@ -148,6 +187,67 @@ type Auth struct {
 	AWSSecretAccessKey string `json:"aws_secret_access_key"`
 	AWSSessionToken    string `json:"aws_session_token"`
 	AWSDefaultRegion   string `json:"aws_default_region"`
+	// Identities are the GitHub accounts, by name, operations can be
+	// performed as instead of the account of GithubToken, e.g. a bot
+	// account for the tags and a personal one for the pull requests.
+	Identities map[string]Identity `json:"identities,omitempty"`
+	// Operations maps the operations, e.g. tag, to the name of the identity
+	// performing them. Operations not listed use GithubToken.
+	Operations map[string]string `json:"operations,omitempty"`
+}
+
+// Operations performed on GitHub which can use their own identity.
+const (
+	// OperationTag creates the tags, branches and releases.
+	OperationTag = "tag"
+	// OperationPullRequest opens the pull requests.
+	OperationPullRequest = "pull-request"
+	// OperationIssue creates and updates the issues and their comments.
+	OperationIssue = "issue"
+	// OperationAssets uploads and deletes the release assets.
+	OperationAssets = "assets"
+	// OperationProject updates the GitHub projects.
+	OperationProject = "project"
+	// OperationReview requests the reviews of the owners on the pull
+	// requests and notifies them.
+	OperationReview = "review"
+)
+
+var operations = []string{OperationTag, OperationPullRequest, OperationIssue, OperationAssets, OperationProject, OperationReview}
+
+// Identity is a GitHub account.
+type Identity struct {
+	GithubToken string `json:"github_token"`
+}
+
+// GithubTokenFor returns the token of the identity performing the given
+// operation, GithubToken if none is configured.
+func (a *Auth) GithubTokenFor(operation string) string {
+	if name, ok := a.Operations[operation]; ok {
+		if identity, ok := a.Identities[name]; ok && identity.GithubToken != "" {
+			return identity.GithubToken
+		}
+	}
+
+	return a.GithubToken
+}
+
+// Validate checks the operations are known and their identities configured.
+func (a *Auth) Validate() error {
+	for operation, name := range a.Operations {
+		if !slices.Contains(operations, operation) {
+			return errors.New("invalid operation " + operation + ", expected one of: " + strings.Join(operations, ", "))
+		}
+		identity, ok := a.Identities[name]
+		if !ok {
+			return errors.New("unknown identity " + name + " of operation " + operation)
+		}
+		if identity.GithubToken == "" {
+			return errors.New("missing github_token of identity " + name)
+		}
+	}
+
+	return nil
 }
 
 // Config
@@ -169,6 +269,62 @@ type Config struct {
 	DashboardRepositoryName   string         `json:"dashboard_repository_name"`
 	CLIRepositoryName         string         `json:"cli_repository_name"`
 	CLIRepositoryGitURI       string         `json:"cli_repository_git_uri"`
+	// OwnersFile is the JSON file mapping the components, e.g. containerd,
+	// to the users and teams reviewing their bump pull requests. Reviews
+	// aren't routed without it.
+	OwnersFile string `json:"owners_file,omitempty"`
+	// Network configures the CAs and proxy of the HTTP and registry
+	// clients, e.g. behind a corporate proxy.
+	Network *ecmHTTP.NetworkConfig `json:"network,omitempty"`
+	// Rehearsal maps the production organizations and repositories to the
+	// sandbox ones used by the --rehearsal flag.
+	Rehearsal *rehearsal.Mapping `json:"rehearsal,omitempty"`
+	// Locale of the dates of the release notes and announcements.
+	Locale *Locale `json:"locale,omitempty"`
+	// Platforms the images of each product are expected for, by release
+	// line, e.g. s390x on some rke2 lines only.
+	Platforms platforms.Matrix `json:"platforms,omitempty"`
+	// SupportMatrix is the end of life date of the release lines of each
+	// product, e.g. rke2, and their migration guidance links.
+	SupportMatrix eol.Matrix `json:"support_matrix,omitempty"`
+	// Mirror is the Artifactory or Nexus repository the release artifacts
+	// are mirrored to.
+	Mirror *mirror.Config `json:"mirror,omitempty"`
+	// ActivityRepos are the repos, owner/name, the release activity report
+	// lists the releases of. Defaults to the k3s, rke2, rancher, dashboard
+	// and cli repos.
+	ActivityRepos []string `json:"activity_repos,omitempty"`
+	// Components maps k3s and rke2 to the specs of the components of their
+	// release notes, e.g. to add one read from go.mod, on top of the
+	// default ones.
+	Components components.Mapping `json:"components,omitempty"`
+	// BackportRules maps k3s, rke2 and rancher to the rules setting the
+	// milestone and labels of their backport pull requests, instead of
+	// the default ones.
+	BackportRules map[string][]backports.Rule `json:"backport_rules,omitempty"`
+	// OBS is the Open Build Service instance the k3s and rke2 rpms are
+	// built in, for some targets.
+	OBS *obs.Config `json:"obs,omitempty"`
+}
+
+// Locale configures the locale, e.g. de-DE, the dates of the release notes
+// are formatted in, globally and per product.
+type Locale struct {
+	Default  string            `json:"default,omitempty"`
+	Products map[string]string `json:"products,omitempty"`
+}
+
+// For returns the locale of the given product, e.g. rke2, empty if none is
+// configured.
+func (l *Locale) For(product string) string {
+	if l == nil {
+		return ""
+	}
+	if locale, ok := l.Products[product]; ok {
+		return locale
+	}
+
+	return l.Default
 }
 
 // OpenOnEditor opens the given config file on the user's default text editor.
@@ -337,7 +493,8 @@ Rancher {{ range $rancherVersion, $rancherValue := .Rancher.Versions }}
 		Release Branch:     {{ $rancherValue.ReleaseBranch }}
 		Rancher Repo Owner: {{ $rancherValue.RancherRepoOwner }}{{ end }}
 
-RKE2{{ range .RKE2.Versions }}
+RKE2
+	Owner: {{ .RKE2.RepoOwner }}{{ range .RKE2.Versions }}
 	{{ . }}{{ end}}
 
 Charts
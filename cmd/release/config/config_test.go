@@ -15,3 +15,59 @@ func TestRead(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestAuthGithubTokenFor(t *testing.T) {
+	auth := &Auth{
+		GithubToken: "default",
+		Identities: map[string]Identity{
+			"bot":      {GithubToken: "bot"},
+			"reviewer": {GithubToken: "reviewer"},
+		},
+		Operations: map[string]string{
+			OperationTag:    "bot",
+			OperationReview: "reviewer",
+		},
+	}
+
+	tests := []struct {
+		operation string
+		want      string
+	}{
+		{operation: OperationTag, want: "bot"},
+		{operation: OperationPullRequest, want: "default"},
+		{operation: OperationReview, want: "reviewer"},
+		{operation: "unknown", want: "default"},
+	}
+	for _, tt := range tests {
+		if got := auth.GithubTokenFor(tt.operation); got != tt.want {
+			t.Errorf("GithubTokenFor(%s) = %s, want %s", tt.operation, got, tt.want)
+		}
+	}
+}
+
+func TestAuthValidate(t *testing.T) {
+	identities := map[string]Identity{
+		"bot":   {GithubToken: "bot"},
+		"empty": {},
+	}
+
+	tests := []struct {
+		name       string
+		operations map[string]string
+		wantErr    bool
+	}{
+		{name: "no operations"},
+		{name: "known operation", operations: map[string]string{OperationTag: "bot", OperationIssue: "bot"}},
+		{name: "unknown operation", operations: map[string]string{"merge": "bot"}, wantErr: true},
+		{name: "unknown identity", operations: map[string]string{OperationTag: "human"}, wantErr: true},
+		{name: "identity without token", operations: map[string]string{OperationTag: "empty"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			auth := &Auth{Identities: identities, Operations: tt.operations}
+			if err := auth.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -20,6 +20,13 @@ func imageBuildBaseReleaseCommand() *cli.Command {
 				EnvVars:  []string{"GITHUB_TOKEN"},
 				Required: true,
 			},
+			&cli.StringFlag{
+				Name:     "owner",
+				Aliases:  []string{"o"},
+				Usage:    "organization to create the releases in, e.g. a sandbox organization for testing",
+				Value:    "rancher",
+				Required: false,
+			},
 			&cli.BoolFlag{
 				Name:     "dry-run",
 				Aliases:  []string{"r"},
@@ -38,5 +45,7 @@ func imageBuildBaseRelease(c *cli.Context) error {
 	dryRun := c.Bool("dry-run")
 	ctx := context.Background()
 	ghClient := repository.NewGithub(ctx, token)
-	return rke2.ImageBuildBaseRelease(ctx, ghClient, dryRun)
+	// there's no config, the golang images are checked for the default
+	// platforms.
+	return rke2.ImageBuildBaseRelease(ctx, ghClient, c.String("owner"), nil, dryRun)
 }
@@ -67,9 +67,11 @@ func main() {
 
 	client := repository.NewGithub(ctx, ghToken)
 
+	releaseClient := release.NewClient(client)
+
 	upstreamBranches := strings.Split(branches, ",")
 	for _, branch := range upstreamBranches {
-		version, err := release.KubernetesGoVersion(ctx, client, branch)
+		version, err := releaseClient.KubernetesGoVersion(ctx, branch)
 		if err != nil {
 			fmt.Println(err)
 			os.Exit(1)
@@ -0,0 +1,167 @@
+package http
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// ConditionalTransport is an http.RoundTripper that stores the responses to
+// GET requests along with their ETag and Last-Modified headers, and
+// revalidates them with conditional requests, so unchanged files, e.g.
+// go.mod files and image lists, aren't downloaded again. Bodies are read
+// completely before being returned, so a truncated response results in an
+// error rather than a partial body.
+type ConditionalTransport struct {
+	base http.RoundTripper
+	dir  string
+}
+
+// NewConditionalTransport creates a new transport wrapping the given one and
+// storing responses in the given directory. An empty directory disables
+// the conditional requests, but bodies are still read completely.
+func NewConditionalTransport(base http.RoundTripper, dir string) *ConditionalTransport {
+	return &ConditionalTransport{
+		base: base,
+		dir:  dir,
+	}
+}
+
+// DefaultCacheDir returns the directory responses are stored in by default,
+// or an empty string if the user has no cache directory.
+func DefaultCacheDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+
+	return filepath.Join(dir, "ecm-distro-tools", "http")
+}
+
+type storedResponse struct {
+	URL    string      `json:"url"`
+	Header http.Header `json:"header"`
+	Body   []byte      `json:"body"`
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *ConditionalTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet || req.Header.Get("Range") != "" {
+		return t.base.RoundTrip(req)
+	}
+
+	var file string
+	var stored *storedResponse
+	if t.dir != "" {
+		sum := sha256.Sum256([]byte(req.URL.String()))
+		file = filepath.Join(t.dir, hex.EncodeToString(sum[:])+".json")
+		stored = load(file, req.URL.String())
+	}
+
+	if stored != nil {
+		req = req.Clone(req.Context())
+		if etag := stored.Header.Get("ETag"); etag != "" {
+			req.Header.Set("If-None-Match", etag)
+		}
+		if lastModified := stored.Header.Get("Last-Modified"); lastModified != "" {
+			req.Header.Set("If-Modified-Since", lastModified)
+		}
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusNotModified && stored != nil {
+		resp.Body.Close()
+		return stored.response(req), nil
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return resp, nil
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, errors.New("failed to read " + req.URL.Redacted() + ": " + err.Error())
+	}
+	if resp.ContentLength >= 0 && int64(len(body)) != resp.ContentLength {
+		return nil, errors.New("incomplete response from " + req.URL.Redacted() + ": read " + strconv.Itoa(len(body)) + " of " + strconv.FormatInt(resp.ContentLength, 10) + " bytes")
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	if file != "" && (resp.Header.Get("ETag") != "" || resp.Header.Get("Last-Modified") != "") {
+		// failing to store the response only means it's downloaded again.
+		_ = store(file, &storedResponse{URL: req.URL.String(), Header: resp.Header, Body: body})
+	}
+
+	return resp, nil
+}
+
+func (s *storedResponse) response(req *http.Request) *http.Response {
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        s.Header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(s.Body)),
+		ContentLength: int64(len(s.Body)),
+		Request:       req,
+	}
+}
+
+// load returns the response stored in the given file, or nil if there's
+// none or it can't be read.
+func load(file, url string) *storedResponse {
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return nil
+	}
+
+	var s storedResponse
+	if err := json.Unmarshal(b, &s); err != nil || s.URL != url {
+		return nil
+	}
+
+	return &s
+}
+
+// store writes the response to a temporary file first and renames it, so
+// interrupted writes never leave a partial response behind.
+func store(file string, s *storedResponse) error {
+	b, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(file), ".response-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), file)
+}
@@ -1,10 +1,149 @@
 package http
 
 import (
+	"fmt"
+	"io"
 	"net/http"
+	"sort"
+	"sync"
 	"time"
 )
 
+// DefaultUserAgent is the User-Agent set by the DefaultTransport unless
+// configured otherwise.
+const DefaultUserAgent = "ecm-distro-tools"
+
+// DefaultTransport is the transport shared by all clients created with
+// NewClient, so requests across the whole invocation are instrumented.
+var DefaultTransport = NewTransport(http.DefaultTransport, DefaultUserAgent)
+
 func NewClient(timeout time.Duration) http.Client {
-	return http.Client{Timeout: timeout}
+	return http.Client{
+		Timeout:   timeout,
+		Transport: DefaultTransport,
+	}
+}
+
+// HostStats contains the request metrics for a single host.
+type HostStats struct {
+	Requests int
+	Errors   int
+	Latency  time.Duration
+}
+
+// Transport is an http.RoundTripper that sets the User-Agent of every
+// request, records per host request counts and latency, and optionally
+// logs every request.
+type Transport struct {
+	base      http.RoundTripper
+	mu        sync.Mutex
+	userAgent string
+	log       io.Writer
+	stats     map[string]*HostStats
+}
+
+// NewTransport creates a new transport wrapping the given one.
+func NewTransport(base http.RoundTripper, userAgent string) *Transport {
+	return &Transport{
+		base:      base,
+		userAgent: userAgent,
+		stats:     make(map[string]*HostStats),
+	}
+}
+
+// SetUserAgent sets the User-Agent of the requests that don't have one.
+func (t *Transport) SetUserAgent(userAgent string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.userAgent = userAgent
+}
+
+// SetBase sets the transport the requests are sent with, e.g. one created
+// with NewBaseTransport.
+func (t *Transport) SetBase(base http.RoundTripper) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.base = base
+}
+
+// SetLog sets where every request is logged to, nil disables logging.
+func (t *Transport) SetLog(w io.Writer) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.log = w
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.mu.Lock()
+	base, userAgent, log := t.base, t.userAgent, t.log
+	t.mu.Unlock()
+
+	// don't override User-Agents set by the callers, e.g. go-github's.
+	if req.Header.Get("User-Agent") == "" || req.Header.Get("User-Agent") == "go-github" {
+		req = req.Clone(req.Context())
+		req.Header.Set("User-Agent", userAgent)
+	}
+
+	start := time.Now()
+	resp, err := base.RoundTrip(req)
+	latency := time.Since(start)
+
+	t.mu.Lock()
+	stats, ok := t.stats[req.URL.Host]
+	if !ok {
+		stats = &HostStats{}
+		t.stats[req.URL.Host] = stats
+	}
+	stats.Requests++
+	stats.Latency += latency
+	if err != nil || resp.StatusCode >= http.StatusBadRequest {
+		stats.Errors++
+	}
+	t.mu.Unlock()
+
+	if log != nil {
+		var status string
+		if err != nil {
+			status = "error: " + err.Error()
+		} else {
+			status = resp.Status
+		}
+		fmt.Fprintf(log, "%s %s %s %s\n", req.Method, req.URL.Redacted(), status, latency.Round(time.Millisecond))
+	}
+
+	return resp, err
+}
+
+// Stats returns a copy of the request metrics per host.
+func (t *Transport) Stats() map[string]HostStats {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	stats := make(map[string]HostStats, len(t.stats))
+	for host, s := range t.stats {
+		stats[host] = *s
+	}
+
+	return stats
+}
+
+// WriteStats writes a summary of the request metrics per host.
+func (t *Transport) WriteStats(w io.Writer) {
+	stats := t.Stats()
+
+	hosts := make([]string, 0, len(stats))
+	for host := range stats {
+		hosts = append(hosts, host)
+	}
+	sort.Strings(hosts)
+
+	for _, host := range hosts {
+		s := stats[host]
+		avg := s.Latency / time.Duration(s.Requests)
+		fmt.Fprintf(w, "%s: %d requests, %d errors, %s avg latency\n", host, s.Requests, s.Errors, avg.Round(time.Millisecond))
+	}
 }
@@ -0,0 +1,109 @@
+package http
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestTransport(t *testing.T) {
+	var userAgents []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userAgents = append(userAgents, r.Header.Get("User-Agent"))
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	transport := NewTransport(http.DefaultTransport, "ecm-distro-tools/test")
+	log := bytes.NewBuffer(nil)
+	transport.SetLog(log)
+	client := http.Client{Transport: transport}
+
+	for _, path := range []string{"/", "/missing"} {
+		resp, err := client.Get(server.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+
+	req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("User-Agent", "custom")
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	want := []string{"ecm-distro-tools/test", "ecm-distro-tools/test", "custom"}
+	for i := range want {
+		if userAgents[i] != want[i] {
+			t.Errorf("User-Agent = %q, want %q", userAgents[i], want[i])
+		}
+	}
+
+	stats := transport.Stats()[strings.TrimPrefix(server.URL, "http://")]
+	if stats.Requests != 3 || stats.Errors != 1 {
+		t.Errorf("Stats() = %+v, want 3 requests and 1 error", stats)
+	}
+
+	if lines := strings.Count(log.String(), "\n"); lines != 3 {
+		t.Errorf("expected 3 logged requests, got %d: %q", lines, log.String())
+	}
+}
+
+func TestConditionalTransport(t *testing.T) {
+	var conditional []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conditional = append(conditional, r.Header.Get("If-None-Match"))
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Write([]byte("module github.com/k3s-io/k3s\n"))
+	}))
+	defer server.Close()
+
+	client := http.Client{Transport: NewConditionalTransport(http.DefaultTransport, t.TempDir())}
+
+	for i := 0; i < 2; i++ {
+		resp, err := client.Get(server.URL + "/go.mod")
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != http.StatusOK || string(b) != "module github.com/k3s-io/k3s\n" {
+			t.Errorf("request %d: got %d %q", i, resp.StatusCode, b)
+		}
+	}
+
+	if want := []string{"", `"v1"`}; len(conditional) != 2 || conditional[0] != want[0] || conditional[1] != want[1] {
+		t.Errorf("If-None-Match headers = %q, want %q", conditional, want)
+	}
+}
+
+func TestConditionalTransportIncompleteResponse(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", "100")
+		w.Write([]byte("partial"))
+	}))
+	defer server.Close()
+
+	client := http.Client{Transport: NewConditionalTransport(http.DefaultTransport, "")}
+	if _, err := client.Get(server.URL); err == nil {
+		t.Error("expected an error for the incomplete response")
+	}
+}
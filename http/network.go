@@ -0,0 +1,216 @@
+package http
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/net/http/httpproxy"
+)
+
+const (
+	// ProxyAuthBasic authenticates to the proxy with the credentials of
+	// every request.
+	ProxyAuthBasic = "basic"
+	// ProxyAuthNTLM authenticates the tunnels to the proxy with an NTLMv2
+	// handshake.
+	ProxyAuthNTLM = "ntlm"
+)
+
+// NetworkConfig configures how the HTTP and registry clients reach the
+// network, e.g. from inside a corporate network with its own CAs and an
+// authenticated proxy.
+type NetworkConfig struct {
+	// CABundle is a PEM file of CAs trusted in addition to the system ones.
+	CABundle string `json:"ca_bundle,omitempty"`
+	// Proxy is the URL of the proxy, e.g. http://proxy.example.com:3128.
+	// The HTTPS_PROXY, HTTP_PROXY and NO_PROXY variables are used if empty.
+	Proxy string `json:"proxy,omitempty"`
+	// NoProxy lists the hosts, domains and CIDRs reached directly, in the
+	// format of the NO_PROXY variable.
+	NoProxy string `json:"no_proxy,omitempty"`
+	// ProxyAuth is the authentication scheme of the proxy, basic or ntlm.
+	ProxyAuth     string `json:"proxy_auth,omitempty"`
+	ProxyUsername string `json:"proxy_username,omitempty"`
+	ProxyPassword string `json:"proxy_password,omitempty"`
+}
+
+// NewBaseTransport returns a transport configured with the CAs and proxy of
+// the given config, to be wrapped by the instrumented transports.
+func NewBaseTransport(cfg NetworkConfig) (*http.Transport, error) {
+	base := http.DefaultTransport.(*http.Transport).Clone()
+
+	if cfg.CABundle != "" {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		pem, err := os.ReadFile(os.ExpandEnv(cfg.CABundle))
+		if err != nil {
+			return nil, err
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, errors.New("no certificates found in " + cfg.CABundle)
+		}
+		base.TLSClientConfig = &tls.Config{RootCAs: pool}
+	}
+
+	proxyConfig := httpproxy.FromEnvironment()
+	if cfg.Proxy != "" {
+		proxyConfig.HTTPProxy = cfg.Proxy
+		proxyConfig.HTTPSProxy = cfg.Proxy
+	}
+	if cfg.NoProxy != "" {
+		proxyConfig.NoProxy = cfg.NoProxy
+	}
+	proxyFunc := proxyConfig.ProxyFunc()
+
+	switch cfg.ProxyAuth {
+	case "":
+		base.Proxy = func(req *http.Request) (*url.URL, error) {
+			return proxyFunc(req.URL)
+		}
+	case ProxyAuthBasic:
+		base.Proxy = func(req *http.Request) (*url.URL, error) {
+			proxyURL, err := proxyFunc(req.URL)
+			if proxyURL == nil || err != nil {
+				return proxyURL, err
+			}
+			// the transport sends the user info of the proxy URL as basic
+			// credentials, both to forward requests and to open tunnels.
+			withAuth := *proxyURL
+			withAuth.User = url.UserPassword(cfg.ProxyUsername, cfg.ProxyPassword)
+			return &withAuth, nil
+		}
+	case ProxyAuthNTLM:
+		// NTLM authenticates connections rather than requests, so every
+		// request goes through a tunnel authenticated when it's opened.
+		domain, username := splitDomain(cfg.ProxyUsername)
+		dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+		base.Proxy = nil
+		base.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+			proxyURL, err := proxyFunc(&url.URL{Scheme: "https", Host: addr})
+			if err != nil {
+				return nil, err
+			}
+			if proxyURL == nil {
+				return dialer.DialContext(ctx, network, addr)
+			}
+			return dialNTLMTunnel(ctx, dialer, proxyURL, addr, domain, username, cfg.ProxyPassword)
+		}
+	default:
+		return nil, errors.New("invalid proxy auth: " + cfg.ProxyAuth + ", expected one of: " + ProxyAuthBasic + ", " + ProxyAuthNTLM)
+	}
+
+	return base, nil
+}
+
+// splitDomain splits a DOMAIN\user username.
+func splitDomain(username string) (string, string) {
+	if domain, user, ok := strings.Cut(username, `\`); ok {
+		return domain, user
+	}
+
+	return "", username
+}
+
+// dialNTLMTunnel opens a tunnel to addr through the proxy, authenticating
+// it with an NTLM negotiate, challenge and authenticate exchange over the
+// same connection.
+func dialNTLMTunnel(ctx context.Context, dialer *net.Dialer, proxyURL *url.URL, addr, domain, username, password string) (net.Conn, error) {
+	proxyAddr := proxyURL.Host
+	if proxyURL.Port() == "" {
+		proxyAddr = net.JoinHostPort(proxyURL.Hostname(), "80")
+	}
+	conn, err := dialer.DialContext(ctx, "tcp", proxyAddr)
+	if err != nil {
+		return nil, err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+		defer conn.SetDeadline(time.Time{})
+	}
+
+	br := bufio.NewReader(conn)
+
+	resp, err := connect(conn, br, addr, "NTLM "+base64.StdEncoding.EncodeToString(ntlmNegotiate()))
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusProxyAuthRequired {
+		conn.Close()
+		return nil, errors.New("expected an NTLM challenge from the proxy, got " + resp.Status)
+	}
+
+	var challenge []byte
+	for _, header := range resp.Header.Values("Proxy-Authenticate") {
+		if encoded, ok := strings.CutPrefix(header, "NTLM "); ok {
+			challenge, err = base64.StdEncoding.DecodeString(encoded)
+			if err != nil {
+				conn.Close()
+				return nil, errors.New("invalid NTLM challenge: " + err.Error())
+			}
+		}
+	}
+	if challenge == nil {
+		conn.Close()
+		return nil, errors.New("the proxy doesn't support NTLM authentication")
+	}
+
+	authenticate, err := ntlmAuthenticate(challenge, domain, username, password, time.Now())
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	resp, err = connect(conn, br, addr, "NTLM "+base64.StdEncoding.EncodeToString(authenticate))
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		conn.Close()
+		return nil, errors.New("failed to authenticate to the proxy: " + resp.Status)
+	}
+
+	return conn, nil
+}
+
+// connect sends a CONNECT request with the given proxy authorization and
+// reads its response, discarding its body so the connection can be reused.
+func connect(conn net.Conn, br *bufio.Reader, addr, authorization string) (*http.Response, error) {
+	req := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: addr},
+		Host:   addr,
+		Header: http.Header{
+			"Proxy-Authorization": {authorization},
+			"Proxy-Connection":    {"Keep-Alive"},
+		},
+	}
+	if err := req.Write(conn); err != nil {
+		return nil, err
+	}
+
+	resp, err := http.ReadResponse(br, req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+	}
+
+	return resp, nil
+}
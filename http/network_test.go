@@ -0,0 +1,176 @@
+package http
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/pem"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNTLMv2Responses(t *testing.T) {
+	// test vectors of MS-NLMP 4.2.4.
+	key := ntowfv2("Domain", "User", "Password")
+	if got, want := hex.EncodeToString(key), "0c868a403bfd7a93a3001ef22ef02e3f"; got != want {
+		t.Fatalf("ntowfv2() = %s, want %s", got, want)
+	}
+
+	serverChallenge, _ := hex.DecodeString("0123456789abcdef")
+	clientChallenge := bytes.Repeat([]byte{0xaa}, 8)
+	targetInfo := append(append([]byte{2, 0, 12, 0}, utf16le("Domain")...), append(append([]byte{1, 0, 12, 0}, utf16le("Server")...), 0, 0, 0, 0)...)
+
+	lm, nt := ntlmv2Responses(key, serverChallenge, clientChallenge, targetInfo, windowsEpoch)
+	if got, want := hex.EncodeToString(lm), "86c35097ac9cec102554764a57cccc19aaaaaaaaaaaaaaaa"; got != want {
+		t.Errorf("LMv2 response = %s, want %s", got, want)
+	}
+	if got, want := hex.EncodeToString(nt[:16]), "68cd0ab851e51c96aabc927bebef6a1c"; got != want {
+		t.Errorf("NTProofStr = %s, want %s", got, want)
+	}
+}
+
+// ntlmProxy is a proxy requiring NTLM authentication of its tunnels, which
+// checks the NTLMv2 response of the given user and tunnels every request to
+// target.
+func ntlmProxy(t *testing.T, target, domain, username, password string) net.Listener {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	serverChallenge := []byte("01234567")
+	challenge := make([]byte, 48)
+	copy(challenge, ntlmSignature)
+	binary.LittleEndian.PutUint32(challenge[8:], 2)
+	binary.LittleEndian.PutUint32(challenge[20:], ntlmFlags)
+	copy(challenge[24:], serverChallenge)
+	binary.LittleEndian.PutUint32(challenge[44:], 48)
+
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				br := bufio.NewReader(conn)
+
+				for {
+					req, err := http.ReadRequest(br)
+					if err != nil {
+						return
+					}
+					encoded := strings.TrimPrefix(req.Header.Get("Proxy-Authorization"), "NTLM ")
+					msg, _ := base64.StdEncoding.DecodeString(encoded)
+					if len(msg) < 12 || binary.LittleEndian.Uint32(msg[8:]) == 1 {
+						io.WriteString(conn, "HTTP/1.1 407 Proxy Authentication Required\r\nProxy-Authenticate: NTLM "+base64.StdEncoding.EncodeToString(challenge)+"\r\nContent-Length: 0\r\n\r\n")
+						continue
+					}
+
+					length := binary.LittleEndian.Uint16(msg[20:])
+					offset := binary.LittleEndian.Uint32(msg[24:])
+					nt := msg[offset : offset+uint32(length)]
+					proof := hmacMD5(ntowfv2(domain, username, password), serverChallenge, nt[16:])
+					if !bytes.Equal(proof, nt[:16]) {
+						io.WriteString(conn, "HTTP/1.1 407 Proxy Authentication Required\r\nContent-Length: 0\r\n\r\n")
+						return
+					}
+
+					upstream, err := net.Dial("tcp", target)
+					if err != nil {
+						return
+					}
+					defer upstream.Close()
+					io.WriteString(conn, "HTTP/1.1 200 Connection established\r\n\r\n")
+					go io.Copy(upstream, br)
+					io.Copy(conn, upstream)
+					return
+				}
+			}()
+		}
+	}()
+
+	return l
+}
+
+func TestNewBaseTransport(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "ok")
+	}))
+	defer server.Close()
+
+	bundle := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(bundle, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	proxy := ntlmProxy(t, server.Listener.Addr().String(), "SUSE", "captain", "secret")
+	defer proxy.Close()
+	proxyURL := "http://" + proxy.Addr().String()
+	// loopback hosts are never proxied, so requests through the proxy use
+	// one of the names of the test certificate, which it tunnels to server.
+	_, port, _ := net.SplitHostPort(server.Listener.Addr().String())
+	proxiedURL := "https://example.com:" + port
+
+	tests := []struct {
+		name    string
+		cfg     NetworkConfig
+		url     string
+		wantErr bool
+	}{
+		{
+			name:    "untrusted CA",
+			cfg:     NetworkConfig{NoProxy: "*"},
+			url:     server.URL,
+			wantErr: true,
+		},
+		{
+			name: "custom CA bundle",
+			cfg:  NetworkConfig{CABundle: bundle, NoProxy: "*"},
+			url:  server.URL,
+		},
+		{
+			name: "ntlm proxy",
+			cfg:  NetworkConfig{CABundle: bundle, Proxy: proxyURL, NoProxy: "-", ProxyAuth: ProxyAuthNTLM, ProxyUsername: `SUSE\captain`, ProxyPassword: "secret"},
+			url:  proxiedURL,
+		},
+		{
+			name:    "ntlm proxy with wrong password",
+			cfg:     NetworkConfig{CABundle: bundle, Proxy: proxyURL, NoProxy: "-", ProxyAuth: ProxyAuthNTLM, ProxyUsername: `SUSE\captain`, ProxyPassword: "wrong"},
+			url:     proxiedURL,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			base, err := NewBaseTransport(tt.cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			client := http.Client{Transport: base}
+
+			resp, err := client.Get(tt.url)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Get() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil {
+				resp.Body.Close()
+			}
+		})
+	}
+
+	if _, err := NewBaseTransport(NetworkConfig{ProxyAuth: "kerberos"}); err == nil {
+		t.Error("NewBaseTransport() expected error for invalid proxy auth")
+	}
+}
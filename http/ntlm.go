@@ -0,0 +1,159 @@
+package http
+
+import (
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"strings"
+	"time"
+	"unicode/utf16"
+
+	"golang.org/x/crypto/md4"
+)
+
+// NTLM messages, as described in MS-NLMP. Only NTLMv2 is supported.
+
+const (
+	ntlmSignature = "NTLMSSP\x00"
+
+	ntlmNegotiateUnicode            = 0x00000001
+	ntlmRequestTarget               = 0x00000004
+	ntlmNegotiateNTLM               = 0x00000200
+	ntlmNegotiateAlwaysSign         = 0x00008000
+	ntlmNegotiateExtendedSessionSec = 0x00080000
+	ntlmNegotiateTargetInfo         = 0x00800000
+	ntlmNegotiate128                = 0x20000000
+	ntlmNegotiate56                 = 0x80000000
+
+	ntlmFlags = ntlmNegotiateUnicode | ntlmRequestTarget | ntlmNegotiateNTLM | ntlmNegotiateAlwaysSign |
+		ntlmNegotiateExtendedSessionSec | ntlmNegotiateTargetInfo | ntlmNegotiate128 | ntlmNegotiate56
+
+	// ntlmAuthenticateHeaderSize is the size of the fixed part of the
+	// authenticate message, without the optional version and MIC.
+	ntlmAuthenticateHeaderSize = 64
+)
+
+// windowsEpoch is the start of the FILETIME timestamps of NTLMv2.
+var windowsEpoch = time.Date(1601, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func ntlmNegotiate() []byte {
+	msg := make([]byte, 32)
+	copy(msg, ntlmSignature)
+	binary.LittleEndian.PutUint32(msg[8:], 1)
+	binary.LittleEndian.PutUint32(msg[12:], ntlmFlags)
+	// empty domain and workstation fields, whose offsets point to the end.
+	binary.LittleEndian.PutUint32(msg[20:], 32)
+	binary.LittleEndian.PutUint32(msg[28:], 32)
+
+	return msg
+}
+
+// ntlmChallenge is the challenge message sent by the server.
+type ntlmChallenge struct {
+	flags      uint32
+	challenge  []byte
+	targetInfo []byte
+}
+
+func parseNTLMChallenge(msg []byte) (*ntlmChallenge, error) {
+	if len(msg) < 48 || string(msg[:8]) != ntlmSignature || binary.LittleEndian.Uint32(msg[8:]) != 2 {
+		return nil, errors.New("invalid NTLM challenge message")
+	}
+
+	c := ntlmChallenge{
+		flags:     binary.LittleEndian.Uint32(msg[20:]),
+		challenge: msg[24:32],
+	}
+
+	length := int(binary.LittleEndian.Uint16(msg[40:]))
+	offset := int(binary.LittleEndian.Uint32(msg[44:]))
+	if offset+length > len(msg) {
+		return nil, errors.New("invalid NTLM challenge target info")
+	}
+	c.targetInfo = msg[offset : offset+length]
+
+	return &c, nil
+}
+
+func utf16le(s string) []byte {
+	encoded := utf16.Encode([]rune(s))
+	b := make([]byte, 2*len(encoded))
+	for i, r := range encoded {
+		binary.LittleEndian.PutUint16(b[2*i:], r)
+	}
+
+	return b
+}
+
+func hmacMD5(key []byte, data ...[]byte) []byte {
+	h := hmac.New(md5.New, key)
+	for _, d := range data {
+		h.Write(d)
+	}
+
+	return h.Sum(nil)
+}
+
+// ntowfv2 is the NTLMv2 response key of the user.
+func ntowfv2(domain, username, password string) []byte {
+	h := md4.New()
+	h.Write(utf16le(password))
+
+	return hmacMD5(h.Sum(nil), utf16le(strings.ToUpper(username)+domain))
+}
+
+// ntlmv2Responses returns the LMv2 and NTLMv2 responses to the server
+// challenge.
+func ntlmv2Responses(key, serverChallenge, clientChallenge, targetInfo []byte, timestamp time.Time) ([]byte, []byte) {
+	temp := make([]byte, 28, 28+len(targetInfo)+4)
+	temp[0], temp[1] = 1, 1
+	binary.LittleEndian.PutUint64(temp[8:], uint64(timestamp.Sub(windowsEpoch).Nanoseconds()/100))
+	copy(temp[16:], clientChallenge)
+	temp = append(temp, targetInfo...)
+	temp = append(temp, 0, 0, 0, 0)
+
+	ntProof := hmacMD5(key, serverChallenge, temp)
+	lm := append(hmacMD5(key, serverChallenge, clientChallenge), clientChallenge...)
+
+	return lm, append(ntProof, temp...)
+}
+
+// ntlmAuthenticate returns the authenticate message answering the given
+// challenge message.
+func ntlmAuthenticate(challengeMsg []byte, domain, username, password string, now time.Time) ([]byte, error) {
+	challenge, err := parseNTLMChallenge(challengeMsg)
+	if err != nil {
+		return nil, err
+	}
+
+	clientChallenge := make([]byte, 8)
+	if _, err := rand.Read(clientChallenge); err != nil {
+		return nil, err
+	}
+
+	key := ntowfv2(domain, username, password)
+	lm, nt := ntlmv2Responses(key, challenge.challenge, clientChallenge, challenge.targetInfo, now)
+
+	fields := [][]byte{lm, nt, utf16le(domain), utf16le(username), nil, nil}
+
+	msg := make([]byte, ntlmAuthenticateHeaderSize)
+	copy(msg, ntlmSignature)
+	binary.LittleEndian.PutUint32(msg[8:], 3)
+	offset := ntlmAuthenticateHeaderSize
+	for i, field := range fields {
+		// lm, nt, domain, user, workstation and session key fields.
+		pos := 12 + 8*i
+		binary.LittleEndian.PutUint16(msg[pos:], uint16(len(field)))
+		binary.LittleEndian.PutUint16(msg[pos+2:], uint16(len(field)))
+		binary.LittleEndian.PutUint32(msg[pos+4:], uint32(offset))
+		offset += len(field)
+	}
+	binary.LittleEndian.PutUint32(msg[60:], challenge.flags&ntlmFlags)
+	for _, field := range fields {
+		msg = append(msg, field...)
+	}
+
+	return msg, nil
+}
@@ -0,0 +1,395 @@
+package http
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// maxRecordedBody is the size above which the recorded bodies are
+	// truncated, e.g. for the release artifacts downloads.
+	maxRecordedBody = 1 << 20
+	// redacted replaces the recorded credentials.
+	redacted = "[REDACTED]"
+)
+
+// sensitiveNames are parts of the names of the headers, query parameters,
+// form parameters and JSON fields holding credentials.
+var sensitiveNames = []string{"authorization", "cookie", "token", "secret", "password", "signature", "credential", "key"}
+
+func sensitive(name string) bool {
+	name = strings.ToLower(name)
+	for _, s := range sensitiveNames {
+		if strings.Contains(name, s) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Body is a recorded request or response body, base64 encoded if it isn't
+// text.
+type Body struct {
+	Data      string `json:"data,omitempty"`
+	Base64    bool   `json:"base64,omitempty"`
+	Truncated bool   `json:"truncated,omitempty"`
+}
+
+func newBody(b []byte, truncated bool) Body {
+	if utf8.Valid(b) {
+		return Body{Data: string(b), Truncated: truncated}
+	}
+
+	return Body{Data: base64.StdEncoding.EncodeToString(b), Base64: true, Truncated: truncated}
+}
+
+// Bytes returns the decoded body.
+func (b Body) Bytes() ([]byte, error) {
+	if b.Base64 {
+		return base64.StdEncoding.DecodeString(b.Data)
+	}
+
+	return []byte(b.Data), nil
+}
+
+// Exchange is a recorded request and its response.
+type Exchange struct {
+	Method         string        `json:"method"`
+	URL            string        `json:"url"`
+	RequestHeader  http.Header   `json:"request_header,omitempty"`
+	RequestBody    Body          `json:"request_body"`
+	Status         int           `json:"status,omitempty"`
+	ResponseHeader http.Header   `json:"response_header,omitempty"`
+	ResponseBody   Body          `json:"response_body"`
+	Err            string        `json:"error,omitempty"`
+	Duration       time.Duration `json:"duration"`
+}
+
+// Bundle is the requests of a run and their responses, with the
+// credentials redacted, to be attached to bug reports and replayed in
+// tests.
+type Bundle struct {
+	Command    string     `json:"command"`
+	RecordedAt time.Time  `json:"recorded_at"`
+	Exchanges  []Exchange `json:"exchanges"`
+}
+
+// LoadBundle reads a bundle written by Recorder.Save.
+func LoadBundle(path string) (*Bundle, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var bundle Bundle
+	if err := json.Unmarshal(b, &bundle); err != nil {
+		return nil, errors.New("invalid recording " + path + ": " + err.Error())
+	}
+
+	return &bundle, nil
+}
+
+// Recorder is an http.RoundTripper recording every request sent through it
+// and its response. The credentials of the headers, query parameters, and
+// of the JSON and form bodies are always redacted, and Redact, if set, is applied to everything recorded,
+// e.g. to redact the known secrets of the run.
+type Recorder struct {
+	Base   http.RoundTripper
+	Redact func(string) string
+
+	mu        sync.Mutex
+	exchanges []Exchange
+}
+
+// NewRecorder creates a recorder wrapping the given transport.
+func NewRecorder(base http.RoundTripper, redact func(string) string) *Recorder {
+	return &Recorder{Base: base, Redact: redact}
+}
+
+// RoundTrip implements http.RoundTripper. The response body is recorded as
+// it's read, up to its first megabyte, so large downloads still stream.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	e := Exchange{
+		Method:        req.Method,
+		URL:           r.redact(redactURL(req.URL)),
+		RequestHeader: r.redactHeader(req.Header),
+	}
+	if req.GetBody != nil {
+		if body, err := req.GetBody(); err == nil {
+			b, truncated := readLimited(body)
+			body.Close()
+			e.RequestBody = r.redactBody(b, req.Header.Get("Content-Type"), truncated)
+		}
+	}
+
+	start := time.Now()
+	resp, err := r.Base.RoundTrip(req)
+	e.Duration = time.Since(start)
+	if err != nil {
+		e.Err = r.redact(err.Error())
+		r.add(e)
+		return resp, err
+	}
+
+	e.Status = resp.StatusCode
+	e.ResponseHeader = r.redactHeader(resp.Header)
+	resp.Body = &recordedBody{ReadCloser: resp.Body, recorder: r, exchange: e}
+
+	return resp, nil
+}
+
+func (r *Recorder) add(e Exchange) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.exchanges = append(r.exchanges, e)
+}
+
+func (r *Recorder) redact(s string) string {
+	if r.Redact == nil {
+		return s
+	}
+
+	return r.Redact(s)
+}
+
+func (r *Recorder) redactHeader(header http.Header) http.Header {
+	redactedHeader := make(http.Header, len(header))
+	for name, values := range header {
+		for _, value := range values {
+			if sensitive(name) {
+				value = redacted
+			}
+			redactedHeader.Add(name, r.redact(value))
+		}
+	}
+
+	return redactedHeader
+}
+
+func (r *Recorder) redactBody(b []byte, contentType string, truncated bool) Body {
+	if utf8.Valid(b) {
+		b = []byte(r.redact(string(redactFields(b, contentType))))
+	}
+
+	return newBody(b, truncated)
+}
+
+// redactFields returns the given JSON or form body with the values of its
+// sensitive fields redacted, e.g. the token of an access token response.
+// The body is returned as is if it has none, or can't be parsed, e.g. once
+// truncated.
+func redactFields(b []byte, contentType string) []byte {
+	if strings.HasPrefix(contentType, "application/x-www-form-urlencoded") {
+		form, err := url.ParseQuery(string(b))
+		if err != nil {
+			return b
+		}
+		redactedForm := false
+		for name := range form {
+			if sensitive(name) {
+				form.Set(name, redacted)
+				redactedForm = true
+			}
+		}
+		if !redactedForm {
+			return b
+		}
+
+		return []byte(form.Encode())
+	}
+
+	if !json.Valid(b) {
+		return b
+	}
+	decoder := json.NewDecoder(bytes.NewReader(b))
+	decoder.UseNumber()
+	var v interface{}
+	if err := decoder.Decode(&v); err != nil || !redactJSON(v) {
+		return b
+	}
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(v); err != nil {
+		return b
+	}
+
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n"))
+}
+
+// redactJSON redacts the values of the sensitive fields of the given
+// decoded JSON, recursively, and returns true if any was.
+func redactJSON(v interface{}) bool {
+	var redactedField bool
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for name, value := range v {
+			if sensitive(name) {
+				v[name] = redacted
+				redactedField = true
+				continue
+			}
+			if redactJSON(value) {
+				redactedField = true
+			}
+		}
+	case []interface{}:
+		for _, value := range v {
+			if redactJSON(value) {
+				redactedField = true
+			}
+		}
+	}
+
+	return redactedField
+}
+
+// redactURL returns the URL without its password and the values of its
+// sensitive query parameters, e.g. the signature of presigned URLs.
+func redactURL(u *url.URL) string {
+	query := u.Query()
+	redactedQuery := false
+	for name := range query {
+		if sensitive(name) {
+			query.Set(name, redacted)
+			redactedQuery = true
+		}
+	}
+	if !redactedQuery {
+		return u.Redacted()
+	}
+
+	c := *u
+	c.RawQuery = query.Encode()
+
+	return c.Redacted()
+}
+
+func readLimited(r io.Reader) ([]byte, bool) {
+	b, _ := io.ReadAll(io.LimitReader(r, maxRecordedBody+1))
+	if len(b) > maxRecordedBody {
+		return b[:maxRecordedBody], true
+	}
+
+	return b, false
+}
+
+// recordedBody records the response body as it's read, and the exchange
+// once it's closed, so responses whose body is never closed aren't
+// recorded.
+type recordedBody struct {
+	io.ReadCloser
+	recorder *Recorder
+	exchange Exchange
+	buf      bytes.Buffer
+	size     int
+	once     sync.Once
+}
+
+func (b *recordedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if remaining := maxRecordedBody - b.buf.Len(); remaining > 0 {
+		b.buf.Write(p[:min(n, remaining)])
+	}
+	b.size += n
+
+	return n, err
+}
+
+func (b *recordedBody) Close() error {
+	b.once.Do(func() {
+		b.exchange.ResponseBody = b.recorder.redactBody(b.buf.Bytes(), b.exchange.ResponseHeader.Get("Content-Type"), b.size > b.buf.Len())
+		b.recorder.add(b.exchange)
+	})
+
+	return b.ReadCloser.Close()
+}
+
+// Bundle returns the exchanges recorded so far, in the order they
+// completed.
+func (r *Recorder) Bundle(command string) *Bundle {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return &Bundle{
+		Command:    r.redact(command),
+		RecordedAt: time.Now().UTC(),
+		Exchanges:  append([]Exchange{}, r.exchanges...),
+	}
+}
+
+// Save writes the exchanges recorded so far to the given path.
+func (r *Recorder) Save(path, command string) error {
+	b, err := json.MarshalIndent(r.Bundle(command), "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, append(b, '\n'), 0600)
+}
+
+// Replayer is an http.RoundTripper answering the requests with the
+// responses of a bundle, e.g. to reproduce a reported run in tests. Each
+// request is answered with the first exchange of the same method and URL
+// not replayed yet.
+type Replayer struct {
+	mu        sync.Mutex
+	exchanges []Exchange
+	replayed  []bool
+}
+
+// NewReplayer creates a replayer of the given bundle.
+func NewReplayer(bundle *Bundle) *Replayer {
+	return &Replayer{exchanges: bundle.Exchanges, replayed: make([]bool, len(bundle.Exchanges))}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (r *Replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+	u := redactURL(req.URL)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, e := range r.exchanges {
+		if r.replayed[i] || e.Method != req.Method || e.URL != u {
+			continue
+		}
+		r.replayed[i] = true
+
+		if e.Err != "" {
+			return nil, errors.New(e.Err)
+		}
+		body, err := e.ResponseBody.Bytes()
+		if err != nil {
+			return nil, err
+		}
+
+		return &http.Response{
+			Status:        strconv.Itoa(e.Status) + " " + http.StatusText(e.Status),
+			StatusCode:    e.Status,
+			Header:        e.ResponseHeader.Clone(),
+			Body:          io.NopCloser(bytes.NewReader(body)),
+			ContentLength: int64(len(body)),
+			Request:       req,
+		}, nil
+	}
+
+	return nil, errors.New("no recorded response for " + req.Method + " " + u)
+}
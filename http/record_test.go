@@ -0,0 +1,177 @@
+package http
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRecorder(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/rancher/rke2/releases":
+			b, _ := io.ReadAll(r.Body)
+			w.WriteHeader(http.StatusCreated)
+			w.Write(b)
+		case "/asset":
+			w.Write([]byte{0x1f, 0x8b, 0xff, 0x00})
+		default:
+			w.Header().Set("Set-Cookie", "session=abc")
+			w.Write([]byte(`{"token":"ghp_secret"}`))
+		}
+	}))
+	defer server.Close()
+
+	recorder := NewRecorder(http.DefaultTransport, func(s string) string {
+		return strings.ReplaceAll(s, "ghp_secret", "[REDACTED]")
+	})
+	client := http.Client{Transport: recorder}
+
+	req, err := http.NewRequest(http.MethodGet, server.URL+"/user?access_token=ghp_secret&per_page=100", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "token ghp_secret")
+	get(t, client, req)
+
+	req, err = http.NewRequest(http.MethodPost, server.URL+"/repos/rancher/rke2/releases", strings.NewReader(`{"tag_name":"v1.29.2+rke2r1"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	get(t, client, req)
+
+	req, err = http.NewRequest(http.MethodGet, server.URL+"/asset", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	get(t, client, req)
+
+	path := filepath.Join(t.TempDir(), "recording.json")
+	if err := recorder.Save(path, "release --config {\"auth\":{\"github_token\":\"ghp_secret\"}}"); err != nil {
+		t.Fatal(err)
+	}
+	bundle, err := LoadBundle(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Contains(bundle.Command, "ghp_secret") {
+		t.Errorf("Command = %q, want the secret redacted", bundle.Command)
+	}
+	if len(bundle.Exchanges) != 3 {
+		t.Fatalf("recorded %d exchanges, want 3", len(bundle.Exchanges))
+	}
+
+	user := bundle.Exchanges[0]
+	if user.URL != server.URL+"/user?access_token=%5BREDACTED%5D&per_page=100" {
+		t.Errorf("URL = %s, want the token redacted", user.URL)
+	}
+	if got := user.RequestHeader.Get("Authorization"); got != redacted {
+		t.Errorf("Authorization = %q, want it redacted", got)
+	}
+	if got := user.ResponseHeader.Get("Set-Cookie"); got != redacted {
+		t.Errorf("Set-Cookie = %q, want it redacted", got)
+	}
+	if got := user.ResponseBody.Data; got != `{"token":"[REDACTED]"}` {
+		t.Errorf("response body = %q, want the secret redacted", got)
+	}
+
+	release := bundle.Exchanges[1]
+	if release.Status != http.StatusCreated || release.RequestBody.Data != `{"tag_name":"v1.29.2+rke2r1"}` {
+		t.Errorf("exchange = %+v, want the request body recorded", release)
+	}
+	if asset := bundle.Exchanges[2]; !asset.ResponseBody.Base64 {
+		t.Errorf("asset body = %+v, want it base64 encoded", asset.ResponseBody)
+	}
+
+	// replay the bundle, the requests are answered without the server.
+	server.Close()
+	replay := http.Client{Transport: NewReplayer(bundle)}
+
+	req, err = http.NewRequest(http.MethodGet, server.URL+"/asset", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body := get(t, replay, req); body != string([]byte{0x1f, 0x8b, 0xff, 0x00}) {
+		t.Errorf("replayed body = %q", body)
+	}
+
+	req, err = http.NewRequest(http.MethodGet, server.URL+"/user?per_page=100&access_token=other", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body := get(t, replay, req); body != `{"token":"[REDACTED]"}` {
+		t.Errorf("replayed body = %q", body)
+	}
+
+	if _, err := replay.Get(server.URL + "/asset"); err == nil {
+		t.Error("expected an error once the recorded exchange was replayed")
+	}
+}
+
+func get(t *testing.T, client http.Client, req *http.Request) string {
+	t.Helper()
+
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return string(b)
+}
+
+func TestRedactFields(t *testing.T) {
+	tests := []struct {
+		name        string
+		body        string
+		contentType string
+		want        string
+	}{
+		{
+			name:        "json",
+			body:        `{"access_token":"gho_secret","token_type":"bearer","scope":"repo"}`,
+			contentType: "application/json",
+			want:        `{"access_token":"[REDACTED]","scope":"repo","token_type":"[REDACTED]"}`,
+		},
+		{
+			name:        "nested json",
+			body:        `[{"name":"ci","config":{"url":"https://ci.example.com","secret":"s3cr3t"}}]`,
+			contentType: "application/json; charset=utf-8",
+			want:        `[{"config":{"secret":"[REDACTED]","url":"https://ci.example.com"},"name":"ci"}]`,
+		},
+		{
+			name:        "json without credentials",
+			body:        `{"tag_name": "v1.29.2+rke2r1", "id": 12345678901234567890}`,
+			contentType: "application/json",
+			want:        `{"tag_name": "v1.29.2+rke2r1", "id": 12345678901234567890}`,
+		},
+		{
+			name:        "form",
+			body:        "grant_type=password&username=captain&password=hunter2",
+			contentType: "application/x-www-form-urlencoded",
+			want:        "grant_type=password&password=%5BREDACTED%5D&username=captain",
+		},
+		{
+			name:        "truncated json",
+			body:        `{"token":"ghp_sec`,
+			contentType: "application/json",
+			want:        `{"token":"ghp_sec`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(redactFields([]byte(tt.body), tt.contentType)); got != tt.want {
+				t.Errorf("redactFields() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
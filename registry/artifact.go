@@ -0,0 +1,141 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"io"
+	"path/filepath"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/static"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+)
+
+const (
+	// ArtifactType is the config media type of the release artifacts, which
+	// tools like ORAS report as their artifact type.
+	ArtifactType = "application/vnd.rancher.ecm-distro-tools.release.v1+json"
+
+	// TitleAnnotation holds the file name of a layer of an artifact, as set
+	// and read by ORAS.
+	TitleAnnotation = "org.opencontainers.image.title"
+)
+
+// mediaTypes are the media types of the files of release artifacts, by
+// extension.
+var mediaTypes = map[string]types.MediaType{
+	".md":   "text/markdown",
+	".json": "application/json",
+	".xml":  "application/xml",
+	".txt":  "text/plain",
+	".yaml": "application/yaml",
+}
+
+// ArtifactFile is a file of a release artifact, e.g. its release notes.
+type ArtifactFile struct {
+	Name    string
+	Content []byte
+}
+
+func (f ArtifactFile) mediaType() types.MediaType {
+	if mt, ok := mediaTypes[filepath.Ext(f.Name)]; ok {
+		return mt
+	}
+
+	return "application/octet-stream"
+}
+
+// NewArtifact returns an OCI artifact with a layer per file, each annotated
+// with its name so ORAS pulls them as files, and the given manifest
+// annotations, e.g. the version of the release.
+func NewArtifact(files []ArtifactFile, annotations map[string]string) (v1.Image, error) {
+	if len(files) == 0 {
+		return nil, errors.New("an artifact needs at least one file")
+	}
+
+	img := mutate.MediaType(empty.Image, types.OCIManifestSchema1)
+	img = mutate.ConfigMediaType(img, ArtifactType)
+
+	seen := make(map[string]bool, len(files))
+	for _, f := range files {
+		if seen[f.Name] {
+			return nil, errors.New("duplicated artifact file " + f.Name)
+		}
+		seen[f.Name] = true
+
+		var err error
+		img, err = mutate.Append(img, mutate.Addendum{
+			Layer:       static.NewLayer(f.Content, f.mediaType()),
+			Annotations: map[string]string{TitleAnnotation: f.Name},
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(annotations) > 0 {
+		img = mutate.Annotations(img, annotations).(v1.Image)
+	}
+
+	return img, nil
+}
+
+// PushArtifact pushes an artifact of the given files to the given reference
+// and returns its digest.
+func PushArtifact(ctx context.Context, ref name.Reference, files []ArtifactFile, annotations map[string]string) (string, error) {
+	img, err := NewArtifact(files, annotations)
+	if err != nil {
+		return "", err
+	}
+
+	if err := remote.Write(ref, img, remoteOptions(ctx)...); err != nil {
+		return "", errors.New("failed to push " + ref.String() + ": " + err.Error())
+	}
+
+	digest, err := img.Digest()
+	if err != nil {
+		return "", err
+	}
+
+	return digest.String(), nil
+}
+
+// PullArtifact returns the files of the artifact at the given reference.
+func PullArtifact(ctx context.Context, ref name.Reference) ([]ArtifactFile, error) {
+	img, err := remote.Image(ref, remoteOptions(ctx)...)
+	if err != nil {
+		return nil, err
+	}
+
+	manifest, err := img.Manifest()
+	if err != nil {
+		return nil, err
+	}
+	if manifest.Config.MediaType != ArtifactType {
+		return nil, errors.New(ref.String() + " isn't a release artifact, its config media type is " + string(manifest.Config.MediaType))
+	}
+
+	files := make([]ArtifactFile, 0, len(manifest.Layers))
+	for _, desc := range manifest.Layers {
+		layer, err := img.LayerByDigest(desc.Digest)
+		if err != nil {
+			return nil, err
+		}
+		rc, err := layer.Compressed()
+		if err != nil {
+			return nil, err
+		}
+		content, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, ArtifactFile{Name: desc.Annotations[TitleAnnotation], Content: content})
+	}
+
+	return files, nil
+}
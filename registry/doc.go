@@ -0,0 +1,6 @@
+// Package registry inspects and promotes container images.
+//
+// Client and RegistryClient are a public API: they follow the semantic
+// versioning of this repository's tags and are only broken in major
+// releases.
+package registry
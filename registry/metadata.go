@@ -0,0 +1,184 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+)
+
+const (
+	// VersionLabel is the OCI label holding the version of the software
+	// packaged by an image.
+	VersionLabel = "org.opencontainers.image.version"
+
+	referenceTypeAnnotation   = "vnd.docker.reference.type"
+	referenceDigestAnnotation = "vnd.docker.reference.digest"
+	predicateTypeAnnotation   = "in-toto.io/predicate-type"
+	attestationManifest       = "attestation-manifest"
+	spdxPredicateType         = "https://spdx.dev/Document"
+)
+
+// Package is a software package listed in the SBOM of an image.
+type Package struct {
+	Name    string
+	Version string
+}
+
+// Metadata contains the labels of an image and the packages listed in its
+// SBOM, if it has one.
+type Metadata struct {
+	Labels   map[string]string
+	Packages []Package
+}
+
+// ImageMetadata reads the labels and the SPDX SBOM attestation, as attached
+// by buildx, of the given image. For multi arch images, the linux/amd64
+// image is used.
+func ImageMetadata(ctx context.Context, ref name.Reference) (Metadata, error) {
+	var metadata Metadata
+
+	desc, err := remote.Get(ref, remoteOptions(ctx)...)
+	if err != nil {
+		return metadata, err
+	}
+
+	if !desc.MediaType.IsIndex() {
+		img, err := desc.Image()
+		if err != nil {
+			return metadata, err
+		}
+		metadata.Labels, err = imageLabels(img)
+		return metadata, err
+	}
+
+	idx, err := desc.ImageIndex()
+	if err != nil {
+		return metadata, err
+	}
+	manifest, err := idx.IndexManifest()
+	if err != nil {
+		return metadata, err
+	}
+
+	image, attestation, ok := platformManifests(manifest.Manifests, Platform{OS: "linux", Architecture: "amd64"})
+	if !ok {
+		return metadata, errors.New("no linux/amd64 image found for " + ref.String())
+	}
+
+	img, err := idx.Image(image.Digest)
+	if err != nil {
+		return metadata, err
+	}
+	if metadata.Labels, err = imageLabels(img); err != nil {
+		return metadata, err
+	}
+
+	if attestation == nil {
+		return metadata, nil
+	}
+	att, err := idx.Image(attestation.Digest)
+	if err != nil {
+		return metadata, err
+	}
+	metadata.Packages, err = sbomPackages(att)
+
+	return metadata, err
+}
+
+// platformManifests returns the manifest of the image of the given platform
+// and of its attestations, if any.
+func platformManifests(manifests []v1.Descriptor, platform Platform) (v1.Descriptor, *v1.Descriptor, bool) {
+	var image v1.Descriptor
+	var found bool
+	for _, m := range manifests {
+		if m.Platform != nil && m.Platform.OS == platform.OS && m.Platform.Architecture == platform.Architecture &&
+			m.Annotations[referenceTypeAnnotation] != attestationManifest {
+			image, found = m, true
+			break
+		}
+	}
+	if !found {
+		return image, nil, false
+	}
+
+	for i, m := range manifests {
+		if m.Annotations[referenceTypeAnnotation] == attestationManifest && m.Annotations[referenceDigestAnnotation] == image.Digest.String() {
+			return image, &manifests[i], true
+		}
+	}
+
+	return image, nil, true
+}
+
+func imageLabels(img v1.Image) (map[string]string, error) {
+	cfg, err := img.ConfigFile()
+	if err != nil {
+		return nil, err
+	}
+
+	return cfg.Config.Labels, nil
+}
+
+// sbomPackages returns the packages of the SPDX documents of the given
+// attestation manifest.
+func sbomPackages(att v1.Image) ([]Package, error) {
+	manifest, err := att.Manifest()
+	if err != nil {
+		return nil, err
+	}
+
+	var packages []Package
+	for _, layer := range manifest.Layers {
+		if layer.Annotations[predicateTypeAnnotation] != spdxPredicateType {
+			continue
+		}
+
+		l, err := att.LayerByDigest(layer.Digest)
+		if err != nil {
+			return nil, err
+		}
+		// attestations are stored as plain JSON blobs.
+		rc, err := l.Compressed()
+		if err != nil {
+			return nil, err
+		}
+		p, err := spdxPackages(rc)
+		rc.Close()
+		if err != nil {
+			return nil, err
+		}
+		packages = append(packages, p...)
+	}
+
+	return packages, nil
+}
+
+// spdxPackages parses an in-toto statement with an SPDX predicate.
+func spdxPackages(r io.Reader) ([]Package, error) {
+	var statement struct {
+		Predicate struct {
+			Packages []struct {
+				Name        string `json:"name"`
+				VersionInfo string `json:"versionInfo"`
+			} `json:"packages"`
+		} `json:"predicate"`
+	}
+	if err := json.NewDecoder(r).Decode(&statement); err != nil {
+		return nil, err
+	}
+
+	packages := make([]Package, 0, len(statement.Predicate.Packages))
+	for _, p := range statement.Predicate.Packages {
+		if p.VersionInfo == "" {
+			continue
+		}
+		packages = append(packages, Package{Name: p.Name, Version: p.VersionInfo})
+	}
+
+	return packages, nil
+}
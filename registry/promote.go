@@ -0,0 +1,121 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"path"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
+	ecmHTTP "github.com/rancher/ecm-distro-tools/http"
+)
+
+// Promotion describes the copy of an image from a source to a destination
+// repository, identified by the digest of its manifest or manifest list.
+type Promotion struct {
+	Source      name.Tag
+	Destination name.Tag
+	Digest      string
+	// Exists indicates the destination already points to the same digest,
+	// so there's nothing to copy.
+	Exists bool
+}
+
+// Retarget returns the tag of the given image under the given prefix, which
+// can be a registry, an org or both, e.g. registry.rancher.com/rancher.
+func Retarget(prefix string, ref name.Reference) (name.Tag, error) {
+	repo, err := name.NewRepository(prefix + "/" + path.Base(ref.Context().RepositoryStr()))
+	if err != nil {
+		return name.Tag{}, err
+	}
+
+	return name.NewTag(repo.String() + ":" + ref.Identifier())
+}
+
+// PlanPromotion resolves the digest of the source image and checks if the
+// destination already has it.
+func PlanPromotion(ctx context.Context, src, dst name.Tag) (Promotion, error) {
+	p := Promotion{
+		Source:      src,
+		Destination: dst,
+	}
+
+	srcDesc, err := remote.Head(src, remoteOptions(ctx)...)
+	if err != nil {
+		return p, errors.New("failed to get source image " + src.String() + ": " + err.Error())
+	}
+	p.Digest = srcDesc.Digest.String()
+
+	dstDesc, err := remote.Head(dst, remoteOptions(ctx)...)
+	if err != nil {
+		var transportErr *transport.Error
+		if errors.As(err, &transportErr) && transportErr.StatusCode == http.StatusNotFound {
+			return p, nil
+		}
+		return p, errors.New("failed to get destination image " + dst.String() + ": " + err.Error())
+	}
+	p.Exists = dstDesc.Digest.String() == p.Digest
+
+	return p, nil
+}
+
+// Promote copies the source image of the given promotion to its destination
+// through the registry API. The source is fetched by the planned digest, so
+// the tag being moved since doesn't change what's promoted. Manifests and
+// manifest lists are written as they are, preserving their digests, and
+// blobs are mounted across repositories when the source and destination
+// share the same registry.
+func Promote(ctx context.Context, p Promotion) error {
+	if p.Exists {
+		return nil
+	}
+	if p.Digest == "" {
+		return errors.New("no digest planned for " + p.Source.String())
+	}
+
+	desc, err := remote.Get(p.Source.Context().Digest(p.Digest), remoteOptions(ctx)...)
+	if err != nil {
+		return err
+	}
+
+	if desc.MediaType.IsIndex() {
+		idx, err := desc.ImageIndex()
+		if err != nil {
+			return err
+		}
+		return remote.WriteIndex(p.Destination, idx, remoteOptions(ctx)...)
+	}
+
+	img, err := desc.Image()
+	if err != nil {
+		return err
+	}
+
+	return remote.Write(p.Destination, img, remoteOptions(ctx)...)
+}
+
+// VerifyPromotion checks that the destination of the given promotion points
+// to the same digest as its source.
+func VerifyPromotion(ctx context.Context, p Promotion) error {
+	desc, err := remote.Head(p.Destination, remoteOptions(ctx)...)
+	if err != nil {
+		return err
+	}
+
+	if desc.Digest.String() != p.Digest {
+		return errors.New("digest mismatch for " + p.Destination.String() + ": expected " + p.Digest + ", got " + desc.Digest.String())
+	}
+
+	return nil
+}
+
+func remoteOptions(ctx context.Context) []remote.Option {
+	return []remote.Option{
+		remote.WithContext(ctx),
+		remote.WithAuthFromKeychain(authn.DefaultKeychain),
+		remote.WithTransport(ecmHTTP.DefaultTransport),
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/google/go-containerregistry/pkg/name"
 	"github.com/google/go-containerregistry/pkg/v1/remote"
 	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
+	ecmHTTP "github.com/rancher/ecm-distro-tools/http"
 )
 
 type Platform struct {
@@ -24,6 +25,14 @@ type Image struct {
 	Exists    bool
 }
 
+// RegistryClient is the interface implemented by Client, to be used by
+// consumers of this package so they can replace the registry.
+type RegistryClient interface {
+	Image(ctx context.Context, ref name.Reference) (Image, error)
+}
+
+var _ RegistryClient = (*Client)(nil)
+
 type Client struct {
 	registry string
 }
@@ -51,7 +60,7 @@ func (c *Client) Image(ctx context.Context, ref name.Reference) (Image, error) {
 		return info, err
 	}
 
-	desc, err := remote.Get(tagRef)
+	desc, err := remote.Get(tagRef, remote.WithContext(ctx), remote.WithTransport(ecmHTTP.DefaultTransport))
 	if err != nil {
 		var transportErr *transport.Error
 		if errors.As(err, &transportErr) && transportErr.StatusCode == http.StatusNotFound {
@@ -116,3 +125,17 @@ func (c *Client) handleSingleArchImage(desc *remote.Descriptor, info *Image) err
 
 	return nil
 }
+
+// Exists returns true if the given image exists in its own registry, e.g.
+// to check the images referenced by a Dockerfile before it's committed.
+func Exists(ctx context.Context, ref name.Reference) (bool, error) {
+	if _, err := remote.Head(ref, remoteOptions(ctx)...); err != nil {
+		var transportErr *transport.Error
+		if errors.As(err, &transportErr) && transportErr.StatusCode == http.StatusNotFound {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return true, nil
+}
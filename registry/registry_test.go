@@ -1,9 +1,19 @@
 package registry
 
 import (
+	"context"
+	"io"
+	"log"
+	"net/http/httptest"
+	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/google/go-containerregistry/pkg/name"
+	ggcrregistry "github.com/google/go-containerregistry/pkg/registry"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
 )
 
 func TestReplaceRegistry(t *testing.T) {
@@ -49,3 +59,189 @@ func TestReplaceRegistry(t *testing.T) {
 		})
 	}
 }
+
+func TestRetarget(t *testing.T) {
+	tests := []struct {
+		name     string
+		prefix   string
+		inputRef string
+		expected string
+	}{
+		{
+			name:     "change registry and org",
+			prefix:   "registry.example.com/rancher-staging",
+			inputRef: "docker.io/rancher/hardened-etcd:v3.5.9-k3s1",
+			expected: "registry.example.com/rancher-staging/hardened-etcd:v3.5.9-k3s1",
+		},
+		{
+			name:     "docker hub org",
+			prefix:   "docker.io/rancher",
+			inputRef: "registry.example.com/rancher-staging/rke2-runtime:v1.29.2-rke2r1",
+			expected: "index.docker.io/rancher/rke2-runtime:v1.29.2-rke2r1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ref, err := name.ParseReference(tt.inputRef)
+			if err != nil {
+				t.Fatalf("failed to parse input reference: %v", err)
+			}
+
+			result, err := Retarget(tt.prefix, ref)
+			if err != nil {
+				t.Fatalf("Retarget() error = %v", err)
+			}
+
+			if result.Name() != tt.expected {
+				t.Errorf("Retarget() = %v, want %v", result.Name(), tt.expected)
+			}
+		})
+	}
+}
+
+func TestPlatformManifests(t *testing.T) {
+	amd64 := v1.Descriptor{
+		Digest:   v1.Hash{Algorithm: "sha256", Hex: "aaaa"},
+		Platform: &v1.Platform{OS: "linux", Architecture: "amd64"},
+	}
+	arm64 := v1.Descriptor{
+		Digest:   v1.Hash{Algorithm: "sha256", Hex: "bbbb"},
+		Platform: &v1.Platform{OS: "linux", Architecture: "arm64"},
+	}
+	attestation := v1.Descriptor{
+		Digest:   v1.Hash{Algorithm: "sha256", Hex: "cccc"},
+		Platform: &v1.Platform{OS: "unknown", Architecture: "unknown"},
+		Annotations: map[string]string{
+			referenceTypeAnnotation:   attestationManifest,
+			referenceDigestAnnotation: "sha256:aaaa",
+		},
+	}
+
+	image, att, ok := platformManifests([]v1.Descriptor{arm64, attestation, amd64}, Platform{OS: "linux", Architecture: "amd64"})
+	if !ok || image.Digest != amd64.Digest {
+		t.Fatalf("platformManifests() = %v, %t, want the linux/amd64 image", image, ok)
+	}
+	if att == nil || att.Digest != attestation.Digest {
+		t.Errorf("platformManifests() attestation = %v, want %v", att, attestation)
+	}
+
+	if _, att, ok := platformManifests([]v1.Descriptor{arm64, attestation}, Platform{OS: "linux", Architecture: "arm64"}); !ok || att != nil {
+		t.Errorf("expected the arm64 image without attestation, got %v, %t", att, ok)
+	}
+	if _, _, ok := platformManifests([]v1.Descriptor{arm64}, Platform{OS: "linux", Architecture: "s390x"}); ok {
+		t.Error("expected no s390x image")
+	}
+}
+
+func TestSPDXPackages(t *testing.T) {
+	const statement = `{
+  "_type": "https://in-toto.io/Statement/v0.1",
+  "predicateType": "https://spdx.dev/Document",
+  "predicate": {
+    "packages": [
+      {"name": "github.com/containerd/containerd", "versionInfo": "v1.7.11-k3s2"},
+      {"name": "github.com/opencontainers/runc", "versionInfo": "v1.1.12"},
+      {"name": "rke2-runtime"}
+    ]
+  }
+}`
+	packages, err := spdxPackages(strings.NewReader(statement))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Package{
+		{Name: "github.com/containerd/containerd", Version: "v1.7.11-k3s2"},
+		{Name: "github.com/opencontainers/runc", Version: "v1.1.12"},
+	}
+	if len(packages) != len(want) {
+		t.Fatalf("spdxPackages() = %v, want %v", packages, want)
+	}
+	for i := range want {
+		if packages[i] != want[i] {
+			t.Errorf("spdxPackages()[%d] = %v, want %v", i, packages[i], want[i])
+		}
+	}
+}
+
+func TestArtifact(t *testing.T) {
+	server := httptest.NewServer(ggcrregistry.New(ggcrregistry.Logger(log.New(io.Discard, "", 0))))
+	defer server.Close()
+
+	ref, err := name.NewTag(strings.TrimPrefix(server.URL, "http://") + "/rancher/release-artifacts/rke2:v1.29.2-rke2r1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	files := []ArtifactFile{
+		{Name: "release-notes.md", Content: []byte("# v1.29.2+rke2r1\n")},
+		{Name: "verification.json", Content: []byte(`{"violations":[]}`)},
+	}
+	ctx := context.Background()
+
+	digest, err := PushArtifact(ctx, ref, files, map[string]string{VersionLabel: "v1.29.2+rke2r1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(digest, "sha256:") {
+		t.Errorf("PushArtifact() digest = %s", digest)
+	}
+
+	got, err := PullArtifact(ctx, ref)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, files) {
+		t.Errorf("PullArtifact() = %v, want %v", got, files)
+	}
+
+	if _, err := NewArtifact([]ArtifactFile{files[0], files[0]}, nil); err == nil {
+		t.Error("NewArtifact() expected error for duplicated files")
+	}
+}
+
+func TestPromote(t *testing.T) {
+	server := httptest.NewServer(ggcrregistry.New(ggcrregistry.Logger(log.New(io.Discard, "", 0))))
+	defer server.Close()
+
+	host := strings.TrimPrefix(server.URL, "http://")
+	src, err := name.NewTag(host + "/rancher/rke2-runtime:v1.29.2-rke2r1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dst, err := name.NewTag(host + "/prime/rke2-runtime:v1.29.2-rke2r1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	planned, err := random.Image(64, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := remote.Write(src, planned, remoteOptions(ctx)...); err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := PlanPromotion(ctx, src, dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the source tag moves between the plan and the promotion.
+	moved, err := random.Image(64, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := remote.Write(src, moved, remoteOptions(ctx)...); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Promote(ctx, p); err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifyPromotion(ctx, p); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,162 @@
+package activity
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v39/github"
+	"github.com/rancher/ecm-distro-tools/repository"
+	"golang.org/x/mod/semver"
+)
+
+// Types of the releases.
+const (
+	GA         = "ga"
+	Prerelease = "prerelease"
+)
+
+// Release is a release published in one of the reported repos.
+type Release struct {
+	// Repo is the owner and name of the repo, e.g. rancher/rke2.
+	Repo        string    `json:"repo"`
+	Tag         string    `json:"tag"`
+	Name        string    `json:"name"`
+	Type        string    `json:"type"`
+	PublishedAt time.Time `json:"published_at"`
+	Author      string    `json:"author"`
+	URL         string    `json:"url"`
+}
+
+// Report lists the releases published across repos within a date range,
+// e.g. for the weekly engineering report.
+type Report struct {
+	// From and To are the first and last days of the range, both included.
+	From     time.Time `json:"from"`
+	To       time.Time `json:"to"`
+	Repos    []string  `json:"repos"`
+	Releases []Release `json:"releases"`
+}
+
+// releaseType classifies the given release as a prerelease if it's marked
+// as one or its tag has a prerelease version, e.g. v1.29.2-rc1+rke2r1, or GA
+// otherwise.
+func releaseType(release *github.RepositoryRelease) string {
+	if release.GetPrerelease() || semver.Prerelease(release.GetTagName()) != "" {
+		return Prerelease
+	}
+
+	return GA
+}
+
+// Collect lists the releases published in the given repos, owner/name,
+// from the first to the last day of the given range, oldest first. Drafts
+// aren't published, so they're skipped.
+func Collect(ctx context.Context, client *github.Client, repos []string, from, to time.Time) (*Report, error) {
+	if to.Before(from) {
+		return nil, errors.New("end date before start date")
+	}
+	end := to.AddDate(0, 0, 1)
+
+	report := Report{From: from, To: to, Repos: repos, Releases: []Release{}}
+	for _, r := range repos {
+		owner, repo, ok := strings.Cut(r, "/")
+		if !ok || owner == "" || repo == "" {
+			return nil, errors.New("invalid repo " + r + ", expected owner/name")
+		}
+
+		opts := &github.ListOptions{PerPage: 100}
+		for {
+			releases, res, err := client.Repositories.ListReleases(ctx, owner, repo, opts)
+			if err != nil {
+				return nil, repository.WrapGithubError(err, owner, repo, "")
+			}
+
+			// releases are listed newest first, the pages after a release
+			// older than the range only have older releases.
+			var older bool
+			for _, release := range releases {
+				published := release.GetPublishedAt().Time
+				if release.GetDraft() || published.IsZero() {
+					continue
+				}
+				if published.Before(from) {
+					older = true
+					continue
+				}
+				if !published.Before(end) {
+					continue
+				}
+
+				report.Releases = append(report.Releases, Release{
+					Repo:        r,
+					Tag:         release.GetTagName(),
+					Name:        release.GetName(),
+					Type:        releaseType(release),
+					PublishedAt: published,
+					Author:      release.GetAuthor().GetLogin(),
+					URL:         release.GetHTMLURL(),
+				})
+			}
+
+			if older || res.NextPage == 0 {
+				break
+			}
+			opts.Page = res.NextPage
+		}
+	}
+
+	sort.SliceStable(report.Releases, func(i, j int) bool {
+		return report.Releases[i].PublishedAt.Before(report.Releases[j].PublishedAt)
+	})
+
+	return &report, nil
+}
+
+// Count returns the number of releases of the given type.
+func (r *Report) Count(releaseType string) int {
+	var count int
+	for _, release := range r.Releases {
+		if release.Type == releaseType {
+			count++
+		}
+	}
+
+	return count
+}
+
+// Markdown renders the report as markdown, a table of the releases of each
+// repo with releases in the range.
+func (r *Report) Markdown() string {
+	var b strings.Builder
+	b.WriteString("# Releases from " + r.From.Format(time.DateOnly) + " to " + r.To.Format(time.DateOnly) + "\n\n")
+	b.WriteString(strconv.Itoa(len(r.Releases)) + " releases published, " + strconv.Itoa(r.Count(GA)) + " GA and " + strconv.Itoa(r.Count(Prerelease)) + " prereleases.\n")
+
+	for _, repo := range r.Repos {
+		var releases []Release
+		for _, release := range r.Releases {
+			if release.Repo == repo {
+				releases = append(releases, release)
+			}
+		}
+		if len(releases) == 0 {
+			continue
+		}
+
+		b.WriteString("\n## " + repo + "\n\n")
+		b.WriteString("| Release | Type | Published | Author |\n")
+		b.WriteString("|---|---|---|---|\n")
+		for _, release := range releases {
+			releaseType := "GA"
+			if release.Type == Prerelease {
+				releaseType = "Prerelease"
+			}
+			b.WriteString("| [" + release.Tag + "](" + release.URL + ") | " + releaseType + " | " + release.PublishedAt.Format(time.DateOnly) + " | " + release.Author + " |\n")
+		}
+	}
+
+	return b.String()
+}
@@ -0,0 +1,83 @@
+package activity
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v39/github"
+)
+
+func TestCollect(t *testing.T) {
+	var olderPage bool
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/rancher/rke2/releases", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("page") == "2" {
+			olderPage = true
+			fmt.Fprint(w, `[]`)
+			return
+		}
+		w.Header().Set("Link", `<`+r.URL.Path+`?page=2>; rel="next"`)
+		fmt.Fprint(w, `[
+			{"tag_name": "v1.29.3-rc1+rke2r1", "draft": true},
+			{"tag_name": "v1.29.2+rke2r1", "html_url": "https://github.com/rancher/rke2/releases/tag/v1.29.2+rke2r1", "published_at": "2024-03-07T23:00:00Z", "author": {"login": "captain"}},
+			{"tag_name": "v1.29.2-rc1+rke2r1", "html_url": "https://github.com/rancher/rke2/releases/tag/v1.29.2-rc1+rke2r1", "published_at": "2024-03-01T10:00:00Z", "author": {"login": "captain"}},
+			{"tag_name": "v1.29.1+rke2r1", "published_at": "2024-02-20T10:00:00Z"}
+		]`)
+	})
+	mux.HandleFunc("/repos/k3s-io/k3s/releases", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[
+			{"tag_name": "v1.29.3+k3s1", "published_at": "2024-03-08T00:00:00Z"},
+			{"tag_name": "v1.29.2+k3s1", "prerelease": true, "html_url": "https://github.com/k3s-io/k3s/releases/tag/v1.29.2+k3s1", "published_at": "2024-03-04T10:00:00Z"}
+		]`)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := github.NewClient(nil)
+	client.BaseURL, _ = url.Parse(server.URL + "/")
+
+	from := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 3, 7, 0, 0, 0, 0, time.UTC)
+	report, err := Collect(context.Background(), client, []string{"rancher/rke2", "k3s-io/k3s"}, from, to)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if olderPage {
+		t.Error("Collect() listed the page after the releases older than the range")
+	}
+
+	var got []string
+	for _, release := range report.Releases {
+		got = append(got, release.Repo+" "+release.Tag+" "+release.Type)
+	}
+	want := []string{
+		"rancher/rke2 v1.29.2-rc1+rke2r1 prerelease",
+		"k3s-io/k3s v1.29.2+k3s1 prerelease",
+		"rancher/rke2 v1.29.2+rke2r1 ga",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Collect() = %v, want %v", got, want)
+	}
+
+	markdown := report.Markdown()
+	for _, line := range []string{
+		"3 releases published, 1 GA and 2 prereleases.",
+		"| [v1.29.2+rke2r1](https://github.com/rancher/rke2/releases/tag/v1.29.2+rke2r1) | GA | 2024-03-07 | captain |",
+		"| [v1.29.2+k3s1](https://github.com/k3s-io/k3s/releases/tag/v1.29.2+k3s1) | Prerelease | 2024-03-04 |  |",
+	} {
+		if !strings.Contains(markdown, line) {
+			t.Errorf("markdown doesn't contain %q:\n%s", line, markdown)
+		}
+	}
+
+	if _, err := Collect(context.Background(), client, []string{"rke2"}, from, to); err == nil {
+		t.Error("Collect() of a repo without owner didn't return an error")
+	}
+}
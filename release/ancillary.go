@@ -0,0 +1,135 @@
+package release
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	kineRepo                 = "kine"
+	helmControllerRepo       = "helm-controller"
+	localPathProvisionerRepo = "local-path-provisioner"
+)
+
+// ancillaryProfile describes how to generate the release notes of a
+// component shipped by k3s that is released on its own.
+type ancillaryProfile struct {
+	owner string
+	// modules maps the names of the dependencies listed in the notes to
+	// the Go modules they are resolved from.
+	modules map[string]string
+}
+
+var ancillaryProfiles = map[string]ancillaryProfile{
+	kineRepo: {
+		owner: "k3s-io",
+		modules: map[string]string{
+			"Etcd":       "go.etcd.io/etcd/server/v3",
+			"SQLite":     "github.com/mattn/go-sqlite3",
+			"MySQL":      "github.com/go-sql-driver/mysql",
+			"PostgreSQL": "github.com/jackc/pgx",
+			"NATS":       "github.com/nats-io/nats.go",
+		},
+	},
+	helmControllerRepo: {
+		owner: "k3s-io",
+		modules: map[string]string{
+			"Kubernetes": "k8s.io/client-go",
+			"Wrangler":   "github.com/rancher/wrangler",
+			"Helm":       "helm.sh/helm/v3",
+		},
+	},
+	localPathProvisionerRepo: {
+		owner: "rancher",
+		modules: map[string]string{
+			"Kubernetes": "k8s.io/client-go",
+		},
+	},
+}
+
+// AncillaryRepos returns the k3s ancillary repos the release notes and
+// asset verification support, e.g. kine.
+func AncillaryRepos() []string {
+	repos := make([]string, 0, len(ancillaryProfiles))
+	for repo := range ancillaryProfiles {
+		repos = append(repos, repo)
+	}
+	sort.Strings(repos)
+
+	return repos
+}
+
+// AncillaryOwner returns the GitHub organization of the given k3s ancillary
+// repo.
+func AncillaryOwner(repo string) (string, error) {
+	profile, ok := ancillaryProfiles[repo]
+	if !ok {
+		return "", errors.New("invalid repo: it must be one of " + strings.Join(AncillaryRepos(), ", ") + ", received " + repo)
+	}
+
+	return profile.owner, nil
+}
+
+type ancillaryReleaseNoteData struct {
+	Name       string
+	Owner      string
+	Components []Component
+	releaseNoteData
+}
+
+func (rd *ancillaryReleaseNoteData) Fill(c *Client, milestone string) error {
+	profile, ok := ancillaryProfiles[rd.Name]
+	if !ok {
+		return errors.New("no release notes profile for " + rd.Name)
+	}
+	rd.Owner = profile.owner
+
+	modFile := c.goModFile(profile.owner+"/"+rd.Name, milestone)
+	if modFile == nil {
+		return nil
+	}
+
+	if modFile.Go != nil {
+		rd.Components = append(rd.Components, Component{Name: "Go", Version: modFile.Go.Version})
+	}
+
+	names := make([]string, 0, len(profile.modules))
+	for name := range profile.modules {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if version := modLibVersion(c.Log, modFile, profile.modules[name]); version != "" {
+			rd.Components = append(rd.Components, Component{Name: name, Version: version})
+		}
+	}
+
+	return nil
+}
+
+func (rd *ancillaryReleaseNoteData) Template() string {
+	return fmt.Sprintf(ancillaryReleaseNoteTemplate, rd.Name)
+}
+func (rd *ancillaryReleaseNoteData) Repo() string { return rd.Name }
+
+// This is the template for the release notes of k3s ancillary repos, which
+// list the versions of their main dependencies.
+const ancillaryReleaseNoteTemplate = `
+{{- define "%s" -}}
+<!-- {{.Milestone}} -->
+
+This release updates {{.Name}} to {{.Milestone}}.
+{{ with .Components }}
+## Dependencies
+
+| Component | Version |
+| --------- | ------- |
+{{- range . }}
+| {{ .Name }} | {{ .Version }} |
+{{- end }}
+{{ end }}
+{{ template "changelog" . }}
+{{ end }}`
@@ -0,0 +1,222 @@
+package release
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/rancher/ecm-distro-tools/release/components"
+	"github.com/rancher/ecm-distro-tools/repository"
+	"github.com/sirupsen/logrus"
+)
+
+// ReleaseClient is the interface implemented by Client. Packages importing
+// this module should depend on it rather than on Client, so they can fake
+// it in their tests. Methods are only added to it in minor releases and
+// changed or removed in major releases.
+type ReleaseClient interface {
+	Notes(ctx context.Context, opts NotesOptions) (*bytes.Buffer, error)
+	NotesSnapshot(ctx context.Context, opts NotesOptions) (*ReleaseNotesSnapshot, error)
+	CheckUpstreamRelease(ctx context.Context, opts UpstreamReleaseOptions) (*UpstreamReleases, error)
+	UpstreamReleaseDates(ctx context.Context, opts UpstreamReleaseOptions) (map[string]time.Time, error)
+	KubernetesGoVersion(ctx context.Context, version string) (string, error)
+	VerifyAssets(ctx context.Context, opts VerifyAssetsOptions) (*AssetsVerification, error)
+	ListAssets(ctx context.Context, owner, repo, tag string) ([]Asset, error)
+	CheckAssetRules(ctx context.Context, owner, repo, tag string) ([]AssetViolation, error)
+	DeleteAssetsByRelease(ctx context.Context, owner, repo, tag string, force bool) (*DeleteAssetsResult, error)
+	LatestRC(ctx context.Context, opts LatestRCOptions) (*LatestRCResult, error)
+	Stats(ctx context.Context, opts StatsOptions) (*StatsData, error)
+}
+
+var _ ReleaseClient = (*Client)(nil)
+
+// Options configures a Client created with New. Zero values are replaced
+// with the defaults used by NewClient.
+type Options struct {
+	// Token authenticates the GitHub API requests, which are
+	// unauthenticated if it's empty.
+	Token      string
+	HTTP       *http.Client
+	Log        logrus.FieldLogger
+	Cache      *repository.ReleaseCache
+	Components components.Mapping
+}
+
+// New creates a new client with the given options.
+func New(opts Options) *Client {
+	c := NewClient(repository.NewGithub(context.Background(), opts.Token))
+	if opts.HTTP != nil {
+		c.HTTP = opts.HTTP
+	}
+	if opts.Log != nil {
+		c.Log = opts.Log
+	}
+	if opts.Cache != nil {
+		c.Cache = opts.Cache
+	}
+	c.Components = opts.Components
+
+	return c
+}
+
+// UpstreamReleaseOptions identifies the releases looked up by
+// CheckUpstreamRelease and UpstreamReleaseDates.
+type UpstreamReleaseOptions struct {
+	// Owner and Repo of the releases, e.g. kubernetes and kubernetes.
+	Owner string
+	Repo  string
+	Tags  []string
+}
+
+// UpstreamReleases reports which of the looked up tags have a published
+// release.
+type UpstreamReleases struct {
+	Published map[string]bool
+}
+
+// VerifyAssetsOptions identifies the releases whose assets VerifyAssets
+// counts.
+type VerifyAssetsOptions struct {
+	Owner string
+	Repo  string
+	Tags  []string
+}
+
+// AssetsVerification reports which of the verified releases have all of
+// their assets uploaded.
+type AssetsVerification struct {
+	Complete map[string]bool
+}
+
+// Asset is an asset of a GitHub release.
+type Asset struct {
+	ID          int64
+	Name        string
+	Size        int
+	ContentType string
+	DownloadURL string
+}
+
+// LatestRCOptions identifies the release candidates LatestRC looks for.
+type LatestRCOptions struct {
+	Owner string
+	Repo  string
+	// K8sVersion and Suffix of the release, e.g. v1.29.2 and rke2r1.
+	K8sVersion string
+	Suffix     string
+}
+
+// LatestRCResult is the latest release candidate found by LatestRC.
+type LatestRCResult struct {
+	// Tag of the latest release candidate, empty if there's none.
+	Tag string
+}
+
+// StatsOptions identifies the releases Stats collects.
+type StatsOptions struct {
+	Owner string
+	Repo  string
+	// Start and End of the period the releases were created in.
+	Start time.Time
+	End   time.Time
+}
+
+// NotesOptions identifies the release notes to generate.
+type NotesOptions struct {
+	// Owner and Repo of the product, e.g. rancher and rke2.
+	Owner string
+	Repo  string
+	// Milestone being released and the previous one the changes are
+	// compared to, e.g. v1.29.2+rke2r1 and v1.29.1+rke2r1.
+	Milestone     string
+	PrevMilestone string
+	// FromImages reads the component versions of k3s and rke2 from the
+	// labels and SBOMs of the images built for the milestone instead of
+	// the Dockerfiles and scripts of the repo. Versions that can't be read
+	// from the images, e.g. before the release is built, are still scraped.
+	FromImages bool
+	// PullRequests, if set, are the pull requests the changelog is made of
+	// instead of the ones merged between the milestones, e.g. when the
+	// milestones were renamed or split.
+	PullRequests []int
+	// ChangeLog configures the workers, progress and checkpoint of the
+	// retrieval of the changelog, e.g. for milestones with hundreds of pull
+	// requests.
+	ChangeLog repository.ChangeLogOptions
+	NotesDates
+}
+
+// NotesDates configures the dates mentioned by the notes.
+type NotesDates struct {
+	// Locale the dates are formatted in, DefaultLocale if empty.
+	Locale string
+	// PublishDate of the release, defaulting to the one of the milestone's
+	// GitHub release if published, and EOLDate of its release line, if
+	// known.
+	PublishDate time.Time
+	EOLDate     time.Time
+}
+
+func (o NotesOptions) validate() error {
+	if o.Owner == "" || o.Repo == "" {
+		return errors.New("owner and repo are required")
+	}
+	if o.Milestone == "" || o.PrevMilestone == "" {
+		return errors.New("milestone and previous milestone are required")
+	}
+
+	return nil
+}
+
+// Notes generates the release notes described by the given options.
+func (c *Client) Notes(ctx context.Context, opts NotesOptions) (*bytes.Buffer, error) {
+	snapshot, err := c.NotesSnapshot(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return RenderReleaseNotes(snapshot)
+}
+
+// NotesSnapshot collects the data used to render the release notes
+// described by the given options, including the components that changed
+// since the previous milestone.
+func (c *Client) NotesSnapshot(ctx context.Context, opts NotesOptions) (*ReleaseNotesSnapshot, error) {
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+
+	var snapshot *ReleaseNotesSnapshot
+	var err error
+	if len(opts.PullRequests) > 0 {
+		var content []repository.ChangeLog
+		content, err = repository.RetrievePullRequestsChangeLog(ctx, c.GitHub, opts.Owner, opts.Repo, opts.PullRequests, opts.ChangeLog)
+		if err != nil {
+			return nil, err
+		}
+		snapshot, err = c.releaseNotesSnapshot(opts.Repo, opts.Milestone, opts.PrevMilestone, content)
+	} else {
+		snapshot, err = c.genReleaseNotesSnapshot(ctx, opts.Owner, opts.Repo, opts.Milestone, opts.PrevMilestone, opts.ChangeLog)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.FromImages {
+		if err := c.fillFromImages(ctx, opts.Owner, snapshot); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := c.addComponentChanges(ctx, opts, snapshot); err != nil {
+		return nil, err
+	}
+
+	if err := c.addDates(ctx, opts.Owner, opts.NotesDates, snapshot); err != nil {
+		return nil, err
+	}
+
+	return snapshot, nil
+}
@@ -0,0 +1,359 @@
+package archive
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/google/go-github/v39/github"
+	"github.com/rancher/ecm-distro-tools/repository"
+	"github.com/sirupsen/logrus"
+)
+
+// minPartSize is the minimum size of a multipart upload part, except for the
+// last one, accepted by both S3 and GCS.
+const minPartSize = 5 * 1024 * 1024
+
+// defaultPartSize is the size of the parts of multipart uploads.
+const defaultPartSize = 16 * 1024 * 1024
+
+// sha256Metadata is the user metadata, sent as x-amz-meta-sha256, holding
+// the hex encoded SHA-256 of the archived objects.
+const sha256Metadata = "sha256"
+
+// s3API contains the subset of the S3 API used by the Archiver, which is
+// also implemented by the GCS XML API when using HMAC keys.
+type s3API interface {
+	HeadObject(ctx context.Context, params *s3.HeadObjectInput, optFns ...func(*s3.Options)) (*s3.HeadObjectOutput, error)
+	PutObject(ctx context.Context, params *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error)
+	ListMultipartUploads(ctx context.Context, params *s3.ListMultipartUploadsInput, optFns ...func(*s3.Options)) (*s3.ListMultipartUploadsOutput, error)
+	CreateMultipartUpload(ctx context.Context, params *s3.CreateMultipartUploadInput, optFns ...func(*s3.Options)) (*s3.CreateMultipartUploadOutput, error)
+	ListParts(ctx context.Context, params *s3.ListPartsInput, optFns ...func(*s3.Options)) (*s3.ListPartsOutput, error)
+	UploadPart(ctx context.Context, params *s3.UploadPartInput, optFns ...func(*s3.Options)) (*s3.UploadPartOutput, error)
+	CompleteMultipartUpload(ctx context.Context, params *s3.CompleteMultipartUploadInput, optFns ...func(*s3.Options)) (*s3.CompleteMultipartUploadOutput, error)
+}
+
+// Archiver uploads release artifacts to an S3 or GCS bucket using the
+// <product>/<version>/<file> layout. Large files are uploaded with multipart
+// uploads that are resumed if a previous run was interrupted.
+type Archiver struct {
+	client   s3API
+	bucket   string
+	partSize int64
+	dryRun   bool
+}
+
+// NewArchiver creates a new archiver for the given bucket. To archive to GCS,
+// the client must be configured with the https://storage.googleapis.com
+// endpoint and HMAC keys.
+func NewArchiver(client *s3.Client, bucket string, dryRun bool) *Archiver {
+	return &Archiver{
+		client:   client,
+		bucket:   bucket,
+		partSize: defaultPartSize,
+		dryRun:   dryRun,
+	}
+}
+
+// Key returns the object key of a release file.
+func Key(product, version, name string) string {
+	return path.Join(product, version, name)
+}
+
+// ArchiveRelease uploads all assets of the given GitHub release, followed by
+// the given local files, e.g. generated notes and verification reports.
+func (a *Archiver) ArchiveRelease(ctx context.Context, client *github.Client, owner, repo, product, tag string, files []string) error {
+	release, err := repository.GetReleaseByTag(ctx, client, owner, repo, tag)
+	if err != nil {
+		return err
+	}
+
+	tmpDir, err := os.MkdirTemp("", "release-archive")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	// the assets are downloaded even if already archived, as their digest
+	// is compared with the one of the archived object.
+	for _, asset := range release.Assets {
+		key := Key(product, tag, asset.GetName())
+		if a.dryRun {
+			logrus.Infof("dry run, would archive %s", key)
+			continue
+		}
+
+		file := filepath.Join(tmpDir, asset.GetName())
+		if err := downloadAsset(ctx, client, owner, repo, asset.GetID(), file); err != nil {
+			return err
+		}
+		if err := a.UploadFile(ctx, key, file); err != nil {
+			return err
+		}
+		if err := os.Remove(file); err != nil {
+			return err
+		}
+	}
+
+	for _, file := range files {
+		key := Key(product, tag, filepath.Base(file))
+		if a.dryRun {
+			logrus.Infof("dry run, would archive %s", key)
+			continue
+		}
+		if err := a.UploadFile(ctx, key, file); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// UploadFile uploads the given local file to the given key, skipping it if
+// an object of the same size and SHA-256 already exists.
+func (a *Archiver) UploadFile(ctx context.Context, key, file string) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return err
+	}
+	sum := hex.EncodeToString(h.Sum(nil))
+
+	uploaded, err := a.uploaded(ctx, key, info.Size(), sum)
+	if err != nil {
+		return err
+	}
+	if uploaded {
+		logrus.Infof("skipping %s, already archived", key)
+		return nil
+	}
+
+	logrus.Infof("archiving %s", key)
+
+	if info.Size() <= a.partSize {
+		_, err := a.client.PutObject(ctx, &s3.PutObjectInput{
+			Bucket:        aws.String(a.bucket),
+			Key:           aws.String(key),
+			Body:          io.NewSectionReader(f, 0, info.Size()),
+			ContentLength: aws.Int64(info.Size()),
+			Metadata:      map[string]string{sha256Metadata: sum},
+		})
+		return err
+	}
+
+	return a.multipartUpload(ctx, key, f, info.Size(), sum)
+}
+
+// uploaded returns true if an object with the given key, size and hex
+// encoded SHA-256 exists. Objects archived without their SHA-256 are
+// uploaded again.
+func (a *Archiver) uploaded(ctx context.Context, key string, size int64, sum string) (bool, error) {
+	out, err := a.client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(a.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		var notFound *types.NotFound
+		if errors.As(err, &notFound) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return aws.ToInt64(out.ContentLength) == size && out.Metadata[sha256Metadata] == sum, nil
+}
+
+// multipartUpload uploads the given file, of the given hex encoded SHA-256,
+// in parts, resuming the most recent unfinished upload for the key if
+// there's one. The parts already uploaded are only reused if their size and
+// SHA-256 match.
+func (a *Archiver) multipartUpload(ctx context.Context, key string, r io.ReaderAt, size int64, sum string) error {
+	uploadID, err := a.pendingUpload(ctx, key)
+	if err != nil {
+		return err
+	}
+
+	done := make(map[int32]types.Part)
+	if uploadID == "" {
+		out, err := a.client.CreateMultipartUpload(ctx, &s3.CreateMultipartUploadInput{
+			Bucket:            aws.String(a.bucket),
+			Key:               aws.String(key),
+			Metadata:          map[string]string{sha256Metadata: sum},
+			ChecksumAlgorithm: types.ChecksumAlgorithmSha256,
+		})
+		if err != nil {
+			return err
+		}
+		uploadID = aws.ToString(out.UploadId)
+	} else {
+		logrus.Infof("resuming upload of %s", key)
+		if done, err = a.uploadedParts(ctx, key, uploadID); err != nil {
+			return err
+		}
+	}
+
+	parts := planParts(size, a.partSize)
+	completed := make([]types.CompletedPart, 0, len(parts))
+	for i, p := range parts {
+		number := int32(i + 1)
+		partSum, err := partChecksum(r, p)
+		if err != nil {
+			return err
+		}
+		if part, ok := done[number]; ok && aws.ToInt64(part.Size) == p.size && aws.ToString(part.ChecksumSHA256) == partSum {
+			completed = append(completed, types.CompletedPart{ETag: part.ETag, ChecksumSHA256: part.ChecksumSHA256, PartNumber: aws.Int32(number)})
+			continue
+		}
+
+		out, err := a.client.UploadPart(ctx, &s3.UploadPartInput{
+			Bucket:         aws.String(a.bucket),
+			Key:            aws.String(key),
+			UploadId:       aws.String(uploadID),
+			PartNumber:     aws.Int32(number),
+			Body:           io.NewSectionReader(r, p.offset, p.size),
+			ContentLength:  aws.Int64(p.size),
+			ChecksumSHA256: aws.String(partSum),
+		})
+		if err != nil {
+			return errors.New("failed to upload part " + strconv.Itoa(int(number)) + " of " + key + ": " + err.Error())
+		}
+		completed = append(completed, types.CompletedPart{ETag: out.ETag, ChecksumSHA256: aws.String(partSum), PartNumber: aws.Int32(number)})
+	}
+
+	_, err = a.client.CompleteMultipartUpload(ctx, &s3.CompleteMultipartUploadInput{
+		Bucket:          aws.String(a.bucket),
+		Key:             aws.String(key),
+		UploadId:        aws.String(uploadID),
+		MultipartUpload: &types.CompletedMultipartUpload{Parts: completed},
+	})
+
+	return err
+}
+
+// pendingUpload returns the ID of the most recent unfinished multipart
+// upload for the given key, or an empty string if there's none.
+func (a *Archiver) pendingUpload(ctx context.Context, key string) (string, error) {
+	out, err := a.client.ListMultipartUploads(ctx, &s3.ListMultipartUploadsInput{
+		Bucket: aws.String(a.bucket),
+		Prefix: aws.String(key),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	var uploadID string
+	var initiated int64
+	for _, u := range out.Uploads {
+		if aws.ToString(u.Key) != key {
+			continue
+		}
+		if t := aws.ToTime(u.Initiated).UnixNano(); uploadID == "" || t > initiated {
+			uploadID = aws.ToString(u.UploadId)
+			initiated = t
+		}
+	}
+
+	return uploadID, nil
+}
+
+// uploadedParts returns the parts already uploaded for the given upload,
+// indexed by part number.
+func (a *Archiver) uploadedParts(ctx context.Context, key, uploadID string) (map[int32]types.Part, error) {
+	parts := make(map[int32]types.Part)
+
+	input := &s3.ListPartsInput{
+		Bucket:   aws.String(a.bucket),
+		Key:      aws.String(key),
+		UploadId: aws.String(uploadID),
+	}
+	for {
+		out, err := a.client.ListParts(ctx, input)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range out.Parts {
+			parts[aws.ToInt32(p.PartNumber)] = p
+		}
+		if !aws.ToBool(out.IsTruncated) {
+			break
+		}
+		input.PartNumberMarker = out.NextPartNumberMarker
+	}
+
+	return parts, nil
+}
+
+type part struct {
+	offset int64
+	size   int64
+}
+
+// planParts splits a file of the given size in parts of the given size,
+// where the last part holds the remainder.
+func planParts(size, partSize int64) []part {
+	if partSize < minPartSize {
+		partSize = minPartSize
+	}
+
+	var parts []part
+	for offset := int64(0); offset < size; offset += partSize {
+		parts = append(parts, part{
+			offset: offset,
+			size:   min(partSize, size-offset),
+		})
+	}
+
+	return parts
+}
+
+// partChecksum returns the base64 encoded SHA-256 of the given part, as
+// S3 reports the checksums of the parts.
+func partChecksum(r io.ReaderAt, p part) (string, error) {
+	h := sha256.New()
+	if _, err := io.Copy(h, io.NewSectionReader(r, p.offset, p.size)); err != nil {
+		return "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(h.Sum(nil)), nil
+}
+
+func downloadAsset(ctx context.Context, client *github.Client, owner, repo string, id int64, file string) error {
+	rc, _, err := client.Repositories.DownloadReleaseAsset(ctx, owner, repo, id, http.DefaultClient)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	f, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if _, err := io.Copy(f, rc); err != nil {
+		return err
+	}
+
+	return f.Close()
+}
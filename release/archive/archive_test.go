@@ -0,0 +1,215 @@
+package archive
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+func TestPlanParts(t *testing.T) {
+	tests := []struct {
+		name     string
+		size     int64
+		partSize int64
+		want     []part
+	}{
+		{
+			name:     "exact parts",
+			size:     2 * minPartSize,
+			partSize: minPartSize,
+			want:     []part{{0, minPartSize}, {minPartSize, minPartSize}},
+		},
+		{
+			name:     "remainder",
+			size:     minPartSize + 10,
+			partSize: minPartSize,
+			want:     []part{{0, minPartSize}, {minPartSize, 10}},
+		},
+		{
+			name:     "part size below minimum",
+			size:     minPartSize,
+			partSize: 1,
+			want:     []part{{0, minPartSize}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := planParts(tt.size, tt.partSize)
+			if len(got) != len(tt.want) {
+				t.Fatalf("planParts() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("planParts()[%d] = %v, want %v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+// fakeS3 implements s3API with a single pending multipart upload that
+// already has its first part uploaded, and a single object.
+type fakeS3 struct {
+	s3API
+	part          types.Part
+	object        *s3.HeadObjectOutput
+	put           *s3.PutObjectInput
+	putBody       []byte
+	uploadedParts []int32
+	completed     []types.CompletedPart
+}
+
+func (f *fakeS3) HeadObject(ctx context.Context, params *s3.HeadObjectInput, optFns ...func(*s3.Options)) (*s3.HeadObjectOutput, error) {
+	if f.object == nil {
+		return nil, &types.NotFound{}
+	}
+	return f.object, nil
+}
+
+func (f *fakeS3) PutObject(ctx context.Context, params *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error) {
+	f.put = params
+	b, err := io.ReadAll(params.Body)
+	f.putBody = b
+	return &s3.PutObjectOutput{}, err
+}
+
+func (f *fakeS3) ListMultipartUploads(ctx context.Context, params *s3.ListMultipartUploadsInput, optFns ...func(*s3.Options)) (*s3.ListMultipartUploadsOutput, error) {
+	return &s3.ListMultipartUploadsOutput{
+		Uploads: []types.MultipartUpload{{Key: params.Prefix, UploadId: aws.String("upload")}},
+	}, nil
+}
+
+func (f *fakeS3) ListParts(ctx context.Context, params *s3.ListPartsInput, optFns ...func(*s3.Options)) (*s3.ListPartsOutput, error) {
+	return &s3.ListPartsOutput{Parts: []types.Part{f.part}}, nil
+}
+
+func (f *fakeS3) UploadPart(ctx context.Context, params *s3.UploadPartInput, optFns ...func(*s3.Options)) (*s3.UploadPartOutput, error) {
+	f.uploadedParts = append(f.uploadedParts, aws.ToInt32(params.PartNumber))
+	return &s3.UploadPartOutput{ETag: aws.String("etag-new")}, nil
+}
+
+func (f *fakeS3) CompleteMultipartUpload(ctx context.Context, params *s3.CompleteMultipartUploadInput, optFns ...func(*s3.Options)) (*s3.CompleteMultipartUploadOutput, error) {
+	f.completed = params.MultipartUpload.Parts
+	return &s3.CompleteMultipartUploadOutput{}, nil
+}
+
+func TestMultipartUploadResume(t *testing.T) {
+	data := bytes.NewReader(make([]byte, 2*minPartSize+1))
+	firstSum, err := partChecksum(data, part{0, minPartSize})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		checksum  string
+		wantParts []int32
+		wantETag  string
+	}{
+		{
+			name:      "same digest",
+			checksum:  firstSum,
+			wantParts: []int32{2, 3},
+			wantETag:  "etag-1",
+		},
+		{
+			name:      "different digest",
+			checksum:  base64.StdEncoding.EncodeToString(make([]byte, sha256.Size)),
+			wantParts: []int32{1, 2, 3},
+			wantETag:  "etag-new",
+		},
+		{
+			name:      "no digest",
+			wantParts: []int32{1, 2, 3},
+			wantETag:  "etag-new",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &fakeS3{part: types.Part{PartNumber: aws.Int32(1), ETag: aws.String("etag-1"), Size: aws.Int64(minPartSize)}}
+			if tt.checksum != "" {
+				client.part.ChecksumSHA256 = aws.String(tt.checksum)
+			}
+			a := &Archiver{client: client, bucket: "bucket", partSize: minPartSize}
+
+			if err := a.multipartUpload(context.Background(), "rke2/v1.29.2+rke2r1/rke2.linux-amd64.tar.gz", data, data.Size(), "sum"); err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(client.uploadedParts, tt.wantParts) {
+				t.Errorf("uploaded parts %v, want %v", client.uploadedParts, tt.wantParts)
+			}
+			if len(client.completed) != 3 || aws.ToString(client.completed[0].ETag) != tt.wantETag {
+				t.Errorf("expected 3 completed parts, the first with etag %s, got %v", tt.wantETag, client.completed)
+			}
+		})
+	}
+}
+
+func TestUploadFileDigest(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "rke2.linux-amd64.tar.gz")
+	if err := os.WriteFile(file, []byte("rke2"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	h := sha256.Sum256([]byte("rke2"))
+	sum := hex.EncodeToString(h[:])
+
+	tests := []struct {
+		name       string
+		object     *s3.HeadObjectOutput
+		wantUpload bool
+	}{
+		{
+			name:       "not archived",
+			wantUpload: true,
+		},
+		{
+			name:   "same digest",
+			object: &s3.HeadObjectOutput{ContentLength: aws.Int64(4), Metadata: map[string]string{sha256Metadata: sum}},
+		},
+		{
+			name:       "same size, different digest",
+			object:     &s3.HeadObjectOutput{ContentLength: aws.Int64(4), Metadata: map[string]string{sha256Metadata: "0000"}},
+			wantUpload: true,
+		},
+		{
+			name:       "same size, no digest",
+			object:     &s3.HeadObjectOutput{ContentLength: aws.Int64(4)},
+			wantUpload: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &fakeS3{object: tt.object}
+			a := &Archiver{client: client, bucket: "bucket", partSize: minPartSize}
+
+			if err := a.UploadFile(context.Background(), "rke2/v1.29.2+rke2r1/rke2.linux-amd64.tar.gz", file); err != nil {
+				t.Fatal(err)
+			}
+
+			if uploaded := client.put != nil; uploaded != tt.wantUpload {
+				t.Fatalf("uploaded = %t, want %t", uploaded, tt.wantUpload)
+			}
+			if client.put == nil {
+				return
+			}
+			if got := client.put.Metadata[sha256Metadata]; got != sum {
+				t.Errorf("sha256 metadata = %q, want %q", got, sum)
+			}
+			if string(client.putBody) != "rke2" {
+				t.Errorf("body = %q, want rke2", client.putBody)
+			}
+		})
+	}
+}
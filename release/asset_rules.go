@@ -0,0 +1,215 @@
+package release
+
+import (
+	"context"
+	"mime"
+	"regexp"
+	"slices"
+	"sort"
+)
+
+var (
+	binaryContentTypes = []string{"application/octet-stream", "application/x-executable", "application/x-msdownload", "application/x-dosexec"}
+	gzipContentTypes   = []string{"application/gzip", "application/x-gzip", "application/x-compressed-tar", "application/octet-stream"}
+	zstdContentTypes   = []string{"application/zstd", "application/octet-stream"}
+	tarContentTypes    = []string{"application/x-tar", "application/octet-stream"}
+	textContentTypes   = []string{"text/plain"}
+	scriptContentTypes = []string{"text/plain", "text/x-sh", "text/x-shellscript", "application/x-sh", "application/octet-stream"}
+)
+
+// assetRule is a naming convention for release assets and the content
+// types the assets following it can be uploaded with.
+type assetRule struct {
+	pattern      *regexp.Regexp
+	contentTypes []string
+}
+
+// assetRules contains the naming conventions of the release assets of each
+// repo. Every asset has to follow one of them, since the install scripts
+// download the assets by name.
+var assetRules = map[string][]assetRule{
+	rke2Repo: {
+		{regexp.MustCompile(`^rke2\.(linux|windows)-(amd64|arm64|s390x)(\.exe)?$`), binaryContentTypes},
+		{regexp.MustCompile(`^rke2\.(linux|windows)-(amd64|arm64|s390x)\.tar\.gz$`), gzipContentTypes},
+		{regexp.MustCompile(`^rke2-images(-[a-z0-9]+)?\.(linux|windows)-(amd64|arm64|s390x)\.tar\.gz$`), gzipContentTypes},
+		{regexp.MustCompile(`^rke2-images(-[a-z0-9]+)?\.(linux|windows)-(amd64|arm64|s390x)\.tar\.zst$`), zstdContentTypes},
+		{regexp.MustCompile(`^rke2-images(-[a-z0-9]+)?\.(linux|windows)-(amd64|arm64|s390x)\.txt$`), textContentTypes},
+		{regexp.MustCompile(`^rke2-windows-[a-z0-9]+-amd64(-images)?\.tar\.gz$`), gzipContentTypes},
+		{regexp.MustCompile(`^rke2-windows-[a-z0-9]+-amd64(-images)?\.tar\.zst$`), zstdContentTypes},
+		{regexp.MustCompile(`^rke2-windows-[a-z0-9]+-amd64(-images)?\.txt$`), textContentTypes},
+		{regexp.MustCompile(`^sha256sum-(amd64|arm64|s390x)\.txt$`), textContentTypes},
+		{regexp.MustCompile(`^(install\.sh|rke2-install\.ps1)$`), scriptContentTypes},
+	},
+	k3sRepo: {
+		{regexp.MustCompile(`^k3s(-(arm64|armhf|s390x))?$`), binaryContentTypes},
+		{regexp.MustCompile(`^k3s-airgap-images-(amd64|arm64|arm|s390x)\.tar$`), tarContentTypes},
+		{regexp.MustCompile(`^k3s-airgap-images-(amd64|arm64|arm|s390x)\.tar\.gz$`), gzipContentTypes},
+		{regexp.MustCompile(`^k3s-airgap-images-(amd64|arm64|arm|s390x)\.tar\.zst$`), zstdContentTypes},
+		{regexp.MustCompile(`^k3s-images\.txt$`), textContentTypes},
+		{regexp.MustCompile(`^sha256sum-(amd64|arm64|arm|s390x)\.txt$`), textContentTypes},
+	},
+	kineRepo: {
+		{regexp.MustCompile(`^kine(-(amd64|arm64|arm|riscv64|s390x))?$`), binaryContentTypes},
+		{regexp.MustCompile(`^sha256sum-(amd64|arm64|arm|riscv64|s390x)\.txt$`), textContentTypes},
+	},
+	helmControllerRepo: {
+		{regexp.MustCompile(`^helm-controller(-(amd64|arm64|arm|s390x))?$`), binaryContentTypes},
+		{regexp.MustCompile(`^sha256sum-(amd64|arm64|arm|s390x)\.txt$`), textContentTypes},
+		{regexp.MustCompile(`^deploy-[a-z-]+\.yaml$`), textContentTypes},
+	},
+	localPathProvisionerRepo: {
+		{regexp.MustCompile(`^local-path-storage\.yaml$`), textContentTypes},
+	},
+}
+
+// requiredAssets contains the assets each release must include.
+var requiredAssets = map[string][]string{
+	rke2Repo: {
+		"rke2.linux-amd64",
+		"rke2.linux-amd64.tar.gz",
+		"rke2.linux-arm64",
+		"rke2.linux-arm64.tar.gz",
+		"rke2-images.linux-amd64.tar.zst",
+		"rke2-images-all.linux-amd64.txt",
+		"rke2-images-all.linux-arm64.txt",
+		"sha256sum-amd64.txt",
+		"sha256sum-arm64.txt",
+	},
+	k3sRepo: {
+		"k3s",
+		"k3s-arm64",
+		"k3s-armhf",
+		"k3s-images.txt",
+		"sha256sum-amd64.txt",
+		"sha256sum-arm64.txt",
+		"sha256sum-arm.txt",
+	},
+	kineRepo: {
+		"kine-amd64",
+		"kine-arm64",
+		"sha256sum-amd64.txt",
+		"sha256sum-arm64.txt",
+	},
+	helmControllerRepo: {
+		"helm-controller-amd64",
+		"helm-controller-arm64",
+		"sha256sum-amd64.txt",
+		"sha256sum-arm64.txt",
+	},
+}
+
+// AssetViolation is a release asset breaking a naming or content type rule.
+type AssetViolation struct {
+	Asset  string
+	Reason string
+}
+
+func (v AssetViolation) String() string {
+	return v.Asset + ": " + v.Reason
+}
+
+// CheckAssetRules checks that the assets of the given k3s, rke2 or k3s
+// ancillary release follow the naming conventions and were uploaded with the
+// right content types, and that none of the required assets is missing.
+func (c *Client) CheckAssetRules(ctx context.Context, owner, repo, tag string) ([]AssetViolation, error) {
+	assets, err := c.ListAssets(ctx, owner, repo, tag)
+	if err != nil {
+		return nil, err
+	}
+
+	return checkAssetRules(repo, assets), nil
+}
+
+func checkAssetRules(repo string, assets []Asset) []AssetViolation {
+	var violations []AssetViolation
+
+	names := make(map[string]bool, len(assets))
+	for _, asset := range assets {
+		name := asset.Name
+		names[name] = true
+
+		if asset.Size == 0 {
+			violations = append(violations, AssetViolation{Asset: name, Reason: "is empty"})
+		}
+
+		rule, ok := matchAssetRule(repo, name)
+		if !ok {
+			violations = append(violations, AssetViolation{Asset: name, Reason: "doesn't follow the " + repo + " naming conventions"})
+			continue
+		}
+
+		contentType := asset.ContentType
+		if mediaType, _, err := mime.ParseMediaType(contentType); err == nil {
+			contentType = mediaType
+		}
+		if !slices.Contains(rule.contentTypes, contentType) {
+			violations = append(violations, AssetViolation{Asset: name, Reason: "unexpected content type " + asset.ContentType})
+		}
+	}
+
+	for _, name := range requiredAssets[repo] {
+		if !names[name] {
+			violations = append(violations, AssetViolation{Asset: name, Reason: "is missing"})
+		}
+	}
+
+	sort.SliceStable(violations, func(i, j int) bool {
+		return violations[i].Asset < violations[j].Asset
+	})
+
+	return violations
+}
+
+// AssetCheck is the outcome of the asset rules for an asset of a release,
+// with the reasons it breaks them, if any.
+type AssetCheck struct {
+	Asset   string
+	Reasons []string
+}
+
+// OK returns true if the asset follows the asset rules.
+func (a AssetCheck) OK() bool {
+	return len(a.Reasons) == 0
+}
+
+// CheckAssets checks the asset rules like CheckAssetRules, and returns the
+// outcome for every asset of the release and every missing required asset,
+// sorted by name, e.g. to report the passed assets too.
+func (c *Client) CheckAssets(ctx context.Context, owner, repo, tag string) ([]AssetCheck, error) {
+	assets, err := c.ListAssets(ctx, owner, repo, tag)
+	if err != nil {
+		return nil, err
+	}
+
+	return assetChecks(repo, assets), nil
+}
+
+func assetChecks(repo string, assets []Asset) []AssetCheck {
+	reasons := make(map[string][]string, len(assets))
+	for _, asset := range assets {
+		reasons[asset.Name] = nil
+	}
+	for _, violation := range checkAssetRules(repo, assets) {
+		reasons[violation.Asset] = append(reasons[violation.Asset], violation.Reason)
+	}
+
+	checks := make([]AssetCheck, 0, len(reasons))
+	for asset, r := range reasons {
+		checks = append(checks, AssetCheck{Asset: asset, Reasons: r})
+	}
+	sort.Slice(checks, func(i, j int) bool {
+		return checks[i].Asset < checks[j].Asset
+	})
+
+	return checks
+}
+
+func matchAssetRule(repo, name string) (assetRule, bool) {
+	for _, rule := range assetRules[repo] {
+		if rule.pattern.MatchString(name) {
+			return rule, true
+		}
+	}
+
+	return assetRule{}, false
+}
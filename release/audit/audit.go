@@ -0,0 +1,55 @@
+package audit
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// DefaultPath is where entries are recorded unless configured otherwise.
+const DefaultPath = "$HOME/.ecm-distro-tools/audit.log"
+
+// Entry is a release action performed by a user, recorded so releases can
+// be reconstructed after the fact.
+type Entry struct {
+	Time    time.Time         `json:"time"`
+	User    string            `json:"user"`
+	Action  string            `json:"action"`
+	Product string            `json:"product"`
+	Version string            `json:"version"`
+	DryRun  bool              `json:"dry_run,omitempty"`
+	Details map[string]string `json:"details,omitempty"`
+	Errors  []string          `json:"errors,omitempty"`
+}
+
+// Record appends the given entry as a JSON line to the audit log at the
+// given path, creating it and its directory if needed. Environment
+// variables in the path are expanded.
+func Record(path string, entry Entry) error {
+	path = os.ExpandEnv(path)
+	if entry.Time.IsZero() {
+		entry.Time = time.Now().UTC()
+	}
+
+	b, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+
+	if _, err := f.Write(append(b, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
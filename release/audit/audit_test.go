@@ -0,0 +1,39 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRecord(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "audit.log")
+
+	for _, version := range []string{"v1.29.4+rke2r1", "v1.29.5+rke2r1"} {
+		if err := Record(path, Entry{User: "captain", Action: "rollback", Product: "rke2", Version: version}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var e Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			t.Fatal(err)
+		}
+		entries = append(entries, e)
+	}
+
+	if len(entries) != 2 || entries[1].Version != "v1.29.5+rke2r1" || entries[0].Time.IsZero() {
+		t.Errorf("expected 2 appended entries with their time set, got %+v", entries)
+	}
+}
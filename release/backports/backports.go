@@ -0,0 +1,214 @@
+package backports
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"sort"
+
+	"github.com/google/go-github/v39/github"
+	"github.com/rancher/ecm-distro-tools/repository"
+	"golang.org/x/mod/semver"
+)
+
+// Rule maps the release branches to the milestone and labels of the pull
+// requests targeting them.
+type Rule struct {
+	// Branch is a regular expression matching the base branches of the
+	// pull requests, e.g. ^release-(\d+)\.(\d+)$.
+	Branch string `json:"branch"`
+	// Milestone is a regular expression matching the titles of the open
+	// milestones the pull requests belong to, expanded with the submatches
+	// of Branch, e.g. ^v${1}\.${2}\.\d+. The lowest version wins when
+	// several milestones are open, e.g. v1.29.3 before v1.29.4.
+	Milestone string `json:"milestone"`
+	// Labels are the labels the pull requests must have.
+	Labels []string `json:"labels,omitempty"`
+}
+
+// DefaultRules map the release-1.29 branches of k3s and rke2, and the
+// release/v2.8 branches of rancher, to the open milestones of their patch
+// releases and the backport label.
+var DefaultRules = []Rule{
+	{Branch: `^release-(\d+)\.(\d+)$`, Milestone: `^v${1}\.${2}\.\d+`, Labels: []string{"kind/backport"}},
+	{Branch: `^release/v(\d+)\.(\d+)$`, Milestone: `^v${1}\.${2}\.\d+`, Labels: []string{"kind/backport"}},
+}
+
+// ValidateRules checks that the branches and milestones of the given rules
+// are valid regular expressions.
+func ValidateRules(rules []Rule) error {
+	for _, rule := range rules {
+		if _, err := regexp.Compile(rule.Branch); err != nil {
+			return errors.New("invalid branch " + rule.Branch + ": " + err.Error())
+		}
+		if rule.Milestone == "" && len(rule.Labels) == 0 {
+			return errors.New("rule of branch " + rule.Branch + " has neither milestone nor labels")
+		}
+	}
+
+	return nil
+}
+
+// Change is what's missing from an open pull request targeting a release
+// branch.
+type Change struct {
+	Number int    `json:"number"`
+	Title  string `json:"title"`
+	URL    string `json:"url"`
+	Base   string `json:"base"`
+	// Milestone to set, empty if the pull request has one already or no
+	// open milestone matches its rule.
+	Milestone       string `json:"milestone,omitempty"`
+	milestoneNumber int
+	// Labels to add.
+	Labels []string `json:"labels,omitempty"`
+	// Warning explains why the pull request can't be fixed, e.g. it has
+	// no milestone and none is open for its branch.
+	Warning string `json:"warning,omitempty"`
+}
+
+// Plan returns the changes to apply to the open pull requests of the given
+// repo targeting a branch matching one of the rules, the first one
+// matching. Pull requests with a milestone and the labels are left out.
+func Plan(ctx context.Context, client *github.Client, owner, repo string, rules []Rule) ([]Change, error) {
+	branches := make([]*regexp.Regexp, len(rules))
+	for i, rule := range rules {
+		re, err := regexp.Compile(rule.Branch)
+		if err != nil {
+			return nil, errors.New("invalid branch " + rule.Branch + ": " + err.Error())
+		}
+		branches[i] = re
+	}
+
+	milestones, err := openMilestones(ctx, client, owner, repo)
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []Change
+	opts := &github.PullRequestListOptions{State: "open", ListOptions: github.ListOptions{PerPage: 100}}
+	for {
+		prs, res, err := client.PullRequests.List(ctx, owner, repo, opts)
+		if err != nil {
+			return nil, repository.WrapGithubError(err, owner, repo, "")
+		}
+
+		for _, pr := range prs {
+			base := pr.GetBase().GetRef()
+			for i, re := range branches {
+				submatches := re.FindStringSubmatchIndex(base)
+				if submatches == nil {
+					continue
+				}
+				change, err := planPullRequest(pr, rules[i], re, submatches, milestones)
+				if err != nil {
+					return nil, err
+				}
+				if change != nil {
+					changes = append(changes, *change)
+				}
+				break
+			}
+		}
+
+		if res.NextPage == 0 {
+			break
+		}
+		opts.Page = res.NextPage
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Number < changes[j].Number
+	})
+
+	return changes, nil
+}
+
+// planPullRequest returns the change the given pull request needs to match
+// the given rule, or nil if it matches already.
+func planPullRequest(pr *github.PullRequest, rule Rule, branch *regexp.Regexp, submatches []int, milestones []*github.Milestone) (*Change, error) {
+	change := Change{Number: pr.GetNumber(), Title: pr.GetTitle(), URL: pr.GetHTMLURL(), Base: pr.GetBase().GetRef()}
+
+	if pr.Milestone == nil && rule.Milestone != "" {
+		pattern := string(branch.ExpandString(nil, rule.Milestone, change.Base, submatches))
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, errors.New("invalid milestone " + pattern + " of branch " + change.Base + ": " + err.Error())
+		}
+		if m := lowestMilestone(milestones, re); m != nil {
+			change.Milestone = m.GetTitle()
+			change.milestoneNumber = m.GetNumber()
+		} else {
+			change.Warning = "no open milestone matches " + pattern
+		}
+	}
+
+	labels := make(map[string]bool, len(pr.Labels))
+	for _, label := range pr.Labels {
+		labels[label.GetName()] = true
+	}
+	for _, label := range rule.Labels {
+		if !labels[label] {
+			change.Labels = append(change.Labels, label)
+		}
+	}
+
+	if change.Milestone == "" && len(change.Labels) == 0 && change.Warning == "" {
+		return nil, nil
+	}
+
+	return &change, nil
+}
+
+// lowestMilestone returns the milestone matching the given regex with the
+// lowest version, or the first one if none is a valid version.
+func lowestMilestone(milestones []*github.Milestone, re *regexp.Regexp) *github.Milestone {
+	var lowest *github.Milestone
+	for _, m := range milestones {
+		if !re.MatchString(m.GetTitle()) {
+			continue
+		}
+		if lowest == nil || (semver.IsValid(m.GetTitle()) && (!semver.IsValid(lowest.GetTitle()) || semver.Compare(m.GetTitle(), lowest.GetTitle()) < 0)) {
+			lowest = m
+		}
+	}
+
+	return lowest
+}
+
+func openMilestones(ctx context.Context, client *github.Client, owner, repo string) ([]*github.Milestone, error) {
+	var milestones []*github.Milestone
+	opts := &github.MilestoneListOptions{State: "open", ListOptions: github.ListOptions{PerPage: 100}}
+	for {
+		page, res, err := client.Issues.ListMilestones(ctx, owner, repo, opts)
+		if err != nil {
+			return nil, repository.WrapGithubError(err, owner, repo, "")
+		}
+		milestones = append(milestones, page...)
+		if res.NextPage == 0 {
+			break
+		}
+		opts.Page = res.NextPage
+	}
+
+	return milestones, nil
+}
+
+// Apply sets the milestones and adds the labels of the given changes.
+func Apply(ctx context.Context, client *github.Client, owner, repo string, changes []Change) error {
+	for _, change := range changes {
+		if change.Milestone != "" {
+			req := &github.IssueRequest{Milestone: github.Int(change.milestoneNumber)}
+			if _, _, err := client.Issues.Edit(ctx, owner, repo, change.Number, req); err != nil {
+				return repository.WrapGithubError(err, owner, repo, change.Base)
+			}
+		}
+		if len(change.Labels) > 0 {
+			if _, _, err := client.Issues.AddLabelsToIssue(ctx, owner, repo, change.Number, change.Labels); err != nil {
+				return repository.WrapGithubError(err, owner, repo, change.Base)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,109 @@
+package backports
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v39/github"
+)
+
+func TestPlanAndApply(t *testing.T) {
+	edits := make(map[string]string)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/rancher/rke2/milestones", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[
+			{"number": 12, "title": "v1.29.4+rke2r1"},
+			{"number": 11, "title": "v1.29.3+rke2r1"},
+			{"number": 10, "title": "v1.28.8+rke2r1"}
+		]`)
+	})
+	mux.HandleFunc("/repos/rancher/rke2/pulls", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[
+			{"number": 5, "title": "[release-1.29] Bump etcd", "base": {"ref": "release-1.29"}},
+			{"number": 3, "title": "[release-1.28] Bump etcd", "base": {"ref": "release-1.28"}, "milestone": {"number": 10}, "labels": [{"name": "kind/backport"}]},
+			{"number": 4, "title": "[release-1.27] Bump etcd", "base": {"ref": "release-1.27"}, "labels": [{"name": "kind/backport"}]},
+			{"number": 2, "title": "Bump etcd", "base": {"ref": "master"}},
+			{"number": 1, "title": "[release-1.28] Bump containerd", "base": {"ref": "release-1.28"}, "milestone": {"number": 10}}
+		]`)
+	})
+	for _, number := range []string{"1", "5"} {
+		number := number
+		mux.HandleFunc("/repos/rancher/rke2/issues/"+number, func(w http.ResponseWriter, r *http.Request) {
+			var req github.IssueRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				t.Error(err)
+			}
+			edits[number+" milestone"] = fmt.Sprint(req.GetMilestone())
+			fmt.Fprint(w, `{}`)
+		})
+		mux.HandleFunc("/repos/rancher/rke2/issues/"+number+"/labels", func(w http.ResponseWriter, r *http.Request) {
+			var labels []string
+			if err := json.NewDecoder(r.Body).Decode(&labels); err != nil {
+				t.Error(err)
+			}
+			edits[number+" labels"] = strings.Join(labels, ",")
+			fmt.Fprint(w, `[]`)
+		})
+	}
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := github.NewClient(nil)
+	client.BaseURL, _ = url.Parse(server.URL + "/")
+
+	changes, err := Plan(context.Background(), client, "rancher", "rke2", DefaultRules)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, change := range changes {
+		got = append(got, fmt.Sprintf("#%d %s [%s] %s", change.Number, change.Milestone, strings.Join(change.Labels, ","), change.Warning))
+	}
+	want := []string{
+		"#1  [kind/backport] ",
+		`#4  [] no open milestone matches ^v1\.27\.\d+`,
+		"#5 v1.29.3+rke2r1 [kind/backport] ",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Plan() = %q, want %q", got, want)
+	}
+
+	if err := Apply(context.Background(), client, "rancher", "rke2", changes); err != nil {
+		t.Fatal(err)
+	}
+	wantEdits := map[string]string{
+		"1 labels":    "kind/backport",
+		"5 milestone": "11",
+		"5 labels":    "kind/backport",
+	}
+	if !reflect.DeepEqual(edits, wantEdits) {
+		t.Errorf("Apply() edits = %v, want %v", edits, wantEdits)
+	}
+}
+
+func TestValidateRules(t *testing.T) {
+	tests := []struct {
+		name    string
+		rules   []Rule
+		wantErr bool
+	}{
+		{name: "defaults", rules: DefaultRules},
+		{name: "invalid branch", rules: []Rule{{Branch: "release-(", Labels: []string{"kind/backport"}}}, wantErr: true},
+		{name: "nothing to apply", rules: []Rule{{Branch: "^release-"}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateRules(tt.rules); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateRules() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package channel
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"path"
+	"time"
+
+	"github.com/rancher/ecm-distro-tools/release/watchdog"
+)
+
+// servers contains the channel servers queried by the install scripts of
+// each product, get.k3s.io and get.rke2.io.
+var servers = map[string]string{
+	"k3s":  "https://update.k3s.io/v1-release/channels/",
+	"rke2": "https://update.rke2.io/v1-release/channels/",
+}
+
+// URL returns the URL the install script of the given product resolves the
+// given channel from, e.g. https://update.rke2.io/v1-release/channels/stable.
+func URL(product, channel string) (string, error) {
+	server, ok := servers[product]
+	if !ok {
+		return "", errors.New("invalid product: " + product + ", expected one of: k3s, rke2")
+	}
+
+	return server + url.PathEscape(channel), nil
+}
+
+// Resolve returns the version the given channel currently points to, the
+// same way the install scripts do, by taking the last path element of the
+// release URL the channel server redirects to.
+func Resolve(ctx context.Context, client *http.Client, product, channel string) (string, error) {
+	u, err := URL(product, channel)
+	if err != nil {
+		return "", err
+	}
+
+	return resolve(ctx, client, u)
+}
+
+func resolve(ctx context.Context, client *http.Client, channelURL string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, channelURL, nil)
+	if err != nil {
+		return "", err
+	}
+
+	// copy the client so the redirect to the release page is never followed.
+	noRedirect := *client
+	noRedirect.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+
+	res, err := noRedirect.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < http.StatusMultipleChoices || res.StatusCode >= http.StatusBadRequest {
+		return "", errors.New("expected a redirect from " + channelURL + ", got " + res.Status)
+	}
+
+	location, err := res.Location()
+	if err != nil {
+		return "", errors.New("failed to read the redirect location from " + channelURL + ": " + err.Error())
+	}
+
+	return path.Base(location.Path), nil
+}
+
+// WaitForVersion polls the given channel until it resolves to the given
+// version. The context controls how long to wait for.
+func WaitForVersion(ctx context.Context, client *http.Client, product, channel, version string, interval time.Duration) error {
+	u, err := URL(product, channel)
+	if err != nil {
+		return err
+	}
+
+	return waitForVersion(ctx, client, u, version, interval)
+}
+
+func waitForVersion(ctx context.Context, client *http.Client, channelURL, version string, interval time.Duration) error {
+	ctx, wait := watchdog.Default.Track(ctx, channelURL+" to resolve to "+version)
+	defer wait.Done()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var current string
+	for {
+		resolved, err := resolve(ctx, client, channelURL)
+		if err != nil && ctx.Err() != nil {
+			return errors.New("timed out waiting for " + channelURL + " to resolve to " + version + ": " + context.Cause(ctx).Error())
+		}
+		if err == nil {
+			if resolved == version {
+				return nil
+			}
+			current = resolved
+			wait.Progress("resolves to " + current)
+		}
+
+		select {
+		case <-ctx.Done():
+			return errors.New("timed out waiting for " + channelURL + " to resolve to " + version + ", currently resolves to " + current + ": " + context.Cause(ctx).Error())
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package channel
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWaitForVersion(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		version := "v1.29.1+rke2r1"
+		if requests.Add(1) > 2 {
+			version = "v1.29.2+rke2r1"
+		}
+		http.Redirect(w, r, "https://github.com/rancher/rke2/releases/tag/"+version, http.StatusFound)
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := waitForVersion(ctx, server.Client(), server.URL+"/stable", "v1.29.2+rke2r1", time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	if got := requests.Load(); got != 3 {
+		t.Errorf("expected 3 requests, got %d", got)
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	if err := waitForVersion(ctx, server.Client(), server.URL+"/stable", "v1.29.3+rke2r1", time.Millisecond); err == nil {
+		t.Error("expected a timeout error")
+	}
+}
+
+func TestURL(t *testing.T) {
+	got, err := URL("rke2", "v1.29")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "https://update.rke2.io/v1-release/channels/v1.29"; got != want {
+		t.Errorf("URL() = %q, want %q", got, want)
+	}
+
+	if _, err := URL("rancher", "stable"); err == nil {
+		t.Error("URL() expected error for invalid product")
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"strconv"
 	"strings"
 
 	"github.com/go-git/go-git/v5"
@@ -17,6 +18,10 @@ import (
 	"github.com/rancher/ecm-distro-tools/repository"
 )
 
+// releaseCommitPrefix starts the message of the commits releasing a chart,
+// followed by the chart name and version.
+const releaseCommitPrefix = "release chart: "
+
 // chartsReleasePRBody is the default PR body for the charts release PR
 const chartsReleasePRBody = `
 ## Charts Checklist (built for v0.9.X charts-build-scripts)
@@ -91,7 +96,7 @@ func Update(ctx context.Context, c *config.ChartsRelease, br, ch, vr string) (st
 		return string(output), err
 	}
 
-	commitMsg := "release chart: " + ch + " - version: " + vr
+	commitMsg := releaseCommitPrefix + ch + " - version: " + vr
 	if _, err := wt.Commit(commitMsg, &git.CommitOptions{All: true}); err != nil {
 		return string(output), err
 	}
@@ -144,9 +149,49 @@ func Push(ctx context.Context, conf *config.ChartsRelease, user *config.User, gh
 		return "", err
 	}
 
+	released, err := releasedCharts(ctx, ghc, repoOwner, repoName, prResp.GetNumber())
+	if err != nil {
+		fmt.Println("failed to list the charts released by the pull request: " + err.Error())
+	}
+	if err := repository.RequestOwnerReviews(ctx, ghc, repoOwner, repoName, prResp.GetNumber(), released...); err != nil {
+		fmt.Println("failed to request reviews from the component owners: " + err.Error())
+	}
+
 	return prResp.GetHTMLURL(), nil
 }
 
+// releasedCharts returns the charts released by the given pull request,
+// e.g. rancher-monitoring, read from the messages of its release commits.
+func releasedCharts(ctx context.Context, ghc *github.Client, owner, repo string, number int) ([]string, error) {
+	var released []string
+	seen := make(map[string]bool)
+
+	opts := &github.ListOptions{PerPage: 100}
+	for {
+		commits, res, err := ghc.PullRequests.ListCommits(ctx, owner, repo, number, opts)
+		if err != nil {
+			return nil, repository.WrapGithubError(err, owner, repo, "#"+strconv.Itoa(number))
+		}
+		for _, commit := range commits {
+			msg, ok := strings.CutPrefix(commit.GetCommit().GetMessage(), releaseCommitPrefix)
+			if !ok {
+				continue
+			}
+			chart, _, _ := strings.Cut(msg, " - version: ")
+			if !seen[chart] {
+				seen[chart] = true
+				released = append(released, chart)
+			}
+		}
+		if res.NextPage == 0 {
+			break
+		}
+		opts.Page = res.NextPage
+	}
+
+	return released, nil
+}
+
 func runChartsBuild(chartsRepoPath string, args ...string) ([]byte, error) {
 	// save current working dir
 	ecmWorkDir, err := os.Getwd()
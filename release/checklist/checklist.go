@@ -0,0 +1,407 @@
+package checklist
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rancher/ecm-distro-tools/release"
+	"github.com/rancher/ecm-distro-tools/release/channel"
+	"github.com/rancher/ecm-distro-tools/release/status"
+	"golang.org/x/mod/semver"
+)
+
+// Kind is the verification of a checklist item.
+type Kind string
+
+const (
+	// Manual items are attested by checking their box in the tracking
+	// issue.
+	Manual Kind = "manual"
+	// Tagged verifies the release tag exists.
+	Tagged Kind = "tagged"
+	// CI verifies the workflow runs triggered by the tag succeeded.
+	CI Kind = "ci"
+	// Published verifies the GitHub release is published, neither draft
+	// nor prerelease.
+	Published Kind = "published"
+	// Assets verifies all the assets of the release were uploaded.
+	Assets Kind = "assets"
+	// AssetRules verifies the assets of the release follow the asset
+	// rules, e.g. their content types.
+	AssetRules Kind = "asset-rules"
+	// Channel verifies the channel of the release line, e.g. v1.29,
+	// resolves to the release.
+	Channel Kind = "channel"
+)
+
+// Item is a step of a release checklist.
+type Item struct {
+	// ID identifies the item in the tracking issue, so its attestation
+	// survives changes to its description.
+	ID          string
+	Description string
+	Kind        Kind
+}
+
+// Automated returns true if the item is verified without attestation.
+func (i Item) Automated() bool {
+	return i.Kind != Manual
+}
+
+// Checklist is the steps of the release of a product.
+type Checklist struct {
+	Product string
+	Items   []Item
+}
+
+// Checklists are the release checklists of each product.
+var Checklists = map[string]Checklist{
+	"k3s": {
+		Product: "k3s",
+		Items: []Item{
+			{ID: "qa-milestone", Description: "QA: Validate and close out all issues in the release milestone", Kind: Manual},
+			{ID: "release-notes", Description: "Release Captain: Prepare the release notes", Kind: Manual},
+			{ID: "tag", Description: "Release Captain: Tag the k3s release", Kind: Tagged},
+			{ID: "ci", Description: "CI is green for the tag", Kind: CI},
+			{ID: "assets", Description: "All release artifacts exist", Kind: Assets},
+			{ID: "asset-rules", Description: "Release artifacts follow the asset rules", Kind: AssetRules},
+			{ID: "kdm", Description: "Release Captain: Update KDM to the release", Kind: Manual},
+			{ID: "publish", Description: "Release Captain: Uncheck pre-release once the release is fully complete", Kind: Published},
+			{ID: "channel", Description: "The release line channel points to the release", Kind: Channel},
+			{ID: "close-milestone", Description: "PJM: Close the milestone in GitHub", Kind: Manual},
+		},
+	},
+	"rke2": {
+		Product: "rke2",
+		Items: []Item{
+			{ID: "qa-milestone", Description: "QA: Validate and close out all issues in the release milestone", Kind: Manual},
+			{ID: "release-notes", Description: "Release Captain: Prepare the release notes", Kind: Manual},
+			{ID: "hardened-kubernetes", Description: "Release Captain: Tag new Hardened Kubernetes release", Kind: Manual},
+			{ID: "charts", Description: "Release Captain: Update Helm chart versions", Kind: Manual},
+			{ID: "tag", Description: "Release Captain: Tag the RKE2 release", Kind: Tagged},
+			{ID: "ci", Description: "CI is green for the tag", Kind: CI},
+			{ID: "assets", Description: "All release artifacts exist", Kind: Assets},
+			{ID: "asset-rules", Description: "Release artifacts follow the asset rules", Kind: AssetRules},
+			{ID: "packaging", Description: `Release Captain: Tag RKE2 packaging release "testing" and "latest"`, Kind: Manual},
+			{ID: "publish", Description: "Release Captain: Uncheck pre-release once the release is fully complete", Kind: Published},
+			{ID: "packaging-stable", Description: `Release Captain: Tag RKE2 packaging "stable" after 24 hours`, Kind: Manual},
+			{ID: "channel", Description: "The release line channel points to the release", Kind: Channel},
+			{ID: "kdm", Description: "Release Captain: Update KDM from RC to the release", Kind: Manual},
+			{ID: "close-milestone", Description: "PJM: Close the milestone in GitHub", Kind: Manual},
+		},
+	},
+}
+
+// Get returns the checklist of the given product.
+func Get(product string) (Checklist, error) {
+	list, ok := Checklists[product]
+	if !ok {
+		return Checklist{}, errors.New("no checklist for " + product + ", expected one of: k3s, rke2")
+	}
+
+	return list, nil
+}
+
+// Verifier performs the automated verifications, implemented by
+// NewVerifier.
+type Verifier interface {
+	Status(ctx context.Context, target status.Target) status.Status
+	CheckAssetRules(ctx context.Context, owner, repo, tag string) ([]release.AssetViolation, error)
+	ResolveChannel(ctx context.Context, product, channel string) (string, error)
+}
+
+type verifier struct {
+	client *release.Client
+	http   *http.Client
+}
+
+// NewVerifier returns a verifier querying GitHub with the given client and
+// the channel servers with the given HTTP client.
+func NewVerifier(client *release.Client, httpClient *http.Client) Verifier {
+	return verifier{client: client, http: httpClient}
+}
+
+func (v verifier) Status(ctx context.Context, target status.Target) status.Status {
+	return status.Check(ctx, v.client, target)
+}
+
+func (v verifier) CheckAssetRules(ctx context.Context, owner, repo, tag string) ([]release.AssetViolation, error) {
+	return v.client.CheckAssetRules(ctx, owner, repo, tag)
+}
+
+func (v verifier) ResolveChannel(ctx context.Context, product, name string) (string, error) {
+	return channel.Resolve(ctx, v.http, product, name)
+}
+
+// Result is the state of a checklist item.
+type Result struct {
+	Item
+	Done bool
+	// Detail explains the state of automated items, e.g. the CI state.
+	Detail string
+	Err    error
+}
+
+// Progress is the state of each item of a checklist for a release.
+type Progress struct {
+	Target  status.Target
+	Results []Result
+}
+
+// Done returns the number of items done.
+func (p Progress) Done() int {
+	var done int
+	for _, r := range p.Results {
+		if r.Done {
+			done++
+		}
+	}
+
+	return done
+}
+
+// Complete returns true if every item is done.
+func (p Progress) Complete() bool {
+	return p.Done() == len(p.Results)
+}
+
+// Summary describes the progress, e.g. 4/10 done.
+func (p Progress) Summary() string {
+	return strconv.Itoa(p.Done()) + "/" + strconv.Itoa(len(p.Results)) + " done"
+}
+
+// Evaluate verifies the automated items of the checklist for the given
+// release, and takes the manual items as done if attested, by ID. The
+// release status is looked up once for all the items relying on it.
+func Evaluate(ctx context.Context, v Verifier, list Checklist, target status.Target, attested map[string]bool) Progress {
+	progress := Progress{Target: target, Results: make([]Result, len(list.Items))}
+
+	var s *status.Status
+	for i, item := range list.Items {
+		r := Result{Item: item}
+		switch item.Kind {
+		case Manual:
+			r.Done = attested[item.ID]
+		case Tagged, CI, Published, Assets:
+			if s == nil {
+				checked := v.Status(ctx, target)
+				s = &checked
+			}
+			r.Done, r.Detail = statusResult(*s, item.Kind)
+			r.Err = s.Err
+		case AssetRules:
+			violations, err := v.CheckAssetRules(ctx, target.Owner, target.Repo, target.Tag)
+			r.Err = err
+			r.Done = err == nil && len(violations) == 0
+			if len(violations) > 0 {
+				r.Detail = strconv.Itoa(len(violations)) + " violations, first: " + violations[0].String()
+			}
+		case Channel:
+			name := semver.MajorMinor(target.Tag)
+			resolved, err := v.ResolveChannel(ctx, list.Product, name)
+			r.Err = err
+			r.Done = err == nil && resolved == target.Tag
+			if err == nil {
+				r.Detail = name + " channel points to " + resolved
+			}
+		default:
+			r.Err = errors.New("unknown check kind " + string(item.Kind))
+		}
+		progress.Results[i] = r
+	}
+
+	return progress
+}
+
+func statusResult(s status.Status, kind Kind) (bool, string) {
+	switch kind {
+	case Tagged:
+		return s.Tagged, ""
+	case CI:
+		detail := s.CI
+		if s.CIURL != "" {
+			detail += " " + s.CIURL
+		}
+		return s.CI == "success", detail
+	case Published:
+		return s.Release == "published", s.Release
+	default:
+		return s.Assets, ""
+	}
+}
+
+// The markers delimiting the checklist in the tracking issue body, so it's
+// rendered again without touching the rest of the issue.
+const (
+	startMarker = "<!-- checklist:start -->"
+	endMarker   = "<!-- checklist:end -->"
+)
+
+// itemRegex matches the rendered items, e.g.
+// - [x] QA: Validate and close out all issues <!-- qa-milestone -->
+var itemRegex = regexp.MustCompile(`^\s*- \[([ xX])\] .*<!-- ([\w-]+) -->\s*$`)
+
+// Attested returns the items checked in the checklist of the given tracking
+// issue body, by ID.
+func Attested(body string) map[string]bool {
+	attested := make(map[string]bool)
+
+	section, ok := checklistSection(body)
+	if !ok {
+		return attested
+	}
+	for _, line := range strings.Split(section, "\n") {
+		if match := itemRegex.FindStringSubmatch(line); match != nil && match[1] != " " {
+			attested[match[2]] = true
+		}
+	}
+
+	return attested
+}
+
+func checklistSection(body string) (string, bool) {
+	start := strings.Index(body, startMarker)
+	if start == -1 {
+		return "", false
+	}
+	end := strings.Index(body[start:], endMarker)
+	if end == -1 {
+		return "", false
+	}
+
+	return body[start+len(startMarker) : start+end], true
+}
+
+// Render renders the progress as a markdown task list, automated items
+// noting the verification.
+func Render(p Progress) string {
+	var b strings.Builder
+	b.WriteString("**Release checklist: " + p.Summary() + "**\n")
+	for _, r := range p.Results {
+		box := "[ ]"
+		if r.Done {
+			box = "[x]"
+		}
+
+		line := "- " + box + " " + r.Description
+		if r.Automated() {
+			note := "verified automatically"
+			if r.Detail != "" {
+				note += ": " + r.Detail
+			}
+			if r.Err != nil {
+				note = "verification failed: " + r.Err.Error()
+			}
+			line += " _(" + note + ")_"
+		}
+		b.WriteString(line + " <!-- " + r.ID + " -->\n")
+	}
+
+	return b.String()
+}
+
+// UpdateBody renders the progress into the given tracking issue body,
+// replacing the checklist it already contains or appending it.
+func UpdateBody(body string, p Progress) string {
+	section := startMarker + "\n" + Render(p) + endMarker
+
+	if _, ok := checklistSection(body); ok {
+		start := strings.Index(body, startMarker)
+		end := start + strings.Index(body[start:], endMarker) + len(endMarker)
+		return body[:start] + section + body[end:]
+	}
+	if body == "" {
+		return section
+	}
+
+	return strings.TrimRight(body, "\n") + "\n\n" + section
+}
+
+// Closing summarizes a completed release in the final comment of its
+// tracking issue, before it's closed.
+type Closing struct {
+	Progress Progress
+	// ReleaseURL is the GitHub release, the releases page of the tag if
+	// empty.
+	ReleaseURL string
+	// Opened is when the tracking issue was opened, Published when the
+	// release was published, zero if unknown, and Closed when the issue is
+	// closed.
+	Opened    time.Time
+	Published time.Time
+	Closed    time.Time
+}
+
+// RenderClosing renders the closing comment of the tracking issue: the tag
+// and its links, the result of every item of the checklist and how long
+// the release took since the issue was opened.
+func RenderClosing(c Closing) string {
+	t := c.Progress.Target
+	repoURL := "https://github.com/" + t.Owner + "/" + t.Repo
+	releaseURL := c.ReleaseURL
+	if releaseURL == "" {
+		releaseURL = repoURL + "/releases/tag/" + t.Tag
+	}
+
+	var b strings.Builder
+	b.WriteString("**Release " + t.Tag + " complete, closing the tracking issue.**\n\n")
+	b.WriteString("- Tag: [" + t.Tag + "](" + repoURL + "/tree/" + t.Tag + ")\n")
+	b.WriteString("- Release: " + releaseURL + "\n")
+	b.WriteString("- CI: " + repoURL + "/actions?query=" + url.QueryEscape("branch:"+t.Tag) + "\n")
+
+	b.WriteString("\n**Verification: " + c.Progress.Summary() + "**\n")
+	for _, r := range c.Progress.Results {
+		line := "- " + r.Description
+		if r.Automated() {
+			line += ": verified automatically"
+			if r.Detail != "" {
+				line += ", " + r.Detail
+			}
+		} else {
+			line += ": attested"
+		}
+		b.WriteString(line + "\n")
+	}
+
+	b.WriteString("\n**Durations**\n")
+	if !c.Published.IsZero() {
+		b.WriteString("- Opened to published: " + formatDuration(c.Published.Sub(c.Opened)) + "\n")
+	}
+	b.WriteString("- Opened to closed: " + formatDuration(c.Closed.Sub(c.Opened)) + "\n")
+
+	return b.String()
+}
+
+// formatDuration formats the given duration in days, hours and minutes,
+// e.g. 2d 3h 5m.
+func formatDuration(d time.Duration) string {
+	d = d.Round(time.Minute)
+	if d < time.Minute {
+		return "0m"
+	}
+
+	days := d / (24 * time.Hour)
+	d -= days * 24 * time.Hour
+	hours := d / time.Hour
+	d -= hours * time.Hour
+	minutes := d / time.Minute
+
+	var parts []string
+	if days > 0 {
+		parts = append(parts, strconv.Itoa(int(days))+"d")
+	}
+	if hours > 0 {
+		parts = append(parts, strconv.Itoa(int(hours))+"h")
+	}
+	if minutes > 0 {
+		parts = append(parts, strconv.Itoa(int(minutes))+"m")
+	}
+
+	return strings.Join(parts, " ")
+}
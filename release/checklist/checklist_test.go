@@ -0,0 +1,198 @@
+package checklist
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rancher/ecm-distro-tools/release"
+	"github.com/rancher/ecm-distro-tools/release/status"
+)
+
+type fakeVerifier struct {
+	status     status.Status
+	violations []release.AssetViolation
+	channel    string
+	channelErr error
+	statuses   int
+}
+
+func (f *fakeVerifier) Status(ctx context.Context, target status.Target) status.Status {
+	f.statuses++
+	s := f.status
+	s.Target = target
+
+	return s
+}
+
+func (f *fakeVerifier) CheckAssetRules(ctx context.Context, owner, repo, tag string) ([]release.AssetViolation, error) {
+	return f.violations, nil
+}
+
+func (f *fakeVerifier) ResolveChannel(ctx context.Context, product, channel string) (string, error) {
+	if channel != "v1.29" {
+		return "", errors.New("unexpected channel " + channel)
+	}
+
+	return f.channel, f.channelErr
+}
+
+func TestEvaluate(t *testing.T) {
+	list := Checklist{
+		Product: "rke2",
+		Items: []Item{
+			{ID: "qa", Description: "QA: Validate the milestone", Kind: Manual},
+			{ID: "notes", Description: "Prepare the release notes", Kind: Manual},
+			{ID: "tag", Description: "Tag the release", Kind: Tagged},
+			{ID: "ci", Description: "CI is green", Kind: CI},
+			{ID: "publish", Description: "Publish the release", Kind: Published},
+			{ID: "assets", Description: "All artifacts exist", Kind: Assets},
+			{ID: "asset-rules", Description: "Artifacts follow the rules", Kind: AssetRules},
+			{ID: "channel", Description: "The channel points to the release", Kind: Channel},
+		},
+	}
+	target := status.Target{Owner: "rancher", Repo: "rke2", Tag: "v1.29.2+rke2r1"}
+
+	v := &fakeVerifier{
+		status:     status.Status{Tagged: true, CI: "failure", CIURL: "https://github.com/rancher/rke2/actions/runs/1", Release: "prerelease", Assets: true},
+		violations: []release.AssetViolation{{Asset: "sha256sum-amd64.txt", Reason: "empty"}},
+		channel:    "v1.29.1+rke2r1",
+	}
+	progress := Evaluate(context.Background(), v, list, target, map[string]bool{"qa": true, "tag": false})
+
+	done := make(map[string]bool)
+	for _, r := range progress.Results {
+		done[r.ID] = r.Done
+	}
+	want := map[string]bool{
+		"qa":          true,
+		"notes":       false,
+		"tag":         true,
+		"ci":          false,
+		"publish":     false,
+		"assets":      true,
+		"asset-rules": false,
+		"channel":     false,
+	}
+	if !reflect.DeepEqual(done, want) {
+		t.Errorf("Evaluate() done = %v, want %v", done, want)
+	}
+	if v.statuses != 1 {
+		t.Errorf("status looked up %d times, want once", v.statuses)
+	}
+	if got := progress.Summary(); got != "3/8 done" {
+		t.Errorf("Summary() = %s, want 3/8 done", got)
+	}
+	if progress.Results[3].Detail != "failure https://github.com/rancher/rke2/actions/runs/1" {
+		t.Errorf("CI detail = %q", progress.Results[3].Detail)
+	}
+
+	v.channelErr = errors.New("connection refused")
+	progress = Evaluate(context.Background(), v, list, target, nil)
+	if r := progress.Results[7]; r.Done || r.Err == nil {
+		t.Errorf("channel result = %+v, want the resolution error", r)
+	}
+}
+
+func TestUpdateBody(t *testing.T) {
+	progress := Progress{Results: []Result{
+		{Item: Item{ID: "qa", Description: "QA: Validate the milestone", Kind: Manual}, Done: true},
+		{Item: Item{ID: "notes", Description: "Prepare the release notes", Kind: Manual}},
+		{Item: Item{ID: "ci", Description: "CI is green", Kind: CI}, Done: true, Detail: "success"},
+		{Item: Item{ID: "assets", Description: "All artifacts exist", Kind: Assets}, Err: errors.New("not found")},
+	}}
+
+	body := UpdateBody("**Summary:**\nTask covering patch release work.\n", progress)
+	for _, want := range []string{
+		"**Summary:**\nTask covering patch release work.\n\n" + startMarker,
+		"**Release checklist: 2/4 done**",
+		"- [x] QA: Validate the milestone <!-- qa -->",
+		"- [ ] Prepare the release notes <!-- notes -->",
+		"- [x] CI is green _(verified automatically: success)_ <!-- ci -->",
+		"- [ ] All artifacts exist _(verification failed: not found)_ <!-- assets -->",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("UpdateBody() = %q, want it to contain %q", body, want)
+		}
+	}
+
+	// attest the release notes in the issue, as a captain would.
+	body = strings.Replace(body, "- [ ] Prepare the release notes", "- [x] Prepare the release notes", 1) + "\nFollow-up: none\n"
+	attested := Attested(body)
+	if want := map[string]bool{"qa": true, "notes": true, "ci": true}; !reflect.DeepEqual(attested, want) {
+		t.Errorf("Attested() = %v, want %v", attested, want)
+	}
+
+	progress.Results[1].Done = attested["notes"]
+	updated := UpdateBody(body, progress)
+	if strings.Count(updated, startMarker) != 1 || !strings.Contains(updated, "3/4 done") || !strings.HasSuffix(updated, "\nFollow-up: none\n") {
+		t.Errorf("UpdateBody() = %q, want the checklist replaced in place", updated)
+	}
+
+	if got := Attested("no checklist - [x] QA <!-- qa -->"); len(got) != 0 {
+		t.Errorf("Attested() = %v, want nothing outside the checklist", got)
+	}
+}
+
+func TestRenderClosing(t *testing.T) {
+	opened := time.Date(2024, time.February, 12, 9, 0, 0, 0, time.UTC)
+	closing := Closing{
+		Progress: Progress{
+			Target: status.Target{Owner: "rancher", Repo: "rke2", Tag: "v1.29.2+rke2r1"},
+			Results: []Result{
+				{Item: Item{ID: "qa", Description: "QA: Validate the milestone", Kind: Manual}, Done: true},
+				{Item: Item{ID: "ci", Description: "CI is green", Kind: CI}, Done: true, Detail: "success"},
+				{Item: Item{ID: "tag", Description: "Tag the release", Kind: Tagged}, Done: true},
+			},
+		},
+		Opened:    opened,
+		Published: opened.Add(50*time.Hour + 30*time.Minute),
+		Closed:    opened.Add(74 * time.Hour),
+	}
+
+	got := RenderClosing(closing)
+	for _, want := range []string{
+		"**Release v1.29.2+rke2r1 complete, closing the tracking issue.**",
+		"- Tag: [v1.29.2+rke2r1](https://github.com/rancher/rke2/tree/v1.29.2+rke2r1)",
+		"- Release: https://github.com/rancher/rke2/releases/tag/v1.29.2+rke2r1",
+		"- CI: https://github.com/rancher/rke2/actions?query=branch%3Av1.29.2%2Brke2r1",
+		"**Verification: 3/3 done**",
+		"- QA: Validate the milestone: attested",
+		"- CI is green: verified automatically, success",
+		"- Tag the release: verified automatically\n",
+		"- Opened to published: 2d 2h 30m",
+		"- Opened to closed: 3d 2h",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("RenderClosing() = %q, want it to contain %q", got, want)
+		}
+	}
+
+	closing.Published = time.Time{}
+	closing.ReleaseURL = "https://github.com/rancher/rke2/releases/tag/v1.29.2%2Brke2r1"
+	got = RenderClosing(closing)
+	if strings.Contains(got, "published") || !strings.Contains(got, "- Release: "+closing.ReleaseURL) {
+		t.Errorf("RenderClosing() = %q, want the given release URL and no publication duration", got)
+	}
+}
+
+func TestChecklists(t *testing.T) {
+	for product, list := range Checklists {
+		if list.Product != product {
+			t.Errorf("checklist %s is for product %s", product, list.Product)
+		}
+		ids := make(map[string]bool)
+		for _, item := range list.Items {
+			if ids[item.ID] {
+				t.Errorf("checklist %s has duplicate item %s", product, item.ID)
+			}
+			ids[item.ID] = true
+			if !itemRegex.MatchString("- [ ] " + item.Description + " <!-- " + item.ID + " -->") {
+				t.Errorf("checklist %s item %s can't be parsed back", product, item.ID)
+			}
+		}
+	}
+}
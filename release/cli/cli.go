@@ -26,7 +26,9 @@ func CreateRelease(ctx context.Context, client *github.Client, opts *repository.
 		return errors.New("tag isn't a valid semver: " + opts.Tag)
 	}
 
-	latestPreRelease, err := release.LatestPreRelease(ctx, client, opts.Owner, opts.Repo, opts.Tag, releaseType)
+	releaseClient := release.NewClient(client)
+
+	latestPreRelease, err := releaseClient.LatestPreRelease(ctx, opts.Owner, opts.Repo, opts.Tag, releaseType)
 	if err != nil {
 		return err
 	}
@@ -51,8 +53,8 @@ func CreateRelease(ctx context.Context, client *github.Client, opts *repository.
 		}
 		opts.Tag = fmt.Sprintf("%s-%s.%d", opts.Tag, releaseType, latestRCNumber)
 	} else {
-		fmt.Printf("release.GenReleaseNotes(ctx, %s, %s, %s, %s, client)", opts.Owner, opts.Repo, opts.Branch, previousTag)
-		buff, err := release.GenReleaseNotes(ctx, opts.Owner, opts.Repo, opts.Branch, previousTag, client)
+		fmt.Printf("GenReleaseNotes(ctx, %s, %s, %s, %s)", opts.Owner, opts.Repo, opts.Branch, previousTag)
+		buff, err := releaseClient.GenReleaseNotes(ctx, opts.Owner, opts.Repo, opts.Branch, previousTag)
 		if err != nil {
 			return err
 		}
@@ -158,6 +160,10 @@ func createCLIReferencesPR(ctx context.Context, ghClient *github.Client, tag, re
 
 	fmt.Println("Pull Request created successfully:", pr.GetHTMLURL())
 
+	if err := repository.RequestOwnerReviews(ctx, ghClient, rancherRepoOwner, cliRepoName, pr.GetNumber(), "rancher"); err != nil {
+		fmt.Println("failed to request reviews from the component owners: " + err.Error())
+	}
+
 	return nil
 }
 
@@ -172,10 +178,9 @@ RELEASE_BRANCH="{{ .ReleaseBranch }}"
 RANCHER_COMMIT_SHA="{{ .RancherCommitSHA }}"
 UPSTREAM_URL="{{ .CLIUpstreamURL }}"
 
-# Add upstream remote if it doesn't exist
-# Note: Using ls | grep is not recommended for general use, but it's okay here
-# since we're only checking for 'rancher'
-git remote -v | grep -w upstream || git remote add upstream "$UPSTREAM_URL"
+# Add the upstream remote, or point it to the given URL, e.g. the sandbox of
+# a rehearsal
+git remote add upstream "$UPSTREAM_URL" 2>/dev/null || git remote set-url upstream "$UPSTREAM_URL"
 git fetch upstream
 git stash
 
@@ -0,0 +1,47 @@
+package release
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-github/v39/github"
+	httpecm "github.com/rancher/ecm-distro-tools/http"
+	"github.com/rancher/ecm-distro-tools/registry"
+	"github.com/rancher/ecm-distro-tools/release/components"
+	"github.com/rancher/ecm-distro-tools/repository"
+	"github.com/sirupsen/logrus"
+)
+
+// Client carries the dependencies of the release functions: the GitHub
+// client, the HTTP client used to fetch raw files, e.g. go.mod files and
+// image lists, the logger, the GitHub release cache and the function reading
+// the labels and SBOMs of images. Any of them can be replaced to proxy,
+// record or fake the requests.
+type Client struct {
+	GitHub        *github.Client
+	HTTP          *http.Client
+	Log           logrus.FieldLogger
+	Cache         *repository.ReleaseCache
+	ImageMetadata func(ctx context.Context, ref name.Reference) (registry.Metadata, error)
+	// Components are the specs of the components of the k3s and rke2
+	// release notes, taking precedence over their default ones.
+	Components components.Mapping
+}
+
+// NewClient creates a new client with the given GitHub client, an HTTP
+// client using the shared transport and revalidating previously fetched
+// files, the standard logger and the shared release cache.
+func NewClient(gh *github.Client) *Client {
+	httpClient := httpecm.NewClient(defaultTimeout)
+	httpClient.Transport = httpecm.NewConditionalTransport(httpClient.Transport, httpecm.DefaultCacheDir())
+
+	return &Client{
+		GitHub: gh,
+		HTTP:   &httpClient,
+		Log:    logrus.StandardLogger(),
+		Cache:  repository.SharedReleaseCache(),
+
+		ImageMetadata: registry.ImageMetadata,
+	}
+}
@@ -0,0 +1,198 @@
+package release
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+)
+
+// ComponentChange is a component whose version changed since the previous
+// milestone.
+type ComponentChange struct {
+	Previous string
+	Current  string
+	// CompareURL is the upstream compare view between both versions.
+	CompareURL string
+}
+
+// componentUpstream is the name and upstream repo of a component of the
+// release notes, where tagPrefix is prepended to the version to get its
+// tag, e.g. v for Traefik, whose version is scraped without it.
+type componentUpstream struct {
+	name      string
+	repo      string
+	tagPrefix string
+}
+
+// componentUpstreams maps the fields of the release notes data of each repo
+// to the name and upstream repo of the component.
+var componentUpstreams = map[string]map[string]componentUpstream{
+	k3sRepo: {
+		"K8sVersion":                  {name: "kubernetes", repo: "kubernetes/kubernetes"},
+		"KineVersion":                 {name: "kine", repo: "k3s-io/kine"},
+		"EtcdVersion":                 {name: "etcd", repo: "k3s-io/etcd"},
+		"ContainerdVersion":           {name: "containerd", repo: "k3s-io/containerd"},
+		"RuncVersion":                 {name: "runc", repo: "opencontainers/runc"},
+		"FlannelVersion":              {name: "flannel", repo: "flannel-io/flannel"},
+		"MetricsServerVersion":        {name: "metrics-server", repo: "kubernetes-sigs/metrics-server"},
+		"TraefikVersion":              {name: "traefik", repo: "traefik/traefik", tagPrefix: "v"},
+		"CoreDNSVersion":              {name: "coredns", repo: "coredns/coredns", tagPrefix: "v"},
+		"HelmControllerVersion":       {name: "helm-controller", repo: "k3s-io/helm-controller"},
+		"LocalPathProvisionerVersion": {name: "local-path-provisioner", repo: "rancher/local-path-provisioner"},
+	},
+	rke2Repo: {
+		"K8sVersion":            {name: "kubernetes", repo: "kubernetes/kubernetes"},
+		"EtcdVersion":           {name: "etcd", repo: "k3s-io/etcd"},
+		"ContainerdVersion":     {name: "containerd", repo: "k3s-io/containerd"},
+		"RuncVersion":           {name: "runc", repo: "opencontainers/runc"},
+		"MetricsServerVersion":  {name: "metrics-server", repo: "kubernetes-sigs/metrics-server"},
+		"CoreDNSVersion":        {name: "coredns", repo: "coredns/coredns"},
+		"IngressNginxVersion":   {name: "ingress-nginx", repo: "rancher/ingress-nginx"},
+		"HelmControllerVersion": {name: "helm-controller", repo: "k3s-io/helm-controller"},
+		"FlannelVersion":        {name: "flannel", repo: "flannel-io/flannel"},
+		"CanalCalicoVersion":    {name: "canal-calico", repo: "projectcalico/calico"},
+		"CalicoVersion":         {name: "calico", repo: "projectcalico/calico"},
+		"CiliumVersion":         {name: "cilium", repo: "cilium/cilium"},
+		"MultusVersion":         {name: "multus", repo: "k8snetworkplumbingwg/multus-cni"},
+		// components of the Windows agents.
+		"WindowsContainerdVersion": {name: "containerd-windows", repo: "k3s-io/containerd"},
+		"WinsVersion":              {name: "wins", repo: "rancher/wins"},
+		"CalicoWindowsVersion":     {name: "calico-windows", repo: "projectcalico/calico"},
+	},
+}
+
+func (rd *releaseNoteData) setComponentChanges(changes map[string]ComponentChange) {
+	rd.ComponentChanges = changes
+}
+
+// addComponentChanges resolves the component versions of the previous
+// milestone the same way as the ones of the snapshot and records the
+// components that changed, so the notes link to their upstream changes.
+// The notes are left untouched if the previous milestone can't be resolved.
+func (c *Client) addComponentChanges(ctx context.Context, opts NotesOptions, snapshot *ReleaseNotesSnapshot) error {
+	if _, ok := componentUpstreams[snapshot.Repo]; !ok {
+		return nil
+	}
+
+	prevSnapshot, err := c.releaseNotesSnapshot(opts.Repo, opts.PrevMilestone, "", nil)
+	if err != nil {
+		c.Log.Warnf("not linking component changes, failed to resolve the components of %s: %v", opts.PrevMilestone, err)
+		return nil
+	}
+	if opts.FromImages {
+		if err := c.fillFromImages(ctx, opts.Owner, prevSnapshot); err != nil {
+			return err
+		}
+	}
+
+	prev, err := newReleaseNote(snapshot.Repo)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(prevSnapshot.Data, prev); err != nil {
+		return err
+	}
+	current, err := newReleaseNote(snapshot.Repo)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(snapshot.Data, current); err != nil {
+		return err
+	}
+
+	changes := componentChanges(snapshot.Repo, prev, current)
+	current.(interface {
+		setComponentChanges(map[string]ComponentChange)
+	}).setComponentChanges(changes)
+
+	data, err := json.Marshal(current)
+	if err != nil {
+		return err
+	}
+	snapshot.Data = data
+
+	return nil
+}
+
+// componentChanges returns the components of the given repo whose version
+// differs between the previous and current release notes data, by field.
+// Components missing from either are skipped.
+func componentChanges(repo string, prev, current releaseNote) map[string]ComponentChange {
+	changes := make(map[string]ComponentChange)
+
+	prevValue := reflect.ValueOf(prev).Elem()
+	currentValue := reflect.ValueOf(current).Elem()
+	for field, upstream := range componentUpstreams[repo] {
+		prevVersion := prevValue.FieldByName(field).String()
+		currentVersion := currentValue.FieldByName(field).String()
+		if prevVersion == "" || currentVersion == "" || prevVersion == currentVersion {
+			continue
+		}
+
+		changes[field] = ComponentChange{
+			Previous:   prevVersion,
+			Current:    currentVersion,
+			CompareURL: "https://github.com/" + upstream.repo + "/compare/" + tagName(upstream.tagPrefix, prevVersion) + "..." + tagName(upstream.tagPrefix, currentVersion),
+		}
+	}
+
+	return changes
+}
+
+func tagName(prefix, version string) string {
+	if strings.HasPrefix(version, prefix) {
+		return version
+	}
+
+	return prefix + version
+}
+
+// ComponentVersions returns the versions of the components of the given
+// release notes snapshot, by component name, e.g. runc. Components the
+// snapshot doesn't have a version for are omitted.
+func ComponentVersions(snapshot *ReleaseNotesSnapshot) (map[string]string, error) {
+	upstreams, ok := componentUpstreams[snapshot.Repo]
+	if !ok {
+		return nil, errors.New("no components for repo " + snapshot.Repo + ", expected one of: k3s, rke2")
+	}
+
+	rd, err := newReleaseNote(snapshot.Repo)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(snapshot.Data, rd); err != nil {
+		return nil, err
+	}
+
+	versions := make(map[string]string, len(upstreams))
+	value := reflect.ValueOf(rd).Elem()
+	for field, upstream := range upstreams {
+		if version := value.FieldByName(field).String(); version != "" {
+			versions[upstream.name] = version
+		}
+	}
+
+	return versions, nil
+}
+
+// ResolveComponentVersions resolves the component versions of the given
+// milestone the same way as its release notes, without its changelog.
+func (c *Client) ResolveComponentVersions(ctx context.Context, owner, repo, milestone string, fromImages bool) (map[string]string, error) {
+	if _, ok := componentUpstreams[repo]; !ok {
+		return nil, errors.New("no components for repo " + repo + ", expected one of: k3s, rke2")
+	}
+
+	snapshot, err := c.releaseNotesSnapshot(repo, milestone, "", nil)
+	if err != nil {
+		return nil, err
+	}
+	if fromImages {
+		if err := c.fillFromImages(ctx, owner, snapshot); err != nil {
+			return nil, err
+		}
+	}
+
+	return ComponentVersions(snapshot)
+}
@@ -0,0 +1,105 @@
+package release
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"reflect"
+
+	"github.com/rancher/ecm-distro-tools/release/components"
+)
+
+// repoFiles reads the files of a GitHub repo at a ref from
+// raw.githubusercontent.com, fetching each of them once, as the components
+// of the release notes are mostly read from the same few files.
+type repoFiles struct {
+	c       *Client
+	baseURL string
+	files   map[string]fetchedFile
+}
+
+type fetchedFile struct {
+	b   []byte
+	err error
+}
+
+var _ components.Files = (*repoFiles)(nil)
+
+// repoFiles returns the files of the given repo, e.g. k3s-io/k3s, at the
+// given ref.
+func (c *Client) repoFiles(repoName, ref string) *repoFiles {
+	return &repoFiles{
+		c:       c,
+		baseURL: "https://raw.githubusercontent.com/" + repoName + "/" + ref + "/",
+		files:   make(map[string]fetchedFile),
+	}
+}
+
+// fetch returns the content of the file at the given path of the repo.
+func (f *repoFiles) fetch(path string) ([]byte, error) {
+	if file, ok := f.files[path]; ok {
+		return file.b, file.err
+	}
+
+	b, err := f.get(f.baseURL + path)
+	f.files[path] = fetchedFile{b: b, err: err}
+
+	return b, err
+}
+
+func (f *repoFiles) get(url string) ([]byte, error) {
+	resp, err := f.c.HTTP.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New("status error: " + resp.Status + " when fetching " + url)
+	}
+
+	return io.ReadAll(resp.Body)
+}
+
+// Read returns the content of the file at the given path of the repo, or
+// nil if it can't be read.
+func (f *repoFiles) Read(path string) []byte {
+	b, err := f.fetch(path)
+	if err != nil {
+		f.c.Log.Debugf("failed to read %s: %v", path, err)
+		return nil
+	}
+
+	return b
+}
+
+// fillComponents resolves the versions of the components of the given specs
+// from the files of the release and sets them in its notes data. The
+// components the notes data has no field for are listed in its
+// OtherComponents, in the order of the specs.
+func fillComponents(rd releaseNote, files components.Files, specs []components.Spec, k8sVersion string) error {
+	versions, err := components.Resolve(files, specs, k8sVersion)
+	if err != nil {
+		return err
+	}
+
+	v := reflect.ValueOf(rd).Elem()
+	var others []Component
+	for _, spec := range specs {
+		version, ok := versions[spec.Field]
+		if !ok {
+			continue
+		}
+		delete(versions, spec.Field)
+
+		if f := v.FieldByName(spec.Field); f.IsValid() && f.Kind() == reflect.String {
+			f.SetString(version)
+			continue
+		}
+		if version != "" {
+			others = append(others, Component{Name: spec.Field, Version: version})
+		}
+	}
+	v.FieldByName("OtherComponents").Set(reflect.ValueOf(others))
+
+	return nil
+}
@@ -0,0 +1,325 @@
+package components
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/semver"
+	"sigs.k8s.io/yaml"
+)
+
+// Kinds of the sources a component version is read from.
+const (
+	GoMod       = "gomod"
+	Dockerfile  = "dockerfile"
+	ImageList   = "image-list"
+	BuildScript = "build-script"
+	ChartIndex  = "chart-index"
+)
+
+// Default paths of the files read by the sources, relative to the repo root.
+const (
+	defaultDockerfile  = "Dockerfile"
+	defaultBuildScript = "scripts/version.sh"
+	defaultChartIndex  = "charts/chart_versions.yaml"
+)
+
+var (
+	dockerfileRegex  = regexp.MustCompile(`FROM\s+[\w-]+/[\w-]+:(.*?)(-build.*)?\s`)
+	imageListRegex   = regexp.MustCompile(`:(.*)(-build.*)?`)
+	buildScriptRegex = regexp.MustCompile(`(?P<version>v[\d\.]+(-k3s.\w*)?)`)
+)
+
+// Files reads the files of a repo at the ref being released.
+type Files interface {
+	// Read returns the content of the file at the given path of the repo,
+	// or nil if it can't be read.
+	Read(path string) []byte
+}
+
+// ComponentSource reads the version of a component from the files of a
+// repo, returning an empty string if it isn't found.
+type ComponentSource interface {
+	Version(files Files) string
+}
+
+// GoModSource reads the version of the first module of the go.mod file
+// whose path contains Module, e.g. etcd/api/v3, preferring replacements.
+type GoModSource struct {
+	Module string
+}
+
+func (s GoModSource) Version(files Files) string {
+	b := files.Read("go.mod")
+	if b == nil {
+		return ""
+	}
+	modFile, err := modfile.Parse("go.mod", b, nil)
+	if err != nil {
+		return ""
+	}
+
+	return ModuleVersion(modFile, s.Module)
+}
+
+// ModuleVersion returns the version of the first module of the given go.mod
+// file whose path contains the given name, preferring replacements.
+func ModuleVersion(modFile *modfile.File, name string) string {
+	for _, replace := range modFile.Replace {
+		if strings.Contains(replace.Old.Path, name) {
+			return replace.New.Version
+		}
+	}
+	for _, require := range modFile.Require {
+		if strings.Contains(require.Mod.Path, name) {
+			return require.Mod.Version
+		}
+	}
+
+	return ""
+}
+
+// DockerfileSource reads the tag, without its -build suffix, of the first
+// FROM instruction of the Dockerfile at Path referencing Image, e.g.
+// hardened-runc.
+type DockerfileSource struct {
+	Path  string
+	Image string
+}
+
+func (s DockerfileSource) Version(files Files) string {
+	path := s.Path
+	if path == "" {
+		path = defaultDockerfile
+	}
+
+	return findInFile(files.Read(path), dockerfileRegex, s.Image)
+}
+
+// ImageListSource reads the tag, without its -build suffix, of the first
+// image of the image list at Path referencing Image, e.g. the airgap image
+// list of k3s.
+type ImageListSource struct {
+	Path  string
+	Image string
+}
+
+func (s ImageListSource) Version(files Files) string {
+	version := findInFile(files.Read(s.Path), imageListRegex, s.Image)
+	if strings.Contains(version, "-build") {
+		return strings.Split(version, "-")[0]
+	}
+
+	return version
+}
+
+// BuildScriptSource reads the version assigned to Variable, e.g.
+// ETCD_VERSION, by the build script at Path.
+type BuildScriptSource struct {
+	Path     string
+	Variable string
+}
+
+func (s BuildScriptSource) Version(files Files) string {
+	path := s.Path
+	if path == "" {
+		path = defaultBuildScript
+	}
+
+	return findInFile(files.Read(path), buildScriptRegex, s.Variable)
+}
+
+// ChartIndexSource reads the version of Chart, e.g. rke2-cilium, from the
+// chart index at Path listing the charts packaged by the repo.
+type ChartIndexSource struct {
+	Path  string
+	Chart string
+}
+
+func (s ChartIndexSource) Version(files Files) string {
+	path := s.Path
+	if path == "" {
+		path = defaultChartIndex
+	}
+	b := files.Read(path)
+	if b == nil {
+		return ""
+	}
+	charts, err := ParseChartIndex(b)
+	if err != nil {
+		return ""
+	}
+
+	return charts[s.Chart].Version
+}
+
+// Chart is a chart of a chart index.
+type Chart struct {
+	Version   string `json:"version"`
+	Filename  string `json:"filename"`
+	Bootstrap bool   `json:"bootstrap"`
+}
+
+// ParseChartIndex parses the given chart index, e.g. the
+// charts/chart_versions.yaml file of rke2, and returns its charts by name,
+// the base of their file name without extension.
+func ParseChartIndex(b []byte) (map[string]Chart, error) {
+	var index struct {
+		Charts []Chart `json:"charts"`
+	}
+	if err := yaml.Unmarshal(b, &index); err != nil {
+		return nil, err
+	}
+
+	charts := make(map[string]Chart, len(index.Charts))
+	for _, chart := range index.Charts {
+		base := filepath.Base(chart.Filename)
+		charts[strings.TrimSuffix(base, filepath.Ext(base))] = chart
+	}
+
+	return charts, nil
+}
+
+// findInFile returns the first submatch of the given regex in the first
+// line of the given file containing str that matches it.
+func findInFile(b []byte, re *regexp.Regexp, str string) string {
+	scanner := bufio.NewScanner(bytes.NewReader(b))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.Contains(line, str) {
+			continue
+		}
+		if submatch := re.FindStringSubmatch(line); len(submatch) > 1 {
+			return submatch[1]
+		}
+	}
+
+	return ""
+}
+
+// Spec declares where the version of a component of the release notes is
+// read from.
+type Spec struct {
+	// Field is the field of the release notes data set to the version,
+	// e.g. EtcdVersion or, if the notes have no such field, the name the
+	// component is listed with in their component table, e.g. Spegel.
+	Field string `json:"field"`
+	// Source is the kind of the source: gomod, dockerfile, image-list,
+	// build-script or chart-index.
+	Source string `json:"source"`
+	// Name is what the source looks for: the module, image, variable or
+	// chart of the component.
+	Name string `json:"name"`
+	// Path of the file read by the source, relative to the repo root, if
+	// not the default one of its kind. Image lists have no default.
+	Path string `json:"path,omitempty"`
+	// Since and Until restrict the spec to the Kubernetes versions from
+	// Since, included, to Until, excluded, e.g. v1.24.1 and v1.26.5.
+	Since string `json:"since,omitempty"`
+	Until string `json:"until,omitempty"`
+}
+
+// NewSource returns the source of the spec.
+func (s Spec) NewSource() (ComponentSource, error) {
+	switch s.Source {
+	case GoMod:
+		return GoModSource{Module: s.Name}, nil
+	case Dockerfile:
+		return DockerfileSource{Path: s.Path, Image: s.Name}, nil
+	case ImageList:
+		if s.Path == "" {
+			return nil, errors.New("the path of the image list of " + s.Field + " is required")
+		}
+		return ImageListSource{Path: s.Path, Image: s.Name}, nil
+	case BuildScript:
+		return BuildScriptSource{Path: s.Path, Variable: s.Name}, nil
+	case ChartIndex:
+		return ChartIndexSource{Path: s.Path, Chart: s.Name}, nil
+	default:
+		return nil, errors.New("invalid source " + s.Source + " of " + s.Field + ", expected one of: " + GoMod + ", " + Dockerfile + ", " + ImageList + ", " + BuildScript + ", " + ChartIndex)
+	}
+}
+
+// Applies reports whether the spec applies to the given Kubernetes
+// version, e.g. v1.29.2.
+func (s Spec) Applies(k8sVersion string) bool {
+	if s.Since != "" && semver.Compare(k8sVersion, s.Since) < 0 {
+		return false
+	}
+	if s.Until != "" && semver.Compare(k8sVersion, s.Until) >= 0 {
+		return false
+	}
+
+	return true
+}
+
+func (s Spec) validate() error {
+	if s.Field == "" || s.Name == "" {
+		return errors.New("field and name are required")
+	}
+	if _, err := s.NewSource(); err != nil {
+		return err
+	}
+	for _, v := range []string{s.Since, s.Until} {
+		if v != "" && !semver.IsValid(v) {
+			return errors.New("invalid Kubernetes version " + v + " of " + s.Field + ", expected e.g. v1.29.2")
+		}
+	}
+
+	return nil
+}
+
+// Mapping maps the repos, e.g. rke2, to the specs of their components, on
+// top of their DefaultSpecs.
+type Mapping map[string][]Spec
+
+// Validate checks that every spec has a field, a name, a valid source and
+// valid Kubernetes version bounds.
+func (m Mapping) Validate() error {
+	for repo, specs := range m {
+		for _, spec := range specs {
+			if err := spec.validate(); err != nil {
+				return errors.New(repo + ": " + err.Error())
+			}
+		}
+	}
+
+	return nil
+}
+
+// For returns the specs of the given repo: the configured ones, which take
+// precedence, followed by its DefaultSpecs.
+func (m Mapping) For(repo string) []Spec {
+	specs := make([]Spec, 0, len(m[repo])+len(DefaultSpecs[repo]))
+	specs = append(specs, m[repo]...)
+
+	return append(specs, DefaultSpecs[repo]...)
+}
+
+// Resolve returns the versions of the components of the given specs, by
+// field, read from the given files. The first spec of a field that applies
+// to the given Kubernetes version and finds a version wins, so specs can
+// fall back to others, e.g. from a containerd v2 module to the v1 one.
+func Resolve(files Files, specs []Spec, k8sVersion string) (map[string]string, error) {
+	versions := make(map[string]string, len(specs))
+	for _, spec := range specs {
+		source, err := spec.NewSource()
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := versions[spec.Field]; !ok {
+			versions[spec.Field] = ""
+		}
+		if versions[spec.Field] != "" || !spec.Applies(k8sVersion) {
+			continue
+		}
+		versions[spec.Field] = source.Version(files)
+	}
+
+	return versions, nil
+}
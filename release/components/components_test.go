@@ -0,0 +1,120 @@
+package components
+
+import (
+	"reflect"
+	"testing"
+)
+
+type files map[string]string
+
+func (f files) Read(path string) []byte {
+	content, ok := f[path]
+	if !ok {
+		return nil
+	}
+
+	return []byte(content)
+}
+
+func TestResolve(t *testing.T) {
+	repo := files{
+		"go.mod": `module github.com/rancher/rke2
+
+require (
+	github.com/containerd/containerd v1.7.11
+	github.com/k3s-io/helm-controller v0.15.8
+)
+`,
+		"Dockerfile": `FROM rancher/hardened-runc:v1.1.12-build20240201 AS runc
+FROM rancher/hardened-containerd:v1.7.11-k3s2-build20240201 AS containerd
+`,
+		"scripts/version.sh":   `ETCD_VERSION=${ETCD_VERSION:-v3.5.9-k3s1}`,
+		"scripts/build-images": `    ${REGISTRY}/rancher/hardened-calico:v3.27.0-build20240206`,
+		"charts/chart_versions.yaml": `charts:
+  - version: 1.15.100
+    filename: /charts/rke2-cilium.yaml
+    bootstrap: false
+`,
+	}
+	specs := append([]Spec{}, DefaultSpecs["rke2"][:6]...)
+	specs = append(specs,
+		Spec{Field: "CanalCalicoVersion", Source: ImageList, Name: "hardened-calico", Path: "scripts/build-images"},
+		Spec{Field: "CiliumChartVersion", Source: ChartIndex, Name: "rke2-cilium"},
+		Spec{Field: "CanalChartVersion", Source: ChartIndex, Name: "rke2-canal"},
+	)
+
+	tests := []struct {
+		name       string
+		k8sVersion string
+		want       map[string]string
+	}{
+		{
+			name:       "dockerfile",
+			k8sVersion: "v1.29.2",
+			want: map[string]string{
+				"EtcdVersion":          "v3.5.9-k3s1",
+				"ContainerdVersion":    "v1.7.11-k3s2",
+				"RuncVersion":          "v1.1.12",
+				"MetricsServerVersion": "",
+				"CanalCalicoVersion":   "v3.27.0",
+				"CiliumChartVersion":   "1.15.100",
+				"CanalChartVersion":    "",
+			},
+		},
+		{
+			name:       "go.mod on 1.23",
+			k8sVersion: "v1.23.17",
+			want: map[string]string{
+				"EtcdVersion":          "v3.5.9-k3s1",
+				"ContainerdVersion":    "v1.7.11",
+				"RuncVersion":          "v1.1.12",
+				"MetricsServerVersion": "",
+				"CanalCalicoVersion":   "v3.27.0",
+				"CiliumChartVersion":   "1.15.100",
+				"CanalChartVersion":    "",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Resolve(repo, specs, tt.k8sVersion)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for field, version := range tt.want {
+				if got[field] != version {
+					t.Errorf("Resolve()[%s] = %q, want %q", field, got[field], version)
+				}
+			}
+		})
+	}
+}
+
+func TestMappingValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		mapping Mapping
+		wantErr bool
+	}{
+		{name: "defaults", mapping: DefaultSpecs},
+		{name: "invalid source", mapping: Mapping{"k3s": {{Field: "Spegel", Source: "helm", Name: "spegel"}}}, wantErr: true},
+		{name: "image list without path", mapping: Mapping{"k3s": {{Field: "Spegel", Source: ImageList, Name: "spegel"}}}, wantErr: true},
+		{name: "invalid version", mapping: Mapping{"k3s": {{Field: "Spegel", Source: GoMod, Name: "spegel", Since: "1.29"}}}, wantErr: true},
+		{name: "missing name", mapping: Mapping{"k3s": {{Field: "Spegel", Source: GoMod}}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.mapping.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	configured := Mapping{"k3s": {{Field: "Spegel", Source: GoMod, Name: "spegel"}}}
+	if got := configured.For("k3s"); !reflect.DeepEqual(got, append(configured["k3s"], DefaultSpecs["k3s"]...)) {
+		t.Errorf("For(k3s) = %v, want the configured specs followed by the default ones", got)
+	}
+	if got := configured.For("rke2"); !reflect.DeepEqual(got, DefaultSpecs["rke2"]) {
+		t.Errorf("For(rke2) = %v, want the default specs", got)
+	}
+}
@@ -0,0 +1,64 @@
+package components
+
+const (
+	k3sImageList      = "scripts/airgap/image-list.txt"
+	rke2ImageList     = "scripts/build-images"
+	rke2WindowsImage  = "Dockerfile.windows"
+	rke2WindowsAgents = "v1.21.3"
+)
+
+// DefaultSpecs are the specs of the components of the k3s and rke2 release
+// notes, unless configured otherwise.
+var DefaultSpecs = Mapping{
+	"k3s": {
+		{Field: "KineVersion", Source: GoMod, Name: "kine"},
+		{Field: "SQLiteVersion", Source: GoMod, Name: "go-sqlite3"},
+		{Field: "EtcdVersion", Source: GoMod, Name: "etcd/api/v3"},
+		{Field: "ContainerdVersion", Source: BuildScript, Name: "VERSION_CONTAINERD", Since: "v1.24.1", Until: "v1.26.5"},
+		{Field: "ContainerdVersion", Source: GoMod, Name: "containerd/containerd/v2"},
+		{Field: "ContainerdVersion", Source: GoMod, Name: "containerd/containerd"},
+		{Field: "RuncVersion", Source: BuildScript, Name: "VERSION_RUNC", Since: "v1.23.0", Until: "v1.24.0"},
+		{Field: "RuncVersion", Source: GoMod, Name: "runc"},
+		{Field: "FlannelVersion", Source: GoMod, Name: "flannel"},
+		{Field: "MetricsServerVersion", Source: ImageList, Name: "metrics-server", Path: k3sImageList},
+		{Field: "TraefikVersion", Source: ImageList, Name: "traefik", Path: k3sImageList},
+		{Field: "CoreDNSVersion", Source: ImageList, Name: "coredns", Path: k3sImageList},
+		{Field: "HelmControllerVersion", Source: GoMod, Name: "helm-controller"},
+		{Field: "LocalPathProvisionerVersion", Source: ImageList, Name: "local-path-provisioner", Path: k3sImageList},
+	},
+	"rke2": {
+		{Field: "EtcdVersion", Source: BuildScript, Name: "ETCD_VERSION"},
+		{Field: "ContainerdVersion", Source: GoMod, Name: "containerd/containerd/v2", Since: "v1.23.0", Until: "v1.24.0"},
+		{Field: "ContainerdVersion", Source: GoMod, Name: "containerd/containerd", Since: "v1.23.0", Until: "v1.24.0"},
+		{Field: "ContainerdVersion", Source: Dockerfile, Name: "hardened-containerd"},
+		{Field: "RuncVersion", Source: Dockerfile, Name: "hardened-runc"},
+		{Field: "MetricsServerVersion", Source: ImageList, Name: "metrics-server", Path: rke2ImageList},
+		{Field: "CoreDNSVersion", Source: ImageList, Name: "coredns", Path: rke2ImageList},
+		{Field: "IngressNginxVersion", Source: ImageList, Name: "nginx-ingress-controller", Path: rke2ImageList},
+		{Field: "HelmControllerVersion", Source: GoMod, Name: "helm-controller"},
+		{Field: "FlannelVersion", Source: ImageList, Name: "flannel", Path: rke2ImageList},
+		{Field: "CanalCalicoVersion", Source: ImageList, Name: "hardened-calico", Path: rke2ImageList},
+		{Field: "CalicoVersion", Source: ImageList, Name: "calico-node", Path: rke2ImageList},
+		{Field: "CiliumVersion", Source: ImageList, Name: "cilium-cilium", Path: rke2ImageList},
+		{Field: "MultusVersion", Source: ImageList, Name: "multus-cni", Path: rke2ImageList},
+		{Field: "CiliumChartVersion", Source: ChartIndex, Name: "rke2-cilium"},
+		{Field: "CanalChartVersion", Source: ChartIndex, Name: "rke2-canal"},
+		{Field: "CalicoChartVersion", Source: ChartIndex, Name: "rke2-calico"},
+		{Field: "CalicoCRDChartVersion", Source: ChartIndex, Name: "rke2-calico-crd"},
+		{Field: "CoreDNSChartVersion", Source: ChartIndex, Name: "rke2-coredns"},
+		{Field: "IngressNginxChartVersion", Source: ChartIndex, Name: "rke2-ingress-nginx"},
+		{Field: "MetricsServerChartVersion", Source: ChartIndex, Name: "rke2-metrics-server"},
+		{Field: "VsphereCSIChartVersion", Source: ChartIndex, Name: "rancher-vsphere-csi"},
+		{Field: "VsphereCPIChartVersion", Source: ChartIndex, Name: "rancher-vsphere-cpi"},
+		{Field: "HarvesterCloudProviderChartVersion", Source: ChartIndex, Name: "harvester-cloud-provider"},
+		{Field: "HarvesterCSIDriverChartVersion", Source: ChartIndex, Name: "harvester-csi-driver"},
+		{Field: "SnapshotControllerChartVersion", Source: ChartIndex, Name: "rke2-snapshot-controller"},
+		{Field: "SnapshotControllerCRDChartVersion", Source: ChartIndex, Name: "rke2-snapshot-controller-crd"},
+		{Field: "SnapshotValidationWebhookChartVersion", Source: ChartIndex, Name: "rke2-snapshot-validation-webhook"},
+		// the Windows agents are supported since v1.21.3, their components
+		// are pinned by the Dockerfile of the Windows runtime image.
+		{Field: "WindowsContainerdVersion", Source: BuildScript, Name: "CONTAINERD_VERSION", Path: rke2WindowsImage, Since: rke2WindowsAgents},
+		{Field: "WinsVersion", Source: BuildScript, Name: "WINS_VERSION", Path: rke2WindowsImage, Since: rke2WindowsAgents},
+		{Field: "CalicoWindowsVersion", Source: BuildScript, Name: "CALICO_VERSION", Path: rke2WindowsImage, Since: rke2WindowsAgents},
+	},
+}
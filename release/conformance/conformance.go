@@ -0,0 +1,304 @@
+package conformance
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/rancher/ecm-distro-tools/version"
+)
+
+const (
+	e2eLogFile  = "e2e.log"
+	junitFile   = "junit_01.xml"
+	resultsDir  = "plugins/e2e/results/global/"
+	productFile = "PRODUCT.yaml"
+	readmeFile  = "README.md"
+	// checksumSuffix is appended to the name of the archive to name its
+	// sha256sum file.
+	checksumSuffix = ".sha256sum"
+)
+
+// summaryRegex matches the ginkgo summary at the end of the e2e.log, e.g.
+// "Ran 380 of 7407 Specs in 5979.238 seconds".
+var summaryRegex = regexp.MustCompile(`Ran (\d+) of (\d+) Specs`)
+
+// Results contains the conformance test results extracted from a sonobuoy
+// results tarball.
+type Results struct {
+	E2ELog  []byte
+	JUnit   []byte
+	Ran     int
+	Total   int
+	Failed  int
+	Passed  int
+	Skipped int
+}
+
+// Product describes a K3s or RKE2 release in the k8s-conformance repository.
+type Product struct {
+	Vendor           string
+	Name             string
+	Version          string
+	WebsiteURL       string
+	RepoURL          string
+	DocumentationURL string
+	LogoURL          string
+	Description      string
+	ContactEmail     string
+	SetupCommands    string
+}
+
+var products = map[string]Product{
+	version.K3s: {
+		Vendor:           "SUSE",
+		Name:             "k3s",
+		WebsiteURL:       "https://k3s.io",
+		RepoURL:          "https://github.com/k3s-io/k3s",
+		DocumentationURL: "https://docs.k3s.io",
+		LogoURL:          "https://raw.githubusercontent.com/cncf/artwork/main/projects/k3s/icon/color/k3s-icon-color.svg",
+		Description:      "Lightweight Kubernetes. Easy to install, half the memory, all in a binary less than 100 MB.",
+		ContactEmail:     "k3s@suse.com",
+		SetupCommands:    "curl -sfL https://get.k3s.io | INSTALL_K3S_VERSION=%s sh -",
+	},
+	version.RKE2: {
+		Vendor:           "SUSE",
+		Name:             "RKE2",
+		WebsiteURL:       "https://docs.rke2.io",
+		RepoURL:          "https://github.com/rancher/rke2",
+		DocumentationURL: "https://docs.rke2.io",
+		LogoURL:          "https://raw.githubusercontent.com/rancher/rke2/master/docs/assets/logo-horizontal-rke2.svg",
+		Description:      "RKE2, also known as RKE Government, is Rancher's next-generation Kubernetes distribution.",
+		ContactEmail:     "rke2@suse.com",
+		SetupCommands:    "curl -sfL https://get.rke2.io | INSTALL_RKE2_VERSION=%s sh -",
+	},
+}
+
+type junitTestSuite struct {
+	Tests    int `xml:"tests,attr"`
+	Failures int `xml:"failures,attr"`
+	Errors   int `xml:"errors,attr"`
+	Skipped  int `xml:"skipped,attr"`
+}
+
+type junitTestSuites struct {
+	Suites []junitTestSuite `xml:"testsuite"`
+}
+
+// ReadResults extracts the e2e.log and junit_01.xml files from the given
+// sonobuoy results tarball, as written by `sonobuoy retrieve`.
+func ReadResults(r io.Reader) (*Results, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	defer gz.Close()
+
+	var results Results
+
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		name := strings.TrimPrefix(header.Name, "./")
+		switch name {
+		case resultsDir + e2eLogFile:
+			if results.E2ELog, err = io.ReadAll(tr); err != nil {
+				return nil, err
+			}
+		case resultsDir + junitFile:
+			if results.JUnit, err = io.ReadAll(tr); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if results.E2ELog == nil || results.JUnit == nil {
+		return nil, errors.New("results tarball doesn't contain " + resultsDir + e2eLogFile + " and " + resultsDir + junitFile)
+	}
+
+	if err := results.summarize(); err != nil {
+		return nil, err
+	}
+
+	return &results, nil
+}
+
+func (r *Results) summarize() error {
+	m := summaryRegex.FindAllSubmatch(r.E2ELog, -1)
+	if len(m) == 0 {
+		return errors.New("e2e.log doesn't contain a test summary")
+	}
+	last := m[len(m)-1]
+	r.Ran, _ = strconv.Atoi(string(last[1]))
+	r.Total, _ = strconv.Atoi(string(last[2]))
+
+	var suites junitTestSuites
+	if err := xml.Unmarshal(r.JUnit, &suites); err != nil {
+		var suite junitTestSuite
+		if err := xml.Unmarshal(r.JUnit, &suite); err != nil {
+			return errors.New("failed to parse junit results: " + err.Error())
+		}
+		suites.Suites = []junitTestSuite{suite}
+	}
+
+	var tests int
+	for _, s := range suites.Suites {
+		tests += s.Tests
+		r.Failed += s.Failures + s.Errors
+		r.Skipped += s.Skipped
+	}
+	r.Passed = tests - r.Failed - r.Skipped
+
+	return nil
+}
+
+// Verify checks that the results contain no failures and that every
+// conformance test that ran has passed.
+func (r *Results) Verify() error {
+	if r.Ran == 0 {
+		return errors.New("no conformance tests ran")
+	}
+	if r.Failed > 0 {
+		return errors.New(strconv.Itoa(r.Failed) + " conformance tests failed")
+	}
+	if r.Passed != r.Ran {
+		return errors.New("expected " + strconv.Itoa(r.Ran) + " passed tests, got " + strconv.Itoa(r.Passed))
+	}
+
+	return nil
+}
+
+// Archive writes the given sonobuoy results tarball to the output directory
+// along with its sha256sum file, named after the archive so the results of
+// several releases can share the directory, and returns the path of the
+// archive.
+func Archive(tarball []byte, outputDir, tag string) (string, error) {
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return "", err
+	}
+
+	name := "sonobuoy-" + strings.ReplaceAll(tag, "+", "-") + ".tar.gz"
+	path := filepath.Join(outputDir, name)
+	if err := os.WriteFile(path, tarball, 0644); err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(tarball)
+	checksum := hex.EncodeToString(sum[:]) + "  " + name + "\n"
+	if err := os.WriteFile(path+checksumSuffix, []byte(checksum), 0644); err != nil {
+		return "", err
+	}
+
+	return path, nil
+}
+
+// PreparePR writes the files for the k8s-conformance pull request of the
+// given release to the output directory, following the repository layout
+// v1.27/<product>/{PRODUCT.yaml,README.md,e2e.log,junit_01.xml}, and returns
+// the directory the files were written to.
+func PreparePR(results *Results, tag, outputDir string) (string, error) {
+	v, err := version.Parse(tag)
+	if err != nil {
+		return "", err
+	}
+
+	product, ok := products[v.Product]
+	if !ok {
+		return "", errors.New("only k3s and rke2 releases are supported, received " + tag)
+	}
+	product.Version = tag
+	product.SetupCommands = strings.Replace(product.SetupCommands, "%s", tag, 1)
+
+	dir := filepath.Join(outputDir, v.MajorMinor(), strings.ToLower(product.Name))
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+
+	files := map[string][]byte{
+		e2eLogFile: results.E2ELog,
+		junitFile:  results.JUnit,
+	}
+	for name, tmpl := range map[string]string{
+		productFile: productTemplate,
+		readmeFile:  readmeTemplate,
+	} {
+		b, err := render(tmpl, product)
+		if err != nil {
+			return "", err
+		}
+		files[name] = b
+	}
+
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), content, 0644); err != nil {
+			return "", err
+		}
+	}
+
+	return dir, nil
+}
+
+func render(tmpl string, data interface{}) ([]byte, error) {
+	t, err := template.New("conformance").Parse(tmpl)
+	if err != nil {
+		return nil, err
+	}
+
+	b := bytes.NewBuffer(nil)
+	if err := t.Execute(b, data); err != nil {
+		return nil, err
+	}
+
+	return b.Bytes(), nil
+}
+
+const productTemplate = `vendor: {{ .Vendor }}
+name: {{ .Name }}
+version: {{ .Version }}
+website_url: {{ .WebsiteURL }}
+repo_url: {{ .RepoURL }}
+documentation_url: {{ .DocumentationURL }}
+product_logo_url: {{ .LogoURL }}
+type: distribution
+description: "{{ .Description }}"
+contact_email_address: {{ .ContactEmail }}
+`
+
+const readmeTemplate = `# {{ .Name }} {{ .Version }}
+
+## Setup
+
+Install {{ .Name }} on a node and wait for it to be ready:
+
+` + "```sh" + `
+{{ .SetupCommands }}
+` + "```" + `
+
+## Run the conformance tests
+
+` + "```sh" + `
+sonobuoy run --mode=certified-conformance --wait
+outfile=$(sonobuoy retrieve)
+mkdir ./results; tar xzf $outfile -C ./results
+` + "```" + `
+
+The results are found in ` + "`plugins/e2e/results/global/`" + `.
+`
@@ -0,0 +1,144 @@
+package conformance
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rancher/ecm-distro-tools/version"
+)
+
+func resultsTarball(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+
+	b := bytes.NewBuffer(nil)
+	gz := gzip.NewWriter(b)
+	tw := tar.NewWriter(gz)
+	for name, content := range files {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content))}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return b.Bytes()
+}
+
+func TestReadResults(t *testing.T) {
+	tests := []struct {
+		name       string
+		files      map[string]string
+		wantErr    bool
+		wantVerify bool
+	}{
+		{
+			name: "passed",
+			files: map[string]string{
+				resultsDir + e2eLogFile: "Ran 2 of 7000 Specs in 10.5 seconds\nSUCCESS! -- 2 Passed | 0 Failed",
+				resultsDir + junitFile:  `<testsuites><testsuite tests="7000" failures="0" errors="0" skipped="6998"></testsuite></testsuites>`,
+			},
+			wantVerify: true,
+		},
+		{
+			name: "failed",
+			files: map[string]string{
+				resultsDir + e2eLogFile: "Ran 2 of 7000 Specs in 10.5 seconds\nFAIL! -- 1 Passed | 1 Failed",
+				resultsDir + junitFile:  `<testsuites><testsuite tests="7000" failures="1" errors="0" skipped="6998"></testsuite></testsuites>`,
+			},
+		},
+		{
+			name: "missing junit",
+			files: map[string]string{
+				resultsDir + e2eLogFile: "Ran 2 of 7000 Specs in 10.5 seconds",
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, err := ReadResults(bytes.NewReader(resultsTarball(t, tt.files)))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ReadResults() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if err := results.Verify(); (err == nil) != tt.wantVerify {
+				t.Errorf("Verify() error = %v, wantVerify %v", err, tt.wantVerify)
+			}
+		})
+	}
+}
+
+func TestPreparePR(t *testing.T) {
+	results := &Results{E2ELog: []byte("e2e"), JUnit: []byte("junit")}
+	outputDir := t.TempDir()
+
+	dir, err := PreparePR(results, "v1.29.2+rke2r1", outputDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := filepath.Join(outputDir, "v1.29", "rke2"); dir != want {
+		t.Errorf("PreparePR() = %v, want %v", dir, want)
+	}
+
+	for _, name := range []string{productFile, readmeFile, e2eLogFile, junitFile} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Error(err)
+		}
+	}
+
+	product, err := os.ReadFile(filepath.Join(dir, productFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "product_logo_url: " + products[version.RKE2].LogoURL + "\n"; !strings.Contains(string(product), want) {
+		t.Errorf("%s = %s, want it to contain %q", productFile, product, want)
+	}
+
+	if _, err := PreparePR(results, "v1.29.2", outputDir); err == nil {
+		t.Error("PreparePR() expected error for a version without product")
+	}
+}
+
+func TestArchive(t *testing.T) {
+	outputDir := t.TempDir()
+
+	tarballs := map[string]string{
+		"v1.29.2+rke2r1": "rke2",
+		"v1.29.2+k3s1":   "k3s",
+	}
+	for tag, tarball := range tarballs {
+		if _, err := Archive([]byte(tarball), outputDir, tag); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the checksums of both releases are kept, in their own files.
+	tests := map[string]string{
+		"sonobuoy-v1.29.2-rke2r1.tar.gz": "b050f2ef23a1468ca6fa1689d5742f62253e863f05942834002032bc088378e3",
+		"sonobuoy-v1.29.2-k3s1.tar.gz":   "86bd33ebf64e01aaafb7dab419436ac025d04abd933bbbb124ddb25c30b59dd7",
+	}
+	for name, sum := range tests {
+		b, err := os.ReadFile(filepath.Join(outputDir, name+checksumSuffix))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := sum + "  " + name + "\n"; string(b) != want {
+			t.Errorf("%s = %q, want %q", name+checksumSuffix, b, want)
+		}
+	}
+}
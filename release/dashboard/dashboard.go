@@ -27,7 +27,9 @@ func CreateRelease(ctx context.Context, client *github.Client, opts *repository.
 		return errors.New("tag isn't a valid semver: " + opts.Tag)
 	}
 
-	latestPreRelease, err := release.LatestPreRelease(ctx, client, opts.Owner, opts.Repo, opts.Tag, releaseType)
+	releaseClient := release.NewClient(client)
+
+	latestPreRelease, err := releaseClient.LatestPreRelease(ctx, opts.Owner, opts.Repo, opts.Tag, releaseType)
 	if err != nil {
 		return err
 	}
@@ -54,8 +56,8 @@ func CreateRelease(ctx context.Context, client *github.Client, opts *repository.
 	opts.ReleaseNotes = ""
 
 	if !rc {
-		fmt.Printf("release.GenReleaseNotes(ctx, %s, %s, %s, %s, client)", opts.Owner, opts.Repo, opts.Branch, previousTag)
-		buff, err := release.GenReleaseNotes(ctx, opts.Owner, opts.Repo, opts.Branch, previousTag, client)
+		fmt.Printf("GenReleaseNotes(ctx, %s, %s, %s, %s)", opts.Owner, opts.Repo, opts.Branch, previousTag)
+		buff, err := releaseClient.GenReleaseNotes(ctx, opts.Owner, opts.Repo, opts.Branch, previousTag)
 		if err != nil {
 			return err
 		}
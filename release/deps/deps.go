@@ -0,0 +1,283 @@
+package deps
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v39/github"
+	"github.com/rancher/ecm-distro-tools/release/imagebuild"
+	"github.com/rancher/ecm-distro-tools/repository"
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/semver"
+)
+
+// ImageBuild is an image-build repo built from an upstream repo.
+type ImageBuild struct {
+	// Name is the component of the ownership map, e.g. containerd.
+	Name          string
+	Repo          string
+	UpstreamOwner string
+	UpstreamRepo  string
+	TagPrefix     string
+}
+
+// Module is a Go module required by a product whose version should follow
+// the latest release of its upstream repo.
+type Module struct {
+	// Name is the component of the ownership map, e.g. containerd.
+	Name          string
+	Owner         string
+	Repo          string
+	Branch        string
+	Path          string
+	UpstreamOwner string
+	UpstreamRepo  string
+}
+
+// DefaultImageBuilds contains the image-build repos checked by default.
+var DefaultImageBuilds = []ImageBuild{
+	{Name: "etcd", Repo: "image-build-etcd", UpstreamOwner: "etcd-io", UpstreamRepo: "etcd"},
+	{Name: "runc", Repo: "image-build-runc", UpstreamOwner: "opencontainers", UpstreamRepo: "runc"},
+	{Name: "containerd", Repo: "image-build-containerd", UpstreamOwner: "containerd", UpstreamRepo: "containerd"},
+	{Name: "coredns", Repo: "image-build-coredns", UpstreamOwner: "coredns", UpstreamRepo: "coredns"},
+	{Name: "cni-plugins", Repo: "image-build-cni-plugins", UpstreamOwner: "containernetworking", UpstreamRepo: "plugins"},
+	{Name: "flannel", Repo: "image-build-flannel", UpstreamOwner: "flannel-io", UpstreamRepo: "flannel"},
+	{Name: "calico", Repo: "image-build-calico", UpstreamOwner: "projectcalico", UpstreamRepo: "calico"},
+	{Name: "metrics-server", Repo: "image-build-k8s-metrics-server", UpstreamOwner: "kubernetes-sigs", UpstreamRepo: "metrics-server"},
+}
+
+// DefaultModules contains the Go modules checked by default.
+var DefaultModules = []Module{
+	{Name: "containerd", Owner: "k3s-io", Repo: "k3s", Branch: "master", Path: "github.com/containerd/containerd", UpstreamOwner: "containerd", UpstreamRepo: "containerd"},
+	{Name: "runc", Owner: "k3s-io", Repo: "k3s", Branch: "master", Path: "github.com/opencontainers/runc", UpstreamOwner: "opencontainers", UpstreamRepo: "runc"},
+	{Name: "etcd", Owner: "k3s-io", Repo: "k3s", Branch: "master", Path: "go.etcd.io/etcd/server/v3", UpstreamOwner: "etcd-io", UpstreamRepo: "etcd"},
+	{Name: "kine", Owner: "k3s-io", Repo: "k3s", Branch: "master", Path: "github.com/k3s-io/kine", UpstreamOwner: "k3s-io", UpstreamRepo: "kine"},
+	{Name: "helm-controller", Owner: "k3s-io", Repo: "k3s", Branch: "master", Path: "github.com/k3s-io/helm-controller", UpstreamOwner: "k3s-io", UpstreamRepo: "helm-controller"},
+	{Name: "helm-controller", Owner: "rancher", Repo: "rke2", Branch: "master", Path: "github.com/k3s-io/helm-controller", UpstreamOwner: "k3s-io", UpstreamRepo: "helm-controller"},
+}
+
+// Bump is a pending dependency bump.
+type Bump struct {
+	Repo      string
+	Component string
+	Current   string
+	Latest    string
+	// Name is the component of the ownership map, and Owners the mentions
+	// of its owners notified in the report, set with MentionOwners.
+	Name   string
+	Owners []string
+}
+
+// ownersSeparator separates the bump of a report entry from the mentions
+// of its owners, which aren't part of its identity.
+const ownersSeparator = " cc "
+
+// MentionOwners sets the owners of the given bumps from the given ownership
+// map, where teams belong to the given organization.
+func MentionOwners(bumps []Bump, owners repository.Owners, org string) {
+	for i := range bumps {
+		bumps[i].Owners = owners.Reviewers(bumps[i].Name).Mentions(org)
+	}
+}
+
+// String returns the checklist entry of the bump.
+func (b Bump) String() string {
+	return b.Repo + ": bump " + b.Component + " from " + b.Current + " to " + b.Latest
+}
+
+// Check runs the image-build and Go module checks and returns the pending
+// bumps. Failed checks don't stop the remaining ones and are returned as
+// errors along with the bumps.
+func Check(ctx context.Context, client *github.Client, owner string, imageBuilds []ImageBuild, modules []Module) ([]Bump, []error) {
+	var bumps []Bump
+	var errs []error
+
+	for _, ib := range imageBuilds {
+		current, latest, err := imagebuild.Pending(ctx, client, owner, ib.Repo, ib.UpstreamOwner, ib.UpstreamRepo, ib.TagPrefix)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if outdated(current, latest) {
+			bumps = append(bumps, Bump{Repo: owner + "/" + ib.Repo, Component: ib.UpstreamOwner + "/" + ib.UpstreamRepo, Current: current, Latest: latest, Name: ib.Name})
+		}
+	}
+
+	for _, m := range modules {
+		current, err := moduleVersion(ctx, client, m)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		release, _, err := client.Repositories.GetLatestRelease(ctx, m.UpstreamOwner, m.UpstreamRepo)
+		if err != nil {
+			errs = append(errs, repository.WrapGithubError(err, m.UpstreamOwner, m.UpstreamRepo, "latest"))
+			continue
+		}
+		if latest := release.GetTagName(); outdated(current, latest) {
+			bumps = append(bumps, Bump{Repo: m.Owner + "/" + m.Repo, Component: m.Path, Current: current, Latest: latest, Name: m.Name})
+		}
+	}
+
+	return bumps, errs
+}
+
+// outdated returns true if the latest version is newer than the current one,
+// ignoring prerelease suffixes added by forks, e.g. v1.7.11-k3s2.
+func outdated(current, latest string) bool {
+	if current == "" || !semver.IsValid(latest) {
+		return false
+	}
+
+	return semver.Compare(baseVersion(current), baseVersion(latest)) < 0
+}
+
+func baseVersion(v string) string {
+	if !strings.HasPrefix(v, "v") {
+		v = "v" + v
+	}
+	v, _, _ = strings.Cut(v, "-")
+	v, _, _ = strings.Cut(v, "+")
+
+	return v
+}
+
+// moduleVersion returns the version of the given module required by the
+// go.mod file of its repo, taking replacements into account.
+func moduleVersion(ctx context.Context, client *github.Client, m Module) (string, error) {
+	rc, _, err := client.Repositories.DownloadContents(ctx, m.Owner, m.Repo, "go.mod", &github.RepositoryContentGetOptions{Ref: m.Branch})
+	if err != nil {
+		return "", repository.WrapGithubError(err, m.Owner, m.Repo, m.Branch)
+	}
+	defer rc.Close()
+
+	b, err := io.ReadAll(rc)
+	if err != nil {
+		return "", err
+	}
+
+	f, err := modfile.ParseLax("go.mod", b, nil)
+	if err != nil {
+		return "", err
+	}
+
+	for _, r := range f.Replace {
+		if r.Old.Path == m.Path && r.New.Version != "" {
+			return r.New.Version, nil
+		}
+	}
+	for _, r := range f.Require {
+		if r.Mod.Path == m.Path {
+			return r.Mod.Version, nil
+		}
+	}
+
+	return "", errors.New(m.Path + " not found in " + m.Owner + "/" + m.Repo + " go.mod")
+}
+
+// Title returns the title of the report issue for the week of the given
+// date, e.g. Dependency Bumps 2024-W07.
+func Title(date time.Time) string {
+	year, week := date.ISOWeek()
+	w := strconv.Itoa(week)
+	if week < 10 {
+		w = "0" + w
+	}
+
+	return "Dependency Bumps " + strconv.Itoa(year) + "-W" + w
+}
+
+// RenderReport renders the body of the report issue with a checkbox for each
+// bump, mentioning its owners. Bumps already checked in the previous body of
+// the issue stay checked, so updating the report doesn't lose the progress.
+func RenderReport(bumps []Bump, errs []error, previous string) string {
+	checked := checkedEntries(previous)
+
+	entries := make([]string, len(bumps))
+	mentions := make(map[string][]string, len(bumps))
+	for i, bump := range bumps {
+		entries[i] = bump.String()
+		mentions[entries[i]] = bump.Owners
+	}
+	sort.Strings(entries)
+
+	var b strings.Builder
+	b.WriteString("# Pending Dependency Bumps\n\n")
+	if len(entries) == 0 {
+		b.WriteString("All dependencies are up to date.\n")
+	}
+	for _, entry := range entries {
+		box := "[ ]"
+		if checked[entry] {
+			box = "[x]"
+		}
+		line := "- " + box + " " + entry
+		if len(mentions[entry]) > 0 {
+			line += ownersSeparator + strings.Join(mentions[entry], " ")
+		}
+		b.WriteString(line + "\n")
+	}
+
+	if len(errs) > 0 {
+		b.WriteString("\n## Failed Checks\n\n")
+		for _, err := range errs {
+			b.WriteString("- " + err.Error() + "\n")
+		}
+	}
+
+	return b.String()
+}
+
+func checkedEntries(body string) map[string]bool {
+	checked := make(map[string]bool)
+
+	scanner := bufio.NewScanner(strings.NewReader(body))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		for _, prefix := range []string{"- [x] ", "- [X] "} {
+			if entry, ok := strings.CutPrefix(line, prefix); ok {
+				entry, _, _ = strings.Cut(entry, ownersSeparator)
+				checked[entry] = true
+			}
+		}
+	}
+
+	return checked
+}
+
+// FileReport creates the report issue with the given title, or updates its
+// body if it's already open, and returns it.
+func FileReport(ctx context.Context, client *github.Client, owner, repo, title string, bumps []Bump, errs []error) (*github.Issue, error) {
+	issue, err := repository.FindOpenIssue(ctx, client, owner, repo, title)
+	if err != nil {
+		return nil, err
+	}
+
+	if issue == nil {
+		body := RenderReport(bumps, errs, "")
+		issue, _, err := client.Issues.Create(ctx, owner, repo, &github.IssueRequest{
+			Title: github.String(title),
+			Body:  github.String(body),
+		})
+		if err != nil {
+			return nil, repository.WrapGithubError(err, owner, repo, "")
+		}
+		return issue, nil
+	}
+
+	body := RenderReport(bumps, errs, issue.GetBody())
+	issue, _, err = client.Issues.Edit(ctx, owner, repo, issue.GetNumber(), &github.IssueRequest{
+		Body: github.String(body),
+	})
+	if err != nil {
+		return nil, repository.WrapGithubError(err, owner, repo, "")
+	}
+
+	return issue, nil
+}
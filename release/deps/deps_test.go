@@ -0,0 +1,58 @@
+package deps
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/rancher/ecm-distro-tools/repository"
+)
+
+func TestOutdated(t *testing.T) {
+	tests := []struct {
+		current string
+		latest  string
+		want    bool
+	}{
+		{current: "v1.7.11", latest: "v1.7.13", want: true},
+		{current: "v1.7.13-k3s1", latest: "v1.7.13", want: false},
+		{current: "v1.7.11-k3s2", latest: "v1.7.13", want: true},
+		{current: "v3.5.12", latest: "v3.5.9", want: false},
+		{current: "", latest: "v3.5.9", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.current+"->"+tt.latest, func(t *testing.T) {
+			if got := outdated(tt.current, tt.latest); got != tt.want {
+				t.Errorf("outdated(%q, %q) = %v, want %v", tt.current, tt.latest, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTitle(t *testing.T) {
+	if got, want := Title(time.Date(2024, time.February, 15, 0, 0, 0, 0, time.UTC)), "Dependency Bumps 2024-W07"; got != want {
+		t.Errorf("Title() = %q, want %q", got, want)
+	}
+}
+
+func TestRenderReport(t *testing.T) {
+	bumps := []Bump{
+		{Repo: "rancher/image-build-runc", Component: "opencontainers/runc", Current: "v1.1.11", Latest: "v1.1.12", Name: "runc"},
+		{Repo: "rancher/image-build-etcd", Component: "etcd-io/etcd", Current: "v3.5.9", Latest: "v3.5.12", Name: "etcd"},
+	}
+	MentionOwners(bumps, repository.Owners{"etcd": {Users: []string{"alice"}, Teams: []string{"k3s"}}}, "rancher")
+
+	previous := "# Pending Dependency Bumps\n\n" +
+		"- [x] rancher/image-build-etcd: bump etcd-io/etcd from v3.5.9 to v3.5.12 cc @bob\n" +
+		"- [x] rancher/image-build-coredns: bump coredns/coredns from v1.11.0 to v1.11.1\n"
+
+	want := "# Pending Dependency Bumps\n\n" +
+		"- [x] rancher/image-build-etcd: bump etcd-io/etcd from v3.5.9 to v3.5.12 cc @alice @rancher/k3s\n" +
+		"- [ ] rancher/image-build-runc: bump opencontainers/runc from v1.1.11 to v1.1.12\n" +
+		"\n## Failed Checks\n\n" +
+		"- rate limited\n"
+
+	if got := RenderReport(bumps, []error{errors.New("rate limited")}, previous); got != want {
+		t.Errorf("RenderReport() = %q, want %q", got, want)
+	}
+}
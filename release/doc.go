@@ -0,0 +1,8 @@
+// Package release generates release notes and inspects the releases of the
+// products maintained with this repository.
+//
+// Client, ReleaseClient, and the option and result types are a public API:
+// they follow the semantic versioning of this repository's tags and are
+// only broken in major releases. Everything else exported by this package
+// is used by the release CLI and may change in any release.
+package release
@@ -0,0 +1,402 @@
+package dockerfile
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	ecmExec "github.com/rancher/ecm-distro-tools/exec"
+)
+
+// keywords are the instructions of the Dockerfile syntax.
+var keywords = map[string]bool{
+	"ADD":         true,
+	"ARG":         true,
+	"CMD":         true,
+	"COPY":        true,
+	"ENTRYPOINT":  true,
+	"ENV":         true,
+	"EXPOSE":      true,
+	"FROM":        true,
+	"HEALTHCHECK": true,
+	"LABEL":       true,
+	"MAINTAINER":  true,
+	"ONBUILD":     true,
+	"RUN":         true,
+	"SHELL":       true,
+	"STOPSIGNAL":  true,
+	"USER":        true,
+	"VOLUME":      true,
+	"WORKDIR":     true,
+}
+
+var (
+	// directiveRegex matches the parser directives at the top of a
+	// Dockerfile, e.g. # escape=`.
+	directiveRegex = regexp.MustCompile(`^#\s*([a-zA-Z]+)\s*=\s*(\S+)\s*$`)
+	// heredocRegex matches the here-documents of the RUN, COPY and ADD
+	// instructions, e.g. <<EOF or <<-"EOF".
+	heredocRegex = regexp.MustCompile(`<<(-?)["']?([A-Za-z_][A-Za-z0-9_]*)["']?`)
+	// argRegex matches the build arguments used in an instruction, e.g.
+	// ${GO_IMAGE} or $GO_IMAGE.
+	argRegex = regexp.MustCompile(`\$\{(\w+)\}|\$(\w+)`)
+)
+
+// Instruction is an instruction of a Dockerfile, joined across its
+// continuation lines.
+type Instruction struct {
+	// Line and EndLine are the first and last lines of the instruction,
+	// from 1.
+	Line    int
+	EndLine int
+	// Keyword is the instruction in upper case, e.g. FROM.
+	Keyword string
+	Args    string
+}
+
+func lineError(line int, message string) error {
+	return errors.New("line " + strconv.Itoa(line) + ": " + message)
+}
+
+func splitLines(b []byte) []string {
+	s := strings.ReplaceAll(string(b), "\r\n", "\n")
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+// Parse parses the given Dockerfile into its instructions. It returns an
+// error on the first malformed instruction, e.g. an unknown keyword or a
+// FROM without image, as left by an edit gone wrong.
+func Parse(b []byte) ([]Instruction, error) {
+	lines := splitLines(b)
+	escape := `\`
+
+	var instructions []Instruction
+	directives := true
+	for i := 0; i < len(lines); i++ {
+		text := strings.TrimSpace(lines[i])
+		if directives {
+			if m := directiveRegex.FindStringSubmatch(text); m != nil {
+				if strings.EqualFold(m[1], "escape") {
+					if m[2] != `\` && m[2] != "`" {
+						return nil, lineError(i+1, "invalid escape directive "+m[2])
+					}
+					escape = m[2]
+				}
+				continue
+			}
+			directives = false
+		}
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		instruction := Instruction{Line: i + 1}
+		for strings.HasSuffix(text, escape) && i+1 < len(lines) {
+			i++
+			next := strings.TrimSpace(lines[i])
+			// comments and empty lines don't end the instruction.
+			if next == "" || strings.HasPrefix(next, "#") {
+				continue
+			}
+			text = strings.TrimSuffix(text, escape) + " " + next
+		}
+		text = strings.TrimSuffix(text, escape)
+
+		keyword, args := text, ""
+		if end := strings.IndexFunc(text, unicode.IsSpace); end >= 0 {
+			keyword, args = text[:end], strings.TrimSpace(text[end:])
+		}
+		instruction.Keyword = strings.ToUpper(keyword)
+		instruction.Args = args
+
+		if !keywords[instruction.Keyword] {
+			return nil, lineError(instruction.Line, "unknown instruction "+keyword)
+		}
+		if args == "" {
+			return nil, lineError(instruction.Line, instruction.Keyword+" without arguments")
+		}
+		if len(instructions) == 0 && instruction.Keyword != "FROM" && instruction.Keyword != "ARG" {
+			return nil, lineError(instruction.Line, "expected FROM before "+instruction.Keyword)
+		}
+
+		switch instruction.Keyword {
+		case "FROM":
+			if _, _, err := fromArgs(args); err != nil {
+				return nil, lineError(instruction.Line, err.Error())
+			}
+		case "ARG", "ENV", "LABEL":
+			if err := keyValueArgs(instruction.Keyword, args); err != nil {
+				return nil, lineError(instruction.Line, err.Error())
+			}
+		case "RUN", "COPY", "ADD":
+			// the here-documents end at their delimiter, in order.
+			for _, m := range heredocRegex.FindAllStringSubmatch(args, -1) {
+				for {
+					i++
+					if i >= len(lines) {
+						return nil, lineError(instruction.Line, "unterminated here-document "+m[2])
+					}
+					line := lines[i]
+					if m[1] == "-" {
+						line = strings.TrimLeft(line, "\t")
+					}
+					if line == m[2] {
+						break
+					}
+				}
+			}
+		}
+
+		instruction.EndLine = i + 1
+		instructions = append(instructions, instruction)
+	}
+
+	if len(instructions) == 0 {
+		return nil, errors.New("no instructions")
+	}
+
+	return instructions, nil
+}
+
+// fromArgs returns the image and the optional stage name of the given FROM
+// arguments, e.g. --platform=$BUILDPLATFORM golang:1.22 AS builder.
+func fromArgs(args string) (string, string, error) {
+	var fields []string
+	for _, field := range strings.Fields(args) {
+		if !strings.HasPrefix(field, "--") {
+			fields = append(fields, field)
+		}
+	}
+
+	switch {
+	case len(fields) == 1:
+		return fields[0], "", nil
+	case len(fields) == 3 && strings.EqualFold(fields[1], "AS"):
+		return fields[0], fields[2], nil
+	case len(fields) == 0:
+		return "", "", errors.New("FROM without image")
+	}
+
+	return "", "", errors.New("invalid FROM " + args + ", expected an image and an optional AS name")
+}
+
+// keyValueArgs checks the arguments of the ARG, ENV and LABEL instructions,
+// e.g. KEY=value or the legacy ENV KEY value.
+func keyValueArgs(keyword, args string) error {
+	first := strings.Fields(args)[0]
+	key, _, ok := strings.Cut(first, "=")
+	if key == "" {
+		return errors.New(keyword + " without name: " + args)
+	}
+	if !ok && keyword != "ARG" && len(strings.Fields(args)) == 1 {
+		return errors.New(keyword + " " + key + " without value")
+	}
+
+	return nil
+}
+
+// Image is the image a stage of a Dockerfile is built from.
+type Image struct {
+	// Instruction is the FROM instruction of the stage.
+	Instruction Instruction
+	// Ref is the image with the global build arguments expanded.
+	Ref name.Reference
+}
+
+// Images returns the images the stages of the given instructions are built
+// from. The scratch image, the previous stages and the images set from
+// build arguments without default aren't images to pull, so they're
+// skipped. It returns an error if an image isn't a valid reference.
+func Images(instructions []Instruction) ([]Image, error) {
+	args := make(map[string]string)
+	stages := make(map[string]bool)
+
+	var images []Image
+	global := true
+	for _, instruction := range instructions {
+		if instruction.Keyword == "ARG" && global {
+			for _, field := range strings.Fields(instruction.Args) {
+				if arg, value, ok := strings.Cut(field, "="); ok {
+					args[arg] = strings.Trim(value, `"'`)
+				}
+			}
+			continue
+		}
+		if instruction.Keyword != "FROM" {
+			continue
+		}
+		global = false
+
+		image, stage, err := fromArgs(instruction.Args)
+		if err != nil {
+			return nil, lineError(instruction.Line, err.Error())
+		}
+		image = argRegex.ReplaceAllStringFunc(image, func(s string) string {
+			if value, ok := args[strings.Trim(s, "${}")]; ok {
+				return value
+			}
+			return s
+		})
+
+		skip := strings.Contains(image, "$") || strings.EqualFold(image, "scratch") || stages[strings.ToLower(image)]
+		if stage != "" {
+			stages[strings.ToLower(stage)] = true
+		}
+		if skip {
+			continue
+		}
+
+		ref, err := name.ParseReference(image)
+		if err != nil {
+			return nil, lineError(instruction.Line, "invalid image "+image+": "+err.Error())
+		}
+		images = append(images, Image{Instruction: instruction, Ref: ref})
+	}
+
+	return images, nil
+}
+
+// Change is a line replaced by an edit.
+type Change struct {
+	Line   int
+	Before string
+	After  string
+}
+
+// Changes returns the lines replaced from before to after. The edits of the
+// release automation replace lines, e.g. with sed, so it returns an error if
+// lines were added or removed.
+func Changes(before, after []byte) ([]Change, error) {
+	b, a := splitLines(before), splitLines(after)
+	if len(b) != len(a) {
+		return nil, errors.New("the edit changed the number of lines from " + strconv.Itoa(len(b)) + " to " + strconv.Itoa(len(a)) + ", expected lines to be replaced only")
+	}
+
+	var changes []Change
+	for i := range b {
+		if b[i] != a[i] {
+			changes = append(changes, Change{Line: i + 1, Before: b[i], After: a[i]})
+		}
+	}
+
+	return changes, nil
+}
+
+// Options configures the validation of the edit of a Dockerfile.
+type Options struct {
+	// Expected matches the lines the edit is expected to change, both
+	// before and after it, e.g. ENV CATTLE_CLI_VERSION=. Any change is
+	// accepted if empty.
+	Expected []*regexp.Regexp
+	// Resolve, if set, checks that the images of the changed FROM
+	// instructions exist, e.g. registry.Exists.
+	Resolve func(ctx context.Context, ref name.Reference) (bool, error)
+}
+
+func (o Options) expected(line string) bool {
+	if len(o.Expected) == 0 {
+		return true
+	}
+	for _, re := range o.Expected {
+		if re.MatchString(line) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Validate checks the edit of a Dockerfile, from before to after, before
+// it's committed: the edited Dockerfile must parse, only the expected lines
+// must have changed and the images of the changed stages must exist. It
+// returns all the unexpected changes and missing images found.
+func Validate(ctx context.Context, before, after []byte, opts Options) error {
+	instructions, err := Parse(after)
+	if err != nil {
+		return err
+	}
+	images, err := Images(instructions)
+	if err != nil {
+		return err
+	}
+	changes, err := Changes(before, after)
+	if err != nil {
+		return err
+	}
+
+	var problems []string
+	changed := make(map[int]bool)
+	for _, c := range changes {
+		changed[c.Line] = true
+		if !opts.expected(c.Before) || !opts.expected(c.After) {
+			problems = append(problems, "line "+strconv.Itoa(c.Line)+": unexpected change from "+strconv.Quote(c.Before)+" to "+strconv.Quote(c.After))
+		}
+	}
+
+	if opts.Resolve != nil {
+		for _, image := range images {
+			edited := false
+			for line := image.Instruction.Line; line <= image.Instruction.EndLine; line++ {
+				edited = edited || changed[line]
+			}
+			if !edited {
+				continue
+			}
+
+			exists, err := opts.Resolve(ctx, image.Ref)
+			if err != nil {
+				problems = append(problems, "line "+strconv.Itoa(image.Instruction.Line)+": failed to resolve "+image.Ref.String()+": "+err.Error())
+			} else if !exists {
+				problems = append(problems, "line "+strconv.Itoa(image.Instruction.Line)+": image "+image.Ref.String()+" doesn't exist")
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		return errors.New(strings.Join(problems, "; "))
+	}
+
+	return nil
+}
+
+// ValidateEdits validates the edits of the Dockerfiles matching the given
+// patterns, relative to the git working tree at dir, against their HEAD
+// version, e.g. after a bump script and before committing it.
+func ValidateEdits(ctx context.Context, dir string, patterns []string, opts Options) error {
+	for _, pattern := range patterns {
+		files, err := filepath.Glob(filepath.Join(dir, pattern))
+		if err != nil {
+			return err
+		}
+		if len(files) == 0 {
+			return errors.New("no Dockerfile matching " + pattern + " in " + dir)
+		}
+
+		for _, file := range files {
+			rel, err := filepath.Rel(dir, file)
+			if err != nil {
+				return err
+			}
+			before, err := ecmExec.RunCommand(dir, "git", "show", "HEAD:./"+filepath.ToSlash(rel))
+			if err != nil {
+				return errors.New("failed to read " + rel + " at HEAD: " + err.Error())
+			}
+			after, err := os.ReadFile(file)
+			if err != nil {
+				return err
+			}
+
+			if err := Validate(ctx, []byte(before), after, opts); err != nil {
+				return errors.New("invalid edit of " + rel + ": " + err.Error())
+			}
+		}
+	}
+
+	return nil
+}
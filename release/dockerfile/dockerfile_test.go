@@ -0,0 +1,174 @@
+package dockerfile
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/name"
+)
+
+const k3sDockerfile = `ARG GOLANG=golang:1.22.5-alpine3.20
+FROM ${GOLANG} AS infra
+
+ARG http_proxy
+RUN apk -U --no-cache add bash git gcc musl-dev \
+    # needed by the tests
+    docker vim less file curl wget
+
+FROM golang:1.22.5-alpine3.20 AS test
+COPY --from=infra /usr/bin/docker /usr/bin/docker
+ENV SELINUX=true
+RUN <<EOF
+go test ./...
+EOF
+
+FROM test
+CMD ["sh"]
+`
+
+func TestParse(t *testing.T) {
+	instructions, err := Parse([]byte(k3sDockerfile))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []struct {
+		line, end int
+		keyword   string
+	}{
+		{1, 1, "ARG"}, {2, 2, "FROM"}, {4, 4, "ARG"}, {5, 7, "RUN"},
+		{9, 9, "FROM"}, {10, 10, "COPY"}, {11, 11, "ENV"}, {12, 14, "RUN"},
+		{16, 16, "FROM"}, {17, 17, "CMD"},
+	}
+	if len(instructions) != len(want) {
+		t.Fatalf("Parse() returned %d instructions, want %d: %+v", len(instructions), len(want), instructions)
+	}
+	for i, w := range want {
+		got := instructions[i]
+		if got.Line != w.line || got.EndLine != w.end || got.Keyword != w.keyword {
+			t.Errorf("instruction %d = %d-%d %s, want %d-%d %s", i, got.Line, got.EndLine, got.Keyword, w.line, w.end, w.keyword)
+		}
+	}
+	if got := instructions[3].Args; got != "apk -U --no-cache add bash git gcc musl-dev  docker vim less file curl wget" {
+		t.Errorf("continued RUN = %q", got)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		dockerfile string
+		want       string
+	}{
+		{name: "unknown instruction", dockerfile: "FROM alpine\nRUNN true\n", want: "line 2: unknown instruction RUNN"},
+		{name: "no arguments", dockerfile: "FROM alpine\nWORKDIR\n", want: "line 2: WORKDIR without arguments"},
+		{name: "no from", dockerfile: "RUN true\n", want: "line 1: expected FROM before RUN"},
+		{name: "from with trailing words", dockerfile: "FROM golang:1.23.1 alpine3.20 AS builder\n", want: "line 1: invalid FROM"},
+		{name: "env without value", dockerfile: "FROM alpine\nENV CATTLE_UI_VERSION\n", want: "line 2: ENV CATTLE_UI_VERSION without value"},
+		{name: "env without name", dockerfile: "FROM alpine\nENV =2.8.0\n", want: "line 2: ENV without name"},
+		{name: "unterminated heredoc", dockerfile: "FROM alpine\nRUN <<EOF\ntrue\n", want: "line 2: unterminated here-document EOF"},
+		{name: "empty", dockerfile: "# syntax=docker/dockerfile:1\n", want: "no instructions"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(tt.dockerfile))
+			if err == nil || !strings.HasPrefix(err.Error(), tt.want) {
+				t.Errorf("Parse() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestImages(t *testing.T) {
+	instructions, err := Parse([]byte(k3sDockerfile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	images, err := Images(instructions)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the last stage is built from the test stage, not an image.
+	want := []string{"golang:1.22.5-alpine3.20", "golang:1.22.5-alpine3.20"}
+	if len(images) != len(want) {
+		t.Fatalf("Images() returned %d images, want %d", len(images), len(want))
+	}
+	for i, image := range images {
+		if image.Ref.String() != want[i] {
+			t.Errorf("image %d = %s, want %s", i, image.Ref, want[i])
+		}
+	}
+
+	instructions, err = Parse([]byte("FROM golang:1.22:alpine\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Images(instructions); err == nil {
+		t.Error("Images() of an invalid reference didn't return an error")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	resolve := func(ctx context.Context, ref name.Reference) (bool, error) {
+		return ref.String() != "golang:1.23.1-alpine3.20-test", nil
+	}
+	golang := []*regexp.Regexp{regexp.MustCompile(`golang:`)}
+
+	tests := []struct {
+		name     string
+		after    string
+		expected []*regexp.Regexp
+		want     string
+	}{
+		{
+			name:     "bump",
+			after:    strings.ReplaceAll(k3sDockerfile, "golang:1.22.5-", "golang:1.23.1-"),
+			expected: golang,
+		},
+		{
+			name:  "any change",
+			after: strings.ReplaceAll(k3sDockerfile, "SELINUX=true", "SELINUX=false"),
+		},
+		{
+			name:     "unexpected change",
+			after:    strings.ReplaceAll(k3sDockerfile, "SELINUX=true", "SELINUX=false"),
+			expected: golang,
+			want:     `line 11: unexpected change from "ENV SELINUX=true" to "ENV SELINUX=false"`,
+		},
+		{
+			name:     "missing image",
+			after:    strings.ReplaceAll(k3sDockerfile, "golang:1.22.5-alpine3.20 AS test", "golang:1.23.1-alpine3.20-test"),
+			expected: golang,
+			want:     "line 9: image golang:1.23.1-alpine3.20-test doesn't exist",
+		},
+		{
+			name:     "removed line",
+			after:    strings.ReplaceAll(k3sDockerfile, "ENV SELINUX=true\n", ""),
+			expected: golang,
+			want:     "the edit changed the number of lines from 17 to 16",
+		},
+		{
+			name:     "malformed",
+			after:    strings.ReplaceAll(k3sDockerfile, "ENV SELINUX=true", "SELINUX=true"),
+			expected: golang,
+			want:     "line 11: unknown instruction SELINUX=true",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(context.Background(), []byte(k3sDockerfile), []byte(tt.after), Options{Expected: tt.expected, Resolve: resolve})
+			if tt.want == "" {
+				if err != nil {
+					t.Errorf("Validate() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.HasPrefix(err.Error(), tt.want) {
+				t.Errorf("Validate() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,304 @@
+package eol
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/google/go-github/v39/github"
+	"github.com/rancher/ecm-distro-tools/repository"
+	"github.com/rancher/ecm-distro-tools/version"
+	"golang.org/x/mod/semver"
+)
+
+// Link is a migration guidance link of an announcement, e.g. the upgrade
+// docs.
+type Link struct {
+	Title string `json:"title"`
+	URL   string `json:"url"`
+}
+
+// Line is the support of a release line.
+type Line struct {
+	// EOL is the end of life date of the line, YYYY-MM-DD.
+	EOL   string `json:"eol"`
+	Links []Link `json:"links,omitempty"`
+}
+
+func (l Line) date() (time.Time, error) {
+	return time.Parse(time.DateOnly, l.EOL)
+}
+
+// Matrix is the support matrix, mapping the products, e.g. rke2, to their
+// release lines, e.g. v1.27, and their end of life.
+type Matrix map[string]map[string]Line
+
+// Validate checks that the release lines are vMAJOR.MINOR versions with
+// an end of life date and that the links have a URL.
+func (m Matrix) Validate() error {
+	for product, lines := range m {
+		for name, line := range lines {
+			if semver.MajorMinor(name) != name {
+				return errors.New(product + ": invalid release line " + name + ", expected e.g. v1.29")
+			}
+			if _, err := line.date(); err != nil {
+				return errors.New(product + " " + name + ": invalid end of life date " + line.EOL + ", expected YYYY-MM-DD")
+			}
+			for _, link := range line.Links {
+				if link.URL == "" {
+					return errors.New(product + " " + name + ": missing url of link " + link.Title)
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// Due returns the release lines of the given product whose end of life is
+// on or before the given date, oldest first.
+func (m Matrix) Due(product string, at time.Time) []string {
+	return m.lines(product, func(eol time.Time) bool {
+		return !eol.After(at)
+	})
+}
+
+// Supported returns the release lines of the given product still supported
+// after the given date, newest first.
+func (m Matrix) Supported(product string, at time.Time) []string {
+	lines := m.lines(product, func(eol time.Time) bool {
+		return eol.After(at)
+	})
+	for i, j := 0, len(lines)-1; i < j; i, j = i+1, j-1 {
+		lines[i], lines[j] = lines[j], lines[i]
+	}
+
+	return lines
+}
+
+func (m Matrix) lines(product string, match func(time.Time) bool) []string {
+	var lines []string
+	for name, line := range m[product] {
+		if eol, err := line.date(); err == nil && match(eol) {
+			lines = append(lines, name)
+		}
+	}
+	sort.Slice(lines, func(i, j int) bool {
+		return semver.Compare(lines[i], lines[j]) < 0
+	})
+
+	return lines
+}
+
+// Announcement is the content of the end of life announcement of a release
+// line.
+type Announcement struct {
+	// Owner of the product's repository, e.g. rancher.
+	Owner   string
+	Product string
+	Line    string
+	EOLDate time.Time
+	// FinalVersion is the last GA release of the line.
+	FinalVersion string
+	// Supported are the release lines users should migrate to, newest
+	// first, and Latest their latest GA release, if any.
+	Supported []string
+	Latest    map[string]string
+	Links     []Link
+}
+
+// NewAnnouncement creates the end of life announcement of the given release
+// line of the product from the support matrix and the tags of the product's
+// repository.
+func NewAnnouncement(m Matrix, owner, product, line string, tags []string) (*Announcement, error) {
+	l, ok := m[product][line]
+	if !ok {
+		return nil, errors.New("release line " + line + " of " + product + " is not in the support matrix")
+	}
+	eol, err := l.date()
+	if err != nil {
+		return nil, errors.New("invalid end of life date " + l.EOL + " of " + product + " " + line)
+	}
+
+	latest := latestReleases(tags, product)
+	final, ok := latest[line]
+	if !ok {
+		return nil, errors.New("no GA release found for " + product + " " + line)
+	}
+
+	a := Announcement{
+		Owner:        owner,
+		Product:      product,
+		Line:         line,
+		EOLDate:      eol,
+		FinalVersion: final,
+		Supported:    m.Supported(product, eol),
+		Latest:       make(map[string]string),
+		Links:        l.Links,
+	}
+	for _, supported := range a.Supported {
+		if v, ok := latest[supported]; ok {
+			a.Latest[supported] = v
+		}
+	}
+
+	return &a, nil
+}
+
+// latestReleases returns the latest GA release of each release line of the
+// product found in the given tags.
+func latestReleases(tags []string, product string) map[string]string {
+	latest := make(map[string]*version.Version)
+	for _, tag := range tags {
+		v, err := version.Parse(tag)
+		if err != nil || v.Product != product || v.IsRC() {
+			continue
+		}
+		if l, ok := latest[v.MajorMinor()]; !ok || version.Compare(v, l) > 0 {
+			latest[v.MajorMinor()] = v
+		}
+	}
+
+	releases := make(map[string]string, len(latest))
+	for line, v := range latest {
+		releases[line] = v.String()
+	}
+
+	return releases
+}
+
+// Tags returns the names of the tags of the given repository.
+func Tags(ctx context.Context, client *github.Client, owner, repo string) ([]string, error) {
+	tags, err := repository.ListAllTags(ctx, client, owner, repo)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, len(tags))
+	for i, tag := range tags {
+		names[i] = tag.GetName()
+	}
+
+	return names, nil
+}
+
+// Title returns the title of the announcement, also used as the title of
+// its pull request.
+func (a *Announcement) Title() string {
+	return a.Product + " " + a.Line + " end of life"
+}
+
+// Render returns the announcement in Markdown.
+func (a *Announcement) Render() (string, error) {
+	tmpl, err := template.New("eol").Funcs(template.FuncMap{
+		"date": func(t time.Time) string {
+			return t.Format("January 2, 2006")
+		},
+	}).Parse(announcementTemplate)
+	if err != nil {
+		return "", err
+	}
+
+	var b bytes.Buffer
+	if err := tmpl.Execute(&b, a); err != nil {
+		return "", err
+	}
+
+	return b.String(), nil
+}
+
+// PROptions describes the pull request adding an announcement to a docs
+// repository.
+type PROptions struct {
+	Owner string
+	Repo  string
+	// Path of the announcement file in the repository.
+	Path string
+	// Head is the fork, e.g. user, the branch is pushed to. The branch is
+	// created in the docs repository if empty.
+	Head string
+}
+
+// OpenPR opens a pull request adding the given announcement to the docs
+// repository and returns its URL.
+func OpenPR(ctx context.Context, client *github.Client, a *Announcement, opts PROptions) (string, error) {
+	content, err := a.Render()
+	if err != nil {
+		return "", err
+	}
+
+	repo, _, err := client.Repositories.Get(ctx, opts.Owner, opts.Repo)
+	if err != nil {
+		return "", repository.WrapGithubError(err, opts.Owner, opts.Repo, "")
+	}
+	base := repo.GetDefaultBranch()
+
+	headOwner := opts.Owner
+	if opts.Head != "" {
+		headOwner = opts.Head
+	}
+	branch := "eol-" + a.Product + "-" + strings.ReplaceAll(a.Line, ".", "-")
+
+	baseRef, _, err := client.Git.GetRef(ctx, opts.Owner, opts.Repo, "refs/heads/"+base)
+	if err != nil {
+		return "", repository.WrapGithubError(err, opts.Owner, opts.Repo, base)
+	}
+	if _, _, err := client.Git.CreateRef(ctx, headOwner, opts.Repo, &github.Reference{
+		Ref:    github.String("refs/heads/" + branch),
+		Object: &github.GitObject{SHA: baseRef.Object.SHA},
+	}); err != nil {
+		return "", repository.WrapGithubError(err, headOwner, opts.Repo, branch)
+	}
+
+	message := "Announce the " + a.Title()
+	if _, _, err := client.Repositories.CreateFile(ctx, headOwner, opts.Repo, opts.Path, &github.RepositoryContentFileOptions{
+		Message: github.String(message),
+		Content: []byte(content),
+		Branch:  github.String(branch),
+	}); err != nil {
+		return "", repository.WrapGithubError(err, headOwner, opts.Repo, branch)
+	}
+
+	pr, _, err := client.PullRequests.Create(ctx, opts.Owner, opts.Repo, &github.NewPullRequest{
+		Title:               github.String(message),
+		Base:                github.String(base),
+		Head:                github.String(headOwner + ":" + branch),
+		Body:                github.String(a.Product + " " + a.Line + " reaches end of life on " + a.EOLDate.Format(time.DateOnly) + ", its final release is " + a.FinalVersion + "."),
+		MaintainerCanModify: github.Bool(true),
+	})
+	if err != nil {
+		return "", repository.WrapGithubError(err, opts.Owner, opts.Repo, branch)
+	}
+
+	return pr.GetHTMLURL(), nil
+}
+
+const announcementTemplate = `# {{ .Product }} {{ .Line }} end of life
+
+The {{ .Product }} {{ .Line }} release line reaches end of life on {{ date .EOLDate }}. Its final release is [{{ .FinalVersion }}](https://github.com/{{ .Owner }}/{{ .Product }}/releases/tag/{{ .FinalVersion }}), no further patch releases, including security fixes, will be published for it.
+{{- if .Supported }}
+
+## Migration
+
+Clusters running {{ .Line }} should be upgraded to a supported release line:
+
+| Release line | Latest release |
+|---|---|
+{{- range .Supported }}
+| {{ . }} | {{ with index $.Latest . }}{{ . }}{{ else }}-{{ end }} |
+{{- end }}
+
+Kubernetes supports upgrading a single minor version at a time, upgrade through each release line in turn.
+{{- end }}
+{{- if .Links }}
+
+## Resources
+{{ range .Links }}
+* [{{ .Title }}]({{ .URL }})
+{{- end }}
+{{- end }}
+`
@@ -0,0 +1,92 @@
+package eol
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+var matrix = Matrix{
+	"rke2": {
+		"v1.27": {EOL: "2024-06-28", Links: []Link{{Title: "Upgrade guide", URL: "https://docs.rke2.io/upgrade"}}},
+		"v1.28": {EOL: "2024-10-28"},
+		"v1.29": {EOL: "2025-02-28"},
+	},
+}
+
+func TestMatrixValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		matrix  Matrix
+		wantErr bool
+	}{
+		{name: "valid", matrix: matrix},
+		{name: "invalid line", matrix: Matrix{"rke2": {"1.27": {EOL: "2024-06-28"}}}, wantErr: true},
+		{name: "invalid date", matrix: Matrix{"rke2": {"v1.27": {EOL: "06/28/2024"}}}, wantErr: true},
+		{name: "link without url", matrix: Matrix{"rke2": {"v1.27": {EOL: "2024-06-28", Links: []Link{{Title: "docs"}}}}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.matrix.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMatrixDue(t *testing.T) {
+	at := time.Date(2024, time.October, 28, 0, 0, 0, 0, time.UTC)
+
+	if got, want := matrix.Due("rke2", at), []string{"v1.27", "v1.28"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Due() = %v, want %v", got, want)
+	}
+	if got, want := matrix.Supported("rke2", at), []string{"v1.29"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Supported() = %v, want %v", got, want)
+	}
+}
+
+func TestNewAnnouncement(t *testing.T) {
+	tags := []string{
+		"v1.27.15+rke2r1",
+		"v1.27.16+rke2r1",
+		"v1.27.16+rke2r2",
+		"v1.27.17-rc1+rke2r1",
+		"v1.28.14+rke2r1",
+		"v1.29.9+rke2r1",
+		"v1.27.20+k3s1",
+	}
+
+	a, err := NewAnnouncement(matrix, "rancher", "rke2", "v1.27", tags)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.FinalVersion != "v1.27.16+rke2r2" {
+		t.Errorf("FinalVersion = %s, want v1.27.16+rke2r2", a.FinalVersion)
+	}
+	if want := []string{"v1.29", "v1.28"}; !reflect.DeepEqual(a.Supported, want) {
+		t.Errorf("Supported = %v, want %v", a.Supported, want)
+	}
+
+	content, err := a.Render()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"reaches end of life on June 28, 2024",
+		"[v1.27.16+rke2r2](https://github.com/rancher/rke2/releases/tag/v1.27.16+rke2r2)",
+		"| v1.28 | v1.28.14+rke2r1 |",
+		"* [Upgrade guide](https://docs.rke2.io/upgrade)",
+	} {
+		if !strings.Contains(content, want) {
+			t.Errorf("announcement doesn't contain %q:\n%s", want, content)
+		}
+	}
+
+	if _, err := NewAnnouncement(matrix, "rancher", "rke2", "v1.26", tags); err == nil {
+		t.Error("expected an error for a line not in the support matrix")
+	}
+	if _, err := NewAnnouncement(matrix, "rancher", "rke2", "v1.27", nil); err == nil {
+		t.Error("expected an error for a line without GA release")
+	}
+}
@@ -0,0 +1,227 @@
+package failure
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"text/template"
+
+	"github.com/google/go-github/v39/github"
+	"github.com/rancher/ecm-distro-tools/repository"
+)
+
+const (
+	// DefaultOwner and DefaultRepo are where failures are reported unless
+	// configured otherwise.
+	DefaultOwner = "rancher"
+	DefaultRepo  = "ecm-distro-tools"
+
+	// maxLogSize is the amount of the most recent logs kept, which leaves
+	// room for the rest of the report within GitHub's 65536 characters body
+	// limit.
+	maxLogSize = 48 * 1024
+
+	redacted = "[REDACTED]"
+)
+
+// secretPatterns match well known credential formats that may end up in the
+// logs even if they aren't part of the configuration, and their replacements
+// which keep the surrounding context.
+var secretPatterns = []struct {
+	re   *regexp.Regexp
+	repl string
+}{
+	{regexp.MustCompile(`gh[pousr]_[A-Za-z0-9]{36,}`), redacted},
+	{regexp.MustCompile(`github_pat_[A-Za-z0-9_]{22,}`), redacted},
+	{regexp.MustCompile(`(?:AKIA|ASIA)[0-9A-Z]{16}`), redacted},
+	{regexp.MustCompile(`(?i)(authorization:\s*(?:bearer\s+|token\s+|basic\s+)?)[^\s"']+`), "${1}" + redacted},
+	{regexp.MustCompile(`(://[^/\s:@]+:)[^/\s@]+@`), "${1}" + redacted + "@"},
+}
+
+// sensitiveNames are substrings of the flags and environment variables whose
+// values are secrets.
+var sensitiveNames = []string{"token", "secret", "password", "passwd", "key", "credential"}
+
+// Report is an automation failure and the context needed to reproduce it.
+type Report struct {
+	Command string
+	Args    []string
+	Flags   map[string]string
+	Err     error
+	Logs    string
+	RunURL  string
+}
+
+// Title returns the title of the tracking issue of the given command, so
+// repeated failures of the same command are appended to the same issue.
+func Title(command string) string {
+	return "Automation failure: " + command
+}
+
+// Sensitive returns true if the flag or environment variable with the given
+// name holds a secret.
+func Sensitive(name string) bool {
+	name = strings.ToLower(name)
+	for _, s := range sensitiveNames {
+		if strings.Contains(name, s) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Sanitize redacts the given secrets and any well known credential format
+// from s.
+func Sanitize(s string, secrets []string) string {
+	// replace the longest secrets first, so secrets containing others are
+	// fully redacted.
+	sorted := append([]string{}, secrets...)
+	sort.Slice(sorted, func(i, j int) bool { return len(sorted[i]) > len(sorted[j]) })
+	for _, secret := range sorted {
+		if len(secret) < 4 {
+			continue
+		}
+		s = strings.ReplaceAll(s, secret, redacted)
+	}
+	for _, p := range secretPatterns {
+		s = p.re.ReplaceAllString(s, p.repl)
+	}
+
+	return s
+}
+
+// EnvSecrets returns the values of the environment variables with sensitive
+// names.
+func EnvSecrets(environ []string) []string {
+	var secrets []string
+	for _, env := range environ {
+		name, value, ok := strings.Cut(env, "=")
+		if !ok || value == "" || !Sensitive(name) {
+			continue
+		}
+		secrets = append(secrets, value)
+	}
+
+	return secrets
+}
+
+// RunURL returns the URL of the current GitHub Actions run, or an empty
+// string when not running in GitHub Actions.
+func RunURL(getenv func(string) string) string {
+	if getenv("GITHUB_ACTIONS") != "true" || getenv("GITHUB_RUN_ID") == "" {
+		return ""
+	}
+
+	return getenv("GITHUB_SERVER_URL") + "/" + getenv("GITHUB_REPOSITORY") + "/actions/runs/" + getenv("GITHUB_RUN_ID")
+}
+
+const reportTemplate = "" +
+	"`{{ .Command }}{{ range .Args }} {{ . }}{{ end }}` failed" +
+	"{{ if .RunURL }} in [{{ .RunURL }}]({{ .RunURL }}){{ end }}:\n\n" +
+	"```\n{{ .Err }}\n```\n" +
+	"{{ if .Flags }}\n**Flags**\n\n" +
+	"| Flag | Value |\n| ---- | ----- |\n" +
+	"{{ range $name, $value := .Flags }}| `--{{ $name }}` | `{{ $value }}` |\n{{ end }}{{ end }}" +
+	"{{ if .Logs }}\n<details>\n<summary>Logs</summary>\n\n```\n{{ .Logs }}\n```\n\n</details>\n{{ end }}"
+
+var reportTmpl = template.Must(template.New("report").Parse(reportTemplate))
+
+// Body renders the report as markdown, redacting the given secrets from all
+// of its content.
+func Body(r Report, secrets []string) (string, error) {
+	if r.Err == nil {
+		return "", errors.New("report doesn't contain an error")
+	}
+
+	flags := make(map[string]string, len(r.Flags))
+	for name, value := range r.Flags {
+		if Sensitive(name) {
+			value = redacted
+		}
+		flags[name] = value
+	}
+
+	logs := r.Logs
+	if len(logs) > maxLogSize {
+		logs = "...\n" + logs[len(logs)-maxLogSize:]
+	}
+
+	var b strings.Builder
+	if err := reportTmpl.Execute(&b, map[string]interface{}{
+		"Command": r.Command,
+		"Args":    r.Args,
+		"Flags":   flags,
+		"Err":     r.Err.Error(),
+		"Logs":    strings.TrimRight(logs, "\n"),
+		"RunURL":  r.RunURL,
+	}); err != nil {
+		return "", err
+	}
+
+	return Sanitize(b.String(), secrets), nil
+}
+
+// File opens a tracking issue for the failed command, or comments on the
+// open one if the command already failed before, and returns its URL.
+func File(ctx context.Context, client *github.Client, owner, repo string, r Report, secrets []string) (string, error) {
+	body, err := Body(r, secrets)
+	if err != nil {
+		return "", err
+	}
+	title := Title(r.Command)
+
+	issue, err := repository.FindOpenIssue(ctx, client, owner, repo, title)
+	if err != nil {
+		return "", err
+	}
+
+	if issue != nil {
+		comment, _, err := client.Issues.CreateComment(ctx, owner, repo, issue.GetNumber(), &github.IssueComment{Body: &body})
+		if err != nil {
+			return "", repository.WrapGithubError(err, owner, repo, "")
+		}
+		return comment.GetHTMLURL(), nil
+	}
+
+	issue, _, err = client.Issues.Create(ctx, owner, repo, &github.IssueRequest{
+		Title: &title,
+		Body:  &body,
+	})
+	if err != nil {
+		return "", repository.WrapGithubError(err, owner, repo, "")
+	}
+
+	return issue.GetHTMLURL(), nil
+}
+
+// Buffer is an io.Writer that keeps the most recent logs written to it, to
+// be included in failure reports.
+type Buffer struct {
+	mu  sync.Mutex
+	buf []byte
+}
+
+// Write implements io.Writer.
+func (b *Buffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.buf = append(b.buf, p...)
+	if len(b.buf) > maxLogSize {
+		b.buf = append([]byte{}, b.buf[len(b.buf)-maxLogSize:]...)
+	}
+
+	return len(p), nil
+}
+
+// String returns the buffered logs.
+func (b *Buffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return string(b.buf)
+}
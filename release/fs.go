@@ -12,6 +12,7 @@ import (
 	"time"
 
 	"github.com/google/go-github/v39/github"
+	"github.com/rancher/ecm-distro-tools/repository"
 )
 
 // FS implements fs.FS for GitHub release assets
@@ -31,7 +32,7 @@ func NewFS(ctx context.Context, client *github.Client, owner, repo, tag string)
 		return nil, errors.New("invalid tag provided")
 	}
 
-	release, _, err := client.Repositories.GetReleaseByTag(ctx, owner, repo, tag)
+	release, err := repository.GetReleaseByTag(ctx, client, owner, repo, tag)
 	if err != nil {
 		return nil, fmt.Errorf("getting release: %w", err)
 	}
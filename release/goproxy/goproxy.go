@@ -0,0 +1,106 @@
+package goproxy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/rancher/ecm-distro-tools/release/watchdog"
+	"golang.org/x/mod/module"
+)
+
+// DefaultURL is the Go module proxy used by default by the go command.
+const DefaultURL = "https://proxy.golang.org"
+
+// ErrNotFound is returned when the proxy doesn't serve a version yet.
+var ErrNotFound = errors.New("version not found in the go module proxy")
+
+// Info is the metadata of a module version served by the proxy.
+type Info struct {
+	Version string
+	Time    time.Time
+}
+
+// Lookup returns the metadata of the given module version from the proxy at
+// proxyURL. ErrNotFound is returned if the proxy doesn't serve it, e.g.
+// because it hasn't fetched the tag yet.
+func Lookup(ctx context.Context, client *http.Client, proxyURL, modulePath, version string) (*Info, error) {
+	u, err := infoURL(proxyURL, modulePath, version)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	switch res.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound, http.StatusGone:
+		return nil, ErrNotFound
+	default:
+		return nil, errors.New("unexpected response from " + u + ": " + res.Status)
+	}
+
+	var info Info
+	if err := json.NewDecoder(res.Body).Decode(&info); err != nil {
+		return nil, errors.New("failed to decode " + u + ": " + err.Error())
+	}
+
+	return &info, nil
+}
+
+// infoURL returns the URL of the info file of the given module version, with
+// the path and version escaped as the proxy protocol requires, e.g.
+// https://proxy.golang.org/github.com/k3s-io/kine/@v/v0.11.4.info.
+func infoURL(proxyURL, modulePath, version string) (string, error) {
+	escapedPath, err := module.EscapePath(modulePath)
+	if err != nil {
+		return "", err
+	}
+	escapedVersion, err := module.EscapeVersion(version)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSuffix(proxyURL, "/") + "/" + escapedPath + "/@v/" + escapedVersion + ".info", nil
+}
+
+// WaitForVersion polls the proxy until it serves the given module version.
+// Requesting a version makes the proxy fetch it from its origin, so this
+// also triggers the indexing of new tags. The context controls how long to
+// wait for.
+func WaitForVersion(ctx context.Context, client *http.Client, proxyURL, modulePath, version string, interval time.Duration) (*Info, error) {
+	ctx, wait := watchdog.Default.Track(ctx, "the go module proxy to serve "+modulePath+"@"+version)
+	defer wait.Done()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	lastErr := ErrNotFound
+	for {
+		info, err := Lookup(ctx, client, proxyURL, modulePath, version)
+		if err == nil {
+			return info, nil
+		}
+		if ctx.Err() == nil {
+			lastErr = err
+			wait.Progress(err.Error())
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, errors.New("timed out waiting for the go module proxy to serve " + modulePath + "@" + version + ": " + lastErr.Error() + ": " + context.Cause(ctx).Error())
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package goproxy
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWaitForVersion(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/github.com/k3s-io/kine/@v/v0.11.4.info" || requests.Add(1) <= 2 {
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`{"Version":"v0.11.4","Time":"2024-02-01T10:00:00Z"}`))
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	info, err := WaitForVersion(ctx, server.Client(), server.URL, "github.com/k3s-io/kine", "v0.11.4", time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Version != "v0.11.4" {
+		t.Errorf("expected version v0.11.4, got %s", info.Version)
+	}
+	if got := requests.Load(); got != 3 {
+		t.Errorf("expected 3 requests, got %d", got)
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	if _, err := WaitForVersion(ctx, server.Client(), server.URL, "github.com/k3s-io/kine", "v0.11.5", time.Millisecond); err == nil {
+		t.Error("expected a timeout error")
+	}
+}
+
+func TestInfoURL(t *testing.T) {
+	got, err := infoURL("https://proxy.golang.org/", "github.com/BurntSushi/toml", "v1.3.2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "https://proxy.golang.org/github.com/!burnt!sushi/toml/@v/v1.3.2.info"; got != want {
+		t.Errorf("infoURL() = %q, want %q", got, want)
+	}
+}
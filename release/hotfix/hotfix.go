@@ -0,0 +1,317 @@
+package hotfix
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"text/template"
+
+	"github.com/google/go-github/v39/github"
+	ecmExec "github.com/rancher/ecm-distro-tools/exec"
+	"github.com/rancher/ecm-distro-tools/release"
+	"github.com/rancher/ecm-distro-tools/repository"
+	"github.com/rancher/ecm-distro-tools/version"
+)
+
+// Plan is a condensed release flow for emergency CVE fixes. The hotfix is
+// branched from the last GA tag of the release line, the given commits are
+// cherry-picked on top of it, and a single rc is verified before cutting the
+// next revision as GA.
+type Plan struct {
+	Owner   string
+	Repo    string
+	Product string
+	// Base is the last GA tag of the release line.
+	Base string
+	// Branch is the hotfix branch created from Base.
+	Branch string
+	// LatestRC is the latest rc of the hotfix, if one has been cut already.
+	LatestRC string
+	// RCCommit is the commit of LatestRC, set once it's verified, which
+	// the GA release is tagged from.
+	RCCommit string
+	// RC is the next rc to cut.
+	RC string
+	// GA is the hotfix release.
+	GA      string
+	Commits []string
+	CVEs    []string
+}
+
+// NewPlan creates the hotfix plan for the given release line, e.g. v1.29,
+// by inspecting the existing tags of the product's repository.
+func NewPlan(ctx context.Context, client *github.Client, owner, product, line string, commits, cves []string) (*Plan, error) {
+	tags, err := repository.ListAllTags(ctx, client, owner, product)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, len(tags))
+	for i, tag := range tags {
+		names[i] = tag.GetName()
+	}
+
+	plan, err := planVersions(names, product, line)
+	if err != nil {
+		return nil, err
+	}
+	plan.Owner = owner
+	plan.Repo = product
+	plan.Commits = commits
+	plan.CVEs = cves
+
+	return plan, nil
+}
+
+// planVersions returns a plan with the base, rc and GA versions of a hotfix
+// for the given release line.
+func planVersions(tags []string, product, line string) (*Plan, error) {
+	var base, latestRC *version.Version
+	for _, tag := range tags {
+		v, err := version.Parse(tag)
+		if err != nil || v.Product != product || v.MajorMinor() != line {
+			continue
+		}
+		if v.IsRC() {
+			if latestRC == nil || version.Compare(v, latestRC) > 0 {
+				latestRC = v
+			}
+			continue
+		}
+		if base == nil || version.Compare(v, base) > 0 {
+			base = v
+		}
+	}
+	if base == nil {
+		return nil, errors.New("no GA release found for " + product + " " + line)
+	}
+
+	ga := base.NextRevision()
+	plan := &Plan{
+		Product: product,
+		Base:    base.String(),
+		Branch:  "hotfix/" + ga.String(),
+		GA:      ga.String(),
+		RC:      ga.NextRC().String(),
+	}
+	// candidates of older releases belong to a previous cycle
+	if latestRC != nil && version.Compare(latestRC.GA(), ga) == 0 {
+		plan.LatestRC = latestRC.String()
+		plan.RC = latestRC.NextRC().String()
+	}
+
+	return plan, nil
+}
+
+// CreateBranch creates the hotfix branch from the base tag, unless it
+// already exists.
+func (p *Plan) CreateBranch(ctx context.Context, client *github.Client) error {
+	if _, _, err := client.Git.GetRef(ctx, p.Owner, p.Repo, "heads/"+p.Branch); err == nil {
+		return nil
+	}
+
+	sha, err := p.tagCommit(ctx, client, p.Base)
+	if err != nil {
+		return err
+	}
+
+	_, _, err = client.Git.CreateRef(ctx, p.Owner, p.Repo, &github.Reference{
+		Ref:    github.String("refs/heads/" + p.Branch),
+		Object: &github.GitObject{SHA: github.String(sha)},
+	})
+
+	return repository.WrapGithubError(err, p.Owner, p.Repo, p.Branch)
+}
+
+// tagCommit returns the commit the given tag points to.
+func (p *Plan) tagCommit(ctx context.Context, client *github.Client, tag string) (string, error) {
+	ref, _, err := client.Git.GetRef(ctx, p.Owner, p.Repo, "tags/"+tag)
+	if err != nil {
+		return "", repository.WrapGithubError(err, p.Owner, p.Repo, tag)
+	}
+
+	sha := ref.GetObject().GetSHA()
+	// annotated tags point to a tag object instead of a commit
+	if ref.GetObject().GetType() == "tag" {
+		t, _, err := client.Git.GetTag(ctx, p.Owner, p.Repo, sha)
+		if err != nil {
+			return "", repository.WrapGithubError(err, p.Owner, p.Repo, tag)
+		}
+		sha = t.GetObject().GetSHA()
+	}
+
+	return sha, nil
+}
+
+// CherryPick clones the hotfix branch from the given remote URL into the
+// workspace with the given clone options, cherry-picks the plan's commits
+// and pushes the branch back. With dryRun, the branch doesn't exist yet, so
+// the cherry-picks are only described.
+func (p *Plan) CherryPick(workspace, remoteURL string, clone repository.CloneOptions, dryRun bool) (string, error) {
+	if len(p.Commits) == 0 {
+		return "", errors.New("no commits to cherry-pick")
+	}
+
+	if dryRun {
+		return p.cherryPickPlan(remoteURL), nil
+	}
+
+	vars := struct {
+		*Plan
+		RemoteURL string
+		Clone     repository.CloneOptions
+	}{p, remoteURL, clone}
+
+	return ecmExec.RunTemplatedScript(workspace, "cherry_pick_hotfix.sh", cherryPickScript, nil, vars)
+}
+
+// cherryPickPlan describes the cherry-picks of the plan, e.g. for a dry run.
+func (p *Plan) cherryPickPlan(remoteURL string) string {
+	var b strings.Builder
+	b.WriteString("would cherry-pick onto " + p.Branch + " of " + remoteURL + ":\n")
+	for _, commit := range p.Commits {
+		b.WriteString("  " + commit + "\n")
+	}
+	b.WriteString("would push " + p.Branch)
+
+	return b.String()
+}
+
+// TagRC creates the next rc pre-release from the hotfix branch.
+func (p *Plan) TagRC(ctx context.Context, client *github.Client) error {
+	_, err := repository.CreateRelease(ctx, client, &repository.CreateReleaseOpts{
+		Owner:      p.Owner,
+		Repo:       p.Repo,
+		Name:       p.RC,
+		Tag:        p.RC,
+		Branch:     p.Branch,
+		Prerelease: true,
+	})
+
+	return repository.WrapGithubError(err, p.Owner, p.Repo, p.RC)
+}
+
+// Verify runs the abbreviated hotfix verification: an rc has to exist and
+// all of its assets have to be uploaded. It records the commit of the rc,
+// which the GA release is tagged from.
+func (p *Plan) Verify(ctx context.Context, client *github.Client) error {
+	if p.LatestRC == "" {
+		return errors.New("no rc found for " + p.GA + ", cut one before the GA release")
+	}
+
+	verified, err := release.NewClient(client).VerifyAssets(ctx, release.VerifyAssetsOptions{Owner: p.Owner, Repo: p.Repo, Tags: []string{p.LatestRC}})
+	if err != nil {
+		return err
+	}
+	if !verified.Complete[p.LatestRC] {
+		return errors.New(p.LatestRC + " is missing assets, verify its build has finished")
+	}
+
+	sha, err := p.tagCommit(ctx, client, p.LatestRC)
+	if err != nil {
+		return err
+	}
+	p.RCCommit = sha
+
+	return nil
+}
+
+// TagGA creates the hotfix GA release with the given notes from the commit
+// of the verified rc, so commits pushed to the branch since aren't
+// released unverified.
+func (p *Plan) TagGA(ctx context.Context, client *github.Client, notes string) error {
+	if p.RCCommit == "" {
+		return errors.New("the commit of the rc of " + p.GA + " is unknown, verify the rc first")
+	}
+
+	rr := &github.RepositoryRelease{
+		Name:            github.String(p.GA),
+		TagName:         github.String(p.GA),
+		TargetCommitish: github.String(p.RCCommit),
+		Body:            github.String(notes),
+	}
+	_, _, err := client.Repositories.CreateRelease(ctx, p.Owner, p.Repo, rr)
+
+	return repository.WrapGithubError(err, p.Owner, p.Repo, p.GA)
+}
+
+// Commit is a cherry-picked commit listed in the security notes.
+type Commit struct {
+	SHA     string
+	Message string
+}
+
+// SecurityNotes generates the notes of the hotfix, listing the addressed
+// CVEs and the cherry-picked commits.
+func (p *Plan) SecurityNotes(ctx context.Context, client *github.Client) (*bytes.Buffer, error) {
+	commits := make([]Commit, 0, len(p.Commits))
+	for _, sha := range p.Commits {
+		c, _, err := client.Repositories.GetCommit(ctx, p.Owner, p.Repo, sha, &github.ListOptions{})
+		if err != nil {
+			return nil, repository.WrapGithubError(err, p.Owner, p.Repo, sha)
+		}
+		commits = append(commits, Commit{
+			SHA:     c.GetSHA(),
+			Message: strings.SplitN(c.GetCommit().GetMessage(), "\n", 2)[0],
+		})
+	}
+
+	return renderSecurityNotes(p, commits)
+}
+
+func renderSecurityNotes(p *Plan, commits []Commit) (*bytes.Buffer, error) {
+	funcMap := template.FuncMap{
+		"short": func(sha string) string {
+			if len(sha) > 7 {
+				return sha[:7]
+			}
+			return sha
+		},
+	}
+	tmpl, err := template.New("security-notes").Funcs(funcMap).Parse(securityNotesTemplate)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, struct {
+		*Plan
+		CommitList []Commit
+	}{p, commits}); err != nil {
+		return nil, err
+	}
+
+	return &buf, nil
+}
+
+const cherryPickScript = `#!/bin/sh
+set -ex
+
+DIR="{{ .Repo }}-{{ .GA }}"
+rm -rf "${DIR}"
+git clone {{ .Clone.Args }} --branch "{{ .Branch }}" "{{ .RemoteURL }}" "${DIR}"
+cd "${DIR}"
+{{ .Clone.SparseCheckout }}
+{{ range .Commits }}
+git fetch origin "{{ . }}"
+git cherry-pick -x "{{ . }}"
+{{- end }}
+
+git push origin "{{ .Branch }}"
+`
+
+const securityNotesTemplate = `<!-- {{ .GA }} -->
+
+This is a security release of {{ .Product }} based on {{ .Base }}. Upgrading is strongly recommended.
+
+## Security Fixes
+{{ range .CVEs }}
+* [{{ . }}](https://nvd.nist.gov/vuln/detail/{{ . }})
+{{- end }}
+
+## Changes since {{ .Base }}:
+{{ range .CommitList }}
+* {{ .Message }} ({{ short .SHA }})
+{{- end }}
+`
@@ -0,0 +1,163 @@
+package hotfix
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v39/github"
+	"github.com/rancher/ecm-distro-tools/repository"
+)
+
+func TestPlanVersions(t *testing.T) {
+	tests := []struct {
+		name    string
+		tags    []string
+		product string
+		line    string
+		want    Plan
+		wantErr bool
+	}{
+		{
+			name:    "first rc",
+			tags:    []string{"v1.29.2+rke2r1", "v1.29.3+rke2r1", "v1.29.3-rc1+rke2r1", "v1.30.0+rke2r1"},
+			product: "rke2",
+			line:    "v1.29",
+			want: Plan{
+				Product: "rke2",
+				Base:    "v1.29.3+rke2r1",
+				Branch:  "hotfix/v1.29.3+rke2r2",
+				RC:      "v1.29.3-rc1+rke2r2",
+				GA:      "v1.29.3+rke2r2",
+			},
+		},
+		{
+			name:    "rc already cut",
+			tags:    []string{"v1.28.8+k3s1", "v1.28.8-rc1+k3s2"},
+			product: "k3s",
+			line:    "v1.28",
+			want: Plan{
+				Product:  "k3s",
+				Base:     "v1.28.8+k3s1",
+				Branch:   "hotfix/v1.28.8+k3s2",
+				LatestRC: "v1.28.8-rc1+k3s2",
+				RC:       "v1.28.8-rc2+k3s2",
+				GA:       "v1.28.8+k3s2",
+			},
+		},
+		{
+			name:    "no GA release",
+			tags:    []string{"v1.31.0-rc1+rke2r1"},
+			product: "rke2",
+			line:    "v1.31",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := planVersions(tt.tags, tt.product, tt.line)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("planVersions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got.Product != tt.want.Product || got.Base != tt.want.Base || got.Branch != tt.want.Branch ||
+				got.LatestRC != tt.want.LatestRC || got.RC != tt.want.RC || got.GA != tt.want.GA {
+				t.Errorf("planVersions() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderSecurityNotes(t *testing.T) {
+	p := &Plan{
+		Product: "rke2",
+		Base:    "v1.29.3+rke2r1",
+		GA:      "v1.29.3+rke2r2",
+		CVEs:    []string{"CVE-2024-0001"},
+	}
+	commits := []Commit{{SHA: "0123456789abcdef", Message: "Bump containerd"}}
+
+	got, err := renderSecurityNotes(p, commits)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `<!-- v1.29.3+rke2r2 -->
+
+This is a security release of rke2 based on v1.29.3+rke2r1. Upgrading is strongly recommended.
+
+## Security Fixes
+
+* [CVE-2024-0001](https://nvd.nist.gov/vuln/detail/CVE-2024-0001)
+
+## Changes since v1.29.3+rke2r1:
+
+* Bump containerd (0123456)
+`
+	if got.String() != want {
+		t.Errorf("renderSecurityNotes() = %q, want %q", got.String(), want)
+	}
+}
+
+func TestCherryPickDryRun(t *testing.T) {
+	p := &Plan{Owner: "rancher", Repo: "rke2", GA: "v1.29.3+rke2r2", Branch: "hotfix/v1.29.3+rke2r2", Commits: []string{"3f2a1b0", "9c8d7e6"}}
+
+	// the workspace doesn't exist: a dry run mustn't run the script.
+	got, err := p.CherryPick("/nonexistent", "git@github.com:sandbox/rke2.git", repository.CloneOptions{}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"hotfix/v1.29.3+rke2r2 of git@github.com:sandbox/rke2.git", "  3f2a1b0\n", "  9c8d7e6\n"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("CherryPick() = %q, want it to contain %q", got, want)
+		}
+	}
+}
+
+func TestTagGA(t *testing.T) {
+	var target string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/rancher/rke2/git/ref/tags/v1.29.3-rc1+rke2r2", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"object": {"type": "tag", "sha": "tagsha"}}`)
+	})
+	mux.HandleFunc("/repos/rancher/rke2/git/tags/tagsha", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"object": {"type": "commit", "sha": "rcsha"}}`)
+	})
+	mux.HandleFunc("/repos/rancher/rke2/releases", func(w http.ResponseWriter, r *http.Request) {
+		var rr github.RepositoryRelease
+		if err := json.NewDecoder(r.Body).Decode(&rr); err != nil {
+			t.Error(err)
+		}
+		target = rr.GetTargetCommitish()
+		fmt.Fprint(w, `{}`)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := github.NewClient(nil)
+	client.BaseURL, _ = url.Parse(server.URL + "/")
+
+	p := &Plan{Owner: "rancher", Repo: "rke2", GA: "v1.29.3+rke2r2", Branch: "hotfix/v1.29.3+rke2r2", LatestRC: "v1.29.3-rc1+rke2r2"}
+	if err := p.TagGA(context.Background(), client, "notes"); err == nil {
+		t.Error("TagGA() before verifying the rc succeeded, want an error")
+	}
+
+	sha, err := p.tagCommit(context.Background(), client, p.LatestRC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.RCCommit = sha
+	if err := p.TagGA(context.Background(), client, "notes"); err != nil {
+		t.Fatal(err)
+	}
+	if target != "rcsha" {
+		t.Errorf("TagGA() target = %q, want the rc commit rcsha", target)
+	}
+}
@@ -0,0 +1,236 @@
+package release
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/rancher/ecm-distro-tools/registry"
+	"github.com/sirupsen/logrus"
+)
+
+// imageComponent maps a field of the release notes data to the built image
+// it's read from, or to a package of the SBOM of the runtime image.
+type imageComponent struct {
+	field string
+	// image is the name of the image in the images list, without its
+	// registry and org, e.g. hardened-etcd.
+	image string
+	// pkg is the suffix of the path of the package in the runtime image
+	// SBOM, e.g. opencontainers/runc.
+	pkg string
+}
+
+// imageListAssets are the release assets listing the images of a release.
+var imageListAssets = map[string]string{
+	k3sRepo:  "k3s-images.txt",
+	rke2Repo: "rke2-images-all.linux-amd64.txt",
+}
+
+// runtimeImages are the images containing the product binaries, whose SBOM
+// lists the versions of the components built into them.
+var runtimeImages = map[string]string{
+	k3sRepo:  "docker.io/rancher/k3s",
+	rke2Repo: "docker.io/rancher/rke2-runtime",
+}
+
+var imageComponents = map[string][]imageComponent{
+	k3sRepo: {
+		{field: "CoreDNSVersion", image: "mirrored-coredns-coredns"},
+		{field: "MetricsServerVersion", image: "mirrored-metrics-server"},
+		{field: "TraefikVersion", image: "mirrored-library-traefik"},
+		{field: "LocalPathProvisionerVersion", image: "local-path-provisioner"},
+		{field: "ContainerdVersion", pkg: "containerd/containerd"},
+		{field: "RuncVersion", pkg: "opencontainers/runc"},
+		{field: "FlannelVersion", pkg: "flannel-io/flannel"},
+		{field: "KineVersion", pkg: "k3s-io/kine"},
+		{field: "EtcdVersion", pkg: "etcd/api/v3"},
+	},
+	rke2Repo: {
+		{field: "EtcdVersion", image: "hardened-etcd"},
+		{field: "CoreDNSVersion", image: "hardened-coredns"},
+		{field: "CanalCalicoVersion", image: "hardened-calico"},
+		{field: "CiliumVersion", image: "mirrored-cilium-cilium"},
+		{field: "IngressNginxVersion", image: "nginx-ingress-controller"},
+		{field: "MetricsServerVersion", image: "hardened-k8s-metrics-server"},
+		{field: "FlannelVersion", image: "hardened-flannel"},
+		{field: "MultusVersion", image: "hardened-multus-cni"},
+		{field: "CalicoVersion", image: "mirrored-calico-node"},
+		{field: "ContainerdVersion", pkg: "containerd/containerd"},
+		{field: "RuncVersion", pkg: "opencontainers/runc"},
+	},
+}
+
+// fillFromImages replaces the component versions of the given snapshot with
+// the ones of the images built for the release: the version label of the
+// images in its images list asset and the packages in the SBOM of its
+// runtime image. Versions that can't be read from the images keep their
+// scraped values, e.g. before the release is built.
+func (c *Client) fillFromImages(ctx context.Context, owner string, snapshot *ReleaseNotesSnapshot) error {
+	components, ok := imageComponents[snapshot.Repo]
+	if !ok {
+		return errors.New("component versions can only be read from the images of k3s and rke2 releases")
+	}
+
+	rd, err := newReleaseNote(snapshot.Repo)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(snapshot.Data, rd); err != nil {
+		return err
+	}
+
+	images, err := c.releaseImages(ctx, owner, snapshot.Repo, snapshot.Milestone)
+	if err != nil {
+		c.Log.Warnf("keeping scraped component versions, failed to read the images list of %s: %v", snapshot.Milestone, err)
+		return nil
+	}
+
+	runtime, err := name.NewTag(runtimeImages[snapshot.Repo] + ":" + strings.ReplaceAll(snapshot.Milestone, "+", "-"))
+	if err != nil {
+		return err
+	}
+	var packages []registry.Package
+	if metadata, err := c.ImageMetadata(ctx, runtime); err != nil {
+		c.Log.Warnf("keeping scraped versions of the runtime components, failed to read %s: %v", runtime, err)
+	} else {
+		packages = metadata.Packages
+	}
+
+	versions := make(map[string]string, len(components))
+	for _, component := range components {
+		if component.pkg != "" {
+			versions[component.field] = packageVersion(packages, component.pkg)
+			continue
+		}
+
+		ref, ok := images[component.image]
+		if !ok {
+			continue
+		}
+		metadata, err := c.ImageMetadata(ctx, ref)
+		if err != nil {
+			c.Log.Warnf("failed to read %s, using its tag: %v", ref, err)
+		}
+		versions[component.field] = imageVersion(ref.TagStr(), metadata.Labels)
+	}
+
+	setComponentVersions(c.Log, rd, versions)
+
+	data, err := json.Marshal(rd)
+	if err != nil {
+		return err
+	}
+	snapshot.Data = data
+
+	return nil
+}
+
+// setComponentVersions sets the given fields of the release notes data,
+// skipping empty versions.
+func setComponentVersions(log logrus.FieldLogger, rd releaseNote, versions map[string]string) {
+	v := reflect.ValueOf(rd).Elem()
+	for field, version := range versions {
+		if version == "" {
+			continue
+		}
+		f := v.FieldByName(field)
+		if !f.IsValid() || f.Kind() != reflect.String {
+			continue
+		}
+		if scraped := f.String(); scraped != version {
+			log.Infof("%s: using %s from the built images instead of %s", field, version, scraped)
+		}
+		f.SetString(version)
+	}
+}
+
+// releaseImages downloads the images list asset of the given release and
+// returns its images by name, e.g. hardened-etcd.
+func (c *Client) releaseImages(ctx context.Context, owner, repo, tag string) (map[string]name.Tag, error) {
+	assets, err := c.ListAssets(ctx, owner, repo, tag)
+	if err != nil {
+		return nil, err
+	}
+
+	var url string
+	for _, asset := range assets {
+		if asset.Name == imageListAssets[repo] {
+			url = asset.DownloadURL
+		}
+	}
+	if url == "" {
+		return nil, errors.New(tag + " doesn't contain " + imageListAssets[repo])
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New("failed to download " + url + ": " + resp.Status)
+	}
+
+	return parseImageList(resp.Body)
+}
+
+// parseImageList parses an images list, with one image per line, and
+// returns its images by name.
+func parseImageList(r io.Reader) (map[string]name.Tag, error) {
+	images := make(map[string]name.Tag)
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		tag, err := name.NewTag(line)
+		if err != nil {
+			return nil, errors.New("invalid image " + line + ": " + err.Error())
+		}
+		repo := tag.Context().RepositoryStr()
+		images[repo[strings.LastIndex(repo, "/")+1:]] = tag
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return images, nil
+}
+
+// imageVersion returns the version label of an image, falling back to its
+// tag without the build suffix, e.g. v3.5.9-k3s1 for v3.5.9-k3s1-build20230802.
+func imageVersion(tag string, labels map[string]string) string {
+	if version := labels[registry.VersionLabel]; version != "" {
+		return version
+	}
+	if i := strings.Index(tag, "-build"); i != -1 {
+		return tag[:i]
+	}
+
+	return tag
+}
+
+// packageVersion returns the version of the SBOM package whose path ends
+// with the given suffix.
+func packageVersion(packages []registry.Package, suffix string) string {
+	for _, p := range packages {
+		if p.Name == suffix || strings.HasSuffix(p.Name, "/"+suffix) {
+			return p.Version
+		}
+	}
+
+	return ""
+}
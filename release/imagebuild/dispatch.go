@@ -0,0 +1,173 @@
+package imagebuild
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-github/v39/github"
+	reg "github.com/rancher/ecm-distro-tools/registry"
+	"github.com/rancher/ecm-distro-tools/release/watchdog"
+	"github.com/sirupsen/logrus"
+)
+
+// DispatchOptions describes the image-build release to create once the pull
+// request updating the image-build repo merges.
+type DispatchOptions struct {
+	Owner       string
+	Repo        string
+	PullRequest int
+	// Version is the upstream version the pull request updates to, e.g.
+	// v3.5.12. The image-build tag adds the build date to it.
+	Version string
+	// Image published by the tag, e.g. rancher/hardened-etcd. Defaults to
+	// the image matching the repo name.
+	Image    string
+	Interval time.Duration
+	DryRun   bool
+}
+
+// BuildTag returns the image-build tag of the given upstream version built
+// on the given date, e.g. v3.5.12-build20240215.
+func BuildTag(version string, date time.Time) string {
+	return version + "-build" + date.Format("20060102")
+}
+
+// DefaultImage returns the image published by the given image-build repo,
+// e.g. rancher/hardened-etcd for image-build-etcd.
+func DefaultImage(owner, repo string) string {
+	return owner + "/hardened-" + strings.TrimPrefix(repo, "image-build-")
+}
+
+// Dispatch waits for the given pull request to merge, tags its merge commit
+// to trigger the image publish and waits for the image to be available in
+// the registry. It returns the created tag. The context controls how long
+// to wait for.
+func Dispatch(ctx context.Context, client *github.Client, registry reg.RegistryClient, opts DispatchOptions) (string, error) {
+	if opts.Image == "" {
+		opts.Image = DefaultImage(opts.Owner, opts.Repo)
+	}
+
+	logrus.Infof("waiting for '%s/%s' pull request #%d to merge", opts.Owner, opts.Repo, opts.PullRequest)
+	sha, err := waitForMerge(ctx, client, opts.Owner, opts.Repo, opts.PullRequest, opts.Interval)
+	if err != nil {
+		return "", err
+	}
+
+	tag := BuildTag(opts.Version, time.Now().UTC())
+
+	if opts.DryRun {
+		logrus.Infof("Dry run, skipping tag '%s' creation on %s for '%s/%s'", tag, sha, opts.Owner, opts.Repo)
+		return tag, nil
+	}
+
+	exists, err := tagExists(ctx, client, opts.Owner, opts.Repo, tag)
+	if err != nil {
+		return "", err
+	}
+	if exists {
+		logrus.Infof("'%s/%s' tag '%s' already exists, skipping release.", opts.Owner, opts.Repo, tag)
+	} else {
+		newRelease := &github.RepositoryRelease{
+			TagName:         github.String(tag),
+			TargetCommitish: github.String(sha),
+			Name:            github.String(tag),
+			Draft:           github.Bool(false),
+		}
+		if _, _, err := client.Repositories.CreateRelease(ctx, opts.Owner, opts.Repo, newRelease); err != nil {
+			return "", fmt.Errorf("failed to create '%s/%s' release '%s': %v", opts.Owner, opts.Repo, tag, err)
+		}
+		logrus.Infof("Successfully created '%s/%s' release '%s'", opts.Owner, opts.Repo, tag)
+	}
+
+	ref, err := name.NewTag(opts.Image + ":" + tag)
+	if err != nil {
+		return "", err
+	}
+
+	logrus.Infof("waiting for image '%s' to be published", ref.String())
+	if err := waitForImage(ctx, registry, ref, opts.Interval); err != nil {
+		return "", err
+	}
+
+	return tag, nil
+}
+
+// waitForMerge polls the given pull request until it merges and returns its
+// merge commit.
+func waitForMerge(ctx context.Context, client *github.Client, owner, repo string, number int, interval time.Duration) (string, error) {
+	ctx, wait := watchdog.Default.Track(ctx, owner+"/"+repo+" pull request #"+strconv.Itoa(number)+" to merge")
+	defer wait.Done()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		pr, _, err := client.PullRequests.Get(ctx, owner, repo, number)
+		if err != nil {
+			if ctx.Err() != nil {
+				return "", errors.New("timed out waiting for pull request #" + strconv.Itoa(number) + " to merge: " + context.Cause(ctx).Error())
+			}
+			return "", fmt.Errorf("failed to get '%s/%s' pull request #%d: %w", owner, repo, number, err)
+		}
+		wait.Progress(pr.GetState() + ", head " + pr.GetHead().GetSHA())
+
+		if pr.GetMerged() {
+			return pr.GetMergeCommitSHA(), nil
+		}
+		if pr.GetState() == "closed" {
+			return "", errors.New("pull request #" + strconv.Itoa(number) + " was closed without merging")
+		}
+
+		select {
+		case <-ctx.Done():
+			return "", errors.New("timed out waiting for pull request #" + strconv.Itoa(number) + " to merge: " + context.Cause(ctx).Error())
+		case <-ticker.C:
+		}
+	}
+}
+
+// waitForImage polls the registry until the given image exists.
+func waitForImage(ctx context.Context, registry reg.RegistryClient, ref name.Reference, interval time.Duration) error {
+	ctx, wait := watchdog.Default.Track(ctx, "image "+ref.String()+" to be published")
+	defer wait.Done()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		image, err := registry.Image(ctx, ref)
+		if err != nil {
+			logrus.Warnf("failed to get image '%s': %v", ref.String(), err)
+			wait.Progress("error: " + err.Error())
+		} else {
+			wait.Progress("not found")
+		}
+		if image.Exists {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return errors.New("timed out waiting for image " + ref.String() + ": " + context.Cause(ctx).Error())
+		case <-ticker.C:
+		}
+	}
+}
+
+func tagExists(ctx context.Context, client *github.Client, owner, repo, tag string) (bool, error) {
+	_, res, err := client.Git.GetRef(ctx, owner, repo, "tags/"+tag)
+	if err != nil {
+		if res != nil && res.StatusCode == http.StatusNotFound {
+			return false, nil
+		}
+		return false, fmt.Errorf("could not get ref for tag '%s': %w", tag, err)
+	}
+
+	return true, nil
+}
@@ -0,0 +1,71 @@
+package imagebuild
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	reg "github.com/rancher/ecm-distro-tools/registry"
+)
+
+func TestBuildTag(t *testing.T) {
+	date := time.Date(2024, time.February, 5, 23, 0, 0, 0, time.UTC)
+	if got, want := BuildTag("v3.5.12", date), "v3.5.12-build20240205"; got != want {
+		t.Errorf("BuildTag() = %q, want %q", got, want)
+	}
+	if got, want := BuildTag("v1.29.2-rke2r1", date), "v1.29.2-rke2r1-build20240205"; got != want {
+		t.Errorf("BuildTag() = %q, want %q", got, want)
+	}
+}
+
+func TestDefaultImage(t *testing.T) {
+	if got, want := DefaultImage("rancher", "image-build-etcd"), "rancher/hardened-etcd"; got != want {
+		t.Errorf("DefaultImage() = %q, want %q", got, want)
+	}
+}
+
+// publishingRegistry reports the image as missing until it's been requested
+// the given number of times.
+type publishingRegistry struct {
+	requests int
+	after    int
+}
+
+func (r *publishingRegistry) Image(ctx context.Context, ref name.Reference) (reg.Image, error) {
+	r.requests++
+	return reg.Image{Exists: r.requests > r.after}, nil
+}
+
+func TestWaitForImage(t *testing.T) {
+	ref, err := name.NewTag("rancher/hardened-etcd:v3.5.12-build20240205")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	registry := &publishingRegistry{after: 2}
+	if err := waitForImage(context.Background(), registry, ref, time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	if registry.requests != 3 {
+		t.Errorf("expected 3 requests, got %d", registry.requests)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := waitForImage(ctx, &publishingRegistry{after: 1 << 30}, ref, time.Millisecond); err == nil {
+		t.Error("expected a timeout error")
+	}
+}
+
+func TestLatestVersions(t *testing.T) {
+	upstream := []string{"v3.5.9", "v3.5.12", "v3.6.0-rc.1", "v3.4.30", "api/v3.5.12"}
+	if got, want := latestUpstreamVersion(upstream, ""), "v3.5.12"; got != want {
+		t.Errorf("latestUpstreamVersion() = %q, want %q", got, want)
+	}
+
+	imageBuild := []string{"v3.5.9-build20231010", "v3.5.10-build20231201", "v3.5.9-k3s1-build20231011"}
+	if got, want := latestImageBuildVersion(imageBuild), "v3.5.10"; got != want {
+		t.Errorf("latestImageBuildVersion() = %q, want %q", got, want)
+	}
+}
@@ -120,8 +120,7 @@ func Sync(ctx context.Context, client *github.Client, owner, repo, upstreamOwner
 		if repo == imageBuildBase {
 			imageBuildTag += "b1"
 		} else {
-			now := time.Now()
-			imageBuildTag += fmt.Sprintf("-build%d%02d%02d", now.Year(), now.Month(), now.Day())
+			imageBuildTag = BuildTag(imageBuildTag, time.Now())
 		}
 
 		newRelease := &github.RepositoryRelease{
@@ -0,0 +1,78 @@
+package imagebuild
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/google/go-github/v39/github"
+)
+
+// Pending returns the latest version built by the given image-build repo and
+// the latest GA version of its upstream, which differ when a bump is
+// pending.
+func Pending(ctx context.Context, client *github.Client, owner, repo, upstreamOwner, upstreamRepo, tagPrefix string) (string, string, error) {
+	upstreamTags, _, err := client.Repositories.ListTags(ctx, upstreamOwner, upstreamRepo, &github.ListOptions{PerPage: 100})
+	if err != nil {
+		return "", "", fmt.Errorf("failed to retrieve '%s/%s' tags: %w", upstreamOwner, upstreamRepo, err)
+	}
+
+	tags, _, err := client.Repositories.ListTags(ctx, owner, repo, &github.ListOptions{PerPage: 100})
+	if err != nil {
+		return "", "", fmt.Errorf("failed to retrieve '%s/%s' tags: %w", owner, repo, err)
+	}
+
+	return latestImageBuildVersion(tagNames(tags)), latestUpstreamVersion(tagNames(upstreamTags), tagPrefix), nil
+}
+
+// latestUpstreamVersion returns the highest GA version among the given
+// upstream tags, without the tag prefix.
+func latestUpstreamVersion(tags []string, tagPrefix string) string {
+	var versions []string
+	for _, tag := range tags {
+		if validateTagFormat(tag, tagPrefix) {
+			versions = append(versions, strings.TrimPrefix(tag, tagPrefix))
+		}
+	}
+
+	return highestVersion(versions)
+}
+
+// latestImageBuildVersion returns the highest upstream version among the
+// given image-build tags, removing any suffixes, e.g. -buildYYYYMMDD.
+func latestImageBuildVersion(tags []string) string {
+	versions := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		version, _, _ := strings.Cut(tag, "-")
+		versions = append(versions, version)
+	}
+
+	return highestVersion(versions)
+}
+
+func highestVersion(versions []string) string {
+	var highest *semver.Version
+	var latest string
+	for _, version := range versions {
+		v, err := semver.NewVersion(version)
+		if err != nil {
+			continue
+		}
+		if highest == nil || v.GreaterThan(highest) {
+			highest = v
+			latest = version
+		}
+	}
+
+	return latest
+}
+
+func tagNames(tags []*github.RepositoryTag) []string {
+	names := make([]string, len(tags))
+	for i, tag := range tags {
+		names[i] = tag.GetName()
+	}
+
+	return names
+}
@@ -0,0 +1,99 @@
+package junit
+
+import (
+	"encoding/xml"
+	"io"
+	"os"
+)
+
+// TestCase is a check reported as a JUnit test case, e.g. an asset of a
+// release.
+type TestCase struct {
+	Name      string   `xml:"name,attr"`
+	ClassName string   `xml:"classname,attr,omitempty"`
+	Failure   *Failure `xml:"failure,omitempty"`
+	Error     *Failure `xml:"error,omitempty"`
+}
+
+// Failure is the failure, or the error, of a test case.
+type Failure struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// TestSuite is a group of checks, e.g. of a release.
+type TestSuite struct {
+	Name     string     `xml:"name,attr"`
+	Tests    int        `xml:"tests,attr"`
+	Failures int        `xml:"failures,attr"`
+	Errors   int        `xml:"errors,attr"`
+	Cases    []TestCase `xml:"testcase"`
+}
+
+// Pass adds a passed test case to the suite.
+func (s *TestSuite) Pass(name string) {
+	s.Cases = append(s.Cases, TestCase{Name: name, ClassName: s.Name})
+	s.Tests++
+}
+
+// Fail adds a failed test case to the suite, e.g. a check that didn't pass.
+func (s *TestSuite) Fail(name, message string) {
+	s.Cases = append(s.Cases, TestCase{Name: name, ClassName: s.Name, Failure: &Failure{Message: message, Text: message}})
+	s.Tests++
+	s.Failures++
+}
+
+// Error adds a test case to the suite that couldn't be checked, e.g. because
+// of a GitHub error.
+func (s *TestSuite) Error(name, message string) {
+	s.Cases = append(s.Cases, TestCase{Name: name, ClassName: s.Name, Error: &Failure{Message: message, Text: message}})
+	s.Tests++
+	s.Errors++
+}
+
+type testSuites struct {
+	XMLName  xml.Name    `xml:"testsuites"`
+	Name     string      `xml:"name,attr"`
+	Tests    int         `xml:"tests,attr"`
+	Failures int         `xml:"failures,attr"`
+	Errors   int         `xml:"errors,attr"`
+	Suites   []TestSuite `xml:"testsuite"`
+}
+
+// Write writes the given suites as a JUnit XML report of the given name, as
+// read by the CI pipelines to surface them as test results.
+func Write(w io.Writer, name string, suites []TestSuite) error {
+	report := testSuites{Name: name, Suites: suites}
+	for _, suite := range suites {
+		report.Tests += suite.Tests
+		report.Failures += suite.Failures
+		report.Errors += suite.Errors
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(report); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// WriteFile writes the given suites as a JUnit XML report to the given file.
+func WriteFile(path, name string, suites []TestSuite) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := Write(f, name, suites); err != nil {
+		return err
+	}
+
+	return f.Close()
+}
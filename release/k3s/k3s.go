@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strconv"
 	"strings"
 	"text/template"
@@ -19,8 +20,11 @@ import (
 	"github.com/google/go-github/v39/github"
 	ecmConfig "github.com/rancher/ecm-distro-tools/cmd/release/config"
 	ecmExec "github.com/rancher/ecm-distro-tools/exec"
+	"github.com/rancher/ecm-distro-tools/registry"
 	"github.com/rancher/ecm-distro-tools/release"
+	"github.com/rancher/ecm-distro-tools/release/dockerfile"
 	"github.com/rancher/ecm-distro-tools/repository"
+	"github.com/rancher/ecm-distro-tools/version"
 	ssh2 "golang.org/x/crypto/ssh"
 	"golang.org/x/mod/semver"
 	"sigs.k8s.io/yaml"
@@ -31,7 +35,6 @@ const (
 	k8sUpstreamURL     = "https://github.com/kubernetes/kubernetes"
 	rancherRemote      = "k3s-io"
 	k8sRancherURL      = "git@github.com:k3s-io/kubernetes.git"
-	k8sUserURL         = "git@github.com:user/kubernetes.git"
 	k3sUpstreamRepoURL = "https://github.com/k3s-io/k3s"
 	gitconfig          = `[safe]
 directory = /home/go/src/kubernetes
@@ -52,9 +55,13 @@ DRY_RUN={{ .K3s.DryRun }}
 BRANCH_NAME={{ .K3s.NewK8sVersion }}-{{ .K3s.NewSuffix }}
 cd {{ .K3s.Workspace }}
 # using ls | grep is not a good idea because it doesn't support non-alphanumeric filenames, but since we're only ever checking 'k3s' it isn't a problem https://www.shellcheck.net/wiki/SC2010
-ls | grep -w k3s || git clone "git@github.com:{{ .User.GithubUsername }}/k3s.git"
+ls | grep -w k3s || git clone {{ .Clone.Args }} "{{ .K3s.K3sFork .User }}"
 cd {{ .K3s.Workspace }}/k3s
-git remote -v | grep -w upstream || git remote add upstream {{ .K3s.K3sUpstreamURL }}
+{{ .Clone.SparseCheckout }}
+# point the remotes of an existing clone to the given URLs too, e.g. the
+# sandboxes of a rehearsal.
+git remote set-url origin "{{ .K3s.K3sFork .User }}"
+git remote add upstream "{{ .K3s.K3sUpstreamURL }}" 2>/dev/null || git remote set-url upstream "{{ .K3s.K3sUpstreamURL }}"
 git fetch upstream
 git stash
 git branch -D "${BRANCH_NAME}" &>/dev/null || true
@@ -82,7 +89,13 @@ Linux)
 	;;
 esac
 
-go mod tidy
+go mod tidy`
+	commitK3sScriptName       = "commit_k3s_references.sh"
+	commitK3sReferencesScript = `#!/bin/bash
+set -ex
+DRY_RUN={{ .K3s.DryRun }}
+BRANCH_NAME={{ .K3s.NewK8sVersion }}-{{ .K3s.NewSuffix }}
+cd {{ .K3s.Workspace }}/k3s
 
 git add go.mod go.sum Dockerfile.* .github/workflows/integration.yaml .github/workflows/unitcoverage.yaml
 	git commit --signoff -m "Update to {{ .K3s.NewK8sVersion }}"
@@ -91,16 +104,22 @@ if [ "${DRY_RUN}" = false ]; then
 fi`
 )
 
+// goImageLines matches the lines of the k3s Dockerfiles the Go bump is
+// expected to change.
+var goImageLines = []*regexp.Regexp{regexp.MustCompile(`golang:`)}
+
 type UpdateScriptVars struct {
-	K3s  *ecmConfig.K3sRelease
-	User *ecmConfig.User
+	K3s   *ecmConfig.K3sRelease
+	User  *ecmConfig.User
+	Clone repository.CloneOptions
 }
 
 // GenerateTags will clone the kubernetes repository, rebase it with the k3s-io fork and
-// generate tags to be pushed
-func GenerateTags(ctx context.Context, ghClient *github.Client, r *ecmConfig.K3sRelease, u *ecmConfig.User, sshKeyPath string) error {
+// generate tags to be pushed. Only the depth of the clone options applies,
+// since the clone doesn't support partial clones nor sparse checkouts.
+func GenerateTags(ctx context.Context, ghClient *github.Client, r *ecmConfig.K3sRelease, u *ecmConfig.User, sshKeyPath string, clone repository.CloneOptions) error {
 	fmt.Println("setting up k8s remotes")
-	if err := setupK8sRemotes(r, u, sshKeyPath); err != nil {
+	if err := setupK8sRemotes(r, u, sshKeyPath, clone); err != nil {
 		return errors.New("failed to clone and setup remotes for k8s repos: " + err.Error())
 	}
 
@@ -130,7 +149,7 @@ func writeTagsFile(r *ecmConfig.K3sRelease, tags []string) error {
 
 // setupK8sRemotes will clone the kubernetes upstream repo and proceed with setting up remotes
 // for rancher and user's forks, then it will fetch branches and tags for all remotes
-func setupK8sRemotes(r *ecmConfig.K3sRelease, u *ecmConfig.User, sshKeyPath string) error {
+func setupK8sRemotes(r *ecmConfig.K3sRelease, u *ecmConfig.User, sshKeyPath string, clone repository.CloneOptions) error {
 	k8sDir := filepath.Join(r.Workspace, "kubernetes")
 
 	fmt.Println("verifying if the k8s dir already exists: " + k8sDir)
@@ -150,6 +169,7 @@ func setupK8sRemotes(r *ecmConfig.K3sRelease, u *ecmConfig.User, sshKeyPath stri
 	repo, err := git.PlainClone(k8sDir, false, &git.CloneOptions{
 		URL:             k8sUpstreamURL,
 		Progress:        os.Stdout,
+		Depth:           clone.Depth,
 		InsecureSkipTLS: true,
 	})
 	if err != nil {
@@ -182,13 +202,8 @@ func setupK8sRemotes(r *ecmConfig.K3sRelease, u *ecmConfig.User, sshKeyPath stri
 	}
 
 	fmt.Println("creating remote: '" + r.K3sRepoOwner + " " + r.K8sRancherURL + "'")
-	if _, err := repo.CreateRemote(&config.RemoteConfig{
-		Name: r.K3sRepoOwner,
-		URLs: []string{r.K8sRancherURL},
-	}); err != nil {
-		if err != git.ErrRemoteExists {
-			return err
-		}
+	if err := setRemote(repo, r.K3sRepoOwner, r.K8sRancherURL); err != nil {
+		return err
 	}
 
 	fmt.Println("fetching remote: " + r.K3sRepoOwner)
@@ -203,15 +218,10 @@ func setupK8sRemotes(r *ecmConfig.K3sRelease, u *ecmConfig.User, sshKeyPath stri
 		}
 	}
 
-	userRemoteURL := strings.Replace(k8sUserURL, "user", u.GithubUsername, -1)
+	userRemoteURL := r.K8sFork(u)
 	fmt.Println("creating remote: '" + u.GithubUsername + " " + userRemoteURL + "'")
-	if _, err := repo.CreateRemote(&config.RemoteConfig{
-		Name: u.GithubUsername,
-		URLs: []string{userRemoteURL},
-	}); err != nil {
-		if err != git.ErrRemoteExists {
-			return err
-		}
+	if err := setRemote(repo, u.GithubUsername, userRemoteURL); err != nil {
+		return err
 	}
 	fmt.Println("fetching remote: " + u.GithubUsername)
 	if err := repo.Fetch(&git.FetchOptions{
@@ -228,6 +238,30 @@ func setupK8sRemotes(r *ecmConfig.K3sRelease, u *ecmConfig.User, sshKeyPath stri
 	return nil
 }
 
+// setRemote creates the given remote, or points it to the given URL if it
+// already exists, so the tags aren't pushed to the URL of a previous run,
+// e.g. production after a rehearsal.
+func setRemote(repo *git.Repository, name, url string) error {
+	remote, err := repo.Remote(name)
+	if err == git.ErrRemoteNotFound {
+		_, err = repo.CreateRemote(&config.RemoteConfig{Name: name, URLs: []string{url}})
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	if urls := remote.Config().URLs; len(urls) == 1 && urls[0] == url {
+		return nil
+	}
+
+	if err := repo.DeleteRemote(name); err != nil {
+		return err
+	}
+	_, err = repo.CreateRemote(&config.RemoteConfig{Name: name, URLs: []string{url}})
+
+	return err
+}
+
 func rebaseAndTag(ctx context.Context, ghClient *github.Client, r *ecmConfig.K3sRelease, u *ecmConfig.User) ([]string, error) {
 	rebaseOut, err := gitRebaseOnto(ctx, ghClient, r)
 	if err != nil {
@@ -322,7 +356,8 @@ func previousK3sReleaseTag(ctx context.Context, ghClient *github.Client, r *ecmC
 		releaseTag := fmt.Sprintf("%s-k3s%d", r.OldK8sVersion, i)
 		_, _, err := ghClient.Git.GetRef(ctx, ecmConfig.K3sGithubOrganization, ecmConfig.K3sK8sRepositoryName, "tags/"+releaseTag)
 		if err != nil {
-			if ghErr, ok := err.(*github.ErrorResponse); ok && ghErr.Response.StatusCode == http.StatusNotFound {
+			err = repository.WrapGithubError(err, ecmConfig.K3sGithubOrganization, ecmConfig.K3sK8sRepositoryName, releaseTag)
+			if errors.Is(err, repository.ErrNotFound) {
 				return latestRelease, nil
 			}
 			return "", fmt.Errorf("error getting Git ref for tag '%s': %w", releaseTag, err)
@@ -342,7 +377,13 @@ type K8sBuildDependencies struct {
 }
 
 func goVersion(r *ecmConfig.K3sRelease) (string, error) {
-	url := "https://raw.githubusercontent.com/kubernetes/kubernetes/refs/tags/" + r.NewK8sVersion + "/build/dependencies.yaml"
+	return k8sGoVersion(r.NewK8sVersion)
+}
+
+// k8sGoVersion returns the Go version the given Kubernetes version is built
+// with, read from its build dependencies.
+func k8sGoVersion(k8sVersion string) (string, error) {
+	url := "https://raw.githubusercontent.com/kubernetes/kubernetes/refs/tags/" + k8sVersion + "/build/dependencies.yaml"
 
 	resp, err := http.Get(url)
 	if err != nil {
@@ -586,8 +627,8 @@ func PushTags(ghClient *github.Client, r *ecmConfig.K3sRelease, u *ecmConfig.Use
 	return nil
 }
 
-func UpdateK3sReferences(ctx context.Context, ghClient *github.Client, r *ecmConfig.K3sRelease, u *ecmConfig.User) error {
-	if err := updateK3sReferencesAndPush(r, u); err != nil {
+func UpdateK3sReferences(ctx context.Context, ghClient *github.Client, r *ecmConfig.K3sRelease, u *ecmConfig.User, clone repository.CloneOptions) error {
+	if err := updateK3sReferencesAndPush(ctx, r, u, clone); err != nil {
 		return err
 	}
 
@@ -599,7 +640,7 @@ func UpdateK3sReferences(ctx context.Context, ghClient *github.Client, r *ecmCon
 	return createK3sReferencesPR(ctx, ghClient, r, u)
 }
 
-func updateK3sReferencesAndPush(r *ecmConfig.K3sRelease, u *ecmConfig.User) error {
+func updateK3sReferencesAndPush(ctx context.Context, r *ecmConfig.K3sRelease, u *ecmConfig.User, clone repository.CloneOptions) error {
 	fmt.Println("verifying if workspace dir exists")
 	if _, err := os.Stat(r.Workspace); err != nil {
 		if !os.IsNotExist(err) {
@@ -623,12 +664,24 @@ func updateK3sReferencesAndPush(r *ecmConfig.K3sRelease, u *ecmConfig.User) erro
 
 	funcMap := template.FuncMap{"replaceAll": strings.ReplaceAll}
 	fmt.Println("creating update k3s references script template")
-	scriptVars := UpdateScriptVars{K3s: r, User: u}
+	scriptVars := UpdateScriptVars{K3s: r, User: u, Clone: clone}
 	updateScriptOut, err := ecmExec.RunTemplatedScript(r.Workspace, updateK3sScriptName, updateK3sReferencesScript, funcMap, scriptVars)
 	if err != nil {
 		return err
 	}
 	fmt.Println(updateScriptOut)
+
+	fmt.Println("validating the Go image bump of the Dockerfiles")
+	opts := dockerfile.Options{Expected: goImageLines, Resolve: registry.Exists}
+	if err := dockerfile.ValidateEdits(ctx, filepath.Join(r.Workspace, "k3s"), []string{"Dockerfile.*"}, opts); err != nil {
+		return err
+	}
+
+	commitScriptOut, err := ecmExec.RunTemplatedScript(r.Workspace, commitK3sScriptName, commitK3sReferencesScript, funcMap, scriptVars)
+	if err != nil {
+		return err
+	}
+	fmt.Println(commitScriptOut)
 	return nil
 }
 
@@ -643,9 +696,28 @@ func createK3sReferencesPR(ctx context.Context, ghClient *github.Client, r *ecmC
 	}
 
 	// creating a pr from your fork branch
-	_, _, err := ghClient.PullRequests.Create(ctx, r.K3sRepoOwner, repo, pull)
+	pr, _, err := ghClient.PullRequests.Create(ctx, r.K3sRepoOwner, repo, pull)
+	if err != nil {
+		return err
+	}
 
-	return err
+	if err := repository.RequestOwnerReviews(ctx, ghClient, r.K3sRepoOwner, repo, pr.GetNumber(), bumpedComponents(r)...); err != nil {
+		fmt.Println("failed to request reviews from the component owners: " + err.Error())
+	}
+
+	return nil
+}
+
+// bumpedComponents returns the components the references pull request
+// bumps: Kubernetes, and Go if the new Kubernetes version is built with
+// another Go version than the old one, or if it can't be told.
+func bumpedComponents(r *ecmConfig.K3sRelease) []string {
+	components := []string{"kubernetes"}
+	if old, err := k8sGoVersion(r.OldK8sVersion); err != nil || old != r.NewGoVersion {
+		components = append(components, "golang")
+	}
+
+	return components
 }
 
 func NewGithubClient(ctx context.Context, token string) (*github.Client, error) {
@@ -756,27 +828,30 @@ func CreateRelease(ctx context.Context, client *github.Client, r *ecmConfig.K3sR
 	name := r.NewK8sVersion + "+" + r.NewSuffix
 	oldName := r.OldK8sVersion + "+" + r.OldSuffix
 
-	latestRC, err := release.LatestRC(ctx, opts.Owner, opts.Repo, r.NewK8sVersion, r.NewSuffix, client)
+	releaseClient := release.NewClient(client)
+
+	latest, err := releaseClient.LatestRC(ctx, release.LatestRCOptions{Owner: opts.Owner, Repo: opts.Repo, K8sVersion: r.NewK8sVersion, Suffix: r.NewSuffix})
 	if err != nil {
 		return err
 	}
-	if latestRC == nil && !rc {
+	latestRC := latest.Tag
+	if latestRC == "" && !rc {
 		return errors.New("couldn't find the latest RC")
 	}
 	if rc {
-		latestRCNumber := 1
-		if latestRC != nil {
-			trimmedRCNumber, _, found := strings.Cut(strings.TrimPrefix(*latestRC, r.NewK8sVersion+"-rc"), "+k3s")
-			if !found {
-				return errors.New("failed to parse rc number from " + *latestRC)
-			}
-			currentRCNumber, err := strconv.Atoi(trimmedRCNumber)
+		current, err := version.Parse(name)
+		if err != nil {
+			return err
+		}
+		next := current.NextRC()
+		if latestRC != "" {
+			current, err = version.Parse(latestRC)
 			if err != nil {
-				return err
+				return errors.New("failed to parse rc number from " + latestRC + ": " + err.Error())
 			}
-			latestRCNumber = currentRCNumber + 1
+			next = current.NextRC()
 		}
-		name = r.NewK8sVersion + "-rc" + strconv.Itoa(latestRCNumber) + "+" + r.NewSuffix
+		name = next.String()
 	}
 
 	opts.Name = name
@@ -788,7 +863,7 @@ func CreateRelease(ctx context.Context, client *github.Client, r *ecmConfig.K3sR
 	fmt.Printf("create release options: %+v\n", *opts)
 
 	if !rc && opts.Repo == "k3s" {
-		buff, err := release.GenReleaseNotes(ctx, opts.Owner, opts.Repo, *latestRC, oldName, client)
+		buff, err := releaseClient.GenReleaseNotes(ctx, opts.Owner, opts.Repo, latestRC, oldName)
 		if err != nil {
 			return err
 		}
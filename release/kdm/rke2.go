@@ -6,10 +6,9 @@ import (
 	"fmt"
 	"os"
 	"slices"
-	"strconv"
 	"strings"
 
-	"github.com/Masterminds/semver/v3"
+	"github.com/rancher/ecm-distro-tools/version"
 	"gopkg.in/yaml.v3"
 )
 
@@ -171,31 +170,33 @@ func (u *RKE2ChannelsUpdater) releases(versions []string) ([]Release, error) {
 	return releases, nil
 }
 
-const (
-	rke2VersionTemplate = "v%d.%d.%d+rke2r%d"
-)
-
 func (u *RKE2ChannelsUpdater) getPreviousVersion(version string) (string, error) {
-	major, minor, patch, release, err := parseRKE2Version(version)
+	v, err := parseRKE2Version(version)
 	if err != nil {
 		return "", err
 	}
-	if release > 1 {
+	if v.Revision > 1 {
 		// for releases higher than 1, we can just return the previous one.
-		return fmt.Sprintf(rke2VersionTemplate, major, minor, patch, release-1), nil
+		prev := *v
+		prev.Revision--
+		return prev.String(), nil
 	}
 
 	// when the patch number is 0, e.g "v1.33.0+rke2r1" we need
 	// to get the latest previous minor.
-	if patch == 0 {
-		prevVersion, err := u.rke2LatestMinor(major, minor)
+	if v.Patch == 0 {
+		prevVersion, err := u.rke2LatestMinor(v.Major, v.Minor)
 		if err != nil {
 			return "", err
 		}
 		return prevVersion, nil
 	}
 
-	return fmt.Sprintf(rke2VersionTemplate, major, minor, patch-1, 1), nil
+	prev := *v
+	prev.Patch--
+	prev.Revision = 1
+
+	return prev.String(), nil
 }
 
 func (u *RKE2ChannelsUpdater) rke2LatestMinor(major, minor int) (string, error) {
@@ -211,29 +212,18 @@ func (u *RKE2ChannelsUpdater) rke2LatestMinor(major, minor int) (string, error)
 }
 
 // parseRKE2Version receives a version in this format: vX.Y.Z+rke2rN
-// and returns the major, minor, patch, and release numbers as integers.
-func parseRKE2Version(version string) (int, int, int, int, error) {
-	v, err := semver.NewVersion(version)
+// and returns it parsed, failing if it isn't a GA RKE2 version.
+func parseRKE2Version(v string) (*version.Version, error) {
+	parsed, err := version.Parse(v)
 	if err != nil {
-		return 0, 0, 0, 0, fmt.Errorf("failed to parse version '%s': %w", version, err)
+		return nil, fmt.Errorf("failed to parse version '%s': %w", v, err)
 	}
 
-	major := int(v.Major())
-	minor := int(v.Minor())
-	patch := int(v.Patch())
-
-	metadata := v.Metadata()
-	if !strings.HasPrefix(metadata, "rke2r") {
-		return 0, 0, 0, 0, fmt.Errorf("invalid metadata format: expected 'rke2r' but got %q", metadata)
-	}
-
-	releaseStr := strings.TrimPrefix(metadata, "rke2r")
-	release, err := strconv.Atoi(releaseStr)
-	if err != nil {
-		return 0, 0, 0, 0, fmt.Errorf("invalid release version part %q: %w", releaseStr, err)
+	if parsed.Product != version.RKE2 || parsed.IsRC() {
+		return nil, fmt.Errorf("invalid version format: expected 'vX.Y.Z+rke2rN' but got %q", v)
 	}
 
-	return major, minor, patch, release, nil
+	return parsed, nil
 }
 
 func (u *RKE2ChannelsUpdater) addRelease(release Release) error {
@@ -0,0 +1,174 @@
+package release
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rancher/ecm-distro-tools/repository"
+)
+
+// DefaultLocale is the locale the dates and numbers of the release notes are
+// formatted in unless configured otherwise.
+const DefaultLocale = "en-US"
+
+// locale describes how dates and numbers are written in a language and
+// region, e.g. de-DE.
+type locale struct {
+	// dateLayout is a date with the {day}, {month}, {monthNumber} and
+	// {year} placeholders.
+	dateLayout string
+	months     [12]string
+	thousands  string
+}
+
+var englishMonths = [12]string{"January", "February", "March", "April", "May", "June", "July", "August", "September", "October", "November", "December"}
+
+var locales = map[string]locale{
+	"en-US": {dateLayout: "{month} {day}, {year}", months: englishMonths, thousands: ","},
+	"en-GB": {dateLayout: "{day} {month} {year}", months: englishMonths, thousands: ","},
+	"de-DE": {
+		dateLayout: "{day}. {month} {year}",
+		months:     [12]string{"Januar", "Februar", "März", "April", "Mai", "Juni", "Juli", "August", "September", "Oktober", "November", "Dezember"},
+		thousands:  ".",
+	},
+	"fr-FR": {
+		dateLayout: "{day} {month} {year}",
+		months:     [12]string{"janvier", "février", "mars", "avril", "mai", "juin", "juillet", "août", "septembre", "octobre", "novembre", "décembre"},
+		thousands:  " ",
+	},
+	"es-ES": {
+		dateLayout: "{day} de {month} de {year}",
+		months:     [12]string{"enero", "febrero", "marzo", "abril", "mayo", "junio", "julio", "agosto", "septiembre", "octubre", "noviembre", "diciembre"},
+		thousands:  ".",
+	},
+	"pt-BR": {
+		dateLayout: "{day} de {month} de {year}",
+		months:     [12]string{"janeiro", "fevereiro", "março", "abril", "maio", "junho", "julho", "agosto", "setembro", "outubro", "novembro", "dezembro"},
+		thousands:  ".",
+	},
+	"ja-JP": {dateLayout: "{year}年{monthNumber}月{day}日", thousands: ","},
+	"zh-CN": {dateLayout: "{year}年{monthNumber}月{day}日", thousands: ","},
+}
+
+// Locales returns the locales the release notes can be formatted in.
+func Locales() []string {
+	names := make([]string, 0, len(locales))
+	for name := range locales {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// ValidateLocale returns an error if the given locale isn't supported.
+func ValidateLocale(name string) error {
+	if _, ok := locales[name]; !ok {
+		return errors.New("invalid locale: " + name + ", expected one of: " + strings.Join(Locales(), ", "))
+	}
+
+	return nil
+}
+
+func localeOrDefault(name string) locale {
+	if l, ok := locales[name]; ok {
+		return l
+	}
+
+	return locales[DefaultLocale]
+}
+
+// formatDate formats the given date in the given locale, e.g. 2. März 2024
+// for de-DE. Unknown locales use DefaultLocale.
+func formatDate(name string, t time.Time) string {
+	l := localeOrDefault(name)
+
+	return strings.NewReplacer(
+		"{day}", strconv.Itoa(t.Day()),
+		"{month}", l.months[t.Month()-1],
+		"{monthNumber}", strconv.Itoa(int(t.Month())),
+		"{year}", strconv.Itoa(t.Year()),
+	).Replace(l.dateLayout)
+}
+
+// formatNumber formats the given number with the thousands separator of
+// the given locale, e.g. 1.234 for de-DE.
+func formatNumber(name string, n int) string {
+	digits := strconv.Itoa(n)
+	sign := ""
+	if n < 0 {
+		sign, digits = "-", digits[1:]
+	}
+
+	var b strings.Builder
+	for i, digit := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			b.WriteString(localeOrDefault(name).thousands)
+		}
+		b.WriteRune(digit)
+	}
+
+	return sign + b.String()
+}
+
+func (rd *releaseNoteData) setDates(locale string, publishDate, eolDate *time.Time) {
+	rd.Locale = locale
+	rd.PublishDate = publishDate
+	rd.EOLDate = eolDate
+}
+
+// addDates records the locale of the snapshot and the dates the notes
+// mention: the publish date, defaulting to the one of the milestone's
+// release if already published, and the end of life date of the release
+// line, if known.
+func (c *Client) addDates(ctx context.Context, owner string, dates NotesDates, snapshot *ReleaseNotesSnapshot) error {
+	locale := dates.Locale
+	if locale == "" {
+		locale = DefaultLocale
+	}
+	if err := ValidateLocale(locale); err != nil {
+		return err
+	}
+
+	var publishDate, eolDate *time.Time
+	if !dates.PublishDate.IsZero() {
+		publishDate = &dates.PublishDate
+	} else {
+		release, err := c.Cache.GetReleaseByTag(ctx, c.GitHub, owner, snapshot.Repo, snapshot.Milestone)
+		switch {
+		case err == nil && !release.GetDraft() && !release.GetPublishedAt().IsZero():
+			published := release.GetPublishedAt().Time
+			publishDate = &published
+		case err != nil && !errors.Is(err, repository.ErrNotFound):
+			c.Log.Warnf("not adding the publish date, failed to get the release of %s: %v", snapshot.Milestone, err)
+		}
+	}
+	if !dates.EOLDate.IsZero() {
+		eolDate = &dates.EOLDate
+	}
+
+	rd, err := newReleaseNote(snapshot.Repo)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(snapshot.Data, rd); err != nil {
+		return err
+	}
+
+	rd.(interface {
+		setDates(string, *time.Time, *time.Time)
+	}).setDates(locale, publishDate, eolDate)
+
+	data, err := json.Marshal(rd)
+	if err != nil {
+		return err
+	}
+	snapshot.Data = data
+
+	return nil
+}
@@ -0,0 +1,413 @@
+package mirror
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/google/go-github/v39/github"
+	"github.com/rancher/ecm-distro-tools/repository"
+	"github.com/sirupsen/logrus"
+)
+
+// Types of the repositories the release artifacts can be mirrored to.
+const (
+	Artifactory = "artifactory"
+	Nexus       = "nexus"
+)
+
+// checksumsRegex matches the checksum files of the k3s and rke2 releases,
+// e.g. sha256sum-amd64.txt.
+var checksumsRegex = regexp.MustCompile(`^sha256sum(-\w+)?\.txt$`)
+
+// Config configures the Artifactory or Nexus repository the release
+// artifacts are mirrored to, e.g. for the air-gap bundles of customers.
+type Config struct {
+	Type string `json:"type"`
+	// URL of the server, e.g. https://artifactory.example.com/artifactory
+	// or https://nexus.example.com.
+	URL string `json:"url"`
+	// Repository is the generic, or raw for Nexus, hosted repository the
+	// artifacts are uploaded to.
+	Repository string `json:"repository"`
+	Username   string `json:"username,omitempty"`
+	Password   string `json:"password,omitempty"`
+	// Token authenticates with a bearer token instead of the username and
+	// password, e.g. an Artifactory access token.
+	Token string `json:"token,omitempty"`
+}
+
+// Validate checks the type of the repository and that it's located.
+func (c *Config) Validate() error {
+	if c.Type != Artifactory && c.Type != Nexus {
+		return errors.New("invalid mirror type: " + c.Type + ", expected one of: " + Artifactory + ", " + Nexus)
+	}
+	if _, err := url.ParseRequestURI(c.URL); err != nil {
+		return errors.New("invalid mirror url: " + c.URL)
+	}
+	if c.Repository == "" {
+		return errors.New("missing mirror repository")
+	}
+
+	return nil
+}
+
+// Repository is a repository the release artifacts are mirrored to.
+type Repository interface {
+	// Digest returns the sha256 digest of the file at the given path, empty
+	// if the file doesn't exist or its digest isn't known.
+	Digest(ctx context.Context, path string) (string, error)
+	// Upload uploads the given file, of the given sha256 digest, to the
+	// given path, replacing the existing one.
+	Upload(ctx context.Context, path string, file *os.File, digest string) error
+}
+
+// New creates the repository described by the given config.
+func New(cfg Config, client *http.Client) (Repository, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	base := server{config: cfg, client: client}
+
+	if cfg.Type == Nexus {
+		return &nexus{server: base}, nil
+	}
+
+	return &artifactory{server: base}, nil
+}
+
+type server struct {
+	config Config
+	client *http.Client
+}
+
+func (s *server) do(req *http.Request) (*http.Response, error) {
+	if s.config.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.config.Token)
+	} else if s.config.Username != "" {
+		req.SetBasicAuth(s.config.Username, s.config.Password)
+	}
+
+	return s.client.Do(req)
+}
+
+// upload puts the given file at the given URL with the given headers.
+func (s *server) upload(ctx context.Context, u string, file *os.File, header http.Header) error {
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, u, file)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = info.Size()
+	for name, values := range header {
+		req.Header[name] = values
+	}
+
+	res, err := s.do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK && res.StatusCode != http.StatusCreated && res.StatusCode != http.StatusNoContent {
+		return errors.New("failed to upload " + u + ": unexpected status code " + strconv.Itoa(res.StatusCode))
+	}
+
+	return nil
+}
+
+// fileURL returns the URL of the file at the given path under the given
+// URL prefix.
+func fileURL(prefix, p string) string {
+	segments := strings.Split(p, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+
+	return strings.TrimSuffix(prefix, "/") + "/" + strings.Join(segments, "/")
+}
+
+// artifactory is an Artifactory generic repository, which returns the
+// digests of the files in the X-Checksum-Sha256 header and verifies the
+// uploads against it.
+type artifactory struct {
+	server
+}
+
+func (a *artifactory) url(p string) string {
+	return fileURL(a.config.URL+"/"+a.config.Repository, p)
+}
+
+func (a *artifactory) Digest(ctx context.Context, p string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, a.url(p), nil)
+	if err != nil {
+		return "", err
+	}
+
+	res, err := a.do(req)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+
+	switch res.StatusCode {
+	case http.StatusOK:
+		return res.Header.Get("X-Checksum-Sha256"), nil
+	case http.StatusNotFound:
+		return "", nil
+	}
+
+	return "", errors.New("failed to check " + a.url(p) + ": unexpected status code " + strconv.Itoa(res.StatusCode))
+}
+
+func (a *artifactory) Upload(ctx context.Context, p string, file *os.File, digest string) error {
+	return a.upload(ctx, a.url(p), file, http.Header{"X-Checksum-Sha256": {digest}})
+}
+
+// nexus is a Nexus raw hosted repository, whose digests are read from its
+// search API.
+type nexus struct {
+	server
+}
+
+func (n *nexus) url(p string) string {
+	return fileURL(n.config.URL+"/repository/"+n.config.Repository, p)
+}
+
+func (n *nexus) Digest(ctx context.Context, p string) (string, error) {
+	query := url.Values{"repository": {n.config.Repository}, "name": {p}}
+	u := strings.TrimSuffix(n.config.URL, "/") + "/service/rest/v1/search/assets?" + query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return "", err
+	}
+
+	res, err := n.do(req)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return "", errors.New("failed to search " + p + " in " + n.config.Repository + ": unexpected status code " + strconv.Itoa(res.StatusCode))
+	}
+
+	var assets struct {
+		Items []struct {
+			Path     string            `json:"path"`
+			Checksum map[string]string `json:"checksum"`
+		} `json:"items"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&assets); err != nil {
+		return "", err
+	}
+	for _, item := range assets.Items {
+		if strings.TrimPrefix(item.Path, "/") == p {
+			return item.Checksum["sha256"], nil
+		}
+	}
+
+	return "", nil
+}
+
+func (n *nexus) Upload(ctx context.Context, p string, file *os.File, digest string) error {
+	return n.upload(ctx, n.url(p), file, nil)
+}
+
+// Path returns the path of a release file in the mirror repository.
+func Path(product, version, name string) string {
+	return path.Join(product, version, name)
+}
+
+// Result lists the paths of the files mirrored and of the ones skipped as
+// already mirrored.
+type Result struct {
+	Uploaded []string
+	Skipped  []string
+}
+
+// Mirror mirrors the GitHub release assets into a repository.
+type Mirror struct {
+	repo   Repository
+	dryRun bool
+}
+
+// NewMirror creates a mirror uploading to the given repository.
+func NewMirror(repo Repository, dryRun bool) *Mirror {
+	return &Mirror{repo: repo, dryRun: dryRun}
+}
+
+// MirrorRelease uploads the assets of the given GitHub release, binaries
+// and checksums, to <product>/<version>/<file>. The assets already
+// mirrored with the same digest aren't downloaded again, and the downloads
+// are verified against the checksum files of the release before being
+// uploaded.
+func (m *Mirror) MirrorRelease(ctx context.Context, client *github.Client, owner, repo, product, tag string) (*Result, error) {
+	release, err := repository.GetReleaseByTag(ctx, client, owner, repo, tag)
+	if err != nil {
+		return nil, err
+	}
+
+	tmpDir, err := os.MkdirTemp("", "release-mirror")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	// the checksum files are downloaded first, the other assets are
+	// checked against them.
+	checksums := make(map[string]string)
+	downloaded := make(map[string]string)
+	for _, asset := range release.Assets {
+		if !checksumsRegex.MatchString(asset.GetName()) {
+			continue
+		}
+		file := filepath.Join(tmpDir, asset.GetName())
+		digest, err := downloadAsset(ctx, client, owner, repo, asset.GetID(), file)
+		if err != nil {
+			return nil, err
+		}
+		downloaded[asset.GetName()] = digest
+
+		f, err := os.Open(file)
+		if err != nil {
+			return nil, err
+		}
+		sums, err := ParseChecksums(f)
+		f.Close()
+		if err != nil {
+			return nil, errors.New("invalid checksums file " + asset.GetName() + ": " + err.Error())
+		}
+		for name, sum := range sums {
+			checksums[name] = sum
+		}
+	}
+
+	var result Result
+	for _, asset := range release.Assets {
+		name := asset.GetName()
+		p := Path(product, tag, name)
+		file := filepath.Join(tmpDir, name)
+
+		expected, ok := downloaded[name]
+		if !ok {
+			expected = checksums[name]
+		}
+
+		mirrored, err := m.repo.Digest(ctx, p)
+		if err != nil {
+			return nil, err
+		}
+		if mirrored != "" && strings.EqualFold(mirrored, expected) {
+			logrus.Infof("skipping %s, already mirrored", p)
+			result.Skipped = append(result.Skipped, p)
+			continue
+		}
+
+		if m.dryRun {
+			logrus.Infof("dry run, would mirror %s", p)
+			continue
+		}
+
+		digest, ok := downloaded[name]
+		if !ok {
+			if digest, err = downloadAsset(ctx, client, owner, repo, asset.GetID(), file); err != nil {
+				return nil, err
+			}
+			if expected != "" && !strings.EqualFold(digest, expected) {
+				return nil, errors.New("sha256 of " + name + " is " + digest + ", expected " + expected + " per the release checksums")
+			}
+			// assets without checksum are only known once downloaded.
+			if mirrored != "" && strings.EqualFold(mirrored, digest) {
+				logrus.Infof("skipping %s, already mirrored", p)
+				result.Skipped = append(result.Skipped, p)
+				if err := os.Remove(file); err != nil {
+					return nil, err
+				}
+				continue
+			}
+		}
+
+		logrus.Infof("mirroring %s", p)
+		if err := m.upload(ctx, p, file, digest); err != nil {
+			return nil, err
+		}
+		result.Uploaded = append(result.Uploaded, p)
+
+		if err := os.Remove(file); err != nil {
+			return nil, err
+		}
+	}
+
+	return &result, nil
+}
+
+func (m *Mirror) upload(ctx context.Context, p, file, digest string) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return m.repo.Upload(ctx, p, f, digest)
+}
+
+// ParseChecksums parses a sha256sum file into the digests of its files, by
+// name.
+func ParseChecksums(r io.Reader) (map[string]string, error) {
+	checksums := make(map[string]string)
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 2 || len(fields[0]) != sha256.Size*2 {
+			return nil, errors.New("invalid line: " + scanner.Text())
+		}
+		checksums[filepath.Base(strings.TrimPrefix(fields[1], "*"))] = strings.ToLower(fields[0])
+	}
+
+	return checksums, scanner.Err()
+}
+
+// downloadAsset downloads the given release asset to the given file and
+// returns its sha256 digest.
+func downloadAsset(ctx context.Context, client *github.Client, owner, repo string, id int64, file string) (string, error) {
+	rc, _, err := client.Repositories.DownloadReleaseAsset(ctx, owner, repo, id, http.DefaultClient)
+	if err != nil {
+		return "", err
+	}
+	defer rc.Close()
+
+	f, err := os.Create(file)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(f, h), rc); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), f.Close()
+}
@@ -0,0 +1,158 @@
+package mirror
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/google/go-github/v39/github"
+)
+
+func sha(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestParseChecksums(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    map[string]string
+		wantErr bool
+	}{
+		{
+			name:    "sha256sum",
+			content: sha("a") + "  rke2.linux-amd64.tar.gz\n\n" + strings.ToUpper(sha("b")) + " *dist/artifacts/rke2-images.linux-amd64.txt\n",
+			want: map[string]string{
+				"rke2.linux-amd64.tar.gz":     sha("a"),
+				"rke2-images.linux-amd64.txt": sha("b"),
+			},
+		},
+		{name: "invalid digest", content: "abc  rke2.linux-amd64.tar.gz\n", wantErr: true},
+		{name: "missing name", content: sha("a") + "\n", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseChecksums(strings.NewReader(tt.content))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseChecksums() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseChecksums() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// fakeArtifactory serves the assets of a GitHub release and an Artifactory
+// repository storing the uploads in memory.
+type fakeArtifactory struct {
+	mu      sync.Mutex
+	assets  map[int64]string
+	stored  map[string]string
+	uploads []string
+}
+
+func (f *fakeArtifactory) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch {
+	case r.URL.Path == "/repos/rancher/rke2/releases/tags/v1.29.2+rke2r1":
+		release := github.RepositoryRelease{TagName: github.String("v1.29.2+rke2r1")}
+		for _, id := range []int64{1, 2, 3} {
+			name := map[int64]string{1: "sha256sum-amd64.txt", 2: "rke2.linux-amd64.tar.gz", 3: "rke2-images.linux-amd64.tar.zst"}[id]
+			release.Assets = append(release.Assets, &github.ReleaseAsset{ID: github.Int64(id), Name: github.String(name)})
+		}
+		json.NewEncoder(w).Encode(release)
+	case strings.HasPrefix(r.URL.Path, "/repos/rancher/rke2/releases/assets/"):
+		id := map[string]int64{"1": 1, "2": 2, "3": 3}[strings.TrimPrefix(r.URL.Path, "/repos/rancher/rke2/releases/assets/")]
+		io.WriteString(w, f.assets[id])
+	case strings.HasPrefix(r.URL.Path, "/artifactory/generic/"):
+		p, _ := url.PathUnescape(strings.TrimPrefix(r.URL.EscapedPath(), "/artifactory/generic/"))
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.Method == http.MethodPut {
+			b, _ := io.ReadAll(r.Body)
+			if sha(string(b)) != r.Header.Get("X-Checksum-Sha256") {
+				w.WriteHeader(http.StatusConflict)
+				return
+			}
+			f.stored[p] = string(b)
+			f.uploads = append(f.uploads, p)
+			w.WriteHeader(http.StatusCreated)
+			return
+		}
+		content, ok := f.stored[p]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("X-Checksum-Sha256", sha(content))
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestMirrorRelease(t *testing.T) {
+	fake := &fakeArtifactory{
+		assets: map[int64]string{
+			2: "rke2 binary",
+			3: "rke2 images",
+		},
+		stored: map[string]string{
+			"rke2/v1.29.2+rke2r1/rke2-images.linux-amd64.tar.zst": "rke2 images",
+		},
+	}
+	fake.assets[1] = sha(fake.assets[2]) + "  rke2.linux-amd64.tar.gz\n" + sha(fake.assets[3]) + "  rke2-images.linux-amd64.tar.zst\n"
+
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	client := github.NewClient(nil)
+	client.BaseURL, _ = url.Parse(server.URL + "/")
+
+	repo, err := New(Config{Type: Artifactory, URL: server.URL + "/artifactory", Repository: "generic", Token: "token"}, server.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := NewMirror(repo, false).MirrorRelease(context.Background(), client, "rancher", "rke2", "rke2", "v1.29.2+rke2r1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantUploaded := []string{"rke2/v1.29.2+rke2r1/sha256sum-amd64.txt", "rke2/v1.29.2+rke2r1/rke2.linux-amd64.tar.gz"}
+	if !reflect.DeepEqual(result.Uploaded, wantUploaded) {
+		t.Errorf("Uploaded = %v, want %v", result.Uploaded, wantUploaded)
+	}
+	if want := []string{"rke2/v1.29.2+rke2r1/rke2-images.linux-amd64.tar.zst"}; !reflect.DeepEqual(result.Skipped, want) {
+		t.Errorf("Skipped = %v, want %v", result.Skipped, want)
+	}
+
+	// everything is mirrored, a second run uploads nothing.
+	result, err = NewMirror(repo, false).MirrorRelease(context.Background(), client, "rancher", "rke2", "rke2", "v1.29.2+rke2r1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Uploaded) != 0 || len(result.Skipped) != 3 {
+		t.Errorf("result = %+v, want every asset skipped", result)
+	}
+
+	// a corrupted download isn't uploaded.
+	fake.assets[2] = "corrupted"
+	delete(fake.stored, "rke2/v1.29.2+rke2r1/rke2.linux-amd64.tar.gz")
+	if _, err := NewMirror(repo, false).MirrorRelease(context.Background(), client, "rancher", "rke2", "rke2", "v1.29.2+rke2r1"); err == nil {
+		t.Error("expected an error for an asset not matching the release checksums")
+	}
+}
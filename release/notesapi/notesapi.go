@@ -0,0 +1,267 @@
+package notesapi
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rancher/ecm-distro-tools/release"
+	"github.com/rancher/ecm-distro-tools/repository"
+)
+
+// maxRequestSize is the maximum size of a request body, requests only
+// identify the notes to generate.
+const maxRequestSize = 64 << 10
+
+// Generator collects the data of release notes, implemented by
+// *release.Client.
+type Generator interface {
+	NotesSnapshot(ctx context.Context, opts release.NotesOptions) (*release.ReleaseNotesSnapshot, error)
+}
+
+// Options configures a Server.
+type Options struct {
+	// Owners maps the repos notes can be generated for to their GitHub
+	// organization, e.g. rke2 to rancher.
+	Owners map[string]string
+	// Token, if set, must be sent by the clients as a bearer token.
+	Token string
+	// Locale returns the locale of the notes of the given repo when the
+	// request doesn't set one. DefaultLocale is used if nil.
+	Locale func(repo string) string
+	// MaxConcurrent limits the notes generated at the same time, since each
+	// one makes many GitHub requests. 0 means no limit.
+	MaxConcurrent int
+}
+
+// Server exposes release notes generation over HTTP so bots, e.g. a Slack
+// slash command, and web UIs can generate notes without the release binary.
+type Server struct {
+	generator Generator
+	opts      Options
+	slots     chan struct{}
+}
+
+// NewServer creates a server generating notes with the given generator.
+func NewServer(generator Generator, opts Options) *Server {
+	s := &Server{generator: generator, opts: opts}
+	if opts.MaxConcurrent > 0 {
+		s.slots = make(chan struct{}, opts.MaxConcurrent)
+	}
+
+	return s
+}
+
+// Request identifies the release notes to generate.
+type Request struct {
+	Repo          string `json:"repo"`
+	Milestone     string `json:"milestone"`
+	PrevMilestone string `json:"prevMilestone"`
+	// PullRequests, if set, are the pull requests the changelog is made of
+	// instead of the ones merged between the milestones.
+	PullRequests []int `json:"pullRequests,omitempty"`
+	FromImages   bool  `json:"fromImages,omitempty"`
+	// Locale, and the publish and end of life dates in YYYY-MM-DD format,
+	// of the dates mentioned by the notes.
+	Locale      string `json:"locale,omitempty"`
+	PublishDate string `json:"publishDate,omitempty"`
+	EOLDate     string `json:"eolDate,omitempty"`
+}
+
+// Response contains the generated release notes, returned when JSON is
+// requested.
+type Response struct {
+	Repo          string `json:"repo"`
+	Milestone     string `json:"milestone"`
+	PrevMilestone string `json:"prevMilestone"`
+	Markdown      string `json:"markdown"`
+	// Snapshot can be rendered again, e.g. with the
+	// release-notes-from-snapshot command, without querying GitHub.
+	Snapshot *release.ReleaseNotesSnapshot `json:"snapshot"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// Handler returns the HTTP handler of the server:
+//
+//	POST /notes   generates the notes of the Request in the body, returned
+//	              as markdown, or as a Response if the format query
+//	              parameter is json or the client accepts application/json
+//	GET  /repos   lists the repos notes can be generated for
+//	GET  /healthz reports the server is up
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/notes", s.authorized(s.notes))
+	mux.HandleFunc("/repos", s.authorized(s.repos))
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	})
+
+	return mux
+}
+
+// authorized rejects the requests without the configured token.
+func (s *Server) authorized(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.opts.Token != "" {
+			token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(s.opts.Token)) != 1 {
+				writeError(w, http.StatusUnauthorized, errors.New("missing or invalid bearer token"))
+				return
+			}
+		}
+
+		next(w, r)
+	}
+}
+
+func (s *Server) repos(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, errors.New("method not allowed: "+r.Method))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, s.Repos())
+}
+
+// Repos returns the repos notes can be generated for, sorted.
+func (s *Server) Repos() []string {
+	repos := make([]string, 0, len(s.opts.Owners))
+	for repo := range s.opts.Owners {
+		repos = append(repos, repo)
+	}
+	sort.Strings(repos)
+
+	return repos
+}
+
+func (s *Server) notes(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, errors.New("method not allowed: "+r.Method))
+		return
+	}
+
+	var req Request
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestSize))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, errors.New("invalid request: "+err.Error()))
+		return
+	}
+
+	opts, err := s.notesOptions(req)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if s.slots != nil {
+		select {
+		case s.slots <- struct{}{}:
+			defer func() { <-s.slots }()
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	snapshot, err := s.generator.NotesSnapshot(r.Context(), opts)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, repository.ErrNotFound) {
+			status = http.StatusNotFound
+		}
+		writeError(w, status, err)
+		return
+	}
+
+	notes, err := release.RenderReleaseNotes(snapshot)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if !wantsJSON(r) {
+		w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+		w.Write(notes.Bytes())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, Response{
+		Repo:          snapshot.Repo,
+		Milestone:     snapshot.Milestone,
+		PrevMilestone: snapshot.PrevMilestone,
+		Markdown:      notes.String(),
+		Snapshot:      snapshot,
+	})
+}
+
+// notesOptions validates the request and returns the options of the notes
+// it identifies.
+func (s *Server) notesOptions(req Request) (release.NotesOptions, error) {
+	owner, ok := s.opts.Owners[req.Repo]
+	if !ok {
+		return release.NotesOptions{}, errors.New("invalid repo: " + req.Repo + ", expected one of: " + strings.Join(s.Repos(), ", "))
+	}
+	if req.Milestone == "" || req.PrevMilestone == "" {
+		return release.NotesOptions{}, errors.New("milestone and prevMilestone are required")
+	}
+
+	dates := release.NotesDates{Locale: req.Locale}
+	if dates.Locale == "" && s.opts.Locale != nil {
+		dates.Locale = s.opts.Locale(req.Repo)
+	}
+	if dates.Locale != "" {
+		if err := release.ValidateLocale(dates.Locale); err != nil {
+			return release.NotesOptions{}, err
+		}
+	}
+
+	var err error
+	if req.PublishDate != "" {
+		if dates.PublishDate, err = time.Parse(time.DateOnly, req.PublishDate); err != nil {
+			return release.NotesOptions{}, errors.New("invalid publishDate, expected YYYY-MM-DD: " + err.Error())
+		}
+	}
+	if req.EOLDate != "" {
+		if dates.EOLDate, err = time.Parse(time.DateOnly, req.EOLDate); err != nil {
+			return release.NotesOptions{}, errors.New("invalid eolDate, expected YYYY-MM-DD: " + err.Error())
+		}
+	}
+
+	return release.NotesOptions{
+		Owner:         owner,
+		Repo:          req.Repo,
+		Milestone:     req.Milestone,
+		PrevMilestone: req.PrevMilestone,
+		FromImages:    req.FromImages,
+		PullRequests:  req.PullRequests,
+		NotesDates:    dates,
+	}, nil
+}
+
+// wantsJSON returns true if the client asked for a JSON response rather
+// than markdown.
+func wantsJSON(r *http.Request) bool {
+	if format := r.URL.Query().Get("format"); format != "" {
+		return format == "json"
+	}
+
+	return strings.Contains(r.Header.Get("Accept"), "application/json")
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, errorResponse{Error: err.Error()})
+}
@@ -0,0 +1,193 @@
+package notesapi
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/rancher/ecm-distro-tools/release"
+	"github.com/rancher/ecm-distro-tools/repository"
+)
+
+type fakeGenerator struct {
+	opts release.NotesOptions
+}
+
+func (f *fakeGenerator) NotesSnapshot(ctx context.Context, opts release.NotesOptions) (*release.ReleaseNotesSnapshot, error) {
+	f.opts = opts
+	if opts.Milestone == "v9.9.9" {
+		return nil, &repository.GithubError{Owner: opts.Owner, Repo: opts.Repo, Ref: opts.Milestone, Err: repository.ErrNotFound}
+	}
+
+	data := `{"Milestone":"` + opts.Milestone + `","ChangeLogData":{"PrevMilestone":"` + opts.PrevMilestone + `","Content":[{"Title":"Fix login","Number":42,"URL":"https://github.com/rancher/cli/pull/42"}]}}`
+
+	return &release.ReleaseNotesSnapshot{
+		Repo:          opts.Repo,
+		Milestone:     opts.Milestone,
+		PrevMilestone: opts.PrevMilestone,
+		Data:          json.RawMessage(data),
+	}, nil
+}
+
+func TestServer(t *testing.T) {
+	generator := &fakeGenerator{}
+	s := NewServer(generator, Options{
+		Owners:        map[string]string{"cli": "rancher", "k3s": "k3s-io"},
+		Token:         "secret",
+		Locale:        func(repo string) string { return "de-DE" },
+		MaxConcurrent: 1,
+	})
+	server := httptest.NewServer(s.Handler())
+	defer server.Close()
+
+	tests := []struct {
+		name       string
+		path       string
+		token      string
+		accept     string
+		body       string
+		wantStatus int
+		wantBody   string
+		wantLocale string
+	}{
+		{
+			name:       "markdown",
+			path:       "/notes",
+			token:      "secret",
+			body:       `{"repo":"cli","milestone":"v2.8.1","prevMilestone":"v2.8.0"}`,
+			wantStatus: http.StatusOK,
+			wantBody:   "Fix login",
+			wantLocale: "de-DE",
+		},
+		{
+			name:       "json",
+			path:       "/notes?format=json",
+			token:      "secret",
+			body:       `{"repo":"cli","milestone":"v2.8.1","prevMilestone":"v2.8.0","locale":"fr-FR","publishDate":"2024-03-01"}`,
+			wantStatus: http.StatusOK,
+			wantBody:   `"prevMilestone":"v2.8.0","markdown":`,
+			wantLocale: "fr-FR",
+		},
+		{
+			name:       "json accepted",
+			path:       "/notes",
+			token:      "secret",
+			accept:     "application/json",
+			body:       `{"repo":"cli","milestone":"v2.8.1","prevMilestone":"v2.8.0"}`,
+			wantStatus: http.StatusOK,
+			wantBody:   `"snapshot":{"repo":"cli"`,
+		},
+		{
+			name:       "unauthorized",
+			path:       "/notes",
+			token:      "wrong",
+			body:       `{"repo":"cli","milestone":"v2.8.1","prevMilestone":"v2.8.0"}`,
+			wantStatus: http.StatusUnauthorized,
+			wantBody:   "missing or invalid bearer token",
+		},
+		{
+			name:       "invalid repo",
+			path:       "/notes",
+			token:      "secret",
+			body:       `{"repo":"kubernetes","milestone":"v1.29.2","prevMilestone":"v1.29.1"}`,
+			wantStatus: http.StatusBadRequest,
+			wantBody:   "expected one of: cli, k3s",
+		},
+		{
+			name:       "missing milestone",
+			path:       "/notes",
+			token:      "secret",
+			body:       `{"repo":"cli","milestone":"v2.8.1"}`,
+			wantStatus: http.StatusBadRequest,
+			wantBody:   "milestone and prevMilestone are required",
+		},
+		{
+			name:       "unknown field",
+			path:       "/notes",
+			token:      "secret",
+			body:       `{"repo":"cli","milestone":"v2.8.1","prevMilestone":"v2.8.0","prev":"v2.8.0"}`,
+			wantStatus: http.StatusBadRequest,
+			wantBody:   "invalid request",
+		},
+		{
+			name:       "invalid date",
+			path:       "/notes",
+			token:      "secret",
+			body:       `{"repo":"cli","milestone":"v2.8.1","prevMilestone":"v2.8.0","eolDate":"03/01/2024"}`,
+			wantStatus: http.StatusBadRequest,
+			wantBody:   "invalid eolDate",
+		},
+		{
+			name:       "not found",
+			path:       "/notes",
+			token:      "secret",
+			body:       `{"repo":"cli","milestone":"v9.9.9","prevMilestone":"v2.8.0"}`,
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "repos",
+			path:       "/repos",
+			token:      "secret",
+			wantStatus: http.StatusMethodNotAllowed,
+			body:       `{}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			generator.opts = release.NotesOptions{}
+
+			req, err := http.NewRequest(http.MethodPost, server.URL+tt.path, strings.NewReader(tt.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+
+			res, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+
+			body, err := io.ReadAll(res.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res.StatusCode != tt.wantStatus {
+				t.Errorf("status = %d, want %d, body: %s", res.StatusCode, tt.wantStatus, body)
+			}
+			if !strings.Contains(string(body), tt.wantBody) {
+				t.Errorf("body = %s, want it to contain %q", body, tt.wantBody)
+			}
+			if tt.wantLocale != "" && generator.opts.Locale != tt.wantLocale {
+				t.Errorf("locale = %q, want %q", generator.opts.Locale, tt.wantLocale)
+			}
+		})
+	}
+
+	req, err := http.NewRequest(http.MethodGet, server.URL+"/repos", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Bearer secret")
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+
+	var repos []string
+	if err := json.NewDecoder(res.Body).Decode(&repos); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(repos, ",") != "cli,k3s" {
+		t.Errorf("repos = %v, want [cli k3s]", repos)
+	}
+}
@@ -0,0 +1,219 @@
+package obs
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// DefaultURL is the API of the public Open Build Service.
+const DefaultURL = "https://api.opensuse.org"
+
+// Config configures the Open Build Service instance some of the k3s and
+// rke2 rpms are built in.
+type Config struct {
+	// URL of the API, DefaultURL if empty.
+	URL      string `json:"url,omitempty"`
+	Username string `json:"username"`
+	Password string `json:"password"`
+	// Packages maps the products, k3s and rke2, to the package their rpms
+	// are built from.
+	Packages map[string]Package `json:"packages"`
+}
+
+// Package is an OBS package, e.g. rke2 in isv:Rancher:RKE2:stable.
+type Package struct {
+	Project string `json:"project"`
+	Name    string `json:"name"`
+}
+
+// Validate checks the URL and that the packages are located.
+func (c *Config) Validate() error {
+	if c.URL != "" {
+		if _, err := url.ParseRequestURI(c.URL); err != nil {
+			return errors.New("invalid obs url: " + c.URL)
+		}
+	}
+	for product, pkg := range c.Packages {
+		if pkg.Project == "" || pkg.Name == "" {
+			return errors.New("missing obs project or package name of " + product)
+		}
+	}
+
+	return nil
+}
+
+// Codes of the build results that don't need a build: the package is
+// disabled or excluded for the repository and architecture.
+var skippedCodes = map[string]bool{
+	"disabled": true,
+	"excluded": true,
+}
+
+// Result is the build result of a package for a repository and
+// architecture, e.g. SLE_15 x86_64.
+type Result struct {
+	Repository string `json:"repository"`
+	Arch       string `json:"arch"`
+	// Code is the build status, e.g. succeeded, failed, unresolvable or
+	// building.
+	Code string `json:"code"`
+	// Dirty results are outdated, a new build is scheduled.
+	Dirty bool `json:"dirty,omitempty"`
+}
+
+// OK returns true if the package is built from its latest sources, or
+// isn't built for the repository and architecture.
+func (r Result) OK() bool {
+	return (r.Code == "succeeded" && !r.Dirty) || skippedCodes[r.Code]
+}
+
+// BuildStatus is the build status of the rpms of a release.
+type BuildStatus struct {
+	Package
+	// Version is the version of the latest sources of the package, and
+	// Expected the one of the release, e.g. 1.29.2~rke2r1.
+	Version  string   `json:"version"`
+	Expected string   `json:"expected"`
+	Revision string   `json:"revision"`
+	Results  []Result `json:"results"`
+}
+
+// Problems returns what's missing for the rpms of the release to be built:
+// the sources of the package being at another version, or builds that
+// failed or are pending.
+func (b *BuildStatus) Problems() []string {
+	if b.Version != b.Expected {
+		return []string{"rpm sources at " + b.Version + " instead of " + b.Expected}
+	}
+
+	var problems []string
+	for _, r := range b.Results {
+		if r.OK() {
+			continue
+		}
+		code := r.Code
+		if r.Dirty {
+			code += ", outdated"
+		}
+		problems = append(problems, "rpm "+r.Repository+"/"+r.Arch+" "+code)
+	}
+
+	return problems
+}
+
+// Version returns the rpm version of the given release tag, e.g. 1.29.2~rke2r1
+// for v1.29.2+rke2r1 or 1.29.2~rc1~rke2r1 for v1.29.2-rc1+rke2r1, as rpm
+// versions can't contain dashes.
+func Version(tag string) string {
+	return strings.NewReplacer("-", "~", "+", "~").Replace(strings.TrimPrefix(tag, "v"))
+}
+
+// Client is a client of the OBS API.
+type Client struct {
+	config Config
+	client *http.Client
+}
+
+// NewClient creates a client of the OBS instance of the given config.
+func NewClient(cfg Config, client *http.Client) *Client {
+	if cfg.URL == "" {
+		cfg.URL = DefaultURL
+	}
+
+	return &Client{config: cfg, client: client}
+}
+
+// Package returns the package of the given product, and false if none is
+// configured.
+func (c *Client) Package(product string) (Package, bool) {
+	pkg, ok := c.config.Packages[product]
+	return pkg, ok
+}
+
+// BuildStatus returns the build results of the given package, per
+// repository and architecture, along with the version of its latest
+// sources, to be compared with the one of the given release tag.
+func (c *Client) BuildStatus(ctx context.Context, pkg Package, tag string) (*BuildStatus, error) {
+	b := BuildStatus{Package: pkg, Expected: Version(tag)}
+
+	var history struct {
+		Revisions []struct {
+			Rev     string `xml:"rev,attr"`
+			Version string `xml:"version"`
+		} `xml:"revision"`
+	}
+	if err := c.get(ctx, "/source/"+url.PathEscape(pkg.Project)+"/"+url.PathEscape(pkg.Name)+"/_history", nil, &history); err != nil {
+		return nil, err
+	}
+	if len(history.Revisions) > 0 {
+		latest := history.Revisions[len(history.Revisions)-1]
+		b.Revision, b.Version = latest.Rev, latest.Version
+	}
+
+	var results struct {
+		Results []struct {
+			Repository string `xml:"repository,attr"`
+			Arch       string `xml:"arch,attr"`
+			Dirty      bool   `xml:"dirty,attr"`
+			Statuses   []struct {
+				Package string `xml:"package,attr"`
+				Code    string `xml:"code,attr"`
+			} `xml:"status"`
+		} `xml:"result"`
+	}
+	query := url.Values{"package": {pkg.Name}}
+	if err := c.get(ctx, "/build/"+url.PathEscape(pkg.Project)+"/_result", query, &results); err != nil {
+		return nil, err
+	}
+	for _, result := range results.Results {
+		for _, status := range result.Statuses {
+			if status.Package != pkg.Name {
+				continue
+			}
+			b.Results = append(b.Results, Result{Repository: result.Repository, Arch: result.Arch, Code: status.Code, Dirty: result.Dirty})
+		}
+	}
+	sort.Slice(b.Results, func(i, j int) bool {
+		if b.Results[i].Repository != b.Results[j].Repository {
+			return b.Results[i].Repository < b.Results[j].Repository
+		}
+		return b.Results[i].Arch < b.Results[j].Arch
+	})
+
+	return &b, nil
+}
+
+// get decodes the XML response of the given API path into v.
+func (c *Client) get(ctx context.Context, path string, query url.Values, v interface{}) error {
+	u := strings.TrimSuffix(c.config.URL, "/") + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	if c.config.Username != "" {
+		req.SetBasicAuth(c.config.Username, c.config.Password)
+	}
+
+	res, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		b, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+		return errors.New("obs: " + res.Status + " when fetching " + u + ": " + strings.TrimSpace(string(b)))
+	}
+
+	return xml.NewDecoder(res.Body).Decode(v)
+}
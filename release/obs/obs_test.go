@@ -0,0 +1,94 @@
+package obs
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestBuildStatus(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/source/isv:Rancher:RKE2/rke2/_history", func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, ok := r.BasicAuth(); !ok || user != "bot" || pass != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprint(w, `<revisionlist>
+			<revision rev="41"><version>1.29.1~rke2r1</version></revision>
+			<revision rev="42"><version>1.29.2~rke2r1</version></revision>
+		</revisionlist>`)
+	})
+	mux.HandleFunc("/build/isv:Rancher:RKE2/_result", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("package") != "rke2" {
+			t.Errorf("package = %q, want rke2", r.URL.Query().Get("package"))
+		}
+		fmt.Fprint(w, `<resultlist>
+			<result repository="SLE_15" arch="x86_64" code="published" state="published">
+				<status package="rke2" code="succeeded"/>
+			</result>
+			<result repository="SLE_15" arch="aarch64" code="building" state="building" dirty="true">
+				<status package="rke2" code="succeeded"/>
+			</result>
+			<result repository="SLE_15" arch="s390x" code="published" state="published">
+				<status package="rke2" code="excluded"/>
+			</result>
+			<result repository="SLE_12" arch="x86_64" code="published" state="published">
+				<status package="rke2" code="failed"/>
+			</result>
+		</resultlist>`)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := NewClient(Config{URL: server.URL, Username: "bot", Password: "secret"}, server.Client())
+	pkg := Package{Project: "isv:Rancher:RKE2", Name: "rke2"}
+
+	tests := []struct {
+		tag  string
+		want []string
+	}{
+		{
+			tag:  "v1.29.2+rke2r1",
+			want: []string{"rpm SLE_12/x86_64 failed", "rpm SLE_15/aarch64 succeeded, outdated"},
+		},
+		{
+			tag:  "v1.29.3+rke2r1",
+			want: []string{"rpm sources at 1.29.2~rke2r1 instead of 1.29.3~rke2r1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.tag, func(t *testing.T) {
+			b, err := client.BuildStatus(context.Background(), pkg, tt.tag)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if b.Revision != "42" || len(b.Results) != 4 {
+				t.Errorf("BuildStatus() = revision %s, %d results, want revision 42, 4 results", b.Revision, len(b.Results))
+			}
+			if got := b.Problems(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Problems() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	unauthorized := NewClient(Config{URL: server.URL}, server.Client())
+	if _, err := unauthorized.BuildStatus(context.Background(), pkg, "v1.29.2+rke2r1"); err == nil {
+		t.Error("BuildStatus() without credentials succeeded, want an error")
+	}
+}
+
+func TestVersion(t *testing.T) {
+	tests := map[string]string{
+		"v1.29.2+rke2r1":     "1.29.2~rke2r1",
+		"v1.29.2-rc1+rke2r1": "1.29.2~rc1~rke2r1",
+		"v1.29.2+k3s1":       "1.29.2~k3s1",
+	}
+	for tag, want := range tests {
+		if got := Version(tag); got != want {
+			t.Errorf("Version(%q) = %q, want %q", tag, got, want)
+		}
+	}
+}
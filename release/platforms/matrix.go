@@ -0,0 +1,138 @@
+package platforms
+
+import (
+	"errors"
+	"sort"
+	"strings"
+
+	reg "github.com/rancher/ecm-distro-tools/registry"
+	"golang.org/x/mod/semver"
+)
+
+// The products whose images are checked besides k3s and rke2: the golang
+// alpine images image-build-base is built from, and the hardened images
+// referenced by rke2.
+const (
+	ImageBuildBase = "image-build-base"
+	Hardened       = "hardened"
+)
+
+var (
+	linuxAmd64   = reg.Platform{OS: "linux", Architecture: "amd64"}
+	linuxArm64   = reg.Platform{OS: "linux", Architecture: "arm64"}
+	linuxArm     = reg.Platform{OS: "linux", Architecture: "arm"}
+	linuxS390x   = reg.Platform{OS: "linux", Architecture: "s390x"}
+	windowsAmd64 = reg.Platform{OS: "windows", Architecture: "amd64"}
+)
+
+// DefaultPlatforms are the platforms the images of each product are
+// released for, unless configured otherwise.
+var DefaultPlatforms = map[string][]reg.Platform{
+	"k3s":          {linuxAmd64, linuxArm64, linuxArm},
+	"rke2":         {linuxAmd64, linuxArm64, windowsAmd64},
+	ImageBuildBase: {linuxAmd64, linuxArm64, linuxS390x},
+	Hardened:       {linuxAmd64, linuxArm64},
+}
+
+// Set is the platforms of a product, e.g. linux/amd64, by release line.
+type Set struct {
+	Default []string `json:"default,omitempty"`
+	// Minors overrides Default for the given release lines, e.g. v1.29, as
+	// s390x is only built on some rke2 lines.
+	Minors map[string][]string `json:"minors,omitempty"`
+}
+
+// Matrix maps products to their platform sets. The products it doesn't
+// configure use DefaultPlatforms.
+type Matrix map[string]Set
+
+// Validate checks that every platform is in os/arch form and that the
+// release lines are vMAJOR.MINOR versions.
+func (m Matrix) Validate() error {
+	for product, set := range m {
+		if _, err := parsePlatforms(set.Default); err != nil {
+			return errors.New(product + ": " + err.Error())
+		}
+		for minor, platforms := range set.Minors {
+			if semver.MajorMinor(minor) != minor {
+				return errors.New(product + ": invalid release line " + minor + ", expected e.g. v1.29")
+			}
+			if _, err := parsePlatforms(platforms); err != nil {
+				return errors.New(product + " " + minor + ": " + err.Error())
+			}
+		}
+	}
+
+	return nil
+}
+
+// For returns the platforms of the given product for the release line of
+// the given version, e.g. v1.29.2+rke2r1, 1.22.5 or release-1.29. Products
+// or lines without platforms configured use the product's set default, and
+// then DefaultPlatforms.
+func (m Matrix) For(product, version string) ([]reg.Platform, error) {
+	set, ok := m[product]
+	if platforms, ok := set.Minors[releaseLine(version)]; ok {
+		return parsePlatforms(platforms)
+	}
+	if ok && len(set.Default) > 0 {
+		return parsePlatforms(set.Default)
+	}
+
+	platforms, ok := DefaultPlatforms[product]
+	if !ok {
+		return nil, errors.New("no platforms configured for " + product)
+	}
+
+	return platforms, nil
+}
+
+// releaseLine returns the vMAJOR.MINOR release line of the given version or
+// release branch.
+func releaseLine(version string) string {
+	version = strings.TrimPrefix(version, "release-")
+	if !strings.HasPrefix(version, "v") {
+		version = "v" + version
+	}
+
+	return semver.MajorMinor(version)
+}
+
+// ParsePlatform parses a platform in os/arch form, e.g. linux/amd64.
+func ParsePlatform(platform string) (reg.Platform, error) {
+	os, arch, ok := strings.Cut(platform, "/")
+	if !ok || os == "" || arch == "" || strings.Contains(arch, "/") {
+		return reg.Platform{}, errors.New("invalid platform " + platform + ", expected os/arch, e.g. linux/amd64")
+	}
+
+	return reg.Platform{OS: os, Architecture: arch}, nil
+}
+
+func parsePlatforms(platforms []string) ([]reg.Platform, error) {
+	parsed := make([]reg.Platform, len(platforms))
+	for i, platform := range platforms {
+		p, err := ParsePlatform(platform)
+		if err != nil {
+			return nil, err
+		}
+		parsed[i] = p
+	}
+
+	return parsed, nil
+}
+
+// Architectures returns the distinct architectures of the given platforms,
+// sorted, e.g. for the Docker Hub checks that only know architectures.
+func Architectures(platforms []reg.Platform) []string {
+	seen := make(map[string]bool, len(platforms))
+	var archs []string
+	for _, platform := range platforms {
+		if !seen[platform.Architecture] {
+			seen[platform.Architecture] = true
+			archs = append(archs, platform.Architecture)
+		}
+	}
+	sort.Strings(archs)
+
+	return archs
+}
@@ -0,0 +1,133 @@
+package platforms
+
+import (
+	"reflect"
+	"testing"
+
+	reg "github.com/rancher/ecm-distro-tools/registry"
+)
+
+func TestMatrixFor(t *testing.T) {
+	matrix := Matrix{
+		"rke2": {
+			Minors: map[string][]string{"v1.29": {"linux/amd64", "linux/arm64", "linux/s390x", "windows/amd64"}},
+		},
+		"k3s": {
+			Default: []string{"linux/amd64", "linux/arm64"},
+			Minors:  map[string][]string{"v1.28": {"linux/amd64", "linux/arm64", "linux/arm"}},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		matrix  Matrix
+		product string
+		version string
+		want    []reg.Platform
+		wantErr bool
+	}{
+		{
+			name:    "release line",
+			matrix:  matrix,
+			product: "rke2",
+			version: "v1.29.2+rke2r1",
+			want:    []reg.Platform{linuxAmd64, linuxArm64, linuxS390x, windowsAmd64},
+		},
+		{
+			name:    "release branch",
+			matrix:  matrix,
+			product: "rke2",
+			version: "release-1.29",
+			want:    []reg.Platform{linuxAmd64, linuxArm64, linuxS390x, windowsAmd64},
+		},
+		{
+			name:    "default platforms of the product",
+			matrix:  matrix,
+			product: "rke2",
+			version: "v1.28.7+rke2r1",
+			want:    DefaultPlatforms["rke2"],
+		},
+		{
+			name:    "configured default",
+			matrix:  matrix,
+			product: "k3s",
+			version: "v1.29.2+k3s1",
+			want:    []reg.Platform{linuxAmd64, linuxArm64},
+		},
+		{
+			name:    "configured release line",
+			matrix:  matrix,
+			product: "k3s",
+			version: "v1.28.7+k3s1",
+			want:    []reg.Platform{linuxAmd64, linuxArm64, linuxArm},
+		},
+		{
+			name:    "go version without config",
+			product: ImageBuildBase,
+			version: "1.22.5",
+			want:    []reg.Platform{linuxAmd64, linuxArm64, linuxS390x},
+		},
+		{
+			name:    "unknown product",
+			matrix:  matrix,
+			product: "rancher",
+			version: "v2.8.2",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.matrix.For(tt.product, tt.version)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("For() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("For() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatrixValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		matrix  Matrix
+		wantErr bool
+	}{
+		{
+			name:   "valid",
+			matrix: Matrix{"rke2": {Default: []string{"linux/amd64"}, Minors: map[string][]string{"v1.29": {"linux/s390x"}}}},
+		},
+		{
+			name:    "invalid platform",
+			matrix:  Matrix{"rke2": {Default: []string{"amd64"}}},
+			wantErr: true,
+		},
+		{
+			name:    "invalid release line",
+			matrix:  Matrix{"rke2": {Minors: map[string][]string{"1.29": {"linux/amd64"}}}},
+			wantErr: true,
+		},
+		{
+			name:    "patch release",
+			matrix:  Matrix{"rke2": {Minors: map[string][]string{"v1.29.2": {"linux/amd64"}}}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.matrix.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestArchitectures(t *testing.T) {
+	got := Architectures([]reg.Platform{linuxS390x, linuxAmd64, windowsAmd64, linuxArm64})
+	if want := []string{"amd64", "arm64", "s390x"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Architectures() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,224 @@
+package platforms
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	reg "github.com/rancher/ecm-distro-tools/registry"
+	"golang.org/x/sync/errgroup"
+)
+
+// maxConcurrentLookups limits the manifests fetched at the same time, a
+// release referencing around a hundred images.
+const maxConcurrentLookups = 16
+
+// ExpectedImage is an image referenced by a release and the platforms it
+// must be available for.
+type ExpectedImage struct {
+	Reference name.Reference
+	Platforms []reg.Platform
+}
+
+// imageList returns the release asset listing the images of the given
+// product for the given platform.
+func imageList(product string, platform reg.Platform) (string, error) {
+	switch product {
+	case "k3s":
+		// k3s lists the images of all platforms once.
+		return "k3s-images.txt", nil
+	case "rke2":
+		if platform.OS == "windows" {
+			return "rke2-images.windows-" + platform.Architecture + ".txt", nil
+		}
+		return "rke2-images-all." + platform.OS + "-" + platform.Architecture + ".txt", nil
+	default:
+		return "", errors.New("invalid product: " + product + ", expected one of: k3s, rke2")
+	}
+}
+
+// ReleaseImages returns the images listed by the image lists among the
+// given release assets, each expected for the platforms whose list
+// references it, sorted by reference.
+func ReleaseImages(assets fs.FS, product string, platforms []reg.Platform) ([]ExpectedImage, error) {
+	images := make(map[string]*ExpectedImage)
+	for _, platform := range platforms {
+		list, err := imageList(product, platform)
+		if err != nil {
+			return nil, err
+		}
+
+		refs, err := readImageList(assets, list)
+		if err != nil {
+			return nil, errors.New("failed to read the " + platform.String() + " images list " + list + ": " + err.Error())
+		}
+		for _, ref := range refs {
+			key := ref.Context().RepositoryStr() + ":" + ref.Identifier()
+			image, ok := images[key]
+			if !ok {
+				image = &ExpectedImage{Reference: ref}
+				images[key] = image
+			}
+			image.Platforms = append(image.Platforms, platform)
+		}
+	}
+
+	keys := make([]string, 0, len(images))
+	for key := range images {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	expected := make([]ExpectedImage, len(keys))
+	for i, key := range keys {
+		expected[i] = *images[key]
+	}
+
+	return expected, nil
+}
+
+func readImageList(assets fs.FS, list string) ([]name.Reference, error) {
+	f, err := assets.Open(list)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	b, err := io.ReadAll(f)
+	if err != nil {
+		return nil, err
+	}
+
+	var refs []name.Reference
+	for _, line := range strings.Split(string(b), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		ref, err := name.ParseReference(line)
+		if err != nil {
+			return nil, err
+		}
+		refs = append(refs, ref)
+	}
+
+	return refs, nil
+}
+
+// Availability is the availability of an image in each registry.
+type Availability struct {
+	ExpectedImage
+	// Images contains the image in each registry, and Errs the registries
+	// it couldn't be looked up in, by registry name.
+	Images map[string]reg.Image
+	Errs   map[string]error
+}
+
+// Missing returns the expected platforms the image isn't available for in
+// the given registry.
+func (a Availability) Missing(registry string) []reg.Platform {
+	var missing []reg.Platform
+	for _, platform := range a.Platforms {
+		if !a.Images[registry].Platforms[platform] {
+			missing = append(missing, platform)
+		}
+	}
+
+	return missing
+}
+
+// Complete returns true if the image is available for all its expected
+// platforms in all registries.
+func (a Availability) Complete() bool {
+	if len(a.Errs) > 0 {
+		return false
+	}
+	for registry := range a.Images {
+		if len(a.Missing(registry)) > 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Report is the availability of the images of a release for each
+// platform across registries.
+type Report struct {
+	// Registries and Platforms are the columns of the report, sorted.
+	Registries []string
+	Platforms  []reg.Platform
+	Images     []Availability
+}
+
+// Incomplete returns the number of images missing a platform in a
+// registry.
+func (r Report) Incomplete() int {
+	var incomplete int
+	for _, image := range r.Images {
+		if !image.Complete() {
+			incomplete++
+		}
+	}
+
+	return incomplete
+}
+
+// Check looks up every given image in each of the given registries,
+// indexed by name. Failed lookups are reported per image rather than
+// aborting the report.
+func Check(ctx context.Context, images []ExpectedImage, registries map[string]reg.RegistryClient) Report {
+	report := Report{Images: make([]Availability, len(images))}
+	for registry := range registries {
+		report.Registries = append(report.Registries, registry)
+	}
+	sort.Strings(report.Registries)
+
+	platforms := make(map[reg.Platform]bool)
+	for _, image := range images {
+		for _, platform := range image.Platforms {
+			if !platforms[platform] {
+				platforms[platform] = true
+				report.Platforms = append(report.Platforms, platform)
+			}
+		}
+	}
+	sort.Slice(report.Platforms, func(i, j int) bool {
+		return report.Platforms[i].String() < report.Platforms[j].String()
+	})
+
+	var mu sync.Mutex
+	g := new(errgroup.Group)
+	g.SetLimit(maxConcurrentLookups)
+	for i, image := range images {
+		report.Images[i] = Availability{
+			ExpectedImage: image,
+			Images:        make(map[string]reg.Image, len(registries)),
+			Errs:          make(map[string]error),
+		}
+		for _, registry := range report.Registries {
+			availability, ref, registry := &report.Images[i], image.Reference, registry
+			g.Go(func() error {
+				found, err := registries[registry].Image(ctx, ref)
+
+				mu.Lock()
+				defer mu.Unlock()
+				if err != nil {
+					availability.Errs[registry] = err
+				} else {
+					availability.Images[registry] = found
+				}
+
+				return nil
+			})
+		}
+	}
+	g.Wait()
+
+	return report
+}
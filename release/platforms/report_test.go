@@ -0,0 +1,125 @@
+package platforms
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"testing/fstest"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	reg "github.com/rancher/ecm-distro-tools/registry"
+)
+
+type mockRegistryClient struct {
+	images map[string]reg.Image
+	err    error
+}
+
+func (m *mockRegistryClient) Image(_ context.Context, ref name.Reference) (reg.Image, error) {
+	if m.err != nil {
+		return reg.Image{}, m.err
+	}
+
+	return m.images[ref.Context().RepositoryStr()+":"+ref.Identifier()], nil
+}
+
+func TestReleaseImages(t *testing.T) {
+	assets := fstest.MapFS{
+		"rke2-images-all.linux-amd64.txt": &fstest.MapFile{Data: []byte("docker.io/rancher/rke2-runtime:v1.29.2-rke2r1\ndocker.io/rancher/hardened-etcd:v3.5.9-k3s1-build20240201\n")},
+		"rke2-images-all.linux-arm64.txt": &fstest.MapFile{Data: []byte("docker.io/rancher/rke2-runtime:v1.29.2-rke2r1\n")},
+		"rke2-images.windows-amd64.txt":   &fstest.MapFile{Data: []byte("docker.io/rancher/rke2-runtime:v1.29.2-rke2r1-windows-amd64\n")},
+		"k3s-images.txt":                  &fstest.MapFile{Data: []byte("docker.io/rancher/klipper-helm:v0.8.3-build20240228\n")},
+	}
+
+	tests := []struct {
+		name      string
+		product   string
+		platforms []reg.Platform
+		want      map[string][]reg.Platform
+		wantErr   bool
+	}{
+		{
+			name:      "rke2",
+			product:   "rke2",
+			platforms: DefaultPlatforms["rke2"],
+			want: map[string][]reg.Platform{
+				"rancher/hardened-etcd:v3.5.9-k3s1-build20240201":   {linuxAmd64},
+				"rancher/rke2-runtime:v1.29.2-rke2r1":               {linuxAmd64, linuxArm64},
+				"rancher/rke2-runtime:v1.29.2-rke2r1-windows-amd64": {windowsAmd64},
+			},
+		},
+		{
+			name:      "k3s",
+			product:   "k3s",
+			platforms: DefaultPlatforms["k3s"],
+			want: map[string][]reg.Platform{
+				"rancher/klipper-helm:v0.8.3-build20240228": {linuxAmd64, linuxArm64, linuxArm},
+			},
+		},
+		{
+			name:      "missing list",
+			product:   "rke2",
+			platforms: []reg.Platform{{OS: "linux", Architecture: "s390x"}},
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			images, err := ReleaseImages(assets, tt.product, tt.platforms)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ReleaseImages() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			got := make(map[string][]reg.Platform, len(images))
+			for _, image := range images {
+				got[image.Reference.Context().RepositoryStr()+":"+image.Reference.Identifier()] = image.Platforms
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ReleaseImages() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	runtime := name.MustParseReference("rancher/rke2-runtime:v1.29.2-rke2r1")
+	etcd := name.MustParseReference("rancher/hardened-etcd:v3.5.9-k3s1-build20240201")
+	images := []ExpectedImage{
+		{Reference: etcd, Platforms: []reg.Platform{linuxAmd64, linuxArm64}},
+		{Reference: runtime, Platforms: []reg.Platform{linuxAmd64}},
+	}
+
+	registries := map[string]reg.RegistryClient{
+		"docker.io": &mockRegistryClient{images: map[string]reg.Image{
+			"rancher/rke2-runtime:v1.29.2-rke2r1":             {Exists: true, Platforms: map[reg.Platform]bool{linuxAmd64: true, linuxArm64: true}},
+			"rancher/hardened-etcd:v3.5.9-k3s1-build20240201": {Exists: true, Platforms: map[reg.Platform]bool{linuxAmd64: true}},
+		}},
+		"registry.example.com": &mockRegistryClient{err: errors.New("unauthorized")},
+	}
+
+	report := Check(context.Background(), images, registries)
+
+	if want := []string{"docker.io", "registry.example.com"}; !reflect.DeepEqual(report.Registries, want) {
+		t.Errorf("Registries = %v, want %v", report.Registries, want)
+	}
+	if want := []reg.Platform{linuxAmd64, linuxArm64}; !reflect.DeepEqual(report.Platforms, want) {
+		t.Errorf("Platforms = %v, want %v", report.Platforms, want)
+	}
+	if got := report.Images[0].Missing("docker.io"); !reflect.DeepEqual(got, []reg.Platform{linuxArm64}) {
+		t.Errorf("Missing() = %v, want %v", got, []reg.Platform{linuxArm64})
+	}
+	if got := report.Images[1].Missing("docker.io"); len(got) != 0 {
+		t.Errorf("Missing() = %v, want none", got)
+	}
+	if report.Images[1].Errs["registry.example.com"] == nil {
+		t.Error("expected the lookup error of registry.example.com")
+	}
+	if got := report.Incomplete(); got != 2 {
+		t.Errorf("Incomplete() = %d, want 2", got)
+	}
+}
@@ -0,0 +1,194 @@
+package pretag
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"os/exec"
+	"sort"
+	"strings"
+
+	"github.com/google/go-github/v39/github"
+	"github.com/rancher/ecm-distro-tools/repository"
+)
+
+// States of the checks.
+const (
+	Success = "success"
+	Pending = "pending"
+	Failure = "failure"
+	Missing = "missing"
+)
+
+// Check is a check of the head of a release branch, either a check run,
+// e.g. of a GitHub Actions job, or a commit status, e.g. of Drone.
+type Check struct {
+	Name  string `json:"name"`
+	State string `json:"state"`
+	URL   string `json:"url,omitempty"`
+}
+
+// Result is the verification of the head of a release branch.
+type Result struct {
+	Owner  string `json:"owner"`
+	Repo   string `json:"repo"`
+	Branch string `json:"branch"`
+	SHA    string `json:"sha"`
+	// Required is whether the checks are the required status checks of the
+	// branch protection or, for unprotected branches, all of its checks.
+	Required bool    `json:"required"`
+	Checks   []Check `json:"checks"`
+}
+
+// Failing returns the checks that aren't successful, including the pending
+// and missing ones.
+func (r *Result) Failing() []Check {
+	var failing []Check
+	for _, check := range r.Checks {
+		if check.State != Success {
+			failing = append(failing, check)
+		}
+	}
+
+	return failing
+}
+
+// Err returns an error listing the failing checks, or nil if they are all
+// successful.
+func (r *Result) Err() error {
+	failing := r.Failing()
+	if len(failing) == 0 {
+		return nil
+	}
+
+	names := make([]string, len(failing))
+	for i, check := range failing {
+		names[i] = check.Name + " (" + check.State + ")"
+	}
+
+	return errors.New("the checks of " + r.Owner + "/" + r.Repo + " " + r.Branch + " at " + r.SHA + " aren't green: " + strings.Join(names, ", "))
+}
+
+// Verify returns the checks of the head of the given branch: its required
+// status checks if the branch is protected, or all of its checks otherwise.
+// Required checks that didn't report are missing.
+func Verify(ctx context.Context, client *github.Client, owner, repo, branch string) (*Result, error) {
+	b, _, err := client.Repositories.GetBranch(ctx, owner, repo, branch, true)
+	if err != nil {
+		return nil, repository.WrapGithubError(err, owner, repo, branch)
+	}
+	r := Result{Owner: owner, Repo: repo, Branch: branch, SHA: b.GetCommit().GetSHA()}
+
+	checks, err := headChecks(ctx, client, owner, repo, r.SHA)
+	if err != nil {
+		return nil, err
+	}
+
+	required, res, err := client.Repositories.GetRequiredStatusChecks(ctx, owner, repo, branch)
+	if err != nil && (res == nil || res.StatusCode != http.StatusNotFound) {
+		return nil, repository.WrapGithubError(err, owner, repo, branch)
+	}
+	if required == nil {
+		for _, check := range checks {
+			r.Checks = append(r.Checks, check)
+		}
+	} else {
+		r.Required = true
+		for _, name := range required.Contexts {
+			check, ok := checks[name]
+			if !ok {
+				check = Check{Name: name, State: Missing}
+			}
+			r.Checks = append(r.Checks, check)
+		}
+	}
+	sort.Slice(r.Checks, func(i, j int) bool {
+		return r.Checks[i].Name < r.Checks[j].Name
+	})
+
+	return &r, nil
+}
+
+// headChecks returns the check runs and commit statuses of the given commit
+// by name. The latest check run of a name wins over a commit status of the
+// same name.
+func headChecks(ctx context.Context, client *github.Client, owner, repo, sha string) (map[string]Check, error) {
+	checks := make(map[string]Check)
+
+	statusOpts := &github.ListOptions{PerPage: 100}
+	for {
+		combined, res, err := client.Repositories.GetCombinedStatus(ctx, owner, repo, sha, statusOpts)
+		if err != nil {
+			return nil, repository.WrapGithubError(err, owner, repo, sha)
+		}
+		for _, status := range combined.Statuses {
+			state := Failure
+			switch status.GetState() {
+			case "success":
+				state = Success
+			case "pending":
+				state = Pending
+			}
+			checks[status.GetContext()] = Check{Name: status.GetContext(), State: state, URL: status.GetTargetURL()}
+		}
+		if res.NextPage == 0 {
+			break
+		}
+		statusOpts.Page = res.NextPage
+	}
+
+	runOpts := &github.ListCheckRunsOptions{Filter: github.String("latest"), ListOptions: github.ListOptions{PerPage: 100}}
+	for {
+		runs, res, err := client.Checks.ListCheckRunsForRef(ctx, owner, repo, sha, runOpts)
+		if err != nil {
+			return nil, repository.WrapGithubError(err, owner, repo, sha)
+		}
+		for _, run := range runs.CheckRuns {
+			checks[run.GetName()] = Check{Name: run.GetName(), State: checkRunState(run), URL: run.GetHTMLURL()}
+		}
+		if res.NextPage == 0 {
+			break
+		}
+		runOpts.Page = res.NextPage
+	}
+
+	return checks, nil
+}
+
+// checkRunState returns the state of the given check run. Neutral and
+// skipped runs don't block the tag.
+func checkRunState(run *github.CheckRun) string {
+	if run.GetStatus() != "completed" {
+		return Pending
+	}
+	switch run.GetConclusion() {
+	case "success", "neutral", "skipped":
+		return Success
+	default:
+		return Failure
+	}
+}
+
+// Build runs the containerized build of the given checkout of the repo,
+// make build, after checking that it's at the given commit, writing its
+// output to the given writer.
+func Build(ctx context.Context, dir, sha string, w io.Writer) error {
+	head, err := exec.CommandContext(ctx, "git", "-C", dir, "rev-parse", "HEAD").Output()
+	if err != nil {
+		return errors.New("failed to read the commit of " + dir + ": " + err.Error())
+	}
+	if strings.TrimSpace(string(head)) != sha {
+		return errors.New(dir + " is at " + strings.TrimSpace(string(head)) + " instead of the head of the release branch, " + sha)
+	}
+
+	cmd := exec.CommandContext(ctx, "make", "build")
+	cmd.Dir = dir
+	cmd.Stdout = w
+	cmd.Stderr = w
+	if err := cmd.Run(); err != nil {
+		return errors.New("make build failed in " + dir + ": " + err.Error())
+	}
+
+	return nil
+}
@@ -0,0 +1,90 @@
+package pretag
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v39/github"
+)
+
+func TestVerify(t *testing.T) {
+	mux := http.NewServeMux()
+	for _, branch := range []string{"release-1.29", "master"} {
+		mux.HandleFunc("/repos/k3s-io/k3s/branches/"+branch, func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, `{"commit": {"sha": "abc123"}}`)
+		})
+	}
+	mux.HandleFunc("/repos/k3s-io/k3s/branches/release-1.29/protection/required_status_checks", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"contexts": ["build", "validate", "continuous-integration/drone/push", "e2e"]}`)
+	})
+	mux.HandleFunc("/repos/k3s-io/k3s/branches/master/protection/required_status_checks", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"message": "Branch not protected"}`)
+	})
+	mux.HandleFunc("/repos/k3s-io/k3s/commits/abc123/status", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"statuses": [{"context": "continuous-integration/drone/push", "state": "success"}]}`)
+	})
+	mux.HandleFunc("/repos/k3s-io/k3s/commits/abc123/check-runs", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"check_runs": [
+			{"name": "build", "status": "completed", "conclusion": "success"},
+			{"name": "validate", "status": "in_progress"},
+			{"name": "lint", "status": "completed", "conclusion": "failure"},
+			{"name": "docs", "status": "completed", "conclusion": "skipped"}
+		]}`)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := github.NewClient(nil)
+	client.BaseURL, _ = url.Parse(server.URL + "/")
+
+	tests := []struct {
+		branch       string
+		wantRequired bool
+		want         []string
+	}{
+		{
+			branch:       "release-1.29",
+			wantRequired: true,
+			want:         []string{"build success", "continuous-integration/drone/push success", "e2e missing", "validate pending"},
+		},
+		{
+			branch: "master",
+			want:   []string{"build success", "continuous-integration/drone/push success", "docs success", "lint failure", "validate pending"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.branch, func(t *testing.T) {
+			r, err := Verify(context.Background(), client, "k3s-io", "k3s", tt.branch)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if r.SHA != "abc123" || r.Required != tt.wantRequired {
+				t.Errorf("Verify() = %s, required %t, want abc123, required %t", r.SHA, r.Required, tt.wantRequired)
+			}
+
+			var got []string
+			for _, check := range r.Checks {
+				got = append(got, check.Name+" "+check.State)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Verify() checks = %v, want %v", got, tt.want)
+			}
+
+			if err := r.Err(); err == nil || !strings.Contains(err.Error(), "validate (pending)") {
+				t.Errorf("Err() = %v, want the pending validate check", err)
+			}
+		})
+	}
+
+	green := Result{Checks: []Check{{Name: "build", State: Success}}}
+	if err := green.Err(); err != nil {
+		t.Errorf("Err() = %v, want nil for green checks", err)
+	}
+}
@@ -0,0 +1,185 @@
+package preview
+
+import (
+	"bytes"
+	"encoding/json"
+	"html/template"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/rancher/ecm-distro-tools/release"
+	"github.com/russross/blackfriday/v2"
+)
+
+// Server serves an HTML preview of release notes drafts. The notes are the
+// concatenation of the given files, which are either markdown snippets or
+// JSON snapshots written by the release-notes commands, and are rendered
+// again on every request. The page reloads itself when any file changes.
+type Server struct {
+	files []string
+}
+
+// NewServer creates a new preview server for the given files.
+func NewServer(files []string) *Server {
+	return &Server{files: files}
+}
+
+// Handler returns the HTTP handler of the preview.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", s.page)
+	mux.HandleFunc("/version", s.version)
+	mux.HandleFunc("/notes.md", s.markdown)
+
+	return mux
+}
+
+func (s *Server) page(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+
+	md, err := s.Markdown()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	data := struct {
+		Body    template.HTML
+		Version string
+	}{
+		Body:    render(md),
+		Version: s.Version(),
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := pageTemplate.Execute(w, data); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func (s *Server) markdown(w http.ResponseWriter, r *http.Request) {
+	md, err := s.Markdown()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+	w.Write(md)
+}
+
+func (s *Server) version(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain")
+	w.Write([]byte(s.Version()))
+}
+
+// Version returns a value that changes whenever any of the files changes.
+func (s *Server) Version() string {
+	var latest time.Time
+	var size int64
+	for _, file := range s.files {
+		info, err := os.Stat(file)
+		if err != nil {
+			continue
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+		size += info.Size()
+	}
+
+	return strconv.FormatInt(latest.UnixNano(), 10) + "-" + strconv.FormatInt(size, 10)
+}
+
+// render renders the given notes to HTML. The notes embed the titles and
+// release notes of the pull requests, written by any contributor, so their
+// raw HTML is escaped rather than rendered, and only safe links are kept.
+func render(md []byte) template.HTML {
+	renderer := escapingRenderer{blackfriday.NewHTMLRenderer(blackfriday.HTMLRendererParameters{
+		Flags: blackfriday.CommonHTMLFlags | blackfriday.Safelink,
+	})}
+
+	return template.HTML(blackfriday.Run(md, blackfriday.WithRenderer(renderer)))
+}
+
+// escapingRenderer is an HTML renderer writing the raw HTML of the markdown
+// as text.
+type escapingRenderer struct {
+	*blackfriday.HTMLRenderer
+}
+
+func (r escapingRenderer) RenderNode(w io.Writer, node *blackfriday.Node, entering bool) blackfriday.WalkStatus {
+	switch node.Type {
+	case blackfriday.HTMLBlock, blackfriday.HTMLSpan:
+		template.HTMLEscape(w, node.Literal)
+		return blackfriday.GoToNext
+	}
+
+	return r.HTMLRenderer.RenderNode(w, node, entering)
+}
+
+// Markdown returns the notes, rendering the snapshots among the files.
+func (s *Server) Markdown() ([]byte, error) {
+	var buf bytes.Buffer
+	for i, file := range s.files {
+		b, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+
+		if filepath.Ext(file) == ".json" {
+			var snapshot release.ReleaseNotesSnapshot
+			if err := json.Unmarshal(b, &snapshot); err != nil {
+				return nil, err
+			}
+			notes, err := release.RenderReleaseNotes(&snapshot)
+			if err != nil {
+				return nil, err
+			}
+			b = notes.Bytes()
+		}
+
+		if i > 0 {
+			buf.WriteString("\n")
+		}
+		buf.Write(b)
+	}
+
+	return buf.Bytes(), nil
+}
+
+var pageTemplate = template.Must(template.New("preview").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Release notes preview</title>
+<style>
+body { font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", Helvetica, Arial, sans-serif; max-width: 980px; margin: 2em auto; padding: 0 1em; line-height: 1.5; color: #1f2328; }
+table { border-collapse: collapse; }
+th, td { border: 1px solid #d0d7de; padding: 6px 13px; }
+code { background: #eff1f3; padding: .2em .4em; border-radius: 6px; }
+</style>
+</head>
+<body>
+{{ .Body }}
+<script>
+const version = "{{ .Version }}";
+setInterval(async () => {
+  try {
+    const res = await fetch("/version");
+    if (res.ok && await res.text() !== version) {
+      location.reload();
+    }
+  } catch (e) {}
+}, 1000);
+</script>
+</body>
+</html>
+`))
@@ -0,0 +1,69 @@
+package preview
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestServer(t *testing.T) {
+	dir := t.TempDir()
+	notes := filepath.Join(dir, "notes.md")
+	snippet := filepath.Join(dir, "snippet.md")
+	if err := os.WriteFile(notes, []byte("## Changes since v1.29.2+rke2r1:\n\n* Bump containerd\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(snippet, []byte("**Important Note**\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	s := NewServer([]string{notes, snippet})
+	server := httptest.NewServer(s.Handler())
+	defer server.Close()
+
+	resp, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"<h2>Changes since v1.29.2+rke2r1:</h2>", "<li>Bump containerd</li>", "<strong>Important Note</strong>"} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("expected page to contain %q, got:\n%s", want, body)
+		}
+	}
+
+	before := s.Version()
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(snippet, later, later); err != nil {
+		t.Fatal(err)
+	}
+	if s.Version() == before {
+		t.Error("expected the version to change after a file changed")
+	}
+}
+
+func TestRenderEscapesHTML(t *testing.T) {
+	md := "* Fix the agent <img src=x onerror=alert(1)> (#1234)\n\n<script>alert(1)</script>\n\n[link](javascript:alert(1))\n"
+
+	got := string(render([]byte(md)))
+	for _, unwanted := range []string{"<script>", "<img", "javascript:"} {
+		if strings.Contains(got, unwanted) {
+			t.Errorf("render() = %q, want no %q", got, unwanted)
+		}
+	}
+	for _, want := range []string{"&lt;script&gt;alert(1)&lt;/script&gt;", "&lt;img src=x onerror=alert(1)&gt;"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("render() = %q, want it to contain %q", got, want)
+		}
+	}
+}
@@ -0,0 +1,354 @@
+package project
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/google/go-github/v39/github"
+	"github.com/rancher/ecm-distro-tools/repository"
+)
+
+// statusField is the name of the single select field used as the columns
+// of the board.
+const statusField = "Status"
+
+// Status is a column of the board.
+type Status struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// Board is a GitHub Projects v2 board.
+type Board struct {
+	ID            string
+	Title         string
+	StatusFieldID string
+	Statuses      []Status
+}
+
+// Item is an issue or pull request on a board.
+type Item struct {
+	ID         string
+	Status     string
+	Repository string
+	Number     int
+	Title      string
+	URL        string
+}
+
+const boardQuery = `query($org: String!, $number: Int!) {
+  organization(login: $org) {
+    projectV2(number: $number) {
+      id
+      title
+      field(name: "` + statusField + `") {
+        ... on ProjectV2SingleSelectField {
+          id
+          options { id name }
+        }
+      }
+    }
+  }
+}`
+
+// GetBoard retrieves the board with the given number from the organization.
+func GetBoard(ctx context.Context, client *github.Client, org string, number int) (*Board, error) {
+	var data struct {
+		Organization struct {
+			ProjectV2 *struct {
+				ID    string `json:"id"`
+				Title string `json:"title"`
+				Field *struct {
+					ID      string   `json:"id"`
+					Options []Status `json:"options"`
+				} `json:"field"`
+			} `json:"projectV2"`
+		} `json:"organization"`
+	}
+	vars := map[string]interface{}{"org": org, "number": number}
+	if err := repository.GraphQL(ctx, client, boardQuery, vars, &data); err != nil {
+		return nil, err
+	}
+
+	project := data.Organization.ProjectV2
+	if project == nil {
+		return nil, errors.New("project " + strconv.Itoa(number) + " not found in " + org)
+	}
+	if project.Field == nil || project.Field.ID == "" {
+		return nil, errors.New("project " + project.Title + " doesn't have a " + statusField + " field")
+	}
+
+	return &Board{
+		ID:            project.ID,
+		Title:         project.Title,
+		StatusFieldID: project.Field.ID,
+		Statuses:      project.Field.Options,
+	}, nil
+}
+
+// status returns the column matching the given name, ignoring case.
+func (b *Board) status(name string) (*Status, error) {
+	for _, s := range b.Statuses {
+		if strings.EqualFold(s.Name, name) {
+			return &s, nil
+		}
+	}
+
+	names := make([]string, 0, len(b.Statuses))
+	for _, s := range b.Statuses {
+		names = append(names, s.Name)
+	}
+
+	return nil, errors.New("invalid status " + name + ", expected one of: " + strings.Join(names, ", "))
+}
+
+const addItemMutation = `mutation($project: ID!, $content: ID!) {
+  addProjectV2ItemById(input: {projectId: $project, contentId: $content}) {
+    item { id }
+  }
+}`
+
+// AddItem adds the issue or pull request with the given node ID to the
+// board and returns the ID of its item. Adding content that is already on
+// the board returns the existing item.
+func AddItem(ctx context.Context, client *github.Client, board *Board, contentID string) (string, error) {
+	var data struct {
+		AddProjectV2ItemByID struct {
+			Item struct {
+				ID string `json:"id"`
+			} `json:"item"`
+		} `json:"addProjectV2ItemById"`
+	}
+	vars := map[string]interface{}{"project": board.ID, "content": contentID}
+	if err := repository.GraphQL(ctx, client, addItemMutation, vars, &data); err != nil {
+		return "", err
+	}
+
+	return data.AddProjectV2ItemByID.Item.ID, nil
+}
+
+const setStatusMutation = `mutation($project: ID!, $item: ID!, $field: ID!, $option: String!) {
+  updateProjectV2ItemFieldValue(input: {projectId: $project, itemId: $item, fieldId: $field, value: {singleSelectOptionId: $option}}) {
+    projectV2Item { id }
+  }
+}`
+
+// SetStatus moves the given item to the given column.
+func SetStatus(ctx context.Context, client *github.Client, board *Board, itemID, status string) error {
+	s, err := board.status(status)
+	if err != nil {
+		return err
+	}
+
+	vars := map[string]interface{}{
+		"project": board.ID,
+		"item":    itemID,
+		"field":   board.StatusFieldID,
+		"option":  s.ID,
+	}
+
+	return repository.GraphQL(ctx, client, setStatusMutation, vars, nil)
+}
+
+// MilestoneIssues returns all issues and pull requests of the milestone with
+// the given title.
+func MilestoneIssues(ctx context.Context, client *github.Client, owner, repo, milestone string) ([]*github.Issue, error) {
+	number, err := milestoneNumber(ctx, client, owner, repo, milestone)
+	if err != nil {
+		return nil, err
+	}
+
+	var issues []*github.Issue
+	opts := &github.IssueListByRepoOptions{
+		Milestone:   strconv.Itoa(number),
+		State:       "all",
+		ListOptions: github.ListOptions{PerPage: 100},
+	}
+	for {
+		page, resp, err := client.Issues.ListByRepo(ctx, owner, repo, opts)
+		if err != nil {
+			return nil, repository.WrapGithubError(err, owner, repo, milestone)
+		}
+		issues = append(issues, page...)
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	return issues, nil
+}
+
+func milestoneNumber(ctx context.Context, client *github.Client, owner, repo, milestone string) (int, error) {
+	m, err := FindMilestone(ctx, client, owner, repo, milestone)
+	if err != nil {
+		return 0, err
+	}
+
+	return m.GetNumber(), nil
+}
+
+// FindMilestone returns the milestone of the repo with the given title.
+func FindMilestone(ctx context.Context, client *github.Client, owner, repo, title string) (*github.Milestone, error) {
+	opts := &github.MilestoneListOptions{
+		State:       "all",
+		ListOptions: github.ListOptions{PerPage: 100},
+	}
+	for {
+		milestones, resp, err := client.Issues.ListMilestones(ctx, owner, repo, opts)
+		if err != nil {
+			return nil, repository.WrapGithubError(err, owner, repo, title)
+		}
+		for _, m := range milestones {
+			if m.GetTitle() == title {
+				return m, nil
+			}
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	return nil, errors.New("milestone " + title + " not found in " + owner + "/" + repo)
+}
+
+// AddIssues adds the given issues and pull requests to the board. If status
+// isn't empty, the items are moved to that column.
+func AddIssues(ctx context.Context, client *github.Client, board *Board, issues []*github.Issue, status string) error {
+	if status != "" {
+		if _, err := board.status(status); err != nil {
+			return err
+		}
+	}
+
+	for _, issue := range issues {
+		itemID, err := AddItem(ctx, client, board, issue.GetNodeID())
+		if err != nil {
+			return errors.New("failed to add " + issue.GetHTMLURL() + ": " + err.Error())
+		}
+		if status == "" {
+			continue
+		}
+		if err := SetStatus(ctx, client, board, itemID, status); err != nil {
+			return errors.New("failed to move " + issue.GetHTMLURL() + ": " + err.Error())
+		}
+	}
+
+	return nil
+}
+
+const itemsQuery = `query($id: ID!, $cursor: String) {
+  node(id: $id) {
+    ... on ProjectV2 {
+      items(first: 100, after: $cursor) {
+        pageInfo { hasNextPage endCursor }
+        nodes {
+          id
+          fieldValueByName(name: "` + statusField + `") {
+            ... on ProjectV2ItemFieldSingleSelectValue { name }
+          }
+          content {
+            ... on Issue { number title url repository { nameWithOwner } }
+            ... on PullRequest { number title url repository { nameWithOwner } }
+          }
+        }
+      }
+    }
+  }
+}`
+
+// Items returns all items on the board.
+func Items(ctx context.Context, client *github.Client, board *Board) ([]Item, error) {
+	var items []Item
+
+	vars := map[string]interface{}{"id": board.ID}
+	for {
+		var data struct {
+			Node struct {
+				Items struct {
+					PageInfo struct {
+						HasNextPage bool   `json:"hasNextPage"`
+						EndCursor   string `json:"endCursor"`
+					} `json:"pageInfo"`
+					Nodes []struct {
+						ID               string `json:"id"`
+						FieldValueByName *struct {
+							Name string `json:"name"`
+						} `json:"fieldValueByName"`
+						Content *struct {
+							Number     int    `json:"number"`
+							Title      string `json:"title"`
+							URL        string `json:"url"`
+							Repository struct {
+								NameWithOwner string `json:"nameWithOwner"`
+							} `json:"repository"`
+						} `json:"content"`
+					} `json:"nodes"`
+				} `json:"items"`
+			} `json:"node"`
+		}
+		if err := repository.GraphQL(ctx, client, itemsQuery, vars, &data); err != nil {
+			return nil, err
+		}
+
+		for _, n := range data.Node.Items.Nodes {
+			// draft issues don't have any content
+			if n.Content == nil || n.Content.Number == 0 {
+				continue
+			}
+			item := Item{
+				ID:         n.ID,
+				Repository: n.Content.Repository.NameWithOwner,
+				Number:     n.Content.Number,
+				Title:      n.Content.Title,
+				URL:        n.Content.URL,
+			}
+			if n.FieldValueByName != nil {
+				item.Status = n.FieldValueByName.Name
+			}
+			items = append(items, item)
+		}
+
+		if !data.Node.Items.PageInfo.HasNextPage {
+			break
+		}
+		vars["cursor"] = data.Node.Items.PageInfo.EndCursor
+	}
+
+	return items, nil
+}
+
+// WriteReport writes the items of the board grouped by column, in the order
+// of the columns on the board. Items without a status are listed last.
+func WriteReport(w io.Writer, board *Board, items []Item) {
+	byStatus := make(map[string][]Item)
+	for _, item := range items {
+		byStatus[item.Status] = append(byStatus[item.Status], item)
+	}
+
+	columns := make([]string, 0, len(board.Statuses)+1)
+	for _, s := range board.Statuses {
+		columns = append(columns, s.Name)
+	}
+	columns = append(columns, "")
+
+	fmt.Fprintf(w, "%s: %d items\n", board.Title, len(items))
+	for _, column := range columns {
+		name := column
+		if name == "" {
+			if len(byStatus[column]) == 0 {
+				continue
+			}
+			name = "No Status"
+		}
+		fmt.Fprintf(w, "\n%s (%d)\n", name, len(byStatus[column]))
+		for _, item := range byStatus[column] {
+			fmt.Fprintf(w, "  %s#%d %s\n", item.Repository, item.Number, item.Title)
+		}
+	}
+}
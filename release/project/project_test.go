@@ -0,0 +1,57 @@
+package project
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestWriteReport(t *testing.T) {
+	board := &Board{
+		Title:    "RKE2 Release",
+		Statuses: []Status{{ID: "1", Name: "Todo"}, {ID: "2", Name: "In Progress"}, {ID: "3", Name: "Done"}},
+	}
+
+	tests := []struct {
+		name  string
+		items []Item
+		want  string
+	}{
+		{
+			name: "grouped by column",
+			items: []Item{
+				{Status: "Done", Repository: "rancher/rke2", Number: 2, Title: "Bump containerd"},
+				{Status: "Todo", Repository: "rancher/rke2", Number: 1, Title: "Bump etcd"},
+			},
+			want: "RKE2 Release: 2 items\n\nTodo (1)\n  rancher/rke2#1 Bump etcd\n\nIn Progress (0)\n\nDone (1)\n  rancher/rke2#2 Bump containerd\n",
+		},
+		{
+			name:  "items without status",
+			items: []Item{{Repository: "rancher/rke2", Number: 3, Title: "Bump runc"}},
+			want:  "RKE2 Release: 1 items\n\nTodo (0)\n\nIn Progress (0)\n\nDone (0)\n\nNo Status (1)\n  rancher/rke2#3 Bump runc\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b bytes.Buffer
+			WriteReport(&b, board, tt.items)
+			if got := b.String(); got != tt.want {
+				t.Errorf("WriteReport() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBoardStatus(t *testing.T) {
+	board := &Board{Statuses: []Status{{ID: "1", Name: "Todo"}, {ID: "2", Name: "Done"}}}
+
+	s, err := board.status("done")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.ID != "2" {
+		t.Errorf("status(done) = %s, want 2", s.ID)
+	}
+	if _, err := board.status("Blocked"); err == nil {
+		t.Error("expected an error for an unknown status")
+	}
+}
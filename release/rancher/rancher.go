@@ -25,6 +25,7 @@ import (
 	ecmHTTP "github.com/rancher/ecm-distro-tools/http"
 	"github.com/rancher/ecm-distro-tools/release"
 	"github.com/rancher/ecm-distro-tools/release/cli"
+	"github.com/rancher/ecm-distro-tools/release/dockerfile"
 	"github.com/rancher/ecm-distro-tools/repository"
 	"golang.org/x/mod/semver"
 	"golang.org/x/sync/errgroup"
@@ -58,6 +59,18 @@ var ReleaseTypes = map[string]ReleaseType{
 	"ga":    ReleaseTypeGA,
 }
 
+// dashboardDockerfileLines and cliDockerfileLines match the lines of the
+// rancher Dockerfile the Dashboard and CLI bumps are expected to change.
+var (
+	dashboardDockerfileLines = []*regexp.Regexp{
+		regexp.MustCompile(`ENV CATTLE_UI_VERSION=`),
+		regexp.MustCompile(`ENV CATTLE_DASHBOARD_UI_VERSION=`),
+	}
+	cliDockerfileLines = []*regexp.Regexp{
+		regexp.MustCompile(`ENV CATTLE_CLI_VERSION=`),
+	}
+)
+
 var regsyncDefaultMediaTypes = []string{
 	"application/vnd.docker.distribution.manifest.v2+json",
 	"application/vnd.docker.distribution.manifest.list.v2+json",
@@ -147,7 +160,7 @@ type regsyncSync struct {
 }
 
 func UpdateDashboardReferences(ctx context.Context, ghClient *github.Client, r *ecmConfig.DashboardRelease, u *ecmConfig.User, tag, rancherReleaseBranch, rancherRepoName, rancherRepoOwner, rancherRepoURL string, dryRun bool) error {
-	if err := updateDashboardReferencesAndPush(tag, rancherReleaseBranch, rancherRepoURL, dryRun); err != nil {
+	if err := updateDashboardReferencesAndPush(ctx, tag, rancherReleaseBranch, rancherRepoURL, dryRun); err != nil {
 		return err
 	}
 
@@ -162,7 +175,7 @@ func UpdateDashboardRefsBranchName(tag string) string {
 	return dashboardUpdateRefsBranchBase + "-" + tag
 }
 
-func updateDashboardReferencesAndPush(tag, rancherReleaseBranch, rancherUpstreamURL string, dryRun bool) error {
+func updateDashboardReferencesAndPush(ctx context.Context, tag, rancherReleaseBranch, rancherUpstreamURL string, dryRun bool) error {
 	updateScriptVars := map[string]string{
 		"Tag":                  tag,
 		"RancherReleaseBranch": rancherReleaseBranch,
@@ -175,6 +188,16 @@ func updateDashboardReferencesAndPush(tag, rancherReleaseBranch, rancherUpstream
 		return err
 	}
 	fmt.Println(updateScriptOut)
+
+	if err := dockerfile.ValidateEdits(ctx, "./", []string{"package/Dockerfile"}, dockerfile.Options{Expected: dashboardDockerfileLines}); err != nil {
+		return err
+	}
+
+	commitScriptOut, err := ecmExec.RunTemplatedScript("./", "commit_dashboard_refs.sh", commitDashboardReferencesScript, nil, updateScriptVars)
+	if err != nil {
+		return err
+	}
+	fmt.Println(commitScriptOut)
 	return nil
 }
 
@@ -194,11 +217,15 @@ func createDashboardReferencesPR(ctx context.Context, ghClient *github.Client, u
 
 	fmt.Println("Pull Request created successfully:", pr.GetHTMLURL())
 
+	if err := repository.RequestOwnerReviews(ctx, ghClient, rancherRepoOwner, rancherRepoName, pr.GetNumber(), "dashboard"); err != nil {
+		fmt.Println("failed to request reviews from the component owners: " + err.Error())
+	}
+
 	return nil
 }
 
 func UpdateCLIReferences(ctx context.Context, ghClient *github.Client, tag, rancherReleaseBranch, githubUsername, rancherRepoName, rancherRepoOwner, rancherUpstreamURL string, dryRun bool) error {
-	if err := updateCLIReferencesAndPush(tag, rancherUpstreamURL, rancherReleaseBranch, dryRun); err != nil {
+	if err := updateCLIReferencesAndPush(ctx, tag, rancherUpstreamURL, rancherReleaseBranch, dryRun); err != nil {
 		return err
 	}
 
@@ -209,7 +236,7 @@ func UpdateCLIReferences(ctx context.Context, ghClient *github.Client, tag, ranc
 	return createCLIReferencesPR(ctx, ghClient, tag, rancherReleaseBranch, githubUsername, rancherRepoName, rancherRepoOwner)
 }
 
-func updateCLIReferencesAndPush(tag, rancherUpstreamURL, rancherReleaseBranch string, dryRun bool) error {
+func updateCLIReferencesAndPush(ctx context.Context, tag, rancherUpstreamURL, rancherReleaseBranch string, dryRun bool) error {
 	updateScriptVars := map[string]string{
 		"DryRun":               strconv.FormatBool(dryRun),
 		"BranchName":           cli.UpdateCLIRefsBranchName(tag),
@@ -222,6 +249,16 @@ func updateCLIReferencesAndPush(tag, rancherUpstreamURL, rancherReleaseBranch st
 		return err
 	}
 	fmt.Println(updateScriptOut)
+
+	if err := dockerfile.ValidateEdits(ctx, "./", []string{"package/Dockerfile"}, dockerfile.Options{Expected: cliDockerfileLines}); err != nil {
+		return err
+	}
+
+	commitScriptOut, err := ecmExec.RunTemplatedScript("./", "commit_cli_ref.sh", commitCLIReferencesScript, nil, updateScriptVars)
+	if err != nil {
+		return err
+	}
+	fmt.Println(commitScriptOut)
 	return nil
 }
 
@@ -241,6 +278,10 @@ func createCLIReferencesPR(ctx context.Context, ghClient *github.Client, tag, ra
 
 	fmt.Println("Pull Request created successfully:", pr.GetHTMLURL())
 
+	if err := repository.RequestOwnerReviews(ctx, ghClient, rancherRepoOwner, rancherRepoName, pr.GetNumber(), "cli"); err != nil {
+		fmt.Println("failed to request reviews from the component owners: " + err.Error())
+	}
+
 	return nil
 }
 
@@ -270,7 +311,7 @@ func CreateRelease(ctx context.Context, ghClient *github.Client, r *ecmConfig.Ra
 	releaseName := opts.Tag
 	if preRelease {
 		latestVersionNumber := 1
-		latestVersion, err := release.LatestPreRelease(ctx, ghClient, opts.Owner, opts.Repo, opts.Tag, releaseType)
+		latestVersion, err := release.NewClient(ghClient).LatestPreRelease(ctx, opts.Owner, opts.Repo, opts.Tag, releaseType)
 		if err != nil {
 			return "", err
 		}
@@ -684,7 +725,7 @@ func createAssetFile(outputFile string, contents fmt.Stringer) error {
 }
 
 func artifactImageList(imagesFileURL, registry string) ([]string, error) {
-	client := http.Client{Timeout: time.Second * 15}
+	client := ecmHTTP.NewClient(time.Second * 15)
 	res, err := client.Get(imagesFileURL)
 	if err != nil {
 		return nil, err
@@ -890,10 +931,9 @@ RANCHER_BRANCH="{{.RancherReleaseBranch}}"
 RANCHER_UPSTREAM_URL="{{ .RancherUpstreamURL }}"
 FILENAME="package/Dockerfile"
 
-# Add upstream remote if it doesn't exist
-# Note: Using ls | grep is not recommended for general use, but it's okay here
-# since we're only checking for 'rancher'
-git remote -v | grep -w upstream || git remote add upstream "${RANCHER_UPSTREAM_URL}"
+# Add the upstream remote, or point it to the given URL, e.g. the sandbox of
+# a rehearsal
+git remote add upstream "${RANCHER_UPSTREAM_URL}" 2>/dev/null || git remote set-url upstream "${RANCHER_UPSTREAM_URL}"
 git fetch upstream
 git stash
 
@@ -928,7 +968,17 @@ update_file() {
 }
 
 # Run the update function
-update_file
+update_file`
+
+// commitDashboardReferencesScript commits and pushes the Dashboard refs
+// updated by updateDashboardReferencesScript, once the Dockerfile edit is
+// validated.
+const commitDashboardReferencesScript = `#!/bin/sh
+set -ex
+DRY_RUN="{{ .DryRun }}"
+BRANCH_NAME="{{ .BranchBaseName }}"
+VERSION="{{ .Tag }}"
+FILENAME="package/Dockerfile"
 
 git add $FILENAME
 git commit --signoff -m "Update Dashboard refs to ${VERSION}"
@@ -952,10 +1002,9 @@ FILENAME="package/Dockerfile"
 RANCHER_UPSTREAM_URL="{{ .RancherUpstreamURL }}"
 RANCHER_RELEASE_BRANCH="{{ .RancherReleaseBranch }}"
 
-# Add upstream remote if it doesn't exist
-# Note: Using ls | grep is not recommended for general use, but it's okay here
-# since we're only checking for 'rancher'
-git remote -v | grep -w upstream || git remote add upstream "$RANCHER_UPSTREAM_URL"
+# Add the upstream remote, or point it to the given URL, e.g. the sandbox of
+# a rehearsal
+git remote add upstream "$RANCHER_UPSTREAM_URL" 2>/dev/null || git remote set-url upstream "$RANCHER_UPSTREAM_URL"
 git fetch upstream
 git stash
 
@@ -987,7 +1036,16 @@ update_file() {
 }
 
 # Run the update function
-update_file
+update_file`
+
+// commitCLIReferencesScript commits and pushes the CLI refs updated by
+// updateCLIReferencesScript, once the Dockerfile edit is validated.
+const commitCLIReferencesScript = `#!/bin/sh
+set -ex
+DRY_RUN="{{ .DryRun }}"
+BRANCH_NAME="{{ .BranchName }}"
+VERSION="{{ .Tag }}"
+FILENAME="package/Dockerfile"
 
 git add $FILENAME
 git commit --signoff -m "Update to Dashboard refs to ${VERSION}"
@@ -0,0 +1,186 @@
+package rehearsal
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// githubHosts are the GitHub API hosts whose requests are redirected to the
+// sandbox, uploads.github.com serving the release assets uploads.
+var githubHosts = map[string]bool{
+	"api.github.com":     true,
+	"uploads.github.com": true,
+}
+
+var repoPathRegex = regexp.MustCompile(`^/repos/([^/]+)/([^/]+)(/.*)?$`)
+
+var gitURLRegex = regexp.MustCompile(`^(https://github\.com/|git@github\.com:|ssh://git@github\.com/)([^/]+)/([^/]+?)(\.git)?/?$`)
+
+// Mapping maps the production organizations and repositories to the
+// sandbox ones a release is rehearsed in.
+type Mapping struct {
+	// Orgs maps organizations, e.g. rancher to rancher-sandbox, whose repos
+	// are mapped to the repos of the same name in the sandbox organization.
+	Orgs map[string]string `json:"orgs,omitempty"`
+	// Repos maps single repositories, e.g. k3s-io/k3s to captain/k3s,
+	// taking precedence over Orgs.
+	Repos map[string]string `json:"repos,omitempty"`
+}
+
+// Validate checks that the mapping isn't empty and that no sandbox is also
+// mapped, which would send the rehearsal to a production repository.
+func (m *Mapping) Validate() error {
+	if m == nil || (len(m.Orgs) == 0 && len(m.Repos) == 0) {
+		return errors.New("no sandbox organizations or repositories configured")
+	}
+
+	for org, sandbox := range m.Orgs {
+		if sandbox == "" {
+			return errors.New("empty sandbox organization for " + org)
+		}
+		if _, ok := m.Orgs[sandbox]; ok {
+			return errors.New("sandbox organization " + sandbox + " is also mapped")
+		}
+	}
+	for repo, sandbox := range m.Repos {
+		if strings.Count(repo, "/") != 1 || strings.Count(sandbox, "/") != 1 {
+			return errors.New("invalid repository mapping " + repo + ": " + sandbox + ", expected owner/repo: owner/repo")
+		}
+		sandboxOwner, _, _ := strings.Cut(sandbox, "/")
+		_, repoMapped := m.Repos[sandbox]
+		_, orgMapped := m.Orgs[sandboxOwner]
+		if repoMapped || orgMapped {
+			return errors.New("sandbox repository " + sandbox + " is also mapped")
+		}
+	}
+
+	return nil
+}
+
+// Repo returns the sandbox repository of the given one, and false if it
+// isn't mapped.
+func (m *Mapping) Repo(owner, repo string) (string, string, bool) {
+	if sandbox, ok := m.Repos[owner+"/"+repo]; ok {
+		sandboxOwner, sandboxRepo, _ := strings.Cut(sandbox, "/")
+		return sandboxOwner, sandboxRepo, true
+	}
+	if sandbox, ok := m.Orgs[owner]; ok {
+		return sandbox, repo, true
+	}
+
+	return owner, repo, false
+}
+
+// isSandbox returns true if the given repository is one of the sandboxes.
+func (m *Mapping) isSandbox(owner, repo string) bool {
+	for _, sandbox := range m.Repos {
+		if sandbox == owner+"/"+repo {
+			return true
+		}
+	}
+	for _, sandbox := range m.Orgs {
+		if sandbox == owner {
+			return true
+		}
+	}
+
+	return false
+}
+
+// GitURL returns the sandbox URL of the given GitHub clone URL, in https or
+// ssh form. Other URLs are returned unchanged.
+func (m *Mapping) GitURL(url string) string {
+	match := gitURLRegex.FindStringSubmatch(url)
+	if match == nil {
+		return url
+	}
+
+	owner, repo, ok := m.Repo(match[2], match[3])
+	if !ok {
+		return url
+	}
+
+	return match[1] + owner + "/" + repo + match[4]
+}
+
+// Transport redirects the GitHub API requests to production repositories
+// to their sandboxes, and refuses the writes that can't be redirected, so a
+// rehearsal creates real tags, pull requests and releases without touching
+// production. Reads of unmapped repositories, e.g. upstream kubernetes, go
+// through unchanged.
+type Transport struct {
+	Mapping *Mapping
+	Base    http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !githubHosts[req.URL.Host] {
+		return t.Base.RoundTrip(req)
+	}
+
+	if match := repoPathRegex.FindStringSubmatch(req.URL.Path); match != nil {
+		owner, repo, ok := t.Mapping.Repo(match[1], match[2])
+		if !ok {
+			if isWrite(req.Method) && !t.Mapping.isSandbox(owner, repo) {
+				return nil, errors.New("rehearsal: refusing to " + req.Method + " " + req.URL.Path + ", " + owner + "/" + repo + " has no sandbox")
+			}
+			return t.Base.RoundTrip(req)
+		}
+
+		req = req.Clone(req.Context())
+		req.URL.Path = "/repos/" + owner + "/" + repo + match[3]
+		req.URL.RawPath = ""
+		return t.Base.RoundTrip(req)
+	}
+
+	if req.URL.Path == "/graphql" {
+		req = req.Clone(req.Context())
+		mutation, err := isMutation(req)
+		if err != nil {
+			return nil, err
+		}
+		if mutation {
+			return nil, errors.New("rehearsal: refusing GraphQL mutations, which can't be redirected to the sandbox")
+		}
+		return t.Base.RoundTrip(req)
+	}
+
+	if isWrite(req.Method) {
+		return nil, errors.New("rehearsal: refusing to " + req.Method + " " + req.URL.Path + " outside of the sandbox repositories")
+	}
+
+	return t.Base.RoundTrip(req)
+}
+
+func isWrite(method string) bool {
+	return method != http.MethodGet && method != http.MethodHead && method != http.MethodOptions
+}
+
+// isMutation reads the query of a GraphQL request, replacing its body with
+// a copy.
+func isMutation(req *http.Request) (bool, error) {
+	if req.Body == nil {
+		return false, nil
+	}
+	body, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return false, err
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+
+	var payload struct {
+		Query string `json:"query"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return false, errors.New("rehearsal: invalid GraphQL request: " + err.Error())
+	}
+
+	return strings.HasPrefix(strings.TrimSpace(payload.Query), "mutation"), nil
+}
@@ -0,0 +1,122 @@
+package rehearsal
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+var testMapping = &Mapping{
+	Orgs:  map[string]string{"rancher": "rancher-sandbox"},
+	Repos: map[string]string{"k3s-io/k3s": "captain/k3s"},
+}
+
+func TestMappingValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		mapping *Mapping
+		wantErr bool
+	}{
+		{name: "valid", mapping: testMapping},
+		{name: "nil", mapping: nil, wantErr: true},
+		{name: "empty", mapping: &Mapping{}, wantErr: true},
+		{name: "chained orgs", mapping: &Mapping{Orgs: map[string]string{"rancher": "k3s-io", "k3s-io": "sandbox"}}, wantErr: true},
+		{name: "repo in mapped org", mapping: &Mapping{Orgs: map[string]string{"rancher": "sandbox"}, Repos: map[string]string{"k3s-io/k3s": "rancher/k3s"}}, wantErr: true},
+		{name: "invalid repo", mapping: &Mapping{Repos: map[string]string{"k3s": "captain/k3s"}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.mapping.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMappingGitURL(t *testing.T) {
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{url: "https://github.com/rancher/rke2", want: "https://github.com/rancher-sandbox/rke2"},
+		{url: "git@github.com:rancher/rancher.git", want: "git@github.com:rancher-sandbox/rancher.git"},
+		{url: "git@github.com:k3s-io/k3s.git", want: "git@github.com:captain/k3s.git"},
+		{url: "https://github.com/k3s-io/kubernetes", want: "https://github.com/k3s-io/kubernetes"},
+		{url: "https://gitlab.com/rancher/rke2", want: "https://gitlab.com/rancher/rke2"},
+		{url: "", want: ""},
+	}
+	for _, tt := range tests {
+		if got := testMapping.GitURL(tt.url); got != tt.want {
+			t.Errorf("GitURL(%q) = %q, want %q", tt.url, got, tt.want)
+		}
+	}
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
+
+func TestTransport(t *testing.T) {
+	var sent *http.Request
+	transport := &Transport{
+		Mapping: testMapping,
+		Base: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			sent = req
+			return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody}, nil
+		}),
+	}
+
+	tests := []struct {
+		name     string
+		method   string
+		url      string
+		body     string
+		wantPath string
+		wantErr  bool
+	}{
+		{name: "mapped org write", method: http.MethodPost, url: "https://api.github.com/repos/rancher/rke2/releases", wantPath: "/repos/rancher-sandbox/rke2/releases"},
+		{name: "mapped repo read", method: http.MethodGet, url: "https://api.github.com/repos/k3s-io/k3s/tags", wantPath: "/repos/captain/k3s/tags"},
+		{name: "asset upload", method: http.MethodPost, url: "https://uploads.github.com/repos/rancher/rke2/releases/1/assets?name=sha256sum.txt", wantPath: "/repos/rancher-sandbox/rke2/releases/1/assets"},
+		{name: "unmapped read", method: http.MethodGet, url: "https://api.github.com/repos/kubernetes/kubernetes/tags", wantPath: "/repos/kubernetes/kubernetes/tags"},
+		{name: "unmapped write", method: http.MethodPost, url: "https://api.github.com/repos/k3s-io/kine/releases", wantErr: true},
+		{name: "sandbox write", method: http.MethodPatch, url: "https://api.github.com/repos/rancher-sandbox/rke2/releases/1", wantPath: "/repos/rancher-sandbox/rke2/releases/1"},
+		{name: "graphql query", method: http.MethodPost, url: "https://api.github.com/graphql", body: `{"query":"query { viewer { login } }"}`, wantPath: "/graphql"},
+		{name: "graphql mutation", method: http.MethodPost, url: "https://api.github.com/graphql", body: `{"query":"mutation($input: AddProjectV2ItemByIdInput!) { addProjectV2ItemById(input: $input) { item { id } } }"}`, wantErr: true},
+		{name: "other write", method: http.MethodPost, url: "https://api.github.com/user/repos", wantErr: true},
+		{name: "other host", method: http.MethodPost, url: "https://hooks.slack.com/services/x", wantPath: "/services/x"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sent = nil
+			req, err := http.NewRequest(tt.method, tt.url, strings.NewReader(tt.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_, err = transport.RoundTrip(req)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("RoundTrip() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				if sent != nil {
+					t.Errorf("refused request was sent to %s", sent.URL)
+				}
+				return
+			}
+			if sent.URL.Path != tt.wantPath {
+				t.Errorf("sent to %s, want %s", sent.URL.Path, tt.wantPath)
+			}
+			if sent.URL.RawQuery != req.URL.RawQuery {
+				t.Errorf("query = %s, want %s", sent.URL.RawQuery, req.URL.RawQuery)
+			}
+			body, err := io.ReadAll(sent.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(body) != tt.body {
+				t.Errorf("body = %s, want %s", body, tt.body)
+			}
+		})
+	}
+}
@@ -4,25 +4,28 @@ import (
 	"bufio"
 	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
-	"path/filepath"
 	"regexp"
 	"sort"
+	"strconv"
 	"strings"
+	"sync"
 	"text/template"
 	"time"
 	"unicode"
 
 	"github.com/google/go-github/v39/github"
-	httpecm "github.com/rancher/ecm-distro-tools/http"
+	"github.com/rancher/ecm-distro-tools/release/components"
 	"github.com/rancher/ecm-distro-tools/repository"
+	"github.com/rancher/ecm-distro-tools/version"
 	"github.com/sirupsen/logrus"
 	"golang.org/x/mod/modfile"
 	"golang.org/x/mod/semver"
-	"sigs.k8s.io/yaml"
+	"golang.org/x/sync/errgroup"
 )
 
 const (
@@ -31,21 +34,10 @@ const (
 	uiRepo                 = "ui"
 	dashboardRepo          = "dashboard"
 	cliRepo                = "cli"
-	alternateVersion       = "1.23"
-	rke2ChartsVersionsFile = "chart_versions.yaml"
+	rke2ChartsVersionsFile = "charts/chart_versions.yaml"
 	defaultTimeout         = 30 * time.Second
 )
 
-type charts struct {
-	Charts []chart `yaml:"charts"`
-}
-
-type chart struct {
-	Version   string `yaml:"version"`
-	Filename  string `yaml:"filename"`
-	Bootstrap bool   `yaml:"bootstrap"`
-}
-
 type changeLogData struct {
 	PrevMilestone string
 	Content       []repository.ChangeLog
@@ -56,10 +48,22 @@ type releaseNoteData struct {
 	MajorMinor       string
 	ChangeLogVersion string
 	ChangeLogData    changeLogData
+	// ComponentChanges contains the components whose version changed since
+	// the previous milestone, by the name of their version field, e.g.
+	// EtcdVersion.
+	ComponentChanges map[string]ComponentChange `json:",omitempty"`
+	// OtherComponents are the components configured for the notes that
+	// have no field of their own, e.g. added to the k3s mapping.
+	OtherComponents []Component `json:",omitempty"`
+	// Locale the dates are formatted in, e.g. de-DE, and the publish and end
+	// of life dates of the release, if known.
+	Locale      string     `json:",omitempty"`
+	PublishDate *time.Time `json:",omitempty"`
+	EOLDate     *time.Time `json:",omitempty"`
 }
 
 type releaseNote interface {
-	Fill(milestone string) error
+	Fill(c *Client, milestone string) error
 	Template() string
 	Repo() string
 }
@@ -94,54 +98,32 @@ type rke2ReleaseNoteData struct {
 	SnapshotControllerChartVersion        string
 	SnapshotControllerCRDChartVersion     string
 	SnapshotValidationWebhookChartVersion string
+	// WindowsAgent is whether the release line supports Windows agents,
+	// whose components are listed in their own section of the notes.
+	WindowsAgent             bool
+	WindowsContainerdVersion string
+	WinsVersion              string
+	CalicoWindowsVersion     string
+	// Charts lists every chart packaged by the release with its app
+	// version, sorted by name.
+	Charts []ChartVersion `json:",omitempty"`
 	releaseNoteData
 }
 
-func (rd *rke2ReleaseNoteData) Fill(milestone string) error {
-	var containerdVersion string
+func (rd *rke2ReleaseNoteData) Fill(c *Client, milestone string) error {
+	files := c.repoFiles("rancher/rke2", milestone)
+	if err := fillComponents(rd, files, c.Components.For(rke2Repo), rd.K8sVersion); err != nil {
+		return err
+	}
+	rd.CanalCalicoURL = c.createCalicoURL(rd.CanalCalicoVersion)
+	rd.CalicoURL = c.createCalicoURL(rd.CalicoVersion)
+	rd.WindowsAgent = rd.WindowsContainerdVersion != "" || rd.WinsVersion != "" || rd.CalicoWindowsVersion != ""
 
-	if rd.MajorMinor == alternateVersion {
-		containerdVersion = goModLibVersion(containerdV2ModLib, rke2Repo, milestone)
-		if containerdVersion == "" {
-			containerdVersion = goModLibVersion(containerdModLib, rke2Repo, milestone)
-		}
-	} else {
-		containerdVersion = dockerfileVersion("hardened-containerd", rke2Repo, milestone)
-	}
-
-	rd.EtcdVersion = buildScriptVersion("ETCD_VERSION", rke2Repo, milestone)
-	rd.RuncVersion = dockerfileVersion("hardened-runc", rke2Repo, milestone)
-	rd.CanalCalicoVersion = imageTagVersion("hardened-calico", rke2Repo, milestone)
-	rd.CanalCalicoURL = createCalicoURL(rd.CanalCalicoVersion)
-	rd.CiliumVersion = imageTagVersion("cilium-cilium", rke2Repo, milestone)
-	rd.ContainerdVersion = containerdVersion
-	rd.MetricsServerVersion = imageTagVersion("metrics-server", rke2Repo, milestone)
-	rd.IngressNginxVersion = imageTagVersion("nginx-ingress-controller", rke2Repo, milestone)
-	rd.FlannelVersion = imageTagVersion("flannel", rke2Repo, milestone)
-	rd.MultusVersion = imageTagVersion("multus-cni", rke2Repo, milestone)
-	rd.CalicoVersion = imageTagVersion("calico-node", rke2Repo, milestone)
-	rd.CalicoURL = createCalicoURL(rd.CalicoVersion)
-
-	// get charts versions
-	chartsData, err := rke2ChartsVersion(milestone)
+	chartsData, err := rke2ChartsVersion(files)
 	if err != nil {
 		return err
 	}
-
-	rd.CiliumChartVersion = chartsData["rke2-cilium.yaml"].Version
-	rd.CanalChartVersion = chartsData["rke2-canal.yaml"].Version
-	rd.CalicoChartVersion = chartsData["rke2-calico.yaml"].Version
-	rd.CalicoCRDChartVersion = chartsData["rke2-calico-crd.yaml"].Version
-	rd.CoreDNSChartVersion = chartsData["rke2-coredns.yaml"].Version
-	rd.IngressNginxChartVersion = chartsData["rke2-ingress-nginx.yaml"].Version
-	rd.MetricsServerChartVersion = chartsData["rke2-metrics-server.yaml"].Version
-	rd.VsphereCSIChartVersion = chartsData["rancher-vsphere-csi.yaml"].Version
-	rd.VsphereCPIChartVersion = chartsData["rancher-vsphere-cpi.yaml"].Version
-	rd.HarvesterCloudProviderChartVersion = chartsData["harvester-cloud-provider.yaml"].Version
-	rd.HarvesterCSIDriverChartVersion = chartsData["harvester-csi-driver.yaml"].Version
-	rd.SnapshotControllerChartVersion = chartsData["rke2-snapshot-controller.yaml"].Version
-	rd.SnapshotControllerCRDChartVersion = chartsData["rke2-snapshot-controller-crd.yaml"].Version
-	rd.SnapshotValidationWebhookChartVersion = chartsData["rke2-snapshot-validation-webhook.yaml"].Version
+	rd.Charts = c.rke2Charts(files, chartsData)
 
 	return nil
 }
@@ -166,33 +148,15 @@ type k3sReleaseNoteData struct {
 	releaseNoteData
 }
 
-func (rd *k3sReleaseNoteData) Fill(milestone string) error {
-	var runcVersion string
-	var containerdVersion string
-
-	if semver.Compare(rd.K8sVersion, "v1.24.0") == 1 && semver.Compare(rd.K8sVersion, "v1.26.5") == -1 {
-		containerdVersion = buildScriptVersion("VERSION_CONTAINERD", k3sRepo, milestone)
-	} else {
-		containerdVersion = goModLibVersion(containerdV2ModLib, k3sRepo, milestone)
-		if containerdVersion == "" {
-			containerdVersion = goModLibVersion(containerdModLib, k3sRepo, milestone)
-		}
-	}
-
-	if rd.MajorMinor == alternateVersion {
-		runcVersion = buildScriptVersion("VERSION_RUNC", k3sRepo, milestone)
-	} else {
-		runcVersion = goModLibVersion("runc", k3sRepo, milestone)
+func (rd *k3sReleaseNoteData) Fill(c *Client, milestone string) error {
+	files := c.repoFiles("k3s-io/k3s", milestone)
+	if err := fillComponents(rd, files, c.Components.For(k3sRepo), rd.K8sVersion); err != nil {
+		return err
 	}
-
-	rd.KineVersion = goModLibVersion("kine", k3sRepo, milestone)
-	rd.EtcdVersion = goModLibVersion("etcd/api/v3", k3sRepo, milestone)
-	rd.ContainerdVersion = containerdVersion
-	rd.RuncVersion = runcVersion
-	rd.FlannelVersion = goModLibVersion("flannel", k3sRepo, milestone)
-	rd.MetricsServerVersion = imageTagVersion("metrics-server", k3sRepo, milestone)
-	rd.TraefikVersion = imageTagVersion("traefik", k3sRepo, milestone)
-	rd.LocalPathProvisionerVersion = imageTagVersion("local-path-provisioner", k3sRepo, milestone)
+	// the go-sqlite3 module embeds the SQLite library, whose version is
+	// the one released.
+	rd.SQLiteVersion = c.sqliteVersionBinding(rd.SQLiteVersion)
+	rd.SQLiteVersionReplaced = strings.ReplaceAll(rd.SQLiteVersion, ".", "_")
 
 	return nil
 }
@@ -204,15 +168,15 @@ type uiReleaseNoteData struct {
 	releaseNoteData
 }
 
-func (_ *uiReleaseNoteData) Fill(_ string) error { return nil }
-func (_ *uiReleaseNoteData) Template() string    { return fmt.Sprintf(defaultReleaseNoteTemplate, uiRepo) }
-func (_ *uiReleaseNoteData) Repo() string        { return uiRepo }
+func (_ *uiReleaseNoteData) Fill(_ *Client, _ string) error { return nil }
+func (_ *uiReleaseNoteData) Template() string               { return fmt.Sprintf(defaultReleaseNoteTemplate, uiRepo) }
+func (_ *uiReleaseNoteData) Repo() string                   { return uiRepo }
 
 type dashboardReleaseNoteData struct {
 	releaseNoteData
 }
 
-func (_ *dashboardReleaseNoteData) Fill(_ string) error { return nil }
+func (_ *dashboardReleaseNoteData) Fill(_ *Client, _ string) error { return nil }
 func (_ *dashboardReleaseNoteData) Template() string {
 	return fmt.Sprintf(defaultReleaseNoteTemplate, dashboardRepo)
 }
@@ -222,7 +186,7 @@ type cliReleaseNoteData struct {
 	releaseNoteData
 }
 
-func (_ *cliReleaseNoteData) Fill(_ string) error { return nil }
+func (_ *cliReleaseNoteData) Fill(_ *Client, _ string) error { return nil }
 func (_ *cliReleaseNoteData) Template() string {
 	return fmt.Sprintf(defaultReleaseNoteTemplate, cliRepo)
 }
@@ -256,49 +220,89 @@ func capitalize(s string) string {
 	return s
 }
 
+// upgradeNotes returns the changelog entries that were labeled as upgrade notes.
+func upgradeNotes(content []repository.ChangeLog) []repository.ChangeLog {
+	var notes []repository.ChangeLog
+	for _, c := range content {
+		if c.UpgradeNote {
+			notes = append(notes, c)
+		}
+	}
+
+	return notes
+}
+
+// experimentalFeatures returns the changelog entries of experimental
+// features and feature gates.
+func experimentalFeatures(content []repository.ChangeLog) []repository.ChangeLog {
+	var features []repository.ChangeLog
+	for _, c := range content {
+		if c.Experimental {
+			features = append(features, c)
+		}
+	}
+
+	return features
+}
+
+// ReleaseNotesSnapshot contains the fully resolved data used to render the
+// release notes of a repository, i.e. component versions and changelog
+// entries, so the notes can be rendered again later without querying any
+// remote source.
+type ReleaseNotesSnapshot struct {
+	Repo          string          `json:"repo"`
+	Milestone     string          `json:"milestone"`
+	PrevMilestone string          `json:"prev_milestone"`
+	Data          json.RawMessage `json:"data"`
+}
+
 // GenReleaseNotes genereates release notes based on the given milestone,
 // previous milestone, and repository.
-func GenReleaseNotes(ctx context.Context, owner, repo, milestone, prevMilestone string, client *github.Client) (*bytes.Buffer, error) {
-	funcMap := template.FuncMap{
-		"majMin":      majMin,
-		"trimPeriods": trimPeriods,
-		"split":       strings.Split,
-		"capitalize":  capitalize,
+func (c *Client) GenReleaseNotes(ctx context.Context, owner, repo, milestone, prevMilestone string) (*bytes.Buffer, error) {
+	snapshot, err := c.GenReleaseNotesSnapshot(ctx, owner, repo, milestone, prevMilestone)
+	if err != nil {
+		return nil, err
 	}
-	const templateName = "release-notes"
-	tmpl := template.New(templateName).Funcs(funcMap)
-	tmpl = template.Must(tmpl.Parse(changelogTemplate))
 
-	content, err := repository.RetrieveChangeLogContents(ctx, client, owner, repo, prevMilestone, milestone)
+	return RenderReleaseNotes(snapshot)
+}
+
+// GenReleaseNotesSnapshot resolves all the data needed to render the release
+// notes for the given milestone, previous milestone, and repository.
+func (c *Client) GenReleaseNotesSnapshot(ctx context.Context, owner, repo, milestone, prevMilestone string) (*ReleaseNotesSnapshot, error) {
+	return c.genReleaseNotesSnapshot(ctx, owner, repo, milestone, prevMilestone, repository.ChangeLogOptions{})
+}
+
+func (c *Client) genReleaseNotesSnapshot(ctx context.Context, owner, repo, milestone, prevMilestone string, opts repository.ChangeLogOptions) (*ReleaseNotesSnapshot, error) {
+	content, err := repository.RetrieveChangeLog(ctx, c.GitHub, owner, repo, prevMilestone, milestone, opts)
 	if err != nil {
 		return nil, err
 	}
 
+	return c.releaseNotesSnapshot(repo, milestone, prevMilestone, content)
+}
+
+// releaseNotesSnapshot resolves the component versions of the given
+// milestone and returns them along with the given changelog entries.
+func (c *Client) releaseNotesSnapshot(repo, milestone, prevMilestone string, content []repository.ChangeLog) (*ReleaseNotesSnapshot, error) {
 	// account for processing against an rc
-	milestoneNoRC := milestone
-	idx := strings.Index(milestone, "-rc")
-	if idx != -1 {
-		tmpMilestone := []rune(milestone)
-		tmpMilestone = append(tmpMilestone[0:idx], tmpMilestone[idx+4:]...)
-		milestoneNoRC = string(tmpMilestone)
+	v, err := version.Parse(milestone)
+	if err != nil {
+		return nil, err
 	}
-
-	k8sVersion := strings.Split(milestoneNoRC, "+")[0]
+	milestoneNoRC := v.GA().String()
+	k8sVersion := v.K8sVersion()
 	markdownVersion := strings.ReplaceAll(k8sVersion, ".", "")
-	tmp := strings.Split(strings.ReplaceAll(k8sVersion, "v", ""), ".")
-	var majorMinor string
-	if len(tmp) > 1 {
-		majorMinor = tmp[0] + "." + tmp[1]
-	} else {
-		// for master branch
-		majorMinor = tmp[0]
-	}
-
-	changeLogSince := strings.ReplaceAll(strings.Split(prevMilestone, "+")[0], ".", "")
-	sqliteVersionK3S := goModLibVersion("go-sqlite3", repo, milestone)
-	sqliteVersionBinding := sqliteVersionBinding(sqliteVersionK3S)
-	helmControllerVersion := goModLibVersion("helm-controller", repo, milestone)
-	coreDNSVersion := imageTagVersion("coredns", repo, milestone)
+	majorMinor := strings.TrimPrefix(v.MajorMinor(), "v")
+
+	var changeLogSince string
+	if prevMilestone != "" {
+		prev, err := version.Parse(prevMilestone)
+		if err != nil {
+			return nil, err
+		}
+		changeLogSince = strings.ReplaceAll(prev.K8sVersion(), ".", "")
+	}
 	cgData := changeLogData{
 		PrevMilestone: prevMilestone,
 		Content:       content,
@@ -315,21 +319,15 @@ func GenReleaseNotes(ctx context.Context, owner, repo, milestone, prevMilestone
 	switch repo {
 	case k3sRepo:
 		rd = &k3sReleaseNoteData{
-			releaseNoteData:       commonRD,
-			K8sVersion:            k8sVersion,
-			ChangeLogSince:        changeLogSince,
-			SQLiteVersion:         sqliteVersionBinding,
-			SQLiteVersionReplaced: strings.ReplaceAll(sqliteVersionBinding, ".", "_"),
-			HelmControllerVersion: helmControllerVersion,
-			CoreDNSVersion:        coreDNSVersion,
+			releaseNoteData: commonRD,
+			K8sVersion:      k8sVersion,
+			ChangeLogSince:  changeLogSince,
 		}
 
 	case rke2Repo:
 		rd = &rke2ReleaseNoteData{
-			releaseNoteData:       commonRD,
-			K8sVersion:            k8sVersion,
-			HelmControllerVersion: helmControllerVersion,
-			CoreDNSVersion:        coreDNSVersion,
+			releaseNoteData: commonRD,
+			K8sVersion:      k8sVersion,
 		}
 
 	case uiRepo:
@@ -346,14 +344,82 @@ func GenReleaseNotes(ctx context.Context, owner, repo, milestone, prevMilestone
 		rd = &cliReleaseNoteData{
 			releaseNoteData: commonRD,
 		}
+
+	case kineRepo, helmControllerRepo, localPathProvisionerRepo:
+		rd = &ancillaryReleaseNoteData{
+			Name:            repo,
+			releaseNoteData: commonRD,
+		}
 	default:
-		return nil, errors.New("invalid repo: it must be k3s, rke2, ui, dashboard or cli, received " + repo)
+		return nil, errors.New("invalid repo: it must be k3s, rke2, ui, dashboard, cli or a k3s ancillary repo, received " + repo)
 	}
 
-	if err := rd.Fill(milestone); err != nil {
+	if err := rd.Fill(c, milestone); err != nil {
 		return nil, err
 	}
 
+	data, err := json.Marshal(rd)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ReleaseNotesSnapshot{
+		Repo:          repo,
+		Milestone:     milestone,
+		PrevMilestone: prevMilestone,
+		Data:          data,
+	}, nil
+}
+
+// notesFuncMap contains the functions available to the notes templates.
+var notesFuncMap = template.FuncMap{
+	"majMin":               majMin,
+	"trimPeriods":          trimPeriods,
+	"split":                strings.Split,
+	"join":                 strings.Join,
+	"capitalize":           capitalize,
+	"upgradeNotes":         upgradeNotes,
+	"experimentalFeatures": experimentalFeatures,
+	"netChanges":           netChanges,
+	"revertedChanges":      revertedChanges,
+	"date":                 formatDate,
+	"number":               formatNumber,
+}
+
+// newReleaseNote returns empty release notes data of the given repo.
+func newReleaseNote(repo string) (releaseNote, error) {
+	switch repo {
+	case k3sRepo:
+		return &k3sReleaseNoteData{}, nil
+	case rke2Repo:
+		return &rke2ReleaseNoteData{}, nil
+	case uiRepo:
+		return &uiReleaseNoteData{}, nil
+	case dashboardRepo:
+		return &dashboardReleaseNoteData{}, nil
+	case cliRepo:
+		return &cliReleaseNoteData{}, nil
+	case kineRepo, helmControllerRepo, localPathProvisionerRepo:
+		return &ancillaryReleaseNoteData{Name: repo}, nil
+	default:
+		return nil, errors.New("invalid repo: it must be k3s, rke2, ui, dashboard, cli or a k3s ancillary repo, received " + repo)
+	}
+}
+
+// RenderReleaseNotes renders the release notes from the given snapshot.
+func RenderReleaseNotes(snapshot *ReleaseNotesSnapshot) (*bytes.Buffer, error) {
+	rd, err := newReleaseNote(snapshot.Repo)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(snapshot.Data, rd); err != nil {
+		return nil, err
+	}
+
+	const templateName = "release-notes"
+	tmpl := template.New(templateName).Funcs(notesFuncMap)
+	tmpl = template.Must(tmpl.Parse(changelogTemplate))
 	tmpl = template.Must(tmpl.Parse(rd.Template()))
 
 	b := bytes.NewBuffer(nil)
@@ -364,50 +430,94 @@ func GenReleaseNotes(ctx context.Context, owner, repo, milestone, prevMilestone
 	return b, nil
 }
 
-const (
-	containerdModLib   = "containerd/containerd"
-	containerdV2ModLib = containerdModLib + "/v2"
-)
+// upstreamReleaseBatchSize is the number of tags above which the releases
+// of a repository are listed once instead of being looked up one by one, to
+// save API calls and rate limit.
+const upstreamReleaseBatchSize = 20
 
-// CheckUpstreamRelease takes the given org, repo, and tags and checks
-// for the tags' existence.
-func CheckUpstreamRelease(ctx context.Context, client *github.Client, org, repo string, tags []string) (map[string]bool, error) {
-	releases := make(map[string]bool, len(tags))
+// CheckUpstreamRelease checks which of the given tags have a published
+// release.
+func (c *Client) CheckUpstreamRelease(ctx context.Context, opts UpstreamReleaseOptions) (*UpstreamReleases, error) {
+	dates, err := c.UpstreamReleaseDates(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
 
-	for _, tag := range tags {
-		_, _, err := client.Repositories.GetReleaseByTag(ctx, org, repo, tag)
-		if err != nil {
-			switch err := err.(type) {
-			case *github.ErrorResponse:
-				if err.Response.StatusCode != http.StatusNotFound {
+	releases := UpstreamReleases{Published: make(map[string]bool, len(opts.Tags))}
+	for _, tag := range opts.Tags {
+		_, releases.Published[tag] = dates[tag]
+	}
+
+	return &releases, nil
+}
+
+// UpstreamReleaseDates returns the publish date of the release of each of
+// the given tags, omitting the tags that don't have a published release.
+// For more than upstreamReleaseBatchSize tags, the releases are listed page
+// by page until all the tags are found instead of being looked up one by
+// one.
+func (c *Client) UpstreamReleaseDates(ctx context.Context, opts UpstreamReleaseOptions) (map[string]time.Time, error) {
+	org, repo, tags := opts.Owner, opts.Repo, opts.Tags
+	dates := make(map[string]time.Time, len(tags))
+
+	if len(tags) <= upstreamReleaseBatchSize {
+		for _, tag := range tags {
+			release, err := c.Cache.GetReleaseByTag(ctx, c.GitHub, org, repo, tag)
+			if err != nil {
+				if !errors.Is(err, repository.ErrNotFound) {
 					return nil, err
 				}
-				releases[tag] = false
 				continue
-			default:
-				return nil, err
 			}
+			dates[tag] = release.GetPublishedAt().Time
 		}
 
-		releases[tag] = true
+		return dates, nil
 	}
 
-	return releases, nil
+	lo := &github.ListOptions{PerPage: 100}
+	for {
+		releases, res, err := c.GitHub.Repositories.ListReleases(ctx, org, repo, lo)
+		if err != nil {
+			return nil, repository.WrapGithubError(err, org, repo, "")
+		}
+		if addReleaseDates(dates, releases, tags) || res.NextPage == 0 {
+			return dates, nil
+		}
+		lo.Page = res.NextPage
+	}
 }
 
-func KubernetesGoVersion(ctx context.Context, client *github.Client, version string) (string, error) {
-	var githubError *github.ErrorResponse
+// addReleaseDates adds the publish dates of the published releases of the
+// given tags to dates, and returns true once all the tags have been found.
+func addReleaseDates(dates map[string]time.Time, releases []*github.RepositoryRelease, tags []string) bool {
+	wanted := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		wanted[tag] = true
+	}
 
-	file, _, _, err := client.Repositories.GetContents(ctx, "kubernetes", "kubernetes", ".go-version", &github.RepositoryContentGetOptions{
+	for _, release := range releases {
+		if release.GetDraft() || !wanted[release.GetTagName()] {
+			continue
+		}
+		dates[release.GetTagName()] = release.GetPublishedAt().Time
+	}
+
+	for _, tag := range tags {
+		if _, ok := dates[tag]; !ok {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (c *Client) KubernetesGoVersion(ctx context.Context, version string) (string, error) {
+	file, _, _, err := c.GitHub.Repositories.GetContents(ctx, "kubernetes", "kubernetes", ".go-version", &github.RepositoryContentGetOptions{
 		Ref: version,
 	})
 	if err != nil {
-		if errors.As(err, &githubError) {
-			if githubError.Response.StatusCode == http.StatusNotFound {
-				return "", err
-			}
-		}
-		return "", err
+		return "", repository.WrapGithubError(err, "kubernetes", "kubernetes", version)
 	}
 
 	goVersion, err := file.GetContent()
@@ -421,7 +531,8 @@ func KubernetesGoVersion(ctx context.Context, client *github.Client, version str
 // VerifyAssets checks the number of assets for the
 // given release and indicates if the expected number has
 // been met.
-func VerifyAssets(ctx context.Context, client *github.Client, owner, repo string, tags []string) (map[string]bool, error) {
+func (c *Client) VerifyAssets(ctx context.Context, opts VerifyAssetsOptions) (*AssetsVerification, error) {
+	owner, repo, tags := opts.Owner, opts.Repo, opts.Tags
 	if len(tags) == 0 {
 		return nil, errors.New("no tags provided")
 	}
@@ -439,18 +550,13 @@ func VerifyAssets(ctx context.Context, client *github.Client, owner, repo string
 			continue
 		}
 
-		release, _, err := client.Repositories.GetReleaseByTag(ctx, owner, repo, tag)
+		release, err := c.Cache.GetReleaseByTag(ctx, c.GitHub, owner, repo, tag)
 		if err != nil {
-			switch err := err.(type) {
-			case *github.ErrorResponse:
-				if err.Response.StatusCode != http.StatusNotFound {
-					return nil, err
-				}
-				releases[tag] = false
-				continue
-			default:
+			if !errors.Is(err, repository.ErrNotFound) {
 				return nil, err
 			}
+			releases[tag] = false
+			continue
 		}
 
 		if repo == rke2Repo && len(release.Assets) == rke2Assets {
@@ -466,188 +572,179 @@ func VerifyAssets(ctx context.Context, client *github.Client, owner, repo string
 		}
 	}
 
-	return releases, nil
+	return &AssetsVerification{Complete: releases}, nil
 }
 
 // ListAssets gets all assets associated with the given release.
-func ListAssets(ctx context.Context, client *github.Client, owner, repo, tag string) ([]*github.ReleaseAsset, error) {
+func (c *Client) ListAssets(ctx context.Context, owner, repo, tag string) ([]Asset, error) {
 	if tag == "" {
 		return nil, errors.New("invalid tag provided")
 	}
 
-	release, _, err := client.Repositories.GetReleaseByTag(ctx, owner, repo, tag)
+	release, err := c.Cache.GetReleaseByTag(ctx, c.GitHub, owner, repo, tag)
 	if err != nil {
-		switch err := err.(type) {
-		case *github.ErrorResponse:
-			if err.Response.StatusCode != http.StatusNotFound {
-				return nil, err
-			}
-		default:
-			return nil, err
-		}
+		return nil, err
 	}
 
-	return release.Assets, nil
+	return releaseAssets(release), nil
 }
 
-// DeleteAssetsByRelease deletes all release assets for the given release tag.
-func DeleteAssetsByRelease(ctx context.Context, client *github.Client, owner, repo, tag string) error {
-	if tag == "" {
-		return errors.New("invalid tag provided")
-	}
-
-	release, _, err := client.Repositories.GetReleaseByTag(ctx, owner, repo, tag)
-	if err != nil {
-		switch err := err.(type) {
-		case *github.ErrorResponse:
-			if err.Response.StatusCode != http.StatusNotFound {
-				return err
-			}
-		default:
-			return err
-		}
-	}
-
-	for _, asset := range release.Assets {
-		if _, err := client.Repositories.DeleteReleaseAsset(ctx, owner, repo, asset.GetID()); err != nil {
-			return err
+// releaseAssets returns the assets of the given GitHub release.
+func releaseAssets(release *github.RepositoryRelease) []Asset {
+	assets := make([]Asset, len(release.Assets))
+	for i, asset := range release.Assets {
+		assets[i] = Asset{
+			ID:          asset.GetID(),
+			Name:        asset.GetName(),
+			Size:        asset.GetSize(),
+			ContentType: asset.GetContentType(),
+			DownloadURL: asset.GetBrowserDownloadURL(),
 		}
 	}
 
-	return nil
+	return assets
 }
 
-// DeleteAssetByID deletes the release asset associated with the given ID.
-func DeleteAssetByID(ctx context.Context, client *github.Client, owner, repo, tag string, id int64) error {
-	if tag == "" {
-		return errors.New("invalid tag provided")
-	}
-
-	if _, err := client.Repositories.DeleteReleaseAsset(ctx, owner, repo, id); err != nil {
-		return err
-	}
+// deleteAssetsConcurrency is the number of assets deleted in parallel.
+const deleteAssetsConcurrency = 8
 
-	return nil
+// DeleteAssetsResult reports the outcome of deleting the assets of a release.
+type DeleteAssetsResult struct {
+	Deleted []string
+	Failed  map[string]error
 }
 
-func goModLibVersion(libraryName, repo, branchVersion string) string {
-	repoName := "k3s-io/k3s"
-	if repo == rke2Repo {
-		repoName = "rancher/rke2"
+// Err returns an error listing the assets that couldn't be deleted, or nil
+// if all of them were deleted.
+func (r *DeleteAssetsResult) Err() error {
+	if len(r.Failed) == 0 {
+		return nil
 	}
 
-	goModURL := "https://raw.githubusercontent.com/" + repoName + "/" + branchVersion + "/go.mod"
-
-	resp, err := http.Get(goModURL)
-	if err != nil {
-		logrus.Debugf("failed to fetch url %s: %v", goModURL, err)
-		return ""
+	names := make([]string, 0, len(r.Failed))
+	for name := range r.Failed {
+		names = append(names, name)
 	}
-	if resp.StatusCode != http.StatusOK {
-		logrus.Debugf("status error: %v when fetching %s", resp.StatusCode, goModURL)
-		return ""
+	sort.Strings(names)
+
+	msgs := make([]string, len(names))
+	for i, name := range names {
+		msgs[i] = name + ": " + r.Failed[name].Error()
 	}
 
-	b, err := io.ReadAll(resp.Body)
-	if err != nil {
-		logrus.Debugf("read body error: %v", err)
-		return ""
+	return errors.New("failed to delete " + strconv.Itoa(len(names)) + " assets:\n" + strings.Join(msgs, "\n"))
+}
+
+// DeleteAssetsByRelease deletes all release assets for the given release tag
+// concurrently. Failures don't stop the remaining deletions and are reported
+// in the result. Assets of GA releases are only deleted when force is set.
+func (c *Client) DeleteAssetsByRelease(ctx context.Context, owner, repo, tag string, force bool) (*DeleteAssetsResult, error) {
+	if tag == "" {
+		return nil, errors.New("invalid tag provided")
 	}
 
-	modFile, err := modfile.Parse("go.mod", b, nil)
+	release, _, err := c.GitHub.Repositories.GetReleaseByTag(ctx, owner, repo, tag)
 	if err != nil {
-		logrus.Debugf("failed to parse go.mod file: %v", err)
-		return ""
+		return nil, repository.WrapGithubError(err, owner, repo, tag)
 	}
 
-	// use replace section if found
-	for _, replace := range modFile.Replace {
-		if strings.Contains(replace.Old.Path, libraryName) {
-			return replace.New.Version
-		}
+	if !release.GetPrerelease() && !force {
+		return nil, errors.New(tag + " is not a prerelease, refusing to delete its assets without force")
 	}
 
-	// if replace not found search in require
-	for _, require := range modFile.Require {
-		if strings.Contains(require.Mod.Path, libraryName) {
-			return require.Mod.Version
-		}
-	}
-	logrus.Debugf("library %s not found", libraryName)
+	defer c.Cache.Invalidate(owner, repo, tag)
 
-	return ""
+	return deleteAssets(ctx, releaseAssets(release), deleteAssetsConcurrency, func(ctx context.Context, id int64) error {
+		_, err := c.GitHub.Repositories.DeleteReleaseAsset(ctx, owner, repo, id)
+		return repository.WrapGithubError(err, owner, repo, tag)
+	}), nil
 }
 
-func buildScriptVersion(varName, repo, branchVersion string) string {
-	repoName := "k3s-io/k3s"
-
-	if repo == rke2Repo {
-		repoName = "rancher/rke2"
+// deleteAssets deletes the given assets with at most limit concurrent calls
+// to the delete function, continuing on errors.
+func deleteAssets(ctx context.Context, assets []Asset, limit int, deleteAsset func(context.Context, int64) error) *DeleteAssetsResult {
+	result := &DeleteAssetsResult{
+		Failed: make(map[string]error),
 	}
 
-	buildScriptURL := "https://raw.githubusercontent.com/" + repoName + "/" + branchVersion + "/scripts/version.sh"
+	var mu sync.Mutex
+	g := new(errgroup.Group)
+	g.SetLimit(limit)
 
-	const regex = `(?P<version>v[\d\.]+(-k3s.\w*)?)`
-	submatch := findInURL(buildScriptURL, regex, varName, true)
+	for _, asset := range assets {
+		asset := asset
+		g.Go(func() error {
+			err := deleteAsset(ctx, asset.ID)
 
-	if len(submatch) > 1 {
-		return submatch[1]
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				result.Failed[asset.Name] = err
+			} else {
+				result.Deleted = append(result.Deleted, asset.Name)
+			}
+
+			return nil
+		})
 	}
+	// failures are collected in the result, so the group never returns one
+	_ = g.Wait()
 
-	return ""
+	sort.Strings(result.Deleted)
+
+	return result
 }
 
-func dockerfileVersion(chartName, repo, branchVersion string) string {
-	if strings.Contains(repo, "k3s") {
-		return ""
+// DeleteAssetByID deletes the release asset associated with the given ID.
+func (c *Client) DeleteAssetByID(ctx context.Context, owner, repo, tag string, id int64) error {
+	if tag == "" {
+		return errors.New("invalid tag provided")
 	}
 
-	const (
-		repoName = "rancher/rke2"
-		regex    = `FROM\s+[\w-]+/[\w-]+:(.*?)(-build.*)?\s`
-	)
-
-	dockerfileURL := "https://raw.githubusercontent.com/" + repoName + "/" + branchVersion + "/Dockerfile"
-
-	submatch := findInURL(dockerfileURL, regex, chartName, true)
-	if len(submatch) > 1 {
-		return submatch[1]
+	if _, err := c.GitHub.Repositories.DeleteReleaseAsset(ctx, owner, repo, id); err != nil {
+		return repository.WrapGithubError(err, owner, repo, tag)
 	}
+	c.Cache.Invalidate(owner, repo, tag)
 
-	return ""
+	return nil
 }
 
-func imageTagVersion(ImageName, repo, branchVersion string) string {
-	repoName := "k3s-io/k3s"
+// goModFile fetches and parses the go.mod file of the given repo, e.g.
+// k3s-io/k3s, at the given ref. It returns nil if it can't be retrieved.
+func (c *Client) goModFile(repoName, ref string) *modfile.File {
+	b := c.repoFiles(repoName, ref).Read("go.mod")
+	if b == nil {
+		return nil
+	}
 
-	imageListURL := "https://raw.githubusercontent.com/" + repoName + "/" + branchVersion + "/scripts/airgap/image-list.txt"
-	if repo == rke2Repo {
-		repoName = "rancher/rke2"
-		imageListURL = "https://raw.githubusercontent.com/" + repoName + "/" + branchVersion + "/scripts/build-images"
+	modFile, err := modfile.Parse("go.mod", b, nil)
+	if err != nil {
+		c.Log.Debugf("failed to parse go.mod file: %v", err)
+		return nil
 	}
 
-	const regex = `:(.*)(-build.*)?`
-	submatch := findInURL(imageListURL, regex, ImageName, true)
+	return modFile
+}
 
-	if len(submatch) > 1 {
-		if strings.Contains(submatch[1], "-build") {
-			versionSplit := strings.Split(submatch[1], "-")
-			return versionSplit[0]
-		}
-		return submatch[1]
+// modLibVersion returns the version of the first module whose path contains
+// the given library name, preferring replacements.
+func modLibVersion(log logrus.FieldLogger, modFile *modfile.File, libraryName string) string {
+	version := components.ModuleVersion(modFile, libraryName)
+	if version == "" {
+		log.Debugf("library %s not found", libraryName)
 	}
 
-	return ""
+	return version
 }
 
-func sqliteVersionBinding(sqliteVersion string) string {
+func (c *Client) sqliteVersionBinding(sqliteVersion string) string {
 	sqliteBindingURL := "https://raw.githubusercontent.com/mattn/go-sqlite3/" + sqliteVersion + "/sqlite3-binding.h"
 	const (
 		regex = `\"(.*)\"`
 		word  = "SQLITE_VERSION"
 	)
 
-	submatch := findInURL(sqliteBindingURL, regex, word, true)
+	submatch := c.findInURL(sqliteBindingURL, regex, word, true)
 	if len(submatch) > 1 {
 		return submatch[1]
 	}
@@ -655,7 +752,7 @@ func sqliteVersionBinding(sqliteVersion string) string {
 	return ""
 }
 
-func createCalicoURL(calicoVersion string) string {
+func (c *Client) createCalicoURL(calicoVersion string) string {
 	const (
 		regex    = `\"(.*)\"`
 		notFound = "Page Not Found"
@@ -676,7 +773,7 @@ func createCalicoURL(calicoVersion string) string {
 	calicoArchiveURL := "https://projectcalico.docs.tigera.io/archive/" + formattedVersion + "/release-notes/#" + strings.Trim(calicoVersion, "")
 
 	// check if doesn't exists content for archive url
-	submatch := findInURL(calicoArchiveURL, regex, notFound, false)
+	submatch := c.findInURL(calicoArchiveURL, regex, notFound, false)
 	if len(submatch) > 1 {
 		return "https://docs.tigera.io/calico/latest/release-notes/#" + formattedVersion
 	}
@@ -686,25 +783,24 @@ func createCalicoURL(calicoVersion string) string {
 
 // findInURL will get and scan a url to find a slice submatch for all the words that matches a regex
 // if the regex is empty then it will return the lines in a file that matches the str
-func findInURL(url, regex, str string, checkStatusCode bool) []string {
+func (c *Client) findInURL(url, regex, str string, checkStatusCode bool) []string {
 	var submatch []string
 
-	client := httpecm.NewClient(defaultTimeout)
-	resp, err := client.Get(url)
+	resp, err := c.HTTP.Get(url)
 	if err != nil {
-		logrus.Debugf("failed to fetch url %s: %v", url, err)
+		c.Log.Debugf("failed to fetch url %s: %v", url, err)
 		return nil
 	}
 	defer resp.Body.Close()
 
 	if checkStatusCode && resp.StatusCode != http.StatusOK {
-		logrus.Debugf("status error: %v when fetching %s", resp.StatusCode, url)
+		c.Log.Debugf("status error: %v when fetching %s", resp.StatusCode, url)
 		return nil
 	}
 
 	b, err := io.ReadAll(resp.Body)
 	if err != nil {
-		logrus.Debugf("read body error: %v", err)
+		c.Log.Debugf("read body error: %v", err)
 		return nil
 	}
 
@@ -728,10 +824,10 @@ func findInURL(url, regex, str string, checkStatusCode bool) []string {
 }
 
 // LatestRC will get the latest rc created for the k8s version in either rke2 or k3s
-func LatestRC(ctx context.Context, owner, repo, k8sVersion, projectSuffix string, client *github.Client) (*string, error) {
+func (c *Client) LatestRC(ctx context.Context, opts LatestRCOptions) (*LatestRCResult, error) {
 	var rcs []*github.RepositoryRelease
 
-	allReleases, _, err := client.Repositories.ListReleases(ctx, owner, repo, &github.ListOptions{
+	allReleases, _, err := c.GitHub.Repositories.ListReleases(ctx, opts.Owner, opts.Repo, &github.ListOptions{
 		Page:    0,
 		PerPage: 40,
 	})
@@ -740,18 +836,23 @@ func LatestRC(ctx context.Context, owner, repo, k8sVersion, projectSuffix string
 	}
 
 	for _, release := range allReleases {
-		if strings.Contains(*release.TagName, k8sVersion+"-rc") && strings.Contains(*release.TagName, projectSuffix) {
+		if strings.Contains(*release.TagName, opts.K8sVersion+"-rc") && strings.Contains(*release.TagName, opts.Suffix) {
 			rcs = append(rcs, release)
 		}
 	}
 
-	return latestRelease(rcs), nil
+	var result LatestRCResult
+	if latest := latestRelease(rcs); latest != nil {
+		result.Tag = *latest
+	}
+
+	return &result, nil
 }
 
-func LatestPreRelease(ctx context.Context, client *github.Client, owner, repo, version, preReleaseSuffix string) (*string, error) {
+func (c *Client) LatestPreRelease(ctx context.Context, owner, repo, version, preReleaseSuffix string) (*string, error) {
 	var versions []*github.RepositoryRelease
 
-	allReleases, _, err := client.Repositories.ListReleases(ctx, owner, repo, &github.ListOptions{
+	allReleases, _, err := c.GitHub.Repositories.ListReleases(ctx, owner, repo, &github.ListOptions{
 		Page:    0,
 		PerPage: 40,
 	})
@@ -806,7 +907,8 @@ func dedup(slice []string) []string {
 
 // Stats collects and processes information regarding a set of releases for the given repo
 // over the given period of time.
-func Stats(ctx context.Context, client *github.Client, startDate, endDate time.Time, owner, repo string) (*StatsData, error) {
+func (c *Client) Stats(ctx context.Context, opts StatsOptions) (*StatsData, error) {
+	startDate, endDate, owner, repo := opts.Start, opts.End, opts.Owner, opts.Repo
 	if endDate.Before(startDate) {
 		return nil, errors.New("end date before start date")
 	}
@@ -820,7 +922,7 @@ func Stats(ctx context.Context, client *github.Client, startDate, endDate time.T
 		PerPage: 100,
 	}
 	for {
-		releases, resp, err := client.Repositories.ListReleases(ctx, owner, repo, &lo)
+		releases, resp, err := c.GitHub.Repositories.ListReleases(ctx, owner, repo, &lo)
 		if err != nil {
 			return nil, err
 		}
@@ -906,47 +1008,20 @@ func latestRelease(versions []*github.RepositoryRelease) *string {
 	return versions[len(versions)-1].TagName
 }
 
-// rke2ChartVersion will return the version of the rke2 chart from the chart versions file
-func rke2ChartsVersion(branchVersion string) (map[string]chart, error) {
-	chartVersionsURL := "https://raw.githubusercontent.com/rancher/rke2/" + branchVersion + "/charts/" + rke2ChartsVersionsFile
-
-	client := httpecm.NewClient(defaultTimeout)
-	resp, err := client.Get(chartVersionsURL)
-	if err != nil {
-		logrus.Debugf("failed to fetch url %s: %v", chartVersionsURL, err)
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		logrus.Debugf("status error: %v when fetching %s", resp.StatusCode, err)
-		return nil, err
-	}
-
-	b, err := io.ReadAll(resp.Body)
+// rke2ChartsVersion returns the charts of the chart versions file of the
+// given rke2 files by name, e.g. rke2-cilium.
+func rke2ChartsVersion(files *repoFiles) (map[string]components.Chart, error) {
+	b, err := files.fetch(rke2ChartsVersionsFile)
 	if err != nil {
 		return nil, err
 	}
 
-	var c charts
-
-	if err := yaml.Unmarshal(b, &c); err != nil {
-		return nil, err
-	}
-
-	chartsData := make(map[string]chart, len(c.Charts))
-	for _, chart := range c.Charts {
-		chartsData[filepath.Base(chart.Filename)] = chart
-	}
-
-	return chartsData, nil
+	return components.ParseChartIndex(b)
 }
 
 var changelogTemplate = `
-{{- define "changelog" -}}
-## Changes since {{.ChangeLogData.PrevMilestone}}:
-{{range .ChangeLogData.Content}}
-* {{ capitalize .Title }} [(#{{.Number}})]({{.URL}})
+{{- define "changelogEntry" -}}
+* {{ capitalize .Title }} [(#{{.Number}})]({{.URL}}){{ with .LinkedIssues }} (fixes {{ join . ", " }}){{ end }}{{ if .Experimental }} 🧪 **Experimental**{{ end }}
 {{- $lines := split .Note "\n"}}
 {{- range $i, $line := $lines}}
 {{- if ne $line "" }}
@@ -954,13 +1029,51 @@ var changelogTemplate = `
 {{- end}}
 {{- end}}
 {{- end}}
+
+{{- define "componentChange" -}}
+{{- with .CompareURL }} ([changes]({{ . }})){{ end -}}
+{{- end}}
+
+{{- define "dates" -}}
+{{- with .PublishDate }} Released on {{ date $.Locale . }}.{{ end -}}
+{{- with .EOLDate }} The {{ $.MajorMinor }} release line reaches end of life on {{ date $.Locale . }}.{{ end -}}
+{{- end}}
+
+{{- define "changelog" -}}
+{{- with upgradeNotes (netChanges .ChangeLogData.Content) -}}
+## Upgrade Notes
+{{range .}}
+{{ template "changelogEntry" . }}
+{{- end}}
+
+{{ end -}}
+{{- with experimentalFeatures (netChanges .ChangeLogData.Content) -}}
+## Experimental Features
+{{range .}}
+{{ template "changelogEntry" . }}
+{{- end}}
+
+{{ end -}}
+## Changes since {{.ChangeLogData.PrevMilestone}}:
+{{range netChanges .ChangeLogData.Content}}
+{{ template "changelogEntry" . }}
+{{- end}}
+{{- with revertedChanges .ChangeLogData.Content }}
+
+## Reverted Changes
+{{range .}}
+* {{ capitalize .Change.Title }} [(#{{.Change.Number}})]({{.Change.URL}})
+{{- range .Steps }}, {{ .Action }} in [#{{.Number}}]({{.URL}}){{ end }}
+{{- if not .Applied }} (no net change){{ end }}
+{{- end}}
+{{- end}}
 {{- end}}`
 
 const rke2ReleaseNoteTemplate = `
 {{- define "rke2" -}}
 <!-- {{.Milestone}} -->
 
-This release updates Kubernetes to {{.K8sVersion}}.
+This release updates Kubernetes to {{.K8sVersion}}.{{ template "dates" . }}
 
 **Important Note**
 
@@ -975,6 +1088,13 @@ cat /var/lib/rancher/rke2/server/token
 
 
 ## Charts Versions
+{{- if .Charts }}
+| Component | Version | App Version |
+| --- | --- | --- |
+{{- range .Charts }}
+| {{ .Name }} | {{ if .URL }}[{{ .Version }}]({{ .URL }}){{ else }}{{ .Version }}{{ end }} | {{ .AppVersion }} |
+{{- end }}
+{{- else }}
 | Component | Version |
 | --- | --- |
 | rke2-cilium | [{{.CiliumChartVersion}}](https://github.com/rancher/rke2-charts/raw/main/assets/rke2-cilium/rke2-cilium-{{.CiliumChartVersion}}.tgz) |
@@ -991,27 +1111,46 @@ cat /var/lib/rancher/rke2/server/token
 | rke2-snapshot-controller | [{{.SnapshotControllerChartVersion}}](https://github.com/rancher/rke2-charts/raw/main/assets/rke2-snapshot-controller/rke2-snapshot-controller-{{.SnapshotControllerChartVersion}}.tgz) |
 | rke2-snapshot-controller-crd | [{{.SnapshotControllerCRDChartVersion}}](https://github.com/rancher/rke2-charts/raw/main/assets/rke2-snapshot-controller/rke2-snapshot-controller-crd-{{.SnapshotControllerCRDChartVersion}}.tgz) |
 | rke2-snapshot-validation-webhook | [{{.SnapshotValidationWebhookChartVersion}}](https://github.com/rancher/rke2-charts/raw/main/assets/rke2-snapshot-validation-webhook/rke2-snapshot-validation-webhook-{{.SnapshotValidationWebhookChartVersion}}.tgz) |
+{{- end }}
 
 
 ## Packaged Component Versions
 | Component | Version |
 | --- | --- |
-| Kubernetes | [{{.K8sVersion}}](https://github.com/kubernetes/kubernetes/blob/master/CHANGELOG/CHANGELOG-{{.MajorMinor}}.md#{{.ChangeLogVersion}}) |
-| Etcd | [{{.EtcdVersion}}](https://github.com/k3s-io/etcd/releases/tag/{{.EtcdVersion}}) |
-| Containerd | [{{.ContainerdVersion}}](https://github.com/k3s-io/containerd/releases/tag/{{.ContainerdVersion}}) |
-| Runc | [{{.RuncVersion}}](https://github.com/opencontainers/runc/releases/tag/{{.RuncVersion}}) |
-| Metrics-server | [{{.MetricsServerVersion}}](https://github.com/kubernetes-sigs/metrics-server/releases/tag/{{.MetricsServerVersion}}) |
-| CoreDNS | [{{.CoreDNSVersion}}](https://github.com/coredns/coredns/releases/tag/{{.CoreDNSVersion}}) |
-| Ingress-Nginx | [{{.IngressNginxVersion}}](https://github.com/rancher/ingress-nginx/releases/tag/{{.IngressNginxVersion}}) |
-| Helm-controller | [{{.HelmControllerVersion}}](https://github.com/k3s-io/helm-controller/releases/tag/{{.HelmControllerVersion}}) |
+| Kubernetes | [{{.K8sVersion}}](https://github.com/kubernetes/kubernetes/blob/master/CHANGELOG/CHANGELOG-{{.MajorMinor}}.md#{{.ChangeLogVersion}}){{ template "componentChange" index .ComponentChanges "K8sVersion" }} |
+| Etcd | [{{.EtcdVersion}}](https://github.com/k3s-io/etcd/releases/tag/{{.EtcdVersion}}){{ template "componentChange" index .ComponentChanges "EtcdVersion" }} |
+| Containerd | [{{.ContainerdVersion}}](https://github.com/k3s-io/containerd/releases/tag/{{.ContainerdVersion}}){{ template "componentChange" index .ComponentChanges "ContainerdVersion" }} |
+| Runc | [{{.RuncVersion}}](https://github.com/opencontainers/runc/releases/tag/{{.RuncVersion}}){{ template "componentChange" index .ComponentChanges "RuncVersion" }} |
+| Metrics-server | [{{.MetricsServerVersion}}](https://github.com/kubernetes-sigs/metrics-server/releases/tag/{{.MetricsServerVersion}}){{ template "componentChange" index .ComponentChanges "MetricsServerVersion" }} |
+| CoreDNS | [{{.CoreDNSVersion}}](https://github.com/coredns/coredns/releases/tag/{{.CoreDNSVersion}}){{ template "componentChange" index .ComponentChanges "CoreDNSVersion" }} |
+| Ingress-Nginx | [{{.IngressNginxVersion}}](https://github.com/rancher/ingress-nginx/releases/tag/{{.IngressNginxVersion}}){{ template "componentChange" index .ComponentChanges "IngressNginxVersion" }} |
+| Helm-controller | [{{.HelmControllerVersion}}](https://github.com/k3s-io/helm-controller/releases/tag/{{.HelmControllerVersion}}){{ template "componentChange" index .ComponentChanges "HelmControllerVersion" }} |
+{{- range .OtherComponents }}
+| {{ .Name }} | {{ .Version }} |
+{{- end }}
 
 ### Available CNIs
 | Component | Version | FIPS Compliant |
 | --- | --- | --- |
-| Canal (Default) | [Flannel {{.FlannelVersion}}](https://github.com/flannel-io/flannel/releases/tag/{{.FlannelVersion}})<br/>[Calico {{.CanalCalicoVersion}}]({{.CanalCalicoURL}}) | Yes |
-| Calico | [{{.CalicoVersion}}]({{.CalicoURL}}) | No |
-| Cilium | [{{.CiliumVersion}}](https://github.com/cilium/cilium/releases/tag/{{.CiliumVersion}}) | No |
-| Multus | [{{.MultusVersion}}](https://github.com/k8snetworkplumbingwg/multus-cni/releases/tag/{{.MultusVersion}}) | No |
+| Canal (Default) | [Flannel {{.FlannelVersion}}](https://github.com/flannel-io/flannel/releases/tag/{{.FlannelVersion}}){{ template "componentChange" index .ComponentChanges "FlannelVersion" }}<br/>[Calico {{.CanalCalicoVersion}}]({{.CanalCalicoURL}}){{ template "componentChange" index .ComponentChanges "CanalCalicoVersion" }} | Yes |
+| Calico | [{{.CalicoVersion}}]({{.CalicoURL}}){{ template "componentChange" index .ComponentChanges "CalicoVersion" }} | No |
+| Cilium | [{{.CiliumVersion}}](https://github.com/cilium/cilium/releases/tag/{{.CiliumVersion}}){{ template "componentChange" index .ComponentChanges "CiliumVersion" }} | No |
+| Multus | [{{.MultusVersion}}](https://github.com/k8snetworkplumbingwg/multus-cni/releases/tag/{{.MultusVersion}}){{ template "componentChange" index .ComponentChanges "MultusVersion" }} | No |
+{{- if .WindowsAgent }}
+
+### Windows Agent Component Versions
+| Component | Version |
+| --- | --- |
+{{- if .WindowsContainerdVersion }}
+| Containerd | [{{.WindowsContainerdVersion}}](https://github.com/k3s-io/containerd/releases/tag/{{.WindowsContainerdVersion}}){{ template "componentChange" index .ComponentChanges "WindowsContainerdVersion" }} |
+{{- end }}
+{{- if .WinsVersion }}
+| Wins | [{{.WinsVersion}}](https://github.com/rancher/wins/releases/tag/{{.WinsVersion}}){{ template "componentChange" index .ComponentChanges "WinsVersion" }} |
+{{- end }}
+{{- if .CalicoWindowsVersion }}
+| Calico for Windows | [{{.CalicoWindowsVersion}}](https://github.com/projectcalico/calico/releases/tag/{{.CalicoWindowsVersion}}){{ template "componentChange" index .ComponentChanges "CalicoWindowsVersion" }} |
+{{- end }}
+{{- end }}
 
 ## Helpful Links
 
@@ -1025,7 +1164,7 @@ const k3sReleaseNoteTemplate = `
 {{- define "k3s" -}}
 <!-- {{.Milestone}} -->
 
-This release updates Kubernetes to {{.K8sVersion}}, and fixes a number of issues.
+This release updates Kubernetes to {{.K8sVersion}}, and fixes a number of issues.{{ template "dates" . }}
 
 For more details on what's new, see the [Kubernetes release notes](https://github.com/kubernetes/kubernetes/blob/master/CHANGELOG/CHANGELOG-{{.MajorMinor}}.md#changelog-since-{{.ChangeLogSince}}).
 
@@ -1034,18 +1173,21 @@ For more details on what's new, see the [Kubernetes release notes](https://githu
 ## Embedded Component Versions
 | Component | Version |
 |---|---|
-| Kubernetes | [{{.K8sVersion}}](https://github.com/kubernetes/kubernetes/blob/master/CHANGELOG/CHANGELOG-{{.MajorMinor}}.md#{{.ChangeLogVersion}}) |
-| Kine | [{{.KineVersion}}](https://github.com/k3s-io/kine/releases/tag/{{.KineVersion}}) |
+| Kubernetes | [{{.K8sVersion}}](https://github.com/kubernetes/kubernetes/blob/master/CHANGELOG/CHANGELOG-{{.MajorMinor}}.md#{{.ChangeLogVersion}}){{ template "componentChange" index .ComponentChanges "K8sVersion" }} |
+| Kine | [{{.KineVersion}}](https://github.com/k3s-io/kine/releases/tag/{{.KineVersion}}){{ template "componentChange" index .ComponentChanges "KineVersion" }} |
 | SQLite | [{{.SQLiteVersion}}](https://sqlite.org/releaselog/{{.SQLiteVersionReplaced}}.html) |
-| Etcd | [{{.EtcdVersion}}](https://github.com/k3s-io/etcd/releases/tag/{{.EtcdVersion}}) |
-| Containerd | [{{.ContainerdVersion}}](https://github.com/k3s-io/containerd/releases/tag/{{.ContainerdVersion}}) |
-| Runc | [{{.RuncVersion}}](https://github.com/opencontainers/runc/releases/tag/{{.RuncVersion}}) |
-| Flannel | [{{.FlannelVersion}}](https://github.com/flannel-io/flannel/releases/tag/{{.FlannelVersion}}) | 
-| Metrics-server | [{{.MetricsServerVersion}}](https://github.com/kubernetes-sigs/metrics-server/releases/tag/{{.MetricsServerVersion}}) |
-| Traefik | [v{{.TraefikVersion}}](https://github.com/traefik/traefik/releases/tag/v{{.TraefikVersion}}) |
-| CoreDNS | [v{{.CoreDNSVersion}}](https://github.com/coredns/coredns/releases/tag/v{{.CoreDNSVersion}}) | 
-| Helm-controller | [{{.HelmControllerVersion}}](https://github.com/k3s-io/helm-controller/releases/tag/{{.HelmControllerVersion}}) |
-| Local-path-provisioner | [{{.LocalPathProvisionerVersion}}](https://github.com/rancher/local-path-provisioner/releases/tag/{{.LocalPathProvisionerVersion}}) |
+| Etcd | [{{.EtcdVersion}}](https://github.com/k3s-io/etcd/releases/tag/{{.EtcdVersion}}){{ template "componentChange" index .ComponentChanges "EtcdVersion" }} |
+| Containerd | [{{.ContainerdVersion}}](https://github.com/k3s-io/containerd/releases/tag/{{.ContainerdVersion}}){{ template "componentChange" index .ComponentChanges "ContainerdVersion" }} |
+| Runc | [{{.RuncVersion}}](https://github.com/opencontainers/runc/releases/tag/{{.RuncVersion}}){{ template "componentChange" index .ComponentChanges "RuncVersion" }} |
+| Flannel | [{{.FlannelVersion}}](https://github.com/flannel-io/flannel/releases/tag/{{.FlannelVersion}}){{ template "componentChange" index .ComponentChanges "FlannelVersion" }} | 
+| Metrics-server | [{{.MetricsServerVersion}}](https://github.com/kubernetes-sigs/metrics-server/releases/tag/{{.MetricsServerVersion}}){{ template "componentChange" index .ComponentChanges "MetricsServerVersion" }} |
+| Traefik | [v{{.TraefikVersion}}](https://github.com/traefik/traefik/releases/tag/v{{.TraefikVersion}}){{ template "componentChange" index .ComponentChanges "TraefikVersion" }} |
+| CoreDNS | [v{{.CoreDNSVersion}}](https://github.com/coredns/coredns/releases/tag/v{{.CoreDNSVersion}}){{ template "componentChange" index .ComponentChanges "CoreDNSVersion" }} | 
+| Helm-controller | [{{.HelmControllerVersion}}](https://github.com/k3s-io/helm-controller/releases/tag/{{.HelmControllerVersion}}){{ template "componentChange" index .ComponentChanges "HelmControllerVersion" }} |
+| Local-path-provisioner | [{{.LocalPathProvisionerVersion}}](https://github.com/rancher/local-path-provisioner/releases/tag/{{.LocalPathProvisionerVersion}}){{ template "componentChange" index .ComponentChanges "LocalPathProvisionerVersion" }} |
+{{- range .OtherComponents }}
+| {{ .Name }} | {{ .Version }} |
+{{- end }}
 
 ## Helpful Links
 As always, we welcome and appreciate feedback from our community of users. Please feel free to:
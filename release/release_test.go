@@ -1,6 +1,25 @@
 package release
 
-import "testing"
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v39/github"
+	"github.com/rancher/ecm-distro-tools/registry"
+	"github.com/rancher/ecm-distro-tools/release/components"
+	"github.com/rancher/ecm-distro-tools/release/rke2"
+	"github.com/rancher/ecm-distro-tools/repository"
+	"github.com/sirupsen/logrus"
+)
 
 func TestMajMin(t *testing.T) {
 	tests := []struct {
@@ -82,3 +101,1026 @@ func TestCapitalize(t *testing.T) {
 		})
 	}
 }
+
+func TestRenderReleaseNotes(t *testing.T) {
+	data, err := json.Marshal(&cliReleaseNoteData{
+		releaseNoteData: releaseNoteData{
+			Milestone: "v2.9.0",
+			ChangeLogData: changeLogData{
+				PrevMilestone: "v2.8.0",
+				Content: []repository.ChangeLog{
+					{Title: "Fix login", Note: "Fix login", Number: 1, URL: "https://github.com/rancher/cli/pull/1"},
+				},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	snapshot := &ReleaseNotesSnapshot{Repo: cliRepo, Milestone: "v2.9.0", PrevMilestone: "v2.8.0", Data: data}
+	b, err := RenderReleaseNotes(snapshot)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{"<!-- v2.9.0 -->", "Fix login", "rancher/cli/pull/1"} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("RenderReleaseNotes() = %q, want it to contain %q", b.String(), want)
+		}
+	}
+
+	snapshot.Repo = "invalid"
+	if _, err := RenderReleaseNotes(snapshot); err == nil {
+		t.Error("RenderReleaseNotes() expected error for invalid repo")
+	}
+}
+
+func TestRenderReleaseNotesUpgradeNotes(t *testing.T) {
+	tests := []struct {
+		name    string
+		content []repository.ChangeLog
+		want    string
+	}{
+		{
+			name: "without upgrade notes",
+			content: []repository.ChangeLog{
+				{Title: "fix login", Note: "Fix login", Number: 1, URL: "https://github.com/rancher/cli/pull/1"},
+			},
+			want: "<!-- v2.9.0 -->\n\n" +
+				"## Changes since v2.8.0:\n\n" +
+				"* Fix login [(#1)](https://github.com/rancher/cli/pull/1)\n" +
+				"  * Fix login\n",
+		},
+		{
+			name: "with upgrade notes",
+			content: []repository.ChangeLog{
+				{Title: "fix login", Note: "Fix login", Number: 1, URL: "https://github.com/rancher/cli/pull/1"},
+				{Title: "drop flag", Note: "The --foo flag was removed", Number: 2, URL: "https://github.com/rancher/cli/pull/2", UpgradeNote: true},
+			},
+			want: "<!-- v2.9.0 -->\n\n" +
+				"## Upgrade Notes\n\n" +
+				"* Drop flag [(#2)](https://github.com/rancher/cli/pull/2)\n" +
+				"  * The --foo flag was removed\n\n" +
+				"## Changes since v2.8.0:\n\n" +
+				"* Fix login [(#1)](https://github.com/rancher/cli/pull/1)\n" +
+				"  * Fix login\n" +
+				"* Drop flag [(#2)](https://github.com/rancher/cli/pull/2)\n" +
+				"  * The --foo flag was removed\n",
+		},
+		{
+			name: "with experimental features",
+			content: []repository.ChangeLog{
+				{Title: "fix login", Number: 1, URL: "https://github.com/rancher/cli/pull/1"},
+				{Title: "add nftables mode", Number: 2, URL: "https://github.com/rancher/cli/pull/2", Experimental: true},
+			},
+			want: "<!-- v2.9.0 -->\n\n" +
+				"## Experimental Features\n\n" +
+				"* Add nftables mode [(#2)](https://github.com/rancher/cli/pull/2) 🧪 **Experimental**\n\n" +
+				"## Changes since v2.8.0:\n\n" +
+				"* Fix login [(#1)](https://github.com/rancher/cli/pull/1)\n" +
+				"* Add nftables mode [(#2)](https://github.com/rancher/cli/pull/2) 🧪 **Experimental**\n",
+		},
+		{
+			name: "with linked issues",
+			content: []repository.ChangeLog{
+				{Title: "fix login", Number: 1, URL: "https://github.com/rancher/cli/pull/1", LinkedIssues: []string{"#10", "rancher/rancher#20"}},
+			},
+			want: "<!-- v2.9.0 -->\n\n" +
+				"## Changes since v2.8.0:\n\n" +
+				"* Fix login [(#1)](https://github.com/rancher/cli/pull/1) (fixes #10, rancher/rancher#20)\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := json.Marshal(&cliReleaseNoteData{
+				releaseNoteData: releaseNoteData{
+					Milestone: "v2.9.0",
+					ChangeLogData: changeLogData{
+						PrevMilestone: "v2.8.0",
+						Content:       tt.content,
+					},
+				},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			b, err := RenderReleaseNotes(&ReleaseNotesSnapshot{Repo: cliRepo, Data: data})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if b.String() != tt.want {
+				t.Errorf("RenderReleaseNotes() = %q, want %q", b.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestMergeComponents(t *testing.T) {
+	k3s := []Component{{"Etcd", "v3.5.9-k3s1"}, {"Containerd", "v1.7.11-k3s2"}, {"CoreDNS", "1.10.1"}, {"Traefik", "2.10.5"}}
+	rke2 := []Component{{"Etcd", "v3.5.9-k3s1"}, {"Containerd", "v1.7.11-k3s1"}, {"CoreDNS", "v1.10.1"}, {"Calico", ""}, {"Cilium", "v1.14.4"}}
+
+	want := []UnifiedComponent{
+		{Name: "Etcd", K3sVersion: "v3.5.9-k3s1", RKE2Version: "v3.5.9-k3s1"},
+		{Name: "Containerd", K3sVersion: "v1.7.11-k3s2", RKE2Version: "v1.7.11-k3s1"},
+		{Name: "CoreDNS", K3sVersion: "v1.10.1", RKE2Version: "v1.10.1"},
+		{Name: "Traefik", K3sVersion: "v2.10.5"},
+		{Name: "Calico"},
+		{Name: "Cilium", RKE2Version: "v1.14.4"},
+	}
+
+	got := mergeComponents(k3s, rke2)
+	if len(got) != len(want) {
+		t.Fatalf("mergeComponents() = %v, want %v", got, want)
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			t.Errorf("mergeComponents()[%d] = %v, want %v", i, got[i], want[i])
+		}
+	}
+
+	data := &unifiedReleaseNoteData{Components: got}
+	if shared := data.SharedComponents(); len(shared) != 2 || shared[0].Name != "Etcd" || shared[1].Name != "CoreDNS" {
+		t.Errorf("SharedComponents() = %v, want Etcd and CoreDNS", shared)
+	}
+	if product := data.ProductComponents(); len(product) != 3 {
+		t.Errorf("ProductComponents() = %v, want Containerd, Traefik and Cilium", product)
+	}
+}
+
+func TestRenderUnifiedReleaseNotes(t *testing.T) {
+	k3sData, err := json.Marshal(&k3sReleaseNoteData{
+		releaseNoteData: releaseNoteData{
+			Milestone:     "v1.29.2+k3s1",
+			MajorMinor:    "1.29",
+			ChangeLogData: changeLogData{PrevMilestone: "v1.29.1+k3s2", Content: []repository.ChangeLog{{Title: "bump kine", Number: 1, URL: "https://github.com/k3s-io/k3s/pull/1"}}},
+		},
+		K8sVersion:  "v1.29.2",
+		EtcdVersion: "v3.5.9-k3s1",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rke2Data, err := json.Marshal(&rke2ReleaseNoteData{
+		releaseNoteData: releaseNoteData{
+			Milestone:     "v1.29.2+rke2r1",
+			MajorMinor:    "1.29",
+			ChangeLogData: changeLogData{PrevMilestone: "v1.29.1+rke2r1", Content: []repository.ChangeLog{{Title: "bump calico", Number: 2, URL: "https://github.com/rancher/rke2/pull/2"}}},
+		},
+		K8sVersion:    "v1.29.2",
+		EtcdVersion:   "v3.5.9-k3s1",
+		CalicoVersion: "v3.27.0",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	k3s := &ReleaseNotesSnapshot{Repo: k3sRepo, Data: k3sData}
+	rke2 := &ReleaseNotesSnapshot{Repo: rke2Repo, Data: rke2Data}
+	b, err := RenderUnifiedReleaseNotes(k3s, rke2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{
+		"<!-- v1.29.2+k3s1 v1.29.2+rke2r1 -->",
+		"| Etcd | v3.5.9-k3s1 |",
+		"| Calico | - | v3.27.0 |",
+		"* Bump kine [(#1)](https://github.com/k3s-io/k3s/pull/1)",
+		"* Bump calico [(#2)](https://github.com/rancher/rke2/pull/2)",
+	} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("RenderUnifiedReleaseNotes() = %q, want it to contain %q", b.String(), want)
+		}
+	}
+
+	if _, err := RenderUnifiedReleaseNotes(rke2, k3s); err == nil {
+		t.Error("RenderUnifiedReleaseNotes() expected error for swapped snapshots")
+	}
+}
+
+func TestNotesOptionsValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    NotesOptions
+		wantErr bool
+	}{
+		{
+			name: "valid",
+			opts: NotesOptions{Owner: "rancher", Repo: "rke2", Milestone: "v1.29.2+rke2r1", PrevMilestone: "v1.29.1+rke2r1"},
+		},
+		{
+			name:    "missing repo",
+			opts:    NotesOptions{Owner: "rancher", Milestone: "v1.29.2+rke2r1", PrevMilestone: "v1.29.1+rke2r1"},
+			wantErr: true,
+		},
+		{
+			name:    "missing previous milestone",
+			opts:    NotesOptions{Owner: "rancher", Repo: "rke2", Milestone: "v1.29.2+rke2r1"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.opts.validate(); (err != nil) != tt.wantErr {
+				t.Errorf("validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestReleaseNotesSnapshotInvalidMilestone(t *testing.T) {
+	c := &Client{}
+	for _, milestones := range [][2]string{{"master", "v1.29.1+k3s1"}, {"v1.29.2+k3s1", "v1.29"}} {
+		if _, err := c.releaseNotesSnapshot(k3sRepo, milestones[0], milestones[1], nil); err == nil {
+			t.Errorf("releaseNotesSnapshot(%s, %s) succeeded, want an invalid version error", milestones[0], milestones[1])
+		}
+	}
+}
+
+func TestNotesSnapshotPullRequestsInvalidMilestone(t *testing.T) {
+	pullRequests := `{"data": {"repository": {"pr0": {"number": 1, "title": "bump kine"}}}}`
+	c := NewClient(github.NewClient(&http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusOK, Header: http.Header{"Content-Type": {"application/json"}}, Body: io.NopCloser(strings.NewReader(pullRequests))}, nil
+	})}))
+
+	_, err := c.NotesSnapshot(context.Background(), NotesOptions{
+		Owner:         "k3s-io",
+		Repo:          k3sRepo,
+		Milestone:     "master",
+		PrevMilestone: "v1.29.1+k3s1",
+		PullRequests:  []int{1},
+	})
+	if err == nil {
+		t.Error("NotesSnapshot() succeeded, want an invalid version error")
+	}
+}
+
+func TestCheckAssetRules(t *testing.T) {
+	asset := func(name, contentType string) Asset {
+		return Asset{Name: name, ContentType: contentType, Size: 1}
+	}
+
+	assets := []Asset{
+		asset("k3s", "application/octet-stream"),
+		asset("k3s-arm64", "application/octet-stream"),
+		asset("k3s-armhf", "application/octet-stream"),
+		asset("k3s-images.txt", "text/plain; charset=utf-8"),
+		asset("k3s-airgap-images-amd64.tar.zst", "application/zstd"),
+		asset("sha256sum-amd64.txt", "application/octet-stream"),
+		asset("sha256sum-arm64.txt", "text/plain"),
+		asset("sha256sums-arm.txt", "text/plain"),
+		{Name: "k3s-airgap-images-arm64.tar.gz", ContentType: "application/gzip"},
+	}
+
+	want := []string{
+		"k3s-airgap-images-arm64.tar.gz: is empty",
+		"sha256sum-amd64.txt: unexpected content type application/octet-stream",
+		"sha256sum-arm.txt: is missing",
+		"sha256sums-arm.txt: doesn't follow the k3s naming conventions",
+	}
+
+	violations := checkAssetRules(k3sRepo, assets)
+	if len(violations) != len(want) {
+		t.Fatalf("checkAssetRules() = %v, want %v", violations, want)
+	}
+	for i := range want {
+		if violations[i].String() != want[i] {
+			t.Errorf("checkAssetRules()[%d] = %q, want %q", i, violations[i].String(), want[i])
+		}
+	}
+
+	checks := assetChecks(k3sRepo, assets)
+	if len(checks) != len(assets)+1 {
+		t.Fatalf("assetChecks() returned %d checks, want one per asset and missing asset: %v", len(checks), checks)
+	}
+	var failing []string
+	for _, check := range checks {
+		if !check.OK() {
+			failing = append(failing, check.Asset)
+		}
+	}
+	wantFailing := []string{"k3s-airgap-images-arm64.tar.gz", "sha256sum-amd64.txt", "sha256sum-arm.txt", "sha256sums-arm.txt"}
+	if !reflect.DeepEqual(failing, wantFailing) {
+		t.Errorf("failing assetChecks() = %v, want %v", failing, wantFailing)
+	}
+}
+
+func TestDeleteAssets(t *testing.T) {
+	assets := []Asset{
+		{ID: 1, Name: "rke2.linux-amd64.tar.gz"},
+		{ID: 2, Name: "rke2.linux-arm64.tar.gz"},
+		{ID: 3, Name: "sha256sum-amd64.txt"},
+	}
+
+	var calls int32
+	result := deleteAssets(context.Background(), assets, 2, func(_ context.Context, id int64) error {
+		atomic.AddInt32(&calls, 1)
+		if id == 2 {
+			return errors.New("server error")
+		}
+		return nil
+	})
+
+	if calls != 3 {
+		t.Errorf("expected all 3 assets to be attempted, got %d", calls)
+	}
+	want := []string{"rke2.linux-amd64.tar.gz", "sha256sum-amd64.txt"}
+	if strings.Join(result.Deleted, ",") != strings.Join(want, ",") {
+		t.Errorf("Deleted = %v, want %v", result.Deleted, want)
+	}
+	if _, ok := result.Failed["rke2.linux-arm64.tar.gz"]; !ok || len(result.Failed) != 1 {
+		t.Errorf("Failed = %v, want rke2.linux-arm64.tar.gz", result.Failed)
+	}
+	if err := result.Err(); err == nil || !strings.Contains(err.Error(), "rke2.linux-arm64.tar.gz: server error") {
+		t.Errorf("Err() = %v", err)
+	}
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestFillComponents(t *testing.T) {
+	const (
+		goMod = `module github.com/k3s-io/k3s
+
+require (
+	github.com/containerd/containerd v1.7.13
+	github.com/k3s-io/kine v0.11.4
+	github.com/spegel-org/spegel v0.0.18
+)
+
+replace github.com/containerd/containerd => github.com/k3s-io/containerd v1.7.13-k3s1
+`
+		imageList = `docker.io/rancher/mirrored-coredns-coredns:1.10.1
+docker.io/rancher/mirrored-library-traefik:2.10.5
+`
+	)
+	files := map[string]string{
+		"https://raw.githubusercontent.com/k3s-io/k3s/v1.29.2+k3s1/go.mod":                        goMod,
+		"https://raw.githubusercontent.com/k3s-io/k3s/v1.29.2+k3s1/scripts/airgap/image-list.txt": imageList,
+	}
+	requested := make(map[string]int)
+	c := NewClient(nil)
+	c.HTTP = &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		requested[req.URL.String()]++
+		body, ok := files[req.URL.String()]
+		if !ok {
+			return &http.Response{StatusCode: http.StatusNotFound, Status: "404 Not Found", Body: io.NopCloser(strings.NewReader(""))}, nil
+		}
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(body))}, nil
+	})}
+
+	specs := []components.Spec{
+		{Field: "KineVersion", Source: components.GoMod, Name: "kine"},
+		{Field: "ContainerdVersion", Source: components.BuildScript, Name: "VERSION_CONTAINERD"},
+		{Field: "ContainerdVersion", Source: components.GoMod, Name: "containerd/containerd"},
+		{Field: "EtcdVersion", Source: components.GoMod, Name: "etcd"},
+		{Field: "TraefikVersion", Source: components.ImageList, Name: "traefik", Path: "scripts/airgap/image-list.txt"},
+		{Field: "CoreDNSVersion", Source: components.ImageList, Name: "coredns", Path: "scripts/airgap/image-list.txt"},
+		{Field: "Spegel", Source: components.GoMod, Name: "spegel"},
+	}
+	rd := &k3sReleaseNoteData{K8sVersion: "v1.29.2"}
+	if err := fillComponents(rd, c.repoFiles("k3s-io/k3s", "v1.29.2+k3s1"), specs, rd.K8sVersion); err != nil {
+		t.Fatal(err)
+	}
+
+	got := map[string]string{
+		"KineVersion":       rd.KineVersion,
+		"ContainerdVersion": rd.ContainerdVersion,
+		"EtcdVersion":       rd.EtcdVersion,
+		"TraefikVersion":    rd.TraefikVersion,
+		"CoreDNSVersion":    rd.CoreDNSVersion,
+	}
+	want := map[string]string{
+		"KineVersion":       "v0.11.4",
+		"ContainerdVersion": "v1.7.13-k3s1",
+		"EtcdVersion":       "",
+		"TraefikVersion":    "2.10.5",
+		"CoreDNSVersion":    "1.10.1",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("fillComponents() = %v, want %v", got, want)
+	}
+	if want := []Component{{Name: "Spegel", Version: "v0.0.18"}}; !reflect.DeepEqual(rd.OtherComponents, want) {
+		t.Errorf("OtherComponents = %v, want %v", rd.OtherComponents, want)
+	}
+	for url, n := range requested {
+		if n != 1 {
+			t.Errorf("requested %s %d times, want once", url, n)
+		}
+	}
+
+	data, err := json.Marshal(rd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	notes, err := RenderReleaseNotes(&ReleaseNotesSnapshot{Repo: k3sRepo, Milestone: "v1.29.2+k3s1", Data: data})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(notes.String(), "|\n| Spegel | v0.0.18 |\n\n## Helpful Links") {
+		t.Errorf("the notes don't list Spegel in their component table:\n%s", notes)
+	}
+
+	if err := fillComponents(rd, c.repoFiles("k3s-io/k3s", "v1.29.2+k3s1"), []components.Spec{{Field: "KineVersion", Source: "helm"}}, rd.K8sVersion); err == nil {
+		t.Error("fillComponents() with an invalid source didn't return an error")
+	}
+}
+
+func TestAddReleaseDates(t *testing.T) {
+	published := func(tag string, day int, draft bool) *github.RepositoryRelease {
+		return &github.RepositoryRelease{
+			TagName:     github.String(tag),
+			Draft:       github.Bool(draft),
+			PublishedAt: &github.Timestamp{Time: time.Date(2024, 3, day, 0, 0, 0, 0, time.UTC)},
+		}
+	}
+	releases := []*github.RepositoryRelease{
+		published("v1.29.2", 2, false),
+		published("v1.29.1", 1, false),
+		published("v1.29.3", 3, true),
+	}
+
+	tests := []struct {
+		name     string
+		tags     []string
+		want     map[string]int
+		complete bool
+	}{
+		{
+			name:     "all found",
+			tags:     []string{"v1.29.1", "v1.29.2"},
+			want:     map[string]int{"v1.29.1": 1, "v1.29.2": 2},
+			complete: true,
+		},
+		{
+			name: "missing tag",
+			tags: []string{"v1.29.2", "v1.29.0"},
+			want: map[string]int{"v1.29.2": 2},
+		},
+		{
+			name: "drafts are ignored",
+			tags: []string{"v1.29.3"},
+			want: map[string]int{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dates := make(map[string]time.Time)
+			if complete := addReleaseDates(dates, releases, tt.tags); complete != tt.complete {
+				t.Errorf("addReleaseDates() = %t, want %t", complete, tt.complete)
+			}
+			if len(dates) != len(tt.want) {
+				t.Fatalf("dates = %v, want %v", dates, tt.want)
+			}
+			for tag, day := range tt.want {
+				if dates[tag].Day() != day {
+					t.Errorf("dates[%s] = %s, want day %d", tag, dates[tag], day)
+				}
+			}
+		})
+	}
+}
+
+func TestNewTemplateReference(t *testing.T) {
+	data, err := json.Marshal(&k3sReleaseNoteData{
+		K8sVersion: "v1.29.2",
+		releaseNoteData: releaseNoteData{
+			Milestone: "v1.29.2+k3s1",
+			ChangeLogData: changeLogData{
+				Content: []repository.ChangeLog{
+					{Title: "Bump kine", Number: 1, LinkedIssues: []string{"#10", "#11"}},
+				},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	snapshot := &ReleaseNotesSnapshot{Repo: k3sRepo, Milestone: "v1.29.2+k3s1", Data: data}
+
+	ref, err := NewTemplateReference(k3sRepo, snapshot)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := strings.Join(ref.Templates, ","); got != "changelog,changelogEntry,componentChange,dates,k3s" {
+		t.Errorf("Templates = %s", got)
+	}
+
+	examples := make(map[string]string)
+	for _, v := range ref.Variables {
+		examples[v.Name] = v.Example
+	}
+	for name, want := range map[string]string{
+		".K8sVersion":                           "v1.29.2",
+		".Milestone":                            "v1.29.2+k3s1",
+		".ChangeLogData.Content":                "1 entries",
+		".ChangeLogData.Content[].Title":        "Bump kine",
+		".ChangeLogData.Content[].LinkedIssues": "#10, #11",
+		".ChangeLogData.PrevMilestone":          "",
+	} {
+		got, ok := examples[name]
+		if !ok {
+			t.Errorf("missing variable %s", name)
+			continue
+		}
+		if got != want {
+			t.Errorf("%s example = %q, want %q", name, got, want)
+		}
+	}
+
+	var majMinSignature string
+	for _, f := range ref.Functions {
+		if f.Name == "majMin" {
+			majMinSignature = f.Signature
+		}
+	}
+	if majMinSignature != "func(string) (string, error)" {
+		t.Errorf("majMin signature = %q", majMinSignature)
+	}
+
+	if _, err := NewTemplateReference(rke2Repo, snapshot); err == nil {
+		t.Error("expected an error for a snapshot of another repo")
+	}
+	if _, err := RenderTemplateReference(ref); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestAncillaryReleaseNotes(t *testing.T) {
+	const goMod = `module github.com/k3s-io/kine
+
+go 1.22
+
+require (
+	github.com/go-sql-driver/mysql v1.8.1
+	github.com/mattn/go-sqlite3 v1.14.22
+	go.etcd.io/etcd/server/v3 v3.5.13
+)
+`
+	var requested string
+	c := NewClient(nil)
+	c.HTTP = &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		requested = req.URL.String()
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(strings.NewReader(goMod)),
+		}, nil
+	})}
+
+	rd := &ancillaryReleaseNoteData{
+		Name: kineRepo,
+		releaseNoteData: releaseNoteData{
+			Milestone:     "v0.11.8",
+			ChangeLogData: changeLogData{PrevMilestone: "v0.11.7"},
+		},
+	}
+	if err := rd.Fill(c, "v0.11.8"); err != nil {
+		t.Fatal(err)
+	}
+	if want := "https://raw.githubusercontent.com/k3s-io/kine/v0.11.8/go.mod"; requested != want {
+		t.Errorf("requested %s, want %s", requested, want)
+	}
+
+	data, err := json.Marshal(rd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := RenderReleaseNotes(&ReleaseNotesSnapshot{Repo: kineRepo, Milestone: "v0.11.8", PrevMilestone: "v0.11.7", Data: data})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{
+		"This release updates kine to v0.11.8.",
+		"| Go | 1.22 |",
+		"| Etcd | v3.5.13 |",
+		"| MySQL | v1.8.1 |",
+		"| SQLite | v1.14.22 |",
+		"## Changes since v0.11.7:",
+	} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("RenderReleaseNotes() = %q, want it to contain %q", b.String(), want)
+		}
+	}
+	if strings.Contains(b.String(), "NATS") {
+		t.Errorf("RenderReleaseNotes() = %q, expected modules missing from go.mod to be omitted", b.String())
+	}
+}
+
+func TestRKE2Charts(t *testing.T) {
+	const (
+		dockerfile = `FROM rancher/hardened-runc:v1.1.12-build20240201 AS runc
+RUN CHART_VERSION="v3.27.0-build2024020601" CHART_FILE=/charts/rke2-canal.yaml CHART_BOOTSTRAP=true /charts/build-chart.sh
+RUN CHART_VERSION="1.29.001"                CHART_FILE=/charts/rke2-coredns.yaml CHART_BOOTSTRAP=true /charts/build-chart.sh
+RUN CHART_VERSION="3.12.002"                CHART_FILE=/charts/rke2-metrics-server.yaml /charts/build-chart.sh
+`
+		index = `entries:
+  rke2-canal:
+  - name: rke2-canal
+    version: v3.27.0-build2024020601
+    appVersion: v3.27.0
+    urls:
+    - assets/rke2-canal/rke2-canal-v3.27.0-build2024020601.tgz
+  rke2-coredns:
+  - name: rke2-coredns
+    version: 1.29.001
+    appVersion: 1.11.1
+    urls:
+    - assets/rke2-coredns/rke2-coredns-1.29.001.tgz
+`
+	)
+	files := map[string]string{
+		"https://raw.githubusercontent.com/rancher/rke2/v1.29.2+rke2r1/Dockerfile": dockerfile,
+		rke2.ChartsIndexURL: index,
+	}
+
+	c := NewClient(nil)
+	c.HTTP = &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		body, ok := files[req.URL.String()]
+		if !ok {
+			return &http.Response{StatusCode: http.StatusNotFound, Status: "404 Not Found", Body: io.NopCloser(strings.NewReader(""))}, nil
+		}
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(body))}, nil
+	})}
+
+	want := []ChartVersion{
+		{Name: "rke2-canal", Version: "v3.27.0-build2024020601", AppVersion: "v3.27.0", URL: "https://raw.githubusercontent.com/rancher/rke2-charts/main/assets/rke2-canal/rke2-canal-v3.27.0-build2024020601.tgz"},
+		{Name: "rke2-coredns", Version: "1.29.001", AppVersion: "1.11.1", URL: "https://raw.githubusercontent.com/rancher/rke2-charts/main/assets/rke2-coredns/rke2-coredns-1.29.001.tgz"},
+		{Name: "rke2-metrics-server", Version: "3.12.002"},
+	}
+	charts := c.rke2Charts(c.repoFiles("rancher/rke2", "v1.29.2+rke2r1"), nil)
+	if !reflect.DeepEqual(charts, want) {
+		t.Errorf("rke2Charts() = %+v, want %+v", charts, want)
+	}
+
+	chartVersions := map[string]components.Chart{
+		"rke2-cilium": {Version: "1.15.100", Filename: "/charts/rke2-cilium.yaml"},
+		"rke2-canal":  {Version: "v3.27.0-build2024020601", Filename: "/charts/rke2-canal.yaml", Bootstrap: true},
+	}
+	if got := c.rke2Charts(c.repoFiles("rancher/rke2", "v1.30.0+rke2r1"), chartVersions); len(got) != 2 || got[0].Name != "rke2-canal" || got[0].AppVersion != "v3.27.0" || got[1] != (ChartVersion{Name: "rke2-cilium", Version: "1.15.100"}) {
+		t.Errorf("rke2Charts() = %+v, want the charts of the chart versions file", got)
+	}
+
+	data, err := json.Marshal(&rke2ReleaseNoteData{
+		K8sVersion: "v1.29.2",
+		Charts:     charts,
+		releaseNoteData: releaseNoteData{
+			Milestone:  "v1.29.2+rke2r1",
+			MajorMinor: "1.29",
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := RenderReleaseNotes(&ReleaseNotesSnapshot{Repo: rke2Repo, Milestone: "v1.29.2+rke2r1", Data: data})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{
+		"| Component | Version | App Version |",
+		"| rke2-coredns | [1.29.001](https://raw.githubusercontent.com/rancher/rke2-charts/main/assets/rke2-coredns/rke2-coredns-1.29.001.tgz) | 1.11.1 |",
+		"| rke2-metrics-server | 3.12.002 |  |",
+	} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("RenderReleaseNotes() = %q, want it to contain %q", b.String(), want)
+		}
+	}
+	if strings.Contains(b.String(), "rke2-cilium") {
+		t.Errorf("RenderReleaseNotes() = %q, expected only the packaged charts", b.String())
+	}
+}
+
+func TestRKE2WindowsAgent(t *testing.T) {
+	const dockerfile = `FROM mcr.microsoft.com/windows/servercore:ltsc2022 AS base
+ENV CONTAINERD_VERSION="v1.7.11-k3s2"
+ENV WINS_VERSION="v0.4.13"
+ENV CALICO_VERSION="v3.26.3"
+`
+	files := map[string]string{
+		"https://raw.githubusercontent.com/rancher/rke2/v1.29.2+rke2r1/Dockerfile.windows":          dockerfile,
+		"https://raw.githubusercontent.com/rancher/rke2/v1.29.2+rke2r1/charts/chart_versions.yaml":  "charts: []\n",
+		"https://raw.githubusercontent.com/rancher/rke2/v1.20.15+rke2r1/charts/chart_versions.yaml": "charts: []\n",
+	}
+	c := NewClient(nil)
+	c.HTTP = &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		body, ok := files[req.URL.String()]
+		if !ok {
+			return &http.Response{StatusCode: http.StatusNotFound, Status: "404 Not Found", Body: io.NopCloser(strings.NewReader(""))}, nil
+		}
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(body))}, nil
+	})}
+
+	tests := []struct {
+		milestone string
+		want      bool
+	}{
+		{milestone: "v1.29.2+rke2r1", want: true},
+		{milestone: "v1.20.15+rke2r1", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.milestone, func(t *testing.T) {
+			rd := &rke2ReleaseNoteData{
+				K8sVersion:      strings.Split(tt.milestone, "+")[0],
+				releaseNoteData: releaseNoteData{Milestone: tt.milestone, MajorMinor: "1.29"},
+			}
+			if err := rd.Fill(c, tt.milestone); err != nil {
+				t.Fatal(err)
+			}
+			if rd.WindowsAgent != tt.want {
+				t.Errorf("WindowsAgent = %t, want %t", rd.WindowsAgent, tt.want)
+			}
+
+			data, err := json.Marshal(rd)
+			if err != nil {
+				t.Fatal(err)
+			}
+			b, err := RenderReleaseNotes(&ReleaseNotesSnapshot{Repo: rke2Repo, Milestone: tt.milestone, Data: data})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := strings.Contains(b.String(), "### Windows Agent Component Versions"); got != tt.want {
+				t.Errorf("RenderReleaseNotes() renders the Windows agent section: %t, want %t", got, tt.want)
+			}
+			if !tt.want {
+				return
+			}
+			for _, want := range []string{
+				"| Containerd | [v1.7.11-k3s2](https://github.com/k3s-io/containerd/releases/tag/v1.7.11-k3s2) |",
+				"| Wins | [v0.4.13](https://github.com/rancher/wins/releases/tag/v0.4.13) |",
+				"| Calico for Windows | [v3.26.3](https://github.com/projectcalico/calico/releases/tag/v3.26.3) |",
+			} {
+				if !strings.Contains(b.String(), want) {
+					t.Errorf("RenderReleaseNotes() = %q, want it to contain %q", b.String(), want)
+				}
+			}
+		})
+	}
+}
+
+func TestImageComponents(t *testing.T) {
+	const imageList = `docker.io/rancher/hardened-etcd:v3.5.9-k3s1-build20230802
+docker.io/rancher/hardened-coredns:v1.10.1-build20230607
+docker.io/rancher/mirrored-cilium-cilium:v1.14.1
+
+docker.io/rancher/rke2-runtime:v1.29.2-rke2r1
+`
+	images, err := parseImageList(strings.NewReader(imageList))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(images) != 4 || images["hardened-etcd"].TagStr() != "v3.5.9-k3s1-build20230802" {
+		t.Fatalf("parseImageList() = %v", images)
+	}
+
+	labels := map[string]string{registry.VersionLabel: "v1.10.1"}
+	if got := imageVersion(images["hardened-etcd"].TagStr(), nil); got != "v3.5.9-k3s1" {
+		t.Errorf("imageVersion() = %s, want v3.5.9-k3s1", got)
+	}
+	if got := imageVersion("v1.10.1-build20230607", labels); got != "v1.10.1" {
+		t.Errorf("imageVersion() = %s, want the label value v1.10.1", got)
+	}
+
+	packages := []registry.Package{
+		{Name: "github.com/containerd/containerd", Version: "v1.7.11-k3s2"},
+		{Name: "github.com/opencontainers/runc", Version: "v1.1.12"},
+	}
+	if got := packageVersion(packages, "opencontainers/runc"); got != "v1.1.12" {
+		t.Errorf("packageVersion() = %s, want v1.1.12", got)
+	}
+	if got := packageVersion(packages, "runc"); got != "v1.1.12" {
+		t.Errorf("packageVersion() = %s, want v1.1.12", got)
+	}
+	if got := packageVersion(packages, "flannel-io/flannel"); got != "" {
+		t.Errorf("packageVersion() = %s, want no version", got)
+	}
+
+	rd := &rke2ReleaseNoteData{EtcdVersion: "v3.5.8-k3s1", RuncVersion: "v1.1.11"}
+	setComponentVersions(logrus.StandardLogger(), rd, map[string]string{
+		"EtcdVersion":       "v3.5.9-k3s1",
+		"RuncVersion":       "",
+		"ContainerdVersion": "v1.7.11-k3s2",
+		"Unknown":           "v1",
+	})
+	if rd.EtcdVersion != "v3.5.9-k3s1" || rd.RuncVersion != "v1.1.11" || rd.ContainerdVersion != "v1.7.11-k3s2" {
+		t.Errorf("setComponentVersions() = %+v", rd)
+	}
+}
+
+func TestComponentChanges(t *testing.T) {
+	prev := &k3sReleaseNoteData{K8sVersion: "v1.29.1", EtcdVersion: "v3.5.9-k3s1", TraefikVersion: "2.10.5", RuncVersion: "v1.1.12"}
+	current := &k3sReleaseNoteData{K8sVersion: "v1.29.2", EtcdVersion: "v3.5.9-k3s1", TraefikVersion: "2.10.7", RuncVersion: "v1.1.12", KineVersion: "v0.11.4"}
+
+	changes := componentChanges(k3sRepo, prev, current)
+
+	want := map[string]ComponentChange{
+		"K8sVersion": {
+			Previous:   "v1.29.1",
+			Current:    "v1.29.2",
+			CompareURL: "https://github.com/kubernetes/kubernetes/compare/v1.29.1...v1.29.2",
+		},
+		"TraefikVersion": {
+			Previous:   "2.10.5",
+			Current:    "2.10.7",
+			CompareURL: "https://github.com/traefik/traefik/compare/v2.10.5...v2.10.7",
+		},
+	}
+	if !reflect.DeepEqual(changes, want) {
+		t.Errorf("componentChanges() = %v, want %v", changes, want)
+	}
+
+	current.Milestone = "v1.29.2+k3s1"
+	current.MajorMinor = "1.29"
+	current.ComponentChanges = changes
+	data, err := json.Marshal(current)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := RenderReleaseNotes(&ReleaseNotesSnapshot{Repo: k3sRepo, Milestone: "v1.29.2+k3s1", PrevMilestone: "v1.29.1+k3s1", Data: data})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{
+		"(https://github.com/traefik/traefik/releases/tag/v2.10.7) ([changes](https://github.com/traefik/traefik/compare/v2.10.5...v2.10.7)) |",
+		"(https://github.com/opencontainers/runc/releases/tag/v1.1.12) |",
+	} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("RenderReleaseNotes() = %q, want it to contain %q", b.String(), want)
+		}
+	}
+}
+
+func TestComponentVersions(t *testing.T) {
+	data, err := json.Marshal(&rke2ReleaseNoteData{K8sVersion: "v1.29.2", RuncVersion: "v1.1.12", EtcdVersion: "v3.5.9-k3s1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	versions, err := ComponentVersions(&ReleaseNotesSnapshot{Repo: rke2Repo, Milestone: "v1.29.2+rke2r1", Data: data})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"kubernetes": "v1.29.2", "runc": "v1.1.12", "etcd": "v3.5.9-k3s1"}
+	if !reflect.DeepEqual(versions, want) {
+		t.Errorf("ComponentVersions() = %v, want %v", versions, want)
+	}
+
+	if _, err := ComponentVersions(&ReleaseNotesSnapshot{Repo: cliRepo, Data: data}); err == nil {
+		t.Error("expected an error for a repo without components")
+	}
+}
+
+func TestLocaleFormats(t *testing.T) {
+	date := time.Date(2024, time.March, 2, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		locale string
+		date   string
+		number string
+	}{
+		{locale: "en-US", date: "March 2, 2024", number: "1,234,567"},
+		{locale: "en-GB", date: "2 March 2024", number: "1,234,567"},
+		{locale: "de-DE", date: "2. März 2024", number: "1.234.567"},
+		{locale: "es-ES", date: "2 de marzo de 2024", number: "1.234.567"},
+		{locale: "ja-JP", date: "2024年3月2日", number: "1,234,567"},
+		{locale: "invalid", date: "March 2, 2024", number: "1,234,567"},
+	}
+	for _, tt := range tests {
+		if got := formatDate(tt.locale, date); got != tt.date {
+			t.Errorf("formatDate(%s) = %q, want %q", tt.locale, got, tt.date)
+		}
+		if got := formatNumber(tt.locale, 1234567); got != tt.number {
+			t.Errorf("formatNumber(%s) = %q, want %q", tt.locale, got, tt.number)
+		}
+	}
+	for n, want := range map[int]string{0: "0", 999: "999", 1000: "1,000", -12345: "-12,345"} {
+		if got := formatNumber("en-US", n); got != want {
+			t.Errorf("formatNumber(%d) = %q, want %q", n, got, want)
+		}
+	}
+
+	eol := time.Date(2025, time.February, 28, 0, 0, 0, 0, time.UTC)
+	data, err := json.Marshal(&rke2ReleaseNoteData{
+		K8sVersion: "v1.29.2",
+		releaseNoteData: releaseNoteData{
+			Milestone:   "v1.29.2+rke2r1",
+			MajorMinor:  "1.29",
+			Locale:      "de-DE",
+			PublishDate: &date,
+			EOLDate:     &eol,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := RenderReleaseNotes(&ReleaseNotesSnapshot{Repo: rke2Repo, Milestone: "v1.29.2+rke2r1", Data: data})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "This release updates Kubernetes to v1.29.2. Released on 2. März 2024. The 1.29 release line reaches end of life on 28. Februar 2025.\n"; !strings.Contains(b.String(), want) {
+		t.Errorf("RenderReleaseNotes() = %q, want it to contain %q", b.String(), want)
+	}
+}
+
+func TestReverts(t *testing.T) {
+	change := func(number, reverts int, title string) repository.ChangeLog {
+		return repository.ChangeLog{Title: title, Number: number, URL: "https://github.com/rancher/rke2/pull/" + strconv.Itoa(number), Reverts: reverts}
+	}
+	content := []repository.ChangeLog{
+		change(1, 0, "Bump containerd"),
+		change(2, 0, "Fix typo"),
+		change(3, 1, `Revert "Bump containerd"`),
+		change(4, 0, "Add nftables mode"),
+		change(5, 4, `Revert "Add nftables mode"`),
+		change(6, 5, `Revert "Revert "Add nftables mode""`),
+		// revert of a change of a previous release
+		change(7, 100, `Revert "Bump runc"`),
+	}
+
+	var numbers []int
+	for _, c := range netChanges(content) {
+		numbers = append(numbers, c.Number)
+	}
+	if want := []int{2, 4, 7}; !reflect.DeepEqual(numbers, want) {
+		t.Errorf("netChanges() = %v, want %v", numbers, want)
+	}
+
+	reverted := revertedChanges(content)
+	if len(reverted) != 2 {
+		t.Fatalf("revertedChanges() = %+v, want 2 changes", reverted)
+	}
+	if reverted[0].Change.Number != 1 || reverted[0].Applied || len(reverted[0].Steps) != 1 || reverted[0].Steps[0].Action != "reverted" {
+		t.Errorf("revertedChanges()[0] = %+v, want #1 reverted in #3", reverted[0])
+	}
+	if reverted[1].Change.Number != 4 || !reverted[1].Applied || len(reverted[1].Steps) != 2 || reverted[1].Steps[1].Action != "re-applied" {
+		t.Errorf("revertedChanges()[1] = %+v, want #4 reverted in #5 and re-applied in #6", reverted[1])
+	}
+
+	data, err := json.Marshal(&rke2ReleaseNoteData{
+		releaseNoteData: releaseNoteData{
+			Milestone:     "v1.29.2+rke2r1",
+			ChangeLogData: changeLogData{PrevMilestone: "v1.29.1+rke2r1", Content: content},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := RenderReleaseNotes(&ReleaseNotesSnapshot{Repo: rke2Repo, Milestone: "v1.29.2+rke2r1", Data: data})
+	if err != nil {
+		t.Fatal(err)
+	}
+	changes, revertedSection, ok := strings.Cut(b.String(), "## Reverted Changes")
+	if !ok {
+		t.Fatalf("RenderReleaseNotes() = %q, want a reverted changes section", b.String())
+	}
+	if strings.Contains(changes, "Bump containerd") {
+		t.Errorf("changes = %q, want net-zero changes collapsed", changes)
+	}
+	for _, want := range []string{
+		"* Bump containerd [(#1)](https://github.com/rancher/rke2/pull/1), reverted in [#3](https://github.com/rancher/rke2/pull/3) (no net change)",
+		"* Add nftables mode [(#4)](https://github.com/rancher/rke2/pull/4), reverted in [#5](https://github.com/rancher/rke2/pull/5), re-applied in [#6](https://github.com/rancher/rke2/pull/6)\n",
+	} {
+		if !strings.Contains(revertedSection, want) {
+			t.Errorf("reverted changes = %q, want it to contain %q", revertedSection, want)
+		}
+	}
+}
+
+func TestLatestRC(t *testing.T) {
+	releases := `[
+		{"tag_name": "v1.29.2-rc2+rke2r1", "published_at": "2024-02-14T10:00:00Z"},
+		{"tag_name": "v1.29.2-rc1+rke2r1", "published_at": "2024-02-12T10:00:00Z"},
+		{"tag_name": "v1.29.1+rke2r1", "published_at": "2024-01-25T10:00:00Z"},
+		{"tag_name": "v1.28.7-rc3+rke2r1", "published_at": "2024-02-13T10:00:00Z"}
+	]`
+	c := NewClient(github.NewClient(&http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusOK, Header: http.Header{"Content-Type": {"application/json"}}, Body: io.NopCloser(strings.NewReader(releases))}, nil
+	})}))
+
+	tests := []struct {
+		k8sVersion string
+		want       string
+	}{
+		{k8sVersion: "v1.29.2", want: "v1.29.2-rc2+rke2r1"},
+		{k8sVersion: "v1.27.11", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.k8sVersion, func(t *testing.T) {
+			got, err := c.LatestRC(context.Background(), LatestRCOptions{Owner: "rancher", Repo: "rke2", K8sVersion: tt.k8sVersion, Suffix: "rke2r1"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Tag != tt.want {
+				t.Errorf("LatestRC() = %q, want %q", got.Tag, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package release
+
+import "github.com/rancher/ecm-distro-tools/repository"
+
+// RevertedChange is a change reverted within the changelog it's part of,
+// along with the reverts, and reverts of reverts, that followed it.
+type RevertedChange struct {
+	Change repository.ChangeLog
+	Steps  []RevertStep
+	// Applied is set if the change was re-applied last, i.e. it's part of
+	// the release.
+	Applied bool
+}
+
+// RevertStep is a pull request that reverted or re-applied a change.
+type RevertStep struct {
+	// Action is reverted or re-applied.
+	Action string
+	repository.ChangeLog
+}
+
+// revertChains returns the changes of the given changelog that were
+// reverted within it, in changelog order, and the numbers of the pull
+// requests of their revert chains, reverts included.
+func revertChains(content []repository.ChangeLog) ([]RevertedChange, map[int]bool) {
+	numbers := make(map[int]bool, len(content))
+	for _, c := range content {
+		numbers[c.Number] = true
+	}
+
+	// revertedBy maps a change to the first revert of it in the changelog.
+	revertedBy := make(map[int]repository.ChangeLog)
+	for _, c := range content {
+		if c.Reverts == 0 || !numbers[c.Reverts] {
+			continue
+		}
+		if _, ok := revertedBy[c.Reverts]; !ok {
+			revertedBy[c.Reverts] = c
+		}
+	}
+
+	var reverted []RevertedChange
+	inChain := make(map[int]bool)
+	for _, c := range content {
+		// chains start at changes that don't revert another change of the
+		// changelog, e.g. at a revert of a change of a previous release.
+		if c.Reverts != 0 && numbers[c.Reverts] {
+			continue
+		}
+		if _, ok := revertedBy[c.Number]; !ok {
+			continue
+		}
+
+		chain := RevertedChange{Change: c, Applied: true}
+		inChain[c.Number] = true
+		for next, ok := revertedBy[c.Number]; ok && !inChain[next.Number]; next, ok = revertedBy[next.Number] {
+			action := "re-applied"
+			if chain.Applied {
+				action = "reverted"
+			}
+			chain.Steps = append(chain.Steps, RevertStep{Action: action, ChangeLog: next})
+			chain.Applied = !chain.Applied
+			inChain[next.Number] = true
+		}
+		reverted = append(reverted, chain)
+	}
+
+	return reverted, inChain
+}
+
+// netChanges returns the entries of the given changelog without the
+// reverts of changes of the same changelog, nor the changes they reverted
+// unless they were re-applied, so net-zero changes aren't listed.
+func netChanges(content []repository.ChangeLog) []repository.ChangeLog {
+	reverted, inChain := revertChains(content)
+	if len(reverted) == 0 {
+		return content
+	}
+
+	applied := make(map[int]bool, len(reverted))
+	for _, r := range reverted {
+		applied[r.Change.Number] = r.Applied
+	}
+
+	changes := make([]repository.ChangeLog, 0, len(content))
+	for _, c := range content {
+		if inChain[c.Number] && !applied[c.Number] {
+			continue
+		}
+		changes = append(changes, c)
+	}
+
+	return changes
+}
+
+// revertedChanges returns the changes of the given changelog that were
+// reverted within it, with their reverts and re-applies.
+func revertedChanges(content []repository.ChangeLog) []RevertedChange {
+	reverted, _ := revertChains(content)
+
+	return reverted
+}
@@ -0,0 +1,338 @@
+package rke2
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/google/go-github/v39/github"
+	"github.com/rancher/ecm-distro-tools/repository"
+	"sigs.k8s.io/yaml"
+)
+
+// ChartsIndexURL is the index of the charts published by rancher/rke2-charts.
+const ChartsIndexURL = "https://raw.githubusercontent.com/rancher/rke2-charts/main/index.yaml"
+
+// chartVersionsFile lists the charts of the rke2 branches that don't set
+// them in the Dockerfile anymore.
+const chartVersionsFile = "charts/chart_versions.yaml"
+
+// dockerfileChartRegex matches the chart builds of the rke2 Dockerfile, e.g.
+// RUN CHART_VERSION="1.15.100" CHART_FILE=/charts/rke2-cilium.yaml CHART_BOOTSTRAP=true /charts/build-chart.sh
+var dockerfileChartRegex = regexp.MustCompile(`CHART_VERSION="?([^"\s]+)"?\s+CHART_FILE=(\S+)(?:\s+CHART_BOOTSTRAP=(\w+))?`)
+
+// ChartRef is a chart version an rke2 branch packages.
+type ChartRef struct {
+	Name      string
+	Version   string
+	Bootstrap bool
+}
+
+// DockerfileCharts returns the charts built by the given rke2 Dockerfile,
+// sorted by name.
+func DockerfileCharts(dockerfile []byte) []ChartRef {
+	var refs []ChartRef
+	for _, match := range dockerfileChartRegex.FindAllStringSubmatch(string(dockerfile), -1) {
+		refs = append(refs, ChartRef{
+			Name:      chartName(match[2]),
+			Version:   match[1],
+			Bootstrap: match[3] == "true",
+		})
+	}
+	sortCharts(refs)
+
+	return refs
+}
+
+// ChartVersionsCharts returns the charts listed by the given
+// chart_versions.yaml, sorted by name.
+func ChartVersionsCharts(chartVersions []byte) ([]ChartRef, error) {
+	var cv struct {
+		Charts []struct {
+			Version   string `json:"version"`
+			Filename  string `json:"filename"`
+			Bootstrap bool   `json:"bootstrap"`
+		} `json:"charts"`
+	}
+	if err := yaml.Unmarshal(chartVersions, &cv); err != nil {
+		return nil, err
+	}
+
+	refs := make([]ChartRef, len(cv.Charts))
+	for i, c := range cv.Charts {
+		refs[i] = ChartRef{Name: chartName(c.Filename), Version: c.Version, Bootstrap: c.Bootstrap}
+	}
+	sortCharts(refs)
+
+	return refs, nil
+}
+
+// chartName returns the name of the chart of the given file, e.g.
+// rke2-cilium for /charts/rke2-cilium.yaml.
+func chartName(file string) string {
+	return strings.TrimSuffix(path.Base(file), path.Ext(file))
+}
+
+func sortCharts(refs []ChartRef) {
+	sort.Slice(refs, func(i, j int) bool {
+		return refs[i].Name < refs[j].Name
+	})
+}
+
+// BranchCharts returns the charts packaged by the given rke2 branch or tag,
+// read from its Dockerfile or, if it doesn't build any, from its
+// chart_versions.yaml.
+func BranchCharts(ctx context.Context, client *github.Client, owner, ref string) ([]ChartRef, error) {
+	dockerfile, err := downloadContents(ctx, client, owner, "Dockerfile", ref)
+	if err != nil {
+		return nil, err
+	}
+	if refs := DockerfileCharts(dockerfile); len(refs) > 0 {
+		return refs, nil
+	}
+
+	chartVersions, err := downloadContents(ctx, client, owner, chartVersionsFile, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	return ChartVersionsCharts(chartVersions)
+}
+
+func downloadContents(ctx context.Context, client *github.Client, owner, file, ref string) ([]byte, error) {
+	rc, _, err := client.Repositories.DownloadContents(ctx, owner, "rke2", file, &github.RepositoryContentGetOptions{Ref: ref})
+	if err != nil {
+		return nil, repository.WrapGithubError(err, owner, "rke2", ref)
+	}
+	defer rc.Close()
+
+	return io.ReadAll(rc)
+}
+
+// ChartsIndex is a Helm repository index.
+type ChartsIndex struct {
+	// URL the index was fetched from, the chart URLs are relative to it.
+	URL     string                    `json:"-"`
+	Entries map[string][]IndexedChart `json:"entries"`
+}
+
+// IndexedChart is a chart version listed by a Helm repository index.
+type IndexedChart struct {
+	Name       string   `json:"name"`
+	Version    string   `json:"version"`
+	AppVersion string   `json:"appVersion"`
+	URLs       []string `json:"urls"`
+}
+
+// Chart returns the given version of the given chart, and false if it isn't
+// in the index.
+func (i *ChartsIndex) Chart(name, version string) (IndexedChart, bool) {
+	for _, chart := range i.Entries[name] {
+		if chart.Version == version {
+			return chart, true
+		}
+	}
+
+	return IndexedChart{}, false
+}
+
+// ArchiveURL returns the URL of the archive of the given chart, resolving
+// the URLs relative to the index.
+func (i *ChartsIndex) ArchiveURL(chart IndexedChart) (string, error) {
+	if len(chart.URLs) == 0 {
+		return "", errors.New("no archive url in the index")
+	}
+
+	return resolveChartURL(i.URL, chart.URLs[0])
+}
+
+// GetChartsIndex fetches and parses the Helm repository index at the given
+// URL.
+func GetChartsIndex(ctx context.Context, client *http.Client, indexURL string) (*ChartsIndex, error) {
+	b, err := get(ctx, client, indexURL)
+	if err != nil {
+		return nil, err
+	}
+
+	index := ChartsIndex{URL: indexURL}
+	if err := yaml.Unmarshal(b, &index); err != nil {
+		return nil, errors.New("invalid charts index " + indexURL + ": " + err.Error())
+	}
+
+	return &index, nil
+}
+
+func get(ctx context.Context, client *http.Client, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, errors.New("status error: " + res.Status + " when fetching " + url)
+	}
+
+	return io.ReadAll(res.Body)
+}
+
+// ChartCheck is the outcome of the validation of a chart packaged by an
+// rke2 branch.
+type ChartCheck struct {
+	ChartRef
+	// AppVersion of the chart in the index, if published.
+	AppVersion string
+	// Problem describes why the chart isn't valid, e.g. that its version
+	// isn't published yet. It's empty if the chart is valid.
+	Problem string
+}
+
+// OK returns true if the chart is published as expected.
+func (c ChartCheck) OK() bool {
+	return c.Problem == ""
+}
+
+// CheckCharts checks that every given chart version is published in the
+// index, catching chart bumps merged before the chart was released. If
+// packaged is set, the published chart archives are also downloaded to
+// check their version and appVersion match the index.
+func CheckCharts(ctx context.Context, client *http.Client, index *ChartsIndex, refs []ChartRef, packaged bool) []ChartCheck {
+	checks := make([]ChartCheck, len(refs))
+
+	var wg sync.WaitGroup
+	for i, ref := range refs {
+		checks[i].ChartRef = ref
+
+		chart, ok := index.Chart(ref.Name, ref.Version)
+		if !ok {
+			checks[i].Problem = "version " + ref.Version + " isn't published" + publishedVersions(index.Entries[ref.Name])
+			continue
+		}
+		checks[i].AppVersion = chart.AppVersion
+		if !packaged {
+			continue
+		}
+
+		wg.Add(1)
+		go func(check *ChartCheck, chart IndexedChart) {
+			defer wg.Done()
+
+			if err := checkPackagedChart(ctx, client, index, chart); err != nil {
+				check.Problem = err.Error()
+			}
+		}(&checks[i], chart)
+	}
+	wg.Wait()
+
+	return checks
+}
+
+// publishedVersions describes the latest versions of a chart in the index,
+// which are sorted newest first.
+func publishedVersions(charts []IndexedChart) string {
+	if len(charts) == 0 {
+		return ", the chart isn't in the index"
+	}
+
+	versions := make([]string, 0, 3)
+	for _, chart := range charts {
+		if len(versions) == cap(versions) {
+			break
+		}
+		versions = append(versions, chart.Version)
+	}
+
+	return ", latest: " + strings.Join(versions, ", ")
+}
+
+// checkPackagedChart downloads the archive of the given chart and checks
+// its Chart.yaml matches the index.
+func checkPackagedChart(ctx context.Context, client *http.Client, index *ChartsIndex, chart IndexedChart) error {
+	archiveURL, err := index.ArchiveURL(chart)
+	if err != nil {
+		return err
+	}
+
+	archive, err := get(ctx, client, archiveURL)
+	if err != nil {
+		return err
+	}
+	packaged, err := packagedChart(archive)
+	if err != nil {
+		return errors.New("invalid archive " + archiveURL + ": " + err.Error())
+	}
+
+	if packaged.Version != chart.Version {
+		return errors.New("packaged version " + packaged.Version + " doesn't match the index")
+	}
+	if packaged.AppVersion != chart.AppVersion {
+		return errors.New("packaged appVersion " + packaged.AppVersion + " doesn't match the index appVersion " + chart.AppVersion)
+	}
+
+	return nil
+}
+
+// resolveChartURL resolves the URL of a chart archive, which may be
+// relative to the index.
+func resolveChartURL(indexURL, chartURL string) (string, error) {
+	base, err := url.Parse(indexURL)
+	if err != nil {
+		return "", err
+	}
+	ref, err := url.Parse(chartURL)
+	if err != nil {
+		return "", err
+	}
+
+	return base.ResolveReference(ref).String(), nil
+}
+
+// packagedChart returns the metadata of the Chart.yaml at the root of the
+// given chart archive.
+func packagedChart(archive []byte) (IndexedChart, error) {
+	gz, err := gzip.NewReader(bytes.NewReader(archive))
+	if err != nil {
+		return IndexedChart{}, err
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return IndexedChart{}, errors.New("no Chart.yaml")
+		}
+		if err != nil {
+			return IndexedChart{}, err
+		}
+		if strings.Count(header.Name, "/") != 1 || path.Base(header.Name) != "Chart.yaml" {
+			continue
+		}
+
+		b, err := io.ReadAll(tr)
+		if err != nil {
+			return IndexedChart{}, err
+		}
+
+		var chart IndexedChart
+		if err := yaml.Unmarshal(b, &chart); err != nil {
+			return IndexedChart{}, err
+		}
+
+		return chart, nil
+	}
+}
@@ -0,0 +1,180 @@
+package rke2
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestDockerfileCharts(t *testing.T) {
+	dockerfile := []byte(`FROM rancher/hardened-build-base:v1.21.6b1 AS charts
+COPY charts/ /charts/
+RUN CHART_VERSION="1.15.100"                  CHART_FILE=/charts/rke2-cilium.yaml         CHART_BOOTSTRAP=true   /charts/build-chart.sh
+RUN CHART_VERSION="v3.27.002"                 CHART_FILE=/charts/rke2-calico.yaml                                /charts/build-chart.sh
+RUN CHART_VERSION="1.29.001"                  CHART_FILE=/charts/rke2-coredns.yaml        CHART_BOOTSTRAP=true   /charts/build-chart.sh
+`)
+
+	want := []ChartRef{
+		{Name: "rke2-calico", Version: "v3.27.002"},
+		{Name: "rke2-cilium", Version: "1.15.100", Bootstrap: true},
+		{Name: "rke2-coredns", Version: "1.29.001", Bootstrap: true},
+	}
+	if got := DockerfileCharts(dockerfile); !reflect.DeepEqual(got, want) {
+		t.Errorf("DockerfileCharts() = %v, want %v", got, want)
+	}
+}
+
+func TestChartVersionsCharts(t *testing.T) {
+	chartVersions := []byte(`charts:
+  - version: 1.29.001
+    filename: /charts/rke2-coredns.yaml
+    bootstrap: true
+  - version: 4.10.001
+    filename: /charts/rke2-ingress-nginx.yaml
+    bootstrap: false
+`)
+
+	got, err := ChartVersionsCharts(chartVersions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []ChartRef{
+		{Name: "rke2-coredns", Version: "1.29.001", Bootstrap: true},
+		{Name: "rke2-ingress-nginx", Version: "4.10.001"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ChartVersionsCharts() = %v, want %v", got, want)
+	}
+}
+
+// chartArchive returns a chart archive whose Chart.yaml has the given
+// contents.
+func chartArchive(t *testing.T, name, chartYAML string) []byte {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for file, content := range map[string]string{name + "/Chart.yaml": chartYAML, name + "/charts/sub/Chart.yaml": "version: 0.0.1\n"} {
+		if err := tw.WriteHeader(&tar.Header{Name: file, Mode: 0644, Size: int64(len(content))}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes()
+}
+
+func TestCheckCharts(t *testing.T) {
+	archives := map[string][]byte{
+		"/assets/rke2-cilium/rke2-cilium-1.15.100.tgz":   chartArchive(t, "rke2-cilium", "name: rke2-cilium\nversion: 1.15.100\nappVersion: 1.15.1\n"),
+		"/assets/rke2-coredns/rke2-coredns-1.29.001.tgz": chartArchive(t, "rke2-coredns", "name: rke2-coredns\nversion: 1.29.001\nappVersion: 1.11.0\n"),
+	}
+	index := []byte(`apiVersion: v1
+entries:
+  rke2-cilium:
+  - name: rke2-cilium
+    version: 1.15.100
+    appVersion: 1.15.1
+    urls:
+    - assets/rke2-cilium/rke2-cilium-1.15.100.tgz
+  rke2-coredns:
+  - name: rke2-coredns
+    version: 1.29.002
+    appVersion: 1.11.1
+    urls:
+    - assets/rke2-coredns/rke2-coredns-1.29.002.tgz
+  - name: rke2-coredns
+    version: 1.29.001
+    appVersion: 1.11.1
+    urls:
+    - assets/rke2-coredns/rke2-coredns-1.29.001.tgz
+  rke2-canal:
+  - name: rke2-canal
+    version: v3.27.3-build2024042301
+    appVersion: v3.27.3
+    urls:
+    - assets/rke2-canal/rke2-canal-v3.27.3-build2024042301.tgz
+`)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/index.yaml" {
+			w.Write(index)
+			return
+		}
+		archive, ok := archives[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write(archive)
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	chartsIndex, err := GetChartsIndex(ctx, server.Client(), server.URL+"/index.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	refs := []ChartRef{
+		{Name: "rke2-canal", Version: "v3.27.3-build2024050601"},
+		{Name: "rke2-cilium", Version: "1.15.100", Bootstrap: true},
+		{Name: "rke2-coredns", Version: "1.29.001", Bootstrap: true},
+		{Name: "rke2-multus", Version: "v4.0.2-build2024020801"},
+	}
+
+	tests := []struct {
+		name     string
+		packaged bool
+		want     []string
+	}{
+		{
+			name: "index",
+			want: []string{
+				"version v3.27.3-build2024050601 isn't published, latest: v3.27.3-build2024042301",
+				"",
+				"",
+				"version v4.0.2-build2024020801 isn't published, the chart isn't in the index",
+			},
+		},
+		{
+			name:     "packaged",
+			packaged: true,
+			want: []string{
+				"version v3.27.3-build2024050601 isn't published, latest: v3.27.3-build2024042301",
+				"",
+				"packaged appVersion 1.11.0 doesn't match the index appVersion 1.11.1",
+				"version v4.0.2-build2024020801 isn't published, the chart isn't in the index",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checks := CheckCharts(ctx, server.Client(), chartsIndex, refs, tt.packaged)
+
+			var got []string
+			for _, check := range checks {
+				got = append(got, check.Problem)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("CheckCharts() problems = %q, want %q", got, tt.want)
+			}
+			if checks[1].AppVersion != "1.15.1" {
+				t.Errorf("CheckCharts() appVersion = %q, want %q", checks[1].AppVersion, "1.15.1")
+			}
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package rke2
+
+import (
+	"context"
+	"io"
+	"regexp"
+	"sort"
+	"sync"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-github/v39/github"
+	reg "github.com/rancher/ecm-distro-tools/registry"
+	"github.com/rancher/ecm-distro-tools/release/platforms"
+	"github.com/rancher/ecm-distro-tools/repository"
+)
+
+// hardenedImageRegex matches the hardened images referenced by the rke2
+// Dockerfile, e.g. rancher/hardened-containerd:v1.7.11-k3s2-build20231208.
+// Tags set from build arguments, e.g. ${KUBERNETES_VERSION}, don't match.
+var hardenedImageRegex = regexp.MustCompile(`rancher/hardened-[\w.-]+:[\w.+-]+`)
+
+// HardenedPlatforms are the platforms every hardened image must be built for
+// unless configured otherwise.
+var HardenedPlatforms = platforms.DefaultPlatforms[platforms.Hardened]
+
+// HardenedImage is a hardened image referenced by the rke2 Dockerfile and
+// what's missing from each registry.
+type HardenedImage struct {
+	Reference name.Reference
+	// Missing contains the missing platforms per registry, or no platforms
+	// if the image doesn't exist at all.
+	Missing map[string][]reg.Platform
+}
+
+// OK returns true if the image exists for all platforms in all registries.
+func (h HardenedImage) OK() bool {
+	return len(h.Missing) == 0
+}
+
+// HardenedImages returns the hardened images referenced by the given
+// Dockerfile, sorted and without duplicates.
+func HardenedImages(dockerfile []byte) ([]name.Reference, error) {
+	matches := hardenedImageRegex.FindAllString(string(dockerfile), -1)
+	sort.Strings(matches)
+
+	var refs []name.Reference
+	for i, match := range matches {
+		if i > 0 && matches[i-1] == match {
+			continue
+		}
+		ref, err := name.ParseReference(match)
+		if err != nil {
+			return nil, err
+		}
+		refs = append(refs, ref)
+	}
+
+	return refs, nil
+}
+
+// DockerfileHardenedImages returns the hardened images referenced by the
+// Dockerfile of the given rke2 branch or tag.
+func DockerfileHardenedImages(ctx context.Context, client *github.Client, owner, ref string) ([]name.Reference, error) {
+	rc, _, err := client.Repositories.DownloadContents(ctx, owner, "rke2", "Dockerfile", &github.RepositoryContentGetOptions{Ref: ref})
+	if err != nil {
+		return nil, repository.WrapGithubError(err, owner, "rke2", ref)
+	}
+	defer rc.Close()
+
+	b, err := io.ReadAll(rc)
+	if err != nil {
+		return nil, err
+	}
+
+	return HardenedImages(b)
+}
+
+// CheckHardenedImages checks that every given image exists for all platforms
+// in each of the given registries, indexed by name.
+func CheckHardenedImages(ctx context.Context, refs []name.Reference, registries map[string]RegistryClient, platforms []reg.Platform) ([]HardenedImage, error) {
+	results := make([]HardenedImage, len(refs))
+	errs := make([]error, len(refs))
+
+	var wg sync.WaitGroup
+	for i, ref := range refs {
+		wg.Add(1)
+		go func(i int, ref name.Reference) {
+			defer wg.Done()
+
+			result := HardenedImage{Reference: ref, Missing: make(map[string][]reg.Platform)}
+			for registry, client := range registries {
+				image, err := client.Image(ctx, ref)
+				if err != nil {
+					errs[i] = err
+					return
+				}
+				if missing := missingPlatforms(image, platforms); len(missing) > 0 || !image.Exists {
+					result.Missing[registry] = missing
+				}
+			}
+			results[i] = result
+		}(i, ref)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return results, nil
+}
+
+// missingPlatforms returns the given platforms the image isn't built for.
+// Images that don't exist are missing all of them.
+func missingPlatforms(image reg.Image, platforms []reg.Platform) []reg.Platform {
+	var missing []reg.Platform
+	for _, platform := range platforms {
+		if !image.Platforms[platform] {
+			missing = append(missing, platform)
+		}
+	}
+
+	return missing
+}
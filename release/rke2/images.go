@@ -5,6 +5,7 @@ import (
 	"errors"
 	"io"
 	"io/fs"
+	"sort"
 	"strings"
 	"sync"
 
@@ -14,9 +15,7 @@ import (
 )
 
 // RegistryClient defines the interface for interacting with container registries
-type RegistryClient interface {
-	Image(ctx context.Context, ref name.Reference) (reg.Image, error)
-}
+type RegistryClient = reg.RegistryClient
 
 type Architecture string
 
@@ -74,6 +73,28 @@ func (r *ReleaseInspector) InspectRelease(ctx context.Context, version string) (
 	return r.checkImages(ctx, requiredImages)
 }
 
+// ReleaseImages returns the images listed for all platforms of the
+// release, sorted by reference.
+func (r *ReleaseInspector) ReleaseImages() ([]ReleaseImage, error) {
+	imageMap, err := r.imageMap()
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, 0, len(imageMap))
+	for key := range imageMap {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	images := make([]ReleaseImage, len(keys))
+	for i, key := range keys {
+		images[i] = imageMap[key]
+	}
+
+	return images, nil
+}
+
 // imageMap reads per-platform image list files and coalesces them
 // into one map to collect images for all platforms.
 func (r *ReleaseInspector) imageMap() (map[string]ReleaseImage, error) {
@@ -0,0 +1,147 @@
+package rke2
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v39/github"
+	"github.com/rancher/ecm-distro-tools/release/watchdog"
+	"github.com/rancher/ecm-distro-tools/repository"
+)
+
+const packagingRepo = "rke2-packaging"
+
+// PackagingTag returns the rke2-packaging tag for the given rke2 tag,
+// channel and iteration, e.g. v1.27.5+rke2r1.testing.0.
+func PackagingTag(rke2Tag, channel string, iteration int) string {
+	return rke2Tag + "." + channel + "." + strconv.Itoa(iteration)
+}
+
+// NextPackagingTag inspects the existing rke2-packaging tags and returns
+// the tag for the next packaging iteration of the given rke2 tag and channel.
+func NextPackagingTag(ctx context.Context, client *github.Client, owner, rke2Tag, channel string) (string, error) {
+	tags, err := repository.ListAllTags(ctx, client, owner, packagingRepo)
+	if err != nil {
+		return "", err
+	}
+
+	names := make([]string, len(tags))
+	for i, tag := range tags {
+		names[i] = tag.GetName()
+	}
+
+	return PackagingTag(rke2Tag, channel, nextPackagingIteration(names, rke2Tag, channel)), nil
+}
+
+// nextPackagingIteration returns the iteration following the highest one
+// found in the given tags for the rke2 tag and channel, or 0 if none exist.
+func nextPackagingIteration(tags []string, rke2Tag, channel string) int {
+	prefix := rke2Tag + "." + channel + "."
+	next := 0
+
+	for _, tag := range tags {
+		if !strings.HasPrefix(tag, prefix) {
+			continue
+		}
+		iteration, err := strconv.Atoi(strings.TrimPrefix(tag, prefix))
+		if err != nil {
+			continue
+		}
+		if iteration >= next {
+			next = iteration + 1
+		}
+	}
+
+	return next
+}
+
+// CreatePackagingTag creates the given tag in the rke2-packaging repository,
+// which triggers the build of the rpm packages.
+func CreatePackagingTag(ctx context.Context, client *github.Client, owner, tag string) error {
+	cro := repository.CreateReleaseOpts{
+		Owner:  owner,
+		Repo:   packagingRepo,
+		Branch: "master",
+		Name:   tag,
+		Tag:    tag,
+	}
+	if _, err := repository.CreateRelease(ctx, client, &cro); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// WatchPackagingBuild polls the workflow runs triggered by the given
+// rke2-packaging tag until all of them complete, and returns an error if any
+// of them didn't succeed. The context controls how long to wait for.
+func WatchPackagingBuild(ctx context.Context, client *github.Client, owner, tag string, interval time.Duration) error {
+	ctx, wait := watchdog.Default.Track(ctx, "rke2-packaging build for "+tag)
+	defer wait.Done()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		runs, _, err := client.Actions.ListRepositoryWorkflowRuns(ctx, owner, packagingRepo, &github.ListWorkflowRunsOptions{
+			Branch: tag,
+		})
+		if err != nil {
+			if ctx.Err() != nil {
+				return errors.New("timed out waiting for rke2-packaging build for " + tag + ": " + context.Cause(ctx).Error())
+			}
+			return repository.WrapGithubError(err, owner, packagingRepo, tag)
+		}
+		wait.Progress(workflowRunsStatus(runs.WorkflowRuns))
+
+		if runs.GetTotalCount() > 0 {
+			done, err := workflowRunsDone(runs.WorkflowRuns)
+			if err != nil {
+				return errors.New("rke2-packaging build for " + tag + " failed: " + err.Error())
+			}
+			if done {
+				return nil
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return errors.New("timed out waiting for rke2-packaging build for " + tag + ": " + context.Cause(ctx).Error())
+		case <-ticker.C:
+		}
+	}
+}
+
+// workflowRunsStatus summarizes the status of the given runs, e.g.
+// 1/3 workflow runs completed.
+func workflowRunsStatus(runs []*github.WorkflowRun) string {
+	var completed int
+	for _, run := range runs {
+		if run.GetStatus() == "completed" {
+			completed++
+		}
+	}
+
+	return strconv.Itoa(completed) + "/" + strconv.Itoa(len(runs)) + " workflow runs completed"
+}
+
+// workflowRunsDone returns true if all given runs completed successfully,
+// and an error if any of them completed with a different conclusion.
+func workflowRunsDone(runs []*github.WorkflowRun) (bool, error) {
+	done := true
+	for _, run := range runs {
+		if run.GetStatus() != "completed" {
+			done = false
+			continue
+		}
+		if conclusion := run.GetConclusion(); conclusion != "success" {
+			return false, fmt.Errorf("workflow %s concluded with %s: %s", run.GetName(), conclusion, run.GetHTMLURL())
+		}
+	}
+
+	return done, nil
+}
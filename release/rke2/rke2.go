@@ -7,12 +7,14 @@ import (
 	"fmt"
 	"net/http"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/google/go-github/v39/github"
 	"github.com/rancher/ecm-distro-tools/docker"
 	ecmHTTP "github.com/rancher/ecm-distro-tools/http"
+	"github.com/rancher/ecm-distro-tools/release/platforms"
 	"github.com/sirupsen/logrus"
 )
 
@@ -27,56 +29,159 @@ type goVersionRecord struct {
 	Stable  bool   `json:"stable"`
 }
 
-func ImageBuildBaseRelease(ctx context.Context, ghClient *github.Client, dryRun bool) error {
-	versions, err := goVersions(goDevURL)
+// ImageBuildBaseStatus is the outcome of releasing image-build-base for a Go
+// version.
+type ImageBuildBaseStatus string
+
+const (
+	ImageBuildBaseCreated  ImageBuildBaseStatus = "created"
+	ImageBuildBaseExists   ImageBuildBaseStatus = "exists"
+	ImageBuildBaseUnstable ImageBuildBaseStatus = "unstable"
+	ImageBuildBaseDryRun   ImageBuildBaseStatus = "dry run"
+	ImageBuildBaseFailed   ImageBuildBaseStatus = "failed"
+)
+
+// ImageBuildBaseResult is the result of releasing image-build-base for a Go
+// version.
+type ImageBuildBaseResult struct {
+	GoVersion string
+	Tag       string
+	Status    ImageBuildBaseStatus
+	Err       error
+}
+
+// ImageBuildBaseResults contains the results of all Go versions.
+type ImageBuildBaseResults []ImageBuildBaseResult
+
+// Summary returns a line per Go version with its outcome.
+func (r ImageBuildBaseResults) Summary() string {
+	var b strings.Builder
+	for _, result := range r {
+		b.WriteString(result.GoVersion + ": " + string(result.Status))
+		if result.Tag != "" {
+			b.WriteString(" " + result.Tag)
+		}
+		if result.Err != nil {
+			b.WriteString(": " + result.Err.Error())
+		}
+		b.WriteString("\n")
+	}
+
+	return b.String()
+}
+
+// Err returns an error listing the Go versions that failed, or nil if none
+// did.
+func (r ImageBuildBaseResults) Err() error {
+	var failed []string
+	for _, result := range r {
+		if result.Status == ImageBuildBaseFailed {
+			failed = append(failed, result.GoVersion+": "+result.Err.Error())
+		}
+	}
+	if len(failed) == 0 {
+		return nil
+	}
+
+	return errors.New("failed to release image-build-base for " + strconv.Itoa(len(failed)) + " go versions:\n" + strings.Join(failed, "\n"))
+}
+
+// ImageBuildBaseRelease creates the image-build-base releases missing for the
+// stable Go versions in the given organization, logs a summary and returns an error if any version
+// failed. Failures don't stop the remaining versions, and since existing
+// releases are skipped, running it again resumes the failed ones. The golang
+// alpine image of each version must be built for the image-build-base
+// platforms of the given matrix.
+func ImageBuildBaseRelease(ctx context.Context, ghClient *github.Client, owner string, matrix platforms.Matrix, dryRun bool) error {
+	results, err := ImageBuildBaseReleases(ctx, ghClient, owner, matrix, dryRun)
 	if err != nil {
 		return err
 	}
 
+	logrus.Info("image-build-base release summary:\n" + results.Summary())
+
+	return results.Err()
+}
+
+// ImageBuildBaseReleases processes every Go version independently and
+// returns the result of each of them.
+func ImageBuildBaseReleases(ctx context.Context, ghClient *github.Client, owner string, matrix platforms.Matrix, dryRun bool) (ImageBuildBaseResults, error) {
+	versions, err := goVersions(goDevURL)
+	if err != nil {
+		return nil, err
+	}
+
+	return imageBuildBaseReleases(versions, func(goVersion string) (string, ImageBuildBaseStatus, error) {
+		return imageBuildBaseRelease(ctx, ghClient, owner, goVersion, matrix, dryRun)
+	}), nil
+}
+
+func imageBuildBaseReleases(versions []goVersionRecord, release func(goVersion string) (string, ImageBuildBaseStatus, error)) ImageBuildBaseResults {
+	results := make(ImageBuildBaseResults, 0, len(versions))
+
 	for _, version := range versions {
 		logrus.Info("version: " + version.Version)
+		goVersion := strings.TrimPrefix(version.Version, "go")
 		if !version.Stable {
 			logrus.Info("version " + version.Version + " is not stable")
+			results = append(results, ImageBuildBaseResult{GoVersion: goVersion, Status: ImageBuildBaseUnstable})
 			continue
 		}
-		goVersion := strings.Split(version.Version, "go")[1]
 
-		// Dynamically find the Alpine version for this Go version.
-		alpineVersion, err := alpineGoVersion(goVersion)
+		tag, status, err := release(goVersion)
 		if err != nil {
-			return fmt.Errorf("failed to find a corresponding alpine version for go %s: %v", goVersion, err)
+			logrus.Error("failed to release image-build-base for go " + goVersion + ": " + err.Error())
+			status = ImageBuildBaseFailed
 		}
-		logrus.Infof("found alpine v%s for go v%s", alpineVersion, goVersion)
+		results = append(results, ImageBuildBaseResult{GoVersion: goVersion, Tag: tag, Status: status, Err: err})
+	}
 
-		alpineTag := goVersion + "-alpine" + alpineVersion
+	return results
+}
 
-		if err := docker.CheckImageArchs(ctx, "library", "golang", alpineTag, []string{"amd64", "arm64", "s390x"}); err != nil {
-			return fmt.Errorf("failed to check image archs for %s: %v", alpineTag, err)
-		}
+// imageBuildBaseRelease creates the image-build-base release for the given
+// Go version if it doesn't exist yet.
+func imageBuildBaseRelease(ctx context.Context, ghClient *github.Client, owner, goVersion string, matrix platforms.Matrix, dryRun bool) (string, ImageBuildBaseStatus, error) {
+	// Dynamically find the Alpine version for this Go version.
+	alpineVersion, err := alpineGoVersion(goVersion)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to find a corresponding alpine version for go %s: %v", goVersion, err)
+	}
+	logrus.Infof("found alpine v%s for go v%s", alpineVersion, goVersion)
 
-		imageBuildBaseTag := "v" + goVersion + "b1"
-		logrus.Info("stripped version: " + imageBuildBaseTag)
-		if _, _, err := ghClient.Repositories.GetReleaseByTag(ctx, "rancher", imageBuildBaseRepo, imageBuildBaseTag); err == nil {
-			logrus.Info("release " + imageBuildBaseTag + " already exists")
-			continue
-		}
-		logrus.Info("release " + imageBuildBaseTag + " doesn't exists, creating release")
-		if dryRun {
-			logrus.Info("dry run, release won't be created")
-			logrus.Infof("Release:\n  Owner: rancher\n  Repo: %s\n  TagName: %s\n  Name: %s\n", imageBuildBaseRepo, imageBuildBaseTag, imageBuildBaseTag)
-			return nil
-		}
-		release := &github.RepositoryRelease{
-			TagName:    github.String(imageBuildBaseTag),
-			Name:       github.String(imageBuildBaseTag),
-			Prerelease: github.Bool(false),
-		}
-		if _, _, err := ghClient.Repositories.CreateRelease(ctx, "rancher", imageBuildBaseRepo, release); err != nil {
-			return err
-		}
-		logrus.Info("created release for version: " + imageBuildBaseTag)
+	alpineTag := goVersion + "-alpine" + alpineVersion
+
+	expected, err := matrix.For(platforms.ImageBuildBase, goVersion)
+	if err != nil {
+		return "", "", err
+	}
+	if err := docker.CheckImageArchs(ctx, "library", "golang", alpineTag, platforms.Architectures(expected)); err != nil {
+		return "", "", fmt.Errorf("failed to check image archs for %s: %v", alpineTag, err)
 	}
-	return nil
+
+	imageBuildBaseTag := "v" + goVersion + "b1"
+	logrus.Info("stripped version: " + imageBuildBaseTag)
+	if _, _, err := ghClient.Repositories.GetReleaseByTag(ctx, owner, imageBuildBaseRepo, imageBuildBaseTag); err == nil {
+		logrus.Info("release " + imageBuildBaseTag + " already exists")
+		return imageBuildBaseTag, ImageBuildBaseExists, nil
+	}
+	logrus.Info("release " + imageBuildBaseTag + " doesn't exists, creating release")
+	if dryRun {
+		logrus.Info("dry run, release won't be created")
+		logrus.Infof("Release:\n  Owner: %s\n  Repo: %s\n  TagName: %s\n  Name: %s\n", owner, imageBuildBaseRepo, imageBuildBaseTag, imageBuildBaseTag)
+		return imageBuildBaseTag, ImageBuildBaseDryRun, nil
+	}
+	release := &github.RepositoryRelease{
+		TagName:    github.String(imageBuildBaseTag),
+		Name:       github.String(imageBuildBaseTag),
+		Prerelease: github.Bool(false),
+	}
+	if _, _, err := ghClient.Repositories.CreateRelease(ctx, owner, imageBuildBaseRepo, release); err != nil {
+		return imageBuildBaseTag, "", err
+	}
+	logrus.Info("created release for version: " + imageBuildBaseTag)
+
+	return imageBuildBaseTag, ImageBuildBaseCreated, nil
 }
 
 // dockerHubResponse defines the structure for the Docker Hub API response.
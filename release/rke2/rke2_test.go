@@ -1,10 +1,13 @@
 package rke2
 
 import (
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"reflect"
 	"testing"
+
+	reg "github.com/rancher/ecm-distro-tools/registry"
 )
 
 func TestGoVersions(t *testing.T) {
@@ -24,3 +27,107 @@ func TestGoVersions(t *testing.T) {
 		t.Errorf("expected %v, got %v", expectedVersions, versions)
 	}
 }
+
+func TestNextPackagingIteration(t *testing.T) {
+	tags := []string{
+		"v1.27.5+rke2r1.testing.0",
+		"v1.27.5+rke2r1.testing.1",
+		"v1.27.5+rke2r1.latest.0",
+		"v1.27.5+rke2r2.testing.4",
+		"v1.27.5+rke2r1.testing.x",
+	}
+
+	tests := []struct {
+		rke2Tag string
+		channel string
+		want    int
+	}{
+		{rke2Tag: "v1.27.5+rke2r1", channel: "testing", want: 2},
+		{rke2Tag: "v1.27.5+rke2r1", channel: "latest", want: 1},
+		{rke2Tag: "v1.27.5+rke2r1", channel: "stable", want: 0},
+		{rke2Tag: "v1.28.1+rke2r1", channel: "testing", want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.rke2Tag+"."+tt.channel, func(t *testing.T) {
+			if got := nextPackagingIteration(tags, tt.rke2Tag, tt.channel); got != tt.want {
+				t.Errorf("nextPackagingIteration() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestImageBuildBaseReleases(t *testing.T) {
+	versions := []goVersionRecord{
+		{Version: "go1.22.1", Stable: true},
+		{Version: "go1.21.8", Stable: true},
+		{Version: "go1.23rc1", Stable: false},
+		{Version: "go1.20.14", Stable: true},
+	}
+
+	var released []string
+	results := imageBuildBaseReleases(versions, func(goVersion string) (string, ImageBuildBaseStatus, error) {
+		released = append(released, goVersion)
+		if goVersion == "1.21.8" {
+			return "", "", errors.New("missing s390x")
+		}
+		return "v" + goVersion + "b1", ImageBuildBaseCreated, nil
+	})
+
+	if want := []string{"1.22.1", "1.21.8", "1.20.14"}; !reflect.DeepEqual(released, want) {
+		t.Errorf("expected %v to be released, got %v", want, released)
+	}
+
+	wantStatuses := []ImageBuildBaseStatus{ImageBuildBaseCreated, ImageBuildBaseFailed, ImageBuildBaseUnstable, ImageBuildBaseCreated}
+	if len(results) != len(wantStatuses) {
+		t.Fatalf("expected %d results, got %v", len(wantStatuses), results)
+	}
+	for i, want := range wantStatuses {
+		if results[i].Status != want {
+			t.Errorf("results[%d].Status = %q, want %q", i, results[i].Status, want)
+		}
+	}
+
+	if err := results.Err(); err == nil {
+		t.Error("expected an error for the failed version")
+	}
+	if err := results[:1].Err(); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+}
+
+func TestHardenedImages(t *testing.T) {
+	dockerfile := []byte(`ARG KUBERNETES_VERSION=dev
+FROM rancher/hardened-build-base:v1.21.6b1 AS build
+FROM rancher/hardened-containerd:v1.7.11-k3s2-build20231208 AS containerd
+FROM rancher/hardened-kubernetes:${KUBERNETES_VERSION} AS kubernetes
+FROM rancher/hardened-build-base:v1.21.6b1 AS test
+`)
+
+	refs, err := HardenedImages(dockerfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, ref := range refs {
+		got = append(got, ref.Context().RepositoryStr()+":"+ref.Identifier())
+	}
+	want := []string{"rancher/hardened-build-base:v1.21.6b1", "rancher/hardened-containerd:v1.7.11-k3s2-build20231208"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("HardenedImages() = %v, want %v", got, want)
+	}
+}
+
+func TestMissingPlatforms(t *testing.T) {
+	amd64 := reg.Platform{OS: "linux", Architecture: "amd64"}
+	arm64 := reg.Platform{OS: "linux", Architecture: "arm64"}
+
+	image := reg.Image{Exists: true, Platforms: map[reg.Platform]bool{amd64: true}}
+	if got := missingPlatforms(image, HardenedPlatforms); !reflect.DeepEqual(got, []reg.Platform{arm64}) {
+		t.Errorf("missingPlatforms() = %v, want %v", got, []reg.Platform{arm64})
+	}
+
+	if got := missingPlatforms(reg.Image{}, HardenedPlatforms); len(got) != 2 {
+		t.Errorf("missingPlatforms() = %v, want all platforms", got)
+	}
+}
@@ -0,0 +1,65 @@
+package release
+
+import (
+	"context"
+	"sort"
+
+	"github.com/rancher/ecm-distro-tools/release/components"
+	"github.com/rancher/ecm-distro-tools/release/rke2"
+)
+
+// ChartVersion is a Helm chart packaged by an rke2 release.
+type ChartVersion struct {
+	Name    string
+	Version string
+	// AppVersion and URL of the chart archive, if the version is published
+	// in the rke2-charts index.
+	AppVersion string `json:",omitempty"`
+	URL        string `json:",omitempty"`
+}
+
+// rke2Charts returns the charts packaged by the given rke2 files, read from
+// the CHART_VERSION lines of their Dockerfile or, for the branches that
+// don't build them there anymore, from their chart versions file. The app
+// versions are looked up in the rke2-charts index, and omitted if it can't
+// be fetched.
+func (c *Client) rke2Charts(files *repoFiles, chartVersions map[string]components.Chart) []ChartVersion {
+	refs := rke2.DockerfileCharts(files.Read("Dockerfile"))
+	if len(refs) == 0 {
+		for name, chart := range chartVersions {
+			refs = append(refs, rke2.ChartRef{
+				Name:      name,
+				Version:   chart.Version,
+				Bootstrap: chart.Bootstrap,
+			})
+		}
+		sort.Slice(refs, func(i, j int) bool {
+			return refs[i].Name < refs[j].Name
+		})
+	}
+
+	index, err := rke2.GetChartsIndex(context.Background(), c.HTTP, rke2.ChartsIndexURL)
+	if err != nil {
+		c.Log.Debugf("failed to fetch the rke2 charts index: %v", err)
+	}
+
+	charts := make([]ChartVersion, len(refs))
+	for i, ref := range refs {
+		charts[i] = ChartVersion{Name: ref.Name, Version: ref.Version}
+		if index == nil {
+			continue
+		}
+
+		indexed, ok := index.Chart(ref.Name, ref.Version)
+		if !ok {
+			c.Log.Debugf("chart %s %s isn't in the rke2 charts index", ref.Name, ref.Version)
+			continue
+		}
+		charts[i].AppVersion = indexed.AppVersion
+		if url, err := index.ArchiveURL(indexed); err == nil {
+			charts[i].URL = url
+		}
+	}
+
+	return charts
+}
@@ -0,0 +1,263 @@
+package rollback
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/google/go-github/v39/github"
+	"github.com/rancher/ecm-distro-tools/release/notify"
+	"github.com/rancher/ecm-distro-tools/repository"
+)
+
+// channelFiles are the files of the product repos the channel servers read
+// the latest version of each channel from.
+var channelFiles = map[string]string{
+	"k3s":  "channel.yaml",
+	"rke2": "channels.yaml",
+}
+
+var latestRegex = regexp.MustCompile(`^(\s*latest:\s*)(\S+)\s*$`)
+var nameRegex = regexp.MustCompile(`^\s*-?\s*name:\s*(\S+)\s*$`)
+
+// Options describes the rollback of a release.
+type Options struct {
+	Owner string
+	Repo  string
+	// Version is the botched release and Previous the release the channels
+	// are reverted to, e.g. v1.29.2+rke2r1 and v1.29.1+rke2r1.
+	Version  string
+	Previous string
+	// Draft unpublishes the release instead of marking it as a prerelease.
+	Draft bool
+	// WebhookURL is a Slack compatible incoming webhook notified of the
+	// rollback. No notification is sent if empty.
+	WebhookURL string
+	// Head is the fork, e.g. user, the channels change is pushed to. The
+	// branch is created in the product repo if empty.
+	Head   string
+	DryRun bool
+}
+
+// Step is the outcome of a step of the rollback.
+type Step struct {
+	Name   string
+	Detail string
+	Err    error
+}
+
+// Result contains the outcome of every step of a rollback.
+type Result struct {
+	Steps []Step
+}
+
+func (r *Result) add(name, detail string, err error) {
+	r.Steps = append(r.Steps, Step{Name: name, Detail: detail, Err: err})
+}
+
+// Summary returns a line per step with its outcome.
+func (r *Result) Summary() string {
+	var b strings.Builder
+	for _, step := range r.Steps {
+		b.WriteString(step.Name + ": ")
+		if step.Err != nil {
+			b.WriteString("failed: " + step.Err.Error())
+		} else {
+			b.WriteString(step.Detail)
+		}
+		b.WriteString("\n")
+	}
+
+	return b.String()
+}
+
+// Err returns an error listing the steps that failed, or nil if none did.
+func (r *Result) Err() error {
+	var failed []string
+	for _, step := range r.Steps {
+		if step.Err != nil {
+			failed = append(failed, step.Name+": "+step.Err.Error())
+		}
+	}
+	if len(failed) == 0 {
+		return nil
+	}
+
+	return errors.New(strconv.Itoa(len(failed)) + " rollback steps failed:\n" + strings.Join(failed, "\n"))
+}
+
+// Rollback unpublishes a botched release: it marks the GitHub release as a
+// prerelease or draft so the install scripts stop picking it, opens a pull
+// request reverting the channels pointing to it to the previous version,
+// and notifies the webhook. A failed step doesn't stop the following ones,
+// since a partial rollback is better than none.
+func Rollback(ctx context.Context, client *github.Client, opts Options) (*Result, error) {
+	file, ok := channelFiles[opts.Repo]
+	if !ok {
+		return nil, errors.New("invalid repo: " + opts.Repo + ", expected one of: k3s, rke2")
+	}
+	if opts.Version == "" || opts.Previous == "" {
+		return nil, errors.New("version and previous version are required")
+	}
+
+	var result Result
+
+	detail, err := unpublish(ctx, client, opts)
+	result.add("release", detail, err)
+
+	detail, err = revertChannels(ctx, client, opts, file)
+	result.add("channels", detail, err)
+
+	if opts.WebhookURL != "" {
+		detail, err = notifyWebhook(ctx, opts, result.Summary())
+		result.add("notification", detail, err)
+	}
+
+	return &result, nil
+}
+
+func unpublish(ctx context.Context, client *github.Client, opts Options) (string, error) {
+	release, err := repository.GetReleaseByTag(ctx, client, opts.Owner, opts.Repo, opts.Version)
+	if err != nil {
+		return "", err
+	}
+
+	edit := &github.RepositoryRelease{Prerelease: github.Bool(true)}
+	detail := "marked " + opts.Version + " as prerelease"
+	if opts.Draft {
+		edit = &github.RepositoryRelease{Draft: github.Bool(true)}
+		detail = "marked " + opts.Version + " as draft"
+	}
+
+	if opts.DryRun {
+		return "dry run, would have " + detail, nil
+	}
+
+	if _, _, err := client.Repositories.EditRelease(ctx, opts.Owner, opts.Repo, release.GetID(), edit); err != nil {
+		return "", repository.WrapGithubError(err, opts.Owner, opts.Repo, opts.Version)
+	}
+	repository.InvalidateRelease(opts.Owner, opts.Repo, opts.Version)
+
+	return detail, nil
+}
+
+// revertChannels opens a pull request to the default branch of the product
+// repo reverting the channels whose latest version is the botched one.
+func revertChannels(ctx context.Context, client *github.Client, opts Options, file string) (string, error) {
+	repo, _, err := client.Repositories.Get(ctx, opts.Owner, opts.Repo)
+	if err != nil {
+		return "", repository.WrapGithubError(err, opts.Owner, opts.Repo, "")
+	}
+	base := repo.GetDefaultBranch()
+
+	content, _, _, err := client.Repositories.GetContents(ctx, opts.Owner, opts.Repo, file, &github.RepositoryContentGetOptions{Ref: base})
+	if err != nil {
+		return "", repository.WrapGithubError(err, opts.Owner, opts.Repo, base)
+	}
+	current, err := content.GetContent()
+	if err != nil {
+		return "", err
+	}
+
+	reverted, channels, err := RevertChannels(current, opts.Version, opts.Previous)
+	if err != nil {
+		return "", err
+	}
+	if len(channels) == 0 {
+		return "no channel points to " + opts.Version, nil
+	}
+	detail := "reverted " + strings.Join(channels, ", ") + " to " + opts.Previous
+
+	if opts.DryRun {
+		return "dry run, would have " + detail, nil
+	}
+
+	headOwner := opts.Owner
+	if opts.Head != "" {
+		headOwner = opts.Head
+	}
+	branch := "rollback-" + strings.ReplaceAll(opts.Version, "+", "-")
+
+	baseRef, _, err := client.Git.GetRef(ctx, opts.Owner, opts.Repo, "refs/heads/"+base)
+	if err != nil {
+		return "", repository.WrapGithubError(err, opts.Owner, opts.Repo, base)
+	}
+	if _, _, err := client.Git.CreateRef(ctx, headOwner, opts.Repo, &github.Reference{
+		Ref:    github.String("refs/heads/" + branch),
+		Object: &github.GitObject{SHA: baseRef.Object.SHA},
+	}); err != nil {
+		return "", repository.WrapGithubError(err, headOwner, opts.Repo, branch)
+	}
+
+	message := "Revert channels from " + opts.Version + " to " + opts.Previous
+	if _, _, err := client.Repositories.UpdateFile(ctx, headOwner, opts.Repo, file, &github.RepositoryContentFileOptions{
+		Message: github.String(message),
+		Content: []byte(reverted),
+		SHA:     content.SHA,
+		Branch:  github.String(branch),
+	}); err != nil {
+		return "", repository.WrapGithubError(err, headOwner, opts.Repo, branch)
+	}
+
+	pr, _, err := client.PullRequests.Create(ctx, opts.Owner, opts.Repo, &github.NewPullRequest{
+		Title:               github.String(message),
+		Base:                github.String(base),
+		Head:                github.String(headOwner + ":" + branch),
+		Body:                github.String(opts.Version + " is being rolled back, this reverts " + strings.Join(channels, ", ") + " to " + opts.Previous + "."),
+		MaintainerCanModify: github.Bool(true),
+	})
+	if err != nil {
+		return "", repository.WrapGithubError(err, opts.Owner, opts.Repo, branch)
+	}
+
+	return detail + " in " + pr.GetHTMLURL(), nil
+}
+
+// RevertChannels replaces the latest version of the channels pointing to
+// version with previous in the given channels file, keeping the rest of the
+// file untouched, and returns the names of the reverted channels.
+func RevertChannels(content, version, previous string) (string, []string, error) {
+	var b strings.Builder
+	var channels []string
+	var channel string
+
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if m := nameRegex.FindStringSubmatch(line); m != nil {
+			channel = m[1]
+		}
+		if m := latestRegex.FindStringSubmatch(line); m != nil && m[2] == version {
+			line = m[1] + previous
+			channels = append(channels, channel)
+		}
+		b.WriteString(line + "\n")
+	}
+	if err := scanner.Err(); err != nil {
+		return "", nil, err
+	}
+
+	reverted := b.String()
+	if !strings.HasSuffix(content, "\n") {
+		reverted = strings.TrimSuffix(reverted, "\n")
+	}
+
+	return reverted, channels, nil
+}
+
+func notifyWebhook(ctx context.Context, opts Options, summary string) (string, error) {
+	text := ":rotating_light: " + opts.Repo + " " + opts.Version + " is being rolled back to " + opts.Previous + "\n" + summary
+	if opts.DryRun {
+		return "dry run, would have notified the webhook", nil
+	}
+
+	if err := notify.Webhook(ctx, http.DefaultClient, opts.WebhookURL, text); err != nil {
+		return "", err
+	}
+
+	return "notified the webhook", nil
+}
@@ -0,0 +1,57 @@
+package rollback
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestRevertChannels(t *testing.T) {
+	const channels = `channels:
+  - name: stable
+    latest: v1.28.9+rke2r1
+  - name: latest
+    latest: v1.29.4+rke2r1
+  - name: v1.29
+    latest: v1.29.4+rke2r1
+    latestRegexp: v1\.29\..*
+  - name: testing
+    latestRegexp: -(alpha|beta|rc)
+`
+	reverted, names, err := RevertChannels(channels, "v1.29.4+rke2r1", "v1.29.3+rke2r1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := strings.Join(names, ","); got != "latest,v1.29" {
+		t.Errorf("RevertChannels() channels = %s, want latest,v1.29", got)
+	}
+	want := strings.ReplaceAll(channels, "latest: v1.29.4+rke2r1", "latest: v1.29.3+rke2r1")
+	if reverted != want {
+		t.Errorf("RevertChannels() =\n%s\nwant\n%s", reverted, want)
+	}
+
+	unchanged, names, err := RevertChannels(channels, "v1.27.1+rke2r1", "v1.27.0+rke2r1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(names) != 0 || unchanged != channels {
+		t.Errorf("expected no changes, got %v", names)
+	}
+}
+
+func TestResult(t *testing.T) {
+	var r Result
+	r.add("release", "marked v1.29.4+rke2r1 as prerelease", nil)
+	if err := r.Err(); err != nil {
+		t.Errorf("Err() = %v, want nil", err)
+	}
+
+	r.add("channels", "", errors.New("not found"))
+	if got, want := r.Summary(), "release: marked v1.29.4+rke2r1 as prerelease\nchannels: failed: not found\n"; got != want {
+		t.Errorf("Summary() = %q, want %q", got, want)
+	}
+	if err := r.Err(); err == nil || !strings.Contains(err.Error(), "channels: not found") {
+		t.Errorf("Err() = %v", err)
+	}
+}
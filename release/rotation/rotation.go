@@ -0,0 +1,272 @@
+package rotation
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v39/github"
+	"github.com/rancher/ecm-distro-tools/release/notify"
+	"github.com/rancher/ecm-distro-tools/release/project"
+	"github.com/rancher/ecm-distro-tools/repository"
+)
+
+const (
+	// DefaultPath is where the rotation is read from unless configured
+	// otherwise.
+	DefaultPath = "$HOME/.ecm-distro-tools/rotation.json"
+
+	dateLayout = time.DateOnly
+)
+
+// Member is a member of the rotation.
+type Member struct {
+	GithubUsername string `json:"github_username"`
+	// Away lists the cycles, by their start date, e.g. 2024-02-05, the
+	// member can't captain.
+	Away []string `json:"away,omitempty"`
+}
+
+// Rotation describes the release captains rotation: cycles of CycleDays
+// days starting on Start, each captained by CaptainsPerCycle members taken
+// in order from Members.
+type Rotation struct {
+	Start            string   `json:"start"`
+	CycleDays        int      `json:"cycle_days"`
+	CaptainsPerCycle int      `json:"captains_per_cycle"`
+	Members          []Member `json:"members"`
+	// WebhookURL is a Slack compatible incoming webhook the captains are
+	// announced on. No announcement is sent if empty.
+	WebhookURL string `json:"webhook_url,omitempty"`
+}
+
+// Cycle is a release cycle and its captains.
+type Cycle struct {
+	Number   int
+	Start    time.Time
+	End      time.Time
+	Captains []string
+}
+
+// Load reads the rotation at the given path. Environment variables in the
+// path are expanded.
+func Load(path string) (*Rotation, error) {
+	f, err := os.Open(os.ExpandEnv(path))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var r Rotation
+	if err := json.NewDecoder(f).Decode(&r); err != nil {
+		return nil, errors.New("failed to read the rotation " + path + ": " + err.Error())
+	}
+	if err := r.validate(); err != nil {
+		return nil, err
+	}
+
+	return &r, nil
+}
+
+func (r *Rotation) validate() error {
+	if _, err := time.Parse(dateLayout, r.Start); err != nil {
+		return errors.New("invalid rotation start " + r.Start + ", expected a YYYY-MM-DD date")
+	}
+	if r.CycleDays <= 0 {
+		return errors.New("the rotation cycle_days must be positive")
+	}
+	if r.CaptainsPerCycle <= 0 || r.CaptainsPerCycle > len(r.Members) {
+		return errors.New("the rotation captains_per_cycle must be between 1 and the number of members")
+	}
+
+	return nil
+}
+
+// CycleAt returns the cycle the given time falls in. Times before the start
+// of the rotation belong to the first cycle.
+func (r *Rotation) CycleAt(t time.Time) (*Cycle, error) {
+	if err := r.validate(); err != nil {
+		return nil, err
+	}
+	start, _ := time.Parse(dateLayout, r.Start)
+
+	days := int(t.Sub(start).Hours() / 24)
+	number := 0
+	if days > 0 {
+		number = days / r.CycleDays
+	}
+
+	return r.Cycle(number)
+}
+
+// Cycle returns the cycle with the given number, counting from 0. Members
+// take turns in order, a member away for a cycle is replaced by the next
+// available one and keeps their turn for the following cycle.
+func (r *Rotation) Cycle(number int) (*Cycle, error) {
+	if err := r.validate(); err != nil {
+		return nil, err
+	}
+	start, _ := time.Parse(dateLayout, r.Start)
+
+	cycle := Cycle{
+		Number: number,
+		Start:  start.AddDate(0, 0, number*r.CycleDays),
+	}
+	cycle.End = cycle.Start.AddDate(0, 0, r.CycleDays-1)
+
+	// walk every cycle since the start, so the turns owed to the members
+	// who were away shift the following cycles the same way every time.
+	next := 0
+	var owed []int
+	for n := 0; n <= number; n++ {
+		date := start.AddDate(0, 0, n*r.CycleDays).Format(dateLayout)
+
+		var captains, stillOwed []int
+		for _, i := range owed {
+			if len(captains) < r.CaptainsPerCycle && !r.Members[i].away(date) {
+				captains = append(captains, i)
+			} else {
+				stillOwed = append(stillOwed, i)
+			}
+		}
+		for tries := 0; len(captains) < r.CaptainsPerCycle && tries < len(r.Members); tries++ {
+			i := next
+			next = (next + 1) % len(r.Members)
+			if contains(captains, i) || contains(stillOwed, i) {
+				continue
+			}
+			if r.Members[i].away(date) {
+				stillOwed = append(stillOwed, i)
+				continue
+			}
+			captains = append(captains, i)
+		}
+		if len(captains) < r.CaptainsPerCycle {
+			return nil, errors.New("not enough members available for the cycle starting on " + date)
+		}
+		owed = stillOwed
+
+		if n == number {
+			for _, i := range captains {
+				cycle.Captains = append(cycle.Captains, r.Members[i].GithubUsername)
+			}
+		}
+	}
+
+	return &cycle, nil
+}
+
+func (m Member) away(date string) bool {
+	for _, d := range m.Away {
+		if d == date {
+			return true
+		}
+	}
+
+	return false
+}
+
+func contains(s []int, v int) bool {
+	for _, e := range s {
+		if e == v {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Mentions returns the GitHub mentions of the captains of the cycle.
+func (c *Cycle) Mentions() string {
+	mentions := make([]string, len(c.Captains))
+	for i, captain := range c.Captains {
+		mentions[i] = "@" + captain
+	}
+
+	return strings.Join(mentions, " ")
+}
+
+// Assign assigns the captains of the cycle to the tracking issue of each
+// of the given releases, titled "Cut <release>", and records them in the
+// description of the release milestone, then mentions them on the issue.
+// Releases without a tracking issue or milestone are reported as errors
+// once every release was attempted.
+func Assign(ctx context.Context, client *github.Client, owner, repo string, cycle *Cycle, releases []string) error {
+	var failed []string
+	for _, release := range releases {
+		if err := assign(ctx, client, owner, repo, cycle, release); err != nil {
+			failed = append(failed, release+": "+err.Error())
+		}
+	}
+	if len(failed) > 0 {
+		return errors.New("failed to assign the captains of " + strconv.Itoa(len(failed)) + " releases:\n" + strings.Join(failed, "\n"))
+	}
+
+	return nil
+}
+
+func assign(ctx context.Context, client *github.Client, owner, repo string, cycle *Cycle, release string) error {
+	issue, err := repository.FindOpenIssue(ctx, client, owner, repo, "Cut "+release)
+	if err != nil {
+		return err
+	}
+	if issue == nil {
+		return errors.New("no open tracking issue titled Cut " + release)
+	}
+
+	if _, _, err := client.Issues.AddAssignees(ctx, owner, repo, issue.GetNumber(), cycle.Captains); err != nil {
+		return repository.WrapGithubError(err, owner, repo, "#"+strconv.Itoa(issue.GetNumber()))
+	}
+
+	milestone, err := project.FindMilestone(ctx, client, owner, repo, release)
+	if err != nil {
+		return err
+	}
+	description := CaptainsLine(milestone.GetDescription(), cycle.Captains)
+	if _, _, err := client.Issues.EditMilestone(ctx, owner, repo, milestone.GetNumber(), &github.Milestone{Description: github.String(description)}); err != nil {
+		return repository.WrapGithubError(err, owner, repo, release)
+	}
+
+	body := cycle.Mentions() + " you are the release captains of " + release + ", for the cycle from " + cycle.Start.Format(dateLayout) + " to " + cycle.End.Format(dateLayout) + "."
+	if _, _, err := client.Issues.CreateComment(ctx, owner, repo, issue.GetNumber(), &github.IssueComment{Body: github.String(body)}); err != nil {
+		return repository.WrapGithubError(err, owner, repo, "#"+strconv.Itoa(issue.GetNumber()))
+	}
+
+	return nil
+}
+
+// captainsPrefix starts the line of a milestone description listing its
+// captains.
+const captainsPrefix = "Captains: "
+
+// CaptainsLine sets the captains line of the given milestone description,
+// replacing the existing one if any.
+func CaptainsLine(description string, captains []string) string {
+	line := captainsPrefix + strings.Join(captains, ", ")
+
+	var lines []string
+	for _, l := range strings.Split(description, "\n") {
+		if !strings.HasPrefix(l, captainsPrefix) && l != "" {
+			lines = append(lines, l)
+		}
+	}
+
+	return strings.Join(append(lines, line), "\n")
+}
+
+// Announce posts the captains of the cycle to the rotation's webhook, if it
+// has one.
+func (r *Rotation) Announce(ctx context.Context, cycle *Cycle) error {
+	if r.WebhookURL == "" {
+		return nil
+	}
+
+	text := "Release captains from " + cycle.Start.Format(dateLayout) + " to " + cycle.End.Format(dateLayout) + ": " + strings.Join(cycle.Captains, ", ")
+
+	return notify.Webhook(ctx, http.DefaultClient, r.WebhookURL, text)
+}
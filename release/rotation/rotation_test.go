@@ -0,0 +1,82 @@
+package rotation
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCycle(t *testing.T) {
+	r := Rotation{
+		Start:            "2024-01-08",
+		CycleDays:        28,
+		CaptainsPerCycle: 1,
+		Members: []Member{
+			{GithubUsername: "alice"},
+			{GithubUsername: "bob", Away: []string{"2024-02-05"}},
+			{GithubUsername: "carol"},
+		},
+	}
+
+	tests := []struct {
+		name      string
+		date      time.Time
+		wantStart string
+		want      []string
+	}{
+		{
+			name:      "before the start",
+			date:      time.Date(2023, 12, 1, 0, 0, 0, 0, time.UTC),
+			wantStart: "2024-01-08",
+			want:      []string{"alice"},
+		},
+		{
+			name:      "away member is replaced",
+			date:      time.Date(2024, 2, 10, 0, 0, 0, 0, time.UTC),
+			wantStart: "2024-02-05",
+			want:      []string{"carol"},
+		},
+		{
+			name:      "away member keeps their turn",
+			date:      time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC),
+			wantStart: "2024-03-04",
+			want:      []string{"bob"},
+		},
+		{
+			name:      "rotation continues",
+			date:      time.Date(2024, 4, 15, 0, 0, 0, 0, time.UTC),
+			wantStart: "2024-04-01",
+			want:      []string{"alice"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cycle, err := r.CycleAt(tt.date)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := cycle.Start.Format(dateLayout); got != tt.wantStart {
+				t.Errorf("cycle start = %s, want %s", got, tt.wantStart)
+			}
+			if !reflect.DeepEqual(cycle.Captains, tt.want) {
+				t.Errorf("captains = %v, want %v", cycle.Captains, tt.want)
+			}
+		})
+	}
+
+	r.CaptainsPerCycle = 2
+	cycle, err := r.Cycle(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"carol", "alice"}; !reflect.DeepEqual(cycle.Captains, want) {
+		t.Errorf("captains = %v, want %v", cycle.Captains, want)
+	}
+}
+
+func TestCaptainsLine(t *testing.T) {
+	got := CaptainsLine("Kubernetes v1.29.3\nCaptains: alice", []string{"bob", "carol"})
+	if want := "Kubernetes v1.29.3\nCaptains: bob, carol"; got != want {
+		t.Errorf("CaptainsLine() = %q, want %q", got, want)
+	}
+}
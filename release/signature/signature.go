@@ -0,0 +1,371 @@
+package signature
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"hash"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/google/go-github/v39/github"
+	"github.com/rancher/ecm-distro-tools/repository"
+	"golang.org/x/crypto/ssh"
+)
+
+const (
+	pgpSignatureHeader = "-----BEGIN PGP SIGNATURE-----"
+	sshSignatureHeader = "-----BEGIN SSH SIGNATURE-----"
+
+	// sshSigMagic is the preamble of SSH signatures, see PROTOCOL.sshsig in
+	// the OpenSSH sources.
+	sshSigMagic = "SSHSIG"
+	// gitNamespace is the namespace git signs tags and commits in.
+	gitNamespace = "git"
+)
+
+// AllowList contains the keys allowed to sign release tags and commits.
+type AllowList struct {
+	keyring openpgp.EntityList
+	signers []allowedSigner
+}
+
+type allowedSigner struct {
+	principals string
+	key        ssh.PublicKey
+}
+
+// LoadAllowList loads the armored GPG public keys of the given keyring file
+// and the SSH keys of the given allowed signers file, in the format of
+// git's gpg.ssh.allowedSignersFile. Either path may be empty.
+func LoadAllowList(keyringPath, allowedSignersPath string) (*AllowList, error) {
+	var allow AllowList
+
+	if keyringPath != "" {
+		f, err := os.Open(keyringPath)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+
+		if allow.keyring, err = openpgp.ReadArmoredKeyRing(f); err != nil {
+			return nil, errors.New("failed to read keyring " + keyringPath + ": " + err.Error())
+		}
+	}
+
+	if allowedSignersPath != "" {
+		f, err := os.Open(allowedSignersPath)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+
+		if allow.signers, err = parseAllowedSigners(f); err != nil {
+			return nil, errors.New("failed to read allowed signers " + allowedSignersPath + ": " + err.Error())
+		}
+	}
+
+	if len(allow.keyring) == 0 && len(allow.signers) == 0 {
+		return nil, errors.New("the allow list doesn't contain any key")
+	}
+
+	return &allow, nil
+}
+
+// parseAllowedSigners parses lines in the format
+// principals [options] keytype key [comment].
+func parseAllowedSigners(r io.Reader) ([]allowedSigner, error) {
+	var signers []allowedSigner
+
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		principals, rest, ok := strings.Cut(text, " ")
+		if !ok {
+			return nil, errors.New("line " + strconv.Itoa(line) + ": missing key")
+		}
+		key, _, _, _, err := ssh.ParseAuthorizedKey([]byte(strings.TrimSpace(rest)))
+		if err != nil {
+			return nil, errors.New("line " + strconv.Itoa(line) + ": " + err.Error())
+		}
+		signers = append(signers, allowedSigner{principals: principals, key: key})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return signers, nil
+}
+
+// Verify checks that the given armored GPG or SSH signature of payload was
+// made by a key on the allow list and returns the signer.
+func (a *AllowList) Verify(signature, payload string) (string, error) {
+	signature = strings.TrimSpace(signature)
+
+	switch {
+	case signature == "":
+		return "", errors.New("not signed")
+	case strings.HasPrefix(signature, pgpSignatureHeader):
+		return a.verifyPGP(signature, payload)
+	case strings.HasPrefix(signature, sshSignatureHeader):
+		return a.verifySSH(signature, payload)
+	default:
+		return "", errors.New("unsupported signature format")
+	}
+}
+
+func (a *AllowList) verifyPGP(signature, payload string) (string, error) {
+	if len(a.keyring) == 0 {
+		return "", errors.New("signed with GPG but the allow list has no GPG keys")
+	}
+
+	entity, err := openpgp.CheckArmoredDetachedSignature(a.keyring, strings.NewReader(payload), strings.NewReader(signature), nil)
+	if err != nil {
+		return "", errors.New("GPG signature not made by an allowed key: " + err.Error())
+	}
+
+	for name := range entity.Identities {
+		return name, nil
+	}
+
+	return strings.ToUpper(entity.PrimaryKey.KeyIdString()), nil
+}
+
+// sshSig is the blob of an SSH signature, after its magic preamble.
+type sshSig struct {
+	Version       uint32
+	PublicKey     []byte
+	Namespace     string
+	Reserved      string
+	HashAlgorithm string
+	Signature     []byte
+}
+
+// sshSignedData is what an SSH signature signs, after its magic preamble.
+type sshSignedData struct {
+	Namespace     string
+	Reserved      string
+	HashAlgorithm string
+	Hash          []byte
+}
+
+func (a *AllowList) verifySSH(signature, payload string) (string, error) {
+	block, _ := pem.Decode([]byte(signature))
+	if block == nil || block.Type != "SSH SIGNATURE" {
+		return "", errors.New("invalid SSH signature")
+	}
+	if !bytes.HasPrefix(block.Bytes, []byte(sshSigMagic)) {
+		return "", errors.New("invalid SSH signature preamble")
+	}
+
+	var sig sshSig
+	if err := ssh.Unmarshal(block.Bytes[len(sshSigMagic):], &sig); err != nil {
+		return "", errors.New("invalid SSH signature: " + err.Error())
+	}
+	if sig.Version != 1 {
+		return "", errors.New("unsupported SSH signature version " + strconv.Itoa(int(sig.Version)))
+	}
+	if sig.Namespace != gitNamespace {
+		return "", errors.New("SSH signature namespace is " + sig.Namespace + ", expected " + gitNamespace)
+	}
+
+	key, err := ssh.ParsePublicKey(sig.PublicKey)
+	if err != nil {
+		return "", err
+	}
+	principals, ok := a.sshSigner(key)
+	if !ok {
+		return "", errors.New("SSH signature made by " + ssh.FingerprintSHA256(key) + ", which isn't an allowed key")
+	}
+
+	var h hash.Hash
+	switch sig.HashAlgorithm {
+	case "sha256":
+		h = sha256.New()
+	case "sha512":
+		h = sha512.New()
+	default:
+		return "", errors.New("unsupported SSH signature hash algorithm " + sig.HashAlgorithm)
+	}
+	h.Write([]byte(payload))
+
+	var s ssh.Signature
+	if err := ssh.Unmarshal(sig.Signature, &s); err != nil {
+		return "", errors.New("invalid SSH signature: " + err.Error())
+	}
+
+	signed := append([]byte(sshSigMagic), ssh.Marshal(sshSignedData{
+		Namespace:     sig.Namespace,
+		Reserved:      sig.Reserved,
+		HashAlgorithm: sig.HashAlgorithm,
+		Hash:          h.Sum(nil),
+	})...)
+	if err := key.Verify(signed, &s); err != nil {
+		return "", errors.New("invalid SSH signature from " + principals + ": " + err.Error())
+	}
+
+	return principals, nil
+}
+
+func (a *AllowList) sshSigner(key ssh.PublicKey) (string, bool) {
+	marshaled := key.Marshal()
+	for _, signer := range a.signers {
+		if bytes.Equal(signer.key.Marshal(), marshaled) {
+			return signer.principals, true
+		}
+	}
+
+	return "", false
+}
+
+// Check is the outcome of the verification of the signature of a tag or
+// commit.
+type Check struct {
+	Object string
+	SHA    string
+	Signer string
+	Err    error
+}
+
+// Result contains the signature checks of a release tag.
+type Result struct {
+	Tag    string
+	Checks []Check
+}
+
+// Summary returns a line per check with its outcome.
+func (r *Result) Summary() string {
+	var b strings.Builder
+	for _, check := range r.Checks {
+		b.WriteString(check.Object + " " + check.SHA + ": ")
+		if check.Err != nil {
+			b.WriteString("failed: " + check.Err.Error())
+		} else {
+			b.WriteString("signed by " + check.Signer)
+		}
+		b.WriteString("\n")
+	}
+
+	return b.String()
+}
+
+// Err returns an error listing the checks that failed, or nil if none did.
+func (r *Result) Err() error {
+	var failed []string
+	for _, check := range r.Checks {
+		if check.Err != nil {
+			failed = append(failed, check.Object+" "+check.SHA+": "+check.Err.Error())
+		}
+	}
+	if len(failed) == 0 {
+		return nil
+	}
+
+	return errors.New(strconv.Itoa(len(failed)) + " signature checks of " + r.Tag + " failed:\n" + strings.Join(failed, "\n"))
+}
+
+// VerifyTag checks that the given tag, which must be annotated, and the
+// commit it points to are signed by keys on the allow list. The signatures
+// and signed payloads are read from GitHub, so nothing is cloned.
+func VerifyTag(ctx context.Context, client *github.Client, owner, repo, tag string, allow *AllowList) (*Result, error) {
+	ref, _, err := client.Git.GetRef(ctx, owner, repo, "refs/tags/"+tag)
+	if err != nil {
+		return nil, repository.WrapGithubError(err, owner, repo, tag)
+	}
+
+	result := Result{Tag: tag}
+
+	commitSHA := ref.GetObject().GetSHA()
+	if ref.GetObject().GetType() == "tag" {
+		t, _, err := client.Git.GetTag(ctx, owner, repo, commitSHA)
+		if err != nil {
+			return nil, repository.WrapGithubError(err, owner, repo, tag)
+		}
+		check := Check{Object: "tag", SHA: t.GetSHA()}
+		check.Signer, check.Err = allow.Verify(t.GetVerification().GetSignature(), t.GetVerification().GetPayload())
+		if check.Err == nil {
+			check.Err = verifyTagPayload(t.GetVerification().GetPayload(), tag, t.GetObject().GetSHA())
+		}
+		result.Checks = append(result.Checks, check)
+		commitSHA = t.GetObject().GetSHA()
+	} else {
+		result.Checks = append(result.Checks, Check{Object: "tag", SHA: commitSHA, Err: errors.New("lightweight tags can't be signed")})
+	}
+
+	commit, _, err := client.Git.GetCommit(ctx, owner, repo, commitSHA)
+	if err != nil {
+		return nil, repository.WrapGithubError(err, owner, repo, commitSHA)
+	}
+	check := Check{Object: "commit", SHA: commit.GetSHA()}
+	check.Signer, check.Err = allow.Verify(commit.GetVerification().GetSignature(), commit.GetVerification().GetPayload())
+	if check.Err == nil {
+		check.Err = verifyCommitPayload(commit.GetVerification().GetPayload(), commit.GetVerification().GetSignature(), commit.GetSHA())
+	}
+	result.Checks = append(result.Checks, check)
+
+	return &result, nil
+}
+
+// verifyTagPayload checks that the signed payload of a tag object is the
+// one of the given tag, pointing to the given object, so a signature of
+// another tag can't be passed off as its own.
+func verifyTagPayload(payload, tag, object string) error {
+	headers := payloadHeaders(payload)
+	if headers["object"] != object {
+		return errors.New("signed payload points to " + headers["object"] + ", expected " + object)
+	}
+	if headers["tag"] != tag {
+		return errors.New("signed payload is of tag " + headers["tag"] + ", expected " + tag)
+	}
+
+	return nil
+}
+
+// verifyCommitPayload checks that the signed payload of a commit, along
+// with its signature in the gpgsig header, hashes to the given commit SHA.
+func verifyCommitPayload(payload, signature, sha string) error {
+	end := strings.Index(payload, "\n\n")
+	if end == -1 {
+		return errors.New("signed payload has no message")
+	}
+	gpgsig := "gpgsig " + strings.ReplaceAll(strings.TrimSuffix(signature, "\n"), "\n", "\n ") + "\n"
+	object := payload[:end+1] + gpgsig + payload[end+1:]
+
+	h := sha1.New()
+	h.Write([]byte("commit " + strconv.Itoa(len(object)) + "\x00" + object))
+	if got := hex.EncodeToString(h.Sum(nil)); got != sha {
+		return errors.New("signed payload is of commit " + got + ", expected " + sha)
+	}
+
+	return nil
+}
+
+// payloadHeaders returns the first value of each header of the given git
+// object, up to the blank line before its message.
+func payloadHeaders(payload string) map[string]string {
+	headers := make(map[string]string)
+	for _, line := range strings.Split(payload, "\n") {
+		if line == "" {
+			break
+		}
+		key, value, _ := strings.Cut(line, " ")
+		if _, ok := headers[key]; !ok {
+			headers[key] = value
+		}
+	}
+
+	return headers
+}
@@ -0,0 +1,184 @@
+package signature
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha512"
+	"encoding/pem"
+	"strings"
+	"testing"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"golang.org/x/crypto/ssh"
+)
+
+const payload = "object 3f2a1b0\ntype commit\ntag v1.29.2+rke2r1\ntagger Release Captain <captain@example.com> 1706781600 +0000\n\nv1.29.2+rke2r1\n"
+
+// sshSign signs the payload the way ssh-keygen -Y sign does.
+func sshSign(t *testing.T, signer ssh.Signer, namespace, payload string) string {
+	t.Helper()
+
+	h := sha512.Sum512([]byte(payload))
+	signed := append([]byte(sshSigMagic), ssh.Marshal(sshSignedData{
+		Namespace:     namespace,
+		HashAlgorithm: "sha512",
+		Hash:          h[:],
+	})...)
+	sig, err := signer.Sign(rand.Reader, signed)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	blob := append([]byte(sshSigMagic), ssh.Marshal(sshSig{
+		Version:       1,
+		PublicKey:     signer.PublicKey().Marshal(),
+		Namespace:     namespace,
+		HashAlgorithm: "sha512",
+		Signature:     ssh.Marshal(sig),
+	})...)
+
+	return string(pem.EncodeToMemory(&pem.Block{Type: "SSH SIGNATURE", Bytes: blob}))
+}
+
+func newSSHSigner(t *testing.T) ssh.Signer {
+	t.Helper()
+
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := ssh.NewSignerFromKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return signer
+}
+
+func TestVerify(t *testing.T) {
+	allowed := newSSHSigner(t)
+	other := newSSHSigner(t)
+
+	signers, err := parseAllowedSigners(strings.NewReader("# release captains\ncaptain@example.com namespaces=\"git\" " + string(ssh.MarshalAuthorizedKey(allowed.PublicKey()))))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entity, err := openpgp.NewEntity("Release Captain", "", "captain@example.com", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var pgpSig bytes.Buffer
+	if err := openpgp.ArmoredDetachSign(&pgpSig, entity, strings.NewReader(payload), nil); err != nil {
+		t.Fatal(err)
+	}
+	unknown, err := openpgp.NewEntity("Someone", "", "someone@example.com", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var unknownSig bytes.Buffer
+	if err := openpgp.ArmoredDetachSign(&unknownSig, unknown, strings.NewReader(payload), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	allow := &AllowList{keyring: openpgp.EntityList{entity}, signers: signers}
+
+	tests := []struct {
+		name       string
+		signature  string
+		payload    string
+		wantSigner string
+		wantErr    bool
+	}{
+		{
+			name:       "allowed ssh key",
+			signature:  sshSign(t, allowed, gitNamespace, payload),
+			payload:    payload,
+			wantSigner: "captain@example.com",
+		},
+		{
+			name:      "unknown ssh key",
+			signature: sshSign(t, other, gitNamespace, payload),
+			payload:   payload,
+			wantErr:   true,
+		},
+		{
+			name:      "ssh signature of another payload",
+			signature: sshSign(t, allowed, gitNamespace, payload),
+			payload:   payload + "tampered",
+			wantErr:   true,
+		},
+		{
+			name:      "ssh signature of another namespace",
+			signature: sshSign(t, allowed, "file", payload),
+			payload:   payload,
+			wantErr:   true,
+		},
+		{
+			name:       "allowed gpg key",
+			signature:  pgpSig.String(),
+			payload:    payload,
+			wantSigner: "Release Captain <captain@example.com>",
+		},
+		{
+			name:      "unknown gpg key",
+			signature: unknownSig.String(),
+			payload:   payload,
+			wantErr:   true,
+		},
+		{
+			name:    "unsigned",
+			payload: payload,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			signer, err := allow.Verify(tt.signature, tt.payload)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Verify() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if signer != tt.wantSigner {
+				t.Errorf("Verify() = %q, want %q", signer, tt.wantSigner)
+			}
+		})
+	}
+}
+
+func TestVerifyTagPayload(t *testing.T) {
+	tests := []struct {
+		name    string
+		tag     string
+		object  string
+		wantErr bool
+	}{
+		{name: "matching", tag: "v1.29.2+rke2r1", object: "3f2a1b0"},
+		{name: "other tag", tag: "v1.29.3+rke2r1", object: "3f2a1b0", wantErr: true},
+		{name: "other object", tag: "v1.29.2+rke2r1", object: "9e8d7c6", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := verifyTagPayload(payload, tt.tag, tt.object); (err != nil) != tt.wantErr {
+				t.Errorf("verifyTagPayload() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestVerifyCommitPayload(t *testing.T) {
+	const (
+		commitPayload = "tree 4b825dc642cb6eb9a060e54bf8d69288fbee4904\nauthor Release Captain <captain@example.com> 1706781600 +0000\ncommitter Release Captain <captain@example.com> 1706781600 +0000\n\nv1.29.2+rke2r1\n"
+		commitSig     = "-----BEGIN SSH SIGNATURE-----\nU1NIU0lHAAAAAQ==\n-----END SSH SIGNATURE-----\n"
+		// the sha of the commit object with the signature in its gpgsig
+		// header, as computed by git hash-object.
+		commitSHA = "d7884e4a5485adbe4ff2d5c135d4e475c9661547"
+	)
+
+	if err := verifyCommitPayload(commitPayload, commitSig, commitSHA); err != nil {
+		t.Error(err)
+	}
+	if err := verifyCommitPayload(commitPayload, commitSig, "3f2a1b0"); err == nil {
+		t.Error("verifyCommitPayload() expected an error for another commit")
+	}
+}
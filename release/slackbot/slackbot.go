@@ -0,0 +1,375 @@
+package slackbot
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rancher/ecm-distro-tools/release"
+	"github.com/rancher/ecm-distro-tools/release/status"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// maxClockSkew is how old a request can be before it's rejected as a
+	// possible replay, as recommended by Slack.
+	maxClockSkew = 5 * time.Minute
+	// queryTimeout bounds the queries answered after the command was
+	// acknowledged.
+	queryTimeout = 5 * time.Minute
+	// maxViolations is the number of asset violations listed in a message,
+	// Slack truncating long blocks.
+	maxViolations  = 20
+	maxRequestSize = 64 << 10
+)
+
+const usage = "Usage:\n" +
+	"`/release status [tag]...` status of the tag, CI, GitHub release and assets of k3s or rke2 releases, e.g. `/release status v1.29.2+rke2r1`\n" +
+	"`/release assets [repo] [tag]` assets of a release breaking the asset rules, e.g. `/release assets k3s v1.29.2+k3s1`"
+
+// Checker answers the release queries, implemented by NewChecker.
+type Checker interface {
+	Statuses(ctx context.Context, targets []status.Target) []status.Status
+	CheckAssetRules(ctx context.Context, owner, repo, tag string) ([]release.AssetViolation, error)
+}
+
+type checker struct {
+	client *release.Client
+}
+
+// NewChecker returns a checker querying GitHub with the given client.
+func NewChecker(client *release.Client) Checker {
+	return checker{client: client}
+}
+
+func (c checker) Statuses(ctx context.Context, targets []status.Target) []status.Status {
+	return status.CheckAll(ctx, c.client, targets)
+}
+
+func (c checker) CheckAssetRules(ctx context.Context, owner, repo, tag string) ([]release.AssetViolation, error) {
+	return c.client.CheckAssetRules(ctx, owner, repo, tag)
+}
+
+// Options configures a Bot.
+type Options struct {
+	// SigningSecret of the Slack app, used to verify the requests come
+	// from Slack.
+	SigningSecret string
+	// Owners maps the repos that can be queried to their GitHub
+	// organization, e.g. rke2 to rancher.
+	Owners map[string]string
+	// HTTP is the client the answers are posted to Slack with,
+	// http.DefaultClient if nil.
+	HTTP *http.Client
+	// Log reports the answers that couldn't be posted,
+	// logrus.StandardLogger if nil.
+	Log logrus.FieldLogger
+}
+
+// Bot handles the Slack slash command answering the common release day
+// questions, e.g. /release status v1.29.2+rke2r1. Commands are acknowledged
+// immediately and answered through their response URL, since the queries
+// take longer than Slack waits for.
+type Bot struct {
+	checker Checker
+	opts    Options
+	now     func() time.Time
+}
+
+// New creates a bot answering the queries with the given checker.
+func New(checker Checker, opts Options) *Bot {
+	if opts.HTTP == nil {
+		opts.HTTP = http.DefaultClient
+	}
+	if opts.Log == nil {
+		opts.Log = logrus.StandardLogger()
+	}
+
+	return &Bot{checker: checker, opts: opts, now: time.Now}
+}
+
+// Message is a Slack message.
+type Message struct {
+	// ResponseType is in_channel for answers visible to the whole channel,
+	// and ephemeral for the ones only visible to the user.
+	ResponseType string  `json:"response_type,omitempty"`
+	Text         string  `json:"text"`
+	Blocks       []Block `json:"blocks,omitempty"`
+}
+
+// Block is a Slack layout block.
+type Block struct {
+	Type     string `json:"type"`
+	Text     *Text  `json:"text,omitempty"`
+	Fields   []Text `json:"fields,omitempty"`
+	Elements []Text `json:"elements,omitempty"`
+}
+
+// Text is a Slack text object.
+type Text struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+func markdown(text string) Text {
+	return Text{Type: "mrkdwn", Text: text}
+}
+
+// ServeHTTP implements http.Handler for the slash command requests.
+func (b *Bot) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed: "+r.Method, http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxRequestSize))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := b.verify(r.Header, body); err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ack, answer := b.command(form.Get("text"))
+	if answer != nil {
+		responseURL := form.Get("response_url")
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), queryTimeout)
+			defer cancel()
+
+			if err := b.post(ctx, responseURL, answer(ctx)); err != nil {
+				b.opts.Log.Errorf("failed to answer %s %s: %v", form.Get("command"), form.Get("text"), err)
+			}
+		}()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ack)
+}
+
+// verify checks the signature of the request, computed by Slack with the
+// signing secret of the app.
+func (b *Bot) verify(header http.Header, body []byte) error {
+	timestamp := header.Get("X-Slack-Request-Timestamp")
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return errors.New("invalid request timestamp")
+	}
+	if skew := b.now().Sub(time.Unix(seconds, 0)); skew > maxClockSkew || skew < -maxClockSkew {
+		return errors.New("request timestamp too far from now")
+	}
+
+	mac := hmac.New(sha256.New, []byte(b.opts.SigningSecret))
+	mac.Write([]byte("v0:" + timestamp + ":"))
+	mac.Write(body)
+	expected := "v0=" + hex.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(header.Get("X-Slack-Signature")), []byte(expected)) {
+		return errors.New("invalid request signature")
+	}
+
+	return nil
+}
+
+// command parses the text of a slash command and returns the message
+// acknowledging it and, if valid, the function answering it.
+func (b *Bot) command(text string) (Message, func(context.Context) Message) {
+	args := strings.Fields(text)
+	if len(args) == 0 {
+		return ephemeral(usage), nil
+	}
+
+	switch args[0] {
+	case "status":
+		targets, err := b.statusTargets(args[1:])
+		if err != nil {
+			return ephemeral(err.Error() + "\n" + usage), nil
+		}
+		return ephemeral("Checking the status of " + strings.Join(args[1:], ", ") + "…"), func(ctx context.Context) Message {
+			return statusMessage(b.checker.Statuses(ctx, targets))
+		}
+	case "assets":
+		if len(args) != 3 {
+			return ephemeral("expected a repo and a tag\n" + usage), nil
+		}
+		repo, tag := args[1], args[2]
+		owner, ok := b.opts.Owners[repo]
+		if !ok {
+			return ephemeral("invalid repo: " + repo + ", expected one of: " + strings.Join(b.repos(), ", ")), nil
+		}
+		return ephemeral("Checking the assets of " + repo + " " + tag + "…"), func(ctx context.Context) Message {
+			violations, err := b.checker.CheckAssetRules(ctx, owner, repo, tag)
+			return assetsMessage(repo, tag, violations, err)
+		}
+	default:
+		return ephemeral("unknown command: " + args[0] + "\n" + usage), nil
+	}
+}
+
+// statusTargets returns the releases of the given tags, whose product is
+// inferred from their suffix, e.g. +rke2r1.
+func (b *Bot) statusTargets(tags []string) ([]status.Target, error) {
+	if len(tags) == 0 {
+		return nil, errors.New("expected at least one tag")
+	}
+
+	targets := make([]status.Target, len(tags))
+	for i, tag := range tags {
+		var repo string
+		switch {
+		case strings.Contains(tag, "+rke2"):
+			repo = "rke2"
+		case strings.Contains(tag, "+k3s"):
+			repo = "k3s"
+		default:
+			return nil, errors.New("invalid tag: " + tag + ", expected a k3s or rke2 tag")
+		}
+		owner, ok := b.opts.Owners[repo]
+		if !ok {
+			return nil, errors.New(repo + " releases can't be queried")
+		}
+		targets[i] = status.Target{Owner: owner, Repo: repo, Tag: tag}
+	}
+
+	return targets, nil
+}
+
+func (b *Bot) repos() []string {
+	repos := make([]string, 0, len(b.opts.Owners))
+	for repo := range b.opts.Owners {
+		repos = append(repos, repo)
+	}
+	sort.Strings(repos)
+
+	return repos
+}
+
+func ephemeral(text string) Message {
+	return Message{ResponseType: "ephemeral", Text: text}
+}
+
+// statusMessage formats a block per release with the status of each of
+// its steps.
+func statusMessage(statuses []status.Status) Message {
+	msg := Message{ResponseType: "in_channel"}
+
+	var summary []string
+	for _, s := range statuses {
+		name := s.Owner + "/" + s.Repo + "@" + s.Tag
+		summary = append(summary, name+": "+statusEmoji(s))
+
+		ci := s.CI
+		if s.CIURL != "" {
+			ci = "<" + s.CIURL + "|" + s.CI + ">"
+		}
+		msg.Blocks = append(msg.Blocks,
+			Block{Type: "section", Text: &Text{Type: "mrkdwn", Text: statusEmoji(s) + " *" + name + "*"}},
+			Block{Type: "section", Fields: []Text{
+				markdown("*Tag*\n" + mark(s.Tagged)),
+				markdown("*CI*\n" + ci),
+				markdown("*Release*\n" + s.Release),
+				markdown("*Assets*\n" + mark(s.Assets)),
+			}},
+		)
+		if s.Err != nil {
+			msg.Blocks = append(msg.Blocks, Block{Type: "context", Elements: []Text{markdown("error: " + s.Err.Error())}})
+		}
+	}
+	msg.Text = strings.Join(summary, "\n")
+
+	return msg
+}
+
+// statusEmoji summarizes the status of a release: failed, released, or
+// still in progress.
+func statusEmoji(s status.Status) string {
+	switch {
+	case s.Err != nil || s.CI == "failure":
+		return ":x:"
+	case s.Tagged && s.CI == "success" && s.Release == "published" && s.Assets:
+		return ":white_check_mark:"
+	default:
+		return ":hourglass_flowing_sand:"
+	}
+}
+
+// assetsMessage formats the assets of a release breaking the asset rules.
+func assetsMessage(repo, tag string, violations []release.AssetViolation, err error) Message {
+	if err != nil {
+		return Message{ResponseType: "in_channel", Text: ":x: failed to check the assets of " + repo + " " + tag + ": " + err.Error()}
+	}
+	if len(violations) == 0 {
+		return Message{ResponseType: "in_channel", Text: ":white_check_mark: all assets of " + repo + " " + tag + " follow the asset rules"}
+	}
+
+	text := ":x: " + strconv.Itoa(len(violations)) + " assets of " + repo + " " + tag + " break the asset rules"
+
+	var list strings.Builder
+	for i, violation := range violations {
+		if i == maxViolations {
+			list.WriteString("… and " + strconv.Itoa(len(violations)-maxViolations) + " more")
+			break
+		}
+		list.WriteString("• `" + violation.Asset + "`: " + violation.Reason + "\n")
+	}
+
+	return Message{
+		ResponseType: "in_channel",
+		Text:         text,
+		Blocks: []Block{
+			{Type: "section", Text: &Text{Type: "mrkdwn", Text: text}},
+			{Type: "section", Text: &Text{Type: "mrkdwn", Text: strings.TrimSuffix(list.String(), "\n")}},
+		},
+	}
+}
+
+func mark(ok bool) string {
+	if ok {
+		return "✓"
+	}
+	return "✗"
+}
+
+// post sends the answer of a command to its response URL.
+func (b *Bot) post(ctx context.Context, responseURL string, msg Message) error {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, responseURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := b.opts.HTTP.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return errors.New("status error: " + res.Status + " when posting to the response url")
+	}
+
+	return nil
+}
@@ -0,0 +1,215 @@
+package slackbot
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rancher/ecm-distro-tools/release"
+	"github.com/rancher/ecm-distro-tools/release/status"
+)
+
+type fakeChecker struct{}
+
+func (fakeChecker) Statuses(ctx context.Context, targets []status.Target) []status.Status {
+	statuses := make([]status.Status, len(targets))
+	for i, t := range targets {
+		statuses[i] = status.Status{Target: t, Tagged: true, CI: "success", Release: "published", Assets: true}
+		if t.Repo == "k3s" {
+			statuses[i] = status.Status{Target: t, Tagged: true, CI: "pending", CIURL: "https://github.com/k3s-io/k3s/actions/runs/1", Release: "missing"}
+		}
+	}
+
+	return statuses
+}
+
+func (fakeChecker) CheckAssetRules(ctx context.Context, owner, repo, tag string) ([]release.AssetViolation, error) {
+	if tag == "v0.0.0+k3s1" {
+		return nil, errors.New("release not found")
+	}
+
+	return []release.AssetViolation{{Asset: "k3s-arm64", Reason: "content type text/plain, expected application/octet-stream"}}, nil
+}
+
+func sign(secret, timestamp, body string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("v0:" + timestamp + ":" + body))
+
+	return "v0=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// messageText returns the texts of the message and of its blocks.
+func messageText(msg Message) string {
+	texts := []string{msg.Text}
+	for _, block := range msg.Blocks {
+		if block.Text != nil {
+			texts = append(texts, block.Text.Text)
+		}
+		for _, field := range append(block.Fields, block.Elements...) {
+			texts = append(texts, field.Text)
+		}
+	}
+
+	return strings.Join(texts, "\n")
+}
+
+func TestBot(t *testing.T) {
+	answers := make(chan Message, 1)
+	slack := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var msg Message
+		if err := json.NewDecoder(r.Body).Decode(&msg); err != nil {
+			t.Error(err)
+		}
+		answers <- msg
+	}))
+	defer slack.Close()
+
+	now := time.Unix(1709300000, 0)
+	bot := New(fakeChecker{}, Options{
+		SigningSecret: "secret",
+		Owners:        map[string]string{"k3s": "k3s-io", "rke2": "rancher"},
+	})
+	bot.now = func() time.Time { return now }
+	server := httptest.NewServer(bot)
+	defer server.Close()
+
+	tests := []struct {
+		name       string
+		text       string
+		timestamp  time.Time
+		secret     string
+		wantStatus int
+		wantAck    string
+		wantAnswer string
+	}{
+		{
+			name:       "status",
+			text:       "status v1.29.2+rke2r1 v1.29.2+k3s1",
+			wantStatus: http.StatusOK,
+			wantAck:    "Checking the status of v1.29.2+rke2r1, v1.29.2+k3s1",
+			wantAnswer: "rancher/rke2@v1.29.2+rke2r1: :white_check_mark:\nk3s-io/k3s@v1.29.2+k3s1: :hourglass_flowing_sand:",
+		},
+		{
+			name:       "status ci link",
+			text:       "status v1.29.2+k3s1",
+			wantStatus: http.StatusOK,
+			wantAck:    "Checking the status",
+			wantAnswer: "*CI*\n<https://github.com/k3s-io/k3s/actions/runs/1|pending>",
+		},
+		{
+			name:       "status invalid tag",
+			text:       "status v1.29.2",
+			wantStatus: http.StatusOK,
+			wantAck:    "invalid tag: v1.29.2, expected a k3s or rke2 tag",
+		},
+		{
+			name:       "assets",
+			text:       "assets k3s v1.29.2+k3s1",
+			wantStatus: http.StatusOK,
+			wantAck:    "Checking the assets of k3s v1.29.2+k3s1",
+			wantAnswer: "• `k3s-arm64`: content type text/plain, expected application/octet-stream",
+		},
+		{
+			name:       "assets error",
+			text:       "assets k3s v0.0.0+k3s1",
+			wantStatus: http.StatusOK,
+			wantAck:    "Checking the assets",
+			wantAnswer: ":x: failed to check the assets of k3s v0.0.0+k3s1: release not found",
+		},
+		{
+			name:       "assets invalid repo",
+			text:       "assets rancher v2.8.2",
+			wantStatus: http.StatusOK,
+			wantAck:    "invalid repo: rancher, expected one of: k3s, rke2",
+		},
+		{
+			name:       "usage",
+			text:       "",
+			wantStatus: http.StatusOK,
+			wantAck:    "Usage:",
+		},
+		{
+			name:       "invalid signature",
+			text:       "status v1.29.2+rke2r1",
+			secret:     "wrong",
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "replayed",
+			text:       "status v1.29.2+rke2r1",
+			timestamp:  now.Add(-10 * time.Minute),
+			wantStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			timestamp := tt.timestamp
+			if timestamp.IsZero() {
+				timestamp = now
+			}
+			secret := tt.secret
+			if secret == "" {
+				secret = "secret"
+			}
+
+			body := url.Values{
+				"command":      {"/release"},
+				"text":         {tt.text},
+				"response_url": {slack.URL},
+			}.Encode()
+			ts := strconv.FormatInt(timestamp.Unix(), 10)
+
+			req, err := http.NewRequest(http.MethodPost, server.URL, strings.NewReader(body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			req.Header.Set("X-Slack-Request-Timestamp", ts)
+			req.Header.Set("X-Slack-Signature", sign(secret, ts, body))
+
+			res, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+
+			if res.StatusCode != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", res.StatusCode, tt.wantStatus)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var ack Message
+			if err := json.NewDecoder(res.Body).Decode(&ack); err != nil {
+				t.Fatal(err)
+			}
+			if ack.ResponseType != "ephemeral" || !strings.Contains(ack.Text, tt.wantAck) {
+				t.Errorf("ack = %+v, want an ephemeral message containing %q", ack, tt.wantAck)
+			}
+
+			if tt.wantAnswer == "" {
+				return
+			}
+			select {
+			case answer := <-answers:
+				if text := messageText(answer); answer.ResponseType != "in_channel" || !strings.Contains(text, tt.wantAnswer) {
+					t.Errorf("answer = %q, want an in_channel message containing %q", text, tt.wantAnswer)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("timed out waiting for the answer")
+			}
+		})
+	}
+}
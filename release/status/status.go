@@ -0,0 +1,282 @@
+package status
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/google/go-github/v39/github"
+	"github.com/rancher/ecm-distro-tools/release"
+	"github.com/rancher/ecm-distro-tools/release/junit"
+	"github.com/rancher/ecm-distro-tools/release/obs"
+	"github.com/rancher/ecm-distro-tools/repository"
+)
+
+// Target is a release to follow.
+type Target struct {
+	Owner string
+	Repo  string
+	Tag   string
+	// RPMs is the OBS package the rpms of the release are built from, nil
+	// if they aren't built in OBS.
+	RPMs *RPMs
+}
+
+// RPMs locates the OBS package the rpms of a release are built from.
+type RPMs struct {
+	Client  *obs.Client
+	Package obs.Package
+}
+
+// Status is the status of each step of a release.
+type Status struct {
+	Target
+	Tagged bool
+	// Release is missing, draft, prerelease or published.
+	Release string
+	// CI is the aggregated state of the workflow runs triggered by the tag:
+	// none, pending, success or failure.
+	CI     string
+	CIURL  string
+	Assets bool
+	// RPMs is the OBS build status of the rpms of the release, nil if they
+	// aren't built in OBS.
+	RPMs *obs.BuildStatus
+	Err  error
+}
+
+// Problems returns what's missing or failed for the release to be complete:
+// tagged, its CI passed, released, all its assets uploaded and, if built in
+// OBS, its rpms built for every repository and architecture.
+func (s Status) Problems() []string {
+	if !s.Tagged {
+		return []string{"not tagged"}
+	}
+
+	var problems []string
+	switch s.CI {
+	case "success":
+	case "none":
+		problems = append(problems, "no CI run")
+	default:
+		problems = append(problems, "CI "+s.CI+" "+s.CIURL)
+	}
+	if s.Release == "missing" || s.Release == "draft" {
+		problems = append(problems, "release "+s.Release)
+	}
+	if !s.Assets {
+		problems = append(problems, "assets missing")
+	}
+	if s.RPMs != nil {
+		problems = append(problems, s.RPMs.Problems()...)
+	}
+
+	return problems
+}
+
+// OK returns true if the release is complete and was checked without error.
+func (s Status) OK() bool {
+	return s.Err == nil && len(s.Problems()) == 0
+}
+
+// Failing returns the statuses of the releases that aren't OK.
+func Failing(statuses []Status) []Status {
+	var failing []Status
+	for _, s := range statuses {
+		if !s.OK() {
+			failing = append(failing, s)
+		}
+	}
+
+	return failing
+}
+
+// JUnit returns the given statuses as a JUnit test suite, a test case per
+// release.
+func JUnit(statuses []Status) junit.TestSuite {
+	suite := junit.TestSuite{Name: "release status"}
+	for _, s := range statuses {
+		name := s.Owner + "/" + s.Repo + "@" + s.Tag
+		switch {
+		case s.Err != nil:
+			suite.Error(name, s.Err.Error())
+		case !s.OK():
+			suite.Fail(name, strings.Join(s.Problems(), ", "))
+		default:
+			suite.Pass(name)
+		}
+	}
+
+	return suite
+}
+
+// Check returns the status of the given release. Errors are reported in the
+// status so a failing lookup doesn't hide the remaining releases.
+func Check(ctx context.Context, client *release.Client, t Target) Status {
+	s := Status{Target: t, Release: "missing", CI: "none"}
+
+	_, res, err := client.GitHub.Git.GetRef(ctx, t.Owner, t.Repo, "tags/"+t.Tag)
+	if err != nil {
+		if res == nil || res.StatusCode != http.StatusNotFound {
+			s.Err = repository.WrapGithubError(err, t.Owner, t.Repo, t.Tag)
+		}
+		return s
+	}
+	s.Tagged = true
+
+	runs, _, err := client.GitHub.Actions.ListRepositoryWorkflowRuns(ctx, t.Owner, t.Repo, &github.ListWorkflowRunsOptions{
+		Branch: t.Tag,
+	})
+	if err != nil {
+		s.Err = repository.WrapGithubError(err, t.Owner, t.Repo, t.Tag)
+		return s
+	}
+	s.CI, s.CIURL = ciState(runs.WorkflowRuns)
+
+	rel, err := client.Cache.GetReleaseByTag(ctx, client.GitHub, t.Owner, t.Repo, t.Tag)
+	if err != nil {
+		if !errors.Is(err, repository.ErrNotFound) {
+			s.Err = err
+		}
+		return s
+	}
+	switch {
+	case rel.GetDraft():
+		s.Release = "draft"
+	case rel.GetPrerelease():
+		s.Release = "prerelease"
+	default:
+		s.Release = "published"
+	}
+
+	verified, err := client.VerifyAssets(ctx, release.VerifyAssetsOptions{Owner: t.Owner, Repo: t.Repo, Tags: []string{t.Tag}})
+	if err != nil {
+		s.Err = err
+		return s
+	}
+	s.Assets = verified.Complete[t.Tag]
+
+	if t.RPMs != nil {
+		s.RPMs, err = t.RPMs.Client.BuildStatus(ctx, t.RPMs.Package, t.Tag)
+		if err != nil {
+			s.Err = err
+		}
+	}
+
+	return s
+}
+
+// CheckAll returns the status of all given releases concurrently.
+func CheckAll(ctx context.Context, client *release.Client, targets []Target) []Status {
+	statuses := make([]Status, len(targets))
+
+	var wg sync.WaitGroup
+	for i, t := range targets {
+		wg.Add(1)
+		go func(i int, t Target) {
+			defer wg.Done()
+			statuses[i] = Check(ctx, client, t)
+		}(i, t)
+	}
+	wg.Wait()
+
+	return statuses
+}
+
+// ciState aggregates the given workflow runs into a single state, and
+// returns the URL of the first failed or pending run.
+func ciState(runs []*github.WorkflowRun) (string, string) {
+	if len(runs) == 0 {
+		return "none", ""
+	}
+
+	sort.Slice(runs, func(i, j int) bool {
+		return runs[i].GetID() < runs[j].GetID()
+	})
+
+	var pendingURL string
+	for _, run := range runs {
+		if run.GetStatus() != "completed" {
+			if pendingURL == "" {
+				pendingURL = run.GetHTMLURL()
+			}
+			continue
+		}
+		if conclusion := run.GetConclusion(); conclusion != "success" && conclusion != "skipped" {
+			return "failure", run.GetHTMLURL()
+		}
+	}
+	if pendingURL != "" {
+		return "pending", pendingURL
+	}
+
+	return "success", ""
+}
+
+// Render writes the given statuses as a table. The RPMS column is only
+// shown if the rpms of one of the releases are built in OBS.
+func Render(w io.Writer, statuses []Status, updated time.Time) error {
+	fmt.Fprintln(w, "Release status, updated "+updated.Format(time.Kitchen))
+	fmt.Fprintln(w)
+
+	var rpms bool
+	for _, s := range statuses {
+		if s.RPMs != nil {
+			rpms = true
+			break
+		}
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	header := []string{"RELEASE", "TAG", "CI", "RELEASE", "ASSETS"}
+	if rpms {
+		header = append(header, "RPMS")
+	}
+	fmt.Fprintln(tw, strings.Join(append(header, "DETAILS"), "\t"))
+	for _, s := range statuses {
+		details := s.CIURL
+		if s.RPMs != nil {
+			if problems := s.RPMs.Problems(); len(problems) > 0 {
+				details = strings.TrimSpace(details + " " + strings.Join(problems, ", "))
+			}
+		}
+		if s.Err != nil {
+			details = "error: " + s.Err.Error()
+		}
+		row := []string{
+			s.Owner + "/" + s.Repo + "@" + s.Tag,
+			mark(s.Tagged),
+			s.CI,
+			s.Release,
+			mark(s.Assets),
+		}
+		if rpms {
+			row = append(row, rpmsMark(s.RPMs))
+		}
+		fmt.Fprintln(tw, strings.Join(append(row, details), "\t"))
+	}
+
+	return tw.Flush()
+}
+
+// rpmsMark returns - for releases whose rpms aren't built in OBS.
+func rpmsMark(b *obs.BuildStatus) string {
+	if b == nil {
+		return "-"
+	}
+	return mark(len(b.Problems()) == 0)
+}
+
+func mark(ok bool) string {
+	if ok {
+		return "✓"
+	}
+	return "✗"
+}
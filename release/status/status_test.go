@@ -0,0 +1,121 @@
+package status
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v39/github"
+	"github.com/rancher/ecm-distro-tools/release/junit"
+	"github.com/rancher/ecm-distro-tools/release/obs"
+)
+
+func TestCIState(t *testing.T) {
+	run := func(id int64, status, conclusion string) *github.WorkflowRun {
+		return &github.WorkflowRun{
+			ID:         github.Int64(id),
+			Status:     github.String(status),
+			Conclusion: github.String(conclusion),
+			HTMLURL:    github.String("https://github.com/rancher/rke2/actions/runs/" + string(rune('0'+id))),
+		}
+	}
+
+	tests := []struct {
+		name    string
+		runs    []*github.WorkflowRun
+		want    string
+		wantURL string
+	}{
+		{name: "no runs", want: "none"},
+		{name: "success", runs: []*github.WorkflowRun{run(1, "completed", "success"), run(2, "completed", "skipped")}, want: "success"},
+		{name: "pending", runs: []*github.WorkflowRun{run(1, "completed", "success"), run(2, "in_progress", "")}, want: "pending", wantURL: "https://github.com/rancher/rke2/actions/runs/2"},
+		{name: "failure", runs: []*github.WorkflowRun{run(2, "in_progress", ""), run(1, "completed", "failure")}, want: "failure", wantURL: "https://github.com/rancher/rke2/actions/runs/1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, url := ciState(tt.runs)
+			if got != tt.want || url != tt.wantURL {
+				t.Errorf("ciState() = %q, %q, want %q, %q", got, url, tt.want, tt.wantURL)
+			}
+		})
+	}
+}
+
+func TestRender(t *testing.T) {
+	statuses := []Status{
+		{Target: Target{Owner: "rancher", Repo: "rke2", Tag: "v1.29.2-rc1+rke2r1"}, Tagged: true, CI: "success", Release: "prerelease", Assets: true},
+		{Target: Target{Owner: "k3s-io", Repo: "k3s", Tag: "v1.29.2-rc1+k3s1"}, Release: "missing", CI: "none", Err: errors.New("rate limited")},
+	}
+
+	var b bytes.Buffer
+	if err := Render(&b, statuses, time.Date(2024, time.February, 15, 15, 4, 0, 0, time.UTC)); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "Release status, updated 3:04PM\n\n" +
+		"RELEASE                          TAG  CI       RELEASE     ASSETS  DETAILS\n" +
+		"rancher/rke2@v1.29.2-rc1+rke2r1  ✓    success  prerelease  ✓       \n" +
+		"k3s-io/k3s@v1.29.2-rc1+k3s1      ✗    none     missing     ✗       error: rate limited\n"
+	if b.String() != want {
+		t.Errorf("Render() =\n%s\nwant\n%s", b.String(), want)
+	}
+}
+
+func TestRenderRPMs(t *testing.T) {
+	statuses := []Status{
+		{
+			Target: Target{Owner: "rancher", Repo: "rke2", Tag: "v1.29.2+rke2r1"}, Tagged: true, CI: "success", Release: "published", Assets: true,
+			RPMs: &obs.BuildStatus{Version: "1.29.2~rke2r1", Expected: "1.29.2~rke2r1", Results: []obs.Result{
+				{Repository: "SLE_15", Arch: "x86_64", Code: "succeeded"},
+				{Repository: "SLE_15", Arch: "aarch64", Code: "failed"},
+			}},
+		},
+		{Target: Target{Owner: "k3s-io", Repo: "k3s", Tag: "v1.29.2+k3s1"}, Tagged: true, CI: "success", Release: "published", Assets: true},
+	}
+
+	if got := statuses[0].Problems(); len(got) != 1 || got[0] != "rpm SLE_15/aarch64 failed" {
+		t.Errorf("Problems() = %q, want the failed rpm build", got)
+	}
+
+	var b bytes.Buffer
+	if err := Render(&b, statuses, time.Date(2024, time.February, 15, 15, 4, 0, 0, time.UTC)); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "Release status, updated 3:04PM\n\n" +
+		"RELEASE                      TAG  CI       RELEASE    ASSETS  RPMS  DETAILS\n" +
+		"rancher/rke2@v1.29.2+rke2r1  ✓    success  published  ✓       ✗     rpm SLE_15/aarch64 failed\n" +
+		"k3s-io/k3s@v1.29.2+k3s1      ✓    success  published  ✓       -     \n"
+	if b.String() != want {
+		t.Errorf("Render() =\n%s\nwant\n%s", b.String(), want)
+	}
+}
+
+func TestJUnit(t *testing.T) {
+	statuses := []Status{
+		{Target: Target{Owner: "rancher", Repo: "rke2", Tag: "v1.29.2+rke2r1"}, Tagged: true, CI: "success", Release: "published", Assets: true},
+		{Target: Target{Owner: "rancher", Repo: "rke2", Tag: "v1.28.7+rke2r1"}, Tagged: true, CI: "failure", CIURL: "https://github.com/rancher/rke2/actions/runs/1", Release: "draft"},
+		{Target: Target{Owner: "k3s-io", Repo: "k3s", Tag: "v1.29.2+k3s1"}, Release: "missing", CI: "none", Err: errors.New("rate limited")},
+	}
+
+	if got := Failing(statuses); len(got) != 2 || got[0].Tag != "v1.28.7+rke2r1" || got[1].Tag != "v1.29.2+k3s1" {
+		t.Errorf("Failing() = %+v", got)
+	}
+
+	var b bytes.Buffer
+	if err := junit.Write(&b, "release", []junit.TestSuite{JUnit(statuses)}); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`<testsuites name="release" tests="3" failures="1" errors="1">`,
+		`<testcase name="rancher/rke2@v1.29.2+rke2r1" classname="release status"></testcase>`,
+		`<failure message="CI failure https://github.com/rancher/rke2/actions/runs/1, release draft, assets missing">`,
+		`<error message="rate limited">rate limited</error>`,
+	} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("JUnit report doesn't contain %q:\n%s", want, b.String())
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package status
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/term"
+)
+
+const (
+	enterAltScreen = "\x1b[?1049h\x1b[?25l"
+	exitAltScreen  = "\x1b[?25h\x1b[?1049l"
+	clearScreen    = "\x1b[H\x1b[2J"
+)
+
+// Dashboard renders the status of the given releases, refreshing it at the
+// given interval until q or ctrl-c is pressed, or the context is done. r
+// refreshes it immediately. If in isn't a terminal, the status is rendered
+// once.
+func Dashboard(ctx context.Context, in *os.File, out io.Writer, interval time.Duration, refresh func(context.Context) []Status) error {
+	fd := int(in.Fd())
+	if !term.IsTerminal(fd) {
+		return Render(out, refresh(ctx), time.Now())
+	}
+
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return err
+	}
+	defer term.Restore(fd, state)
+
+	io.WriteString(out, enterAltScreen)
+	defer io.WriteString(out, exitAltScreen)
+
+	keys := make(chan byte)
+	go func() {
+		b := make([]byte, 1)
+		for {
+			if _, err := in.Read(b); err != nil {
+				close(keys)
+				return
+			}
+			keys <- b[0]
+		}
+	}()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := draw(out, refresh(ctx)); err != nil {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		case key, ok := <-keys:
+			if !ok {
+				return nil
+			}
+			switch key {
+			case 'q', 3: // 3 is ctrl-c, which doesn't raise SIGINT in raw mode.
+				return nil
+			}
+		}
+	}
+}
+
+// draw clears the screen and renders the statuses. Lines must end with
+// \r\n since the terminal is in raw mode.
+func draw(out io.Writer, statuses []Status) error {
+	var b bytes.Buffer
+	b.WriteString(clearScreen)
+	if err := Render(&b, statuses, time.Now()); err != nil {
+		return err
+	}
+	b.WriteString("\npress r to refresh, q to quit\n")
+
+	_, err := io.WriteString(out, strings.ReplaceAll(b.String(), "\n", "\r\n"))
+	return err
+}
@@ -0,0 +1,215 @@
+package release
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// maxExampleLength is the length above which example values are truncated.
+const maxExampleLength = 60
+
+// notesFuncDocs describes the functions of notesFuncMap.
+var notesFuncDocs = map[string]string{
+	"majMin":               "major and minor of a semantic version, e.g. v1.29",
+	"trimPeriods":          "removes all periods, e.g. v1.29.2 to v1292",
+	"split":                "strings.Split",
+	"join":                 "strings.Join",
+	"capitalize":           "capitalizes the first letter",
+	"upgradeNotes":         "changelog entries labeled kind/upgrade-note",
+	"experimentalFeatures": "changelog entries labeled kind/experimental or kind/feature-gate",
+	"netChanges":           "changelog entries without the changes reverted within the changelog and their reverts",
+	"revertedChanges":      "changes reverted within the changelog, with their reverts and re-applies",
+	"date":                 "formats a date in the given locale, e.g. 2. März 2024 for de-DE",
+	"number":               "formats a number with the thousands separator of the given locale, e.g. 1.234 for de-DE",
+}
+
+// TemplateVariable is a value available to the release notes templates.
+type TemplateVariable struct {
+	// Name is the template expression of the variable, where [] stands
+	// for the elements of a list, e.g. .ChangeLogData.Content[].Title.
+	Name    string
+	Type    string
+	Example string
+}
+
+// TemplateFunction is a function available to the release notes templates.
+type TemplateFunction struct {
+	Name        string
+	Signature   string
+	Description string
+}
+
+// TemplateReference lists everything available to the release notes
+// templates of a repo.
+type TemplateReference struct {
+	Repo      string
+	Milestone string
+	Templates []string
+	Variables []TemplateVariable
+	Functions []TemplateFunction
+}
+
+// NewTemplateReference introspects the release notes templates and data of
+// the given repo. If a snapshot is given, its data is used as example
+// values.
+func NewTemplateReference(repo string, snapshot *ReleaseNotesSnapshot) (*TemplateReference, error) {
+	rd, err := newReleaseNote(repo)
+	if err != nil {
+		return nil, err
+	}
+
+	ref := TemplateReference{Repo: repo}
+
+	if snapshot != nil {
+		if snapshot.Repo != repo {
+			return nil, errors.New("snapshot is for " + snapshot.Repo + ", expected " + repo)
+		}
+		if err := json.Unmarshal(snapshot.Data, rd); err != nil {
+			return nil, err
+		}
+		ref.Milestone = snapshot.Milestone
+	}
+
+	tmpl := template.New("release-notes").Funcs(notesFuncMap)
+	tmpl = template.Must(tmpl.Parse(changelogTemplate))
+	tmpl = template.Must(tmpl.Parse(rd.Template()))
+	for _, t := range tmpl.Templates() {
+		if t.Name() != "release-notes" {
+			ref.Templates = append(ref.Templates, t.Name())
+		}
+	}
+	sort.Strings(ref.Templates)
+
+	ref.Variables = templateVariables("", reflect.ValueOf(rd).Elem())
+
+	for name, fn := range notesFuncMap {
+		ref.Functions = append(ref.Functions, TemplateFunction{
+			Name:        name,
+			Signature:   reflect.TypeOf(fn).String(),
+			Description: notesFuncDocs[name],
+		})
+	}
+	sort.Slice(ref.Functions, func(i, j int) bool {
+		return ref.Functions[i].Name < ref.Functions[j].Name
+	})
+
+	return &ref, nil
+}
+
+// templateVariables returns the exported fields of the given struct,
+// including the ones of embedded and nested structs and of the elements of
+// lists of structs, in declaration order.
+func templateVariables(prefix string, v reflect.Value) []TemplateVariable {
+	var vars []TemplateVariable
+
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		value := v.Field(i)
+
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			vars = append(vars, templateVariables(prefix, value)...)
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+
+		name := prefix + "." + field.Name
+		switch {
+		case field.Type.Kind() == reflect.Struct:
+			vars = append(vars, templateVariables(name, value)...)
+		case field.Type.Kind() == reflect.Slice && field.Type.Elem().Kind() == reflect.Struct:
+			vars = append(vars, TemplateVariable{
+				Name:    name,
+				Type:    field.Type.String(),
+				Example: strconv.Itoa(value.Len()) + " entries",
+			})
+			elem := reflect.New(field.Type.Elem()).Elem()
+			if value.Len() > 0 {
+				elem = value.Index(0)
+			}
+			vars = append(vars, templateVariables(name+"[]", elem)...)
+		default:
+			vars = append(vars, TemplateVariable{
+				Name:    name,
+				Type:    field.Type.String(),
+				Example: exampleValue(value),
+			})
+		}
+	}
+
+	return vars
+}
+
+// exampleValue formats the given value, truncating long ones.
+func exampleValue(v reflect.Value) string {
+	var s string
+	switch v.Kind() {
+	case reflect.String:
+		s = v.String()
+	case reflect.Bool:
+		s = strconv.FormatBool(v.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if v.Int() != 0 {
+			s = strconv.FormatInt(v.Int(), 10)
+		}
+	case reflect.Slice:
+		values := make([]string, 0, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			values = append(values, exampleValue(v.Index(i)))
+		}
+		s = strings.Join(values, ", ")
+	}
+
+	s = strings.Join(strings.Fields(s), " ")
+	if len(s) > maxExampleLength {
+		s = s[:maxExampleLength] + "..."
+	}
+
+	return s
+}
+
+// RenderTemplateReference renders the given reference as markdown.
+func RenderTemplateReference(ref *TemplateReference) (*bytes.Buffer, error) {
+	tmpl, err := template.New("reference").Funcs(template.FuncMap{
+		"escape": func(s string) string { return strings.ReplaceAll(s, "|", `\|`) },
+	}).Parse(templateReferenceTemplate)
+	if err != nil {
+		return nil, err
+	}
+
+	b := bytes.NewBuffer(nil)
+	if err := tmpl.Execute(b, ref); err != nil {
+		return nil, err
+	}
+
+	return b, nil
+}
+
+const templateReferenceTemplate = `# {{ .Repo }} release notes template reference
+{{ if .Milestone }}
+Example values are from {{ .Milestone }}.
+{{ end }}
+## Templates
+
+{{ range .Templates }}- ` + "`{{ . }}`" + `
+{{ end }}
+## Variables
+
+| Variable | Type | Example |
+| -------- | ---- | ------- |
+{{ range .Variables }}| ` + "`{{ .Name }}`" + ` | ` + "`{{ .Type }}`" + ` | {{ escape .Example }} |
+{{ end }}
+## Functions
+
+| Function | Signature | Description |
+| -------- | --------- | ----------- |
+{{ range .Functions }}| ` + "`{{ .Name }}`" + ` | ` + "`{{ .Signature }}`" + ` | {{ .Description }} |
+{{ end }}`
@@ -0,0 +1,230 @@
+package release
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Component is a packaged component and its version.
+type Component struct {
+	Name    string
+	Version string
+}
+
+// UnifiedComponent is a component packaged by k3s, rke2 or both, with the
+// version shipped by each product.
+type UnifiedComponent struct {
+	Name        string
+	K3sVersion  string
+	RKE2Version string
+}
+
+// Shared returns true if both products ship the same version.
+func (u UnifiedComponent) Shared() bool {
+	return u.K3sVersion != "" && u.K3sVersion == u.RKE2Version
+}
+
+func (rd *k3sReleaseNoteData) components() []Component {
+	return []Component{
+		{"Etcd", rd.EtcdVersion},
+		{"Containerd", rd.ContainerdVersion},
+		{"Runc", rd.RuncVersion},
+		{"Flannel", rd.FlannelVersion},
+		{"Metrics-server", rd.MetricsServerVersion},
+		{"CoreDNS", rd.CoreDNSVersion},
+		{"Helm-controller", rd.HelmControllerVersion},
+		{"Kine", rd.KineVersion},
+		{"SQLite", rd.SQLiteVersion},
+		{"Traefik", rd.TraefikVersion},
+		{"Local-path-provisioner", rd.LocalPathProvisionerVersion},
+	}
+}
+
+func (rd *rke2ReleaseNoteData) components() []Component {
+	return []Component{
+		{"Etcd", rd.EtcdVersion},
+		{"Containerd", rd.ContainerdVersion},
+		{"Runc", rd.RuncVersion},
+		{"Flannel", rd.FlannelVersion},
+		{"Metrics-server", rd.MetricsServerVersion},
+		{"CoreDNS", rd.CoreDNSVersion},
+		{"Helm-controller", rd.HelmControllerVersion},
+		{"Ingress-Nginx", rd.IngressNginxVersion},
+		{"Calico", rd.CalicoVersion},
+		{"Cilium", rd.CiliumVersion},
+		{"Multus", rd.MultusVersion},
+	}
+}
+
+// mergeComponents merges the components of both products, keeping the k3s
+// order followed by the components only packaged by rke2.
+func mergeComponents(k3s, rke2 []Component) []UnifiedComponent {
+	var merged []UnifiedComponent
+	index := make(map[string]int)
+
+	for _, c := range k3s {
+		index[c.Name] = len(merged)
+		merged = append(merged, UnifiedComponent{Name: c.Name, K3sVersion: normalizeVersion(c.Version)})
+	}
+	for _, c := range rke2 {
+		if i, ok := index[c.Name]; ok {
+			merged[i].RKE2Version = normalizeVersion(c.Version)
+			continue
+		}
+		merged = append(merged, UnifiedComponent{Name: c.Name, RKE2Version: normalizeVersion(c.Version)})
+	}
+
+	return merged
+}
+
+// normalizeVersion prefixes versions with a v, since some are read without
+// it, e.g. from image tags.
+func normalizeVersion(v string) string {
+	if v == "" || strings.HasPrefix(v, "v") {
+		return v
+	}
+
+	return "v" + v
+}
+
+type unifiedReleaseNoteData struct {
+	K8sVersion  string
+	MajorMinor  string
+	Locale      string
+	PublishDate *time.Time
+	EOLDate     *time.Time
+	K3s         *k3sReleaseNoteData
+	RKE2        *rke2ReleaseNoteData
+	Components  []UnifiedComponent
+}
+
+// SharedComponents returns the components shipped with the same version by
+// both products.
+func (u *unifiedReleaseNoteData) SharedComponents() []UnifiedComponent {
+	var shared []UnifiedComponent
+	for _, c := range u.Components {
+		if c.Shared() {
+			shared = append(shared, c)
+		}
+	}
+
+	return shared
+}
+
+// ProductComponents returns the components whose versions differ between
+// the products, or that only one of them ships.
+func (u *unifiedReleaseNoteData) ProductComponents() []UnifiedComponent {
+	var product []UnifiedComponent
+	for _, c := range u.Components {
+		if !c.Shared() && (c.K3sVersion != "" || c.RKE2Version != "") {
+			product = append(product, c)
+		}
+	}
+
+	return product
+}
+
+// GenUnifiedReleaseNotes generates a single announcement for the k3s and
+// rke2 releases of the same Kubernetes patch, listing both products'
+// versions, the component versions they share and each product's changes.
+func (c *Client) GenUnifiedReleaseNotes(ctx context.Context, k3sMilestone, k3sPrevMilestone, rke2Milestone, rke2PrevMilestone string, dates NotesDates) (*bytes.Buffer, error) {
+	k3s, err := c.GenReleaseNotesSnapshot(ctx, "k3s-io", k3sRepo, k3sMilestone, k3sPrevMilestone)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.addDates(ctx, "k3s-io", dates, k3s); err != nil {
+		return nil, err
+	}
+
+	rke2, err := c.GenReleaseNotesSnapshot(ctx, "rancher", rke2Repo, rke2Milestone, rke2PrevMilestone)
+	if err != nil {
+		return nil, err
+	}
+
+	return RenderUnifiedReleaseNotes(k3s, rke2)
+}
+
+// RenderUnifiedReleaseNotes renders the unified notes from the k3s and rke2
+// snapshots of the same Kubernetes patch.
+func RenderUnifiedReleaseNotes(k3sSnapshot, rke2Snapshot *ReleaseNotesSnapshot) (*bytes.Buffer, error) {
+	if k3sSnapshot.Repo != k3sRepo || rke2Snapshot.Repo != rke2Repo {
+		return nil, errors.New("expected a k3s and an rke2 snapshot, received " + k3sSnapshot.Repo + " and " + rke2Snapshot.Repo)
+	}
+
+	var k3s k3sReleaseNoteData
+	if err := json.Unmarshal(k3sSnapshot.Data, &k3s); err != nil {
+		return nil, err
+	}
+	var rke2 rke2ReleaseNoteData
+	if err := json.Unmarshal(rke2Snapshot.Data, &rke2); err != nil {
+		return nil, err
+	}
+
+	if k3s.K8sVersion != rke2.K8sVersion {
+		return nil, errors.New("k3s and rke2 releases are for different Kubernetes versions: " + k3s.K8sVersion + " and " + rke2.K8sVersion)
+	}
+
+	data := &unifiedReleaseNoteData{
+		K8sVersion: k3s.K8sVersion,
+		MajorMinor: k3s.MajorMinor,
+		// the products are released together, so the dates of k3s are
+		// used for both.
+		Locale:      k3s.Locale,
+		PublishDate: k3s.PublishDate,
+		EOLDate:     k3s.EOLDate,
+		K3s:         &k3s,
+		RKE2:        &rke2,
+		Components:  mergeComponents(k3s.components(), rke2.components()),
+	}
+
+	tmpl := template.New("unified").Funcs(notesFuncMap)
+	tmpl = template.Must(tmpl.Parse(changelogTemplate))
+	tmpl = template.Must(tmpl.Parse(unifiedReleaseNoteTemplate))
+
+	b := bytes.NewBuffer(nil)
+	if err := tmpl.ExecuteTemplate(b, "unified", data); err != nil {
+		return nil, err
+	}
+
+	return b, nil
+}
+
+const unifiedReleaseNoteTemplate = `
+{{- define "unified" -}}
+<!-- {{.K3s.Milestone}} {{.RKE2.Milestone}} -->
+
+This release updates Kubernetes to {{.K8sVersion}} in [K3s {{.K3s.Milestone}}](https://github.com/k3s-io/k3s/releases/tag/{{.K3s.Milestone}}) and [RKE2 {{.RKE2.Milestone}}](https://github.com/rancher/rke2/releases/tag/{{.RKE2.Milestone}}).{{ template "dates" . }}
+
+For more details on what's new, see the [Kubernetes release notes](https://github.com/kubernetes/kubernetes/blob/master/CHANGELOG/CHANGELOG-{{.MajorMinor}}.md#{{.K3s.ChangeLogVersion}}).
+{{- with .SharedComponents }}
+
+## Shared Component Versions
+| Component | Version |
+| --- | --- |
+{{- range . }}
+| {{.Name}} | {{.K3sVersion}} |
+{{- end }}
+{{- end }}
+{{- with .ProductComponents }}
+
+## Product Component Versions
+| Component | K3s | RKE2 |
+| --- | --- | --- |
+{{- range . }}
+| {{.Name}} | {{ or .K3sVersion "-" }} | {{ or .RKE2Version "-" }} |
+{{- end }}
+{{- end }}
+
+# K3s
+
+{{ template "changelog" .K3s }}
+
+# RKE2
+
+{{ template "changelog" .RKE2 }}
+{{ end }}`
@@ -0,0 +1,148 @@
+package upgrade
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"sort"
+	"strings"
+
+	"golang.org/x/mod/semver"
+)
+
+// The markers delimiting the validated upgrade paths section of the notes
+// and tracking issues, so it's replaced when injected again.
+const (
+	startMarker = "<!-- upgrade-paths:start -->"
+	endMarker   = "<!-- upgrade-paths:end -->"
+)
+
+// Result is the outcome of an upgrade test run of the upgrade test matrix.
+type Result struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	// Platform the upgrade was tested on, e.g. ubuntu-22.04.
+	Platform string `json:"platform"`
+	Passed   bool   `json:"passed"`
+	// URL of the run, e.g. its CI job.
+	URL string `json:"url,omitempty"`
+}
+
+// Report is the structured results of the upgrade test matrix.
+type Report struct {
+	Results []Result `json:"results"`
+}
+
+// Load reads the report at the given path.
+func Load(path string) (*Report, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var report Report
+	if err := json.Unmarshal(b, &report); err != nil {
+		return nil, errors.New("invalid upgrade results " + path + ": " + err.Error())
+	}
+	for _, r := range report.Results {
+		if r.From == "" || r.To == "" {
+			return nil, errors.New("invalid upgrade results " + path + ": from and to are required")
+		}
+	}
+
+	return &report, nil
+}
+
+// Path is an upgrade path to a release, with the platforms it was tested
+// on.
+type Path struct {
+	From   string
+	Passed []string
+	Failed []string
+}
+
+// Validated returns true if the upgrade passed on every platform it was
+// tested on.
+func (p Path) Validated() bool {
+	return len(p.Failed) == 0
+}
+
+// Paths returns the upgrade paths to the given version, newest first. The
+// platforms whose last run failed are listed as failed.
+func (r *Report) Paths(version string) []Path {
+	// the last run of each path and platform wins, e.g. after a retry.
+	outcomes := make(map[string]map[string]bool)
+	for _, result := range r.Results {
+		if result.To != version {
+			continue
+		}
+		if _, ok := outcomes[result.From]; !ok {
+			outcomes[result.From] = make(map[string]bool)
+		}
+		outcomes[result.From][result.Platform] = result.Passed
+	}
+
+	paths := make([]Path, 0, len(outcomes))
+	for from, platforms := range outcomes {
+		path := Path{From: from}
+		for platform, passed := range platforms {
+			if passed {
+				path.Passed = append(path.Passed, platform)
+			} else {
+				path.Failed = append(path.Failed, platform)
+			}
+		}
+		sort.Strings(path.Passed)
+		sort.Strings(path.Failed)
+		paths = append(paths, path)
+	}
+	sort.Slice(paths, func(i, j int) bool {
+		if c := semver.Compare(paths[i].From, paths[j].From); c != 0 {
+			return c > 0
+		}
+		return paths[i].From > paths[j].From
+	})
+
+	return paths
+}
+
+// Section returns the validated upgrade paths section of the notes, between
+// the markers it's replaced by when injected again.
+func Section(version string, paths []Path) string {
+	var b strings.Builder
+	b.WriteString(startMarker + "\n")
+	b.WriteString("## Validated upgrade paths\n\n")
+	b.WriteString("The following upgrades to " + version + " were tested:\n\n")
+	b.WriteString("| From | Platforms | Result |\n")
+	b.WriteString("|---|---|---|\n")
+	for _, p := range paths {
+		platforms := append(append([]string{}, p.Passed...), p.Failed...)
+		sort.Strings(platforms)
+
+		result := "Passed"
+		if !p.Validated() {
+			result = "Failed on " + strings.Join(p.Failed, ", ")
+		}
+		b.WriteString("| " + p.From + " | " + strings.Join(platforms, ", ") + " | " + result + " |\n")
+	}
+	b.WriteString(endMarker)
+
+	return b.String()
+}
+
+// Inject returns the given notes or issue body with the given section,
+// replacing the section already injected if any, or appended otherwise.
+func Inject(body, section string) string {
+	start := strings.Index(body, startMarker)
+	end := strings.Index(body, endMarker)
+	if start >= 0 && end > start {
+		return body[:start] + section + body[end+len(endMarker):]
+	}
+
+	body = strings.TrimRight(body, "\n")
+	if body == "" {
+		return section + "\n"
+	}
+
+	return body + "\n\n" + section + "\n"
+}
@@ -0,0 +1,55 @@
+package upgrade
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var report = Report{Results: []Result{
+	{From: "v1.28.7+rke2r1", To: "v1.29.2+rke2r1", Platform: "ubuntu-22.04", Passed: true},
+	{From: "v1.28.7+rke2r1", To: "v1.29.2+rke2r1", Platform: "sles-15", Passed: false},
+	{From: "v1.28.7+rke2r1", To: "v1.29.2+rke2r1", Platform: "sles-15", Passed: true},
+	{From: "v1.29.1+rke2r1", To: "v1.29.2+rke2r1", Platform: "ubuntu-22.04", Passed: true},
+	{From: "v1.29.1+rke2r1", To: "v1.29.2+rke2r1", Platform: "rhel-9", Passed: false},
+	{From: "v1.28.6+rke2r1", To: "v1.28.7+rke2r1", Platform: "ubuntu-22.04", Passed: true},
+}}
+
+func TestPaths(t *testing.T) {
+	want := []Path{
+		{From: "v1.29.1+rke2r1", Passed: []string{"ubuntu-22.04"}, Failed: []string{"rhel-9"}},
+		{From: "v1.28.7+rke2r1", Passed: []string{"sles-15", "ubuntu-22.04"}},
+	}
+	if got := report.Paths("v1.29.2+rke2r1"); !reflect.DeepEqual(got, want) {
+		t.Errorf("Paths() = %+v, want %+v", got, want)
+	}
+}
+
+func TestInject(t *testing.T) {
+	section := Section("v1.29.2+rke2r1", report.Paths("v1.29.2+rke2r1"))
+	for _, want := range []string{
+		"| v1.29.1+rke2r1 | rhel-9, ubuntu-22.04 | Failed on rhel-9 |",
+		"| v1.28.7+rke2r1 | sles-15, ubuntu-22.04 | Passed |",
+	} {
+		if !strings.Contains(section, want) {
+			t.Errorf("section doesn't contain %q:\n%s", want, section)
+		}
+	}
+
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{name: "empty", body: "", want: section + "\n"},
+		{name: "append", body: "# Notes\n", want: "# Notes\n\n" + section + "\n"},
+		{name: "replace", body: "# Notes\n\n" + startMarker + "\nold\n" + endMarker + "\n\n## Changes\n", want: "# Notes\n\n" + section + "\n\n## Changes\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Inject(tt.body, section); got != tt.want {
+				t.Errorf("Inject() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
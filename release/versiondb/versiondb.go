@@ -0,0 +1,233 @@
+package versiondb
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/mod/semver"
+)
+
+// Release is the component versions shipped by a release.
+type Release struct {
+	Repo    string `json:"repo"`
+	Version string `json:"version"`
+	// Components maps the component names, e.g. runc, to their version.
+	Components map[string]string `json:"components"`
+	RecordedAt time.Time         `json:"recorded_at"`
+}
+
+// DB is the component versions of past releases, stored as a JSON dataset
+// meant to be committed to a repo, so the history can be queried without
+// grepping old release notes.
+type DB struct {
+	Releases []Release `json:"releases"`
+}
+
+// Load reads the database at the given path. A missing file is an empty
+// database, so the first release recorded creates it.
+func Load(path string) (*DB, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return &DB{}, nil
+		}
+		return nil, err
+	}
+
+	var db DB
+	if err := json.Unmarshal(b, &db); err != nil {
+		return nil, errors.New("invalid component versions database " + path + ": " + err.Error())
+	}
+
+	return &db, nil
+}
+
+// Save writes the database to the given path, with the releases sorted by
+// repo and version so the changes are easy to review.
+func (db *DB) Save(path string) error {
+	sortReleases(db.Releases)
+
+	b, err := json.MarshalIndent(db, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, append(b, '\n'), 0644)
+}
+
+// compareVersions compares release versions, e.g. v1.29.2+rke2r1, ordering
+// the builds of the same version, which semver considers equal.
+func compareVersions(a, b string) int {
+	if c := semver.Compare(a, b); c != 0 {
+		return c
+	}
+
+	return strings.Compare(a, b)
+}
+
+// Put records the given release, replacing the one of the same repo and
+// version if already recorded.
+func (db *DB) Put(release Release) {
+	for i, r := range db.Releases {
+		if r.Repo == release.Repo && r.Version == release.Version {
+			db.Releases[i] = release
+			return
+		}
+	}
+
+	db.Releases = append(db.Releases, release)
+}
+
+// Shipped returns the releases that shipped the given version of the given
+// component, sorted by repo and version. A version without the v prefix
+// matches the versions with it and conversely, as the sources of the
+// versions disagree on it.
+func (db *DB) Shipped(component, version string) []Release {
+	var shipped []Release
+	for _, r := range db.Releases {
+		if v, ok := r.Components[component]; ok && sameVersion(v, version) {
+			shipped = append(shipped, r)
+		}
+	}
+	sortReleases(shipped)
+
+	return shipped
+}
+
+func sameVersion(a, b string) bool {
+	return strings.TrimPrefix(a, "v") == strings.TrimPrefix(b, "v")
+}
+
+// Entry is the version of a component shipped by a release.
+type Entry struct {
+	Release string
+	Version string
+}
+
+// History returns the versions of the given component shipped by each
+// release of the given repo, sorted by release.
+func (db *DB) History(repo, component string) []Entry {
+	var releases []Release
+	for _, r := range db.Releases {
+		if _, ok := r.Components[component]; ok && r.Repo == repo {
+			releases = append(releases, r)
+		}
+	}
+	sortReleases(releases)
+
+	history := make([]Entry, len(releases))
+	for i, r := range releases {
+		history[i] = Entry{Release: r.Version, Version: r.Components[component]}
+	}
+
+	return history
+}
+
+func sortReleases(releases []Release) {
+	sort.SliceStable(releases, func(i, j int) bool {
+		if releases[i].Repo != releases[j].Repo {
+			return releases[i].Repo < releases[j].Repo
+		}
+		return compareVersions(releases[i].Version, releases[j].Version) < 0
+	})
+}
+
+// Range is the versions of a component affected by a vulnerability, from
+// Introduced, included, to Fixed, excluded. An empty bound is open.
+type Range struct {
+	Introduced string
+	Fixed      string
+}
+
+// Validate checks the bounds are semantic versions.
+func (r Range) Validate() error {
+	for _, bound := range []string{r.Introduced, r.Fixed} {
+		if bound != "" && !semver.IsValid(baseVersion(bound)) {
+			return errors.New("invalid version " + bound + ", expected a semantic version, e.g. v1.1.12")
+		}
+	}
+	if r.Introduced != "" && r.Fixed != "" && semver.Compare(baseVersion(r.Introduced), baseVersion(r.Fixed)) >= 0 {
+		return errors.New("the fixed version " + r.Fixed + " must be greater than the introduced version " + r.Introduced)
+	}
+
+	return nil
+}
+
+// Affects returns true if the given version is in the range, ignoring the
+// suffixes added by forks, e.g. v1.7.11-k3s2 is v1.7.11. Versions that
+// aren't semantic versions are never affected.
+func (r Range) Affects(version string) bool {
+	v := baseVersion(version)
+	if !semver.IsValid(v) {
+		return false
+	}
+	if r.Introduced != "" && semver.Compare(v, baseVersion(r.Introduced)) < 0 {
+		return false
+	}
+
+	return !r.fixes(v)
+}
+
+func (r Range) fixes(v string) bool {
+	return r.Fixed != "" && semver.IsValid(v) && semver.Compare(v, baseVersion(r.Fixed)) >= 0
+}
+
+// baseVersion returns the given version with a v prefix and without the
+// suffixes added by forks.
+func baseVersion(v string) string {
+	if !strings.HasPrefix(v, "v") {
+		v = "v" + v
+	}
+	v, _, _ = strings.Cut(v, "-")
+	v, _, _ = strings.Cut(v, "+")
+
+	return v
+}
+
+// Impact is a release shipping an affected version of a component.
+type Impact struct {
+	Repo    string
+	Release string
+	// Version of the component shipped by the release.
+	Version string
+	// FixedIn is the first later release of the same repo and release line
+	// shipping a fixed version, empty if none is recorded yet.
+	FixedIn string
+}
+
+// Impacted returns the recorded releases shipping a version of the given
+// component in the given range, sorted by repo and release, along with the
+// patch releases containing the fix.
+func (db *DB) Impacted(component string, r Range) []Impact {
+	releases := make([]Release, 0, len(db.Releases))
+	for _, rel := range db.Releases {
+		if _, ok := rel.Components[component]; ok {
+			releases = append(releases, rel)
+		}
+	}
+	sortReleases(releases)
+
+	var impacts []Impact
+	for i, rel := range releases {
+		version := rel.Components[component]
+		if !r.Affects(version) {
+			continue
+		}
+
+		impact := Impact{Repo: rel.Repo, Release: rel.Version, Version: version}
+		for _, later := range releases[i+1:] {
+			if later.Repo == rel.Repo && semver.MajorMinor(later.Version) == semver.MajorMinor(rel.Version) && r.fixes(baseVersion(later.Components[component])) {
+				impact.FixedIn = later.Version
+				break
+			}
+		}
+		impacts = append(impacts, impact)
+	}
+
+	return impacts
+}
@@ -0,0 +1,110 @@
+package versiondb
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDB(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "component-versions.json")
+
+	db, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(db.Releases) != 0 {
+		t.Fatalf("Load() of a missing file = %+v, want an empty database", db)
+	}
+
+	recorded := time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC)
+	db.Put(Release{Repo: "rke2", Version: "v1.29.2+rke2r1", Components: map[string]string{"runc": "v1.1.12", "etcd": "v3.5.9-k3s1"}, RecordedAt: recorded})
+	db.Put(Release{Repo: "k3s", Version: "v1.29.2+k3s1", Components: map[string]string{"runc": "v1.1.12", "traefik": "2.10.5"}, RecordedAt: recorded})
+	db.Put(Release{Repo: "rke2", Version: "v1.28.7+rke2r1", Components: map[string]string{"runc": "v1.1.11"}, RecordedAt: recorded})
+	db.Put(Release{Repo: "rke2", Version: "v1.28.10+rke2r1", Components: map[string]string{"runc": "v1.1.12"}, RecordedAt: recorded})
+	// recording a release again replaces it.
+	db.Put(Release{Repo: "rke2", Version: "v1.28.7+rke2r1", Components: map[string]string{"runc": "v1.1.11", "etcd": "v3.5.9-k3s1"}, RecordedAt: recorded})
+
+	if err := db.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	db, err = Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var versions []string
+	for _, r := range db.Releases {
+		versions = append(versions, r.Version)
+	}
+	if want := []string{"v1.29.2+k3s1", "v1.28.7+rke2r1", "v1.28.10+rke2r1", "v1.29.2+rke2r1"}; !reflect.DeepEqual(versions, want) {
+		t.Errorf("saved releases = %v, want %v", versions, want)
+	}
+
+	versions = nil
+	for _, r := range db.Shipped("runc", "1.1.12") {
+		versions = append(versions, r.Version)
+	}
+	if want := []string{"v1.29.2+k3s1", "v1.28.10+rke2r1", "v1.29.2+rke2r1"}; !reflect.DeepEqual(versions, want) {
+		t.Errorf("Shipped() = %v, want %v", versions, want)
+	}
+	if got := db.Shipped("traefik", "v2.10.5"); len(got) != 1 || got[0].Repo != "k3s" {
+		t.Errorf("Shipped() = %v, want the k3s release", got)
+	}
+
+	want := []Entry{
+		{Release: "v1.28.7+rke2r1", Version: "v3.5.9-k3s1"},
+		{Release: "v1.29.2+rke2r1", Version: "v3.5.9-k3s1"},
+	}
+	if got := db.History("rke2", "etcd"); !reflect.DeepEqual(got, want) {
+		t.Errorf("History() = %v, want %v", got, want)
+	}
+}
+
+func TestImpacted(t *testing.T) {
+	db := &DB{Releases: []Release{
+		{Repo: "rke2", Version: "v1.29.3+rke2r1", Components: map[string]string{"containerd": "v1.7.11-k3s2"}},
+		{Repo: "rke2", Version: "v1.29.2+rke2r1", Components: map[string]string{"containerd": "v1.7.11-k3s2"}},
+		{Repo: "rke2", Version: "v1.29.1+rke2r1", Components: map[string]string{"containerd": "v1.7.10-k3s1"}},
+		{Repo: "rke2", Version: "v1.28.7+rke2r1", Components: map[string]string{"containerd": "v1.7.10-k3s1"}},
+		{Repo: "rke2", Version: "v1.27.11+rke2r1", Components: map[string]string{"containerd": "v1.6.28-k3s1"}},
+		{Repo: "k3s", Version: "v1.29.1+k3s1", Components: map[string]string{"containerd": "v1.7.10-k3s1"}},
+		{Repo: "k3s", Version: "v1.29.1+k3s2", Components: map[string]string{"containerd": "v1.7.11-k3s2"}},
+		{Repo: "k3s", Version: "v1.29.0+k3s1", Components: map[string]string{"runc": "v1.1.10"}},
+	}}
+
+	got := db.Impacted("containerd", Range{Introduced: "v1.7.0", Fixed: "1.7.11"})
+	want := []Impact{
+		{Repo: "k3s", Release: "v1.29.1+k3s1", Version: "v1.7.10-k3s1", FixedIn: "v1.29.1+k3s2"},
+		{Repo: "rke2", Release: "v1.28.7+rke2r1", Version: "v1.7.10-k3s1"},
+		{Repo: "rke2", Release: "v1.29.1+rke2r1", Version: "v1.7.10-k3s1", FixedIn: "v1.29.2+rke2r1"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Impacted() = %+v, want %+v", got, want)
+	}
+
+	if got := db.Impacted("containerd", Range{Fixed: "v1.6.0"}); len(got) != 0 {
+		t.Errorf("Impacted() = %+v, want none", got)
+	}
+}
+
+func TestRangeValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		r       Range
+		wantErr bool
+	}{
+		{name: "bounded", r: Range{Introduced: "v1.1.0", Fixed: "1.1.12"}},
+		{name: "fixed only", r: Range{Fixed: "v1.1.12"}},
+		{name: "invalid version", r: Range{Fixed: "latest"}, wantErr: true},
+		{name: "inverted", r: Range{Introduced: "v1.1.12", Fixed: "v1.1.0"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.r.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
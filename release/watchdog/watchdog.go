@@ -0,0 +1,215 @@
+package watchdog
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Default is the watchdog of the waits of the release flows, e.g. for a
+// build to finish or a release to propagate. It's configured by the release
+// command flags and disabled otherwise.
+var Default = New()
+
+// Watchdog aborts the tracked waits still pending after its deadline, or
+// that didn't make progress for longer than its inactivity timeout, so they
+// don't hang CI jobs indefinitely.
+type Watchdog struct {
+	mu         sync.Mutex
+	deadline   time.Time
+	timer      *time.Timer
+	inactivity time.Duration
+	waits      map[*Wait]struct{}
+}
+
+// New creates a watchdog without deadline nor inactivity timeout.
+func New() *Watchdog {
+	return &Watchdog{waits: make(map[*Wait]struct{})}
+}
+
+// SetDeadline sets when all the pending waits are aborted, the zero time
+// disables it.
+func (w *Watchdog) SetDeadline(deadline time.Time) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.deadline = deadline
+	if w.timer != nil {
+		w.timer.Stop()
+		w.timer = nil
+	}
+	if !deadline.IsZero() {
+		w.timer = time.AfterFunc(time.Until(deadline), w.expire)
+	}
+}
+
+// SetInactivity sets how long a wait can go without progress before it's
+// aborted, 0 disables it. It applies to the waits tracked afterwards.
+func (w *Watchdog) SetInactivity(inactivity time.Duration) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.inactivity = inactivity
+}
+
+// Wait is a wait tracked by a watchdog.
+type Wait struct {
+	w          *Watchdog
+	cancel     context.CancelCauseFunc
+	inactivity *time.Timer
+	state      State
+}
+
+// State describes a pending wait.
+type State struct {
+	// Name describes what is waited for, e.g. pull request #12 to merge.
+	Name         string
+	Status       string
+	Started      time.Time
+	LastProgress time.Time
+}
+
+// String describes the wait, how long it has been pending and its last
+// status.
+func (s State) String() string {
+	description := s.Name + " (pending for " + time.Since(s.Started).Round(time.Second).String()
+	if s.Status != "" {
+		description += ", last status: " + s.Status
+	}
+
+	return description + ")"
+}
+
+// Track registers a wait and returns a context canceled when the watchdog
+// aborts it, whose cause is an *Error. Done must be called once the wait is
+// over.
+func (w *Watchdog) Track(ctx context.Context, name string) (context.Context, *Wait) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	now := time.Now()
+	wait := &Wait{
+		w:      w,
+		cancel: cancel,
+		state:  State{Name: name, Started: now, LastProgress: now},
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if !w.deadline.IsZero() && !now.Before(w.deadline) {
+		cancel(&Error{Deadline: w.deadline, Pending: []State{wait.state}})
+		return ctx, wait
+	}
+
+	w.waits[wait] = struct{}{}
+	if w.inactivity > 0 {
+		inactivity := w.inactivity
+		wait.inactivity = time.AfterFunc(inactivity, func() {
+			w.mu.Lock()
+			if _, ok := w.waits[wait]; !ok {
+				w.mu.Unlock()
+				return
+			}
+			delete(w.waits, wait)
+			state := wait.state
+			w.mu.Unlock()
+
+			cancel(&Error{Inactivity: inactivity, Pending: []State{state}})
+		})
+	}
+
+	return ctx, wait
+}
+
+// Progress records the current status of the wait, e.g. the state of a
+// build. A status different than the previous one restarts the inactivity
+// timeout.
+func (wt *Wait) Progress(status string) {
+	wt.w.mu.Lock()
+	defer wt.w.mu.Unlock()
+
+	if status == wt.state.Status {
+		return
+	}
+	wt.state.Status = status
+	wt.state.LastProgress = time.Now()
+
+	if _, ok := wt.w.waits[wt]; ok && wt.inactivity != nil {
+		wt.inactivity.Reset(wt.w.inactivity)
+	}
+}
+
+// Done unregisters the wait and releases its context.
+func (wt *Wait) Done() {
+	wt.w.mu.Lock()
+	delete(wt.w.waits, wt)
+	if wt.inactivity != nil {
+		wt.inactivity.Stop()
+	}
+	wt.w.mu.Unlock()
+
+	wt.cancel(nil)
+}
+
+// Pending returns the state of the pending waits, oldest first.
+func (w *Watchdog) Pending() []State {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.pending()
+}
+
+func (w *Watchdog) pending() []State {
+	states := make([]State, 0, len(w.waits))
+	for wait := range w.waits {
+		states = append(states, wait.state)
+	}
+	sort.Slice(states, func(i, j int) bool {
+		return states[i].Started.Before(states[j].Started)
+	})
+
+	return states
+}
+
+// expire aborts all the pending waits once the deadline is reached.
+func (w *Watchdog) expire() {
+	w.mu.Lock()
+	err := &Error{Deadline: w.deadline, Pending: w.pending()}
+	waits := w.waits
+	w.waits = make(map[*Wait]struct{})
+	w.mu.Unlock()
+
+	for wait := range waits {
+		if wait.inactivity != nil {
+			wait.inactivity.Stop()
+		}
+		wait.cancel(err)
+	}
+}
+
+// Error is the cause of the cancellation of the waits aborted by a
+// watchdog, listing what was still pending.
+type Error struct {
+	// Deadline is set if the waits were aborted by the deadline, and
+	// Inactivity if they were aborted for not making progress.
+	Deadline   time.Time
+	Inactivity time.Duration
+	Pending    []State
+}
+
+func (e *Error) Error() string {
+	var b strings.Builder
+	if e.Inactivity > 0 {
+		b.WriteString("watchdog aborted the wait after " + e.Inactivity.String() + " without progress")
+	} else {
+		b.WriteString("watchdog deadline " + e.Deadline.Format(time.RFC3339) + " exceeded")
+	}
+
+	b.WriteString(", still pending:")
+	for _, state := range e.Pending {
+		b.WriteString("\n\t" + state.String())
+	}
+
+	return b.String()
+}
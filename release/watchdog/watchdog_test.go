@@ -0,0 +1,87 @@
+package watchdog
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWatchdog(t *testing.T) {
+	t.Run("deadline aborts all pending waits", func(t *testing.T) {
+		w := New()
+		w.SetDeadline(time.Now().Add(50 * time.Millisecond))
+
+		ctx1, build := w.Track(context.Background(), "build")
+		defer build.Done()
+		build.Progress("running")
+		ctx2, image := w.Track(context.Background(), "image")
+		defer image.Done()
+		done, finished := w.Track(context.Background(), "finished")
+		finished.Done()
+
+		for _, ctx := range []context.Context{ctx1, ctx2} {
+			<-ctx.Done()
+			var werr *Error
+			if !errors.As(context.Cause(ctx), &werr) {
+				t.Fatalf("cause = %v, expected a watchdog error", context.Cause(ctx))
+			}
+			if len(werr.Pending) != 2 || werr.Pending[0].Name != "build" || werr.Pending[1].Name != "image" {
+				t.Errorf("pending = %v, expected build and image", werr.Pending)
+			}
+			if msg := werr.Error(); !strings.Contains(msg, "deadline") || !strings.Contains(msg, "last status: running") {
+				t.Errorf("unexpected error message: %s", msg)
+			}
+		}
+		if !errors.Is(context.Cause(done), context.Canceled) {
+			t.Errorf("finished wait cause = %v, expected context.Canceled", context.Cause(done))
+		}
+		if pending := w.Pending(); len(pending) != 0 {
+			t.Errorf("pending after deadline = %v", pending)
+		}
+
+		ctx, late := w.Track(context.Background(), "late")
+		defer late.Done()
+		if ctx.Err() == nil {
+			t.Error("wait tracked after the deadline was not aborted")
+		}
+	})
+
+	t.Run("progress restarts the inactivity timeout", func(t *testing.T) {
+		w := New()
+		w.SetInactivity(100 * time.Millisecond)
+
+		ctx, wait := w.Track(context.Background(), "build")
+		defer wait.Done()
+		for i := 0; i < 4; i++ {
+			time.Sleep(40 * time.Millisecond)
+			wait.Progress(strings.Repeat("x", i+1))
+		}
+		if ctx.Err() != nil {
+			t.Fatalf("wait making progress was aborted: %v", context.Cause(ctx))
+		}
+
+		select {
+		case <-ctx.Done():
+		case <-time.After(time.Second):
+			t.Fatal("inactive wait was not aborted")
+		}
+		var werr *Error
+		if !errors.As(context.Cause(ctx), &werr) || werr.Inactivity != 100*time.Millisecond {
+			t.Errorf("cause = %v, expected an inactivity error", context.Cause(ctx))
+		}
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		w := New()
+		ctx, wait := w.Track(context.Background(), "build")
+		if len(w.Pending()) != 1 {
+			t.Errorf("pending = %v, expected build", w.Pending())
+		}
+		wait.Done()
+		if !errors.Is(context.Cause(ctx), context.Canceled) {
+			t.Errorf("cause = %v, expected context.Canceled", context.Cause(ctx))
+		}
+	})
+}
@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/google/go-github/v39/github"
+	"golang.org/x/sync/singleflight"
+)
+
+// defaultReleaseCacheTTL is how long release lookups are kept in the
+// shared release cache.
+const defaultReleaseCacheTTL = 5 * time.Minute
+
+// sharedReleaseCache is used by GetReleaseByTag so that every lookup in the
+// same invocation shares the same cache.
+var sharedReleaseCache = NewReleaseCache(defaultReleaseCacheTTL)
+
+type releaseCacheEntry struct {
+	release *github.RepositoryRelease
+	err     error
+	expires time.Time
+}
+
+// ReleaseCache is a goroutine safe, in-memory cache of GitHub release
+// lookups by tag. Entries, including not found results, expire after the
+// configured TTL, and concurrent lookups of the same tag result in a single
+// API call.
+type ReleaseCache struct {
+	ttl     time.Duration
+	mu      sync.Mutex
+	entries map[string]releaseCacheEntry
+	group   singleflight.Group
+	now     func() time.Time
+}
+
+// NewReleaseCache creates a new release cache whose entries expire after
+// the given TTL.
+func NewReleaseCache(ttl time.Duration) *ReleaseCache {
+	return &ReleaseCache{
+		ttl:     ttl,
+		entries: make(map[string]releaseCacheEntry),
+		now:     time.Now,
+	}
+}
+
+// GetReleaseByTag returns the release for the given tag, only calling the
+// GitHub API if there's no valid cached entry for it. Errors other than
+// ErrNotFound aren't cached.
+func (c *ReleaseCache) GetReleaseByTag(ctx context.Context, client *github.Client, owner, repo, tag string) (*github.RepositoryRelease, error) {
+	key := owner + "/" + repo + "@" + tag
+
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	c.mu.Unlock()
+	if ok && c.now().Before(entry.expires) {
+		return entry.release, entry.err
+	}
+
+	v, err, _ := c.group.Do(key, func() (interface{}, error) {
+		release, _, err := client.Repositories.GetReleaseByTag(ctx, owner, repo, tag)
+		err = WrapGithubError(err, owner, repo, tag)
+		if err == nil || errors.Is(err, ErrNotFound) {
+			c.mu.Lock()
+			c.entries[key] = releaseCacheEntry{
+				release: release,
+				err:     err,
+				expires: c.now().Add(c.ttl),
+			}
+			c.mu.Unlock()
+		}
+		return release, err
+	})
+
+	release, _ := v.(*github.RepositoryRelease)
+	return release, err
+}
+
+// Invalidate removes the cached entry for the given tag, if any, so that
+// the next lookup hits the GitHub API.
+func (c *ReleaseCache) Invalidate(owner, repo, tag string) {
+	c.mu.Lock()
+	delete(c.entries, owner+"/"+repo+"@"+tag)
+	c.mu.Unlock()
+}
+
+// GetReleaseByTag returns the release for the given tag through a cache
+// shared by every caller in the current process.
+func GetReleaseByTag(ctx context.Context, client *github.Client, owner, repo, tag string) (*github.RepositoryRelease, error) {
+	return sharedReleaseCache.GetReleaseByTag(ctx, client, owner, repo, tag)
+}
+
+// InvalidateRelease removes the given tag from the shared release cache.
+// It must be called after modifying a release, e.g. its assets.
+func InvalidateRelease(owner, repo, tag string) {
+	sharedReleaseCache.Invalidate(owner, repo, tag)
+}
+
+// SharedReleaseCache returns the release cache used by GetReleaseByTag.
+func SharedReleaseCache() *ReleaseCache {
+	return sharedReleaseCache
+}
@@ -0,0 +1,410 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/google/go-github/v39/github"
+)
+
+// changeLogQuery fetches a page of the commits between two refs, along with
+// the pull request each of them was merged in, including its body, labels,
+// author and the issues it closes.
+const changeLogQuery = `query($owner: String!, $repo: String!, $base: String!, $head: String!, $cursor: String) {
+  repository(owner: $owner, name: $repo) {
+    ref(qualifiedName: $base) {
+      compare(headRef: $head) {
+        commits(first: 100, after: $cursor) {
+          pageInfo { hasNextPage endCursor }
+          nodes {
+            associatedPullRequests(first: 2) {
+              nodes { ...changeLogPullRequest }
+            }
+          }
+        }
+      }
+    }
+  }
+}
+` + changeLogPullRequestFragment
+
+// changeLogPullRequestFragment contains the fields of a pull request a
+// changelog entry is made of.
+const changeLogPullRequestFragment = `fragment changeLogPullRequest on PullRequest {
+  number
+  title
+  body
+  url
+  author { login }
+  labels(first: 50) { nodes { name } }
+  closingIssuesReferences(first: 10) {
+    nodes { number repository { nameWithOwner } }
+  }
+}`
+
+// revertTitleRegex matches the titles of the pull requests opened with the
+// GitHub revert button, e.g. Revert "Fix foo", and revertBodyRegex the
+// reference to the reverted pull request in their body, e.g. Reverts
+// rancher/rke2#1234.
+var (
+	revertTitleRegex = regexp.MustCompile(`^Revert "(.*)"$`)
+	revertBodyRegex  = regexp.MustCompile(`(?m)^\s*Reverts ([\w.-]+/[\w.-]+)?#(\d+)`)
+)
+
+// pullRequestsBatchSize is the number of pull requests fetched per query
+// when building a changelog from a list of pull requests.
+const pullRequestsBatchSize = 50
+
+type changeLogLabel struct {
+	Name string `json:"name"`
+}
+
+type changeLogIssue struct {
+	Number     int `json:"number"`
+	Repository struct {
+		NameWithOwner string `json:"nameWithOwner"`
+	} `json:"repository"`
+}
+
+type changeLogPullRequest struct {
+	Number int    `json:"number"`
+	Title  string `json:"title"`
+	Body   string `json:"body"`
+	URL    string `json:"url"`
+	Author struct {
+		Login string `json:"login"`
+	} `json:"author"`
+	Labels struct {
+		Nodes []changeLogLabel `json:"nodes"`
+	} `json:"labels"`
+	ClosingIssuesReferences struct {
+		Nodes []changeLogIssue `json:"nodes"`
+	} `json:"closingIssuesReferences"`
+}
+
+type changeLogCommit struct {
+	AssociatedPullRequests struct {
+		Nodes []changeLogPullRequest `json:"nodes"`
+	} `json:"associatedPullRequests"`
+}
+
+// changeLogNumbersQuery fetches a page of the commits between two refs,
+// along with the number of the pull request each of them was merged in,
+// for the pull requests to be fetched in parallel batches.
+const changeLogNumbersQuery = `query($owner: String!, $repo: String!, $base: String!, $head: String!, $cursor: String) {
+  repository(owner: $owner, name: $repo) {
+    ref(qualifiedName: $base) {
+      compare(headRef: $head) {
+        commits(first: 100, after: $cursor) {
+          pageInfo { hasNextPage endCursor }
+          nodes {
+            associatedPullRequests(first: 2) {
+              nodes { number }
+            }
+          }
+        }
+      }
+    }
+  }
+}`
+
+// RetrieveChangeLogContents gets the relevant changes
+// for the given release, formats, and returns them.
+// The commits between the milestones and their pull requests are retrieved
+// with a single paginated GraphQL query, instead of a call per commit.
+func RetrieveChangeLogContents(ctx context.Context, client *github.Client, owner, repo, prevMilestone, milestone string) ([]ChangeLog, error) {
+	commits, err := compareCommits[changeLogCommit](ctx, client, changeLogQuery, owner, repo, prevMilestone, milestone)
+	if err != nil {
+		return nil, err
+	}
+
+	return changeLogFromCommits(owner, repo, commits), nil
+}
+
+// compareCommits returns the nodes of the commits between the given refs
+// fetched with the given paginated compare query.
+func compareCommits[T any](ctx context.Context, client *github.Client, query, owner, repo, base, head string) ([]T, error) {
+	var commits []T
+
+	vars := map[string]interface{}{
+		"owner": owner,
+		"repo":  repo,
+		"base":  base,
+		"head":  head,
+	}
+	for {
+		var data struct {
+			Repository struct {
+				Ref *struct {
+					Compare *struct {
+						Commits struct {
+							PageInfo struct {
+								HasNextPage bool   `json:"hasNextPage"`
+								EndCursor   string `json:"endCursor"`
+							} `json:"pageInfo"`
+							Nodes []T `json:"nodes"`
+						} `json:"commits"`
+					} `json:"compare"`
+				} `json:"ref"`
+			} `json:"repository"`
+		}
+		if err := GraphQL(ctx, client, query, vars, &data); err != nil {
+			return nil, WrapGithubError(err, owner, repo, base+"..."+head)
+		}
+
+		ref := data.Repository.Ref
+		if ref == nil || ref.Compare == nil {
+			return nil, &GithubError{Owner: owner, Repo: repo, Ref: base + "..." + head, Err: ErrNotFound, kind: ErrNotFound}
+		}
+
+		commits = append(commits, ref.Compare.Commits.Nodes...)
+		if !ref.Compare.Commits.PageInfo.HasNextPage {
+			break
+		}
+		vars["cursor"] = ref.Compare.Commits.PageInfo.EndCursor
+	}
+
+	return commits, nil
+}
+
+// RetrievePullRequestsChangeLog returns the changelog entries of the given
+// pull requests, in the given order, for when the changes of a release
+// can't be found by comparing its milestones, e.g. renamed or split ones.
+// The pull requests are fetched in batches, in parallel and resumed per
+// the given options.
+func RetrievePullRequestsChangeLog(ctx context.Context, client *github.Client, owner, repo string, numbers []int, opts ChangeLogOptions) ([]ChangeLog, error) {
+	cp, err := loadCheckpoint(opts.Checkpoint, pullRequestsKey(owner, repo, numbers))
+	if err != nil {
+		return nil, err
+	}
+	if cp.Commits == nil {
+		for _, number := range numbers {
+			cp.Commits = append(cp.Commits, []int{number})
+		}
+	}
+
+	return retrieveChangeLog(ctx, client, owner, repo, cp, opts)
+}
+
+// fetchPullRequests fetches the changelog fields of the given pull requests
+// with a single query.
+func fetchPullRequests(ctx context.Context, client *github.Client, owner, repo string, numbers []int) (map[int]changeLogPullRequest, error) {
+	var query strings.Builder
+	query.WriteString("query($owner: String!, $repo: String!) {\n  repository(owner: $owner, name: $repo) {\n")
+	for i, number := range numbers {
+		query.WriteString("    pr" + strconv.Itoa(i) + ": pullRequest(number: " + strconv.Itoa(number) + ") { ...changeLogPullRequest }\n")
+	}
+	query.WriteString("  }\n}\n" + changeLogPullRequestFragment)
+
+	var data struct {
+		Repository map[string]*changeLogPullRequest `json:"repository"`
+	}
+	vars := map[string]interface{}{"owner": owner, "repo": repo}
+	if err := GraphQL(ctx, client, query.String(), vars, &data); err != nil {
+		return nil, WrapGithubError(err, owner, repo, "")
+	}
+
+	prs := make(map[int]changeLogPullRequest, len(numbers))
+	for i, number := range numbers {
+		pr := data.Repository["pr"+strconv.Itoa(i)]
+		if pr == nil {
+			return nil, &GithubError{Owner: owner, Repo: repo, Ref: "#" + strconv.Itoa(number), Err: ErrNotFound, kind: ErrNotFound}
+		}
+		prs[number] = *pr
+	}
+
+	return prs, nil
+}
+
+// ParsePullRequestList reads the pull request numbers of a JSON array of
+// numbers or of objects with a number field, or of a CSV file whose first
+// column holds them, e.g. 1234, #1234 or a pull request URL. A CSV header
+// is skipped.
+func ParsePullRequestList(r io.Reader) ([]int, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	b = bytes.TrimSpace(b)
+
+	if bytes.HasPrefix(b, []byte("[")) {
+		var entries []json.RawMessage
+		if err := json.Unmarshal(b, &entries); err != nil {
+			return nil, err
+		}
+		numbers := make([]int, 0, len(entries))
+		for _, entry := range entries {
+			var pr struct {
+				Number int `json:"number"`
+			}
+			if err := json.Unmarshal(entry, &pr.Number); err != nil {
+				if err := json.Unmarshal(entry, &pr); err != nil || pr.Number == 0 {
+					return nil, errors.New("invalid pull request " + string(entry) + ", expected a number or an object with a number")
+				}
+			}
+			numbers = append(numbers, pr.Number)
+		}
+		return numbers, nil
+	}
+
+	reader := csv.NewReader(bytes.NewReader(b))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	var numbers []int
+	for i, record := range records {
+		field := strings.TrimSpace(record[0])
+		if field == "" {
+			continue
+		}
+		if idx := strings.LastIndex(field, "/pull/"); idx != -1 {
+			field = field[idx+len("/pull/"):]
+		}
+		number, err := strconv.Atoi(strings.TrimPrefix(field, "#"))
+		if err != nil {
+			if i == 0 {
+				continue
+			}
+			return nil, errors.New("line " + strconv.Itoa(i+1) + ": invalid pull request " + record[0])
+		}
+		numbers = append(numbers, number)
+	}
+
+	return numbers, nil
+}
+
+// changeLogFromCommits returns a changelog entry for each pull request the
+// given commits were merged in. Commits associated to more than one pull
+// request are ambiguous and skipped.
+func changeLogFromCommits(owner, repo string, commits []changeLogCommit) []ChangeLog {
+	var found []ChangeLog
+	addedPRs := make(map[int]bool)
+	// revertedTitles contains the titles of the pull requests reverted by
+	// the reverts whose body doesn't reference them, by index in found.
+	revertedTitles := make(map[int]string)
+
+	for _, commit := range commits {
+		prs := commit.AssociatedPullRequests.Nodes
+		if len(prs) != 1 {
+			continue
+		}
+		pr := prs[0]
+		if addedPRs[pr.Number] {
+			continue
+		}
+
+		var upgradeNote, experimental bool
+		for _, label := range pr.Labels.Nodes {
+			switch label.Name {
+			case upgradeNoteLabel:
+				upgradeNote = true
+			case experimentalLabel, featureGateLabel:
+				experimental = true
+			}
+		}
+
+		var linkedIssues []string
+		for _, issue := range pr.ClosingIssuesReferences.Nodes {
+			linkedIssues = append(linkedIssues, issueReference(owner, repo, issue.Repository.NameWithOwner, issue.Number))
+		}
+
+		title := strings.TrimSpace(pr.Title)
+		var reverts int
+		if m := revertTitleRegex.FindStringSubmatch(title); m != nil {
+			reverted := stripBackportTag(m[1])
+			title = `Revert "` + reverted + `"`
+			reverts = revertedNumber(owner, repo, pr.Body)
+			if reverts == 0 {
+				revertedTitles[len(found)] = reverted
+			}
+		} else {
+			title = stripBackportTag(title)
+		}
+
+		found = append(found, ChangeLog{
+			Title:        title,
+			Note:         releaseNote(pr.Body),
+			Number:       pr.Number,
+			URL:          pr.URL,
+			Author:       pr.Author.Login,
+			UpgradeNote:  upgradeNote,
+			Experimental: experimental,
+			LinkedIssues: linkedIssues,
+			Reverts:      reverts,
+		})
+		addedPRs[pr.Number] = true
+	}
+
+	// reverts without a reference are matched to the pull request with the
+	// title they revert.
+	for i, reverted := range revertedTitles {
+		for _, change := range found {
+			if change.Title == reverted && change.Number != found[i].Number {
+				found[i].Reverts = change.Number
+				break
+			}
+		}
+	}
+
+	return found
+}
+
+// revertedNumber returns the number of the pull request of the given
+// repository referenced by the body of a revert, 0 if there's none.
+func revertedNumber(owner, repo, body string) int {
+	m := revertBodyRegex.FindStringSubmatch(body)
+	if m == nil || (m[1] != "" && !strings.EqualFold(m[1], owner+"/"+repo)) {
+		return 0
+	}
+	number, _ := strconv.Atoi(m[2])
+
+	return number
+}
+
+// releaseNote returns the contents of the release-note block of a pull
+// request body, or an empty string if it's missing, empty or NONE.
+func releaseNote(body string) string {
+	if !strings.Contains(body, releaseNoteSection) || strings.Contains(body, emptyReleaseNote) || strings.Contains(body, noneReleaseNote) {
+		return ""
+	}
+
+	var note string
+	var inNote bool
+	for _, line := range strings.Split(body, "\n") {
+		if strings.Contains(line, releaseNoteSection) {
+			inNote = true
+			continue
+		}
+		if strings.Contains(line, "```") {
+			inNote = false
+		}
+		if inNote && line != "" {
+			line = strings.TrimPrefix(line, "* ")
+			note += line
+		}
+	}
+	note = strings.TrimSpace(note)
+
+	return strings.ReplaceAll(note, "\r", "\n")
+}
+
+// issueReference returns the shortest reference to an issue from the given
+// repository.
+func issueReference(owner, repo, nameWithOwner string, number int) string {
+	if strings.EqualFold(nameWithOwner, owner+"/"+repo) {
+		return "#" + strconv.Itoa(number)
+	}
+
+	return nameWithOwner + "#" + strconv.Itoa(number)
+}
@@ -0,0 +1,228 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/google/go-github/v39/github"
+	"golang.org/x/sync/errgroup"
+)
+
+// ChangeLogOptions configures the retrieval of large changelogs, e.g. of
+// the rancher/rancher milestones with hundreds of pull requests.
+type ChangeLogOptions struct {
+	// Workers is the number of batches of pull requests fetched in
+	// parallel, 1 if not set.
+	Workers int
+	// Progress, if set, is called after each batch with the number of pull
+	// requests fetched so far, including the resumed ones, and their total.
+	Progress func(done, total int)
+	// Checkpoint, if set, is the path of the partial changelog saved when
+	// the retrieval is interrupted, e.g. by the rate limit, and resumed
+	// from by the next retrieval of the same changes. It's removed once the
+	// changelog is retrieved.
+	Checkpoint string
+}
+
+func (o ChangeLogOptions) workers() int {
+	if o.Workers < 1 {
+		return 1
+	}
+
+	return o.Workers
+}
+
+// changeLogCheckpoint is the partial changelog of an interrupted retrieval.
+type changeLogCheckpoint struct {
+	// Key identifies the changes, e.g. the repository and milestones.
+	Key string `json:"key"`
+	// Commits are the numbers of the pull requests each commit was merged
+	// in.
+	Commits      [][]int                      `json:"commits"`
+	PullRequests map[int]changeLogPullRequest `json:"pull_requests"`
+}
+
+// changeLogCommitNumbers is a commit with the numbers of the pull requests
+// it was merged in.
+type changeLogCommitNumbers struct {
+	AssociatedPullRequests struct {
+		Nodes []struct {
+			Number int `json:"number"`
+		} `json:"nodes"`
+	} `json:"associatedPullRequests"`
+}
+
+func compareKey(owner, repo, base, head string) string {
+	return owner + "/" + repo + " " + base + "..." + head
+}
+
+func pullRequestsKey(owner, repo string, numbers []int) string {
+	refs := make([]string, len(numbers))
+	for i, number := range numbers {
+		refs[i] = "#" + strconv.Itoa(number)
+	}
+
+	return owner + "/" + repo + " " + strings.Join(refs, ",")
+}
+
+// loadCheckpoint returns the checkpoint at the given path if it's of the
+// changes with the given key, or an empty one.
+func loadCheckpoint(path, key string) (*changeLogCheckpoint, error) {
+	empty := &changeLogCheckpoint{Key: key, PullRequests: make(map[int]changeLogPullRequest)}
+	if path == "" {
+		return empty, nil
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return empty, nil
+		}
+		return nil, err
+	}
+
+	var cp changeLogCheckpoint
+	if err := json.Unmarshal(b, &cp); err != nil {
+		return nil, errors.New("invalid changelog checkpoint " + path + ": " + err.Error())
+	}
+	if cp.Key != key {
+		return nil, errors.New("changelog checkpoint " + path + " is of " + cp.Key + ", not " + key + ", remove it to start over")
+	}
+	if cp.PullRequests == nil {
+		cp.PullRequests = make(map[int]changeLogPullRequest)
+	}
+
+	return &cp, nil
+}
+
+func (cp *changeLogCheckpoint) save(path string) error {
+	b, err := json.Marshal(cp)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, b, 0644)
+}
+
+// pending returns the pull requests not fetched yet, in the order of the
+// commits. The commits merged in several pull requests are ambiguous and
+// skipped by the changelog, so are their pull requests.
+func (cp *changeLogCheckpoint) pending() []int {
+	var numbers []int
+	seen := make(map[int]bool)
+	for _, commit := range cp.Commits {
+		if len(commit) != 1 || seen[commit[0]] {
+			continue
+		}
+		seen[commit[0]] = true
+		if _, ok := cp.PullRequests[commit[0]]; !ok {
+			numbers = append(numbers, commit[0])
+		}
+	}
+
+	return numbers
+}
+
+// changeLogCommits returns the commits with their fetched pull request.
+func (cp *changeLogCheckpoint) changeLogCommits() []changeLogCommit {
+	commits := make([]changeLogCommit, len(cp.Commits))
+	for i, numbers := range cp.Commits {
+		for _, number := range numbers {
+			pr, ok := cp.PullRequests[number]
+			if !ok {
+				pr = changeLogPullRequest{Number: number}
+			}
+			commits[i].AssociatedPullRequests.Nodes = append(commits[i].AssociatedPullRequests.Nodes, pr)
+		}
+	}
+
+	return commits
+}
+
+// RetrieveChangeLog returns the changelog entries of the pull requests
+// merged between the given milestones, like RetrieveChangeLogContents. The
+// commits are listed first, then their pull requests are fetched in
+// batches by the workers of the given options, and the retrieval is
+// resumed from the checkpoint of an interrupted one.
+func RetrieveChangeLog(ctx context.Context, client *github.Client, owner, repo, prevMilestone, milestone string, opts ChangeLogOptions) ([]ChangeLog, error) {
+	if opts.workers() == 1 && opts.Progress == nil && opts.Checkpoint == "" {
+		return RetrieveChangeLogContents(ctx, client, owner, repo, prevMilestone, milestone)
+	}
+
+	cp, err := loadCheckpoint(opts.Checkpoint, compareKey(owner, repo, prevMilestone, milestone))
+	if err != nil {
+		return nil, err
+	}
+	if cp.Commits == nil {
+		nodes, err := compareCommits[changeLogCommitNumbers](ctx, client, changeLogNumbersQuery, owner, repo, prevMilestone, milestone)
+		if err != nil {
+			return nil, err
+		}
+
+		cp.Commits = make([][]int, len(nodes))
+		for i, node := range nodes {
+			cp.Commits[i] = []int{}
+			for _, pr := range node.AssociatedPullRequests.Nodes {
+				cp.Commits[i] = append(cp.Commits[i], pr.Number)
+			}
+		}
+	}
+
+	return retrieveChangeLog(ctx, client, owner, repo, cp, opts)
+}
+
+// retrieveChangeLog fetches the pending pull requests of the given
+// checkpoint and returns the changelog, saving the checkpoint if
+// interrupted.
+func retrieveChangeLog(ctx context.Context, client *github.Client, owner, repo string, cp *changeLogCheckpoint, opts ChangeLogOptions) ([]ChangeLog, error) {
+	pending := cp.pending()
+	total := len(cp.PullRequests) + len(pending)
+
+	var mu sync.Mutex
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(opts.workers())
+	for start := 0; start < len(pending); start += pullRequestsBatchSize {
+		batch := pending[start:min(start+pullRequestsBatchSize, len(pending))]
+		g.Go(func() error {
+			prs, err := fetchPullRequests(gctx, client, owner, repo, batch)
+			if err != nil {
+				return err
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			for number, pr := range prs {
+				cp.PullRequests[number] = pr
+			}
+			if opts.Progress != nil {
+				opts.Progress(len(cp.PullRequests), total)
+			}
+
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		if opts.Checkpoint == "" {
+			return nil, err
+		}
+		if saveErr := cp.save(opts.Checkpoint); saveErr != nil {
+			return nil, fmt.Errorf("%w, failed to save the changelog checkpoint: %v", err, saveErr)
+		}
+		return nil, fmt.Errorf("%w, %d of %d pull requests saved to %s, run again to resume", err, len(cp.PullRequests), total, opts.Checkpoint)
+	}
+
+	if opts.Checkpoint != "" {
+		if err := os.Remove(opts.Checkpoint); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+	}
+
+	return changeLogFromCommits(owner, repo, cp.changeLogCommits()), nil
+}
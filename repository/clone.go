@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"strconv"
+	"strings"
+)
+
+// CloneOptions configures how the clone based flows clone repositories.
+// The zero value clones the full history and checks out the whole tree.
+type CloneOptions struct {
+	// Depth limits the history to the given number of commits, 0 clones
+	// the full history.
+	Depth int
+	// Filter is a partial clone filter, e.g. blob:none, so only the blobs
+	// of the checked out commits are downloaded and the rest are fetched
+	// on demand.
+	Filter string
+	// Sparse limits the checkout to the given directories.
+	Sparse []string
+}
+
+// cloneDefaults contains the clone options of the repositories whose full
+// clones take minutes. Blobless clones keep the whole history, so they are
+// safe for every flow, including rebasing, cherry-picking and pushing.
+var cloneDefaults = map[string]CloneOptions{
+	"rancher":    {Filter: "blob:none"},
+	"kubernetes": {Filter: "blob:none"},
+	"k3s":        {Filter: "blob:none"},
+	"rke2":       {Filter: "blob:none"},
+}
+
+// DefaultCloneOptions returns the clone options used for the given
+// repository unless overridden.
+func DefaultCloneOptions(repo string) CloneOptions {
+	return cloneDefaults[repo]
+}
+
+// Args returns the git clone arguments for the options.
+func (o CloneOptions) Args() string {
+	var args []string
+	if o.Depth > 0 {
+		args = append(args, "--depth", strconv.Itoa(o.Depth))
+	}
+	if o.Filter != "" {
+		args = append(args, "--filter="+o.Filter)
+	}
+	if len(o.Sparse) > 0 {
+		args = append(args, "--sparse")
+	}
+
+	return strings.Join(args, " ")
+}
+
+// SparseCheckout returns the git command restricting the checkout to the
+// sparse directories, or an empty string if there are none.
+func (o CloneOptions) SparseCheckout() string {
+	if len(o.Sparse) == 0 {
+		return ""
+	}
+
+	dirs := make([]string, len(o.Sparse))
+	for i, dir := range o.Sparse {
+		dirs[i] = `"` + dir + `"`
+	}
+
+	return "git sparse-checkout set " + strings.Join(dirs, " ")
+}
@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/google/go-github/v39/github"
+)
+
+var (
+	// ErrNotFound is returned when the requested GitHub resource doesn't exist.
+	ErrNotFound = errors.New("not found")
+	// ErrRateLimited is returned when the GitHub API rate limit has been exceeded.
+	ErrRateLimited = errors.New("rate limited")
+	// ErrPermission is returned when the credentials in use don't have access
+	// to the requested GitHub resource.
+	ErrPermission = errors.New("permission denied")
+)
+
+// GithubError wraps an error returned by the GitHub API with the repository
+// and ref it relates to. It matches ErrNotFound, ErrRateLimited or
+// ErrPermission with errors.Is, and the original error with errors.As.
+type GithubError struct {
+	Owner string
+	Repo  string
+	Ref   string
+	Err   error
+	kind  error
+}
+
+func (e *GithubError) Error() string {
+	msg := e.Owner + "/" + e.Repo
+	if e.Ref != "" {
+		msg += "@" + e.Ref
+	}
+
+	switch e.kind {
+	case ErrNotFound:
+		msg += ": not found"
+	case ErrRateLimited:
+		msg += ": GitHub API rate limit exceeded, try again later"
+	case ErrPermission:
+		msg += ": permission denied, verify your GitHub token has access to the repository"
+	}
+
+	return msg + ": " + e.Err.Error()
+}
+
+func (e *GithubError) Unwrap() []error {
+	if e.kind == nil {
+		return []error{e.Err}
+	}
+
+	return []error{e.kind, e.Err}
+}
+
+// WrapGithubError classifies the given GitHub API error and wraps it with
+// the owner, repo and ref context. It returns nil if err is nil.
+func WrapGithubError(err error, owner, repo, ref string) error {
+	if err == nil {
+		return nil
+	}
+
+	ghErr := &GithubError{
+		Owner: owner,
+		Repo:  repo,
+		Ref:   ref,
+		Err:   err,
+	}
+
+	var rateLimitErr *github.RateLimitError
+	var abuseRateLimitErr *github.AbuseRateLimitError
+	var errResponse *github.ErrorResponse
+	var graphQLErr *GraphQLError
+
+	switch {
+	case errors.As(err, &rateLimitErr), errors.As(err, &abuseRateLimitErr):
+		ghErr.kind = ErrRateLimited
+	case errors.As(err, &graphQLErr):
+		switch {
+		case graphQLErr.hasType("RATE_LIMITED"):
+			ghErr.kind = ErrRateLimited
+		case graphQLErr.hasType("NOT_FOUND"):
+			ghErr.kind = ErrNotFound
+		case graphQLErr.hasType("FORBIDDEN"):
+			ghErr.kind = ErrPermission
+		}
+	case errors.As(err, &errResponse) && errResponse.Response != nil:
+		switch errResponse.Response.StatusCode {
+		case http.StatusNotFound:
+			ghErr.kind = ErrNotFound
+		case http.StatusUnauthorized, http.StatusForbidden:
+			ghErr.kind = ErrPermission
+		case http.StatusTooManyRequests:
+			ghErr.kind = ErrRateLimited
+		}
+	}
+
+	return ghErr
+}
@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/google/go-github/v39/github"
+)
+
+type graphQLRequest struct {
+	Query     string                 `json:"query"`
+	Variables map[string]interface{} `json:"variables,omitempty"`
+}
+
+type graphQLResponse struct {
+	Data json.RawMessage `json:"data"`
+	GraphQLError
+}
+
+// GraphQLError contains the errors returned by the GitHub GraphQL API along
+// with the data, their type being e.g. RATE_LIMITED, NOT_FOUND or FORBIDDEN.
+// WrapGithubError classifies it like the REST API errors.
+type GraphQLError struct {
+	Errors []struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+func (e *GraphQLError) Error() string {
+	msgs := make([]string, 0, len(e.Errors))
+	for _, err := range e.Errors {
+		msgs = append(msgs, err.Message)
+	}
+
+	return "graphql: " + strings.Join(msgs, ", ")
+}
+
+// hasType returns true if one of the errors is of the given type.
+func (e *GraphQLError) hasType(t string) bool {
+	for _, err := range e.Errors {
+		if err.Type == t {
+			return true
+		}
+	}
+
+	return false
+}
+
+// GraphQL runs the given query or mutation against the GitHub GraphQL API
+// using the given client, and decodes the returned data into out.
+func GraphQL(ctx context.Context, client *github.Client, query string, variables map[string]interface{}, out interface{}) error {
+	req, err := client.NewRequest(http.MethodPost, "graphql", graphQLRequest{Query: query, Variables: variables})
+	if err != nil {
+		return err
+	}
+
+	var resp graphQLResponse
+	if _, err := client.Do(ctx, req, &resp); err != nil {
+		return err
+	}
+	if len(resp.Errors) > 0 {
+		return &resp.GraphQLError
+	}
+	if out == nil {
+		return nil
+	}
+
+	return json.Unmarshal(resp.Data, out)
+}
@@ -0,0 +1,151 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/google/go-github/v39/github"
+)
+
+// ComponentOwner contains the GitHub users and the team slugs, relative to
+// the organization of the repository, that own a component.
+type ComponentOwner struct {
+	Users []string `json:"users,omitempty"`
+	Teams []string `json:"teams,omitempty"`
+}
+
+// Owners maps component names, e.g. containerd or ingress-nginx, to their
+// owners.
+type Owners map[string]ComponentOwner
+
+var (
+	ownersMu     sync.Mutex
+	sharedOwners Owners
+	reviewClient *github.Client
+)
+
+// LoadOwners reads the ownership map from the given JSON file. There is no
+// builtin map: the owning users and teams are confirmed by each team in the
+// file configured with owners_file.
+func LoadOwners(file string) (Owners, error) {
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	var owners Owners
+	if err := json.Unmarshal(b, &owners); err != nil {
+		return nil, err
+	}
+
+	return owners, nil
+}
+
+// SetOwners replaces the ownership map used by RequestOwnerReviews.
+func SetOwners(owners Owners) {
+	ownersMu.Lock()
+	defer ownersMu.Unlock()
+
+	sharedOwners = owners
+}
+
+// SetReviewClient sets the client RequestOwnerReviews requests the reviews
+// with, instead of the one given to it, e.g. the one of the identity of the
+// review operation. A nil client restores the default.
+func SetReviewClient(client *github.Client) {
+	ownersMu.Lock()
+	defer ownersMu.Unlock()
+
+	reviewClient = client
+}
+
+// ConfiguredOwners returns the ownership map set with SetOwners, nil if
+// none is configured.
+func ConfiguredOwners() Owners {
+	ownersMu.Lock()
+	defer ownersMu.Unlock()
+
+	return sharedOwners
+}
+
+// Reviewers returns the deduplicated and sorted owners of the given
+// components. Unknown components are ignored.
+func (o Owners) Reviewers(components ...string) ComponentOwner {
+	users := make(map[string]struct{})
+	teams := make(map[string]struct{})
+	for _, component := range components {
+		owner := o[component]
+		for _, u := range owner.Users {
+			users[u] = struct{}{}
+		}
+		for _, t := range owner.Teams {
+			teams[t] = struct{}{}
+		}
+	}
+
+	return ComponentOwner{
+		Users: sortedKeys(users),
+		Teams: sortedKeys(teams),
+	}
+}
+
+// Mentions returns the GitHub mentions of the owners, where teams belong to
+// the given organization.
+func (c ComponentOwner) Mentions(org string) []string {
+	mentions := make([]string, 0, len(c.Users)+len(c.Teams))
+	for _, u := range c.Users {
+		mentions = append(mentions, "@"+u)
+	}
+	for _, t := range c.Teams {
+		mentions = append(mentions, "@"+org+"/"+t)
+	}
+
+	return mentions
+}
+
+// RequestOwnerReviews requests reviews on the given pull request from the
+// owners of the given components, and notifies them with a comment. If no
+// ownership map is configured or none of the components has owners,
+// nothing is done and the review is left to the release captain. The
+// client set with SetReviewClient, if any, is used instead of the given one.
+func RequestOwnerReviews(ctx context.Context, client *github.Client, owner, repo string, number int, components ...string) error {
+	reviewers := ConfiguredOwners().Reviewers(components...)
+	if len(reviewers.Users) == 0 && len(reviewers.Teams) == 0 {
+		return nil
+	}
+
+	ownersMu.Lock()
+	if reviewClient != nil {
+		client = reviewClient
+	}
+	ownersMu.Unlock()
+
+	if _, _, err := client.PullRequests.RequestReviewers(ctx, owner, repo, number, github.ReviewersRequest{
+		Reviewers:     reviewers.Users,
+		TeamReviewers: reviewers.Teams,
+	}); err != nil {
+		return WrapGithubError(err, owner, repo, "#"+strconv.Itoa(number))
+	}
+
+	body := strings.Join(reviewers.Mentions(owner), " ") + " this pull request bumps " + strings.Join(components, ", ") + ", please review."
+	if _, _, err := client.Issues.CreateComment(ctx, owner, repo, number, &github.IssueComment{Body: github.String(body)}); err != nil {
+		return WrapGithubError(err, owner, repo, "#"+strconv.Itoa(number))
+	}
+
+	return nil
+}
+
+func sortedKeys(m map[string]struct{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
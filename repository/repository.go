@@ -16,6 +16,7 @@ import (
 	"github.com/go-git/go-git/v5/plumbing/transport/http"
 	"github.com/google/go-github/v39/github"
 	"github.com/rancher/ecm-distro-tools/exec"
+	ecmHTTP "github.com/rancher/ecm-distro-tools/http"
 	"github.com/rancher/ecm-distro-tools/types"
 	"github.com/sirupsen/logrus"
 	"golang.org/x/oauth2"
@@ -27,6 +28,9 @@ const (
 	releaseNoteSection = "```release-note"
 	emptyReleaseNote   = "```release-note\r\n\r\n```"
 	noneReleaseNote    = "```release-note\r\nNONE\r\n```"
+	upgradeNoteLabel   = "kind/upgrade-note"
+	experimentalLabel  = "kind/experimental"
+	featureGateLabel   = "kind/feature-gate"
 	httpTimeout        = time.Second * 10
 	ghContentURL       = "https://raw.githubusercontent.com"
 )
@@ -58,14 +62,16 @@ func (t *TokenSource) Token() (*oauth2.Token, error) {
 // NewGithub creates a value of type github.Client pointer
 // with the given context and Github token.
 func NewGithub(ctx context.Context, token string) *github.Client {
+	// use the shared transport so GitHub requests are instrumented
+	httpClient := ecmHTTP.NewClient(0)
 	if token == "" {
-		return github.NewClient(nil)
+		return github.NewClient(&httpClient)
 	}
 
 	ts := TokenSource{
 		AccessToken: token,
 	}
-	oauthClient := oauth2.NewClient(ctx, &ts)
+	oauthClient := oauth2.NewClient(context.WithValue(ctx, oauth2.HTTPClient, &httpClient), &ts)
 	oauthClient.Timeout = httpTimeout
 
 	return github.NewClient(oauthClient)
@@ -73,12 +79,15 @@ func NewGithub(ctx context.Context, token string) *github.Client {
 
 // CreateReleaseOpts
 type CreateReleaseOpts struct {
-	Owner        string `json:"owner"`
-	Repo         string `json:"repo"`
-	Name         string `json:"name"`
-	Tag          string `json:"tag"`
-	Prerelease   bool   `json:"pre_release"`
-	Branch       string `json:"branch"`
+	Owner      string `json:"owner"`
+	Repo       string `json:"repo"`
+	Name       string `json:"name"`
+	Tag        string `json:"tag"`
+	Prerelease bool   `json:"pre_release"`
+	Branch     string `json:"branch"`
+	// Commit is the commit the tag is created from, e.g. the verified head
+	// of Branch, instead of its current head.
+	Commit       string `json:"commit,omitempty"`
 	ReleaseNotes string `json:"release_notes"`
 	Draft        bool   `json:"draft"`
 }
@@ -87,7 +96,7 @@ type CreateReleaseOpts struct {
 func ListReleases(ctx context.Context, client *github.Client, owner, repo string) ([]*github.RepositoryRelease, error) {
 	releases, _, err := client.Repositories.ListReleases(ctx, owner, repo, &github.ListOptions{})
 	if err != nil {
-		return nil, err
+		return nil, WrapGithubError(err, owner, repo, "")
 	}
 
 	return releases, nil
@@ -97,12 +106,35 @@ func ListReleases(ctx context.Context, client *github.Client, owner, repo string
 func ListTags(ctx context.Context, client *github.Client, owner, repo string) ([]*github.RepositoryTag, error) {
 	tags, _, err := client.Repositories.ListTags(ctx, owner, repo, &github.ListOptions{})
 	if err != nil {
-		return nil, err
+		return nil, WrapGithubError(err, owner, repo, "")
 	}
 
 	return tags, nil
 }
 
+// ListAllTags retrieves every tag of the given repository, following
+// pagination until there are no pages left.
+func ListAllTags(ctx context.Context, client *github.Client, owner, repo string) ([]*github.RepositoryTag, error) {
+	var allTags []*github.RepositoryTag
+	opt := &github.ListOptions{PerPage: 100}
+
+	for {
+		tags, resp, err := client.Repositories.ListTags(ctx, owner, repo, opt)
+		if err != nil {
+			return nil, WrapGithubError(err, owner, repo, "")
+		}
+
+		allTags = append(allTags, tags...)
+
+		if resp.NextPage == 0 {
+			break
+		}
+		opt.Page = resp.NextPage
+	}
+
+	return allTags, nil
+}
+
 func LatestTag(ctx context.Context, client *github.Client, owner, repo string) (*github.RepositoryTag, error) {
 	tags, err := ListTags(ctx, client, owner, repo)
 	if err != nil {
@@ -129,6 +161,9 @@ func CreateRelease(ctx context.Context, client *github.Client, cro *CreateReleas
 		TargetCommitish: &cro.Branch,
 		Draft:           &cro.Draft,
 	}
+	if cro.Commit != "" {
+		rr.TargetCommitish = &cro.Commit
+	}
 	if cro.ReleaseNotes != "" {
 		genReleaseNotes := true
 		rr.Body = &cro.ReleaseNotes
@@ -169,6 +204,30 @@ func CreateReleaseIssue(ctx context.Context, client *github.Client, cri *CreateR
 	return issue, nil
 }
 
+// FindOpenIssue returns the open issue with the given title, or nil if
+// there's none.
+func FindOpenIssue(ctx context.Context, client *github.Client, owner, repo, title string) (*github.Issue, error) {
+	opts := &github.IssueListByRepoOptions{
+		State:       "open",
+		ListOptions: github.ListOptions{PerPage: 100},
+	}
+	for {
+		issues, res, err := client.Issues.ListByRepo(ctx, owner, repo, opts)
+		if err != nil {
+			return nil, WrapGithubError(err, owner, repo, "")
+		}
+		for _, issue := range issues {
+			if !issue.IsPullRequest() && issue.GetTitle() == title {
+				return issue, nil
+			}
+		}
+		if res.NextPage == 0 {
+			return nil, nil
+		}
+		opts.Page = res.NextPage
+	}
+}
+
 // RetrieveOriginalIssue
 func RetrieveOriginalIssue(ctx context.Context, client *github.Client, owner, repo string, issueID uint) (*github.Issue, error) {
 	issue, _, err := client.Issues.Get(ctx, owner, repo, int(issueID))
@@ -193,6 +252,19 @@ type ChangeLog struct {
 	Note   string
 	Number int
 	URL    string
+	// Author is the GitHub login of the pull request author.
+	Author string
+	// UpgradeNote is set for pull requests labeled kind/upgrade-note.
+	UpgradeNote bool
+	// Experimental is set for pull requests labeled kind/experimental or
+	// kind/feature-gate.
+	Experimental bool
+	// LinkedIssues contains references to the issues closed by the pull
+	// request, e.g. #1234 or rancher/rancher#1234 for other repositories.
+	LinkedIssues []string
+	// Reverts is the number of the pull request of the same repository
+	// reverted by this one, 0 if it isn't a revert.
+	Reverts int
 }
 
 // CreateBackportIssues
@@ -367,68 +439,6 @@ func PerformBackport(ctx context.Context, client *github.Client, pbo *PerformBac
 	return issues, nil
 }
 
-// RetrieveChangeLogContents gets the relevant changes
-// for the given release, formats, and returns them.
-func RetrieveChangeLogContents(ctx context.Context, client *github.Client, owner, repo, prevMilestone, milestone string) ([]ChangeLog, error) {
-	comp, _, err := client.Repositories.CompareCommits(ctx, owner, repo, prevMilestone, milestone, &github.ListOptions{})
-	if err != nil {
-		return nil, err
-	}
-
-	var found []ChangeLog
-	addedPRs := make(map[int]bool)
-	for _, commit := range comp.Commits {
-		sha := commit.GetSHA()
-		if sha == "" {
-			continue
-		}
-
-		prs, _, err := client.PullRequests.ListPullRequestsWithCommit(ctx, owner, repo, sha, &github.PullRequestListOptions{})
-		if err != nil {
-			return nil, err
-		}
-		if len(prs) == 1 {
-			if exists := addedPRs[prs[0].GetNumber()]; exists {
-				continue
-			}
-
-			title := stripBackportTag(strings.TrimSpace(prs[0].GetTitle()))
-			body := prs[0].GetBody()
-
-			var releaseNote string
-			var inNote bool
-			if strings.Contains(body, releaseNoteSection) && !strings.Contains(body, emptyReleaseNote) && !strings.Contains(body, noneReleaseNote) {
-				lines := strings.Split(body, "\n")
-				for _, line := range lines {
-					if strings.Contains(line, releaseNoteSection) {
-						inNote = true
-						continue
-					}
-					if strings.Contains(line, "```") {
-						inNote = false
-					}
-					if inNote && line != "" {
-						line = strings.TrimPrefix(line, "* ")
-						releaseNote += line
-					}
-				}
-				releaseNote = strings.TrimSpace(releaseNote)
-				releaseNote = strings.ReplaceAll(releaseNote, "\r", "\n")
-			}
-
-			found = append(found, ChangeLog{
-				Title:  title,
-				Note:   releaseNote,
-				Number: prs[0].GetNumber(),
-				URL:    prs[0].GetHTMLURL(),
-			})
-			addedPRs[prs[0].GetNumber()] = true
-		}
-	}
-
-	return found, nil
-}
-
 const cutRKE2ReleaseIssue = `**Summary:**
 Task covering patch release work.
 Dev Complete: 1/12 (Typically ~1 week prior to upstream release date)
@@ -1,6 +1,25 @@
 package repository
 
-import "testing"
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v39/github"
+)
 
 func TestStripBackportTag(t *testing.T) {
 	tests := []struct {
@@ -32,3 +51,563 @@ func TestStripBackportTag(t *testing.T) {
 		})
 	}
 }
+
+func TestWrapGithubError(t *testing.T) {
+	response := func(code int) *http.Response {
+		return &http.Response{
+			StatusCode: code,
+			Request:    &http.Request{Method: http.MethodGet, URL: &url.URL{}},
+		}
+	}
+
+	tests := []struct {
+		name string
+		err  error
+		want error
+	}{
+		{
+			name: "not found",
+			err:  &github.ErrorResponse{Response: response(http.StatusNotFound)},
+			want: ErrNotFound,
+		},
+		{
+			name: "forbidden",
+			err:  &github.ErrorResponse{Response: response(http.StatusForbidden)},
+			want: ErrPermission,
+		},
+		{
+			name: "rate limited",
+			err:  &github.RateLimitError{Response: response(http.StatusForbidden)},
+			want: ErrRateLimited,
+		},
+		{
+			name: "server error",
+			err:  &github.ErrorResponse{Response: response(http.StatusInternalServerError)},
+		},
+		{
+			name: "graphql rate limited",
+			err:  graphQLError("RATE_LIMITED"),
+			want: ErrRateLimited,
+		},
+		{
+			name: "graphql not found",
+			err:  graphQLError("NOT_FOUND"),
+			want: ErrNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := WrapGithubError(tt.err, "rancher", "rke2", "v1.27.5+rke2r1")
+			for _, sentinel := range []error{ErrNotFound, ErrPermission, ErrRateLimited} {
+				if got := errors.Is(err, sentinel); got != (sentinel == tt.want) {
+					t.Errorf("errors.Is(%v) = %v, want %v", sentinel, got, sentinel == tt.want)
+				}
+			}
+			if !errors.Is(err, tt.err) {
+				t.Errorf("WrapGithubError() = %v, doesn't wrap %v", err, tt.err)
+			}
+		})
+	}
+
+	if err := WrapGithubError(nil, "rancher", "rke2", ""); err != nil {
+		t.Errorf("WrapGithubError(nil) = %v, want nil", err)
+	}
+}
+
+func graphQLError(typ string) *GraphQLError {
+	var err GraphQLError
+	if jsonErr := json.Unmarshal([]byte(`{"errors": [{"type": "`+typ+`", "message": "`+typ+`"}]}`), &err); jsonErr != nil {
+		panic(jsonErr)
+	}
+
+	return &err
+}
+
+func TestReleaseCache(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		if r.URL.Path == "/repos/rancher/rke2/releases/tags/missing" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`{"tag_name": "v1.27.5+rke2r1"}`))
+	}))
+	defer server.Close()
+
+	client := github.NewClient(nil)
+	client.BaseURL, _ = url.Parse(server.URL + "/")
+
+	now := time.Now()
+	cache := NewReleaseCache(time.Minute)
+	cache.now = func() time.Time { return now }
+
+	ctx := context.Background()
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			release, err := cache.GetReleaseByTag(ctx, client, "rancher", "rke2", "v1.27.5+rke2r1")
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if release.GetTagName() != "v1.27.5+rke2r1" {
+				t.Errorf("GetReleaseByTag() = %v, want v1.27.5+rke2r1", release.GetTagName())
+			}
+		}()
+	}
+	wg.Wait()
+
+	for i := 0; i < 2; i++ {
+		if _, err := cache.GetReleaseByTag(ctx, client, "rancher", "rke2", "missing"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("GetReleaseByTag() error = %v, want ErrNotFound", err)
+		}
+	}
+	if got := atomic.LoadInt32(&calls); got > 2 {
+		t.Errorf("expected at most 2 API calls, got %d", got)
+	}
+
+	now = now.Add(2 * time.Minute)
+	if _, err := cache.GetReleaseByTag(ctx, client, "rancher", "rke2", "v1.27.5+rke2r1"); err != nil {
+		t.Fatal(err)
+	}
+	if got := atomic.LoadInt32(&calls); got != 3 {
+		t.Errorf("expected expired entry to be fetched again, got %d API calls", got)
+	}
+}
+
+func TestOwnersReviewers(t *testing.T) {
+	owners := Owners{
+		"containerd":    {Users: []string{"bob"}, Teams: []string{"runtime"}},
+		"runc":          {Users: []string{"alice", "bob"}, Teams: []string{"runtime"}},
+		"ingress-nginx": {Teams: []string{"networking"}},
+	}
+
+	tests := []struct {
+		name       string
+		components []string
+		want       ComponentOwner
+		mentions   []string
+	}{
+		{
+			name:       "single component",
+			components: []string{"ingress-nginx"},
+			want:       ComponentOwner{Users: []string{}, Teams: []string{"networking"}},
+			mentions:   []string{"@rancher/networking"},
+		},
+		{
+			name:       "deduplicated owners",
+			components: []string{"containerd", "runc"},
+			want:       ComponentOwner{Users: []string{"alice", "bob"}, Teams: []string{"runtime"}},
+			mentions:   []string{"@alice", "@bob", "@rancher/runtime"},
+		},
+		{
+			name:       "unknown component",
+			components: []string{"traefik"},
+			want:       ComponentOwner{Users: []string{}, Teams: []string{}},
+			mentions:   []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := owners.Reviewers(tt.components...)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Reviewers() = %v, want %v", got, tt.want)
+			}
+			if mentions := got.Mentions("rancher"); !reflect.DeepEqual(mentions, tt.mentions) {
+				t.Errorf("Mentions() = %v, want %v", mentions, tt.mentions)
+			}
+		})
+	}
+}
+
+func TestRequestOwnerReviewsClient(t *testing.T) {
+	var requested bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("User-Agent"); got != "reviewer" {
+			t.Errorf("%s %s as %q, want the review client", r.Method, r.URL.Path, got)
+		}
+		if r.URL.Path == "/repos/rancher/rke2/pulls/1/requested_reviewers" {
+			requested = true
+		}
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	newClient := func(userAgent string) *github.Client {
+		client := github.NewClient(nil)
+		client.BaseURL, _ = url.Parse(server.URL + "/")
+		client.UserAgent = userAgent
+		return client
+	}
+
+	SetOwners(Owners{"containerd": {Teams: []string{"runtime"}}})
+	SetReviewClient(newClient("reviewer"))
+	defer SetOwners(nil)
+	defer SetReviewClient(nil)
+
+	if err := RequestOwnerReviews(context.Background(), newClient("author"), "rancher", "rke2", 1, "containerd"); err != nil {
+		t.Fatal(err)
+	}
+	if !requested {
+		t.Error("RequestOwnerReviews() didn't request the reviews")
+	}
+}
+
+func TestLoadOwners(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "owners.json")
+	if err := os.WriteFile(file, []byte(`{"containerd": {"teams": ["k3s"]}, "ingress-nginx": {"users": ["alice"]}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	owners, err := LoadOwners(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Owners{
+		"containerd":    {Teams: []string{"k3s"}},
+		"ingress-nginx": {Users: []string{"alice"}},
+	}
+	if !reflect.DeepEqual(owners, want) {
+		t.Errorf("LoadOwners() = %v, want %v", owners, want)
+	}
+
+	if _, err := LoadOwners(""); err == nil {
+		t.Error("LoadOwners() without a file succeeded, want an error as there is no builtin map")
+	}
+	if got := Owners(nil).Reviewers("containerd"); len(got.Users) != 0 || len(got.Teams) != 0 {
+		t.Errorf("Reviewers() of no ownership map = %v, want none", got)
+	}
+}
+
+func TestIssueReference(t *testing.T) {
+	tests := []struct {
+		name          string
+		nameWithOwner string
+		number        int
+		want          string
+	}{
+		{
+			name:          "same repository",
+			nameWithOwner: "rancher/rke2",
+			number:        1234,
+			want:          "#1234",
+		},
+		{
+			name:          "same repository different case",
+			nameWithOwner: "Rancher/RKE2",
+			number:        1234,
+			want:          "#1234",
+		},
+		{
+			name:          "other repository",
+			nameWithOwner: "rancher/rancher",
+			number:        42,
+			want:          "rancher/rancher#42",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := issueReference("rancher", "rke2", tt.nameWithOwner, tt.number); got != tt.want {
+				t.Errorf("issueReference() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestChangeLogFromCommits(t *testing.T) {
+	pr := func(number int, title, body string, labels []string, issues map[string]int) changeLogPullRequest {
+		p := changeLogPullRequest{
+			Number: number,
+			Title:  title,
+			Body:   body,
+			URL:    "https://github.com/rancher/rke2/pull/" + strconv.Itoa(number),
+		}
+		for _, l := range labels {
+			p.Labels.Nodes = append(p.Labels.Nodes, changeLogLabel{Name: l})
+		}
+		for repo, n := range issues {
+			issue := changeLogIssue{Number: n}
+			issue.Repository.NameWithOwner = repo
+			p.ClosingIssuesReferences.Nodes = append(p.ClosingIssuesReferences.Nodes, issue)
+		}
+		return p
+	}
+	commit := func(prs ...changeLogPullRequest) changeLogCommit {
+		var c changeLogCommit
+		c.AssociatedPullRequests.Nodes = prs
+		return c
+	}
+
+	bump := pr(1, "[release-1.29] Bump containerd", "```release-note\r\nBump containerd to v1.7.13\r\n```", []string{upgradeNoteLabel}, map[string]int{"rancher/rke2": 10})
+	commits := []changeLogCommit{
+		commit(bump),
+		// second commit of the same pull request
+		commit(bump),
+		commit(pr(2, "Fix typo", "```release-note\r\nNONE\r\n```", nil, nil)),
+		commit(pr(5, "Add nftables mode", "", []string{featureGateLabel}, nil)),
+		// ambiguous commits are skipped
+		commit(pr(3, "a", "", nil, nil), pr(4, "b", "", nil, nil)),
+		commit(),
+		commit(pr(6, `Revert "[release-1.29] Bump containerd"`, "Reverts rancher/rke2#1\r\n\r\nBreaks arm64", nil, nil)),
+		// reverts without a reference are matched by title
+		commit(pr(7, `Revert "Fix typo"`, "", nil, nil)),
+		commit(pr(8, `Revert "Revert "Fix typo""`, "", nil, nil)),
+		// reverts of other repositories are ignored
+		commit(pr(9, `Revert "Bump runc"`, "Reverts k3s-io/k3s#1", nil, nil)),
+	}
+
+	got := changeLogFromCommits("rancher", "rke2", commits)
+	want := []ChangeLog{
+		{
+			Title:        "Bump containerd",
+			Note:         "Bump containerd to v1.7.13",
+			Number:       1,
+			URL:          "https://github.com/rancher/rke2/pull/1",
+			UpgradeNote:  true,
+			LinkedIssues: []string{"#10"},
+		},
+		{
+			Title:  "Fix typo",
+			Number: 2,
+			URL:    "https://github.com/rancher/rke2/pull/2",
+		},
+		{
+			Title:        "Add nftables mode",
+			Number:       5,
+			URL:          "https://github.com/rancher/rke2/pull/5",
+			Experimental: true,
+		},
+		{
+			Title:   `Revert "Bump containerd"`,
+			Number:  6,
+			URL:     "https://github.com/rancher/rke2/pull/6",
+			Reverts: 1,
+		},
+		{
+			Title:   `Revert "Fix typo"`,
+			Number:  7,
+			URL:     "https://github.com/rancher/rke2/pull/7",
+			Reverts: 2,
+		},
+		{
+			Title:   `Revert "Revert "Fix typo""`,
+			Number:  8,
+			URL:     "https://github.com/rancher/rke2/pull/8",
+			Reverts: 7,
+		},
+		{
+			Title:  `Revert "Bump runc"`,
+			Number: 9,
+			URL:    "https://github.com/rancher/rke2/pull/9",
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("changeLogFromCommits() = %+v, want %+v", got, want)
+	}
+}
+
+func TestCloneOptions(t *testing.T) {
+	tests := []struct {
+		name       string
+		opts       CloneOptions
+		wantArgs   string
+		wantSparse string
+	}{
+		{
+			name: "full clone",
+		},
+		{
+			name:     "shallow blobless clone",
+			opts:     CloneOptions{Depth: 1, Filter: "blob:none"},
+			wantArgs: "--depth 1 --filter=blob:none",
+		},
+		{
+			name:       "sparse checkout",
+			opts:       CloneOptions{Sparse: []string{"scripts", "charts"}},
+			wantArgs:   "--sparse",
+			wantSparse: `git sparse-checkout set "scripts" "charts"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.opts.Args(); got != tt.wantArgs {
+				t.Errorf("Args() = %q, want %q", got, tt.wantArgs)
+			}
+			if got := tt.opts.SparseCheckout(); got != tt.wantSparse {
+				t.Errorf("SparseCheckout() = %q, want %q", got, tt.wantSparse)
+			}
+		})
+	}
+}
+
+func TestParsePullRequestList(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    []int
+		wantErr bool
+	}{
+		{
+			name:  "json numbers",
+			input: "[5432, 5433]",
+			want:  []int{5432, 5433},
+		},
+		{
+			name:  "json objects",
+			input: `[{"number": 5432, "title": "Bump containerd"}, {"number": 5433}]`,
+			want:  []int{5432, 5433},
+		},
+		{
+			name:  "csv with header",
+			input: "pr,title\n#5432,Bump containerd\nhttps://github.com/rancher/rke2/pull/5433,Bump runc\n\n5434\n",
+			want:  []int{5432, 5433, 5434},
+		},
+		{
+			name:    "invalid csv entry",
+			input:   "5432\nbump\n",
+			wantErr: true,
+		},
+		{
+			name:    "invalid json entry",
+			input:   `["5432"]`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParsePullRequestList(strings.NewReader(tt.input))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParsePullRequestList() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParsePullRequestList() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetrieveChangeLogResume(t *testing.T) {
+	const prs = 60
+	var failed atomic.Bool
+	prRegex := regexp.MustCompile(`(pr\d+): pullRequest\(number: (\d+)\)`)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req graphQLRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Error(err)
+			return
+		}
+
+		if strings.Contains(req.Query, "compare(headRef: $head)") {
+			nodes := make([]string, 0, prs+1)
+			for i := 1; i <= prs; i++ {
+				nodes = append(nodes, `{"associatedPullRequests": {"nodes": [{"number": `+strconv.Itoa(i)+`}]}}`)
+			}
+			// ambiguous commits are skipped.
+			nodes = append(nodes, `{"associatedPullRequests": {"nodes": [{"number": 1}, {"number": 2}]}}`)
+			w.Write([]byte(`{"data": {"repository": {"ref": {"compare": {"commits": {"pageInfo": {"hasNextPage": false}, "nodes": [` + strings.Join(nodes, ",") + `]}}}}}}`))
+			return
+		}
+
+		// the second batch is rate limited once, which the GraphQL API
+		// reports in the errors of the response.
+		if strings.Contains(req.Query, "pullRequest(number: 51)") && !failed.Swap(true) {
+			w.Write([]byte(`{"errors": [{"type": "RATE_LIMITED", "message": "API rate limit exceeded"}]}`))
+			return
+		}
+		var fields []string
+		for _, m := range prRegex.FindAllStringSubmatch(req.Query, -1) {
+			fields = append(fields, `"`+m[1]+`": {"number": `+m[2]+`, "title": "PR `+m[2]+`"}`)
+		}
+		w.Write([]byte(`{"data": {"repository": {` + strings.Join(fields, ",") + `}}}`))
+	}))
+	defer server.Close()
+
+	client := github.NewClient(nil)
+	client.BaseURL, _ = url.Parse(server.URL + "/")
+
+	var progress []int
+	opts := ChangeLogOptions{
+		Workers:    1,
+		Checkpoint: filepath.Join(t.TempDir(), "checkpoint.json"),
+		Progress: func(done, total int) {
+			if total != prs {
+				t.Errorf("total = %d, want %d", total, prs)
+			}
+			progress = append(progress, done)
+		},
+	}
+
+	if _, err := RetrieveChangeLog(context.Background(), client, "rancher", "rancher", "v2.9.0", "v2.9.1", opts); !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("RetrieveChangeLog() error = %v, want it rate limited", err)
+	}
+	cp, err := loadCheckpoint(opts.Checkpoint, compareKey("rancher", "rancher", "v2.9.0", "v2.9.1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cp.PullRequests) != 50 {
+		t.Fatalf("checkpoint has %d pull requests, want the 50 of the first batch", len(cp.PullRequests))
+	}
+
+	changes, err := RetrieveChangeLog(context.Background(), client, "rancher", "rancher", "v2.9.0", "v2.9.1", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != prs || changes[0].Title != "PR 1" || changes[prs-1].Title != "PR 60" {
+		t.Errorf("got %d changes, want the %d pull requests in order", len(changes), prs)
+	}
+	if !reflect.DeepEqual(progress, []int{50, 60}) {
+		t.Errorf("progress = %v, want [50 60]", progress)
+	}
+	if _, err := os.Stat(opts.Checkpoint); !os.IsNotExist(err) {
+		t.Errorf("expected the checkpoint to be removed, got %v", err)
+	}
+
+	if _, err := loadCheckpoint(opts.Checkpoint, compareKey("rancher", "rancher", "v2.9.1", "v2.9.2")); err != nil {
+		t.Errorf("expected a missing checkpoint to be empty, got %v", err)
+	}
+}
+
+func TestCreateReleaseTarget(t *testing.T) {
+	tests := []struct {
+		name string
+		opts CreateReleaseOpts
+		want string
+	}{
+		{
+			name: "branch",
+			opts: CreateReleaseOpts{Owner: "rancher", Repo: "rke2", Tag: "v1.29.2+rke2r1", Branch: "release-1.29"},
+			want: "release-1.29",
+		},
+		{
+			name: "commit",
+			opts: CreateReleaseOpts{Owner: "rancher", Repo: "rke2", Tag: "v1.29.2+rke2r1", Branch: "release-1.29", Commit: "abc123"},
+			want: "abc123",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var release github.RepositoryRelease
+				if err := json.NewDecoder(r.Body).Decode(&release); err != nil {
+					t.Error(err)
+				}
+				if got := release.GetTargetCommitish(); got != tt.want {
+					t.Errorf("target_commitish = %q, want %q", got, tt.want)
+				}
+				w.Write([]byte(`{"tag_name": "v1.29.2+rke2r1"}`))
+			}))
+			defer server.Close()
+
+			client := github.NewClient(nil)
+			client.BaseURL, _ = url.Parse(server.URL + "/")
+
+			if _, err := CreateRelease(context.Background(), client, &tt.opts); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
@@ -0,0 +1,188 @@
+package selfupdate
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/google/go-github/v39/github"
+	"github.com/rancher/ecm-distro-tools/release/signature"
+	"github.com/rancher/ecm-distro-tools/repository"
+	"golang.org/x/mod/semver"
+)
+
+const (
+	owner = "rancher"
+	repo  = "ecm-distro-tools"
+)
+
+// executablePath returns the path of the running executable, which Update
+// replaces.
+var executablePath = os.Executable
+
+// Update checks the latest ecm-distro-tools release and, if it's newer than
+// the current version, downloads the binary for the running platform,
+// verifies it against the published sha256 checksums, which must be signed
+// by a key of the given allow list, and replaces the running executable
+// with it. It returns the latest version, and whether the binary was
+// updated.
+func Update(ctx context.Context, client *github.Client, binary, current string, allow *signature.AllowList, dryRun bool) (string, bool, error) {
+	if allow == nil {
+		return "", false, errors.New("an allow list of the keys signing the releases is required")
+	}
+
+	release, _, err := client.Repositories.GetLatestRelease(ctx, owner, repo)
+	if err != nil {
+		return "", false, repository.WrapGithubError(err, owner, repo, "latest")
+	}
+	latest := release.GetTagName()
+
+	if !Newer(latest, current) {
+		return latest, false, nil
+	}
+
+	assetName := binary + "-" + runtime.GOOS + "-" + runtime.GOARCH
+	checksumsName := "sha256sums-" + binary + ".txt"
+	signatureName := checksumsName + ".asc"
+
+	var asset, checksums, sig *github.ReleaseAsset
+	for _, a := range release.Assets {
+		switch a.GetName() {
+		case assetName:
+			asset = a
+		case checksumsName:
+			checksums = a
+		case signatureName:
+			sig = a
+		}
+	}
+	if asset == nil {
+		return latest, false, errors.New("release " + latest + " doesn't contain " + assetName)
+	}
+	if checksums == nil {
+		return latest, false, errors.New("release " + latest + " doesn't contain " + checksumsName)
+	}
+	if sig == nil {
+		return latest, false, errors.New("release " + latest + " doesn't contain " + signatureName)
+	}
+
+	if dryRun {
+		return latest, false, nil
+	}
+
+	sums, err := download(ctx, client, checksums.GetID())
+	if err != nil {
+		return latest, false, err
+	}
+	armored, err := download(ctx, client, sig.GetID())
+	if err != nil {
+		return latest, false, err
+	}
+	if _, err := allow.Verify(string(armored), string(sums)); err != nil {
+		return latest, false, errors.New(checksumsName + ": " + err.Error())
+	}
+	expected, err := checksum(sums, assetName)
+	if err != nil {
+		return latest, false, err
+	}
+
+	b, err := download(ctx, client, asset.GetID())
+	if err != nil {
+		return latest, false, err
+	}
+	if err := verify(b, expected); err != nil {
+		return latest, false, errors.New(assetName + ": " + err.Error())
+	}
+
+	executable, err := executablePath()
+	if err != nil {
+		return latest, false, err
+	}
+	if executable, err = filepath.EvalSymlinks(executable); err != nil {
+		return latest, false, err
+	}
+
+	if err := replace(executable, b); err != nil {
+		return latest, false, err
+	}
+
+	return latest, true, nil
+}
+
+// Newer returns true if the latest version is newer than the current one,
+// or if the current one isn't a semantic version, e.g. a dev build.
+func Newer(latest, current string) bool {
+	return !semver.IsValid(current) || semver.Compare(latest, current) > 0
+}
+
+// checksum returns the checksum of the given file from the contents of a
+// sha256sum output, where file names may include a directory.
+func checksum(sums []byte, name string) (string, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(sums))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 {
+			continue
+		}
+		if filepath.Base(strings.TrimPrefix(fields[1], "*")) == name {
+			return fields[0], nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+
+	return "", errors.New("checksum not found for " + name)
+}
+
+// verify checks the sha256 checksum of the given content.
+func verify(b []byte, expected string) error {
+	sum := sha256.Sum256(b)
+	if got := hex.EncodeToString(sum[:]); got != expected {
+		return errors.New("checksum mismatch: expected " + expected + ", got " + got)
+	}
+
+	return nil
+}
+
+// replace atomically replaces the file at path with the given content,
+// writing it to a temporary file in the same directory first.
+func replace(path string, b []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+"-update-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0755); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+func download(ctx context.Context, client *github.Client, id int64) ([]byte, error) {
+	rc, _, err := client.Repositories.DownloadReleaseAsset(ctx, owner, repo, id, http.DefaultClient)
+	if err != nil {
+		return nil, repository.WrapGithubError(err, owner, repo, "latest")
+	}
+	defer rc.Close()
+
+	return io.ReadAll(rc)
+}
@@ -0,0 +1,261 @@
+package selfupdate
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	"github.com/google/go-github/v39/github"
+	"github.com/rancher/ecm-distro-tools/release/signature"
+)
+
+func TestChecksum(t *testing.T) {
+	sums := []byte("1111  bin/release-linux-amd64\n2222  bin/release-darwin-arm64\n3333 *release-linux-arm64\n")
+
+	tests := []struct {
+		name    string
+		want    string
+		wantErr bool
+	}{
+		{name: "release-linux-amd64", want: "1111"},
+		{name: "release-darwin-arm64", want: "2222"},
+		{name: "release-linux-arm64", want: "3333"},
+		{name: "release-windows-amd64", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := checksum(sums, tt.name)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("checksum() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("checksum() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerifyAndReplace(t *testing.T) {
+	b := []byte("release")
+	// sha256 of "release"
+	const sum = "a4d451ec23463726f72c43d64c710968f6b602cd653b4de8adee1b556240a829"
+	if err := verify(b, sum); err != nil {
+		t.Error(err)
+	}
+	if err := verify([]byte("tampered"), sum); err == nil {
+		t.Error("verify() expected checksum mismatch")
+	}
+
+	path := filepath.Join(t.TempDir(), "release")
+	if err := os.WriteFile(path, []byte("old"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := replace(path, b); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "release" {
+		t.Errorf("replace() wrote %q, want %q", got, "release")
+	}
+}
+
+func TestNewer(t *testing.T) {
+	tests := []struct {
+		latest  string
+		current string
+		want    bool
+	}{
+		{latest: "v0.40.0", current: "v0.39.1", want: true},
+		{latest: "v0.40.0", current: "v0.40.0", want: false},
+		{latest: "v0.39.1", current: "v0.40.0", want: false},
+		{latest: "v0.40.0", current: "v0.40.0-rc1", want: true},
+		{latest: "v0.40.0", current: "dev", want: true},
+	}
+	for _, tt := range tests {
+		if got := Newer(tt.latest, tt.current); got != tt.want {
+			t.Errorf("Newer(%q, %q) = %v, want %v", tt.latest, tt.current, got, tt.want)
+		}
+	}
+}
+
+func TestUpdateSignature(t *testing.T) {
+	allowed, err := openpgp.NewEntity("ECM Distro Tools", "", "ecm@example.com", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var keyring bytes.Buffer
+	w, err := armor.Encode(&keyring, openpgp.PublicKeyType, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := allowed.Serialize(w); err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+	keyringPath := filepath.Join(t.TempDir(), "keyring.asc")
+	if err := os.WriteFile(keyringPath, keyring.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	allow, err := signature.LoadAllowList(keyringPath, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	unknown, err := openpgp.NewEntity("Someone", "", "someone@example.com", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assetName := "release-" + runtime.GOOS + "-" + runtime.GOARCH
+	sums := "a4d451ec23463726f72c43d64c710968f6b602cd653b4de8adee1b556240a829  " + assetName + "\n"
+	var sig bytes.Buffer
+	if err := openpgp.ArmoredDetachSign(&sig, unknown, strings.NewReader(sums), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		assets  map[string]string
+		wantErr string
+	}{
+		{
+			name:    "unsigned",
+			assets:  map[string]string{assetName: "release", "sha256sums-release.txt": sums},
+			wantErr: "doesn't contain sha256sums-release.txt.asc",
+		},
+		{
+			name:    "signed by an unknown key",
+			assets:  map[string]string{assetName: "release", "sha256sums-release.txt": sums, "sha256sums-release.txt.asc": sig.String()},
+			wantErr: "not made by an allowed key",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := releaseClient(t, tt.assets)
+
+			_, updated, err := Update(context.Background(), client, "release", "v0.39.1", allow, false)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Update() error = %v, want %q", err, tt.wantErr)
+			}
+			if updated {
+				t.Error("Update() updated the binary")
+			}
+		})
+	}
+}
+
+// TestUpdateRelease updates from the assets laid out as the release workflow
+// publishes them: the binaries and tarballs of every platform, and the
+// checksums of each binary, generated in bin, along with their signature.
+func TestUpdateRelease(t *testing.T) {
+	key, err := openpgp.NewEntity("ECM Distro Tools", "", "ecm@example.com", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var keyring bytes.Buffer
+	w, err := armor.Encode(&keyring, openpgp.PublicKeyType, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := key.Serialize(w); err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+	keyringPath := filepath.Join(t.TempDir(), "ecm-distro-tools.asc")
+	if err := os.WriteFile(keyringPath, keyring.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	allow, err := signature.LoadAllowList(keyringPath, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assets := map[string]string{"ecm-distro-tools.asc": keyring.String()}
+	for _, binary := range []string{"release", "backport"} {
+		var sums strings.Builder
+		for _, platform := range []string{"linux-amd64", "linux-arm64", "darwin-amd64", "darwin-arm64", runtime.GOOS + "-" + runtime.GOARCH} {
+			name := binary + "-" + platform
+			if _, ok := assets[name]; ok {
+				continue
+			}
+			assets[name] = name + " binary"
+			sum := sha256.Sum256([]byte(assets[name]))
+			sums.WriteString(hex.EncodeToString(sum[:]) + "  bin/" + name + "\n")
+			assets["ecm-distro-tools."+platform+".tar.gz"] = "tarball"
+		}
+		var sig bytes.Buffer
+		if err := openpgp.ArmoredDetachSign(&sig, key, strings.NewReader(sums.String()), nil); err != nil {
+			t.Fatal(err)
+		}
+		assets["sha256sums-"+binary+".txt"] = sums.String()
+		assets["sha256sums-"+binary+".txt.asc"] = sig.String()
+	}
+
+	executable := filepath.Join(t.TempDir(), "release")
+	if err := os.WriteFile(executable, []byte("v0.39.1"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	executablePath = func() (string, error) { return executable, nil }
+	defer func() { executablePath = os.Executable }()
+
+	latest, updated, err := Update(context.Background(), releaseClient(t, assets), "release", "v0.39.1", allow, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if latest != "v0.40.0" || !updated {
+		t.Errorf("Update() = %s, %t, want v0.40.0, true", latest, updated)
+	}
+	want := "release-" + runtime.GOOS + "-" + runtime.GOARCH + " binary"
+	if got, err := os.ReadFile(executable); err != nil || string(got) != want {
+		t.Errorf("executable = %q, %v, want %q", got, err, want)
+	}
+}
+
+// releaseClient returns a client of a server publishing a v0.40.0 release
+// with the given assets, by name.
+func releaseClient(t *testing.T, contents map[string]string) *github.Client {
+	assets := make([]*github.ReleaseAsset, 0, len(contents))
+	byID := make([]string, 0, len(contents))
+	for name, content := range contents {
+		id, name := int64(len(assets)), name
+		assets = append(assets, &github.ReleaseAsset{ID: &id, Name: &name})
+		byID = append(byID, content)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/rancher/ecm-distro-tools/releases/latest", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(github.RepositoryRelease{TagName: github.String("v0.40.0"), Assets: assets})
+	})
+	mux.HandleFunc("/repos/rancher/ecm-distro-tools/releases/assets/", func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/repos/rancher/ecm-distro-tools/releases/assets/"))
+		if err != nil || id >= len(byID) {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Write([]byte(byID[id]))
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	client := github.NewClient(nil)
+	client.BaseURL, _ = url.Parse(server.URL + "/")
+
+	return client
+}
@@ -0,0 +1,234 @@
+package version
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+const (
+	// K3s is the product name used in K3s build metadata, e.g. v1.27.5+k3s1.
+	K3s = "k3s"
+	// RKE2 is the product name used in RKE2 build metadata, e.g. v1.27.5+rke2r1.
+	RKE2 = "rke2"
+)
+
+var versionRegex = regexp.MustCompile(`^v(\d+)\.(\d+)\.(\d+)(?:-rc(\d+))?(?:\+(k3s|rke2r)(\d+))?$`)
+
+// Version represents a K3s or RKE2 release version composed of the
+// Kubernetes version, an optional release candidate number, the product
+// suffix and the product revision, e.g. v1.27.5-rc1+rke2r1.
+type Version struct {
+	Major    int
+	Minor    int
+	Patch    int
+	RC       int
+	Product  string
+	Revision int
+}
+
+// Parse parses the given tag or milestone into a Version. Tags with no
+// product metadata, e.g. v1.27.5, are accepted and have an empty Product.
+func Parse(v string) (*Version, error) {
+	m := versionRegex.FindStringSubmatch(v)
+	if m == nil {
+		return nil, errors.New("invalid version: " + v)
+	}
+
+	var ver Version
+	ver.Major, _ = strconv.Atoi(m[1])
+	ver.Minor, _ = strconv.Atoi(m[2])
+	ver.Patch, _ = strconv.Atoi(m[3])
+
+	if m[4] != "" {
+		ver.RC, _ = strconv.Atoi(m[4])
+		if ver.RC == 0 {
+			return nil, errors.New("invalid rc number in version: " + v)
+		}
+	}
+
+	if m[5] != "" {
+		ver.Product = K3s
+		if m[5] == "rke2r" {
+			ver.Product = RKE2
+		}
+		ver.Revision, _ = strconv.Atoi(m[6])
+	}
+
+	return &ver, nil
+}
+
+// MustParse is like Parse but panics if the version can't be parsed.
+func MustParse(v string) *Version {
+	ver, err := Parse(v)
+	if err != nil {
+		panic(err)
+	}
+
+	return ver
+}
+
+// String returns the full tag representation of the version.
+func (v *Version) String() string {
+	s := v.K8sVersion()
+	if v.RC > 0 {
+		s += "-rc" + strconv.Itoa(v.RC)
+	}
+	if v.Product != "" {
+		s += "+" + v.Suffix()
+	}
+
+	return s
+}
+
+// K8sVersion returns the Kubernetes part of the version, e.g. v1.27.5.
+func (v *Version) K8sVersion() string {
+	return fmt.Sprintf("v%d.%d.%d", v.Major, v.Minor, v.Patch)
+}
+
+// MajorMinor returns the release line of the version, e.g. v1.27.
+func (v *Version) MajorMinor() string {
+	return fmt.Sprintf("v%d.%d", v.Major, v.Minor)
+}
+
+// Suffix returns the product suffix with its revision, e.g. rke2r1 or k3s1.
+func (v *Version) Suffix() string {
+	switch v.Product {
+	case K3s:
+		return K3s + strconv.Itoa(v.Revision)
+	case RKE2:
+		return RKE2 + "r" + strconv.Itoa(v.Revision)
+	}
+
+	return ""
+}
+
+// IsRC returns true if the version is a release candidate.
+func (v *Version) IsRC() bool {
+	return v.RC > 0
+}
+
+// GA returns a copy of the version without the release candidate number.
+func (v *Version) GA() *Version {
+	ga := *v
+	ga.RC = 0
+
+	return &ga
+}
+
+// NextRC returns a copy of the version with the release candidate number
+// incremented. A GA version returns its first release candidate.
+func (v *Version) NextRC() *Version {
+	next := *v
+	next.RC++
+
+	return &next
+}
+
+// NextRevision returns a copy of the version with the product revision
+// incremented and the release candidate number reset.
+func (v *Version) NextRevision() *Version {
+	next := *v
+	next.RC = 0
+	next.Revision++
+
+	return &next
+}
+
+// Compare returns an integer comparing two versions. The result is 0 if
+// a == b, -1 if a < b and +1 if a > b. The Kubernetes version is compared
+// first, then the product revision and finally the release candidate
+// number, where a GA release is greater than any of its candidates.
+func Compare(a, b *Version) int {
+	for _, pair := range [][2]int{
+		{a.Major, b.Major},
+		{a.Minor, b.Minor},
+		{a.Patch, b.Patch},
+		{a.Revision, b.Revision},
+	} {
+		if c := compareInt(pair[0], pair[1]); c != 0 {
+			return c
+		}
+	}
+
+	switch {
+	case a.RC == b.RC:
+		return 0
+	case a.RC == 0:
+		return 1
+	case b.RC == 0:
+		return -1
+	}
+
+	return compareInt(a.RC, b.RC)
+}
+
+func compareInt(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+
+	return 0
+}
+
+// Next inspects the given tags and returns the next tag for the product in
+// the release line, e.g. v1.27. Tags that can't be parsed or that belong to
+// another product or line are ignored.
+//
+// If the latest tag is a release candidate the next candidate is returned,
+// or its GA version when rc is false. If the latest tag is a GA release, the
+// next patch with revision 1 is returned, or the next revision of the same
+// patch when revision is true. A line with no tags starts at patch 0.
+func Next(tags []string, product, line string, rc, revision bool) (*Version, error) {
+	if product != K3s && product != RKE2 {
+		return nil, errors.New("invalid product: " + product)
+	}
+
+	lineVersion, err := Parse(line + ".0")
+	if err != nil {
+		return nil, errors.New("invalid release line: " + line)
+	}
+
+	var latest *Version
+	for _, tag := range tags {
+		v, err := Parse(tag)
+		if err != nil {
+			continue
+		}
+		if v.Product != product || v.MajorMinor() != lineVersion.MajorMinor() {
+			continue
+		}
+		if latest == nil || Compare(v, latest) > 0 {
+			latest = v
+		}
+	}
+
+	var next *Version
+	switch {
+	case latest == nil:
+		next = lineVersion
+		next.Product = product
+		next.Revision = 1
+	case latest.IsRC():
+		if rc {
+			return latest.NextRC(), nil
+		}
+		return latest.GA(), nil
+	case revision:
+		next = latest.NextRevision()
+	default:
+		next = latest.GA()
+		next.Patch++
+		next.Revision = 1
+	}
+
+	if rc {
+		next = next.NextRC()
+	}
+
+	return next, nil
+}
@@ -0,0 +1,208 @@
+package version
+
+import "testing"
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		version string
+		want    Version
+		wantErr bool
+	}{
+		{
+			version: "v1.27.5+rke2r1",
+			want:    Version{Major: 1, Minor: 27, Patch: 5, Product: RKE2, Revision: 1},
+		},
+		{
+			version: "v1.28.2-rc3+k3s2",
+			want:    Version{Major: 1, Minor: 28, Patch: 2, RC: 3, Product: K3s, Revision: 2},
+		},
+		{
+			version: "v1.29.0",
+			want:    Version{Major: 1, Minor: 29, Patch: 0},
+		},
+		{
+			version: "v1.27.5-rc0+rke2r1",
+			wantErr: true,
+		},
+		{
+			version: "1.27.5+rke2r1",
+			wantErr: true,
+		},
+		{
+			version: "v1.27+rke2r1",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			got, err := Parse(tt.version)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if *got != tt.want {
+				t.Errorf("Parse() = %+v, want %+v", *got, tt.want)
+			}
+			if got.String() != tt.version {
+				t.Errorf("String() = %v, want %v", got.String(), tt.version)
+			}
+		})
+	}
+}
+
+func TestNextRCAndRevision(t *testing.T) {
+	tests := []struct {
+		version      string
+		nextRC       string
+		nextRevision string
+	}{
+		{
+			version:      "v1.27.5+rke2r1",
+			nextRC:       "v1.27.5-rc1+rke2r1",
+			nextRevision: "v1.27.5+rke2r2",
+		},
+		{
+			version:      "v1.28.2-rc3+k3s1",
+			nextRC:       "v1.28.2-rc4+k3s1",
+			nextRevision: "v1.28.2+k3s2",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			v := MustParse(tt.version)
+			if got := v.NextRC().String(); got != tt.nextRC {
+				t.Errorf("NextRC() = %v, want %v", got, tt.nextRC)
+			}
+			if got := v.NextRevision().String(); got != tt.nextRevision {
+				t.Errorf("NextRevision() = %v, want %v", got, tt.nextRevision)
+			}
+		})
+	}
+}
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		a    string
+		b    string
+		want int
+	}{
+		{
+			a:    "v1.27.5+rke2r1",
+			b:    "v1.27.5+rke2r1",
+			want: 0,
+		},
+		{
+			a:    "v1.27.5-rc1+rke2r1",
+			b:    "v1.27.5+rke2r1",
+			want: -1,
+		},
+		{
+			a:    "v1.27.5-rc2+rke2r1",
+			b:    "v1.27.5-rc1+rke2r1",
+			want: 1,
+		},
+		{
+			a:    "v1.27.5+rke2r2",
+			b:    "v1.27.5-rc1+rke2r3",
+			want: -1,
+		},
+		{
+			a:    "v1.28.0+k3s1",
+			b:    "v1.27.10+k3s1",
+			want: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.a+"_"+tt.b, func(t *testing.T) {
+			if got := Compare(MustParse(tt.a), MustParse(tt.b)); got != tt.want {
+				t.Errorf("Compare() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNext(t *testing.T) {
+	tags := []string{
+		"v1.27.5-rc1+rke2r1",
+		"v1.27.5+rke2r1",
+		"v1.27.6-rc1+rke2r1",
+		"v1.27.6-rc2+rke2r1",
+		"v1.28.1+rke2r1",
+		"v1.28.1+rke2r2",
+		"v1.28.1+k3s1",
+		"not-a-version",
+	}
+
+	tests := []struct {
+		name     string
+		product  string
+		line     string
+		rc       bool
+		revision bool
+		want     string
+		wantErr  bool
+	}{
+		{
+			name:    "next rc of an rc",
+			product: RKE2,
+			line:    "v1.27",
+			rc:      true,
+			want:    "v1.27.6-rc3+rke2r1",
+		},
+		{
+			name:    "ga of an rc",
+			product: RKE2,
+			line:    "v1.27",
+			want:    "v1.27.6+rke2r1",
+		},
+		{
+			name:    "next patch rc",
+			product: RKE2,
+			line:    "v1.28",
+			rc:      true,
+			want:    "v1.28.2-rc1+rke2r1",
+		},
+		{
+			name:     "next revision",
+			product:  RKE2,
+			line:     "v1.28",
+			revision: true,
+			want:     "v1.28.1+rke2r3",
+		},
+		{
+			name:    "other product",
+			product: K3s,
+			line:    "v1.28",
+			want:    "v1.28.2+k3s1",
+		},
+		{
+			name:    "new line",
+			product: K3s,
+			line:    "v1.29",
+			rc:      true,
+			want:    "v1.29.0-rc1+k3s1",
+		},
+		{
+			name:    "invalid line",
+			product: K3s,
+			line:    "1.29",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Next(tags, tt.product, tt.line, tt.rc, tt.revision)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Next() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got.String() != tt.want {
+				t.Errorf("Next() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}